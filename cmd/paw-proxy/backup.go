@@ -0,0 +1,300 @@
+package main
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	"github.com/alexcatdad/paw-proxy/internal/setup"
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+const importUsage = "Usage: paw-proxy import [file]"
+
+// Names of the files in a backup archive.
+const (
+	backupCert   = "ca.crt"
+	backupKey    = "ca.key"
+	backupConfig = "config.json"
+)
+
+// maxBackupEntry bounds each file read from a backup archive.
+const maxBackupEntry = 1 << 20
+
+// backup is the state that moves paw-proxy to another machine: the CA, so
+// browsers there already trust its certificates, and the daemon config.
+// Permanent routes travel in config.json's "routes"; routes registered by
+// up are not exported, and come back when up runs again.
+type backup struct {
+	cert   []byte
+	key    []byte
+	config []byte // nil when there is no config file
+}
+
+// loadBackup reads the state to export. A protected CA key is decrypted:
+// it is bound to this machine and would be useless anywhere else.
+func loadBackup(config *daemon.Config) (backup, error) {
+	var b backup
+	var err error
+	b.cert, err = os.ReadFile(filepath.Join(config.SupportDir, "ca.crt"))
+	if err != nil {
+		return b, err
+	}
+	b.key, err = ssl.ReadKeyPEM(filepath.Join(config.SupportDir, "ca.key"))
+	if err != nil {
+		return b, err
+	}
+	b.config, err = os.ReadFile(config.ConfigPath)
+	if err != nil && !os.IsNotExist(err) {
+		return b, err
+	}
+	return b, nil
+}
+
+// writeBackup writes b to w as a tar archive.
+func writeBackup(w io.Writer, b backup) error {
+	tw := tar.NewWriter(w)
+	now := time.Now()
+	files := []struct {
+		name string
+		data []byte
+	}{{backupCert, b.cert}, {backupKey, b.key}, {backupConfig, b.config}}
+	for _, f := range files {
+		if f.data == nil {
+			continue
+		}
+		// SECURITY: the archive holds the CA key; keep its entries
+		// owner-only when extracted by hand.
+		hdr := &tar.Header{Name: f.name, Mode: 0600, Size: int64(len(f.data)), ModTime: now, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// readBackup reads an archive written by writeBackup. Unknown entries are
+// ignored.
+func readBackup(r io.Reader) (backup, error) {
+	var b backup
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return b, fmt.Errorf("reading archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		var dst *[]byte
+		switch hdr.Name {
+		case backupCert:
+			dst = &b.cert
+		case backupKey:
+			dst = &b.key
+		case backupConfig:
+			dst = &b.config
+		default:
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxBackupEntry+1))
+		if err != nil {
+			return b, fmt.Errorf("reading %s: %w", hdr.Name, err)
+		}
+		if len(data) > maxBackupEntry {
+			return b, fmt.Errorf("%s: larger than %d bytes", hdr.Name, maxBackupEntry)
+		}
+		*dst = data
+	}
+	if b.cert == nil || b.key == nil {
+		return b, fmt.Errorf("not a paw-proxy export: missing %s or %s", backupCert, backupKey)
+	}
+	return b, nil
+}
+
+// validate checks that b holds a CA certificate with its own key, and a
+// config file the daemon would accept for primaryTLD.
+func (b backup) validate(primaryTLD string) (*x509.Certificate, error) {
+	pair, err := tls.X509KeyPair(b.cert, b.key)
+	if err != nil {
+		return nil, fmt.Errorf("CA: %w", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("CA: %w", err)
+	}
+	if !cert.IsCA {
+		return nil, fmt.Errorf("CA: %s is not a CA certificate", cert.Subject.CommonName)
+	}
+	if b.config != nil {
+		var fc daemon.FileConfig
+		if err := json.Unmarshal(b.config, &fc); err != nil {
+			return nil, fmt.Errorf("%s: %w", backupConfig, err)
+		}
+		if err := fc.Validate(primaryTLD); err != nil {
+			return nil, fmt.Errorf("%s: %w", backupConfig, err)
+		}
+	}
+	return cert, nil
+}
+
+// cmdExport writes the CA and config to stdout as a tar archive.
+func cmdExport() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: paw-proxy export > paw-backup.tar")
+		os.Exit(1)
+	}
+	if fi, err := os.Stdout.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintln(os.Stderr, "Error: the export is a tar archive; redirect it to a file")
+		fmt.Fprintln(os.Stderr, "Run: paw-proxy export > paw-backup.tar")
+		os.Exit(1)
+	}
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	b, err := loadBackup(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Run: sudo paw-proxy setup")
+		os.Exit(1)
+	}
+	if err := writeBackup(os.Stdout, b); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if b.config != nil {
+		fmt.Fprintln(os.Stderr, "Exported the CA and config.json")
+	} else {
+		fmt.Fprintln(os.Stderr, "Exported the CA")
+	}
+	fmt.Fprintln(os.Stderr, "The archive holds the CA private key: anyone with it can sign certificates your browsers trust. Keep it private.")
+}
+
+// cmdImport replaces the CA and config with the ones from an export, and
+// trusts the imported CA.
+func cmdImport() {
+	args := os.Args[2:]
+	if len(args) > 1 {
+		fmt.Println(importUsage)
+		os.Exit(1)
+	}
+	if os.Geteuid() != 0 {
+		fmt.Println("Error: import requires sudo to trust the CA")
+		fmt.Println("Run: sudo paw-proxy import paw-backup.tar")
+		os.Exit(1)
+	}
+
+	in := io.Reader(os.Stdin)
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	b, err := readBackup(in)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	cert, err := b.validate(config.TLD)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := importBackup(config, b, cert); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Imported CA %s (expires %s)\n", cert.Subject.CommonName, cert.NotAfter.Format("2006-01-02"))
+	if b.config != nil {
+		fmt.Printf("Imported %s\n", config.ConfigPath)
+	}
+
+	if setup.ServiceInstalled() {
+		if err := setup.RestartDaemon(); err != nil {
+			fmt.Printf("Error: restarting daemon: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Restarted the daemon")
+	} else if _, err := pawclient.New(config.SocketPath).InvalidateCerts(context.Background()); err == nil {
+		fmt.Println("The running daemon now issues certificates from the imported CA")
+	}
+	fmt.Println("Firefox and Chromium profiles pick up the imported CA when you re-run: sudo paw-proxy setup")
+}
+
+// importBackup installs the config first, so the CA domains are computed
+// from it, then the CA. If the CA can't be installed, the old config is
+// put back so the machine isn't left with one without the other.
+func importBackup(config *daemon.Config, b backup, cert *x509.Certificate) (err error) {
+	if b.config != nil {
+		old, readErr := os.ReadFile(config.ConfigPath)
+		if readErr != nil && !os.IsNotExist(readErr) {
+			return readErr
+		}
+		if err := setup.InstallConfig(config.ConfigPath, b.config); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				err = errors.Join(err, restoreConfig(config.ConfigPath, old))
+			}
+		}()
+	}
+	setupCfg, err := newSetupConfig(config)
+	if err != nil {
+		return err
+	}
+	if err := setup.InstallCA(setupCfg, b.cert, b.key); err != nil {
+		return err
+	}
+	// The CA keeps the name constraints it was created with.
+	if missing := ssl.Unpermitted(cert, setupCfg.CADomains); len(missing) > 0 {
+		fmt.Printf("Not covered: .%s (browsers reject these); run: sudo paw-proxy ca rotate\n", strings.Join(missing, ", ."))
+	}
+	return nil
+}
+
+// restoreConfig puts back the config file replaced by an import, or
+// removes it if there was none (old is nil).
+func restoreConfig(path string, old []byte) error {
+	var err error
+	if old == nil {
+		err = os.Remove(path)
+	} else {
+		// Writing over the file keeps its owner.
+		err = os.WriteFile(path, old, 0600)
+	}
+	if err != nil {
+		return fmt.Errorf("restoring %s: %w", path, err)
+	}
+	return nil
+}
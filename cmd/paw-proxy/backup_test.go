@@ -0,0 +1,129 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+)
+
+// testCA generates a CA and returns its certificate and key PEM.
+func testCA(t *testing.T) (cert, key []byte) {
+	t.Helper()
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := ssl.GenerateCA(certPath, keyPath, "test"); err != nil {
+		t.Fatal(err)
+	}
+	cert, _ = os.ReadFile(certPath)
+	key, _ = os.ReadFile(keyPath)
+	return cert, key
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	cert, key := testCA(t)
+	in := backup{cert: cert, key: key, config: []byte(`{"tlds":["localhost"]}`)}
+
+	var buf bytes.Buffer
+	if err := writeBackup(&buf, in); err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		if hdr.Mode != 0600 {
+			t.Errorf("%s mode = %o, want 600", hdr.Name, hdr.Mode)
+		}
+	}
+
+	out, err := readBackup(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.cert, cert) || !bytes.Equal(out.key, key) || string(out.config) != string(in.config) {
+		t.Error("backup changed in the round trip")
+	}
+	ca, err := out.validate("test")
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if !ca.IsCA {
+		t.Error("validate returned a non-CA certificate")
+	}
+
+	// No config file: none is written or read back.
+	buf.Reset()
+	writeBackup(&buf, backup{cert: cert, key: key})
+	if out, err := readBackup(&buf); err != nil || out.config != nil {
+		t.Errorf("readBackup without config = %q, %v", out.config, err)
+	}
+}
+
+func TestBackupValidate(t *testing.T) {
+	cert, key := testCA(t)
+	_, otherKey := testCA(t)
+
+	tests := []struct {
+		name string
+		b    backup
+		want string
+	}{
+		{"key of another CA", backup{cert: cert, key: otherKey}, "CA:"},
+		{"invalid config", backup{cert: cert, key: key, config: []byte(`{"hsts":"off"}`)}, "config.json: hsts"},
+		{"unparseable config", backup{cert: cert, key: key, config: []byte(`{`)}, "config.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.b.validate("test")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("validate() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadBackupRejects(t *testing.T) {
+	cert, _ := testCA(t)
+
+	var buf bytes.Buffer
+	writeBackup(&buf, backup{cert: cert})
+	if _, err := readBackup(&buf); err == nil || !strings.Contains(err.Error(), "not a paw-proxy export") {
+		t.Errorf("archive without a key: %v", err)
+	}
+
+	if _, err := readBackup(strings.NewReader("not a tar file at all")); err == nil {
+		t.Error("garbage accepted")
+	}
+
+	buf.Reset()
+	writeBackup(&buf, backup{cert: cert, key: bytes.Repeat([]byte("k"), maxBackupEntry+1)})
+	if _, err := readBackup(&buf); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("oversized entry: %v", err)
+	}
+}
+
+func TestRestoreConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"imported":true}`), 0600)
+	if err := restoreConfig(path, []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != `{}` {
+		t.Errorf("config = %s, want the old one back", got)
+	}
+
+	// With no config before the import, the imported one is removed.
+	if err := restoreConfig(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("imported config left behind: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	"github.com/alexcatdad/paw-proxy/internal/setup"
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+const caUsage = "Usage: paw-proxy ca rotate|status"
+
+// cmdCA shows the CA's name constraints, or replaces the CA with one
+// constrained to the configured TLDs. CAs from older versions of setup are
+// unconstrained: their key can sign for any domain.
+func cmdCA() {
+	if len(os.Args) != 3 {
+		fmt.Println(caUsage)
+		os.Exit(1)
+	}
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	setupCfg, err := newSetupConfig(config)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	certPath := filepath.Join(config.SupportDir, "ca.crt")
+
+	switch os.Args[2] {
+	case "status":
+		cert, err := loadCertFile(certPath)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			fmt.Println("Run: sudo paw-proxy setup")
+			os.Exit(1)
+		}
+		fmt.Printf("%s: %s, expires %s\n", certPath, cert.Subject.CommonName, cert.NotAfter.Format("2006-01-02"))
+		if len(cert.PermittedDNSDomains) == 0 {
+			fmt.Println("Unconstrained: the CA key can sign for any domain")
+			fmt.Println("Run: sudo paw-proxy ca rotate")
+			return
+		}
+		fmt.Printf("Limited to: .%s\n", strings.Join(cert.PermittedDNSDomains, ", ."))
+		if missing := ssl.Unpermitted(cert, setupCfg.CADomains); len(missing) > 0 {
+			fmt.Printf("Not covered: .%s (browsers reject these); run: sudo paw-proxy ca rotate\n", strings.Join(missing, ", ."))
+			os.Exit(1)
+		}
+	case "rotate":
+		if os.Geteuid() != 0 {
+			fmt.Println("Error: ca rotate requires sudo")
+			fmt.Println("Run: sudo paw-proxy ca rotate")
+			os.Exit(1)
+		}
+		if err := setup.RegenerateCA(setupCfg); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Generated a new CA limited to .%s\n", strings.Join(setupCfg.CADomains, ", ."))
+		if setup.ServiceInstalled() {
+			if err := setup.RestartDaemon(); err != nil {
+				fmt.Printf("Error: restarting daemon: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println("Restarted the daemon")
+		} else if _, err := pawclient.New(config.SocketPath).InvalidateCerts(context.Background()); err == nil {
+			// A daemon started by hand loads the new CA without a restart.
+			fmt.Println("The running daemon now issues certificates from the new CA")
+		}
+		fmt.Println("Firefox and Chromium profiles keep the old CA until you re-run: sudo paw-proxy setup")
+		fmt.Println("Restart your browser, and dev servers given the old CA file, to pick up the new CA.")
+	default:
+		fmt.Printf("Error: unknown action: %s\n", os.Args[2])
+		fmt.Println(caUsage)
+		os.Exit(1)
+	}
+}
+
+// loadCertFile parses the PEM certificate at path.
+func loadCertFile(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s: no PEM certificate", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// loadSharedCA reads the CA passed to setup with --ca-cert and --ca-key, or
+// downloads it from --ca-url and checks it against --ca-sha256. It must be
+// able to issue for domains.
+func loadSharedCA(flags map[string]string, domains []string) (*setup.SharedCA, error) {
+	var ca *setup.SharedCA
+	var err error
+	switch {
+	case flags["--ca-url"] != "":
+		if flags["--ca-cert"] != "" || flags["--ca-key"] != "" {
+			return nil, errors.New("--ca-url can't be combined with --ca-cert or --ca-key")
+		}
+		if flags["--ca-sha256"] == "" {
+			return nil, errors.New("--ca-url requires --ca-sha256 with the file's SHA-256")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		ca, err = setup.FetchSharedCA(ctx, flags["--ca-url"], flags["--ca-sha256"])
+	case flags["--ca-cert"] != "" && flags["--ca-key"] != "":
+		if flags["--ca-sha256"] != "" {
+			return nil, errors.New("--ca-sha256 only applies to --ca-url")
+		}
+		ca, err = setup.LoadSharedCA(flags["--ca-cert"], flags["--ca-key"])
+	default:
+		return nil, errors.New("a shared CA needs --ca-cert and --ca-key, or --ca-url and --ca-sha256")
+	}
+	if err != nil {
+		return nil, err
+	}
+	cert, err := ca.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if missing := ssl.Unpermitted(cert, domains); len(missing) > 0 {
+		return nil, fmt.Errorf("the shared CA can't issue for .%s", strings.Join(missing, ", ."))
+	}
+	if len(cert.PermittedDNSDomains) == 0 {
+		fmt.Println("Warning: the shared CA is unconstrained: anyone with its key can sign for any domain on every machine that trusts it")
+	}
+	return ca, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+)
+
+const caKeyUsage = "Usage: paw-proxy ca-key protect|unprotect|status"
+
+// cmdCAKey encrypts the CA private key at rest with a key bound to this
+// machine, or decrypts it again. The daemon reads either form at startup.
+func cmdCAKey() {
+	if len(os.Args) != 3 {
+		fmt.Println(caKeyUsage)
+		os.Exit(1)
+	}
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	keyPath := filepath.Join(config.SupportDir, "ca.key")
+	protected, err := ssl.KeyProtected(keyPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println("Run: sudo paw-proxy setup")
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "protect":
+		if protected {
+			fmt.Println("CA key is already protected")
+			return
+		}
+		if err := ssl.ProtectKey(keyPath); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Encrypted %s with this machine's key\n", keyPath)
+		fmt.Println("Copies of it (backups, synced folders) can't be used on other machines.")
+		fmt.Println("Before moving the CA to another machine, run: paw-proxy ca-key unprotect")
+	case "unprotect":
+		if !protected {
+			fmt.Println("CA key is not protected")
+			return
+		}
+		if err := ssl.UnprotectKey(keyPath); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Decrypted %s (owner-only plaintext)\n", keyPath)
+	case "status":
+		if protected {
+			fmt.Printf("%s: encrypted with this machine's key\n", keyPath)
+		} else {
+			fmt.Printf("%s: plaintext (owner-only); run: paw-proxy ca-key protect\n", keyPath)
+		}
+	default:
+		fmt.Printf("Error: unknown action: %s\n", os.Args[2])
+		fmt.Println(caKeyUsage)
+		os.Exit(1)
+	}
+}
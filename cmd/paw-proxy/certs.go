@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+const certsUsage = "Usage: paw-proxy certs list [--name pattern] [--json] | paw-proxy certs flush"
+
+// certsOptions are the parsed arguments of `paw-proxy certs`.
+type certsOptions struct {
+	flush bool
+	name  string // glob matched against each certificate name
+	json  bool
+}
+
+func parseCertsArgs(args []string) (certsOptions, error) {
+	var opts certsOptions
+	if len(args) == 0 || (args[0] != "list" && args[0] != "flush") {
+		return opts, fmt.Errorf("unknown action")
+	}
+	opts.flush = args[0] == "flush"
+	args = args[1:]
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case opts.flush:
+			return opts, fmt.Errorf("unknown argument: %s", arg)
+		case arg == "--json":
+			opts.json = true
+		case arg == "--name":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--name requires a pattern")
+			}
+			i++
+			if _, err := path.Match(args[i], ""); err != nil {
+				return opts, fmt.Errorf("--name: invalid pattern %q", args[i])
+			}
+			opts.name = args[i]
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+	return opts, nil
+}
+
+// filterIssued returns the certificates with a name matching pattern.
+func filterIssued(certs []ssl.IssuedCert, pattern string) []ssl.IssuedCert {
+	if pattern == "" {
+		return certs
+	}
+	var out []ssl.IssuedCert
+	for _, c := range certs {
+		if slices.ContainsFunc(c.Names, func(name string) bool {
+			ok, _ := path.Match(pattern, name)
+			return ok
+		}) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// printIssued writes certificates one per line, oldest first.
+func printIssued(w io.Writer, certs []ssl.IssuedCert, now time.Time) {
+	if len(certs) == 0 {
+		fmt.Fprintln(w, "No certificates issued yet")
+		return
+	}
+	for _, c := range certs {
+		state := "valid"
+		if now.After(c.NotAfter) {
+			state = "expired"
+		}
+		fmt.Fprintf(w, "%s  %-8s  %s  until %s (%s)  serial %s\n",
+			c.Time.Local().Format("2006-01-02 15:04"), c.Source, strings.Join(c.Names, ", "),
+			c.NotAfter.Local().Format("2006-01-02"), state, c.Serial)
+	}
+}
+
+// cmdCerts lists the certificates the daemon has issued, from its
+// append-only log, or makes the daemon drop the ones it has cached.
+func cmdCerts() {
+	opts, err := parseCertsArgs(os.Args[2:])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println(certsUsage)
+		os.Exit(1)
+	}
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if opts.flush {
+		n, err := pawclient.New(config.SocketPath).InvalidateCerts(context.Background())
+		if pawclient.IsUnavailable(err) {
+			fmt.Println("Error: daemon not running")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Dropped %d cached certificate(s); they are issued again on the next connection\n", n)
+		return
+	}
+	certs, err := ssl.ReadIssueLog(daemon.IssueLogPath(config.SupportDir))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	certs = filterIssued(certs, opts.name)
+
+	if opts.json {
+		enc := json.NewEncoder(os.Stdout)
+		for _, c := range certs {
+			enc.Encode(c) //nolint:errcheck // stdout
+		}
+		return
+	}
+	printIssued(os.Stdout, certs, time.Now())
+}
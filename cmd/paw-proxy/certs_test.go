@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+)
+
+func TestParseCertsArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    certsOptions
+		wantErr bool
+	}{
+		{args: []string{"list"}, want: certsOptions{}},
+		{args: []string{"list", "--json", "--name", "*.shop.test"}, want: certsOptions{name: "*.shop.test", json: true}},
+		{args: []string{}, wantErr: true},
+		{args: []string{"show"}, wantErr: true},
+		{args: []string{"list", "--name"}, wantErr: true},
+		{args: []string{"list", "--name", "["}, wantErr: true},
+		{args: []string{"list", "--all"}, wantErr: true},
+		{args: []string{"flush"}, want: certsOptions{flush: true}},
+		{args: []string{"flush", "--json"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCertsArgs(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCertsArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseCertsArgs(%q) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestPrintIssued(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	certs := []ssl.IssuedCert{
+		{Time: now.AddDate(-2, 0, 0), Source: ssl.SourceLocalCA, Names: []string{"old.test"}, Serial: "01", NotAfter: now.AddDate(-1, 0, 0)},
+		{Time: now, Source: ssl.SourceLocalCA, Names: []string{"myapp.test"}, Serial: "5F3A", NotAfter: now.AddDate(1, 0, 0)},
+		{Time: now, Source: ssl.SourceACME, Names: []string{"*.dev.example.com"}, Serial: "AB", NotAfter: now.AddDate(0, 3, 0)},
+	}
+
+	var buf bytes.Buffer
+	printIssued(&buf, filterIssued(certs, "*.test"), now)
+	out := buf.String()
+	if strings.Count(out, "\n") != 2 || strings.Contains(out, "dev.example.com") {
+		t.Fatalf("filtered output:\n%s", out)
+	}
+	if !strings.Contains(out, "old.test") || !strings.Contains(out, "(expired)") {
+		t.Errorf("expired certificate not marked:\n%s", out)
+	}
+	if !strings.Contains(out, "myapp.test") || !strings.Contains(out, "serial 5F3A") {
+		t.Errorf("missing myapp.test entry:\n%s", out)
+	}
+
+	buf.Reset()
+	printIssued(&buf, nil, now)
+	if !strings.Contains(buf.String(), "No certificates") {
+		t.Errorf("empty output = %q", buf.String())
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+const dashboardUsage = "Usage: paw-proxy dashboard open [--no-browser]"
+
+func cmdDashboard() {
+	if len(os.Args) < 3 || os.Args[2] != "open" {
+		fmt.Println(dashboardUsage)
+		os.Exit(1)
+	}
+	noBrowser := slices.Contains(os.Args[3:], "--no-browser")
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	pairURL, err := fetchPairURL(pawclient.New(config.SocketPath))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Open this link to sign in to the dashboard (valid once, for 2 minutes):")
+	fmt.Println()
+	fmt.Printf("  %s\n", pairURL)
+	if noBrowser {
+		return
+	}
+	if name, args := browserCommand(runtime.GOOS, pairURL); name != "" {
+		// Best effort: the printed link still works if no browser opens.
+		exec.Command(name, args...).Start()
+	}
+}
+
+// fetchPairURL asks the daemon for a one-time dashboard sign-in URL.
+func fetchPairURL(client *pawclient.Client) (string, error) {
+	pairURL, err := client.PairDashboard(context.Background())
+	if pawclient.IsUnavailable(err) {
+		return "", fmt.Errorf("daemon not running")
+	}
+	if err != nil {
+		return "", fmt.Errorf("pairing failed: %w", err)
+	}
+	return pairURL, nil
+}
+
+// browserCommand returns the command that opens url in the default browser
+// on goos, or an empty name when there isn't one.
+func browserCommand(goos, url string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "open", []string{url}
+	case "linux":
+		return "xdg-open", []string{url}
+	}
+	return "", nil
+}
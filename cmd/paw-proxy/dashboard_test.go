@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+func TestFetchPairURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/dashboard/pair" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"url":"https://_paw.test/pair?code=abc"}`))
+	}))
+	defer srv.Close()
+
+	got, err := fetchPairURL(testClient(srv))
+	if err != nil {
+		t.Fatalf("fetchPairURL() error = %v", err)
+	}
+	if got != "https://_paw.test/pair?code=abc" {
+		t.Errorf("fetchPairURL() = %q", got)
+	}
+}
+
+func TestFetchPairURL_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"too many pending pairing links"}`))
+	}))
+	defer srv.Close()
+
+	if _, err := fetchPairURL(testClient(srv)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestBrowserCommand(t *testing.T) {
+	if name, _ := browserCommand("darwin", "u"); name != "open" {
+		t.Errorf("darwin: got %q", name)
+	}
+	if name, _ := browserCommand("linux", "u"); name != "xdg-open" {
+		t.Errorf("linux: got %q", name)
+	}
+	if name, _ := browserCommand("plan9", "u"); name != "" {
+		t.Errorf("plan9: got %q", name)
+	}
+}
+
+// testClient returns a client that sends http://unix/... requests to srv,
+// like the daemon client does over its socket.
+func testClient(srv *httptest.Server) *pawclient.Client {
+	addr := srv.Listener.Addr().String()
+	return pawclient.NewWithHTTPClient(&http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return net.Dial("tcp", addr)
+		},
+	}})
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// doctorFix is a repair offered for a failed doctor check.
+type doctorFix struct {
+	desc string
+	// needRoot fixes write system files and are skipped without sudo.
+	needRoot bool
+	// confirm, when set, is asked before running a destructive fix.
+	confirm string
+	run     func() error
+}
+
+// doctorOptions holds the parsed flags for `paw-proxy doctor`.
+type doctorOptions struct {
+	fix  bool
+	yes  bool
+	json bool
+}
+
+func parseDoctorArgs(args []string) (doctorOptions, error) {
+	var opts doctorOptions
+	for _, arg := range args {
+		switch arg {
+		case "--fix":
+			opts.fix = true
+		case "--yes", "-y":
+			opts.yes = true
+		case "--json":
+			opts.json = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+	if opts.yes && !opts.fix {
+		return opts, fmt.Errorf("--yes only applies with --fix")
+	}
+	if opts.json && opts.fix {
+		return opts, fmt.Errorf("--json can't be combined with --fix")
+	}
+	return opts, nil
+}
+
+// doctorCheck is the result of one doctor check. ID names the check and
+// stays the same across releases, for scripts reading `doctor --json`;
+// Message and Details are for people.
+type doctorCheck struct {
+	ID      string   `json:"id"`
+	Pass    bool     `json:"pass"`
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"`
+}
+
+// doctorReport collects check results. With w set, each is also printed
+// as it comes in.
+type doctorReport struct {
+	w      io.Writer
+	checks []doctorCheck
+}
+
+// check records the result of check id.
+func (r *doctorReport) check(id string, pass bool, format string, args ...any) {
+	c := doctorCheck{ID: id, Pass: pass, Message: fmt.Sprintf(format, args...)}
+	r.checks = append(r.checks, c)
+	if r.w != nil {
+		mark := "✓"
+		if !pass {
+			mark = "✗"
+		}
+		fmt.Fprintf(r.w, "[%s] %s\n", mark, c.Message)
+	}
+}
+
+// detail adds a line explaining the last check.
+func (r *doctorReport) detail(line string) {
+	last := &r.checks[len(r.checks)-1]
+	last.Details = append(last.Details, line)
+	if r.w != nil {
+		fmt.Fprintf(r.w, "    %s\n", line)
+	}
+}
+
+// writeJSON writes the report for `doctor --json`: whether every check
+// passed, how many failed, and each check in order.
+func (r *doctorReport) writeJSON(w io.Writer) error {
+	failed := 0
+	for _, c := range r.checks {
+		if !c.Pass {
+			failed++
+		}
+	}
+	data, err := json.MarshalIndent(struct {
+		OK     bool          `json:"ok"`
+		Failed int           `json:"failed"`
+		Checks []doctorCheck `json:"checks"`
+	}{failed == 0, failed, r.checks}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// runFixes applies fixes in order and reports each outcome. Prompts are
+// read from in unless assumeYes is set. It returns how many fixes failed.
+func runFixes(w io.Writer, in io.Reader, fixes []doctorFix, isRoot, assumeYes bool) (failed int) {
+	reader := bufio.NewReader(in)
+	for _, f := range fixes {
+		if f.needRoot && !isRoot {
+			fmt.Fprintf(w, "[-] %s: skipped (requires sudo)\n", f.desc)
+			failed++
+			continue
+		}
+		if f.confirm != "" && !assumeYes {
+			fmt.Fprintf(w, "%s [y/N] ", f.confirm)
+			answer, _ := reader.ReadString('\n')
+			if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+				fmt.Fprintf(w, "[-] %s: skipped\n", f.desc)
+				failed++
+				continue
+			}
+		}
+		if err := f.run(); err != nil {
+			fmt.Fprintf(w, "[✗] %s: %v\n", f.desc, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(w, "[✓] %s\n", f.desc)
+	}
+	return failed
+}
+
+// e2eCheck exercises the path a browser takes for name: it resolves name
+// through the DNS server at dnsAddr, completes a TLS handshake with
+// httpsAddr using name as SNI and only roots as trusted CAs, then expects
+// the daemon's "no app" page for the unregistered host. Each stage's
+// failure is reported separately so broken trust or SNI handling is
+// distinguishable from a dead listener.
+func e2eCheck(name, dnsAddr, httpsAddr string, roots *x509.CertPool) error {
+	const timeout = 3 * time.Second
+
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), dns.TypeA)
+	c := &dns.Client{Timeout: timeout}
+	reply, _, err := c.Exchange(m, dnsAddr)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", name, err)
+	}
+	resolved := false
+	for _, rr := range reply.Answer {
+		if a, ok := rr.(*dns.A); ok && a.A.IsLoopback() {
+			resolved = true
+		}
+	}
+	if !resolved {
+		return fmt.Errorf("resolving %s: no loopback A record (rcode %s)", name, dns.RcodeToString[reply.Rcode])
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", httpsAddr, &tls.Config{
+		ServerName: name,
+		RootCAs:    roots,
+	})
+	if err != nil {
+		return fmt.Errorf("TLS handshake with SNI %s: %w", name, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	req, err := http.NewRequest("GET", "https://"+name+"/", nil)
+	if err != nil {
+		return err
+	}
+	req.Close = true
+	if err := req.Write(conn); err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusBadGateway || !strings.Contains(string(body), "No app at") {
+		return fmt.Errorf("unexpected response: HTTP %d, not the paw-proxy error page", resp.StatusCode)
+	}
+	return nil
+}
+
+// hasIPv6Loopback reports whether ::1 can be bound, so the daemon is
+// expected to listen there too.
+func hasIPv6Loopback() bool {
+	l, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
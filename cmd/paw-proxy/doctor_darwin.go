@@ -12,3 +12,9 @@ func doctorCheckDNS() (bool, string) {
 	}
 	return true, "DNS resolver configured (/etc/resolver/test)"
 }
+
+// doctorCheckCapabilities does not apply on macOS: launchd passes the
+// daemon its privileged sockets.
+func doctorCheckCapabilities(binaryPath string) (applies, ok bool, msg string) {
+	return false, true, ""
+}
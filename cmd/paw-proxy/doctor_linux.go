@@ -2,7 +2,14 @@
 
 package main
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/alexcatdad/paw-proxy/internal/setup"
+)
 
 // doctorCheckDNS verifies the systemd-resolved stub zone config exists.
 func doctorCheckDNS() (bool, string) {
@@ -12,3 +19,19 @@ func doctorCheckDNS() (bool, string) {
 	}
 	return true, "systemd-resolved stub zone configured"
 }
+
+// doctorCheckCapabilities verifies the binary still has
+// cap_net_bind_service, which is lost whenever the binary is replaced.
+func doctorCheckCapabilities(binaryPath string) (applies, ok bool, msg string) {
+	if setup.UsesSocketActivation() {
+		return true, true, "Ports bound by systemd socket activation (no capability needed)"
+	}
+	if _, err := exec.LookPath("getcap"); err != nil {
+		return true, false, "getcap not found; cannot check port binding capability"
+	}
+	out, err := exec.Command("getcap", binaryPath).Output()
+	if err != nil || !strings.Contains(string(out), "cap_net_bind_service") {
+		return true, false, fmt.Sprintf("Port binding capability missing on %s", binaryPath)
+	}
+	return true, true, "Port binding capability set"
+}
@@ -6,3 +6,8 @@ package main
 func doctorCheckDNS() (bool, string) {
 	return false, "DNS check not supported on this platform"
 }
+
+// doctorCheckCapabilities is a stub for unsupported platforms.
+func doctorCheckCapabilities(binaryPath string) (applies, ok bool, msg string) {
+	return false, true, ""
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexcatdad/paw-proxy/internal/errorpage"
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+	"github.com/miekg/dns"
+)
+
+func TestParseDoctorArgs(t *testing.T) {
+	opts, err := parseDoctorArgs([]string{"--fix", "-y"})
+	if err != nil {
+		t.Fatalf("parseDoctorArgs: %v", err)
+	}
+	if !opts.fix || !opts.yes {
+		t.Errorf("opts = %+v, want fix and yes", opts)
+	}
+
+	for _, args := range [][]string{{"--yes"}, {"--bogus"}, {"--json", "--fix"}} {
+		if _, err := parseDoctorArgs(args); err == nil {
+			t.Errorf("parseDoctorArgs(%v): expected error", args)
+		}
+	}
+}
+
+func TestDoctorReport(t *testing.T) {
+	var out bytes.Buffer
+	report := &doctorReport{w: &out}
+	report.check("socket", true, "Unix socket exists at %s", "/tmp/paw.sock")
+	report.check("daemon", false, "Daemon degraded")
+	report.detail("port 443 held by nginx (pid 12)")
+
+	want := "[✓] Unix socket exists at /tmp/paw.sock\n[✗] Daemon degraded\n    port 443 held by nginx (pid 12)\n"
+	if out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	if err := report.writeJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		OK     bool          `json:"ok"`
+		Failed int           `json:"failed"`
+		Checks []doctorCheck `json:"checks"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if got.OK || got.Failed != 1 || len(got.Checks) != 2 {
+		t.Fatalf("report = %+v", got)
+	}
+	if c := got.Checks[1]; c.ID != "daemon" || c.Pass || len(c.Details) != 1 {
+		t.Errorf("daemon check = %+v", c)
+	}
+}
+
+func TestRunFixes(t *testing.T) {
+	var ran []string
+	fix := func(desc string, err error) func() error {
+		return func() error {
+			ran = append(ran, desc)
+			return err
+		}
+	}
+	fixes := []doctorFix{
+		{desc: "resolver", needRoot: true, run: fix("resolver", nil)},
+		{desc: "ca", confirm: "Regenerate?", run: fix("ca", nil)},
+		{desc: "service", run: fix("service", errors.New("boom"))},
+		{desc: "restart", run: fix("restart", nil)},
+	}
+
+	tests := []struct {
+		name       string
+		isRoot     bool
+		assumeYes  bool
+		input      string
+		wantRan    []string
+		wantFailed int
+		wantOutput []string
+	}{
+		{
+			name:       "not root, declined",
+			input:      "n\n",
+			wantRan:    []string{"service", "restart"},
+			wantFailed: 3,
+			wantOutput: []string{"[-] resolver: skipped (requires sudo)", "[-] ca: skipped", "[✗] service: boom", "[✓] restart"},
+		},
+		{
+			name:       "root, confirmed",
+			isRoot:     true,
+			input:      "y\n",
+			wantRan:    []string{"resolver", "ca", "service", "restart"},
+			wantFailed: 1,
+			wantOutput: []string{"[✓] resolver", "Regenerate? [y/N]", "[✓] ca"},
+		},
+		{
+			name:       "assume yes skips prompt",
+			isRoot:     true,
+			assumeYes:  true,
+			wantRan:    []string{"resolver", "ca", "service", "restart"},
+			wantFailed: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran = nil
+			var out bytes.Buffer
+			failed := runFixes(&out, strings.NewReader(tt.input), fixes, tt.isRoot, tt.assumeYes)
+			if failed != tt.wantFailed {
+				t.Errorf("failed = %d, want %d", failed, tt.wantFailed)
+			}
+			if strings.Join(ran, ",") != strings.Join(tt.wantRan, ",") {
+				t.Errorf("ran %v, want %v", ran, tt.wantRan)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, out.String())
+				}
+			}
+			if tt.assumeYes && strings.Contains(out.String(), "[y/N]") {
+				t.Errorf("unexpected prompt with assumeYes:\n%s", out.String())
+			}
+		})
+	}
+}
+
+// startFakeDNS answers every A query with addr.
+func startFakeDNS(t *testing.T, addr string) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	srv := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		if addr != "" {
+			m.Answer = append(m.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.ParseIP(addr),
+			})
+		}
+		w.WriteMsg(m)
+	})}
+	go srv.ActivateAndServe()
+	t.Cleanup(func() { srv.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+func TestE2ECheck(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "ca.crt")
+	keyPath := filepath.Join(dir, "ca.key")
+	if err := ssl.GenerateCA(certPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA: %v", err)
+	}
+	ca, err := ssl.LoadCA(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("LoadCA: %v", err)
+	}
+	roots, err := loadCAPool(certPath)
+	if err != nil {
+		t.Fatalf("loadCAPool: %v", err)
+	}
+
+	notFound := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errorpage.NotFound(w, "en", r.Host, "doctor", nil)
+	})
+	paw := httptest.NewUnstartedServer(notFound)
+	paw.TLS = &tls.Config{GetCertificate: ssl.NewCertCache(ca, "test").GetCertificate}
+	paw.StartTLS()
+	defer paw.Close()
+
+	// A different server with its own (untrusted) certificate, as when
+	// something else holds port 443 or the CA was never trusted.
+	impostor := httptest.NewTLSServer(notFound)
+	defer impostor.Close()
+
+	plain := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	plain.TLS = &tls.Config{GetCertificate: ssl.NewCertCache(ca, "test").GetCertificate}
+	plain.StartTLS()
+	defer plain.Close()
+
+	dnsOK := startFakeDNS(t, "127.0.0.1")
+	dnsEmpty := startFakeDNS(t, "")
+
+	tests := []struct {
+		name      string
+		dnsAddr   string
+		httpsAddr string
+		wantErr   string
+	}{
+		{"healthy", dnsOK, paw.Listener.Addr().String(), ""},
+		{"no DNS answer", dnsEmpty, paw.Listener.Addr().String(), "no loopback A record"},
+		{"untrusted certificate", dnsOK, impostor.Listener.Addr().String(), "TLS handshake"},
+		{"not paw-proxy", dnsOK, plain.Listener.Addr().String(), "unexpected response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := e2eCheck("doctor.test", tt.dnsAddr, tt.httpsAddr, roots)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("e2eCheck: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("e2eCheck error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// rotatedLogRetention is how long rotated copies of the daemon log (from
+// logrotate, newsyslog, or a manual move) are kept.
+const rotatedLogRetention = 7 * 24 * time.Hour
+
+// gcFile is a file selected for removal.
+type gcFile struct {
+	path string
+	size int64
+}
+
+// staleRotatedLogs returns rotated copies of the log at logPath
+// (paw-proxy.log.1, paw-proxy.log-20260101.gz, ...) last modified before
+// now minus retention. The live log itself is never selected.
+func staleRotatedLogs(logPath string, now time.Time, retention time.Duration) ([]gcFile, error) {
+	dir, base := filepath.Split(logPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stale []gcFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || name == base {
+			continue
+		}
+		if !strings.HasPrefix(name, base+".") && !strings.HasPrefix(name, base+"-") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if now.Sub(info.ModTime()) > retention {
+			stale = append(stale, gcFile{path: filepath.Join(dir, name), size: info.Size()})
+		}
+	}
+	return stale, nil
+}
+
+// formatBytes renders n using binary units (e.g. "1.5 MiB").
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStaleRotatedLogs(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	old := now.Add(-rotatedLogRetention - time.Hour)
+
+	files := map[string]time.Time{
+		"paw-proxy.log":             old, // live log, never removed
+		"paw-proxy.log.1":           old,
+		"paw-proxy.log-20260101.gz": old,
+		"paw-proxy.log.2":           now, // within retention
+		"other.log.1":               old, // not ours
+	}
+	for name, mtime := range files {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte("0123456789"), 0600)
+		os.Chtimes(path, mtime, mtime)
+	}
+
+	stale, err := staleRotatedLogs(filepath.Join(dir, "paw-proxy.log"), now, rotatedLogRetention)
+	if err != nil {
+		t.Fatalf("staleRotatedLogs: %v", err)
+	}
+	got := map[string]bool{}
+	for _, f := range stale {
+		got[filepath.Base(f.path)] = true
+		if f.size != 10 {
+			t.Errorf("%s: size = %d, want 10", f.path, f.size)
+		}
+	}
+	if len(got) != 2 || !got["paw-proxy.log.1"] || !got["paw-proxy.log-20260101.gz"] {
+		t.Errorf("stale = %v, want paw-proxy.log.1 and paw-proxy.log-20260101.gz", got)
+	}
+
+	if stale, err := staleRotatedLogs(filepath.Join(dir, "missing", "paw-proxy.log"), now, rotatedLogRetention); err != nil || len(stale) != 0 {
+		t.Errorf("missing dir: got %v, %v; want nothing", stale, err)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{5 << 20, "5.0 MiB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
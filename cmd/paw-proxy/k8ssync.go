@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+// Service annotations that opt a Service into k8s-sync without an Ingress.
+const (
+	k8sNameAnnotation = "paw-proxy.dev/name"
+	k8sPortAnnotation = "paw-proxy.dev/port"
+)
+
+// k8sForwardTimeout bounds how long kubectl port-forward may take to
+// report its local port.
+const k8sForwardTimeout = 15 * time.Second
+
+// kubeList is the subset of `kubectl get ingress,service -o json` that
+// k8s-sync reads.
+type kubeList struct {
+	Items []kubeObject `json:"items"`
+}
+
+type kubeObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name        string            `json:"name"`
+		Namespace   string            `json:"namespace"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		// Ingress
+		Rules []struct {
+			Host string `json:"host"`
+			HTTP *struct {
+				Paths []struct {
+					Backend struct {
+						Service *struct {
+							Name string `json:"name"`
+							Port struct {
+								Number int    `json:"number"`
+								Name   string `json:"name"`
+							} `json:"port"`
+						} `json:"service"`
+					} `json:"backend"`
+				} `json:"paths"`
+			} `json:"http"`
+		} `json:"rules"`
+		// Service
+		Ports []struct {
+			Name string `json:"name"`
+			Port int    `json:"port"`
+		} `json:"ports"`
+	} `json:"spec"`
+}
+
+// k8sRoute is a .test route backed by a cluster Service port.
+type k8sRoute struct {
+	Name      string
+	Namespace string
+	Service   string
+	Port      string // number or named port
+}
+
+// target identifies the port-forward a route needs.
+func (r k8sRoute) target() string {
+	return fmt.Sprintf("%s/%s:%s", r.Namespace, r.Service, r.Port)
+}
+
+// dir is the synthetic absolute path the route is registered from, so
+// conflicts name the cluster object that owns the route.
+func (r k8sRoute) dir(kubeContext string) string {
+	if kubeContext == "" {
+		kubeContext = "current"
+	}
+	return "/k8s/" + kubeContext + "/" + r.Namespace + "/" + r.Service
+}
+
+// k8sRouteNamePattern matches names the daemon accepts for routes.
+var k8sRouteNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,62}$`)
+
+// desiredRoutes maps Ingress rules with a host under tld, and Services
+// annotated with paw-proxy.dev/name, to routes. The first object claiming
+// a name wins; items are processed in kubectl's (namespace, name) order.
+func desiredRoutes(list kubeList, tld string) []k8sRoute {
+	var routes []k8sRoute
+	seen := make(map[string]bool)
+	add := func(r k8sRoute) {
+		if r.Port == "" || r.Port == "0" || !k8sRouteNamePattern.MatchString(r.Name) || seen[r.Name] {
+			return
+		}
+		seen[r.Name] = true
+		routes = append(routes, r)
+	}
+
+	for _, obj := range list.Items {
+		ns := obj.Metadata.Namespace
+		if ns == "" {
+			ns = "default"
+		}
+		switch obj.Kind {
+		case "Ingress":
+			for _, rule := range obj.Spec.Rules {
+				name, ok := strings.CutSuffix(rule.Host, "."+tld)
+				if !ok || rule.HTTP == nil {
+					continue
+				}
+				for _, path := range rule.HTTP.Paths {
+					svc := path.Backend.Service
+					if svc == nil {
+						continue
+					}
+					port := svc.Port.Name
+					if svc.Port.Number != 0 {
+						port = strconv.Itoa(svc.Port.Number)
+					}
+					add(k8sRoute{Name: name, Namespace: ns, Service: svc.Name, Port: port})
+					break
+				}
+			}
+		case "Service":
+			name := obj.Metadata.Annotations[k8sNameAnnotation]
+			if name == "" {
+				continue
+			}
+			port := obj.Metadata.Annotations[k8sPortAnnotation]
+			if port == "" && len(obj.Spec.Ports) > 0 {
+				port = strconv.Itoa(obj.Spec.Ports[0].Port)
+			}
+			add(k8sRoute{Name: name, Namespace: ns, Service: obj.Metadata.Name, Port: port})
+		}
+	}
+	return routes
+}
+
+var forwardLinePattern = regexp.MustCompile(`^Forwarding from 127\.0\.0\.1:(\d+) -> `)
+
+// parseForwardLine extracts the local port from kubectl port-forward's
+// "Forwarding from 127.0.0.1:54321 -> 80" line.
+func parseForwardLine(line string) (int, bool) {
+	m := forwardLinePattern.FindStringSubmatch(line)
+	if m == nil {
+		return 0, false
+	}
+	port, err := strconv.Atoi(m[1])
+	return port, err == nil
+}
+
+// kubectlArgs prefixes args with --context when one was given.
+func kubectlArgs(kubeContext string, args ...string) []string {
+	if kubeContext == "" {
+		return args
+	}
+	return append([]string{"--context", kubeContext}, args...)
+}
+
+// portForward is a running `kubectl port-forward` for one route.
+type portForward struct {
+	cmd       *exec.Cmd
+	localPort int
+	done      chan struct{}
+}
+
+func (pf *portForward) exited() bool {
+	select {
+	case <-pf.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (pf *portForward) stop() {
+	if !pf.exited() {
+		pf.cmd.Process.Kill() //nolint:errcheck // already exiting is fine
+	}
+	<-pf.done
+}
+
+// startPortForward forwards a random loopback port to the route's Service
+// and waits for kubectl to report which port it picked.
+func startPortForward(kubeContext string, r k8sRoute) (*portForward, error) {
+	cmd := exec.Command("kubectl", kubectlArgs(kubeContext,
+		"-n", r.Namespace, "port-forward", "--address", "127.0.0.1",
+		"svc/"+r.Service, ":"+r.Port)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting kubectl: %w", err)
+	}
+
+	pf := &portForward{cmd: cmd, done: make(chan struct{})}
+	portCh := make(chan int, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if port, ok := parseForwardLine(scanner.Text()); ok {
+				select {
+				case portCh <- port:
+				default:
+				}
+			}
+		}
+		io.Copy(io.Discard, stdout) //nolint:errcheck // drain until exit
+		cmd.Wait()                  //nolint:errcheck // exit is observed via done
+		close(pf.done)
+	}()
+
+	select {
+	case pf.localPort = <-portCh:
+		return pf, nil
+	case <-pf.done:
+		return nil, fmt.Errorf("kubectl port-forward %s exited: %s", r.target(), strings.TrimSpace(stderr.String()))
+	case <-time.After(k8sForwardTimeout):
+		pf.stop()
+		return nil, fmt.Errorf("kubectl port-forward %s did not report a port", r.target())
+	}
+}
+
+// syncedRoute is a registered route and the port-forward behind it.
+type syncedRoute struct {
+	route k8sRoute
+	pf    *portForward
+}
+
+// k8sSyncer keeps daemon routes in line with a cluster's Ingresses and
+// annotated Services.
+type k8sSyncer struct {
+	client      *pawclient.Client
+	kubeContext string
+	namespace   string
+	tld         string
+	active      map[string]*syncedRoute
+}
+
+// list fetches Ingresses and Services from the cluster.
+func (s *k8sSyncer) list() (kubeList, error) {
+	args := []string{"get", "ingress,service", "-o", "json"}
+	if s.namespace != "" {
+		args = append(args, "-n", s.namespace)
+	} else {
+		args = append(args, "--all-namespaces")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("kubectl", kubectlArgs(s.kubeContext, args...)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return kubeList{}, fmt.Errorf("kubectl get: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	var list kubeList
+	if err := json.Unmarshal(out, &list); err != nil {
+		return kubeList{}, fmt.Errorf("parsing kubectl output: %w", err)
+	}
+	return list, nil
+}
+
+// reconcile removes routes whose object disappeared or whose port-forward
+// died, adds new ones, and heartbeats the rest.
+func (s *k8sSyncer) reconcile(desired []k8sRoute) {
+	want := make(map[string]k8sRoute, len(desired))
+	for _, r := range desired {
+		want[r.Name] = r
+	}
+
+	for name, sr := range s.active {
+		r, ok := want[name]
+		if ok && r == sr.route && !sr.pf.exited() {
+			continue
+		}
+		s.remove(sr)
+	}
+
+	for _, r := range desired {
+		if _, ok := s.active[r.Name]; ok {
+			continue
+		}
+		pf, err := startPortForward(s.kubeContext, r)
+		if err != nil {
+			fmt.Printf("✗ %s.%s: %v\n", r.Name, s.tld, err)
+			continue
+		}
+		upstream := fmt.Sprintf("127.0.0.1:%d", pf.localPort)
+		if err := s.register(r.Name, upstream, r.dir(s.kubeContext)); err != nil {
+			pf.stop()
+			fmt.Printf("✗ %s.%s: %v\n", r.Name, s.tld, err)
+			continue
+		}
+		s.active[r.Name] = &syncedRoute{route: r, pf: pf}
+		fmt.Printf("+ https://%s.%s -> %s\n", r.Name, s.tld, r.target())
+	}
+
+	for name, sr := range s.active {
+		err := s.client.Heartbeat(context.Background(), name)
+		if pawclient.IsNotFound(err) {
+			// The daemon restarted; register the route again.
+			upstream := fmt.Sprintf("127.0.0.1:%d", sr.pf.localPort)
+			err = s.register(name, upstream, sr.route.dir(s.kubeContext))
+		}
+		if err != nil {
+			fmt.Printf("! %s.%s heartbeat: %v\n", name, s.tld, err)
+		}
+	}
+}
+
+// remove deregisters a route and stops its port-forward.
+func (s *k8sSyncer) remove(sr *syncedRoute) {
+	sr.pf.stop()
+	if err := s.client.Deregister(context.Background(), sr.route.Name); err != nil {
+		fmt.Printf("! %s.%s deregister: %v\n", sr.route.Name, s.tld, err)
+	}
+	delete(s.active, sr.route.Name)
+	fmt.Printf("- https://%s.%s\n", sr.route.Name, s.tld)
+}
+
+// register adds a route for the syncer's cluster to the daemon.
+func (s *k8sSyncer) register(name, upstream, dir string) error {
+	return s.client.Register(context.Background(), pawclient.RegisterRequest{Name: name, Upstream: upstream, Dir: dir})
+}
+
+// k8sSyncOptions are the parsed k8s-sync flags.
+type k8sSyncOptions struct {
+	context   string
+	namespace string
+	interval  time.Duration
+}
+
+func parseK8sSyncArgs(args []string) (k8sSyncOptions, error) {
+	opts := k8sSyncOptions{interval: 5 * time.Second}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--context", "--namespace", "-n", "--interval":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("%s requires a value", arg)
+			}
+			i++
+			switch arg {
+			case "--context":
+				opts.context = args[i]
+			case "--namespace", "-n":
+				opts.namespace = args[i]
+			case "--interval":
+				d, err := time.ParseDuration(args[i])
+				if err != nil || d < time.Second {
+					return opts, fmt.Errorf("--interval: must be a duration of at least 1s")
+				}
+				opts.interval = d
+			}
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+	return opts, nil
+}
+
+func cmdK8sSync() {
+	opts, err := parseK8sSyncArgs(os.Args[2:])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		fmt.Println("Error: kubectl not found in PATH")
+		os.Exit(1)
+	}
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	client := pawclient.New(config.SocketPath)
+	if _, err := client.Health(context.Background()); err != nil {
+		fmt.Println("Error: paw-proxy daemon not running")
+		fmt.Println("Run: sudo paw-proxy setup")
+		os.Exit(1)
+	}
+
+	s := &k8sSyncer{
+		client:      client,
+		kubeContext: opts.context,
+		namespace:   opts.namespace,
+		tld:         config.TLD,
+		active:      make(map[string]*syncedRoute),
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	fmt.Printf("Syncing Ingresses and annotated Services (every %s, Ctrl-C to stop)\n", opts.interval)
+	for {
+		if list, err := s.list(); err != nil {
+			fmt.Printf("! %v\n", err)
+		} else {
+			s.reconcile(desiredRoutes(list, s.tld))
+		}
+
+		select {
+		case <-sigCh:
+			names := make([]string, 0, len(s.active))
+			for name := range s.active {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			for _, name := range names {
+				s.remove(s.active[name])
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+const kubectlListJSON = `{
+  "items": [
+    {
+      "kind": "Ingress",
+      "metadata": {"name": "web", "namespace": "shop"},
+      "spec": {"rules": [
+        {"host": "shop.test", "http": {"paths": [{"backend": {"service": {"name": "frontend", "port": {"number": 80}}}}]}},
+        {"host": "shop.example.com", "http": {"paths": [{"backend": {"service": {"name": "frontend", "port": {"number": 80}}}}]}},
+        {"host": "api.shop.test", "http": {"paths": [{"backend": {"service": {"name": "api", "port": {"name": "http"}}}}]}}
+      ]}
+    },
+    {
+      "kind": "Service",
+      "metadata": {"name": "grafana", "namespace": "monitoring", "annotations": {"paw-proxy.dev/name": "grafana"}},
+      "spec": {"ports": [{"name": "http", "port": 3000}]}
+    },
+    {
+      "kind": "Service",
+      "metadata": {"name": "db", "namespace": "shop", "annotations": {"paw-proxy.dev/name": "shop", "paw-proxy.dev/port": "5432"}},
+      "spec": {"ports": [{"port": 5432}]}
+    },
+    {
+      "kind": "Service",
+      "metadata": {"name": "kube-dns", "namespace": "kube-system"},
+      "spec": {"ports": [{"port": 53}]}
+    }
+  ]
+}`
+
+func TestDesiredRoutes(t *testing.T) {
+	var list kubeList
+	if err := json.Unmarshal([]byte(kubectlListJSON), &list); err != nil {
+		t.Fatal(err)
+	}
+
+	got := desiredRoutes(list, "test")
+	want := []k8sRoute{
+		{Name: "shop", Namespace: "shop", Service: "frontend", Port: "80"},
+		{Name: "api.shop", Namespace: "shop", Service: "api", Port: "http"},
+		{Name: "grafana", Namespace: "monitoring", Service: "grafana", Port: "3000"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("desiredRoutes =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := want[0].dir("kind-dev"); got != "/k8s/kind-dev/shop/frontend" {
+		t.Errorf("dir = %q", got)
+	}
+	if got := want[0].dir(""); got != "/k8s/current/shop/frontend" {
+		t.Errorf("dir without context = %q", got)
+	}
+}
+
+func TestParseForwardLine(t *testing.T) {
+	if port, ok := parseForwardLine("Forwarding from 127.0.0.1:54321 -> 80"); !ok || port != 54321 {
+		t.Errorf("parseForwardLine = %d, %v", port, ok)
+	}
+	if _, ok := parseForwardLine("Handling connection for 54321"); ok {
+		t.Error("expected no match for connection log line")
+	}
+}
+
+func TestParseK8sSyncArgs(t *testing.T) {
+	opts, err := parseK8sSyncArgs([]string{"--context", "kind-dev", "-n", "shop", "--interval", "10s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.context != "kind-dev" || opts.namespace != "shop" || opts.interval != 10*time.Second {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	for _, args := range [][]string{{"--context"}, {"--interval", "10ms"}, {"--bogus"}} {
+		if _, err := parseK8sSyncArgs(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
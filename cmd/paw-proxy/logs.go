@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+const logsUsage = "Usage: paw-proxy logs [--tail|-f] [--clear] [--json] [--since 10m] [--route name] [--level warn]"
+
+// defaultLogLines is how many matching lines are shown without --since.
+const defaultLogLines = 50
+
+// ANSI colors used by the pretty renderer.
+const (
+	colorReset  = "\033[0m"
+	colorDim    = "\033[2m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+// logsOptions holds the parsed flags for `paw-proxy logs`.
+type logsOptions struct {
+	tail  bool
+	clear bool
+	json  bool
+	color bool
+	// Filters. Zero values match everything.
+	since    time.Time
+	route    string
+	level    slog.Level
+	hasLevel bool
+}
+
+// filtered reports whether any filter flag was given.
+func (o logsOptions) filtered() bool {
+	return !o.since.IsZero() || o.route != "" || o.hasLevel
+}
+
+// parseLogsArgs parses `paw-proxy logs` flags. now anchors --since.
+func parseLogsArgs(args []string, now time.Time) (logsOptions, error) {
+	var opts logsOptions
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		needValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("%s requires a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch name {
+		case "--tail", "-f":
+			opts.tail = true
+		case "--clear":
+			opts.clear = true
+		case "--json":
+			opts.json = true
+		case "--since":
+			v, err := needValue()
+			if err != nil {
+				return opts, err
+			}
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return opts, fmt.Errorf("--since expects a positive duration like 10m or 2h")
+			}
+			opts.since = now.Add(-d)
+		case "--route":
+			v, err := needValue()
+			if err != nil {
+				return opts, err
+			}
+			opts.route = api.ExtractName(v)
+		case "--level":
+			v, err := needValue()
+			if err != nil {
+				return opts, err
+			}
+			if err := opts.level.UnmarshalText([]byte(v)); err != nil {
+				return opts, fmt.Errorf("--level expects debug, info, warn, or error")
+			}
+			opts.hasLevel = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+	return opts, nil
+}
+
+// logField is one key/value pair of a log entry, in file order.
+type logField struct {
+	key   string
+	value json.RawMessage
+}
+
+// logEntry is a parsed slog JSON line.
+type logEntry struct {
+	time   time.Time
+	level  slog.Level
+	msg    string
+	fields []logField // everything except time, level, and msg
+}
+
+// str returns the named field as a string ("" if absent).
+func (e logEntry) str(key string) string {
+	for _, f := range e.fields {
+		if f.key == key {
+			var s string
+			if json.Unmarshal(f.value, &s) == nil {
+				return s
+			}
+			return string(f.value)
+		}
+	}
+	return ""
+}
+
+// parseLogLine parses a slog JSON line, preserving field order. Lines
+// written by the standard logger (plain text) return ok=false.
+func parseLogLine(line string) (logEntry, bool) {
+	var e logEntry
+	if !strings.HasPrefix(line, "{") {
+		return e, false
+	}
+	dec := json.NewDecoder(strings.NewReader(line))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return e, false
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return e, false
+		}
+		key, _ := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return e, false
+		}
+		switch key {
+		case slog.TimeKey:
+			var s string
+			json.Unmarshal(raw, &s)
+			e.time, _ = time.Parse(time.RFC3339Nano, s)
+		case slog.LevelKey:
+			var s string
+			json.Unmarshal(raw, &s)
+			e.level.UnmarshalText([]byte(s))
+		case slog.MessageKey:
+			json.Unmarshal(raw, &e.msg)
+		default:
+			e.fields = append(e.fields, logField{key: key, value: raw})
+		}
+	}
+	return e, true
+}
+
+// match reports whether a line passes the filters. Plain-text lines have
+// no structure to filter on, so they are only shown when unfiltered.
+func (o logsOptions) match(line string) bool {
+	e, ok := parseLogLine(line)
+	if !ok {
+		return !o.filtered()
+	}
+	if !o.since.IsZero() && e.time.Before(o.since) {
+		return false
+	}
+	if o.hasLevel && e.level < o.level {
+		return false
+	}
+	if o.route != "" && e.str("route") != o.route && api.ExtractName(e.str("host")) != o.route {
+		return false
+	}
+	return true
+}
+
+// render writes line in the selected output format.
+func (o logsOptions) render(w io.Writer, line string) {
+	if o.json {
+		fmt.Fprintln(w, line)
+		return
+	}
+	e, ok := parseLogLine(line)
+	if !ok {
+		fmt.Fprintln(w, line)
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(o.paint(colorDim, e.time.Local().Format("2006-01-02 15:04:05.000")))
+	b.WriteByte(' ')
+	b.WriteString(o.paint(levelColor(e.level), fmt.Sprintf("%-5s", e.level.String())))
+	b.WriteByte(' ')
+	b.WriteString(e.msg)
+	for _, f := range e.fields {
+		value := string(f.value)
+		var s string
+		if json.Unmarshal(f.value, &s) == nil && !strings.ContainsAny(s, " \"=") {
+			value = s
+		}
+		if f.key == "status" {
+			value = o.paint(statusColor(value), value)
+		}
+		b.WriteString("  ")
+		b.WriteString(o.paint(colorDim, f.key+"="))
+		b.WriteString(value)
+	}
+	fmt.Fprintln(w, b.String())
+}
+
+func (o logsOptions) paint(color, s string) string {
+	if !o.color || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func levelColor(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return colorRed
+	case level >= slog.LevelWarn:
+		return colorYellow
+	case level >= slog.LevelInfo:
+		return colorCyan
+	default:
+		return colorDim
+	}
+}
+
+func statusColor(status string) string {
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case code >= 500:
+		return colorRed
+	case code >= 400:
+		return colorYellow
+	case code >= 300:
+		return colorCyan
+	default:
+		return colorGreen
+	}
+}
+
+// stdoutIsTerminal reports whether colors should be used by default.
+func stdoutIsTerminal() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// showLogs prints matching lines from the log file: all lines since
+// --since if given, otherwise the last defaultLogLines.
+func showLogs(w io.Writer, path string, opts logsOptions) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var matched []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || !opts.match(line) {
+			continue
+		}
+		matched = append(matched, line)
+		if opts.since.IsZero() && len(matched) > defaultLogLines {
+			matched = matched[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	for _, line := range matched {
+		opts.render(w, line)
+	}
+	return nil
+}
+
+// followLogs prints matching lines appended to the log file until the
+// process is interrupted.
+func followLogs(w io.Writer, path string, opts logsOptions) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("seeking: %w", err)
+	}
+
+	reader := bufio.NewReader(f)
+	var partial bytes.Buffer
+	for {
+		chunk, err := reader.ReadBytes('\n')
+		partial.Write(chunk)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				// EOF is normal -- keep any partial line and wait for more data
+				time.Sleep(200 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		line := strings.TrimRight(partial.String(), "\r\n")
+		partial.Reset()
+		if line != "" && opts.match(line) {
+			opts.render(w, line)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogsArgs(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	opts, err := parseLogsArgs([]string{"-f", "--json", "--since", "10m", "--route=myapp.test", "--level", "warn"}, now)
+	if err != nil {
+		t.Fatalf("parseLogsArgs: %v", err)
+	}
+	if !opts.tail || !opts.json {
+		t.Error("expected --tail and --json to be set")
+	}
+	if !opts.since.Equal(now.Add(-10 * time.Minute)) {
+		t.Errorf("since = %v, want %v", opts.since, now.Add(-10*time.Minute))
+	}
+	if opts.route != "myapp" {
+		t.Errorf("route = %q, want myapp", opts.route)
+	}
+	if !opts.hasLevel || opts.level != slog.LevelWarn {
+		t.Errorf("level = %v, want WARN", opts.level)
+	}
+
+	for _, args := range [][]string{
+		{"--since", "yesterday"},
+		{"--since"},
+		{"--level", "loud"},
+		{"--bogus"},
+	} {
+		if _, err := parseLogsArgs(args, now); err == nil {
+			t.Errorf("parseLogsArgs(%v): expected error", args)
+		}
+	}
+}
+
+func TestLogsOptionsMatch(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	request := `{"time":"2026-01-01T11:58:00Z","level":"INFO","msg":"request","host":"myapp.test","route":"myapp","status":200}`
+	old := `{"time":"2026-01-01T10:00:00Z","level":"INFO","msg":"request","host":"other.test","route":"other","status":200}`
+	warning := `{"time":"2026-01-01T11:59:00Z","level":"WARN","msg":"invalid host rejected","host_len":9000}`
+	notFound := `{"time":"2026-01-01T11:59:30Z","level":"INFO","msg":"request","host":"myapp.test","status":404}`
+	plain := `2026/01/01 11:59:00 paw-proxy daemon starting...`
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"no filters", nil, []string{request, old, warning, notFound, plain}},
+		{"since", []string{"--since", "10m"}, []string{request, warning, notFound}},
+		{"route matches host too", []string{"--route", "myapp"}, []string{request, notFound}},
+		{"level", []string{"--level", "warn"}, []string{warning}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseLogsArgs(tt.args, now)
+			if err != nil {
+				t.Fatalf("parseLogsArgs: %v", err)
+			}
+			var got []string
+			for _, line := range []string{request, old, warning, notFound, plain} {
+				if opts.match(line) {
+					got = append(got, line)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("matched:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestLogsOptionsRender(t *testing.T) {
+	line := `{"time":"2026-01-01T11:58:00Z","level":"ERROR","msg":"component failure","error":"DNS server: bind failed","status":502}`
+
+	var buf bytes.Buffer
+	logsOptions{}.render(&buf, line)
+	out := buf.String()
+	if !strings.Contains(out, "ERROR component failure") {
+		t.Errorf("expected level and message, got %q", out)
+	}
+	if !strings.Contains(out, `error="DNS server: bind failed"`) || !strings.Contains(out, "status=502") {
+		t.Errorf("expected fields in file order, got %q", out)
+	}
+	if strings.Contains(out, "\033[") {
+		t.Errorf("expected no color codes when color is off, got %q", out)
+	}
+
+	buf.Reset()
+	logsOptions{color: true}.render(&buf, line)
+	if !strings.Contains(buf.String(), colorRed) {
+		t.Errorf("expected red for ERROR/5xx with color on, got %q", buf.String())
+	}
+
+	buf.Reset()
+	logsOptions{json: true}.render(&buf, line)
+	if buf.String() != line+"\n" {
+		t.Errorf("expected raw JSON line, got %q", buf.String())
+	}
+}
+
+func TestShowLogsLimitsLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "paw-proxy.log")
+	var data strings.Builder
+	for i := range defaultLogLines + 10 {
+		data.WriteString(`{"time":"2026-01-01T11:58:00Z","level":"INFO","msg":"request","n":`)
+		data.WriteString(strings.Repeat("1", i+1))
+		data.WriteString("}\n")
+	}
+	os.WriteFile(path, []byte(data.String()), 0600)
+
+	var buf bytes.Buffer
+	if err := showLogs(&buf, path, logsOptions{json: true}); err != nil {
+		t.Fatalf("showLogs: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != defaultLogLines {
+		t.Fatalf("expected %d lines, got %d", defaultLogLines, len(lines))
+	}
+	if !strings.Contains(lines[len(lines)-1], strings.Repeat("1", defaultLogLines+10)) {
+		t.Error("expected the most recent lines to be kept")
+	}
+}
@@ -11,15 +11,21 @@ import (
 	"io"
 	"log"
 	"net"
-	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/alexcatdad/paw-proxy/internal/api"
 	"github.com/alexcatdad/paw-proxy/internal/daemon"
 	"github.com/alexcatdad/paw-proxy/internal/help"
+	"github.com/alexcatdad/paw-proxy/internal/i18n"
 	"github.com/alexcatdad/paw-proxy/internal/setup"
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+	"github.com/alexcatdad/paw-proxy/internal/trust"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
 )
 
 // version is set via -ldflags at build time; defaults to "dev" for local builds.
@@ -75,6 +81,125 @@ func main() {
 			}
 			cmdDoctor()
 			return
+		case "reload":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "reload")
+				return
+			}
+			cmdReload()
+			return
+		case "dashboard":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "dashboard")
+				return
+			}
+			cmdDashboard()
+			return
+		case "routes":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "routes")
+				return
+			}
+			cmdRoutes()
+			return
+		case "tray":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "tray")
+				return
+			}
+			cmdTray()
+			return
+		case "gc":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "gc")
+				return
+			}
+			cmdGC()
+			return
+		case "k8s-sync":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "k8s-sync")
+				return
+			}
+			cmdK8sSync()
+			return
+		case "service":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "service")
+				return
+			}
+			cmdService()
+			return
+		case "trust":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "trust")
+				return
+			}
+			cmdTrust()
+			return
+		case "certs":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "certs")
+				return
+			}
+			cmdCerts()
+			return
+		case "ca":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "ca")
+				return
+			}
+			cmdCA()
+			return
+		case "ca-key":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "ca-key")
+				return
+			}
+			cmdCAKey()
+			return
+		case "export":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "export")
+				return
+			}
+			cmdExport()
+			return
+		case "import":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "import")
+				return
+			}
+			cmdImport()
+			return
+		case "tailscale":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "tailscale")
+				return
+			}
+			cmdTailscale()
+			return
+		case "tap":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "tap")
+				return
+			}
+			cmdTap()
+			return
+		case "throttle":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "throttle")
+				return
+			}
+			cmdThrottle()
+			return
+		case "update":
+			if hasHelpFlag(os.Args[2:]) {
+				help.PawProxyCommand.RenderSubcommand(os.Stdout, "update")
+				return
+			}
+			cmdUpdate()
+			return
 		case "version":
 			fmt.Printf("paw-proxy version %s\n", version)
 			return
@@ -102,6 +227,58 @@ func cmdRun() {
 		os.Exit(1)
 	}
 
+	// Parse flags. --log-level beats PAW_PROXY_LOG_LEVEL, which beats
+	// log_level in config.json.
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--verbose" || arg == "-v":
+			config.LogLevel = "debug"
+		case arg == "--log-level" && i+1 < len(args):
+			i++
+			config.LogLevel = args[i]
+		case strings.HasPrefix(arg, "--log-level="):
+			config.LogLevel = strings.TrimPrefix(arg, "--log-level=")
+		case (arg == "--http-port" || arg == "--https-port") && i+1 < len(args):
+			i++
+			port, err := parsePort(args[i])
+			if err != nil {
+				fmt.Printf("Error: %s: %v\n", arg, err)
+				os.Exit(1)
+			}
+			if arg == "--http-port" {
+				config.HTTPPort = port
+			} else {
+				config.HTTPSPort = port
+			}
+		case arg == "--api-addr" && i+1 < len(args):
+			i++
+			if err := api.ValidateLoopbackAddr(args[i]); err != nil {
+				fmt.Printf("Error: %s: %v\n", arg, err)
+				os.Exit(1)
+			}
+			config.APIAddr = args[i]
+		case arg == "--read-only":
+			config.ReadOnly = true
+		case arg == "--listen-addr" && i+1 < len(args):
+			i++
+			config.ListenAddr = args[i]
+		case arg == "--allow" && i+1 < len(args):
+			i++
+			config.AllowFrom = append(config.AllowFrom, splitList(args[i])...)
+		default:
+			fmt.Printf("Unknown flag: %s\n", arg)
+			fmt.Println("Usage: paw-proxy run [--log-level debug|info|warn|error] [--verbose] [--http-port N] [--https-port N] [--api-addr 127.0.0.1:PORT] [--listen-addr IP --allow CIDR] [--read-only]")
+			os.Exit(1)
+		}
+	}
+	if config.ListenAddr != "" || len(config.AllowFrom) > 0 {
+		if _, _, err := daemon.ParseListen(config.ListenAddr, config.AllowFrom); err != nil {
+			fmt.Printf("Error: --listen-addr: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Ensure log directory exists (e.g. ~/.local/state/paw-proxy/ on Linux)
 	if err := os.MkdirAll(filepath.Dir(config.LogPath), 0700); err != nil {
 		log.Fatalf("Failed to create log directory: %v", err)
@@ -128,44 +305,260 @@ func cmdRun() {
 	}
 }
 
-func cmdSetup() {
-	// Check for root/sudo
-	if os.Geteuid() != 0 {
-		fmt.Println("Error: setup requires sudo")
-		fmt.Println("Run: sudo paw-proxy setup")
+func cmdReload() {
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	exe, err := os.Executable()
+	err = pawclient.New(config.SocketPath).Reload(context.Background())
+	if pawclient.IsUnavailable(err) {
+		fmt.Println("Error: daemon not running")
+		os.Exit(1)
+	}
 	if err != nil {
-		fmt.Printf("Error: cannot determine binary path: %v\n", err)
+		msg := err.Error()
+		var apiErr *pawclient.APIError
+		if errors.As(err, &apiErr) && apiErr.Message != "" {
+			msg = apiErr.Message
+		}
+		fmt.Printf("Reload failed: %s\n", msg)
+		fmt.Println("The daemon is still running with its previous settings.")
 		os.Exit(1)
 	}
+	fmt.Printf("Reloaded %s\n", config.ConfigPath)
+}
+
+func cmdGC() {
+	dryRun := false
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--dry-run", "-n":
+			dryRun = true
+		default:
+			fmt.Printf("Error: unknown flag: %s\n", arg)
+			fmt.Println("Usage: paw-proxy gc [--dry-run]")
+			os.Exit(1)
+		}
+	}
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 1. Expired routes and tombstones live in the daemon.
+	if dryRun {
+		fmt.Println("Routes: skipped (dry run)")
+	} else {
+		result, err := pawclient.New(config.SocketPath).GC(context.Background())
+		switch {
+		case pawclient.IsUnavailable(err):
+			fmt.Println("Routes: skipped (daemon not running)")
+		case err != nil:
+			fmt.Printf("Routes: sweep failed: %v\n", err)
+		default:
+			fmt.Printf("Routes: removed %d expired, %d idle previews, %d orphaned, %d tombstones\n",
+				result.Expired, result.IdleExpired, result.Orphaned, result.Tombstones)
+		}
+	}
+
+	// 2. Rotated logs past retention.
+	stale, err := staleRotatedLogs(config.LogPath, time.Now(), rotatedLogRetention)
+	if err != nil {
+		fmt.Printf("Error: scanning logs: %v\n", err)
+		os.Exit(1)
+	}
+	var reclaimed int64
+	removed := 0
+	for _, f := range stale {
+		if dryRun {
+			fmt.Printf("  would remove %s (%s)\n", f.path, formatBytes(f.size))
+		} else if err := os.Remove(f.path); err != nil {
+			fmt.Printf("  warning: could not remove %s: %v\n", f.path, err)
+			continue
+		}
+		removed++
+		reclaimed += f.size
+	}
+
+	verb := "Reclaimed"
+	if dryRun {
+		verb = "Would reclaim"
+	}
+	fmt.Printf("Logs: %d rotated file(s) older than %d days\n", removed, int(rotatedLogRetention.Hours()/24))
+	fmt.Printf("%s %s\n", verb, formatBytes(reclaimed))
+}
 
+func cmdSetup() {
 	defaultCfg, err := daemon.DefaultConfig()
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	config := &setup.Config{
-		SupportDir: defaultCfg.SupportDir,
-		BinaryPath: exe,
-		DNSPort:    9353,
-		TLD:        "test",
+	config, err := newSetupConfig(defaultCfg)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	dryRun := false
+	shared := make(map[string]string)
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--dry-run", "-n":
+			dryRun = true
+		case "--socket-activation":
+			if runtime.GOOS != "linux" {
+				fmt.Println("Error: --socket-activation is only for Linux; macOS always uses launchd sockets")
+				os.Exit(1)
+			}
+			config.SocketActivation = true
+		case "--http-port", "--https-port":
+			if i+1 >= len(args) {
+				fmt.Printf("Error: %s requires a port\n", arg)
+				os.Exit(1)
+			}
+			i++
+			port, err := parsePort(args[i])
+			if err != nil {
+				fmt.Printf("Error: %s: %v\n", arg, err)
+				os.Exit(1)
+			}
+			if arg == "--http-port" {
+				config.HTTPPort = port
+			} else {
+				config.HTTPSPort = port
+			}
+		case "--api-addr":
+			if i+1 >= len(args) {
+				fmt.Printf("Error: %s requires an address\n", arg)
+				os.Exit(1)
+			}
+			i++
+			if err := api.ValidateLoopbackAddr(args[i]); err != nil {
+				fmt.Printf("Error: %s: %v\n", arg, err)
+				os.Exit(1)
+			}
+			config.APIAddr = args[i]
+		case "--ca-cert", "--ca-key", "--ca-url", "--ca-sha256":
+			if i+1 >= len(args) {
+				fmt.Printf("Error: %s requires a value\n", arg)
+				os.Exit(1)
+			}
+			i++
+			shared[arg] = args[i]
+		case "--listen-addr", "--allow":
+			if i+1 >= len(args) {
+				fmt.Printf("Error: %s requires an address\n", arg)
+				os.Exit(1)
+			}
+			i++
+			if arg == "--listen-addr" {
+				config.ListenAddr = args[i]
+			} else {
+				config.AllowFrom = append(config.AllowFrom, splitList(args[i])...)
+			}
+		default:
+			fmt.Printf("Error: unknown flag: %s\n", arg)
+			os.Exit(1)
+		}
+	}
+	if config.ListenAddr != "" || len(config.AllowFrom) > 0 {
+		if _, _, err := daemon.ParseListen(config.ListenAddr, config.AllowFrom); err != nil {
+			fmt.Printf("Error: --listen-addr: %v\n", err)
+			os.Exit(1)
+		}
+		if config.SocketActivation {
+			fmt.Println("Error: --listen-addr binds its own sockets and can't be combined with --socket-activation")
+			os.Exit(1)
+		}
+	}
+	if len(shared) > 0 {
+		config.SharedCA, err = loadSharedCA(shared, config.CADomains)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if dryRun {
+		plan, err := setup.PlanSetup(config)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		plan.Print(os.Stdout)
+		return
+	}
+
+	// Check for root/sudo
+	if os.Geteuid() != 0 {
+		fmt.Println(i18n.T("Error: setup requires sudo"))
+		fmt.Println(i18n.T("Run: %s", "sudo paw-proxy setup"))
+		os.Exit(1)
 	}
 
 	if err := setup.Run(config); err != nil {
-		fmt.Printf("Setup failed: %v\n", err)
+		fmt.Println(i18n.T("Setup failed: %v", err))
 		os.Exit(1)
 	}
 }
 
+// parsePort parses a TCP port number.
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// newSetupConfig builds the setup configuration for this binary. Homebrew
+// installs use the stable bin symlink so services survive upgrades.
+func newSetupConfig(config *daemon.Config) (*setup.Config, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine binary path: %w", err)
+	}
+	// A broken config file is reported by the daemon; the CA still
+	// covers the primary TLD.
+	domains, err := config.CADomains()
+	if err != nil {
+		domains = []string{config.TLD}
+	}
+	return &setup.Config{
+		SupportDir: config.SupportDir,
+		BinaryPath: setup.StableBinaryPath(exe),
+		DNSPort:    config.DNSPort,
+		TLD:        config.TLD,
+		CADomains:  domains,
+	}, nil
+}
+
 func cmdUninstall() {
 	brewFlag := false
+	dryRun := false
 	for _, arg := range os.Args[2:] {
-		if arg == "--brew" {
+		switch arg {
+		case "--brew":
 			brewFlag = true
+		case "--dry-run", "-n":
+			dryRun = true
 		}
 	}
 
@@ -174,73 +567,125 @@ func cmdUninstall() {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
+	if dryRun {
+		plan, err := setup.PlanUninstall(config.SupportDir, "test", brewFlag)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		plan.Print(os.Stdout)
+		return
+	}
 	if err := setup.Uninstall(config.SupportDir, "test", brewFlag); err != nil {
-		fmt.Printf("Uninstall failed: %v\n", err)
+		fmt.Println(i18n.T("Uninstall failed: %v", err))
 		os.Exit(1)
 	}
 }
 
 func cmdStatus() {
+	jsonOut, watch := false, false
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--json":
+			jsonOut = true
+		case "--watch", "-w":
+			watch = true
+		default:
+			fmt.Printf("Error: unknown flag: %s\n", arg)
+			fmt.Println("Usage: paw-proxy status [--json | --watch]")
+			os.Exit(1)
+		}
+	}
+	if jsonOut && watch {
+		fmt.Println("Error: --json can't be combined with --watch")
+		os.Exit(1)
+	}
+
 	config, err := daemon.DefaultConfig()
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
-	socketPath := config.SocketPath
+	client := pawclient.New(config.SocketPath)
+	if watch {
+		watchStatus(client)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
 
-	client := &http.Client{
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				return net.Dial("unix", socketPath)
-			},
-		},
-		Timeout: 2 * time.Second,
+	if jsonOut {
+		data, err := json.MarshalIndent(collectStatus(ctx, client, filepath.Join(config.SupportDir, "ca.crt")), "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s\n", data)
+		return
 	}
 
 	// Check health
-	resp, err := client.Get("http://unix/health")
+	health, err := client.Health(ctx)
 	if err != nil {
-		fmt.Println("Status: ❌ Daemon not running")
+		fmt.Println(i18n.T("Status: ❌ Daemon not running"))
 		fmt.Println("")
-		fmt.Println("Run: sudo paw-proxy setup")
+		fmt.Println(i18n.T("Run: %s", "sudo paw-proxy setup"))
 		return
 	}
-	defer resp.Body.Close()
 
-	var health struct {
-		Status  string `json:"status"`
-		Version string `json:"version"`
-		Uptime  string `json:"uptime"`
+	if health.Degraded() {
+		fmt.Println(i18n.T("Status: ⚠️  Degraded (v%s, up %s)", health.Version, health.Uptime))
+		for _, p := range health.Problems {
+			fmt.Printf("  - %s\n", p)
+		}
+		fmt.Println("  " + i18n.T("Stop the conflicting process; paw-proxy retries the port automatically."))
+	} else {
+		fmt.Println(i18n.T("Status: ✅ Running (v%s, up %s)", health.Version, health.Uptime))
 	}
-	json.NewDecoder(resp.Body).Decode(&health)
-
-	fmt.Printf("Status: ✅ Running (v%s, up %s)\n", health.Version, health.Uptime)
 	fmt.Println("")
 
 	// Get routes
-	resp, err = client.Get("http://unix/routes")
+	routes, err := client.List(ctx)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
-	var routes []struct {
-		Name          string    `json:"name"`
-		Upstream      string    `json:"upstream"`
-		Dir           string    `json:"dir"`
-		Registered    time.Time `json:"registered"`
-		LastHeartbeat time.Time `json:"lastHeartbeat"`
+	// Previews are listed separately from regular routes
+	regular := routes[:0:0]
+	previews := routes[:0:0]
+	for _, r := range routes {
+		if r.Preview != "" {
+			previews = append(previews, r)
+		} else {
+			regular = append(regular, r)
+		}
 	}
-	json.NewDecoder(resp.Body).Decode(&routes)
 
-	if len(routes) == 0 {
-		fmt.Println("Routes: (none)")
+	if len(regular) == 0 {
+		fmt.Println(i18n.T("Routes: (none)"))
 	} else {
-		fmt.Println("Routes:")
-		for _, r := range routes {
+		fmt.Println(i18n.T("Routes:"))
+		printRouteGroups(os.Stdout, regular)
+	}
+
+	if len(previews) > 0 {
+		fmt.Println("")
+		fmt.Println(i18n.T("Previews:"))
+		for _, r := range previews {
 			age := time.Since(r.Registered).Round(time.Second)
-			fmt.Printf("  • %s.test -> %s (%s)\n", r.Name, r.Upstream, age)
-			fmt.Printf("    Dir: %s\n", r.Dir)
+			fmt.Printf("  • %s.test -> %s [%s] (%s)\n", r.Name, routeTarget(r), r.Preview, age)
+			if r.IdleTimeoutSeconds > 0 {
+				idleSince := r.Registered
+				if r.LastRequest.After(idleSince) {
+					idleSince = r.LastRequest
+				}
+				remaining := time.Duration(r.IdleTimeoutSeconds)*time.Second - time.Since(idleSince)
+				fmt.Printf("    %s\n", i18n.T("Expires if idle for %s more", max(remaining, 0).Round(time.Second)))
+			}
+			fmt.Printf("    %s\n", i18n.T("Dir: %s", r.Dir))
+			if r.Owner != nil {
+				fmt.Printf("    %s\n", i18n.T("Owner: %s", formatOwner(r.Owner)))
+			}
 		}
 	}
 
@@ -252,12 +697,49 @@ func cmdStatus() {
 			cert, _ := x509.ParseCertificate(block.Bytes)
 			if cert != nil {
 				fmt.Println("")
-				fmt.Printf("CA Expires: %s\n", cert.NotAfter.Format("2006-01-02"))
+				fmt.Println(i18n.T("CA Expires: %s", cert.NotAfter.Format("2006-01-02")))
 			}
 		}
 	}
 }
 
+// statusReport is the output of `paw-proxy status --json`. Its field
+// names are kept stable for scripts and editor extensions.
+type statusReport struct {
+	Running bool `json:"running"`
+	// Status is "ok" or "degraded" while the daemon runs.
+	Status   string            `json:"status,omitempty"`
+	Version  string            `json:"version,omitempty"`
+	Uptime   string            `json:"uptime,omitempty"`
+	Problems []string          `json:"problems,omitempty"`
+	Routes   []pawclient.Route `json:"routes"`
+	// CAExpires is when the CA certificate expires, if it can be read.
+	CAExpires *time.Time `json:"caExpires,omitempty"`
+}
+
+// collectStatus gathers what `paw-proxy status` shows. Previews are part
+// of Routes, with their preview label set. An empty caPath skips the CA.
+func collectStatus(ctx context.Context, client *pawclient.Client, caPath string) statusReport {
+	report := statusReport{Routes: []pawclient.Route{}}
+	if health, err := client.Health(ctx); err == nil {
+		report.Running = true
+		report.Status = health.Status
+		report.Version = health.Version
+		report.Uptime = health.Uptime
+		report.Problems = health.Problems
+		if routes, err := client.List(ctx); err == nil {
+			report.Routes = routes
+		}
+	}
+	if caPath == "" {
+		return report
+	}
+	if cert, err := loadCertFile(caPath); err == nil {
+		report.CAExpires = &cert.NotAfter
+	}
+	return report
+}
+
 func cmdLogs() {
 	config, err := daemon.DefaultConfig()
 	if err != nil {
@@ -265,23 +747,15 @@ func cmdLogs() {
 		os.Exit(1)
 	}
 
-	// Parse flags
-	tail := false
-	clear := false
-	for _, arg := range os.Args[2:] {
-		switch arg {
-		case "--tail", "-f":
-			tail = true
-		case "--clear":
-			clear = true
-		default:
-			fmt.Printf("Unknown flag: %s\n", arg)
-			fmt.Println("Usage: paw-proxy logs [--tail|-f] [--clear]")
-			os.Exit(1)
-		}
+	opts, err := parseLogsArgs(os.Args[2:], time.Now())
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println(logsUsage)
+		os.Exit(1)
 	}
+	opts.color = !opts.json && stdoutIsTerminal()
 
-	if clear {
+	if opts.clear {
 		if err := os.Truncate(config.LogPath, 0); err != nil {
 			if os.IsNotExist(err) {
 				fmt.Println("No log file found")
@@ -294,19 +768,20 @@ func cmdLogs() {
 		return
 	}
 
-	fmt.Printf("Showing logs from %s\n", config.LogPath)
-	fmt.Println(strings.Repeat("-", 50))
+	// --json output stays pure JSON lines so it can be piped to jq
+	if !opts.json {
+		fmt.Printf("Showing logs from %s\n", config.LogPath)
+		fmt.Println(strings.Repeat("-", 50))
+	}
 
-	if tail {
-		cmdLogsTail(config.LogPath)
+	if opts.tail {
+		if !opts.json {
+			fmt.Println("Following log output (Ctrl+C to stop)...")
+		}
+		err = followLogs(os.Stdout, config.LogPath, opts)
 	} else {
-		cmdLogsShow(config.LogPath, 50)
+		err = showLogs(os.Stdout, config.LogPath, opts)
 	}
-}
-
-// cmdLogsShow prints the last N lines of the log file.
-func cmdLogsShow(path string, n int) {
-	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			fmt.Println("No log file found -- daemon may not have run yet")
@@ -315,180 +790,318 @@ func cmdLogsShow(path string, n int) {
 		fmt.Printf("Error reading log: %v\n", err)
 		os.Exit(1)
 	}
-
-	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
-	start := 0
-	if len(lines) > n {
-		start = len(lines) - n
-	}
-	for _, line := range lines[start:] {
-		fmt.Println(line)
-	}
 }
 
 func cmdDoctor() {
+	opts, err := parseDoctorArgs(os.Args[2:])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println("Usage: paw-proxy doctor [--fix [--yes] | --json]")
+		os.Exit(1)
+	}
+
 	config, err := daemon.DefaultConfig()
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
+	setupCfg, err := newSetupConfig(config)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 
-	fmt.Println("paw-proxy doctor")
-	fmt.Println("================")
-	fmt.Println()
+	report := &doctorReport{}
+	if !opts.json {
+		report.w = os.Stdout
+		fmt.Println("paw-proxy doctor")
+		fmt.Println("================")
+		fmt.Println()
+	}
 
 	issues := 0
+	var fixes []doctorFix
+	// restart is set by any failure that a daemon restart resolves, and by
+	// fixes that only take effect once the daemon restarts.
+	restart := false
 
 	// 1. Check socket exists
 	if _, err := os.Stat(config.SocketPath); err != nil {
-		printCheck(false, "Unix socket missing at %s", config.SocketPath)
+		report.check("socket", false, "Unix socket missing at %s", config.SocketPath)
 		issues++
+		restart = true
 	} else {
-		printCheck(true, "Unix socket exists at %s", config.SocketPath)
+		report.check("socket", true, "Unix socket exists at %s", config.SocketPath)
 	}
 
 	// 2. Check daemon health via unix socket
-	client := &http.Client{
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				return net.Dial("unix", config.SocketPath)
-			},
-		},
-		Timeout: 2 * time.Second,
-	}
+	healthCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	health, err := pawclient.New(config.SocketPath).Health(healthCtx)
+	cancel()
 
-	resp, err := client.Get("http://unix/health")
-	if err != nil {
-		printCheck(false, "Daemon not responding")
+	daemonUp := false
+	if pawclient.IsUnavailable(err) {
+		report.check("daemon", false, "Daemon not responding")
 		issues++
+		restart = true
 	} else {
-		var health struct {
-			Status  string `json:"status"`
-			Version string `json:"version"`
-			Uptime  string `json:"uptime"`
-		}
-		if decErr := json.NewDecoder(resp.Body).Decode(&health); decErr != nil {
-			printCheck(false, "Daemon health response invalid: %v", decErr)
+		daemonUp = true
+		if err != nil {
+			report.check("daemon", false, "Daemon health response invalid: %v", err)
+			issues++
+			restart = true
+		} else if health.Degraded() {
+			report.check("daemon", false, "Daemon degraded (v%s, up %s)", health.Version, health.Uptime)
+			for _, p := range health.Problems {
+				report.detail(p)
+			}
+			report.detail("Stop the conflicting process, or move paw-proxy with:")
+			report.detail("sudo paw-proxy setup --http-port 8080 --https-port 8443")
 			issues++
 		} else {
-			printCheck(true, "Daemon running (v%s, up %s)", health.Version, health.Uptime)
+			report.check("daemon", true, "Daemon running (v%s, up %s)", health.Version, health.Uptime)
 		}
-		resp.Body.Close()
 	}
 
-	// 3. Check DNS resolver (platform-specific)
+	// 3. Check the daemon is installed as a launchd/systemd service
+	if setup.ServiceInstalled() {
+		report.check("service", true, "Daemon service installed")
+	} else {
+		report.check("service", false, "Daemon service not installed")
+		issues++
+		fixes = append(fixes, doctorFix{
+			desc: "Reinstall daemon service",
+			run:  func() error { return setup.RepairService(setupCfg) },
+		})
+	}
+
+	// 4. Check DNS resolver (platform-specific)
 	if ok, msg := doctorCheckDNS(); !ok {
-		printCheck(false, "%s", msg)
+		report.check("dns-resolver", false, "%s", msg)
 		issues++
+		fixes = append(fixes, doctorFix{
+			desc:     "Recreate DNS resolver config",
+			needRoot: true,
+			run:      func() error { return setup.RepairResolver(setupCfg) },
+		})
 	} else {
-		printCheck(true, "%s", msg)
+		report.check("dns-resolver", true, "%s", msg)
 	}
 
-	// 4. Check DNS server reachability on port 9353
+	// 5. Check DNS server reachability on port 9353
 	dnsConn, err := net.DialTimeout("udp", "127.0.0.1:9353", 2*time.Second)
 	if err != nil {
-		printCheck(false, "DNS server not reachable on port 9353")
+		report.check("dns-server", false, "DNS server not reachable on port 9353")
 		issues++
+		restart = true
 	} else {
 		dnsConn.Close()
-		printCheck(true, "DNS server reachable on port 9353")
+		report.check("dns-server", true, "DNS server reachable on port 9353")
 	}
 
-	// 5. Check CA certificate exists, is parseable, and not expired/expiring
+	// 6. Check CA certificate exists, is parseable, and not expired/expiring
+	caOK := false
 	certPath := filepath.Join(config.SupportDir, "ca.crt")
 	certData, err := os.ReadFile(certPath)
 	if err != nil {
-		printCheck(false, "CA certificate not found")
+		report.check("ca", false, "CA certificate not found")
 		issues++
 	} else {
 		block, _ := pem.Decode(certData)
 		if block == nil {
-			printCheck(false, "CA certificate invalid (cannot parse PEM)")
+			report.check("ca", false, "CA certificate invalid (cannot parse PEM)")
 			issues++
 		} else {
 			cert, parseErr := x509.ParseCertificate(block.Bytes)
 			if parseErr != nil {
-				printCheck(false, "CA certificate invalid: %v", parseErr)
+				report.check("ca", false, "CA certificate invalid: %v", parseErr)
 				issues++
 			} else {
 				daysLeft := int(time.Until(cert.NotAfter).Hours() / 24)
 				if daysLeft < 0 {
-					printCheck(false, "CA certificate expired %d days ago", -daysLeft)
+					report.check("ca", false, "CA certificate expired %d days ago", -daysLeft)
 					issues++
 				} else if daysLeft < 30 {
-					printCheck(false, "CA certificate expires in %d days -- re-run setup", daysLeft)
+					report.check("ca", false, "CA certificate expires in %d days -- re-run setup", daysLeft)
 					issues++
+				} else if missing := ssl.Unpermitted(cert, setupCfg.CADomains); len(missing) > 0 {
+					report.check("ca", false, "CA name constraints don't cover .%s", strings.Join(missing, ", ."))
+					issues++
+				} else if len(cert.PermittedDNSDomains) == 0 {
+					report.check("ca", true, "CA certificate valid (expires %s; unconstrained, limit it with: sudo paw-proxy ca rotate)", cert.NotAfter.Format("2006-01-02"))
+					caOK = true
 				} else {
-					printCheck(true, "CA certificate valid (expires %s)", cert.NotAfter.Format("2006-01-02"))
+					report.check("ca", true, "CA certificate valid (expires %s)", cert.NotAfter.Format("2006-01-02"))
+					caOK = true
 				}
 			}
 		}
 	}
+	// A key protected on another machine, or before the machine ID
+	// changed, can't be decrypted.
+	if caOK {
+		if _, err := ssl.LoadCA(certPath, filepath.Join(config.SupportDir, "ca.key")); err != nil {
+			report.check("ca-key", false, "CA key unusable: %v", err)
+			issues++
+			caOK = false
+		}
+	}
+	if !caOK {
+		fixes = append(fixes, doctorFix{
+			desc:     "Regenerate and trust CA certificate",
+			needRoot: true,
+			confirm:  "Regenerate the CA? Browsers must be restarted to trust the new one.",
+			run:      func() error { return setup.RegenerateCA(setupCfg) },
+		})
+		restart = true
+	}
 
-	// 6. Check ports 80 and 443 are listening
+	// 7. Check the combined CA bundle up gives dev servers is current
+	if _, err := trust.SystemBundle(); err == nil && caOK {
+		if trust.CABundleCurrent(config.SupportDir, certPath) {
+			report.check("ca-bundle", true, "CA bundle up to date (%s)", trust.CABundlePath(config.SupportDir))
+		} else {
+			report.check("ca-bundle", false, "CA bundle missing or older than the CA or system roots")
+			issues++
+			fixes = append(fixes, doctorFix{
+				desc: "Rebuild CA bundle",
+				run: func() error {
+					_, err := setup.RefreshCABundle(setupCfg)
+					return err
+				},
+			})
+		}
+	}
+
+	// 8. Check the binary may bind ports 80/443 (Linux only)
+	if applies, ok, msg := doctorCheckCapabilities(setupCfg.BinaryPath); applies {
+		report.check("capabilities", ok, "%s", msg)
+		if !ok {
+			issues++
+			fixes = append(fixes, doctorFix{
+				desc:     "Grant port binding capability",
+				needRoot: true,
+				run:      func() error { return setup.RepairCapabilities(setupCfg.BinaryPath) },
+			})
+			restart = true
+		}
+	}
+
+	// 9. Check the 80/443 firewall redirect when alternative ports are used
+	if pf := setup.PortForwardStatus(); pf.Configured {
+		switch {
+		case !pf.Checked:
+			report.check("port-forward", true, "Port forwarding configured (run with sudo to verify rules are loaded)")
+		case pf.Loaded:
+			report.check("port-forward", true, "Port forwarding rules loaded")
+		default:
+			report.check("port-forward", false, "Port forwarding configured but rules not loaded")
+			issues++
+			fixes = append(fixes, doctorFix{
+				desc:     "Reload port forwarding rules",
+				needRoot: true,
+				run:      setup.RepairPortForward,
+			})
+		}
+	}
+
+	// 10. Check ports 80 and 443 are listening
 	for _, port := range []int{80, 443} {
 		conn, dialErr := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 2*time.Second)
 		if dialErr != nil {
-			printCheck(false, "Port %d not listening", port)
+			report.check(fmt.Sprintf("port-%d", port), false, "Port %d not listening", port)
 			issues++
+			restart = true
 		} else {
 			conn.Close()
-			printCheck(true, "Port %d listening", port)
+			// With the daemon down, whatever answers is someone else.
+			if !daemonUp {
+				if holder := daemon.FindPortHolder(port); holder.PID != 0 {
+					report.check(fmt.Sprintf("port-%d", port), false, "%s", holder)
+					issues++
+					continue
+				}
+			}
+			report.check(fmt.Sprintf("port-%d", port), true, "Port %d listening", port)
+		}
+		// Browsers try ::1 first when DNS returns AAAA records.
+		if dialErr == nil && hasIPv6Loopback() {
+			conn, err := net.DialTimeout("tcp", fmt.Sprintf("[::1]:%d", port), 2*time.Second)
+			if err != nil {
+				report.check(fmt.Sprintf("port-%d-ipv6", port), false, "Port %d not listening on ::1", port)
+				issues++
+				restart = true
+			} else {
+				conn.Close()
+				report.check(fmt.Sprintf("port-%d-ipv6", port), true, "Port %d listening on ::1", port)
+			}
 		}
 	}
 
-	// Summary
-	fmt.Println()
-	if issues == 0 {
-		fmt.Println("All checks passed!")
+	// 11. End-to-end: DNS, TLS trust and SNI, and an HTTP response for a
+	// synthetic name, as a browser would see it.
+	if roots, err := loadCAPool(certPath); err != nil {
+		report.check("end-to-end", false, "End-to-end check skipped: %v", err)
+		issues++
 	} else {
-		fmt.Printf("%d issue(s) found. Try: sudo paw-proxy setup\n", issues)
+		name := "doctor." + config.TLD
+		dnsAddr := fmt.Sprintf("127.0.0.1:%d", config.DNSPort)
+		httpsAddr := fmt.Sprintf("127.0.0.1:%d", config.HTTPSPort)
+		if err := e2eCheck(name, dnsAddr, httpsAddr, roots); err != nil {
+			report.check("end-to-end", false, "End-to-end request to https://%s failed: %v", name, err)
+			issues++
+			restart = true
+		} else {
+			report.check("end-to-end", true, "End-to-end request to https://%s served by paw-proxy", name)
+		}
 	}
-}
 
-func printCheck(ok bool, format string, args ...interface{}) {
-	mark := "✓"
-	if !ok {
-		mark = "✗"
+	// Restart last so it picks up every other fix.
+	if restart {
+		fixes = append(fixes, doctorFix{
+			desc: "Restart daemon",
+			run:  setup.RestartDaemon,
+		})
 	}
-	fmt.Printf("[%s] %s\n", mark, fmt.Sprintf(format, args...))
-}
 
-// cmdLogsTail follows the log file, printing new lines as they appear.
-func cmdLogsTail(path string) {
-	f, err := os.Open(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			fmt.Println("No log file found -- daemon may not have run yet")
-			return
+	if opts.json {
+		if err := report.writeJSON(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
-		fmt.Printf("Error opening log: %v\n", err)
-		os.Exit(1)
+		return
 	}
-	defer f.Close()
 
-	// Seek to end
-	if _, err := f.Seek(0, io.SeekEnd); err != nil {
-		fmt.Printf("Error seeking: %v\n", err)
-		os.Exit(1)
+	// Summary
+	fmt.Println()
+	if issues == 0 {
+		fmt.Println("All checks passed!")
+		return
+	}
+	if !opts.fix {
+		fmt.Printf("%d issue(s) found. Try: sudo paw-proxy doctor --fix\n", issues)
+		return
 	}
 
-	fmt.Println("Following log output (Ctrl+C to stop)...")
+	fmt.Printf("%d issue(s) found. Applying fixes...\n\n", issues)
+	failed := runFixes(os.Stdout, os.Stdin, fixes, os.Geteuid() == 0, opts.yes)
+	fmt.Println()
+	if failed > 0 {
+		fmt.Printf("%d fix(es) did not complete. Re-run with sudo, or try: sudo paw-proxy setup\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("All fixes applied. Run paw-proxy doctor again to confirm.")
+}
 
-	buf := make([]byte, 4096)
-	for {
-		n, err := f.Read(buf)
-		if n > 0 {
-			os.Stdout.Write(buf[:n])
-		}
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				// EOF is normal -- just wait for more data
-				time.Sleep(200 * time.Millisecond)
-				continue
-			}
-			fmt.Printf("Error reading log: %v\n", err)
-			return
-		}
+// loadCAPool returns a pool holding only the CA certificate at certPath.
+func loadCAPool(certPath string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate in %s", certPath)
 	}
+	return pool, nil
 }
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+// routeGroup is a project's routes, or a single route without a project.
+type routeGroup struct {
+	project string
+	routes  []pawclient.Route
+}
+
+// groupRoutes groups routes by project, ordered by project (or route name
+// for routes without one), with each group's routes sorted by name.
+func groupRoutes(routes []pawclient.Route) []routeGroup {
+	var groups []routeGroup
+	byProject := make(map[string]int)
+	for _, r := range routes {
+		if r.Project == "" {
+			groups = append(groups, routeGroup{routes: []pawclient.Route{r}})
+			continue
+		}
+		i, ok := byProject[r.Project]
+		if !ok {
+			i = len(groups)
+			byProject[r.Project] = i
+			groups = append(groups, routeGroup{project: r.Project})
+		}
+		groups[i].routes = append(groups[i].routes, r)
+	}
+	for _, g := range groups {
+		slices.SortFunc(g.routes, func(a, b pawclient.Route) int { return strings.Compare(a.Name, b.Name) })
+	}
+	slices.SortFunc(groups, func(a, b routeGroup) int { return strings.Compare(a.key(), b.key()) })
+	return groups
+}
+
+func (g routeGroup) key() string {
+	if g.project != "" {
+		return g.project
+	}
+	return g.routes[0].Name
+}
+
+// titled reports whether the group is listed under a project heading. A
+// project holding one route of the same name (a plain `up` run) isn't.
+func (g routeGroup) titled() bool {
+	return g.project != "" && (len(g.routes) > 1 || g.routes[0].Name != g.project)
+}
+
+// printRouteGroups writes routes grouped by project, as in status.
+func printRouteGroups(w io.Writer, routes []pawclient.Route) {
+	for _, g := range groupRoutes(routes) {
+		indent := "  "
+		if g.titled() {
+			fmt.Fprintf(w, "  %s:\n", g.project)
+			indent = "    "
+		}
+		for _, r := range g.routes {
+			printRoute(w, r, indent)
+		}
+	}
+}
+
+// printRoute writes one route with its directory and owner, and a warning
+// if it is flapping.
+func printRoute(w io.Writer, r pawclient.Route, indent string) {
+	age := time.Since(r.Registered).Round(time.Second)
+	if r.Preview != "" {
+		fmt.Fprintf(w, "%s• %s.test -> %s [%s] (%s)\n", indent, r.Name, routeTarget(r), r.Preview, age)
+	} else {
+		fmt.Fprintf(w, "%s• %s.test -> %s (%s)\n", indent, r.Name, routeTarget(r), age)
+	}
+	if r.Permanent {
+		fmt.Fprintf(w, "%s  Defined in the config file\n", indent)
+	}
+	if r.Dir != "" || !r.Permanent {
+		fmt.Fprintf(w, "%s  Dir: %s\n", indent, r.Dir)
+	}
+	if r.Owner != nil {
+		fmt.Fprintf(w, "%s  Owner: %s\n", indent, formatOwner(r.Owner))
+	}
+	if r.Balance != nil {
+		mode := r.Balance.Mode
+		if mode == "" {
+			mode = pawclient.BalanceRoundRobin
+		}
+		fmt.Fprintf(w, "%s  Balance (%s): %s\n", indent, mode, strings.Join(r.Balance.Upstreams, ", "))
+	}
+	if r.Churn != nil && r.Churn.Flapping {
+		fmt.Fprintf(w, "%s  ⚠️  Flapping: %d registrations, %d missed heartbeats in the last hour\n",
+			indent, r.Churn.Registrations, r.Churn.MissedHeartbeats)
+	}
+}
+
+// routeTarget is what a route forwards to: its upstream, or the directory
+// a static route serves.
+func routeTarget(r pawclient.Route) string {
+	if r.Static != nil {
+		return r.Static.Root
+	}
+	return r.Upstream
+}
+
+// formatOwner describes the process that registered a route. The user is
+// only named when it isn't the one running status.
+func formatOwner(o *pawclient.PeerCred) string {
+	name := o.Process
+	if name == "" {
+		name = "process"
+	}
+	if o.UID != os.Getuid() {
+		return fmt.Sprintf("%s (pid %d, uid %d)", name, o.PID, o.UID)
+	}
+	return fmt.Sprintf("%s (pid %d)", name, o.PID)
+}
+
+// cmdRoutes lists routes grouped by project, or removes a project's routes
+// with `routes rm --project name`.
+func cmdRoutes() {
+	args := os.Args[2:]
+	remove := len(args) > 0 && args[0] == "rm"
+	if remove {
+		args = args[1:]
+	}
+
+	project := ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--project", "-p":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --project requires a name")
+				os.Exit(1)
+			}
+			i++
+			project = args[i]
+		default:
+			fmt.Printf("Error: unknown argument: %s\n", args[i])
+			fmt.Println("Usage: paw-proxy routes [--project name] | paw-proxy routes rm --project name")
+			os.Exit(1)
+		}
+	}
+	if remove && project == "" {
+		fmt.Println("Error: routes rm requires --project")
+		os.Exit(1)
+	}
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	client := pawclient.New(config.SocketPath)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if remove {
+		removed, err := client.DeregisterProject(ctx, project)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(removed) == 0 {
+			fmt.Printf("No routes in project %s\n", project)
+			return
+		}
+		for _, name := range removed {
+			fmt.Printf("Removed %s.test\n", name)
+		}
+		return
+	}
+
+	routes, err := client.List(ctx)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if project != "" {
+		routes = slices.DeleteFunc(routes, func(r pawclient.Route) bool { return r.Project != project })
+	}
+	if len(routes) == 0 {
+		fmt.Println("Routes: (none)")
+		return
+	}
+	fmt.Println("Routes:")
+	printRouteGroups(os.Stdout, routes)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+func TestGroupRoutes(t *testing.T) {
+	routes := []pawclient.Route{
+		{Name: "web.shop", Project: "shop"},
+		{Name: "blog", Project: "blog"},
+		{Name: "api.shop", Project: "shop"},
+		{Name: "adhoc"},
+	}
+
+	groups := groupRoutes(routes)
+	var got []string
+	for _, g := range groups {
+		var names []string
+		for _, r := range g.routes {
+			names = append(names, r.Name)
+		}
+		got = append(got, g.project+"="+strings.Join(names, ","))
+	}
+	want := []string{"=adhoc", "blog=blog", "shop=api.shop,web.shop"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+
+	if groups[1].titled() {
+		t.Error("single route named after its project should not get a heading")
+	}
+	if !groups[2].titled() {
+		t.Error("multi-route project should get a heading")
+	}
+}
+
+func TestPrintRouteGroups(t *testing.T) {
+	var buf bytes.Buffer
+	printRouteGroups(&buf, []pawclient.Route{
+		{Name: "web.shop", Upstream: "localhost:3000", Dir: "/src/shop", Project: "shop"},
+		{Name: "api.shop", Upstream: "localhost:3001", Dir: "/src/shop", Project: "shop"},
+		{Name: "blog", Upstream: "localhost:4000", Dir: "/src/blog", Project: "blog",
+			Owner: &pawclient.PeerCred{UID: -1, PID: 42, Process: "node"},
+			Churn: &pawclient.RouteChurn{Registrations: 6, Flapping: true}},
+	})
+	out := buf.String()
+
+	for _, want := range []string{
+		"  • blog.test -> localhost:4000",
+		"    Owner: node (pid 42, uid -1)",
+		"    ⚠️  Flapping: 6 registrations, 0 missed heartbeats in the last hour",
+		"  shop:\n    • api.shop.test -> localhost:3001",
+		"      Dir: /src/shop",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	"github.com/alexcatdad/paw-proxy/internal/setup"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+const serviceUsage = "Usage: paw-proxy service install|restart|status"
+
+func cmdService() {
+	if len(os.Args) < 3 {
+		fmt.Println(serviceUsage)
+		os.Exit(1)
+	}
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	setupCfg, err := newSetupConfig(config)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "install":
+		if err := setup.RepairService(setupCfg); err != nil {
+			fmt.Printf("Error: installing service: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Service installed and started (runs %s)\n", setupCfg.BinaryPath)
+	case "restart":
+		if !setup.ServiceInstalled() {
+			fmt.Println("Service not installed. Run: paw-proxy service install")
+			os.Exit(1)
+		}
+		inPlace, err := restartDaemon(config.SocketPath)
+		if err != nil {
+			fmt.Printf("Error: restarting service: %v\n", err)
+			os.Exit(1)
+		}
+		if inPlace {
+			fmt.Println("Service restarted in place; routes and connections kept")
+		} else {
+			fmt.Println("Service restarted")
+		}
+	case "status":
+		state, err := setup.Status()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !printServiceStatus(state, setupCfg.BinaryPath) {
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Error: unknown service command: %s\n", os.Args[2])
+		fmt.Println(serviceUsage)
+		os.Exit(1)
+	}
+}
+
+// printServiceStatus describes state and reports whether it is healthy:
+// installed, running, and pointing at the current binary.
+func printServiceStatus(state setup.ServiceState, currentBinary string) bool {
+	if !state.Installed {
+		fmt.Printf("Service: not installed (%s)\n", state.Path)
+		fmt.Println("Run: paw-proxy service install")
+		return false
+	}
+	fmt.Printf("Service: installed (%s)\n", state.Path)
+
+	healthy := true
+	switch _, err := os.Stat(state.BinaryPath); {
+	case err != nil:
+		fmt.Printf("Binary:  %s (missing)\n", state.BinaryPath)
+		healthy = false
+	case state.BinaryPath != currentBinary:
+		fmt.Printf("Binary:  %s (this binary is %s)\n", state.BinaryPath, currentBinary)
+		healthy = false
+	default:
+		fmt.Printf("Binary:  %s\n", state.BinaryPath)
+	}
+
+	if state.Running {
+		fmt.Printf("State:   running (pid %d)\n", state.PID)
+	} else {
+		fmt.Println("State:   not running")
+		healthy = false
+	}
+
+	if !healthy {
+		fmt.Println("Run: paw-proxy service install  (regenerates the service for this binary)")
+	}
+	return healthy
+}
+
+// restartDaemon restarts the daemon in place if it can, keeping its
+// sockets and routes, and otherwise restarts the service. It reports
+// whether the restart was in place.
+func restartDaemon(socketPath string) (inPlace bool, err error) {
+	if restartInPlace(pawclient.New(socketPath)) == nil {
+		return true, nil
+	}
+	return false, setup.RestartDaemon()
+}
+
+// restartInPlace asks the daemon to restart in place and waits for the
+// new process to answer, which it recognizes by an uptime shorter than
+// the wait.
+func restartInPlace(c *pawclient.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	requested := time.Now()
+	if err := c.Restart(ctx); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if h, err := c.Health(ctx); err == nil {
+			if uptime, err := time.ParseDuration(h.Uptime); err == nil && uptime < time.Since(requested) {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for the restarted daemon: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
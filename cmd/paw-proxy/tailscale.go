@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+const tailscaleUsage = "Usage: paw-proxy tailscale enable <name>... [--port N] | paw-proxy tailscale disable <name>... | paw-proxy tailscale status"
+
+// tailscaleFirstPort is the first HTTPS port handed out to shared routes.
+// 443 is left to whatever else the machine serves on the tailnet.
+const tailscaleFirstPort = 8443
+
+// tailscaleServe is a route shared on the tailnet, as recorded in
+// tailscale.json in the support directory.
+type tailscaleServe struct {
+	Port     int    `json:"port"`
+	Upstream string `json:"upstream"`
+}
+
+// tailscaleState maps route names to how they are shared.
+type tailscaleState map[string]tailscaleServe
+
+func loadTailscaleState(path string) (tailscaleState, error) {
+	state := make(tailscaleState)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return state, nil
+}
+
+func (s tailscaleState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// freePort returns the lowest port from tailscaleFirstPort that no shared
+// route uses.
+func (s tailscaleState) freePort() int {
+	port := tailscaleFirstPort
+	for s.portOwner(port) != "" {
+		port++
+	}
+	return port
+}
+
+// portOwner returns the route shared on port, or "".
+func (s tailscaleState) portOwner(port int) string {
+	for name, ts := range s {
+		if ts.Port == port {
+			return name
+		}
+	}
+	return ""
+}
+
+// tailscaleOptions are the parsed arguments of `paw-proxy tailscale`.
+type tailscaleOptions struct {
+	action string // enable, disable, or status
+	names  []string
+	port   int
+}
+
+func parseTailscaleArgs(args []string) (tailscaleOptions, error) {
+	var opts tailscaleOptions
+	if len(args) == 0 {
+		return opts, errors.New("action required")
+	}
+	opts.action = args[0]
+	switch opts.action {
+	case "enable", "disable", "status":
+	default:
+		return opts, fmt.Errorf("unknown action: %s", opts.action)
+	}
+	for i := 1; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--port" && opts.action == "enable":
+			if i+1 >= len(args) {
+				return opts, errors.New("--port requires a value")
+			}
+			i++
+			port, err := parsePort(args[i])
+			if err != nil {
+				return opts, fmt.Errorf("--port: %w", err)
+			}
+			opts.port = port
+		case strings.HasPrefix(arg, "-") || opts.action == "status":
+			return opts, fmt.Errorf("unknown argument: %s", arg)
+		default:
+			opts.names = append(opts.names, arg)
+		}
+	}
+	if opts.action != "status" && len(opts.names) == 0 {
+		return opts, errors.New("route name required")
+	}
+	if opts.port != 0 && len(opts.names) > 1 {
+		return opts, errors.New("--port takes a single route")
+	}
+	return opts, nil
+}
+
+// parseTailscaleStatus returns this machine's MagicDNS name from
+// `tailscale status --json`.
+func parseTailscaleStatus(data []byte) (string, error) {
+	var status struct {
+		BackendState string
+		Self         struct {
+			DNSName string
+		}
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return "", fmt.Errorf("parsing tailscale status: %w", err)
+	}
+	if status.BackendState != "Running" {
+		return "", fmt.Errorf("tailscale is not connected (state %q); run: tailscale up", status.BackendState)
+	}
+	name := strings.TrimSuffix(status.Self.DNSName, ".")
+	if name == "" {
+		return "", errors.New("this machine has no MagicDNS name; enable MagicDNS for the tailnet")
+	}
+	return name, nil
+}
+
+// runTailscale runs the tailscale CLI, returning its stdout.
+func runTailscale(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("tailscale", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("tailscale %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// tailnetURL is where a route shared on port is reached on the tailnet.
+func tailnetURL(dnsName string, port int) string {
+	if port == 443 {
+		return "https://" + dnsName
+	}
+	return fmt.Sprintf("https://%s:%d", dnsName, port)
+}
+
+// cmdTailscale shares routes on the tailnet with `tailscale serve`, which
+// terminates HTTPS with a Let's Encrypt certificate for the machine's
+// MagicDNS name. Each route gets its own port on that name and is served
+// straight from its upstream.
+func cmdTailscale() {
+	opts, err := parseTailscaleArgs(os.Args[2:])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println(tailscaleUsage)
+		os.Exit(1)
+	}
+	if _, err := exec.LookPath("tailscale"); err != nil {
+		fmt.Println("Error: tailscale not found in PATH")
+		os.Exit(1)
+	}
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	statePath := filepath.Join(config.SupportDir, "tailscale.json")
+	state, err := loadTailscaleState(statePath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	out, err := runTailscale("status", "--json")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	dnsName, err := parseTailscaleStatus(out)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	routes := make(map[string]pawclient.Route)
+	if opts.action != "disable" {
+		list, err := pawclient.New(config.SocketPath).List(context.Background())
+		if err != nil {
+			fmt.Println("Error: paw-proxy daemon not running")
+			os.Exit(1)
+		}
+		for _, r := range list {
+			routes[r.Name] = r
+		}
+	}
+
+	failed := false
+	switch opts.action {
+	case "enable":
+		for _, name := range opts.names {
+			r, ok := routes[name]
+			if !ok {
+				fmt.Printf("✗ %s: route not found\n", name)
+				failed = true
+				continue
+			}
+			if r.Passthrough || r.UDP || r.Static != nil {
+				fmt.Printf("✗ %s: only HTTP routes with an upstream can be shared\n", name)
+				failed = true
+				continue
+			}
+			port := opts.port
+			if port == 0 {
+				// Sharing again keeps the port and points it at the
+				// route's current upstream.
+				port = state[name].Port
+			}
+			if port == 0 {
+				port = state.freePort()
+			}
+			if owner := state.portOwner(port); owner != "" && owner != name {
+				fmt.Printf("✗ %s: port %d is already sharing %s\n", name, port, owner)
+				failed = true
+				continue
+			}
+			if old, ok := state[name]; ok && old.Port != port {
+				runTailscale("serve", "--https="+strconv.Itoa(old.Port), "off") //nolint:errcheck // best effort
+			}
+			if _, err := runTailscale("serve", "--bg", "--https="+strconv.Itoa(port), "http://"+r.Upstream); err != nil {
+				fmt.Printf("✗ %s: %v\n", name, err)
+				failed = true
+				continue
+			}
+			state[name] = tailscaleServe{Port: port, Upstream: r.Upstream}
+			fmt.Printf("+ %s -> %s.%s (%s)\n", tailnetURL(dnsName, port), name, config.TLD, r.Upstream)
+		}
+	case "disable":
+		for _, name := range opts.names {
+			ts, ok := state[name]
+			if !ok {
+				fmt.Printf("✗ %s: not shared\n", name)
+				failed = true
+				continue
+			}
+			if _, err := runTailscale("serve", "--https="+strconv.Itoa(ts.Port), "off"); err != nil {
+				fmt.Printf("✗ %s: %v\n", name, err)
+				failed = true
+				continue
+			}
+			delete(state, name)
+			fmt.Printf("- %s (%s.%s)\n", tailnetURL(dnsName, ts.Port), name, config.TLD)
+		}
+	case "status":
+		if len(state) == 0 {
+			fmt.Println("No routes shared on the tailnet")
+		}
+		names := make([]string, 0, len(state))
+		for name := range state {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			ts := state[name]
+			note := ""
+			if r, ok := routes[name]; !ok {
+				note = " [route not registered]"
+			} else if r.Upstream != ts.Upstream {
+				note = fmt.Sprintf(" [stale: route now at %s; run: paw-proxy tailscale enable %s]", r.Upstream, name)
+			}
+			fmt.Printf("  • %s -> %s.%s (%s)%s\n", tailnetURL(dnsName, ts.Port), name, config.TLD, ts.Upstream, note)
+		}
+		return
+	}
+
+	if err := state.save(statePath); err != nil {
+		fmt.Printf("Error: saving %s: %v\n", statePath, err)
+		os.Exit(1)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseTailscaleArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    tailscaleOptions
+		wantErr bool
+	}{
+		{args: []string{"enable", "myapp"}, want: tailscaleOptions{action: "enable", names: []string{"myapp"}}},
+		{args: []string{"enable", "myapp", "api"}, want: tailscaleOptions{action: "enable", names: []string{"myapp", "api"}}},
+		{args: []string{"enable", "--port", "9443", "myapp"}, want: tailscaleOptions{action: "enable", names: []string{"myapp"}, port: 9443}},
+		{args: []string{"disable", "myapp"}, want: tailscaleOptions{action: "disable", names: []string{"myapp"}}},
+		{args: []string{"status"}, want: tailscaleOptions{action: "status"}},
+		{args: []string{}, wantErr: true},
+		{args: []string{"share", "myapp"}, wantErr: true},
+		{args: []string{"enable"}, wantErr: true},
+		{args: []string{"enable", "myapp", "--port"}, wantErr: true},
+		{args: []string{"enable", "myapp", "--port", "0"}, wantErr: true},
+		{args: []string{"enable", "myapp", "api", "--port", "9443"}, wantErr: true},
+		{args: []string{"disable", "myapp", "--port", "9443"}, wantErr: true},
+		{args: []string{"status", "myapp"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTailscaleArgs(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTailscaleArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (got.action != tt.want.action || got.port != tt.want.port || !slices.Equal(got.names, tt.want.names)) {
+			t.Errorf("parseTailscaleArgs(%q) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseTailscaleStatus(t *testing.T) {
+	name, err := parseTailscaleStatus([]byte(`{"BackendState":"Running","Self":{"DNSName":"laptop.tail1234.ts.net."}}`))
+	if err != nil || name != "laptop.tail1234.ts.net" {
+		t.Errorf("parseTailscaleStatus = %q, %v; want laptop.tail1234.ts.net", name, err)
+	}
+	if _, err := parseTailscaleStatus([]byte(`{"BackendState":"Stopped","Self":{"DNSName":"laptop.tail1234.ts.net."}}`)); err == nil {
+		t.Error("parseTailscaleStatus accepted a stopped backend")
+	}
+	if _, err := parseTailscaleStatus([]byte(`{"BackendState":"Running","Self":{}}`)); err == nil {
+		t.Error("parseTailscaleStatus accepted a machine without a MagicDNS name")
+	}
+}
+
+func TestTailscaleState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tailscale.json")
+	state, err := loadTailscaleState(path)
+	if err != nil || len(state) != 0 {
+		t.Fatalf("loadTailscaleState(missing) = %v, %v", state, err)
+	}
+	if got := state.freePort(); got != tailscaleFirstPort {
+		t.Errorf("freePort() = %d, want %d", got, tailscaleFirstPort)
+	}
+
+	state["myapp"] = tailscaleServe{Port: 8443, Upstream: "localhost:3000"}
+	state["api"] = tailscaleServe{Port: 8445, Upstream: "localhost:4000"}
+	if got := state.freePort(); got != 8444 {
+		t.Errorf("freePort() = %d, want 8444", got)
+	}
+	if got := state.portOwner(8445); got != "api" {
+		t.Errorf("portOwner(8445) = %q, want api", got)
+	}
+
+	if err := state.save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadTailscaleState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded["myapp"] != state["myapp"] || loaded["api"] != state["api"] {
+		t.Errorf("loaded state = %v, want %v", loaded, state)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+const tapUsage = "Usage: paw-proxy tap <name> [--out file] [--bodies bytes]"
+
+// tapOptions are the parsed arguments of `paw-proxy tap`.
+type tapOptions struct {
+	name   string
+	out    string // "" writes to stdout
+	bodies int
+}
+
+func parseTapArgs(args []string) (tapOptions, error) {
+	var opts tapOptions
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--out", "-o", "--bodies":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("%s requires a value", arg)
+			}
+			i++
+			if arg == "--bodies" {
+				n, err := strconv.Atoi(args[i])
+				if err != nil || n < 0 || n > api.MaxCaptureBody {
+					return opts, fmt.Errorf("--bodies must be 0-%d bytes", api.MaxCaptureBody)
+				}
+				opts.bodies = n
+			} else {
+				opts.out = args[i]
+			}
+		default:
+			if opts.name != "" || len(arg) > 0 && arg[0] == '-' {
+				return opts, fmt.Errorf("unknown argument: %s", arg)
+			}
+			opts.name = arg
+		}
+	}
+	if opts.name == "" {
+		return opts, fmt.Errorf("route name required")
+	}
+	return opts, nil
+}
+
+// cmdTap writes a route's requests as ndjson until interrupted.
+func cmdTap() {
+	opts, err := parseTapArgs(os.Args[2:])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println(tapUsage)
+		os.Exit(1)
+	}
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Progress goes to stderr when the captures themselves go to stdout.
+	var out io.Writer = os.Stdout
+	progress := io.Writer(os.Stderr)
+	if opts.out != "" {
+		f, err := os.Create(opts.out)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+		progress = os.Stdout
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(progress, "Tapping %s.test (Ctrl-C to stop)\n", opts.name)
+	enc := json.NewEncoder(out)
+	count := 0
+	var writeErr error
+	err = pawclient.New(config.SocketPath).Capture(ctx, opts.name, opts.bodies, func(c pawclient.Capture) {
+		if writeErr != nil {
+			return
+		}
+		if writeErr = enc.Encode(c); writeErr != nil {
+			stop()
+			return
+		}
+		count++
+		if opts.out != "" {
+			fmt.Fprintln(progress, tapLine(c))
+		}
+	})
+	switch {
+	case writeErr != nil:
+		fmt.Printf("Error: writing capture: %v\n", writeErr)
+		os.Exit(1)
+	case pawclient.IsNotFound(err):
+		fmt.Printf("Error: no route named %s\n", opts.name)
+		os.Exit(1)
+	case pawclient.IsUnavailable(err) && count == 0:
+		fmt.Println("Error: paw-proxy daemon not running")
+		os.Exit(1)
+	case err != nil && !pawclient.IsUnavailable(err):
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(progress, "%d requests captured\n", count)
+}
+
+// tapLine summarizes a capture for the progress output.
+func tapLine(c pawclient.Capture) string {
+	return fmt.Sprintf("%s %s %s → %d (%.1fms)", c.Time.Format("15:04:05"), c.Method, c.URL, c.Status, c.DurationMs)
+}
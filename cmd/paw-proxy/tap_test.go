@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestParseTapArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    tapOptions
+		wantErr bool
+	}{
+		{args: []string{"myapp"}, want: tapOptions{name: "myapp"}},
+		{args: []string{"myapp", "-o", "tap.ndjson", "--bodies", "4096"}, want: tapOptions{name: "myapp", out: "tap.ndjson", bodies: 4096}},
+		{args: []string{"--out", "tap.ndjson", "myapp"}, want: tapOptions{name: "myapp", out: "tap.ndjson"}},
+		{args: []string{}, wantErr: true},
+		{args: []string{"myapp", "other"}, wantErr: true},
+		{args: []string{"myapp", "--out"}, wantErr: true},
+		{args: []string{"myapp", "--bodies", "-1"}, wantErr: true},
+		{args: []string{"myapp", "--bodies", "2097152"}, wantErr: true},
+		{args: []string{"myapp", "--verbose"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTapArgs(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTapArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseTapArgs(%q) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
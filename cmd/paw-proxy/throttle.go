@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+const throttleUsage = "Usage: paw-proxy throttle <name> [slow-3g|3g|dsl|down=KBPS,up=KBPS,latency=DURATION|off]"
+
+// cmdThrottle shows or changes how much a route's traffic is slowed down.
+func cmdThrottle() {
+	args := os.Args[2:]
+	if len(args) < 1 || len(args) > 2 || strings.HasPrefix(args[0], "-") {
+		fmt.Println(throttleUsage)
+		os.Exit(1)
+	}
+	name := args[0]
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	client := pawclient.New(config.SocketPath)
+	ctx := context.Background()
+
+	if len(args) == 1 {
+		routes, err := client.List(ctx)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, route := range routes {
+			if route.Name == name {
+				fmt.Println(throttleLine(name, route.Throttle))
+				return
+			}
+		}
+		fmt.Printf("Error: no route named %s\n", name)
+		os.Exit(1)
+	}
+
+	throttle, err := api.ParseThrottle(args[1])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	switch err := client.SetThrottle(ctx, name, throttle); {
+	case pawclient.IsNotFound(err):
+		fmt.Printf("Error: no route named %s\n", name)
+		os.Exit(1)
+	case pawclient.IsUnavailable(err):
+		fmt.Println("Error: paw-proxy daemon not running")
+		os.Exit(1)
+	case err != nil:
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(throttleLine(name, throttle))
+}
+
+func throttleLine(name string, throttle *api.ThrottleConfig) string {
+	if throttle == nil {
+		return name + ".test: not throttled"
+	}
+	return name + ".test: throttled to " + throttle.String()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+// trayRefresh is how often `tray --stream` redraws without a route event,
+// to keep the health line current.
+const trayRefresh = time.Minute
+
+// trayRetry is how long `tray --stream` waits before reconnecting to a
+// daemon that is down.
+const trayRetry = 5 * time.Second
+
+// trayState is what the tray menu shows.
+type trayState struct {
+	// health is nil when the daemon is unreachable.
+	health *pawclient.Health
+	routes []pawclient.Route
+}
+
+// fetchTrayState reads the daemon's health and routes.
+func fetchTrayState(ctx context.Context, client *pawclient.Client) trayState {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	health, err := client.Health(ctx)
+	if err != nil {
+		return trayState{}
+	}
+	routes, _ := client.List(ctx)
+	return trayState{health: &health, routes: routes}
+}
+
+// renderTray writes st as an xbar/SwiftBar/Argos menu: a title line, then
+// menu items after "---". self is the paw-proxy binary run by menu actions.
+func renderTray(w io.Writer, st trayState, tld, self string) {
+	if st.health == nil {
+		fmt.Fprintln(w, "🐾 off")
+		fmt.Fprintln(w, "---")
+		fmt.Fprintln(w, "paw-proxy daemon not running | color=red")
+		fmt.Fprintln(w, "Run: sudo paw-proxy setup | disabled=true")
+		fmt.Fprintln(w, "---")
+		fmt.Fprintln(w, "Refresh | refresh=true")
+		return
+	}
+
+	title := "🐾 " + strconv.Itoa(len(st.routes))
+	if st.health.Degraded() {
+		title = "🐾 ⚠️ " + strconv.Itoa(len(st.routes))
+	}
+	fmt.Fprintln(w, title)
+	fmt.Fprintln(w, "---")
+	if st.health.Degraded() {
+		fmt.Fprintf(w, "paw-proxy %s: degraded, up %s | color=orange\n", st.health.Version, st.health.Uptime)
+		for _, p := range st.health.Problems {
+			fmt.Fprintf(w, "⚠️ %s | color=orange\n", p)
+		}
+	} else {
+		fmt.Fprintf(w, "paw-proxy %s: running, up %s\n", st.health.Version, st.health.Uptime)
+	}
+
+	fmt.Fprintln(w, "---")
+	if len(st.routes) == 0 {
+		fmt.Fprintln(w, "No routes | disabled=true")
+	}
+	for _, g := range groupRoutes(st.routes) {
+		indent := ""
+		if g.titled() {
+			fmt.Fprintf(w, "%s | disabled=true\n", g.project)
+			indent = "  "
+		}
+		for _, r := range g.routes {
+			label := r.Name + "." + tld + " → " + r.Upstream
+			if r.Preview != "" {
+				label += " [" + r.Preview + "]"
+			}
+			fmt.Fprintf(w, "%s%s | href=https://%s.%s\n", indent, label, r.Name, tld)
+		}
+	}
+
+	fmt.Fprintln(w, "---")
+	fmt.Fprintf(w, "Open dashboard | bash=%q param1=dashboard param2=open terminal=false\n", self)
+	fmt.Fprintln(w, "Refresh | refresh=true")
+}
+
+// cmdTray prints the menu bar / tray menu once, or with --stream redraws
+// it on every route change for SwiftBar's streamable plugins.
+func cmdTray() {
+	stream := false
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--stream":
+			stream = true
+		default:
+			fmt.Printf("Error: unknown flag: %s\n", arg)
+			fmt.Println("Usage: paw-proxy tray [--stream]")
+			os.Exit(1)
+		}
+	}
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		self = "paw-proxy"
+	}
+	client := pawclient.New(config.SocketPath)
+	ctx := context.Background()
+
+	if !stream {
+		renderTray(os.Stdout, fetchTrayState(ctx, client), config.TLD, self)
+		return
+	}
+	streamTray(ctx, os.Stdout, client, config.TLD, self)
+}
+
+// streamTray redraws the menu, each preceded by SwiftBar's "~~~"
+// separator, whenever the event stream reports a change, every
+// trayRefresh, and when the daemon goes away or comes back.
+func streamTray(ctx context.Context, w io.Writer, client *pawclient.Client, tld, self string) {
+	changed := make(chan struct{}, 1)
+	ended := make(chan struct{})
+	follow := func() {
+		client.Events(ctx, func(pawclient.RouteEvent) {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+		ended <- struct{}{}
+	}
+
+	draw := func() {
+		fmt.Fprintln(w, "~~~")
+		renderTray(w, fetchTrayState(ctx, client), tld, self)
+	}
+
+	draw()
+	go follow()
+	ticker := time.NewTicker(trayRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+			draw()
+		case <-ticker.C:
+			draw()
+		case <-ended:
+			// The daemon stopped or restarted: show it, then reconnect.
+			draw()
+			time.Sleep(trayRetry)
+			draw()
+			go follow()
+		}
+	}
+}
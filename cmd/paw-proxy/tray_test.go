@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+func TestRenderTray(t *testing.T) {
+	var buf bytes.Buffer
+	renderTray(&buf, trayState{
+		health: &pawclient.Health{Status: "ok", Version: "1.2.3", Uptime: "3h"},
+		routes: []pawclient.Route{
+			{Name: "web.shop", Upstream: "localhost:3000", Project: "shop"},
+			{Name: "api.shop", Upstream: "localhost:3001", Project: "shop"},
+			{Name: "pr-1.blog", Upstream: "localhost:4000", Preview: "pr-1"},
+		},
+	}, "test", "/usr/local/bin/paw-proxy")
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "🐾 3\n---\n") {
+		t.Errorf("unexpected title:\n%s", out)
+	}
+	for _, want := range []string{
+		"paw-proxy 1.2.3: running, up 3h\n",
+		"shop | disabled=true\n  api.shop.test → localhost:3001 | href=https://api.shop.test\n",
+		"pr-1.blog.test → localhost:4000 [pr-1] | href=https://pr-1.blog.test\n",
+		`Open dashboard | bash="/usr/local/bin/paw-proxy" param1=dashboard param2=open terminal=false`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderTrayDegradedAndDown(t *testing.T) {
+	var buf bytes.Buffer
+	renderTray(&buf, trayState{
+		health: &pawclient.Health{Status: "degraded", Version: "1.2.3", Uptime: "1m", Problems: []string{"port 443 in use"}},
+	}, "test", "paw-proxy")
+	out := buf.String()
+	if !strings.HasPrefix(out, "🐾 ⚠️ 0\n") || !strings.Contains(out, "⚠️ port 443 in use | color=orange") || !strings.Contains(out, "No routes") {
+		t.Errorf("unexpected degraded menu:\n%s", out)
+	}
+
+	buf.Reset()
+	renderTray(&buf, trayState{}, "test", "paw-proxy")
+	if out := buf.String(); !strings.HasPrefix(out, "🐾 off\n") || !strings.Contains(out, "not running") {
+		t.Errorf("unexpected menu for a stopped daemon:\n%s", out)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	"github.com/alexcatdad/paw-proxy/internal/trust"
+)
+
+const trustUsage = "Usage: paw-proxy trust java [--print] [--remove] | paw-proxy trust python [--remove]"
+
+// cmdTrust makes the JVM or Python trust the paw-proxy CA.
+func cmdTrust() {
+	if len(os.Args) < 3 {
+		fmt.Println(trustUsage)
+		os.Exit(1)
+	}
+	printFlags, remove := false, false
+	for _, arg := range os.Args[3:] {
+		switch arg {
+		case "--print":
+			printFlags = true
+		case "--remove":
+			remove = true
+		default:
+			fmt.Printf("Error: unknown flag: %s\n", arg)
+			fmt.Println(trustUsage)
+			os.Exit(1)
+		}
+	}
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	caPath := filepath.Join(config.SupportDir, "ca.crt")
+	if !remove {
+		if _, err := os.Stat(caPath); err != nil {
+			fmt.Printf("Error: CA not found at %s\n", caPath)
+			fmt.Println("Run: sudo paw-proxy setup")
+			os.Exit(1)
+		}
+	}
+
+	switch os.Args[2] {
+	case "java":
+		err = trustJava(config.SupportDir, caPath, printFlags, remove)
+	case "python":
+		if printFlags {
+			fmt.Println("Error: --print is only for java")
+			os.Exit(1)
+		}
+		err = trustPython(config.SupportDir, caPath, remove)
+	default:
+		fmt.Printf("Error: unknown runtime: %s\n", os.Args[2])
+		fmt.Println(trustUsage)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// trustJava imports the CA into the JDK's cacerts or, with printFlags, into
+// a copy in the support directory and prints the flags that select it.
+func trustJava(supportDir, caPath string, printFlags, remove bool) error {
+	home, err := trust.JavaHome()
+	if err != nil {
+		return err
+	}
+	store := trust.JavaTrustStorePath(supportDir)
+
+	if remove {
+		if err := os.Remove(store); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		cacerts, err := trust.Cacerts(home)
+		if err != nil {
+			return err
+		}
+		if err := trust.RemoveJava(home, cacerts); err != nil {
+			return fmt.Errorf("removing CA from %s: %w", cacerts, err)
+		}
+		fmt.Printf("✓ Removed CA from %s\n", cacerts)
+		return nil
+	}
+
+	if printFlags {
+		if err := trust.WriteJavaTrustStore(home, caPath, store); err != nil {
+			return err
+		}
+		flags := trust.JavaFlags(store)
+		fmt.Printf("✓ Wrote %s (JDK cacerts plus the paw-proxy CA)\n", store)
+		fmt.Println("")
+		fmt.Println("Pass these JVM options:")
+		fmt.Printf("  %s\n", flags)
+		fmt.Println("")
+		fmt.Println("Or for every JVM in this shell:")
+		fmt.Printf("  export JAVA_TOOL_OPTIONS='%s'\n", flags)
+		return nil
+	}
+
+	cacerts, err := trust.Cacerts(home)
+	if err != nil {
+		return err
+	}
+	if err := trust.ImportJava(home, cacerts, caPath); err != nil {
+		fmt.Printf("Could not update %s: %v\n", cacerts, err)
+		fmt.Println("Run with sudo, or use a private truststore: paw-proxy trust java --print")
+		return fmt.Errorf("importing CA failed")
+	}
+	fmt.Printf("✓ CA imported into %s (alias %s)\n", cacerts, trust.JavaAlias)
+	fmt.Println("Re-run after upgrading the JDK; new JDKs ship a fresh cacerts.")
+	return nil
+}
+
+// trustPython builds the certifi bundle copy that `up` exports to dev
+// servers, or removes it.
+func trustPython(supportDir, caPath string, remove bool) error {
+	bundle := trust.PythonBundlePath(supportDir)
+	if remove {
+		if err := os.Remove(bundle); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		fmt.Printf("✓ Removed %s\n", bundle)
+		return nil
+	}
+
+	base, err := trust.PythonBase()
+	if err != nil {
+		return err
+	}
+	if err := trust.WriteBundle(base, caPath, bundle); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Wrote %s (%s plus the paw-proxy CA)\n", bundle, base)
+	fmt.Println("")
+	fmt.Println("up now sets REQUESTS_CA_BUNDLE and SSL_CERT_FILE to it for dev servers.")
+	fmt.Println("For other shells:")
+	fmt.Printf("  export REQUESTS_CA_BUNDLE=%q SSL_CERT_FILE=%q\n", bundle, bundle)
+	fmt.Println("Re-run after upgrading certifi to pick up its new roots.")
+	return nil
+}
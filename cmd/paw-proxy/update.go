@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
+	"github.com/alexcatdad/paw-proxy/internal/setup"
+	"github.com/alexcatdad/paw-proxy/internal/update"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+// managedByHomebrew reports whether path is inside a Homebrew prefix, where
+// `brew upgrade` must be used so brew's records stay correct.
+func managedByHomebrew(path string) bool {
+	return strings.Contains(path, "/Cellar/") || strings.HasPrefix(path, "/opt/homebrew/")
+}
+
+func cmdUpdate() {
+	checkOnly, force := false, false
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--check":
+			checkOnly = true
+		case "--force":
+			force = true
+		default:
+			fmt.Printf("Error: unknown flag: %s\n", arg)
+			fmt.Println("Usage: paw-proxy update [--check] [--force]")
+			os.Exit(1)
+		}
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fmt.Printf("Error: cannot determine binary path: %v\n", err)
+		os.Exit(1)
+	}
+	if managedByHomebrew(exe) {
+		fmt.Println("paw-proxy was installed with Homebrew. Update with:")
+		fmt.Println("  brew upgrade paw-proxy")
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	client := &update.Client{HTTP: &http.Client{Timeout: 2 * time.Minute}}
+
+	rel, err := client.Latest(ctx)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !update.Newer(rel.TagName, version) && !force {
+		fmt.Printf("paw-proxy %s is up to date (latest: %s)\n", version, rel.TagName)
+		if version == "dev" {
+			fmt.Println("This is a development build; use --force to install the release.")
+		}
+		return
+	}
+	if checkOnly {
+		fmt.Printf("Update available: %s -> %s\n", version, rel.TagName)
+		fmt.Println("Run: sudo paw-proxy update")
+		return
+	}
+
+	// Update paw-proxy, plus `up` when it is installed alongside.
+	targets := map[string]string{"paw-proxy": exe}
+	if upPath := filepath.Join(filepath.Dir(exe), "up"); fileExists(upPath) {
+		targets["up"] = upPath
+	}
+
+	fmt.Printf("Updating %s -> %s\n", version, rel.TagName)
+	for _, binary := range []string{"paw-proxy", "up"} {
+		path, ok := targets[binary]
+		if !ok {
+			continue
+		}
+		name := update.AssetName(binary, runtime.GOOS, runtime.GOARCH)
+		data, err := client.Download(ctx, rel, name)
+		if err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			os.Exit(1)
+		}
+		if err := update.Replace(path, data); err != nil {
+			fmt.Printf("  ✗ %v\n", err)
+			if os.IsPermission(err) || strings.Contains(err.Error(), "permission denied") {
+				fmt.Println("Run: sudo paw-proxy update")
+			}
+			os.Exit(1)
+		}
+		fmt.Printf("  ✓ %s (checksum verified)\n", path)
+	}
+
+	// Replacing the binary clears its file capabilities on Linux, which
+	// would leave the daemon unable to bind ports 80/443.
+	if runtime.GOOS == "linux" && !setup.UsesSocketActivation() {
+		if err := setup.RepairCapabilities(exe); err != nil {
+			fmt.Printf("  ✗ restoring port binding capability: %v\n", err)
+			fmt.Println("Run: sudo paw-proxy doctor --fix")
+			os.Exit(1)
+		}
+		fmt.Println("  ✓ cap_net_bind_service restored")
+	}
+
+	config, err := daemon.DefaultConfig()
+	if err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		os.Exit(1)
+	}
+	if setup.ServiceInstalled() {
+		inPlace, err := restartDaemon(config.SocketPath)
+		if err != nil {
+			fmt.Printf("  ✗ restarting daemon: %v\n", err)
+			os.Exit(1)
+		}
+		if inPlace {
+			fmt.Println("  ✓ daemon restarted in place (routes kept)")
+		} else {
+			fmt.Println("  ✓ daemon restarted")
+		}
+	} else if restartInPlace(pawclient.New(config.SocketPath)) == nil {
+		fmt.Println("  ✓ running daemon restarted in place (routes kept)")
+	}
+	fmt.Printf("Updated to %s\n", rel.TagName)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
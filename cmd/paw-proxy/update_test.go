@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestManagedByHomebrew(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/opt/homebrew/bin/paw-proxy", true},
+		{"/usr/local/Cellar/paw-proxy/1.2.3/bin/paw-proxy", true},
+		{"/usr/local/bin/paw-proxy", false},
+		{"/home/dev/.local/bin/paw-proxy", false},
+	}
+	for _, tt := range tests {
+		if got := managedByHomebrew(tt.path); got != tt.want {
+			t.Errorf("managedByHomebrew(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+// watchInterval is how often `status --watch` redraws without a route
+// change.
+const watchInterval = time.Second
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+// watchStatus redraws the daemon status and its routes with their request
+// counters every watchInterval, and as soon as the event stream reports a
+// route change, until interrupted. A daemon that isn't running is shown as
+// such until it comes up.
+func watchStatus(client *pawclient.Client) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	changed := make(chan struct{}, 1)
+	go followRouteEvents(ctx, client, changed)
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		reqCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		report := collectStatus(reqCtx, client, "")
+		var stats map[string]pawclient.RouteStats
+		if report.Running {
+			// Older daemons have no /stats; their counters stay blank.
+			stats, _ = client.Stats(reqCtx)
+		}
+		cancel()
+
+		var buf bytes.Buffer
+		buf.WriteString(clearScreen)
+		renderWatch(&buf, report, stats, time.Now())
+		os.Stdout.Write(buf.Bytes())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-changed:
+		}
+	}
+}
+
+// followRouteEvents signals changed for each route event, reconnecting
+// after the daemon restarts, until ctx is done.
+func followRouteEvents(ctx context.Context, client *pawclient.Client, changed chan<- struct{}) {
+	for {
+		client.Events(ctx, func(pawclient.RouteEvent) {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// renderWatch writes one frame of `status --watch`.
+func renderWatch(w io.Writer, report statusReport, stats map[string]pawclient.RouteStats, now time.Time) {
+	switch {
+	case !report.Running:
+		fmt.Fprintln(w, "Status: ❌ Daemon not running")
+	case report.Status == "degraded":
+		fmt.Fprintf(w, "Status: ⚠️  Degraded (v%s, up %s)\n", report.Version, report.Uptime)
+		for _, p := range report.Problems {
+			fmt.Fprintf(w, "  - %s\n", p)
+		}
+	default:
+		fmt.Fprintf(w, "Status: ✅ Running (v%s, up %s)\n", report.Version, report.Uptime)
+	}
+	fmt.Fprintf(w, "Updated %s (Ctrl-C to stop)\n\n", now.Format("15:04:05"))
+
+	if len(report.Routes) == 0 {
+		fmt.Fprintln(w, "Routes: (none)")
+		return
+	}
+	routes := slices.Clone(report.Routes)
+	slices.SortFunc(routes, func(a, b pawclient.Route) int { return strings.Compare(a.Name, b.Name) })
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ROUTE\tTARGET\tREQUESTS\tERRORS\tAVG\tAGE")
+	for _, r := range routes {
+		name := r.Name + ".test"
+		if r.Preview != "" {
+			name += " [" + r.Preview + "]"
+		}
+		if r.Paused {
+			name += " (paused)"
+		} else if r.InMaintenance(time.Now()) {
+			name += " (maintenance)"
+		}
+		reqs, errs, avg := "-", "-", "-"
+		if s, ok := stats[r.Name]; ok {
+			reqs = fmt.Sprint(s.Requests)
+			errs = fmt.Sprint(s.Errors)
+			avg = fmt.Sprintf("%dms", s.AvgMs)
+		} else if stats != nil {
+			reqs, errs = "0", "0"
+		}
+		age := now.Sub(r.Registered).Round(time.Second)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", name, routeTarget(r), reqs, errs, avg, age)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+func TestRenderWatch(t *testing.T) {
+	now := time.Date(2026, 10, 18, 14, 2, 11, 0, time.UTC)
+	report := statusReport{
+		Running: true,
+		Status:  "ok",
+		Version: "1.2.0",
+		Uptime:  "3h0m0s",
+		Routes: []pawclient.Route{
+			{Name: "web", Upstream: "localhost:3000", Registered: now.Add(-time.Minute)},
+			{Name: "api", Upstream: "localhost:3001", Registered: now.Add(-2 * time.Minute), Paused: true},
+		},
+	}
+	stats := map[string]pawclient.RouteStats{"web": {Requests: 12, Errors: 1, AvgMs: 4}}
+
+	var buf bytes.Buffer
+	renderWatch(&buf, report, stats, now)
+	lines := strings.Split(buf.String(), "\n")
+	if lines[0] != "Status: ✅ Running (v1.2.0, up 3h0m0s)" || lines[1] != "Updated 14:02:11 (Ctrl-C to stop)" {
+		t.Errorf("header = %q", lines[:2])
+	}
+	// Sorted by name; a route without requests counts 0 when the daemon
+	// reports stats.
+	for i, want := range [][]string{
+		{"ROUTE", "TARGET", "REQUESTS", "ERRORS", "AVG", "AGE"},
+		{"api.test", "(paused)", "localhost:3001", "0", "0", "-", "2m0s"},
+		{"web.test", "localhost:3000", "12", "1", "4ms", "1m0s"},
+	} {
+		if got := strings.Fields(lines[3+i]); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("row %d = %q, want %q", i, got, want)
+		}
+	}
+
+	buf.Reset()
+	renderWatch(&buf, statusReport{}, nil, now)
+	if !strings.HasPrefix(buf.String(), "Status: ❌ Daemon not running\n") || !strings.Contains(buf.String(), "Routes: (none)") {
+		t.Errorf("stopped daemon frame = %q", buf.String())
+	}
+}
@@ -3,18 +3,22 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/alexcatdad/paw-proxy/internal/notification"
+	"github.com/alexcatdad/paw-proxy/internal/trust"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
 )
 
 // composeDetection holds the result of scanning args for Docker Compose mode.
@@ -57,7 +61,9 @@ type composeConfig struct {
 }
 
 type composeService struct {
-	Ports []composePort `json:"ports"`
+	Ports  []composePort     `json:"ports"`
+	Labels map[string]string `json:"labels"`
+	XPaw   *composeXPaw      `json:"x-paw"`
 }
 
 type composePort struct {
@@ -66,10 +72,76 @@ type composePort struct {
 	Protocol  string `json:"protocol"`
 }
 
-// discoveredService represents a Docker Compose service with a published port.
+// composeXPaw is the optional x-paw extension on a compose service:
+//
+//	x-paw:
+//	  domain: shop.test        # hostname for the first published port
+//	  ports:
+//	    "9229": debug.shop.test # hostname for another port, by target
+type composeXPaw struct {
+	Domain string            `json:"domain"`
+	Ports  map[string]string `json:"ports"`
+}
+
+// Service labels equivalent to x-paw: paw-proxy.domain, and
+// paw-proxy.domain.<target> for additional ports. x-paw wins when both
+// are set.
+const composeDomainLabel = "paw-proxy.domain"
+
+// domainFor returns the hostname configured for the port with the given
+// target, or "" when the service doesn't choose one. The first published
+// port also honors the service-wide domain.
+func (svc composeService) domainFor(target int, first bool) string {
+	key := strconv.Itoa(target)
+	if svc.XPaw != nil {
+		if d := svc.XPaw.Ports[key]; d != "" {
+			return d
+		}
+		if first && svc.XPaw.Domain != "" {
+			return svc.XPaw.Domain
+		}
+	}
+	if d := svc.Labels[composeDomainLabel+"."+key]; d != "" {
+		return d
+	}
+	if first {
+		return svc.Labels[composeDomainLabel]
+	}
+	return ""
+}
+
+// composeDomainRouteName turns a configured hostname such as "shop.test"
+// or "api.shop" into a route name.
+func composeDomainRouteName(domain string) (string, error) {
+	name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	name = strings.TrimSuffix(name, ".test")
+	if name == "" {
+		return "", fmt.Errorf("invalid domain %q", domain)
+	}
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if label == "" {
+			return "", fmt.Errorf("invalid domain %q", domain)
+		}
+		labels[i] = sanitizeName(label)
+	}
+	name = strings.Join(labels, ".")
+	if len(name) > 63 {
+		return "", fmt.Errorf("domain %q is too long (max 63 characters before .test)", domain)
+	}
+	return name, nil
+}
+
+// discoveredService represents a published port of a Docker Compose service.
 type discoveredService struct {
 	service       string
 	publishedPort string
+	target        int
+	// extra marks ports after the service's first published one, which
+	// get a service-target.project name.
+	extra bool
+	// routeName is set when the service configured its own hostname.
+	routeName string
 }
 
 // composeRoute is a fully resolved route ready for registration.
@@ -80,7 +152,8 @@ type composeRoute struct {
 }
 
 // parseComposeConfig parses `docker compose config --format json` output and
-// extracts services that have published ports.
+// extracts every published TCP port, with any hostname chosen through
+// x-paw or paw-proxy.domain labels.
 func parseComposeConfig(data []byte) ([]discoveredService, string, error) {
 	var config composeConfig
 	if err := json.Unmarshal(data, &config); err != nil {
@@ -98,43 +171,67 @@ func parseComposeConfig(data []byte) ([]discoveredService, string, error) {
 
 	for _, name := range names {
 		svc := config.Services[name]
-		if len(svc.Ports) == 0 {
-			continue
-		}
-		port := svc.Ports[0]
-		if port.Published == "" {
-			continue
+		first := true
+		for _, port := range svc.Ports {
+			if port.Published == "" || port.Protocol == "udp" {
+				continue
+			}
+			ds := discoveredService{
+				service:       name,
+				publishedPort: port.Published,
+				target:        port.Target,
+				extra:         !first,
+			}
+			if domain := svc.domainFor(port.Target, first); domain != "" {
+				routeName, err := composeDomainRouteName(domain)
+				if err != nil {
+					return nil, "", fmt.Errorf("service %s: %w", name, err)
+				}
+				ds.routeName = routeName
+			}
+			services = append(services, ds)
+			first = false
 		}
-		services = append(services, discoveredService{
-			service:       name,
-			publishedPort: port.Published,
-		})
 	}
 
 	return services, config.Name, nil
 }
 
-// buildComposeRouteNames creates route entries with sanitized names.
-// If nameFlag is set, it overrides the project name portion.
+// buildComposeRouteNames creates route entries with sanitized names:
+// service.project for a service's first port, service-target.project for
+// the others, or the hostname the service configured. If nameFlag is set,
+// it overrides the project name portion.
 func buildComposeRouteNames(services []discoveredService, projectName, nameFlag string) []composeRoute {
-	project := projectName
-	if nameFlag != "" {
-		project = nameFlag
-	}
-	project = sanitizeName(project)
+	project := composeProject(projectName, nameFlag)
 
 	routes := make([]composeRoute, 0, len(services))
 	for _, svc := range services {
-		svcName := sanitizeName(svc.service)
+		routeName := svc.routeName
+		if routeName == "" {
+			svcName := sanitizeName(svc.service)
+			if svc.extra {
+				svcName = fmt.Sprintf("%s-%d", svcName, svc.target)
+			}
+			routeName = svcName + "." + project
+		}
 		routes = append(routes, composeRoute{
 			service:   svc.service,
-			routeName: svcName + "." + project,
+			routeName: routeName,
 			upstream:  fmt.Sprintf("localhost:%s", svc.publishedPort),
 		})
 	}
 	return routes
 }
 
+// composeProject returns the sanitized project part of compose route
+// names: the compose project, or nameFlag if set.
+func composeProject(projectName, nameFlag string) string {
+	if nameFlag != "" {
+		projectName = nameFlag
+	}
+	return sanitizeName(projectName)
+}
+
 // multiRouteState manages multiple route entries for Docker Compose mode.
 type multiRouteState struct {
 	mu     sync.RWMutex
@@ -158,6 +255,13 @@ func (s *multiRouteState) Snapshot() ([]composeRoute, string) {
 	return routes, s.dir
 }
 
+// Replace swaps in a new set of registered routes.
+func (s *multiRouteState) Replace(routes []composeRoute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = routes
+}
+
 // RouteNames returns the route names in order.
 func (s *multiRouteState) RouteNames() []string {
 	s.mu.RLock()
@@ -185,7 +289,7 @@ func runComposeConfig(composeFlags []string) ([]byte, error) {
 }
 
 // registerComposeRoutes registers all compose routes with the daemon.
-func registerComposeRoutes(client *http.Client, routes []composeRoute, dir string) error {
+func registerComposeRoutes(client *pawclient.Client, routes []composeRoute, dir string) error {
 	for _, r := range routes {
 		if err := registerRoute(client, r.routeName, r.upstream, dir); err != nil {
 			return fmt.Errorf("registering %s: %w", r.routeName, err)
@@ -195,7 +299,7 @@ func registerComposeRoutes(client *http.Client, routes []composeRoute, dir strin
 }
 
 // deregisterComposeRoutes deregisters all compose routes from the daemon.
-func deregisterComposeRoutes(client *http.Client, routes []composeRoute) {
+func deregisterComposeRoutes(client *pawclient.Client, routes []composeRoute) {
 	for _, r := range routes {
 		if err := deregisterRoute(client, r.routeName); err != nil {
 			log.Printf("warning: deregister %s failed: %v", r.routeName, err)
@@ -204,55 +308,59 @@ func deregisterComposeRoutes(client *http.Client, routes []composeRoute) {
 }
 
 // heartbeatCompose sends heartbeats for all compose routes.
-func heartbeatCompose(ctx context.Context, client *http.Client, state *multiRouteState) {
+func heartbeatCompose(ctx context.Context, client *pawclient.Client, state *multiRouteState) {
 	heartbeatComposeWithInterval(ctx, client, state, 10*time.Second)
 }
 
-func heartbeatComposeWithInterval(ctx context.Context, client *http.Client, state *multiRouteState, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			routes, dir := state.Snapshot()
-			for _, r := range routes {
-				req, err := http.NewRequest("POST", fmt.Sprintf("http://unix/routes/%s/heartbeat", r.routeName), nil)
-				if err != nil {
-					log.Printf("warning: compose heartbeat request failed for %s: %v", r.routeName, err)
-					continue
-				}
-				resp, err := client.Do(req)
-				if err != nil {
-					log.Printf("warning: compose heartbeat failed for %s: %v", r.routeName, err)
-					continue
-				}
+func heartbeatComposeWithInterval(ctx context.Context, client *pawclient.Client, state *multiRouteState, interval time.Duration) {
+	names := func() []string {
+		routes, _ := state.Snapshot()
+		out := make([]string, 0, len(routes))
+		for _, r := range routes {
+			out = append(out, r.routeName)
+		}
+		return out
+	}
+	handle := func(name string, err error) bool {
+		routes, dir := state.Snapshot()
+		for _, r := range routes {
+			if r.routeName == name {
+				composeHeartbeatFailed(client, r, dir, err)
+			}
+		}
+		return false
+	}
+	poll := func() bool {
+		routes, dir := state.Snapshot()
+		for _, r := range routes {
+			composeHeartbeatFailed(client, r, dir, client.Heartbeat(context.Background(), r.routeName))
+		}
+		return false
+	}
+	keepAlive(ctx, client, interval, names, handle, poll)
+}
 
-				if resp.StatusCode == http.StatusOK {
-					resp.Body.Close()
-					continue
-				}
-				resp.Body.Close()
-
-				if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
-					if err := registerRoute(client, r.routeName, r.upstream, dir); err != nil {
-						log.Printf("warning: compose auto re-register failed for %s: %v", r.routeName, err)
-						continue
-					}
-					log.Printf("route re-registered after daemon restart: %s.test -> %s", r.routeName, r.upstream)
-					continue
-				}
+// composeHeartbeatFailed re-registers a compose route the daemon no longer
+// knows. A nil err is a successful heartbeat.
+func composeHeartbeatFailed(client *pawclient.Client, r composeRoute, dir string, err error) {
+	if err == nil {
+		return
+	}
 
-				log.Printf("warning: compose heartbeat for %s returned status %d", r.routeName, resp.StatusCode)
-			}
+	if pawclient.IsNotFound(err) || errors.Is(err, pawclient.ErrExpired) {
+		if err := registerRoute(client, r.routeName, r.upstream, dir); err != nil {
+			log.Printf("warning: compose auto re-register failed for %s: %v", r.routeName, err)
+			return
 		}
+		log.Printf("route re-registered after daemon restart: %s.test -> %s", r.routeName, r.upstream)
+		return
 	}
+
+	log.Printf("warning: compose heartbeat failed for %s: %v", r.routeName, err)
 }
 
 // runDockerComposeMode handles the entire lifecycle when `up` wraps `docker compose up`.
-func runDockerComposeMode(client *http.Client, dc composeDetection, args []string, caPath string) {
+func runDockerComposeMode(client *pawclient.Client, dc composeDetection, args []string, caPath, supportDir string) {
 	// 1. Discover services via docker compose config
 	configOutput, err := runComposeConfig(dc.composeFlags)
 	if err != nil {
@@ -273,6 +381,7 @@ func runDockerComposeMode(client *http.Client, dc composeDetection, args []strin
 
 	// 2. Build route names
 	routes := buildComposeRouteNames(services, projectName, *nameFlag)
+	registrationOptions.Project = routeProject(composeProject(projectName, *nameFlag))
 
 	dir, err := os.Getwd()
 	if err != nil {
@@ -291,7 +400,7 @@ func runDockerComposeMode(client *http.Client, dc composeDetection, args []strin
 	for _, r := range routes {
 		fmt.Printf("Mapping https://%s.test -> %s...\n", r.routeName, r.upstream)
 	}
-	fmt.Printf("%d services live:\n", len(routes))
+	fmt.Printf("%d routes live:\n", len(routes))
 	for _, r := range routes {
 		fmt.Printf("   https://%s.test\n", r.routeName)
 	}
@@ -302,18 +411,22 @@ func runDockerComposeMode(client *http.Client, dc composeDetection, args []strin
 	ctx, cancel := context.WithCancel(context.Background())
 	go heartbeatCompose(ctx, client, state)
 
-	// 6. Cleanup function
+	// 6. Follow services starting and stopping, and compose file edits
+	go watchCompose(ctx, client, state, composeSnapshotFunc(dc.composeFlags, *nameFlag), composeWatchInterval)
+
+	// 7. Cleanup function
 	cleanup := func() {
+		routes, _ := state.Snapshot()
 		fmt.Printf("\nRemoving %d route mappings...\n", len(routes))
 		notification.Notify("paw-proxy", fmt.Sprintf("Removing %d route mappings", len(routes)))
 		deregisterComposeRoutes(client, routes)
 	}
 
-	// 7. Setup signal handling
+	// 8. Setup signal handling
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	// 8. Run docker compose up as child process
+	// 9. Run docker compose up as child process
 	cmd := exec.Command(args[0], args[1:]...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
@@ -321,16 +434,19 @@ func runDockerComposeMode(client *http.Client, dc composeDetection, args []strin
 	cmd.Env = append(os.Environ(),
 		fmt.Sprintf("NODE_EXTRA_CA_CERTS=%s", caPath),
 	)
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if !*noTrustEnvFlag {
+		cmd.Env = append(cmd.Env, trust.ChildEnv(supportDir, caPath, os.Getenv)...)
+	}
+	group := newProcessGroup(cmd)
 
-	if err := cmd.Start(); err != nil {
+	if err := group.start(); err != nil {
 		fmt.Printf("Error starting docker compose: %v\n", err)
 		cancel()
 		cleanup()
 		os.Exit(1)
 	}
 
-	// 9. Wait for signal or command exit
+	// 10. Wait for signal or command exit
 	doneCh := make(chan error, 1)
 	go func() {
 		doneCh <- cmd.Wait()
@@ -339,18 +455,16 @@ func runDockerComposeMode(client *http.Client, dc composeDetection, args []strin
 	var exitCode int
 	select {
 	case sig := <-sigCh:
-		syscall.Kill(-cmd.Process.Pid, sig.(syscall.Signal))
-		select {
-		case <-doneCh:
-		case <-time.After(10 * time.Second):
-			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
-		}
+		pauseRoutes(client, state.RouteNames())
+		group.stop(sig, doneCh, 10*time.Second)
 	case err := <-doneCh:
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			exitCode = exitErr.ExitCode()
 		}
 	}
 
+	group.close()
+
 	cancel()
 	cleanup()
 	os.Exit(exitCode)
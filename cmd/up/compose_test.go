@@ -164,14 +164,15 @@ func TestParseComposeConfig(t *testing.T) {
 		}
 	})
 
-	t.Run("service with multiple ports uses first", func(t *testing.T) {
+	t.Run("service with multiple ports registers each", func(t *testing.T) {
 		configJSON := `{
 			"name": "myapp",
 			"services": {
 				"web": {
 					"ports": [
 						{"published": "3000", "target": 3000, "protocol": "tcp"},
-						{"published": "3001", "target": 3001, "protocol": "tcp"}
+						{"published": "9229", "target": 9229, "protocol": "tcp"},
+						{"published": "5353", "target": 5353, "protocol": "udp"}
 					]
 				}
 			}
@@ -182,11 +183,73 @@ func TestParseComposeConfig(t *testing.T) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 
-		if len(routes) != 1 {
-			t.Fatalf("got %d routes, want 1", len(routes))
+		if len(routes) != 2 {
+			t.Fatalf("got %d routes, want 2 (udp skipped)", len(routes))
+		}
+		if routes[0].publishedPort != "3000" || routes[0].extra {
+			t.Errorf("first route = %+v, want primary port 3000", routes[0])
+		}
+		if routes[1].publishedPort != "9229" || !routes[1].extra || routes[1].target != 9229 {
+			t.Errorf("second route = %+v, want extra port 9229", routes[1])
+		}
+
+		names := buildComposeRouteNames(routes, "myapp", "")
+		if names[0].routeName != "web.myapp" || names[1].routeName != "web-9229.myapp" {
+			t.Errorf("route names = %q, %q", names[0].routeName, names[1].routeName)
+		}
+	})
+
+	t.Run("x-paw and labels choose domains", func(t *testing.T) {
+		configJSON := `{
+			"name": "myapp",
+			"services": {
+				"frontend": {
+					"ports": [
+						{"published": "3000", "target": 3000, "protocol": "tcp"},
+						{"published": "9229", "target": 9229, "protocol": "tcp"}
+					],
+					"x-paw": {"domain": "shop.test", "ports": {"9229": "debug.shop.test"}}
+				},
+				"api": {
+					"ports": [{"published": "8080", "target": 8080, "protocol": "tcp"}],
+					"labels": {"paw-proxy.domain": "API.Shop.test"}
+				}
+			}
+		}`
+
+		services, _, err := parseComposeConfig([]byte(configJSON))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		routes := buildComposeRouteNames(services, "myapp", "ignored")
+		got := make(map[string]string)
+		for _, r := range routes {
+			got[r.upstream] = r.routeName
+		}
+		want := map[string]string{
+			"localhost:8080": "api.shop",
+			"localhost:3000": "shop",
+			"localhost:9229": "debug.shop",
+		}
+		for upstream, name := range want {
+			if got[upstream] != name {
+				t.Errorf("%s: routeName = %q, want %q", upstream, got[upstream], name)
+			}
 		}
-		if routes[0].publishedPort != "3000" {
-			t.Errorf("port = %q, want %q", routes[0].publishedPort, "3000")
+	})
+
+	t.Run("invalid domain", func(t *testing.T) {
+		configJSON := `{
+			"name": "myapp",
+			"services": {
+				"web": {
+					"ports": [{"published": "3000", "target": 3000}],
+					"x-paw": {"domain": "shop..test"}
+				}
+			}
+		}`
+		if _, _, err := parseComposeConfig([]byte(configJSON)); err == nil {
+			t.Fatal("expected error for invalid domain")
 		}
 	})
 
@@ -353,7 +416,7 @@ func TestRegisterComposeRoutes(t *testing.T) {
 	t.Run("registers all routes successfully", func(t *testing.T) {
 		var registered []string
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method == http.MethodPost && r.URL.Path == "/routes" {
+			if r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/routes/") {
 				var body map[string]string
 				json.NewDecoder(r.Body).Decode(&body)
 				registered = append(registered, body["name"])
@@ -385,7 +448,7 @@ func TestRegisterComposeRoutes(t *testing.T) {
 	t.Run("stops on first error and wraps route name", func(t *testing.T) {
 		var registered []string
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method == http.MethodPost && r.URL.Path == "/routes" {
+			if r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/routes/") {
 				var body map[string]string
 				json.NewDecoder(r.Body).Decode(&body)
 				registered = append(registered, body["name"])
@@ -425,8 +488,8 @@ func TestRegisterComposeRoutes(t *testing.T) {
 func TestDeregisterComposeRoutes(t *testing.T) {
 	var deregistered []string
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/routes/") {
-			name := strings.TrimPrefix(r.URL.Path, "/routes/")
+		if r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/routes/") {
+			name := strings.TrimPrefix(r.URL.Path, "/v1/routes/")
 			deregistered = append(deregistered, name)
 			w.WriteHeader(http.StatusOK)
 			return
@@ -456,10 +519,10 @@ func TestHeartbeatComposeReRegisters(t *testing.T) {
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch {
-		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/heartbeat"):
-			// Extract route name from path: /routes/{name}/heartbeat
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/v1/routes/") && strings.HasSuffix(r.URL.Path, "/heartbeat"):
+			// Extract route name from path: /v1/routes/{name}/heartbeat
 			parts := strings.Split(r.URL.Path, "/")
-			name := parts[2]
+			name := parts[3]
 			val, _ := heartbeatCounts.LoadOrStore(name, &atomic.Int32{})
 			counter := val.(*atomic.Int32)
 			if counter.Add(1) == 1 {
@@ -468,7 +531,7 @@ func TestHeartbeatComposeReRegisters(t *testing.T) {
 				return
 			}
 			w.WriteHeader(http.StatusOK)
-		case r.Method == http.MethodPost && r.URL.Path == "/routes":
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/routes/"):
 			registerCount.Add(1)
 			w.WriteHeader(http.StatusOK)
 		default:
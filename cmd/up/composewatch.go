@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+// composeWatchInterval is how often compose mode re-reads the compose
+// config and running containers.
+const composeWatchInterval = 5 * time.Second
+
+// composePSEntry is the subset of `docker compose ps --format json` used to
+// tell which services are running.
+type composePSEntry struct {
+	Service string `json:"Service"`
+	State   string `json:"State"`
+}
+
+// parseComposePS returns the services with a running container. Compose
+// prints a JSON array (older releases) or one object per line (newer).
+func parseComposePS(data []byte) (map[string]bool, error) {
+	var entries []composePSEntry
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("parsing compose ps: %w", err)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var e composePSEntry
+			if err := json.Unmarshal(line, &e); err != nil {
+				return nil, fmt.Errorf("parsing compose ps: %w", err)
+			}
+			entries = append(entries, e)
+		}
+	}
+
+	running := make(map[string]bool)
+	for _, e := range entries {
+		if strings.EqualFold(e.State, "running") {
+			running[e.Service] = true
+		}
+	}
+	return running, nil
+}
+
+// filterRunning keeps the routes whose service has a running container.
+func filterRunning(routes []composeRoute, running map[string]bool) []composeRoute {
+	var out []composeRoute
+	for _, r := range routes {
+		if running[r.service] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// diffComposeRoutes compares registered routes with the desired set. A
+// route whose upstream changed is removed and added again.
+func diffComposeRoutes(current, desired []composeRoute) (add, remove []composeRoute) {
+	want := make(map[string]composeRoute, len(desired))
+	for _, r := range desired {
+		want[r.routeName] = r
+	}
+	have := make(map[string]composeRoute, len(current))
+	for _, r := range current {
+		have[r.routeName] = r
+		if d, ok := want[r.routeName]; !ok || d.upstream != r.upstream {
+			remove = append(remove, r)
+		}
+	}
+	for _, r := range desired {
+		if h, ok := have[r.routeName]; !ok || h.upstream != r.upstream {
+			add = append(add, r)
+		}
+	}
+	return add, remove
+}
+
+// composeOutput runs `docker compose [flags] args...` and returns stdout.
+// Errors carry compose's stderr instead of printing it, so a half-edited
+// compose file doesn't flood the terminal every tick.
+func composeOutput(composeFlags []string, args ...string) ([]byte, error) {
+	cmdArgs := append([]string{"compose"}, composeFlags...)
+	cmdArgs = append(cmdArgs, args...)
+	var stderr bytes.Buffer
+	cmd := exec.Command("docker", cmdArgs...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("docker compose %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// composeSnapshot reads the routes the compose project currently wants and
+// which services are running. running is nil when compose ps failed.
+type composeSnapshot func() (routes []composeRoute, running map[string]bool, err error)
+
+// composeSnapshotFunc builds a composeSnapshot from the docker CLI.
+func composeSnapshotFunc(composeFlags []string, nameFlag string) composeSnapshot {
+	return func() ([]composeRoute, map[string]bool, error) {
+		data, err := composeOutput(composeFlags, "config", "--format", "json")
+		if err != nil {
+			return nil, nil, err
+		}
+		services, projectName, err := parseComposeConfig(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		routes := buildComposeRouteNames(services, projectName, nameFlag)
+
+		var running map[string]bool
+		if out, err := composeOutput(composeFlags, "ps", "--format", "json"); err == nil {
+			running, _ = parseComposePS(out)
+		}
+		return routes, running, nil
+	}
+}
+
+// watchCompose keeps registered routes in step with the compose project:
+// services added to or removed from the compose file, and services that
+// start or stop. Until some container is seen running, stopped services
+// keep their routes so the project can finish booting.
+func watchCompose(ctx context.Context, client *pawclient.Client, state *multiRouteState, snapshot composeSnapshot, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seenRunning := false
+	lastErr := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		desired, running, err := snapshot()
+		if err != nil {
+			if err.Error() != lastErr {
+				log.Printf("warning: compose watch: %v", err)
+				lastErr = err.Error()
+			}
+			continue
+		}
+		lastErr = ""
+		if len(running) > 0 {
+			seenRunning = true
+		}
+		if seenRunning && running != nil {
+			desired = filterRunning(desired, running)
+		}
+
+		syncComposeRoutes(client, state, desired)
+	}
+}
+
+// syncComposeRoutes registers and deregisters routes so the state matches
+// desired. Routes that fail to register are retried on the next tick.
+func syncComposeRoutes(client *pawclient.Client, state *multiRouteState, desired []composeRoute) {
+	current, dir := state.Snapshot()
+	add, remove := diffComposeRoutes(current, desired)
+	if len(add) == 0 && len(remove) == 0 {
+		return
+	}
+
+	registered := make(map[string]bool, len(current))
+	for _, r := range current {
+		registered[r.routeName] = true
+	}
+	for _, r := range remove {
+		if err := deregisterRoute(client, r.routeName); err != nil {
+			log.Printf("warning: deregister %s failed: %v", r.routeName, err)
+		}
+		delete(registered, r.routeName)
+		fmt.Printf("- https://%s.test\n", r.routeName)
+	}
+	for _, r := range add {
+		if err := registerRoute(client, r.routeName, r.upstream, dir); err != nil {
+			log.Printf("warning: registering %s failed: %v", r.routeName, err)
+			continue
+		}
+		registered[r.routeName] = true
+		fmt.Printf("+ https://%s.test -> %s\n", r.routeName, r.upstream)
+	}
+
+	var next []composeRoute
+	for _, r := range desired {
+		if registered[r.routeName] {
+			next = append(next, r)
+		}
+	}
+	state.Replace(next)
+}
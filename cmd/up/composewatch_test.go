@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseComposePS(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"ndjson", `{"Service":"api","State":"running"}
+{"Service":"worker","State":"exited"}
+{"Service":"frontend","State":"running"}
+`},
+		{"array", `[{"Service":"api","State":"running"},{"Service":"worker","State":"exited"},{"Service":"frontend","State":"running"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			running, err := parseComposePS([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !running["api"] || !running["frontend"] || running["worker"] {
+				t.Errorf("running = %v", running)
+			}
+		})
+	}
+
+	if running, err := parseComposePS(nil); err != nil || len(running) != 0 {
+		t.Errorf("empty output: %v, %v", running, err)
+	}
+	if _, err := parseComposePS([]byte("not json")); err == nil {
+		t.Error("expected error for invalid output")
+	}
+}
+
+func TestDiffComposeRoutes(t *testing.T) {
+	current := []composeRoute{
+		{service: "frontend", routeName: "frontend.myapp", upstream: "localhost:3000"},
+		{service: "api", routeName: "api.myapp", upstream: "localhost:8080"},
+		{service: "worker", routeName: "worker.myapp", upstream: "localhost:9090"},
+	}
+	desired := []composeRoute{
+		{service: "frontend", routeName: "frontend.myapp", upstream: "localhost:3000"},
+		{service: "api", routeName: "api.myapp", upstream: "localhost:8081"},
+		{service: "admin", routeName: "admin.myapp", upstream: "localhost:4000"},
+	}
+
+	add, remove := diffComposeRoutes(current, desired)
+	names := func(routes []composeRoute) []string {
+		var out []string
+		for _, r := range routes {
+			out = append(out, r.routeName)
+		}
+		return out
+	}
+	if got := names(add); !slices.Equal(got, []string{"api.myapp", "admin.myapp"}) {
+		t.Errorf("add = %v", got)
+	}
+	if got := names(remove); !slices.Equal(got, []string{"api.myapp", "worker.myapp"}) {
+		t.Errorf("remove = %v", got)
+	}
+}
+
+func TestWatchComposeFollowsServices(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/routes/"):
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			events = append(events, "+"+body["name"])
+		case r.Method == http.MethodDelete:
+			events = append(events, "-"+strings.TrimPrefix(r.URL.Path, "/v1/routes/"))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client := unixHostClient(t, server)
+
+	frontend := composeRoute{service: "frontend", routeName: "frontend.myapp", upstream: "localhost:3000"}
+	api := composeRoute{service: "api", routeName: "api.myapp", upstream: "localhost:8080"}
+	state := newMultiRouteState([]composeRoute{frontend, api}, "/tmp/project")
+
+	// Booting (nothing running yet), then both running, then api stopped.
+	steps := []map[string]bool{
+		{},
+		{"frontend": true, "api": true},
+		{"frontend": true},
+	}
+	var step int
+	done := make(chan struct{})
+	snapshot := func() ([]composeRoute, map[string]bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if step == len(steps) {
+			select {
+			case <-done:
+			default:
+				close(done)
+			}
+			return []composeRoute{frontend, api}, steps[len(steps)-1], nil
+		}
+		running := steps[step]
+		step++
+		return []composeRoute{frontend, api}, running, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchCompose(ctx, client, state, snapshot, 5*time.Millisecond)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("watcher did not run all steps")
+	}
+	cancel()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(events, []string{"-api.myapp"}) {
+		t.Errorf("events = %v, want [-api.myapp]", events)
+	}
+	if names := state.RouteNames(); !slices.Equal(names, []string{"frontend.myapp"}) {
+		t.Errorf("routes = %v", names)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// crashTailLines is how much of the dev server's output a crash report
+// keeps.
+const crashTailLines = 100
+
+// maxTailLine cuts overly long lines (minified bundles, progress bars
+// without newlines) so the tail stays small.
+const maxTailLine = 4096
+
+// outputTail remembers the last crashTailLines lines written to it.
+type outputTail struct {
+	mu      sync.Mutex
+	lines   []string
+	partial []byte
+}
+
+func (t *outputTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			t.appendPartial(p)
+			break
+		}
+		t.appendPartial(p[:i])
+		t.push(string(bytes.TrimSuffix(t.partial, []byte("\r"))))
+		t.partial = t.partial[:0]
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+func (t *outputTail) appendPartial(p []byte) {
+	if room := maxTailLine - len(t.partial); len(p) > room {
+		p = p[:max(room, 0)]
+	}
+	t.partial = append(t.partial, p...)
+}
+
+func (t *outputTail) push(line string) {
+	if len(t.lines) == crashTailLines {
+		t.lines = append(t.lines[:0], t.lines[1:]...)
+	}
+	t.lines = append(t.lines, line)
+}
+
+// Lines returns the remembered lines, oldest first, including an
+// unterminated last line.
+func (t *outputTail) Lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := append([]string(nil), t.lines...)
+	if len(t.partial) > 0 {
+		lines = append(lines, string(t.partial))
+	}
+	if len(lines) > crashTailLines {
+		lines = lines[len(lines)-crashTailLines:]
+	}
+	return lines
+}
+
+// teeOutput copies the command's stdout and stderr to tail as well,
+// except for streams that are terminals: piping those would make the dev
+// server drop its colors and prompts. It reports whether any output is
+// captured.
+func teeOutput(cmd *exec.Cmd, tail *outputTail) bool {
+	captured := false
+	if f, ok := cmd.Stdout.(*os.File); ok && !isTerminal(f) {
+		cmd.Stdout = io.MultiWriter(f, tail)
+		captured = true
+	}
+	if f, ok := cmd.Stderr.(*os.File); ok && !isTerminal(f) {
+		cmd.Stderr = io.MultiWriter(f, tail)
+		captured = true
+	}
+	return captured
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// crashReport describes a dev server that exited with exitCode after
+// running for ran, followed by the last lines it printed. captured is
+// false when its output went straight to a terminal.
+func crashReport(name, upstream, dir string, exitCode int, ran time.Duration, lines []string, captured bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "💥 %s.test: dev server exited with code %d after %s\n", name, exitCode, ran.Round(time.Millisecond))
+	fmt.Fprintf(&b, "   Upstream: %s\n", upstream)
+	fmt.Fprintf(&b, "   Directory: %s\n", dir)
+	switch {
+	case !captured:
+		b.WriteString("   Output went straight to the terminal; run with --pty to keep its last lines\n")
+	case len(lines) > 0:
+		fmt.Fprintf(&b, "   Last %d lines of output:\n", len(lines))
+		for _, line := range lines {
+			fmt.Fprintf(&b, "   | %s\n", line)
+		}
+	}
+	return b.String()
+}
+
+// writeCrashLog saves report as <supportDir>/crash/<name>-<timestamp>.log
+// and returns its path.
+func writeCrashLog(supportDir, name string, now time.Time, report string) (string, error) {
+	dir := filepath.Join(supportDir, "crash")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.log", name, now.Format("20060102-150405")))
+	if err := os.WriteFile(path, []byte(report), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
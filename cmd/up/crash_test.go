@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputTailKeepsLastLines(t *testing.T) {
+	tail := &outputTail{}
+	for i := range crashTailLines + 20 {
+		fmt.Fprintf(tail, "line %d\r\n", i)
+	}
+	tail.Write([]byte("partial"))
+
+	lines := tail.Lines()
+	if len(lines) != crashTailLines {
+		t.Fatalf("got %d lines, want %d", len(lines), crashTailLines)
+	}
+	if lines[0] != "line 21" {
+		t.Errorf("first line = %q, want %q", lines[0], "line 21")
+	}
+	if last := lines[len(lines)-1]; last != "partial" {
+		t.Errorf("last line = %q, want the unterminated line", last)
+	}
+}
+
+func TestOutputTailCutsLongLines(t *testing.T) {
+	tail := &outputTail{}
+	tail.Write([]byte(strings.Repeat("x", maxTailLine*2)))
+	tail.Write([]byte("\n"))
+	if lines := tail.Lines(); len(lines) != 1 || len(lines[0]) != maxTailLine {
+		t.Errorf("got %d lines, first %d bytes; want 1 line of %d bytes", len(lines), len(lines[0]), maxTailLine)
+	}
+}
+
+func TestCrashReport(t *testing.T) {
+	report := crashReport("shop", "localhost:4000", "/src/shop", 1, 1500*time.Millisecond, []string{"Error: boom"}, true)
+	for _, want := range []string{"shop.test", "code 1", "1.5s", "localhost:4000", "/src/shop", "| Error: boom"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+
+	report = crashReport("shop", "localhost:4000", "/src/shop", 1, time.Second, nil, false)
+	if !strings.Contains(report, "--pty") {
+		t.Errorf("report for uncaptured output should point at --pty:\n%s", report)
+	}
+}
+
+func TestWriteCrashLog(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 14, 5, 9, 0, time.UTC)
+	path, err := writeCrashLog(dir, "shop", now, "report\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "crash", "shop-20260301-140509.log"); path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "report\n" {
+		t.Errorf("contents = %q", data)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// relayConfig describes how the host daemon reaches a dev server running
+// inside a container (e.g. a devcontainer): the container port the server
+// binds and the host port it is published or forwarded on.
+type relayConfig struct {
+	port     int // fixed port for the dev server; 0 picks a free one
+	hostPort int // port published on the host; 0 means same as port
+}
+
+// parseRelay reads --port and --host-port, falling back to PAW_PROXY_PORT
+// and PAW_PROXY_HOST_PORT so a devcontainer.json can set them once.
+func parseRelay(port, hostPort int, getenv func(string) string) (relayConfig, error) {
+	var err error
+	if port == 0 {
+		if port, err = envPort(getenv, "PAW_PROXY_PORT"); err != nil {
+			return relayConfig{}, err
+		}
+	}
+	if hostPort == 0 {
+		if hostPort, err = envPort(getenv, "PAW_PROXY_HOST_PORT"); err != nil {
+			return relayConfig{}, err
+		}
+	}
+	if port < 0 || port > 65535 {
+		return relayConfig{}, fmt.Errorf("--port: invalid port %d", port)
+	}
+	if hostPort < 0 || hostPort > 65535 {
+		return relayConfig{}, fmt.Errorf("--host-port: invalid port %d", hostPort)
+	}
+	if hostPort != 0 && port == 0 {
+		// A published port maps to a fixed container port.
+		return relayConfig{}, fmt.Errorf("--host-port requires --port (the container port it is published from)")
+	}
+	return relayConfig{port: port, hostPort: hostPort}, nil
+}
+
+func envPort(getenv func(string) string, key string) (int, error) {
+	v := getenv(key)
+	if v == "" {
+		return 0, nil
+	}
+	port, err := strconv.Atoi(v)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%s: invalid port %q", key, v)
+	}
+	return port, nil
+}
+
+// active reports whether the dev server is reached through a published
+// port rather than directly on the host's loopback.
+func (r relayConfig) active() bool {
+	return r.hostPort != 0
+}
+
+// upstream returns the address the daemon proxies to for a dev server
+// listening on port inside this environment.
+func (r relayConfig) upstream(port int) string {
+	if r.hostPort != 0 {
+		return fmt.Sprintf("localhost:%d", r.hostPort)
+	}
+	return fmt.Sprintf("localhost:%d", port)
+}
+
+// inContainer reports whether up appears to run inside a container, where
+// the host daemon's unix socket is only reachable if mounted.
+func inContainer() bool {
+	for _, key := range []string{"REMOTE_CONTAINERS", "CODESPACES", "DEVCONTAINER"} {
+		if os.Getenv(key) == "true" {
+			return true
+		}
+	}
+	_, err := os.Stat("/.dockerenv")
+	return err == nil
+}
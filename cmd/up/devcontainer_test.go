@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestParseRelay(t *testing.T) {
+	env := func(m map[string]string) func(string) string {
+		return func(k string) string { return m[k] }
+	}
+
+	tests := []struct {
+		name         string
+		port, host   int
+		env          map[string]string
+		want         relayConfig
+		wantErr      bool
+		wantUpstream string
+	}{
+		{name: "default", want: relayConfig{}, wantUpstream: "localhost:4000"},
+		{name: "fixed port", port: 3000, want: relayConfig{port: 3000}, wantUpstream: "localhost:3000"},
+		{name: "published port", port: 3000, host: 13000, want: relayConfig{port: 3000, hostPort: 13000}, wantUpstream: "localhost:13000"},
+		{name: "from env", env: map[string]string{"PAW_PROXY_PORT": "3000", "PAW_PROXY_HOST_PORT": "13000"},
+			want: relayConfig{port: 3000, hostPort: 13000}, wantUpstream: "localhost:13000"},
+		{name: "flag beats env", port: 5173, env: map[string]string{"PAW_PROXY_PORT": "3000"},
+			want: relayConfig{port: 5173}, wantUpstream: "localhost:5173"},
+		{name: "host port without port", host: 13000, wantErr: true},
+		{name: "bad env", env: map[string]string{"PAW_PROXY_HOST_PORT": "abc"}, wantErr: true},
+		{name: "out of range", port: 70000, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRelay(tt.port, tt.host, env(tt.env))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseRelay = %+v, want %+v", got, tt.want)
+			}
+			port := got.port
+			if port == 0 {
+				port = 4000
+			}
+			if up := got.upstream(port); up != tt.wantUpstream {
+				t.Errorf("upstream = %q, want %q", up, tt.wantUpstream)
+			}
+			if got.active() != (tt.want.hostPort != 0) {
+				t.Errorf("active = %v", got.active())
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envKeyPattern matches the variable names accepted in env files.
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// envEntry is one KEY=value assignment from an env file.
+type envEntry struct {
+	key   string
+	value string
+	// literal values were single-quoted and are not interpolated.
+	literal bool
+}
+
+// readEnvFiles parses the --env-file files, in the order given.
+func readEnvFiles(paths []string) ([]envEntry, error) {
+	var entries []envEntry
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("env file: %w", err)
+		}
+		parsed, err := parseEnvFile(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("env file %s: %w", path, err)
+		}
+		entries = append(entries, parsed...)
+	}
+	return entries, nil
+}
+
+// parseEnvFile parses dotenv syntax: KEY=value lines with an optional
+// "export " prefix, # comments, and single- or double-quoted values.
+// Double-quoted values may span lines and understand \n, \t, \" and \\.
+func parseEnvFile(data string) ([]envEntry, error) {
+	var entries []envEntry
+	sc := bufio.NewScanner(strings.NewReader(data))
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=value", lineNo)
+		}
+		value = strings.TrimSpace(value)
+
+		entry := envEntry{key: key}
+		switch {
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single quote", lineNo)
+			}
+			entry.value = value[1 : end+1]
+			entry.literal = true
+		case strings.HasPrefix(value, `"`):
+			start := lineNo
+			raw := value[1:]
+			for !closedDoubleQuote(raw) {
+				if !sc.Scan() {
+					return nil, fmt.Errorf("line %d: unterminated double quote", start)
+				}
+				lineNo++
+				raw += "\n" + sc.Text()
+			}
+			entry.value = unescapeDoubleQuoted(raw)
+		default:
+			// An unquoted value ends at a comment.
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+			entry.value = value
+		}
+		entries = append(entries, entry)
+	}
+	return entries, sc.Err()
+}
+
+// closedDoubleQuote reports whether s, the text after an opening double
+// quote, contains its unescaped closing quote.
+func closedDoubleQuote(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeDoubleQuoted returns the value of a double-quoted string up to
+// its closing quote, with escapes applied.
+func unescapeDoubleQuoted(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '"' {
+			break
+		}
+		if c == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\':
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// resolveEnvFile turns env file entries into KEY=value pairs for the dev
+// server. $VAR and ${VAR} in values are expanded from routeEnv (PORT,
+// APP_URL, ...) first, then the shell environment, then entries earlier in
+// the files. Variables already set in the shell are left out, since the
+// shell wins over env files, and later entries win over earlier ones.
+func resolveEnvFile(entries []envEntry, routeEnv []string, lookupEnv func(string) (string, bool)) []string {
+	route := make(map[string]string, len(routeEnv))
+	for _, kv := range routeEnv {
+		k, v, _ := strings.Cut(kv, "=")
+		route[k] = v
+	}
+	values := make(map[string]string)
+	var order []string
+	lookup := func(key string) string {
+		if v, ok := route[key]; ok {
+			return v
+		}
+		if v, ok := lookupEnv(key); ok {
+			return v
+		}
+		return values[key]
+	}
+	for _, e := range entries {
+		if _, ok := lookupEnv(e.key); ok {
+			continue
+		}
+		value := e.value
+		if !e.literal {
+			value = os.Expand(value, lookup)
+		}
+		if _, seen := values[e.key]; !seen {
+			order = append(order, e.key)
+		}
+		values[e.key] = value
+	}
+
+	env := make([]string, 0, len(order))
+	for _, k := range order {
+		env = append(env, k+"="+values[k])
+	}
+	return env
+}
+
+// envGetter returns a getenv that sees fileEnv, from resolveEnvFile, as
+// well as the shell environment.
+func envGetter(fileEnv []string) func(string) string {
+	return func(key string) string {
+		if v, ok := os.LookupEnv(key); ok {
+			return v
+		}
+		for _, kv := range fileEnv {
+			if k, v, _ := strings.Cut(kv, "="); k == key {
+				return v
+			}
+		}
+		return ""
+	}
+}
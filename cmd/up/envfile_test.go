@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	entries, err := parseEnvFile(`# comment
+export NODE_ENV=development
+PLAIN = value # trailing comment
+SINGLE='$APP_URL stays'
+DOUBLE="line one\nline \"two\""
+MULTI="a
+b"
+EMPTY=
+`)
+	if err != nil {
+		t.Fatalf("parseEnvFile() = %v", err)
+	}
+	want := []envEntry{
+		{key: "NODE_ENV", value: "development"},
+		{key: "PLAIN", value: "value"},
+		{key: "SINGLE", value: "$APP_URL stays", literal: true},
+		{key: "DOUBLE", value: "line one\nline \"two\""},
+		{key: "MULTI", value: "a\nb"},
+		{key: "EMPTY", value: ""},
+	}
+	if !slices.Equal(entries, want) {
+		t.Errorf("parseEnvFile() = %+v\nwant %+v", entries, want)
+	}
+
+	for _, bad := range []string{"NOEQUALS", "1BAD=x", "Q='open", "Q=\"open\nstill open"} {
+		if _, err := parseEnvFile(bad); err == nil {
+			t.Errorf("parseEnvFile(%q) accepted", bad)
+		}
+	}
+}
+
+func TestResolveEnvFile(t *testing.T) {
+	entries := []envEntry{
+		{key: "AUTH_URL", value: "$APP_URL/auth"},
+		{key: "API", value: "${APP_URL}/api"},
+		{key: "FROM_SHELL", value: "file"},
+		{key: "USES_SHELL", value: "$FROM_SHELL"},
+		{key: "PORT", value: "9999"},
+		{key: "API", value: "$API/v2"},
+		{key: "RAW", value: "$APP_URL", literal: true},
+	}
+	shell := map[string]string{"FROM_SHELL": "shell"}
+	lookup := func(k string) (string, bool) {
+		v, ok := shell[k]
+		return v, ok
+	}
+
+	got := resolveEnvFile(entries, []string{"PORT=3000", "APP_URL=https://myapp.test"}, lookup)
+	want := []string{
+		"AUTH_URL=https://myapp.test/auth",
+		"API=https://myapp.test/api/v2",
+		"USES_SHELL=shell",
+		"PORT=9999",
+		"RAW=$APP_URL",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("resolveEnvFile() = %q\nwant %q", got, want)
+	}
+}
+
+func TestReadEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, ".env")
+	second := filepath.Join(dir, ".env.local")
+	os.WriteFile(first, []byte("A=1\nB=1\n"), 0o600)
+	os.WriteFile(second, []byte("B=2\n"), 0o600)
+
+	entries, err := readEnvFiles([]string{first, second})
+	if err != nil {
+		t.Fatalf("readEnvFiles() = %v", err)
+	}
+	got := resolveEnvFile(entries, nil, func(string) (string, bool) { return "", false })
+	if want := []string{"A=1", "B=2"}; !slices.Equal(got, want) {
+		t.Errorf("resolved = %q, want %q", got, want)
+	}
+
+	if _, err := readEnvFiles([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("expected an error for a missing env file")
+	}
+}
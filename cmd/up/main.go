@@ -2,48 +2,133 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"net"
-	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/daemon"
 	"github.com/alexcatdad/paw-proxy/internal/help"
 	"github.com/alexcatdad/paw-proxy/internal/notification"
 	"github.com/alexcatdad/paw-proxy/internal/paths"
+	"github.com/alexcatdad/paw-proxy/internal/trust"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
 )
 
 // version is set via -ldflags at build time; defaults to "dev" for local builds.
 var version = "dev"
 
 var (
-	nameFlag    = flag.String("n", "", "Custom app name (default: from package.json or directory)")
-	restartFlag = flag.Bool("restart", false, "Auto-restart on crash (non-zero exit)")
-	showVersion = flag.Bool("version", false, "Show version")
-	showVersionShort = flag.Bool("v", false, "")
+	nameFlag             = flag.String("n", "", "Custom app name (default: from package.json or directory)")
+	restartFlag          = flag.Bool("restart", false, "Auto-restart on crash (non-zero exit)")
+	maxRestartsFlag      = flag.Int("max-restarts", 0, "With --restart, give up after this many restarts (default: no limit)")
+	warmupFlag           = flag.String("warmup", "", "Request this path once the dev server is ready (e.g. /)")
+	authFlag             = flag.String("auth", "", "Require basic auth (user:password) for the route")
+	tokenFlag            = flag.String("auth-token", "", "Require a bearer token for the route")
+	corsFlag             = flag.Bool("cors", false, "Answer CORS preflights and allow cross-origin requests")
+	corsOrigins          = flag.String("cors-origins", "", "Comma-separated origins allowed by --cors (default: any)")
+	previewFlag          = flag.String("preview", "", "Register as a preview at <label>.<name>.test (e.g. pr-123)")
+	previewIdle          = flag.Duration("preview-idle", 2*time.Hour, "Remove a preview after this long without requests")
+	projectFlag          = flag.String("project", "", "Group the routes under this project (default: the app name, or the compose project)")
+	portFlag             = flag.Int("port", 0, "Fixed port for the dev server (default: a free port)")
+	hostPortFlag         = flag.Int("host-port", 0, "Host port the dev server's --port is published on (devcontainers)")
+	proxyProtocolFlag    = flag.Bool("proxy-protocol", false, "Send a PROXY protocol v2 header to the dev server")
+	trustForwardedFlag   = flag.Bool("trust-forwarded", false, "Append to forwarding headers sent by a local proxy instead of replacing them")
+	plainHTTPFlag        = flag.Bool("plain-http", false, "Also serve the route over plain HTTP instead of redirecting to HTTPS")
+	passthroughFlag      = flag.Bool("passthrough", false, "Forward TLS connections to the dev server undecrypted; it serves its own certificate")
+	mirrorFlag           = flag.String("mirror", "", "Also send each request to this port or host:port, discarding its responses")
+	balanceFlag          = flag.String("balance", "", "Comma-separated ports or host:ports of more instances to spread requests over")
+	balanceModeFlag      = flag.String("balance-mode", "", "How --balance picks an instance: round-robin or fallback (default: round-robin)")
+	alertErrorRateFlag   = flag.Float64("alert-error-rate", 0, "Warn when more than this percent of requests fail with 5xx over a minute")
+	alertRequestRateFlag = flag.Float64("alert-request-rate", 0, "Warn when the route gets more than this many requests per second over a minute")
+	stripPrefixFlag      = flag.String("strip-prefix", "", "Remove this path prefix (e.g. /api) before forwarding to the dev server")
+	rewriteLocationFlag  = flag.String("rewrite-location", "", "Comma-separated origins (e.g. localhost:3000) whose redirects are rewritten to the route's URL")
+	keepUpstreamURLsFlag = flag.Bool("keep-upstream-urls", false, "Don't rewrite the dev server's redirects to its own address, or cookies for Domain=localhost")
+	cookiesFlag          = flag.String("cookies", "", "Comma-separated cookie adjustments: secure, domain, samesite-none")
+	staticFlag           = flag.String("static", "", "Serve this directory from the daemon instead of running a dev server")
+	autoIndexFlag        = flag.Bool("autoindex", false, "With --static, list directories that have no index.html")
+	spaFlag              = flag.Bool("spa", false, "Serve the app's /index.html for page loads the dev server answers with 404 (client-side routes)")
+	spaIndexFlag         = flag.String("spa-index", "", "Path --spa serves instead of a missing page (default: /index.html)")
+	spaStatusFlag        = flag.String("spa-status", "", "Comma-separated statuses --spa replaces (default: 404)")
+	spaKeepStatusFlag    = flag.Bool("spa-keep-status", false, "With --spa, serve the index page with the dev server's status instead of 200")
+	trailingSlashFlag    = flag.String("trailing-slash", "", "Redirect paths to end with a slash (add) or not (strip)")
+	lowercaseHostFlag    = flag.Bool("lowercase-host", false, "Redirect hosts with capitals to lowercase")
+	stripWWWFlag         = flag.Bool("strip-www", false, "Redirect www.<name>.test to <name>.test")
+	canonicalStatusFlag  = flag.Int("canonical-status", 0, "Status for --trailing-slash, --lowercase-host, and --strip-www redirects: 301, 302, 307, or 308 (default: 301)")
+	maintenanceFlag      = flag.String("maintenance", "", "Cron schedule (e.g. \"0 12 * * mon-fri\") for maintenance windows that take the route down")
+	maintenanceForFlag   = flag.String("maintenance-for", "", "How long each --maintenance window lasts (e.g. 30m)")
+	throttleFlag         = flag.String("throttle", "", "Simulate a slow network: slow-3g, 3g, dsl, or down=KBPS,up=KBPS,latency=DURATION")
+	hostHeaderFlag       = flag.String("host-header", "", "Host header sent to the dev server: preserve, upstream, or custom:<host> (default: preserve)")
+	subdomainsFlag       = flag.Bool("subdomains", false, "Also route any <sub>.<name>.test to the dev server, with the subdomain in X-Paw-Subdomain")
+	udpFlag              = flag.Bool("udp", false, "The dev server listens for UDP on PORT; forward datagrams and publish the port as a DNS SRV record")
+	poolMaxIdle          = flag.Int("pool-max-idle", 0, "Idle keep-alive connections the daemon keeps to the dev server (-1 disables keep-alives)")
+	poolIdleTimeout      = flag.Duration("pool-idle-timeout", 0, "Close pooled connections to the dev server after this long unused")
+	hstsFlag             = flag.String("hsts", "", "Handle the dev server's Strict-Transport-Security header: keep, strip, or rewrite (default: daemon setting)")
+	crashLogFlag         = flag.Bool("crash-log", false, "Also save a crash report with the last output lines under the support directory's crash/")
+	ptyFlag              = flag.Bool("pty", false, "Run the dev server on a pseudo-terminal, for colors and interactive prompts")
+	noTrustEnvFlag       = flag.Bool("no-trust-env", false, "Don't point SSL_CERT_FILE, REQUESTS_CA_BUNDLE, DENO_CERT, CURL_CA_BUNDLE, and GIT_SSL_CAINFO at the CA")
+	showVersion          = flag.Bool("version", false, "Show version")
+	showVersionShort     = flag.Bool("v", false, "")
 )
 
+// Repeatable flags.
+var (
+	envFileFlag stringsFlag
+	portEnvFlag stringsFlag
+)
+
+func init() {
+	flag.Var(&envFileFlag, "env-file", "Load environment variables for the dev server from this file (repeatable)")
+	flag.Var(&portEnvFlag, "port-env", "Also pass the port in this environment variable, e.g. NUXT_PORT (repeatable)")
+}
+
+// stringsFlag collects the values of a repeatable flag in order.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
 type routeState struct {
-	mu       sync.RWMutex
-	name     string
-	upstream string
-	dir      string
+	mu          sync.RWMutex
+	name        string
+	upstream    string
+	dir         string
+	aliases     []string
+	static      *pawclient.StaticConfig
+	expired     chan struct{}
+	expiredOnce sync.Once
 }
 
 func newRouteState(name, dir string) *routeState {
-	return &routeState{name: name, dir: dir}
+	return &routeState{name: name, dir: dir, expired: make(chan struct{})}
+}
+
+// MarkExpired records that the daemon retired the route for inactivity.
+func (s *routeState) MarkExpired() {
+	s.expiredOnce.Do(func() { close(s.expired) })
+}
+
+// Expired is closed once the daemon has retired the route.
+func (s *routeState) Expired() <-chan struct{} {
+	return s.expired
 }
 
 func (s *routeState) SetName(name string) {
@@ -52,12 +137,252 @@ func (s *routeState) SetName(name string) {
 	s.name = name
 }
 
+// SetAliases records the extra route names registered for this app.
+func (s *routeState) SetAliases(aliases []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aliases = append([]string(nil), aliases...)
+}
+
+// Aliases returns a copy of the registered alias names.
+func (s *routeState) Aliases() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.aliases...)
+}
+
 func (s *routeState) SetUpstream(upstream string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.upstream = upstream
 }
 
+// SetStatic records that the route serves a directory instead of an
+// upstream, so it can be re-registered without one.
+func (s *routeState) SetStatic(static *pawclient.StaticConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.static = static
+}
+
+// Static returns the static config the route was registered with, or nil.
+func (s *routeState) Static() *pawclient.StaticConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.static
+}
+
+// routeOptions holds optional per-route settings sent with every
+// registration (including automatic re-registration after daemon restarts).
+type routeOptions struct {
+	Auth           *pawclient.RouteAuth
+	Headers        map[string]string
+	CORS           *pawclient.CORSConfig
+	Preview        string
+	IdleTimeout    string
+	Project        string
+	ProxyProtocol  bool
+	TrustForwarded bool
+	PlainHTTP      bool
+	Passthrough    bool
+	UDP            bool
+	Mirror         string
+	Rewrite        *pawclient.RewriteConfig
+	Cookies        *pawclient.CookieConfig
+	Pool           *pawclient.PoolConfig
+	Balance        *pawclient.BalanceConfig
+	Alerts         *pawclient.AlertConfig
+	Throttle       *pawclient.ThrottleConfig
+	Static         *pawclient.StaticConfig
+	SPA            *pawclient.SPAConfig
+	Canonical      *pawclient.CanonicalConfig
+	Maintenance    *pawclient.MaintenanceConfig
+	HSTS           string
+	HostHeader     string
+	Subdomains     bool
+}
+
+// registrationOptions is populated from flags in main.
+var registrationOptions routeOptions
+
+// parseCORSOptions builds CORS settings from the --cors and --cors-origins
+// flag values. Origins imply --cors.
+func parseCORSOptions(enabled bool, origins string) *pawclient.CORSConfig {
+	if !enabled && origins == "" {
+		return nil
+	}
+	cors := &pawclient.CORSConfig{}
+	for _, o := range strings.Split(origins, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			cors.Origins = append(cors.Origins, o)
+		}
+	}
+	return cors
+}
+
+// parseRewriteOptions builds rewrite rules from the --strip-prefix,
+// --rewrite-location, and --keep-upstream-urls flag values.
+func parseRewriteOptions(prefix, locations string, keepUpstreamURLs bool) *pawclient.RewriteConfig {
+	if prefix == "" && locations == "" && !keepUpstreamURLs {
+		return nil
+	}
+	rewrite := &pawclient.RewriteConfig{StripPrefix: prefix, KeepUpstreamURLs: keepUpstreamURLs}
+	for _, l := range strings.Split(locations, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			rewrite.Locations = append(rewrite.Locations, l)
+		}
+	}
+	return rewrite
+}
+
+// parseCookieOptions builds cookie adjustments from the --cookies flag
+// value, e.g. "secure,domain".
+func parseCookieOptions(s string) (*pawclient.CookieConfig, error) {
+	if s == "" {
+		return nil, nil
+	}
+	cookies := &pawclient.CookieConfig{}
+	for _, opt := range strings.Split(s, ",") {
+		switch strings.TrimSpace(opt) {
+		case "secure":
+			cookies.Secure = true
+		case "domain":
+			cookies.Domain = true
+		case "samesite-none":
+			cookies.SameSiteNone = true
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown cookie option %q: use secure, domain, or samesite-none", opt)
+		}
+	}
+	return cookies, nil
+}
+
+// parsePoolOptions builds pool settings from the --pool-max-idle and
+// --pool-idle-timeout flag values. Zero values keep the daemon defaults.
+func parsePoolOptions(maxIdle int, idleTimeout time.Duration) *pawclient.PoolConfig {
+	if maxIdle == 0 && idleTimeout == 0 {
+		return nil
+	}
+	pool := &pawclient.PoolConfig{MaxIdle: maxIdle}
+	if idleTimeout > 0 {
+		pool.IdleTimeout = idleTimeout.String()
+	}
+	return pool
+}
+
+// parseBalanceOptions builds balance settings from the --balance and
+// --balance-mode flag values, reading bare ports as ones on localhost.
+func parseBalanceOptions(upstreams, mode string) (*pawclient.BalanceConfig, error) {
+	if upstreams == "" {
+		if mode != "" {
+			return nil, fmt.Errorf("--balance-mode requires --balance")
+		}
+		return nil, nil
+	}
+	if mode != "" && mode != pawclient.BalanceRoundRobin && mode != pawclient.BalanceFallback {
+		return nil, fmt.Errorf("--balance-mode must be %s or %s", pawclient.BalanceRoundRobin, pawclient.BalanceFallback)
+	}
+	balance := &pawclient.BalanceConfig{Mode: mode}
+	for _, u := range strings.Split(upstreams, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			balance.Upstreams = append(balance.Upstreams, parseMirror(u))
+		}
+	}
+	return balance, nil
+}
+
+// parseSPAOptions builds SPA fallback settings from the --spa,
+// --spa-index, --spa-status, and --spa-keep-status flag values. The
+// others imply --spa.
+func parseSPAOptions(enabled bool, index, statuses string, keepStatus bool) (*pawclient.SPAConfig, error) {
+	if !enabled && index == "" && statuses == "" && !keepStatus {
+		return nil, nil
+	}
+	spa := &pawclient.SPAConfig{Index: index, KeepStatus: keepStatus}
+	for _, s := range strings.Split(statuses, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		status, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("--spa-status: %q is not a status code", s)
+		}
+		spa.Statuses = append(spa.Statuses, status)
+	}
+	if err := api.ValidateSPA(spa); err != nil {
+		return nil, err
+	}
+	return spa, nil
+}
+
+// parseCanonicalOptions builds canonical redirect rules from the
+// --trailing-slash, --lowercase-host, --strip-www, and --canonical-status
+// flag values, or nil when none are set.
+func parseCanonicalOptions(trailingSlash string, lowercaseHost, stripWWW bool, status int) (*pawclient.CanonicalConfig, error) {
+	if trailingSlash == "" && !lowercaseHost && !stripWWW {
+		if status != 0 {
+			return nil, fmt.Errorf("--canonical-status needs --trailing-slash, --lowercase-host, or --strip-www")
+		}
+		return nil, nil
+	}
+	canonical := &pawclient.CanonicalConfig{
+		TrailingSlash: trailingSlash,
+		LowercaseHost: lowercaseHost,
+		StripWWW:      stripWWW,
+		Status:        status,
+	}
+	if err := api.ValidateCanonical(canonical); err != nil {
+		return nil, err
+	}
+	return canonical, nil
+}
+
+// parseMaintenanceOptions builds a maintenance schedule from the
+// --maintenance and --maintenance-for flag values, which go together.
+func parseMaintenanceOptions(schedule, duration string) (*pawclient.MaintenanceConfig, error) {
+	if schedule == "" && duration == "" {
+		return nil, nil
+	}
+	if schedule == "" || duration == "" {
+		return nil, fmt.Errorf("--maintenance and --maintenance-for go together")
+	}
+	maintenance := &pawclient.MaintenanceConfig{Schedule: schedule, Duration: duration}
+	if err := api.ValidateMaintenance(maintenance); err != nil {
+		return nil, err
+	}
+	return maintenance, nil
+}
+
+// parseMirror turns a --mirror value into a host:port, reading a bare
+// port as one on localhost.
+func parseMirror(mirror string) string {
+	if mirror != "" && strings.Trim(mirror, "0123456789") == "" {
+		return "localhost:" + mirror
+	}
+	return mirror
+}
+
+// parseRouteOptions builds registration options from the --auth and
+// --auth-token flag values.
+func parseRouteOptions(auth, token string) (routeOptions, error) {
+	var opts routeOptions
+	if auth != "" && token != "" {
+		return opts, fmt.Errorf("--auth and --auth-token are mutually exclusive")
+	}
+	if auth != "" {
+		user, pass, ok := strings.Cut(auth, ":")
+		if !ok || user == "" || pass == "" {
+			return opts, fmt.Errorf("--auth must be in user:password form")
+		}
+		opts.Auth = &pawclient.RouteAuth{Username: user, Password: pass}
+	}
+	if token != "" {
+		opts.Auth = &pawclient.RouteAuth{Token: token}
+	}
+	return opts, nil
+}
+
 func (s *routeState) Snapshot() (name string, upstream string, dir string) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -78,11 +403,99 @@ func main() {
 		return
 	}
 
-	if flag.NArg() == 0 {
+	if flag.NArg() == 0 && *staticFlag == "" {
 		help.UpCommand.Render(os.Stderr)
 		os.Exit(1)
 	}
 
+	opts, err := parseRouteOptions(*authFlag, *tokenFlag)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	opts.CORS = parseCORSOptions(*corsFlag, *corsOrigins)
+	opts.ProxyProtocol = *proxyProtocolFlag
+	opts.TrustForwarded = *trustForwardedFlag
+	opts.PlainHTTP = *plainHTTPFlag
+	opts.Subdomains = *subdomainsFlag
+	opts.Passthrough = *passthroughFlag
+	opts.UDP = *udpFlag
+	opts.Mirror = parseMirror(*mirrorFlag)
+	opts.Rewrite = parseRewriteOptions(*stripPrefixFlag, *rewriteLocationFlag, *keepUpstreamURLsFlag)
+	if err := api.ValidateHSTS(*hstsFlag); err != nil {
+		fmt.Printf("Error: --hsts: %v\n", err)
+		os.Exit(1)
+	}
+	opts.HSTS = *hstsFlag
+	if err := api.ValidateHostHeader(*hostHeaderFlag); err != nil {
+		fmt.Printf("Error: --host-header: %v\n", err)
+		os.Exit(1)
+	}
+	opts.HostHeader = *hostHeaderFlag
+	if opts.Cookies, err = parseCookieOptions(*cookiesFlag); err != nil {
+		fmt.Printf("Error: --cookies: %v\n", err)
+		os.Exit(1)
+	}
+	opts.Pool = parsePoolOptions(*poolMaxIdle, *poolIdleTimeout)
+	if opts.Balance, err = parseBalanceOptions(*balanceFlag, *balanceModeFlag); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *alertErrorRateFlag != 0 || *alertRequestRateFlag != 0 {
+		opts.Alerts = &pawclient.AlertConfig{ErrorRate: *alertErrorRateFlag, RequestRate: *alertRequestRateFlag}
+		if err := api.ValidateAlerts(opts.Alerts); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *throttleFlag != "" {
+		if opts.Throttle, err = api.ParseThrottle(*throttleFlag); err != nil {
+			fmt.Printf("Error: --throttle: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if opts.Static, err = parseStaticOptions(*staticFlag, *autoIndexFlag, flag.NArg()); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if opts.SPA, err = parseSPAOptions(*spaFlag, *spaIndexFlag, *spaStatusFlag, *spaKeepStatusFlag); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if opts.Canonical, err = parseCanonicalOptions(*trailingSlashFlag, *lowercaseHostFlag, *stripWWWFlag, *canonicalStatusFlag); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if opts.Maintenance, err = parseMaintenanceOptions(*maintenanceFlag, *maintenanceForFlag); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	registrationOptions = opts
+
+	relay, err := parseRelay(*portFlag, *hostPortFlag, os.Getenv)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if relay.port != 0 {
+		if err := checkPortFree(relay.port); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	envEntries, err := readEnvFiles(envFileFlag)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	portEnv, err := portEnvNames(portEnvFlag, "package.json")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Get paths
 	p, err := paths.DefaultPaths()
 	if err != nil {
@@ -93,37 +506,86 @@ func main() {
 	caPath := p.CAPath
 
 	// Check if daemon is running via health endpoint
-	client := socketClient(socketPath)
-	{
-		resp, err := client.Get("http://unix/health")
-		if err != nil {
-			fmt.Println("Error: paw-proxy daemon not running")
-			fmt.Println("Run: sudo paw-proxy setup")
+	client, err := apiClient(socketPath, p.SupportDir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	routeTokenDir = filepath.Join(p.SupportDir, "route-tokens")
+	if _, err := client.Health(context.Background()); err != nil {
+		if pawclient.IsUnauthorized(err) {
+			fmt.Println("Error: paw-proxy rejected the API token (check PAW_PROXY_API_TOKEN)")
+			os.Exit(1)
+		}
+		fmt.Println("Error: paw-proxy daemon not running")
+		if inContainer() && os.Getenv("PAW_PROXY_API") == "" {
+			fmt.Println("Inside a container, mount the host's paw-proxy socket or set PAW_PROXY_API")
 			os.Exit(1)
 		}
-		resp.Body.Close()
+		fmt.Println("Run: sudo paw-proxy setup")
+		os.Exit(1)
 	}
 
 	// Check for Docker Compose mode
 	args := flag.Args()
 	dc := detectDockerCompose(args)
 	if dc.detected {
-		runDockerComposeMode(client, dc, args, caPath)
+		runDockerComposeMode(client, dc, args, caPath, p.SupportDir)
 		return
 	}
 
-	// Determine app name (single-app flow)
-	name := determineName(*nameFlag)
+	// Load shared team defaults and per-developer overrides (.paw/*.yaml)
 	dir, _ := os.Getwd()
+	projectCfg, projectCfgDir, err := loadProjectConfig(dir)
+	if err != nil {
+		fmt.Printf("Error: project config: %v\n", err)
+		os.Exit(1)
+	}
+	if projectCfgDir != "" {
+		fmt.Printf("📋 Using project config from %s\n", projectCfgDir)
+	}
+	if len(projectCfg.Headers) > 0 {
+		registrationOptions.Headers = projectCfg.Headers
+	}
+	warmupPath := *warmupFlag
+	if warmupPath == "" {
+		warmupPath = projectCfg.Warmup
+	}
+
+	// Determine app name (single-app flow). -n wins over project config.
+	explicitName := *nameFlag
+	if explicitName == "" {
+		explicitName = projectCfg.Name
+	}
+	name := determineName(explicitName)
+	// Previews are grouped with the app they preview.
+	registrationOptions.Project = routeProject(name)
+	if *previewFlag != "" {
+		label := sanitizeName(*previewFlag)
+		name = previewName(label, name)
+		registrationOptions.Preview = label
+		registrationOptions.IdleTimeout = previewIdle.String()
+	}
 	state := newRouteState(name, dir)
 
-	// Setup cleanup (deregisters route from daemon)
+	// Setup cleanup (deregisters route and aliases from daemon)
 	cleanup := func() {
 		fmt.Printf("\n🛑 Removing mapping for %s.test...\n", name)
 		notification.Notify("paw-proxy", fmt.Sprintf("Removing mapping for %s.test", name))
 		if err := deregisterRoute(client, name); err != nil {
 			log.Printf("warning: cleanup deregistration failed: %v", err)
 		}
+		deregisterAliases(client, state.Aliases())
+	}
+
+	// One terminal for the whole session, so input typed during a restart
+	// reaches the next run.
+	var term *ptyTerminal
+	if *ptyFlag {
+		if term, err = newPTYTerminal(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
 	// Start heartbeat (runs for the entire lifetime, across restarts)
@@ -135,26 +597,40 @@ func main() {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	var exitCode int
+	var restarts, attempt int
+	var uptime time.Duration
+	if registrationOptions.Static != nil {
+		name, exitCode = runStatic(client, state, name, dir, projectCfg.Aliases, sigCh)
+		goto done
+	}
 	for {
-		// Find free port
-		port, err := findFreePort()
-		if err != nil {
-			fmt.Printf("Error finding free port: %v\n", err)
-			os.Exit(1)
+		// Find free port unless the dev server needs a fixed one (e.g. a
+		// devcontainer port published to the host)
+		port := relay.port
+		if port == 0 {
+			port, err = findFreePort()
+			if err != nil {
+				fmt.Printf("Error finding free port: %v\n", err)
+				os.Exit(1)
+			}
+		} else if err := checkPortFree(port); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exitCode = 1
+			break
 		}
 
-		upstream := fmt.Sprintf("localhost:%d", port)
+		upstream := relay.upstream(port)
 		state.SetUpstream(upstream)
 
-		// On restart, deregister old route first so re-registration succeeds
-		if exitCode != 0 {
-			if err := deregisterRoute(client, name); err != nil {
-				log.Printf("warning: restart deregistration failed: %v", err)
-			}
+		// Register route (updated in place on restart, with automatic
+		// fallback to directory name on conflict). Previews keep their exact
+		// name: a fallback would drop the preview label.
+		finalName := name
+		if registrationOptions.Preview != "" {
+			err = registerRoute(client, name, upstream, dir)
+		} else {
+			finalName, err = registerWithFallback(client, name, upstream, dir)
 		}
-
-		// Register route (with automatic fallback to directory name on conflict)
-		finalName, err := registerWithFallback(client, name, upstream, dir)
 		if err != nil {
 			fmt.Printf("Error registering route: %v\n", err)
 			os.Exit(1)
@@ -163,11 +639,30 @@ func main() {
 			name = finalName
 			state.SetName(name)
 		}
+		if registrationOptions.Preview == "" {
+			state.SetAliases(registerAliases(client, projectCfg.Aliases, upstream, dir))
+		}
 
-		fmt.Printf("🔗 Mapping https://%s.test -> localhost:%d...\n", name, port)
+		if relay.active() {
+			fmt.Printf("🔗 Mapping https://%s.test -> %s (container port %d)...\n", name, upstream, port)
+		} else {
+			fmt.Printf("🔗 Mapping https://%s.test -> localhost:%d...\n", name, port)
+		}
 		if exitCode == 0 {
 			fmt.Printf("🚀 Project is live at: https://%s.test\n", name)
+			for _, alias := range state.Aliases() {
+				fmt.Printf("   Also at: https://%s.test\n", alias)
+			}
 			notification.Notify("paw-proxy", fmt.Sprintf("Project is live at: https://%s.test", name))
+			if registrationOptions.Auth != nil {
+				fmt.Println("🔒 Route requires credentials")
+			}
+			if registrationOptions.UDP {
+				fmt.Printf("📡 Forwarding UDP; look up the port with: dig @127.0.0.1 -p 9353 SRV _app._udp.%s.test\n", name)
+			}
+			if registrationOptions.Preview != "" {
+				fmt.Printf("🧪 Preview %s, removed after %s without requests\n", registrationOptions.Preview, *previewIdle)
+			}
 		} else {
 			fmt.Printf("🔄 Restarting (previous exit code: %d)...\n", exitCode)
 		}
@@ -178,21 +673,69 @@ func main() {
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
-		cmd.Env = append(os.Environ(),
+		routeEnv := []string{
 			fmt.Sprintf("PORT=%d", port),
 			fmt.Sprintf("APP_DOMAIN=%s.test", name),
 			fmt.Sprintf("APP_URL=https://%s.test", name),
 			"HTTPS=true",
 			fmt.Sprintf("NODE_EXTRA_CA_CERTS=%s", caPath),
-		)
+		}
+		for _, name := range portEnv {
+			routeEnv = append(routeEnv, fmt.Sprintf("%s=%d", name, port))
+		}
+		// Env files sit between the shell environment and the route's own
+		// variables, which always win.
+		fileEnv := resolveEnvFile(envEntries, routeEnv, os.LookupEnv)
+		getenv := envGetter(fileEnv)
+		cmd.Env = append(os.Environ(), fileEnv...)
+		cmd.Env = append(cmd.Env, routeEnv...)
+		if !*noTrustEnvFlag {
+			cmd.Env = append(cmd.Env, trust.ChildEnv(p.SupportDir, caPath, getenv)...)
+		}
+		// A published port only reaches servers bound to all interfaces.
+		if relay.active() && getenv("HOST") == "" {
+			cmd.Env = append(cmd.Env, "HOST=0.0.0.0")
+		}
 
 		// Run child in its own process group so we can signal the entire group
-		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		group := newProcessGroup(cmd)
+
+		if hook := projectCfg.Hooks[hookPreStart]; hook != "" {
+			if err := runProjectHook(hookPreStart, hook, filepath.Dir(projectCfgDir), cmd.Env); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				exitCode = 1
+				break
+			}
+		}
+
+		// Keep the tail of the output for a crash report.
+		tail := &outputTail{}
+		captured := teeOutput(cmd, tail)
+		detachPTY := func() {}
+		if term != nil {
+			captured = true
+			detach, err := term.attach(cmd, tail)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				exitCode = 1
+				break
+			}
+			detachPTY = detach
+		}
 
-		if err := cmd.Start(); err != nil {
+		if err := group.start(); err != nil {
 			fmt.Printf("Error starting command: %v\n", err)
+			detachPTY()
 			break
 		}
+		startedAt := time.Now()
+
+		// Warm up the dev server in the background; cancelled when this
+		// child exits so a restart does not race against a stale port.
+		warmCtx, warmCancel := context.WithCancel(ctx)
+		if warmupPath != "" {
+			go warmUp(warmCtx, fmt.Sprintf("localhost:%d", port), name+".test", normalizeWarmupPath(warmupPath))
+		}
 
 		// Wait for signal or command exit
 		doneCh := make(chan error, 1)
@@ -204,14 +747,15 @@ func main() {
 		select {
 		case sig := <-sigCh:
 			gotSignal = true
-			// Forward signal to entire process group (negative PID)
-			syscall.Kill(-cmd.Process.Pid, sig.(syscall.Signal))
-			// Wait for child with timeout
-			select {
-			case <-doneCh:
-			case <-time.After(5 * time.Second):
-				syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
-			}
+			// Park the route on the waiting page first, then forward the
+			// signal to the entire process group, waiting for the child
+			// with a timeout
+			pauseRoutes(client, append([]string{name}, state.Aliases()...))
+			group.stop(sig, doneCh, 5*time.Second)
+		case <-state.Expired():
+			gotSignal = true
+			fmt.Printf("\n⏰ Preview %s.test expired after %s without requests\n", name, *previewIdle)
+			group.stop(syscall.SIGTERM, doneCh, 5*time.Second)
 		case err := <-doneCh:
 			if exitErr, ok := err.(*exec.ExitError); ok {
 				exitCode = exitErr.ExitCode()
@@ -219,30 +763,71 @@ func main() {
 				exitCode = 0
 			}
 		}
+		group.close()
+		detachPTY()
+		warmCancel()
+		ran := time.Since(startedAt)
+		uptime += ran
 
 		if gotSignal {
 			break
 		}
 
+		if exitCode != 0 {
+			report := crashReport(name, upstream, dir, exitCode, ran, tail.Lines(), captured)
+			fmt.Print("\n" + report)
+			if *crashLogFlag {
+				if path, err := writeCrashLog(p.SupportDir, name, time.Now(), report); err != nil {
+					log.Printf("warning: crash log: %v", err)
+				} else {
+					fmt.Printf("   Saved to %s\n", path)
+				}
+			}
+		}
+
 		// If not restarting, or clean exit, stop the loop
 		if !*restartFlag || exitCode == 0 {
 			break
 		}
+		if *maxRestartsFlag > 0 && restarts >= *maxRestartsFlag {
+			fmt.Printf("\n❌ Process exited with code %d, giving up after %d restarts\n", exitCode, restarts)
+			break
+		}
 
-		fmt.Printf("\n⚠️  Process exited with code %d, restarting in 1s...\n", exitCode)
+		// Back off so a crash-looping command doesn't spin; a run that
+		// stayed up a while starts the backoff over.
+		if ran >= restartResetAfter {
+			attempt = 0
+		}
+		delay := restartDelay(attempt, rand.Float64)
+		attempt++
+		restarts++
+		fmt.Printf("\n⚠️  Process exited with code %d, restarting in %s...\n", exitCode, delay)
 
-		// Brief delay before restart to avoid tight crash loops
 		select {
-		case <-time.After(1 * time.Second):
+		case <-time.After(delay):
 		case <-sigCh:
 			goto done
+		case <-state.Expired():
+			goto done
 		}
 	}
 
 done:
 
+	if term != nil {
+		term.close()
+	}
+	if restarts > 0 {
+		fmt.Println(restartSummary(restarts, uptime))
+	}
 	cancel()
 	cleanup()
+	if hook := projectCfg.Hooks[hookPostStop]; hook != "" {
+		if err := runProjectHook(hookPostStop, hook, filepath.Dir(projectCfgDir), os.Environ()); err != nil {
+			log.Printf("warning: %v", err)
+		}
+	}
 	os.Exit(exitCode)
 }
 
@@ -255,6 +840,23 @@ func findFreePort() (int, error) {
 	return l.Addr().(*net.TCPAddr).Port, nil
 }
 
+// checkPortFree reports who holds port, so a dev server pinned with --port
+// fails here with a clear message instead of crashing on EADDRINUSE. Both
+// loopback and the wildcard address are tried, since a server may listen
+// on either.
+func checkPortFree(port int) error {
+	for _, addr := range []string{fmt.Sprintf("127.0.0.1:%d", port), fmt.Sprintf(":%d", port)} {
+		l, err := net.Listen("tcp", addr)
+		if isAddrInUse(err) {
+			return fmt.Errorf("--port: %s; stop it or pick another port", daemon.FindPortHolder(port))
+		}
+		if err == nil {
+			l.Close()
+		}
+	}
+	return nil
+}
+
 func determineName(explicit string) string {
 	if explicit != "" {
 		return sanitizeName(explicit)
@@ -302,126 +904,254 @@ func sanitizeName(name string) string {
 	return s
 }
 
-func socketClient(socketPath string) *http.Client {
-	return &http.Client{
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				return net.Dial("unix", socketPath)
-			},
-		},
-		Timeout: 5 * time.Second,
+// apiClient returns a client for the daemon's control API: the unix socket
+// by default, or the token-protected TCP listener named by PAW_PROXY_API
+// for processes (e.g. in containers) that can't reach the socket.
+func apiClient(socketPath, supportDir string) (*pawclient.Client, error) {
+	addr := os.Getenv("PAW_PROXY_API")
+	if addr == "" {
+		return pawclient.New(socketPath), nil
+	}
+	addr = strings.TrimSuffix(strings.TrimPrefix(addr, "http://"), "/")
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("PAW_PROXY_API: %w", err)
 	}
-}
-
-// conflictError represents a route name conflict from the daemon API.
-type conflictError struct {
-	dir string
-}
 
-func (e *conflictError) Error() string {
-	return fmt.Sprintf("route conflict: already registered from %s", e.dir)
+	token := os.Getenv("PAW_PROXY_API_TOKEN")
+	if token == "" {
+		data, err := os.ReadFile(filepath.Join(supportDir, api.TokenFile))
+		if err != nil {
+			return nil, fmt.Errorf("PAW_PROXY_API is set but PAW_PROXY_API_TOKEN is not, and the token file is unreadable: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	return pawclient.NewTCP(addr, token), nil
 }
 
-func registerRoute(client *http.Client, name, upstream, dir string) error {
-	body, _ := json.Marshal(map[string]string{
-		"name":     name,
-		"upstream": upstream,
-		"dir":      dir,
+// registerRoute registers name, or takes it over if it is still registered
+// from dir (by an earlier run that crashed, or before a restart).
+func registerRoute(client *pawclient.Client, name, upstream, dir string) error {
+	loadRouteToken(client, name)
+	opts := registrationOptions
+	err := client.Upsert(context.Background(), pawclient.RegisterRequest{
+		Name:           name,
+		Upstream:       upstream,
+		Dir:            dir,
+		Auth:           opts.Auth,
+		Headers:        opts.Headers,
+		CORS:           opts.CORS,
+		Preview:        opts.Preview,
+		Project:        opts.Project,
+		IdleTimeout:    opts.IdleTimeout,
+		ProxyProtocol:  opts.ProxyProtocol,
+		TrustForwarded: opts.TrustForwarded,
+		PlainHTTP:      opts.PlainHTTP,
+		Passthrough:    opts.Passthrough,
+		UDP:            opts.UDP,
+		Mirror:         opts.Mirror,
+		Rewrite:        opts.Rewrite,
+		Cookies:        opts.Cookies,
+		Pool:           opts.Pool,
+		Balance:        opts.Balance,
+		Alerts:         opts.Alerts,
+		Throttle:       opts.Throttle,
+		Static:         opts.Static,
+		SPA:            opts.SPA,
+		Canonical:      opts.Canonical,
+		Maintenance:    opts.Maintenance,
+		HSTS:           opts.HSTS,
+		HostHeader:     opts.HostHeader,
+		Subdomains:     opts.Subdomains,
+		BindProcess:    true,
 	})
-
-	resp, err := client.Post("http://unix/routes", "application/json", bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusConflict {
-		var errResp map[string]string
-		json.NewDecoder(resp.Body).Decode(&errResp)
-		return &conflictError{dir: errResp["existingDir"]}
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		var errResp map[string]string
-		json.NewDecoder(resp.Body).Decode(&errResp)
-		return fmt.Errorf("%s: %s", resp.Status, errResp["error"])
-	}
-
+	saveRouteToken(client, name)
 	return nil
 }
 
-func deregisterRoute(client *http.Client, name string) error {
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("http://unix/routes/%s", name), nil)
-	if err != nil {
-		return err
-	}
-	resp, err := client.Do(req)
-	if err != nil {
+func deregisterRoute(client *pawclient.Client, name string) error {
+	if err := client.Deregister(context.Background(), name); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
-		return fmt.Errorf("unexpected deregister status: %s", resp.Status)
-	}
-
+	forgetRouteToken(name)
 	return nil
 }
 
-func heartbeat(ctx context.Context, client *http.Client, state *routeState) {
+func heartbeat(ctx context.Context, client *pawclient.Client, state *routeState) {
 	heartbeatWithInterval(ctx, client, state, 10*time.Second)
 }
 
-func heartbeatWithInterval(ctx context.Context, client *http.Client, state *routeState, interval time.Duration) {
+func heartbeatWithInterval(ctx context.Context, client *pawclient.Client, state *routeState, interval time.Duration) {
+	names := func() []string {
+		name, _, _ := state.Snapshot()
+		return append([]string{name}, state.Aliases()...)
+	}
+	handle := func(n string, err error) (stop bool) {
+		name, upstream, dir := state.Snapshot()
+		if heartbeatFailed(client, n, upstream, dir, state.Static(), err) && n == name {
+			state.MarkExpired()
+			return true
+		}
+		return false
+	}
+	poll := func() (stop bool) {
+		name, upstream, dir := state.Snapshot()
+		for _, n := range names() {
+			if heartbeatOnce(client, n, upstream, dir, state.Static()) && n == name {
+				state.MarkExpired()
+				return true
+			}
+		}
+		return false
+	}
+	keepAlive(ctx, client, interval, names, handle, poll)
+}
+
+// keepAlive holds the routes returned by names alive over one keep-alive
+// connection, so the daemon removes them as soon as up dies, even from
+// SIGKILL. handle is called for each route the daemon didn't refresh.
+// While the connection is down (e.g. the daemon is restarting), and for
+// good with daemons that don't support it, poll heartbeats each route once
+// per interval instead. Either callback returning true stops keepAlive.
+func keepAlive(ctx context.Context, client *pawclient.Client, interval time.Duration, names func() []string, handle func(name string, err error) (stop bool), poll func() (stop bool)) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	onStatus := func(status pawclient.KeepAliveStatus) {
+		for _, failed := range []struct {
+			names []string
+			err   error
+		}{
+			{status.Missing, pawclient.ErrNotFound},
+			{status.Expired, pawclient.ErrExpired},
+			{status.Denied, pawclient.ErrNotOwner},
+		} {
+			for _, n := range failed.names {
+				if handle(n, failed.err) {
+					cancel()
+					return
+				}
+			}
+		}
+	}
+
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
-
+	supported := true
 	for {
+		if supported {
+			err := client.KeepAlive(ctx, interval, names, onStatus)
+			supported = !errors.Is(err, pawclient.ErrKeepAliveUnsupported)
+		}
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			name, _, _ := state.Snapshot()
-			req, err := http.NewRequest("POST", fmt.Sprintf("http://unix/routes/%s/heartbeat", name), nil)
-			if err != nil {
-				log.Printf("warning: heartbeat request creation failed: %v", err)
-				continue
-			}
-			resp, err := client.Do(req)
-			if err != nil {
-				log.Printf("warning: heartbeat failed: %v", err)
-				continue
-			}
+		}
+		if poll() {
+			return
+		}
+	}
+}
 
-			if resp.StatusCode == http.StatusOK {
-				resp.Body.Close()
-				continue
-			}
-			resp.Body.Close()
+// heartbeatOnce refreshes a single route, re-registering it if the daemon
+// no longer knows about it (e.g. after a daemon restart). It reports true
+// when a preview route was retired by the daemon for inactivity, in which
+// case it is not re-registered. A route with static set serves a directory
+// and is re-registered without an upstream.
+func heartbeatOnce(client *pawclient.Client, name, upstream, dir string, static *pawclient.StaticConfig) (expired bool) {
+	return heartbeatFailed(client, name, upstream, dir, static, client.Heartbeat(context.Background(), name))
+}
 
-			if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
-				name, upstream, dir := state.Snapshot()
-				if upstream == "" {
-					log.Printf("warning: heartbeat route missing but no upstream available for %s", name)
-					continue
-				}
-				if err := registerRoute(client, name, upstream, dir); err != nil {
-					log.Printf("warning: auto re-register failed: %v", err)
-					continue
-				}
-				log.Printf("route re-registered after daemon restart: %s.test -> %s", name, upstream)
-				continue
-			}
+// heartbeatFailed handles a failed heartbeat for a route, as
+// heartbeatOnce does. A nil err is a successful heartbeat.
+func heartbeatFailed(client *pawclient.Client, name, upstream, dir string, static *pawclient.StaticConfig, err error) (expired bool) {
+	if err == nil {
+		return false
+	}
+
+	gone := errors.Is(err, pawclient.ErrExpired)
+	if gone && registrationOptions.Preview != "" {
+		return true
+	}
+
+	if gone || pawclient.IsNotFound(err) {
+		target := upstream
+		if static != nil {
+			target = static.Root
+		}
+		if target == "" {
+			log.Printf("warning: heartbeat route missing but no upstream available for %s", name)
+			return false
+		}
+		if err := registerRoute(client, name, upstream, dir); err != nil {
+			log.Printf("warning: auto re-register failed: %v", err)
+			return false
+		}
+		log.Printf("route re-registered after daemon restart: %s.test -> %s", name, target)
+		return false
+	}
+
+	log.Printf("warning: heartbeat failed: %v", err)
+	return false
+}
+
+// routeProject returns the project routes are grouped under: --project if
+// set, otherwise fallback.
+func routeProject(fallback string) string {
+	if *projectFlag != "" {
+		return sanitizeName(*projectFlag)
+	}
+	return fallback
+}
+
+// previewName returns the route name for a preview of app, e.g.
+// "pr-123.myapp" for https://pr-123.myapp.test.
+func previewName(label, app string) string {
+	return label + "." + app
+}
+
+// registerAliases registers extra route names pointing at the same upstream.
+// Aliases that fail (e.g. already taken) are reported and skipped; the
+// names that were registered are returned.
+func registerAliases(client *pawclient.Client, aliases []string, upstream, dir string) []string {
+	var registered []string
+	for _, alias := range aliases {
+		alias = sanitizeName(alias)
+		if err := registerRoute(client, alias, upstream, dir); err != nil {
+			fmt.Printf("⚠️  Alias %s.test not registered: %v\n", alias, err)
+			continue
+		}
+		registered = append(registered, alias)
+	}
+	return registered
+}
+
+// deregisterAliases removes alias routes from the daemon.
+func deregisterAliases(client *pawclient.Client, aliases []string) {
+	for _, alias := range aliases {
+		if err := deregisterRoute(client, alias); err != nil {
+			log.Printf("warning: alias deregistration failed for %s: %v", alias, err)
+		}
+	}
+}
 
-			log.Printf("warning: heartbeat returned status %s", resp.Status)
+// pauseRoutes has the daemon show its waiting page for names while the
+// dev server stops, instead of the connection errors it would give on the
+// way down. The routes are removed once it has exited.
+func pauseRoutes(client *pawclient.Client, names []string) {
+	for _, name := range names {
+		if err := client.Pause(context.Background(), name); err != nil && !pawclient.IsNotFound(err) {
+			log.Printf("warning: pausing %s failed: %v", name, err)
 		}
 	}
 }
 
 func extractConflictDir(err error) string {
-	var ce *conflictError
+	var ce *pawclient.ConflictError
 	if errors.As(err, &ce) {
-		return ce.dir
+		return ce.ExistingDir
 	}
 	return ""
 }
@@ -429,7 +1159,7 @@ func extractConflictDir(err error) string {
 // registerWithFallback attempts to register a route. On a name conflict, it
 // falls back to using the directory basename (if different from the original
 // name). Returns the final registered name.
-func registerWithFallback(client *http.Client, name, upstream, dir string) (string, error) {
+func registerWithFallback(client *pawclient.Client, name, upstream, dir string) (string, error) {
 	err := registerRoute(client, name, upstream, dir)
 	if err == nil {
 		return name, nil
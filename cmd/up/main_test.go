@@ -5,16 +5,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
 )
 
-func unixHostClient(t *testing.T, ts *httptest.Server) *http.Client {
+func unixHostClient(t *testing.T, ts *httptest.Server) *pawclient.Client {
 	t.Helper()
 
 	parsed, err := url.Parse(ts.URL)
@@ -22,14 +29,14 @@ func unixHostClient(t *testing.T, ts *httptest.Server) *http.Client {
 		t.Fatalf("failed to parse test server URL: %v", err)
 	}
 
-	return &http.Client{
+	return pawclient.NewWithHTTPClient(&http.Client{
 		Transport: &http.Transport{
 			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
 				return (&net.Dialer{}).DialContext(ctx, "tcp", parsed.Host)
 			},
 		},
 		Timeout: 2 * time.Second,
-	}
+	})
 }
 
 func TestSanitizeName(t *testing.T) {
@@ -75,13 +82,13 @@ func TestHeartbeatReRegistersRouteOnNotFound(t *testing.T) {
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch {
-		case r.Method == http.MethodPost && r.URL.Path == "/routes/myapp/heartbeat":
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/routes/myapp/heartbeat":
 			if heartbeatCount.Add(1) == 1 {
 				w.WriteHeader(http.StatusNotFound)
 				return
 			}
 			w.WriteHeader(http.StatusOK)
-		case r.Method == http.MethodPost && r.URL.Path == "/routes":
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/routes/"):
 			registerCount.Add(1)
 			w.WriteHeader(http.StatusOK)
 		default:
@@ -109,12 +116,164 @@ func TestHeartbeatReRegistersRouteOnNotFound(t *testing.T) {
 	t.Fatalf("expected re-registration after heartbeat 404, register calls=%d", registerCount.Load())
 }
 
+func TestHeartbeatReRegistersStaticRouteOnNotFound(t *testing.T) {
+	old := registrationOptions
+	registrationOptions.Static = &pawclient.StaticConfig{Root: "/tmp/project/dist"}
+	defer func() { registrationOptions = old }()
+
+	var heartbeatCount atomic.Int32
+	registered := make(chan pawclient.RegisterRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/routes/docs/heartbeat":
+			if heartbeatCount.Add(1) == 1 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/routes/docs":
+			var req pawclient.RegisterRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			select {
+			case registered <- req:
+			default:
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := unixHostClient(t, server)
+	state := newRouteState("docs", "/tmp/project")
+	state.SetStatic(registrationOptions.Static)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go heartbeatWithInterval(ctx, client, state, 20*time.Millisecond)
+
+	select {
+	case req := <-registered:
+		if req.Upstream != "" || req.Static == nil || req.Static.Root != "/tmp/project/dist" {
+			t.Errorf("re-registered with upstream %q, static %+v; want the static root", req.Upstream, req.Static)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("static route not re-registered after heartbeat 404")
+	}
+}
+
+func TestHeartbeatKeepAliveReRegistersMissingRoute(t *testing.T) {
+	var registerCount atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/heartbeat":
+			rc := http.NewResponseController(w)
+			rc.EnableFullDuplex()
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"missing":["myapp"]}` + "\n"))
+			rc.Flush()
+			io.Copy(io.Discard, r.Body)
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/routes/"):
+			registerCount.Add(1)
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := unixHostClient(t, server)
+	state := newRouteState("myapp", "/tmp/project")
+	state.SetUpstream("localhost:3000")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go heartbeatWithInterval(ctx, client, state, 20*time.Millisecond)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if registerCount.Load() > 0 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	t.Fatal("expected re-registration after keep-alive reported the route missing")
+}
+
+func TestRegisterRouteReusesSavedToken(t *testing.T) {
+	var presented atomic.Value
+	presented.Store("")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			presented.Store(r.Header.Get("X-Paw-Route-Token"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"token":"tok-1"}`))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	old := routeTokenDir
+	routeTokenDir = t.TempDir()
+	defer func() { routeTokenDir = old }()
+
+	if err := registerRoute(unixHostClient(t, server), "myapp", "localhost:3000", "/tmp/project"); err != nil {
+		t.Fatalf("registerRoute() = %v", err)
+	}
+	if data, err := os.ReadFile(routeTokenPath("myapp")); err != nil || strings.TrimSpace(string(data)) != "tok-1" {
+		t.Fatalf("saved token = %q, %v", data, err)
+	}
+
+	// A later run (e.g. after a crash) presents the saved token.
+	client := unixHostClient(t, server)
+	if err := registerRoute(client, "myapp", "localhost:3000", "/tmp/project"); err != nil {
+		t.Fatalf("registerRoute() again = %v", err)
+	}
+	if got := presented.Load(); got != "tok-1" {
+		t.Errorf("second run presented token %q, want tok-1", got)
+	}
+
+	if err := deregisterRoute(client, "myapp"); err != nil {
+		t.Fatalf("deregisterRoute() = %v", err)
+	}
+	if _, err := os.Stat(routeTokenPath("myapp")); !os.IsNotExist(err) {
+		t.Errorf("token file left behind after deregister: %v", err)
+	}
+}
+
+func TestRegisterRouteSendsProject(t *testing.T) {
+	var got pawclient.RegisterRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"token":"tok"}`))
+	}))
+	defer server.Close()
+
+	old := registrationOptions
+	registrationOptions.Project = "shop"
+	defer func() { registrationOptions = old }()
+
+	if err := registerRoute(unixHostClient(t, server), "web.shop", "localhost:3000", "/tmp/shop"); err != nil {
+		t.Fatalf("registerRoute() = %v", err)
+	}
+	if got.Project != "shop" {
+		t.Errorf("registered project = %q, want shop", got.Project)
+	}
+}
+
 func TestDeregisterRouteStatusHandling(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
-		case "/routes/myapp":
+		case "/v1/routes/myapp":
 			w.WriteHeader(http.StatusInternalServerError)
-		case "/routes/missing":
+		case "/v1/routes/missing":
 			w.WriteHeader(http.StatusNotFound)
 		default:
 			w.WriteHeader(http.StatusOK)
@@ -231,7 +390,7 @@ func TestRegisterWithFallback(t *testing.T) {
 
 func TestExtractConflictDir(t *testing.T) {
 	t.Run("conflict error returns dir", func(t *testing.T) {
-		err := &conflictError{dir: "/home/user/project"}
+		err := &pawclient.ConflictError{ExistingDir: "/home/user/project"}
 		got := extractConflictDir(err)
 		if got != "/home/user/project" {
 			t.Errorf("extractConflictDir() = %q, want %q", got, "/home/user/project")
@@ -239,7 +398,7 @@ func TestExtractConflictDir(t *testing.T) {
 	})
 
 	t.Run("wrapped conflict error returns dir", func(t *testing.T) {
-		err := fmt.Errorf("registration failed: %w", &conflictError{dir: "/tmp/app"})
+		err := fmt.Errorf("registration failed: %w", &pawclient.ConflictError{ExistingDir: "/tmp/app"})
 		got := extractConflictDir(err)
 		if got != "/tmp/app" {
 			t.Errorf("extractConflictDir() = %q, want %q", got, "/tmp/app")
@@ -261,3 +420,296 @@ func TestExtractConflictDir(t *testing.T) {
 		}
 	})
 }
+
+func TestParseRouteOptions(t *testing.T) {
+	opts, err := parseRouteOptions("dev:s3:cret", "")
+	if err != nil {
+		t.Fatalf("parseRouteOptions() error: %v", err)
+	}
+	if opts.Auth == nil || opts.Auth.Username != "dev" || opts.Auth.Password != "s3:cret" {
+		t.Fatalf("unexpected basic auth: %+v", opts.Auth)
+	}
+
+	opts, err = parseRouteOptions("", "tok")
+	if err != nil {
+		t.Fatalf("parseRouteOptions() error: %v", err)
+	}
+	if opts.Auth == nil || opts.Auth.Token != "tok" {
+		t.Fatalf("unexpected token auth: %+v", opts.Auth)
+	}
+
+	if _, err := parseRouteOptions("nopassword", ""); err == nil {
+		t.Error("expected error for --auth without password")
+	}
+	if _, err := parseRouteOptions("dev:pw", "tok"); err == nil {
+		t.Error("expected error when both flags are set")
+	}
+}
+
+func TestParseCORSOptions(t *testing.T) {
+	if got := parseCORSOptions(false, ""); got != nil {
+		t.Errorf("expected nil when CORS disabled, got %+v", got)
+	}
+
+	got := parseCORSOptions(true, "")
+	if got == nil || len(got.Origins) != 0 {
+		t.Errorf("expected permissive CORS, got %+v", got)
+	}
+
+	got = parseCORSOptions(false, "https://app.test, https://admin.test,")
+	if got == nil || len(got.Origins) != 2 || got.Origins[1] != "https://admin.test" {
+		t.Errorf("expected origins to imply --cors, got %+v", got)
+	}
+}
+
+func TestParsePoolOptions(t *testing.T) {
+	if got := parsePoolOptions(0, 0); got != nil {
+		t.Errorf("expected nil without pool flags, got %+v", got)
+	}
+	got := parsePoolOptions(-1, 0)
+	if got == nil || got.MaxIdle != -1 || got.IdleTimeout != "" {
+		t.Errorf("expected keep-alives disabled, got %+v", got)
+	}
+	got = parsePoolOptions(0, 5*time.Second)
+	if got == nil || got.MaxIdle != 0 || got.IdleTimeout != "5s" {
+		t.Errorf("expected 5s idle timeout, got %+v", got)
+	}
+}
+
+func TestParseMirror(t *testing.T) {
+	for in, want := range map[string]string{
+		"":               "",
+		"3001":           "localhost:3001",
+		"127.0.0.1:3001": "127.0.0.1:3001",
+	} {
+		if got := parseMirror(in); got != want {
+			t.Errorf("parseMirror(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseRewriteOptions(t *testing.T) {
+	if got := parseRewriteOptions("", "", false); got != nil {
+		t.Errorf("no flags: got %+v, want nil", got)
+	}
+	if got := parseRewriteOptions("", "", true); got == nil || !got.KeepUpstreamURLs {
+		t.Errorf("--keep-upstream-urls: got %+v", got)
+	}
+	got := parseRewriteOptions("/api", "localhost:3000, http://127.0.0.1:3000,", false)
+	if got.StripPrefix != "/api" || len(got.Locations) != 2 || got.Locations[1] != "http://127.0.0.1:3000" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestParseCookieOptions(t *testing.T) {
+	if got, err := parseCookieOptions(""); got != nil || err != nil {
+		t.Errorf("empty: got %+v, %v", got, err)
+	}
+	got, err := parseCookieOptions("secure, samesite-none")
+	if err != nil || !got.Secure || got.Domain || !got.SameSiteNone {
+		t.Errorf("got %+v, %v", got, err)
+	}
+	if _, err := parseCookieOptions("httponly"); err == nil {
+		t.Error("unknown option should fail")
+	}
+}
+
+func TestHeartbeatStopsWhenPreviewExpired(t *testing.T) {
+	registrationOptions = routeOptions{Preview: "pr-1", IdleTimeout: "1h"}
+	t.Cleanup(func() { registrationOptions = routeOptions{} })
+
+	var registerCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/routes/pr-1.myapp/heartbeat":
+			w.WriteHeader(http.StatusGone)
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/routes/"):
+			registerCount.Add(1)
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := unixHostClient(t, server)
+	state := newRouteState(previewName("pr-1", "myapp"), "/tmp/project")
+	state.SetUpstream("localhost:3000")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go heartbeatWithInterval(ctx, client, state, 20*time.Millisecond)
+
+	select {
+	case <-state.Expired():
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected preview to be marked expired after heartbeat 410")
+	}
+	if registerCount.Load() != 0 {
+		t.Errorf("expected expired preview not to be re-registered, got %d registrations", registerCount.Load())
+	}
+}
+
+func TestAPIClient_TCPSendsToken(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"status":"ok"}`)
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PAW_PROXY_API", "http://"+parsed.Host)
+	t.Setenv("PAW_PROXY_API_TOKEN", "secret")
+
+	client, err := apiClient("/nonexistent.sock", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want Bearer secret", gotAuth)
+	}
+}
+
+func TestAPIClient_TokenFromSupportDir(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"status":"ok"}`)
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	t.Setenv("PAW_PROXY_API", parsed.Host)
+	t.Setenv("PAW_PROXY_API_TOKEN", "")
+
+	if _, err := apiClient("/nonexistent.sock", dir); err == nil {
+		t.Fatal("expected error without a token")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "api-token"), []byte("filetoken\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := apiClient("/nonexistent.sock", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if gotAuth != "Bearer filetoken" {
+		t.Errorf("Authorization = %q, want Bearer filetoken", gotAuth)
+	}
+
+	t.Setenv("PAW_PROXY_API", "no-port")
+	if _, err := apiClient("/nonexistent.sock", dir); err == nil {
+		t.Error("expected error for address without port")
+	}
+}
+
+func TestCheckPortFree(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+
+	err = checkPortFree(port)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("port %d", port)) {
+		t.Errorf("checkPortFree() on a held port = %v", err)
+	}
+
+	l.Close()
+	if err := checkPortFree(port); err != nil {
+		t.Errorf("checkPortFree() after release = %v", err)
+	}
+}
+
+func TestParseSPAOptions(t *testing.T) {
+	if spa, err := parseSPAOptions(false, "", "", false); spa != nil || err != nil {
+		t.Errorf("no flags = %+v, %v; want nil", spa, err)
+	}
+	spa, err := parseSPAOptions(false, "/app.html", "404, 503", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spa.Index != "/app.html" || !slices.Equal(spa.Statuses, []int{404, 503}) || !spa.KeepStatus {
+		t.Errorf("spa = %+v", spa)
+	}
+	if _, err := parseSPAOptions(true, "", "not-found", false); err == nil {
+		t.Error("non-numeric --spa-status accepted")
+	}
+	if _, err := parseSPAOptions(true, "", "200", false); err == nil {
+		t.Error("--spa-status 200 accepted")
+	}
+}
+
+func TestParseCanonicalOptions(t *testing.T) {
+	if canonical, err := parseCanonicalOptions("", false, false, 0); canonical != nil || err != nil {
+		t.Errorf("no flags = %+v, %v; want nil", canonical, err)
+	}
+	canonical, err := parseCanonicalOptions("strip", false, true, 308)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canonical.TrailingSlash != "strip" || !canonical.StripWWW || canonical.LowercaseHost || canonical.Status != 308 {
+		t.Errorf("canonical = %+v", canonical)
+	}
+	if _, err := parseCanonicalOptions("always", false, false, 0); err == nil {
+		t.Error("unknown --trailing-slash accepted")
+	}
+	if _, err := parseCanonicalOptions("", true, false, 200); err == nil {
+		t.Error("--canonical-status 200 accepted")
+	}
+	if _, err := parseCanonicalOptions("", false, false, 302); err == nil {
+		t.Error("--canonical-status without a rule accepted")
+	}
+}
+
+func TestParseMaintenanceOptions(t *testing.T) {
+	if maintenance, err := parseMaintenanceOptions("", ""); maintenance != nil || err != nil {
+		t.Errorf("no flags = %+v, %v; want nil", maintenance, err)
+	}
+	maintenance, err := parseMaintenanceOptions("0 12 * * mon-fri", "30m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maintenance.Schedule != "0 12 * * mon-fri" || maintenance.Duration != "30m" {
+		t.Errorf("maintenance = %+v", maintenance)
+	}
+	if _, err := parseMaintenanceOptions("@daily", ""); err == nil {
+		t.Error("--maintenance without --maintenance-for accepted")
+	}
+	if _, err := parseMaintenanceOptions("at noon", "30m"); err == nil {
+		t.Error("invalid cron schedule accepted")
+	}
+}
+
+func TestParseBalanceOptions(t *testing.T) {
+	balance, err := parseBalanceOptions("3001, 127.0.0.1:3002", "fallback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"localhost:3001", "127.0.0.1:3002"}; !slices.Equal(balance.Upstreams, want) || balance.Mode != "fallback" {
+		t.Errorf("balance = %+v, want %v in fallback mode", balance, want)
+	}
+	if balance, err := parseBalanceOptions("", ""); balance != nil || err != nil {
+		t.Errorf("no flags = %+v, %v; want nil", balance, err)
+	}
+	if _, err := parseBalanceOptions("", "fallback"); err == nil {
+		t.Error("--balance-mode without --balance accepted")
+	}
+	if _, err := parseBalanceOptions("3001", "random"); err == nil {
+		t.Error("unknown --balance-mode accepted")
+	}
+}
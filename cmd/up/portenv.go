@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// frameworkPortEnv maps package.json dependencies to the variables their
+// dev servers read the port from, for frameworks that ignore PORT.
+var frameworkPortEnv = map[string][]string{
+	"nuxt": {"NUXT_PORT", "NITRO_PORT"},
+	"vite": {"VITE_PORT"},
+}
+
+// portEnvNames returns the variables besides PORT that get the dev
+// server's port: the --port-env names, then those detected from the
+// dependencies in the package.json at pkgPath.
+func portEnvNames(flagNames []string, pkgPath string) ([]string, error) {
+	var names []string
+	add := func(name string) {
+		if name != "PORT" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, name := range flagNames {
+		if !envKeyPattern.MatchString(name) {
+			return nil, fmt.Errorf("--port-env: invalid variable name %q", name)
+		}
+		add(name)
+	}
+	for _, name := range detectPortEnv(pkgPath) {
+		add(name)
+	}
+	return names, nil
+}
+
+// detectPortEnv returns the port variables of the frameworks the
+// package.json at path depends on, if any.
+func detectPortEnv(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+	var deps []string
+	for dep := range frameworkPortEnv {
+		_, ok := pkg.Dependencies[dep]
+		_, dev := pkg.DevDependencies[dep]
+		if ok || dev {
+			deps = append(deps, dep)
+		}
+	}
+	slices.Sort(deps)
+	var names []string
+	for _, dep := range deps {
+		names = append(names, frameworkPortEnv[dep]...)
+	}
+	return names
+}
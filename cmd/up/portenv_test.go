@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPortEnvNames(t *testing.T) {
+	pkg := filepath.Join(t.TempDir(), "package.json")
+	os.WriteFile(pkg, []byte(`{"dependencies":{"nuxt":"^3.0.0"},"devDependencies":{"vite":"^5.0.0"}}`), 0o600)
+
+	got, err := portEnvNames([]string{"DEV_SERVER_PORT", "PORT", "VITE_PORT"}, pkg)
+	if err != nil {
+		t.Fatalf("portEnvNames() = %v", err)
+	}
+	want := []string{"DEV_SERVER_PORT", "VITE_PORT", "NUXT_PORT", "NITRO_PORT"}
+	if !slices.Equal(got, want) {
+		t.Errorf("portEnvNames() = %q, want %q", got, want)
+	}
+
+	if got, _ := portEnvNames(nil, filepath.Join(t.TempDir(), "package.json")); len(got) != 0 {
+		t.Errorf("portEnvNames() without package.json = %q", got)
+	}
+	if _, err := portEnvNames([]string{"BAD-NAME"}, pkg); err == nil {
+		t.Error("expected an error for an invalid variable name")
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// stop sends sig to the dev command's process group and waits up to grace
+// for done, killing the whole group if it hasn't exited by then.
+func (g *processGroup) stop(sig os.Signal, done <-chan error, grace time.Duration) {
+	g.signal(sig)
+	select {
+	case <-done:
+	case <-time.After(grace):
+		g.kill()
+	}
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// processGroup runs the dev command in its own process group, so signals
+// and kills reach everything it starts (npm, then node, then workers).
+type processGroup struct {
+	cmd *exec.Cmd
+}
+
+// newProcessGroup prepares cmd to run in its own process group. Start it
+// with start rather than cmd.Start.
+func newProcessGroup(cmd *exec.Cmd) *processGroup {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return &processGroup{cmd: cmd}
+}
+
+func (g *processGroup) start() error {
+	return g.cmd.Start()
+}
+
+// signal forwards sig to the entire group (negative PID).
+func (g *processGroup) signal(sig os.Signal) {
+	if s, ok := sig.(syscall.Signal); ok {
+		syscall.Kill(-g.cmd.Process.Pid, s)
+	}
+}
+
+func (g *processGroup) kill() {
+	syscall.Kill(-g.cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// close releases the group once the command has exited. Nothing to do
+// here; processes left behind keep running, as they would from a shell.
+func (g *processGroup) close() {}
+
+// isAddrInUse reports whether a listen failed because the port is taken.
+func isAddrInUse(err error) bool {
+	return errors.Is(err, syscall.EADDRINUSE)
+}
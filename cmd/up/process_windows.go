@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// processGroup runs the dev command in a new console process group, so
+// CTRL_BREAK reaches everything it starts, and in a job object, so a kill
+// does too. Windows has no process group signals or kill(-pid).
+type processGroup struct {
+	cmd *exec.Cmd
+	job windows.Handle
+}
+
+// newProcessGroup prepares cmd to run in its own process group. Start it
+// with start rather than cmd.Start.
+func newProcessGroup(cmd *exec.Cmd) *processGroup {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP}
+	return &processGroup{cmd: cmd}
+}
+
+// start starts the command and puts it in a job object that kills every
+// process in it when the job is closed, including when up itself dies.
+// Processes the command starts before it joins the job escape it; without
+// a job object, kill falls back to the command's own process.
+func (g *processGroup) start() error {
+	if err := g.cmd.Start(); err != nil {
+		return err
+	}
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil
+	}
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+		BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+			LimitFlags: windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE,
+		},
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		windows.CloseHandle(job)
+		return nil
+	}
+	proc, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(g.cmd.Process.Pid))
+	if err != nil {
+		windows.CloseHandle(job)
+		return nil
+	}
+	defer windows.CloseHandle(proc)
+	if err := windows.AssignProcessToJobObject(job, proc); err != nil {
+		windows.CloseHandle(job)
+		return nil
+	}
+	g.job = job
+	return nil
+}
+
+// signal sends CTRL_BREAK to the group, whatever sig is: it is the only
+// console event that can be sent to one process group, and dev servers
+// treat it like Ctrl+C.
+func (g *processGroup) signal(sig os.Signal) {
+	windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(g.cmd.Process.Pid))
+}
+
+func (g *processGroup) kill() {
+	if g.job != 0 {
+		windows.TerminateJobObject(g.job, 1)
+		return
+	}
+	g.cmd.Process.Kill()
+}
+
+// close releases the job object once the command has exited, which also
+// ends any processes it left behind.
+func (g *processGroup) close() {
+	if g.job != 0 {
+		windows.CloseHandle(g.job)
+		g.job = 0
+	}
+}
+
+// isAddrInUse reports whether a listen failed because the port is taken.
+func isAddrInUse(err error) bool {
+	return errors.Is(err, windows.WSAEADDRINUSE)
+}
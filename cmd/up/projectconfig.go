@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Project config files live in a .paw directory at the repository root.
+// team.yaml is committed and shared; local.yaml is per-developer (add it
+// to .gitignore) and overrides team settings key by key.
+const (
+	projectConfigDir  = ".paw"
+	teamConfigFile    = "team.yaml"
+	localConfigFile   = "local.yaml"
+	maxProjectAliases = 10
+)
+
+// Hook names accepted under the "hooks" key.
+const (
+	hookPreStart = "pre-start"
+	hookPostStop = "post-stop"
+)
+
+// projectConfig is the merged view of .paw/team.yaml and .paw/local.yaml.
+type projectConfig struct {
+	Name    string
+	Aliases []string
+	Headers map[string]string
+	Hooks   map[string]string
+	Warmup  string
+}
+
+// findProjectConfigDir walks up from start looking for a .paw directory
+// containing team.yaml or local.yaml. Returns "" if none is found.
+func findProjectConfigDir(start string) string {
+	dir := start
+	for {
+		candidate := filepath.Join(dir, projectConfigDir)
+		for _, f := range []string{teamConfigFile, localConfigFile} {
+			if _, err := os.Stat(filepath.Join(candidate, f)); err == nil {
+				return candidate
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadProjectConfig finds and merges the project config files above start.
+// Missing files are not an error; an empty config is returned.
+func loadProjectConfig(start string) (projectConfig, string, error) {
+	var cfg projectConfig
+	dir := findProjectConfigDir(start)
+	if dir == "" {
+		return cfg, "", nil
+	}
+
+	for _, f := range []string{teamConfigFile, localConfigFile} {
+		path := filepath.Join(dir, f)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return cfg, dir, fmt.Errorf("reading %s: %w", path, err)
+		}
+		layer, err := parseProjectConfig(data)
+		if err != nil {
+			return cfg, dir, fmt.Errorf("%s: %w", path, err)
+		}
+		cfg = mergeProjectConfig(cfg, layer)
+	}
+
+	if len(cfg.Aliases) > maxProjectAliases {
+		return cfg, dir, fmt.Errorf("too many aliases (max %d)", maxProjectAliases)
+	}
+	for hook := range cfg.Hooks {
+		if hook != hookPreStart && hook != hookPostStop {
+			return cfg, dir, fmt.Errorf("unknown hook %q (expected %s or %s)", hook, hookPreStart, hookPostStop)
+		}
+	}
+	return cfg, dir, nil
+}
+
+// mergeProjectConfig overlays override on base. Scalars and the alias list
+// replace base values when set; headers and hooks merge key by key.
+func mergeProjectConfig(base, override projectConfig) projectConfig {
+	if override.Name != "" {
+		base.Name = override.Name
+	}
+	if override.Warmup != "" {
+		base.Warmup = override.Warmup
+	}
+	if override.Aliases != nil {
+		base.Aliases = override.Aliases
+	}
+	base.Headers = mergeStringMaps(base.Headers, override.Headers)
+	base.Hooks = mergeStringMaps(base.Hooks, override.Hooks)
+	return base
+}
+
+func mergeStringMaps(base, override map[string]string) map[string]string {
+	if len(override) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// parseProjectConfig parses the YAML subset used by project config files:
+// top-level scalars, a list ("aliases", as a block or a flow "[a, b]"),
+// and flat maps ("headers", "hooks"). Comments and quoted strings are
+// supported; anchors, flow maps, and multi-line strings are not.
+func parseProjectConfig(data []byte) (projectConfig, error) {
+	var cfg projectConfig
+	var section string // current block key, "" at top level
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := stripYAMLComment(scanner.Text())
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		indented := raw[0] == ' ' || raw[0] == '\t'
+		line := strings.TrimSpace(raw)
+
+		if !indented {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				return cfg, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+			}
+			key = strings.TrimSpace(key)
+			value = unquoteYAML(strings.TrimSpace(value))
+			section = ""
+			switch key {
+			case "name":
+				cfg.Name = value
+			case "warmup":
+				cfg.Warmup = value
+			case "aliases":
+				if value == "" {
+					section = key
+					cfg.Aliases = []string{}
+					break
+				}
+				aliases, ok := parseFlowList(value)
+				if !ok {
+					return cfg, fmt.Errorf("line %d: aliases must be a list of \"- alias\" lines or [a, b]", lineNo)
+				}
+				cfg.Aliases = aliases
+			case "headers", "hooks":
+				if value != "" {
+					return cfg, fmt.Errorf("line %d: %s must be a block of \"key: value\" lines", lineNo, key)
+				}
+				section = key
+			default:
+				return cfg, fmt.Errorf("line %d: unknown key %q", lineNo, key)
+			}
+			continue
+		}
+
+		switch section {
+		case "aliases":
+			item, ok := strings.CutPrefix(line, "- ")
+			if !ok {
+				return cfg, fmt.Errorf("line %d: expected \"- alias\" list item", lineNo)
+			}
+			cfg.Aliases = append(cfg.Aliases, unquoteYAML(strings.TrimSpace(item)))
+		case "headers", "hooks":
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				return cfg, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+			}
+			key = unquoteYAML(strings.TrimSpace(key))
+			value = unquoteYAML(strings.TrimSpace(value))
+			if section == "headers" {
+				if cfg.Headers == nil {
+					cfg.Headers = make(map[string]string)
+				}
+				cfg.Headers[key] = value
+			} else {
+				if cfg.Hooks == nil {
+					cfg.Hooks = make(map[string]string)
+				}
+				cfg.Hooks[key] = value
+			}
+		default:
+			return cfg, fmt.Errorf("line %d: unexpected indentation", lineNo)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// parseFlowList parses a flow list such as "[api, 'admin']". Items can't
+// contain commas, which aliases never do.
+func parseFlowList(s string) ([]string, bool) {
+	inner, ok := strings.CutPrefix(s, "[")
+	if !ok {
+		return nil, false
+	}
+	if inner, ok = strings.CutSuffix(inner, "]"); !ok {
+		return nil, false
+	}
+	items := []string{}
+	if strings.TrimSpace(inner) == "" {
+		return items, true
+	}
+	for _, item := range strings.Split(inner, ",") {
+		item = unquoteYAML(strings.TrimSpace(item))
+		if item == "" {
+			return nil, false
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+// stripYAMLComment removes a trailing "# comment" that is not inside quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimRight(line[:i], " \t")
+		}
+	}
+	return strings.TrimRight(line, " \t")
+}
+
+func unquoteYAML(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// runProjectHook runs a configured hook command through the shell from the
+// project root, with the given environment.
+func runProjectHook(name, command, root string, env []string) error {
+	fmt.Printf("🪝 Running %s hook: %s\n", name, command)
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = root
+	cmd.Env = env
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+	return nil
+}
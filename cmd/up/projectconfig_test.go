@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseProjectConfig(t *testing.T) {
+	data := []byte(`# Shared defaults for the team
+name: shop   # route name
+warmup: "/"
+aliases:
+  - api
+  - 'admin'
+headers:
+  X-Team: core
+  X-Debug: "1 # not a comment"
+hooks:
+  pre-start: npm install
+`)
+
+	cfg, err := parseProjectConfig(data)
+	if err != nil {
+		t.Fatalf("parseProjectConfig() error: %v", err)
+	}
+	if cfg.Name != "shop" {
+		t.Errorf("Name = %q, want shop", cfg.Name)
+	}
+	if cfg.Warmup != "/" {
+		t.Errorf("Warmup = %q, want /", cfg.Warmup)
+	}
+	if !reflect.DeepEqual(cfg.Aliases, []string{"api", "admin"}) {
+		t.Errorf("Aliases = %v", cfg.Aliases)
+	}
+	wantHeaders := map[string]string{"X-Team": "core", "X-Debug": "1 # not a comment"}
+	if !reflect.DeepEqual(cfg.Headers, wantHeaders) {
+		t.Errorf("Headers = %v, want %v", cfg.Headers, wantHeaders)
+	}
+	if cfg.Hooks[hookPreStart] != "npm install" {
+		t.Errorf("Hooks = %v", cfg.Hooks)
+	}
+}
+
+func TestParseProjectConfigFlowList(t *testing.T) {
+	tests := map[string][]string{
+		"aliases: [api, 'admin', \"docs\"]\n": {"api", "admin", "docs"},
+		"aliases: []\n":                       {},
+	}
+	for input, want := range tests {
+		cfg, err := parseProjectConfig([]byte(input))
+		if err != nil {
+			t.Errorf("parseProjectConfig(%q) error: %v", input, err)
+			continue
+		}
+		if !reflect.DeepEqual(cfg.Aliases, want) {
+			t.Errorf("parseProjectConfig(%q) Aliases = %v, want %v", input, cfg.Aliases, want)
+		}
+	}
+}
+
+func TestParseProjectConfigErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown key":       "colour: blue\n",
+		"missing colon":     "name\n",
+		"bad list item":     "aliases:\n  api\n",
+		"orphan indent":     "  name: x\n",
+		"inline map header": "headers: X-Foo\n",
+		"scalar aliases":    "aliases: api\n",
+		"unclosed aliases":  "aliases: [api, admin\n",
+		"empty alias":       "aliases: [api,, admin]\n",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseProjectConfig([]byte(input)); err == nil {
+				t.Errorf("expected error for %q", input)
+			}
+		})
+	}
+}
+
+func TestMergeProjectConfig(t *testing.T) {
+	team := projectConfig{
+		Name:    "shop",
+		Aliases: []string{"api"},
+		Headers: map[string]string{"X-Team": "core", "X-Env": "dev"},
+	}
+	local := projectConfig{
+		Name:    "shop-alice",
+		Headers: map[string]string{"X-Env": "alice"},
+	}
+
+	got := mergeProjectConfig(team, local)
+	if got.Name != "shop-alice" {
+		t.Errorf("Name = %q, want local override", got.Name)
+	}
+	if !reflect.DeepEqual(got.Aliases, []string{"api"}) {
+		t.Errorf("Aliases = %v, want team aliases kept", got.Aliases)
+	}
+	want := map[string]string{"X-Team": "core", "X-Env": "alice"}
+	if !reflect.DeepEqual(got.Headers, want) {
+		t.Errorf("Headers = %v, want %v", got.Headers, want)
+	}
+
+	// An explicit empty list in local.yaml clears team aliases
+	got = mergeProjectConfig(team, projectConfig{Aliases: []string{}})
+	if len(got.Aliases) != 0 {
+		t.Errorf("expected aliases cleared, got %v", got.Aliases)
+	}
+}
+
+func TestLoadProjectConfigWalksUp(t *testing.T) {
+	root := t.TempDir()
+	pawDir := filepath.Join(root, ".paw")
+	if err := os.MkdirAll(pawDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pawDir, "team.yaml"), []byte("name: shop\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pawDir, "local.yaml"), []byte("warmup: /health\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "packages", "web")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, dir, err := loadProjectConfig(sub)
+	if err != nil {
+		t.Fatalf("loadProjectConfig() error: %v", err)
+	}
+	if dir != pawDir {
+		t.Errorf("dir = %q, want %q", dir, pawDir)
+	}
+	if cfg.Name != "shop" || cfg.Warmup != "/health" {
+		t.Errorf("unexpected merged config: %+v", cfg)
+	}
+}
+
+func TestLoadProjectConfigRejectsUnknownHook(t *testing.T) {
+	root := t.TempDir()
+	pawDir := filepath.Join(root, ".paw")
+	if err := os.MkdirAll(pawDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pawDir, "team.yaml"), []byte("hooks:\n  on-boot: echo hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := loadProjectConfig(root); err == nil {
+		t.Fatal("expected error for unknown hook")
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !darwin && !linux
+
+package main
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+)
+
+// ptyTerminal is only implemented for macOS and Linux.
+type ptyTerminal struct{}
+
+func newPTYTerminal() (*ptyTerminal, error) {
+	return nil, errors.New("--pty is only supported on macOS and Linux")
+}
+
+func (t *ptyTerminal) attach(cmd *exec.Cmd, tail io.Writer) (func(), error) {
+	return func() {}, nil
+}
+
+func (t *ptyTerminal) close() {}
@@ -0,0 +1,151 @@
+//go:build darwin || linux
+
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// ptyTerminal runs dev commands on a pseudo-terminal for --pty, so they see
+// a TTY (colors, spinners, "use another port? y/n" prompts) even though up
+// sits between them and the real terminal. It lives for the whole up
+// session: stdin is read by one pump that feeds whichever command is
+// running, so a restart does not lose keystrokes to a stale reader.
+type ptyTerminal struct {
+	mu     sync.Mutex
+	master *os.File // the running command's PTY, nil between runs
+
+	restore func()
+	winch   chan os.Signal
+}
+
+// newPTYTerminal puts stdin, if it is a terminal, into raw mode and starts
+// forwarding input and window size changes to the running command.
+func newPTYTerminal() (*ptyTerminal, error) {
+	t := &ptyTerminal{restore: func() {}, winch: make(chan os.Signal, 1)}
+	if restore, err := makeRaw(int(os.Stdin.Fd())); err == nil {
+		t.restore = restore
+	}
+	signal.Notify(t.winch, syscall.SIGWINCH)
+	go t.forwardResize()
+	go t.pumpStdin()
+	return t, nil
+}
+
+// attach wires cmd to a new PTY, copying its output to tail as well as
+// stdout. The command starts a session with the PTY as its controlling
+// terminal; a new session is also a new process group, so the
+// processGroup signals still reach everything it starts. Call the
+// returned detach once the command has exited.
+func (t *ptyTerminal) attach(cmd *exec.Cmd, tail io.Writer) (detach func(), err error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	copySize(master)
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = false
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		io.Copy(io.MultiWriter(os.Stdout, tail), master)
+	}()
+
+	t.mu.Lock()
+	t.master = master
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		t.master = nil
+		t.mu.Unlock()
+
+		// Once the last slave descriptor closes, reads drain what the
+		// command wrote and then fail. Processes it left behind may hold
+		// the slave open, so don't wait on them for long.
+		slave.Close()
+		select {
+		case <-copied:
+		case <-time.After(time.Second):
+		}
+		master.Close()
+	}, nil
+}
+
+// close puts the terminal back the way up found it.
+func (t *ptyTerminal) close() {
+	signal.Stop(t.winch)
+	t.restore()
+}
+
+func (t *ptyTerminal) pumpStdin() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n > 0 {
+			t.mu.Lock()
+			if t.master != nil {
+				t.master.Write(buf[:n])
+			}
+			t.mu.Unlock()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (t *ptyTerminal) forwardResize() {
+	for range t.winch {
+		t.mu.Lock()
+		if t.master != nil {
+			copySize(t.master)
+		}
+		t.mu.Unlock()
+	}
+}
+
+// copySize gives the PTY the window size of up's own terminal, if it has
+// one.
+func copySize(master *os.File) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return
+	}
+	unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, ws)
+}
+
+// makeRaw switches fd to raw input: no echo or line buffering, since the
+// PTY does both for the command. ISIG stays on so Ctrl+C still signals up,
+// which stops the command the same way it does without --pty.
+func makeRaw(fd int) (restore func(), err error) {
+	old, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+	raw := *old
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.IEXTEN
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+	return func() { unix.IoctlSetTermios(fd, ioctlSetTermios, old) }, nil
+}
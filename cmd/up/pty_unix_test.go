@@ -0,0 +1,35 @@
+//go:build darwin || linux
+
+package main
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestOpenPTYGivesCommandATerminal(t *testing.T) {
+	master, slave, err := openPTY()
+	if err != nil {
+		t.Skipf("no pty available: %v", err)
+	}
+	defer master.Close()
+
+	cmd := exec.Command("sh", "-c", `test -t 0 && test -t 1 && echo "is a tty"`)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	slave.Close()
+
+	line, _ := bufio.NewReader(master).ReadString('\n')
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("command failed: %v (output %q)", err, line)
+	}
+	if got := strings.TrimSpace(line); got != "is a tty" {
+		t.Errorf("output = %q, want %q", got, "is a tty")
+	}
+}
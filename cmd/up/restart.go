@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Backoff for --restart: the wait doubles after each crash, from
+// restartBaseDelay up to restartMaxDelay, and starts over once the
+// command has stayed up for restartResetAfter.
+const (
+	restartBaseDelay  = time.Second
+	restartMaxDelay   = 30 * time.Second
+	restartResetAfter = time.Minute
+)
+
+// restartDelay returns the wait before restart attempt n, counting from 0.
+// rnd returns a number in [0, 1) that spreads the delay by up to ±20%, so
+// commands crashing together don't restart in lockstep.
+func restartDelay(n int, rnd func() float64) time.Duration {
+	delay := restartMaxDelay
+	if n < 16 {
+		delay = min(restartBaseDelay<<n, restartMaxDelay)
+	}
+	jitter := 0.8 + 0.4*rnd()
+	return time.Duration(float64(delay) * jitter).Round(time.Millisecond)
+}
+
+// restartSummary describes a --restart session on exit.
+func restartSummary(restarts int, uptime time.Duration) string {
+	times := "times"
+	if restarts == 1 {
+		times = "time"
+	}
+	return fmt.Sprintf("📊 Restarted %d %s, up %s in total", restarts, times, uptime.Round(time.Second))
+}
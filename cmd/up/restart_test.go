@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestartDelay(t *testing.T) {
+	middle := func() float64 { return 0.5 }
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{3, 8 * time.Second},
+		{5, restartMaxDelay},
+		{100, restartMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := restartDelay(tt.n, middle); got != tt.want {
+			t.Errorf("restartDelay(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+
+	low := restartDelay(2, func() float64 { return 0 })
+	high := restartDelay(2, func() float64 { return 0.999 })
+	if low != 3200*time.Millisecond || high < 4790*time.Millisecond || high > 4800*time.Millisecond {
+		t.Errorf("jitter range = [%s, %s], want about [3.2s, 4.8s]", low, high)
+	}
+}
+
+func TestRestartSummary(t *testing.T) {
+	if got, want := restartSummary(1, 90*time.Second+400*time.Millisecond), "📊 Restarted 1 time, up 1m30s in total"; got != want {
+		t.Errorf("restartSummary() = %q, want %q", got, want)
+	}
+	if got, want := restartSummary(3, time.Minute), "📊 Restarted 3 times, up 1m0s in total"; got != want {
+		t.Errorf("restartSummary() = %q, want %q", got, want)
+	}
+}
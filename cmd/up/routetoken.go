@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+// routeTokenDir holds the route tokens the daemon issued to up, one file
+// per route, so a run that crashed can be replaced by the next one from
+// the same directory. Set in main; empty keeps tokens in memory only.
+var routeTokenDir string
+
+func routeTokenPath(name string) string {
+	return filepath.Join(routeTokenDir, name+".token")
+}
+
+// loadRouteToken gives client the token an earlier run saved for name,
+// unless it already has one.
+func loadRouteToken(client *pawclient.Client, name string) {
+	if routeTokenDir == "" || client.RouteToken(name) != "" {
+		return
+	}
+	data, err := os.ReadFile(routeTokenPath(name))
+	if err != nil {
+		return
+	}
+	client.SetRouteToken(name, strings.TrimSpace(string(data)))
+}
+
+// saveRouteToken persists client's token for name. Failures only cost
+// crash recovery (the route is freed by its heartbeat timeout instead), so
+// they are ignored.
+func saveRouteToken(client *pawclient.Client, name string) {
+	token := client.RouteToken(name)
+	if routeTokenDir == "" || token == "" {
+		return
+	}
+	if err := os.MkdirAll(routeTokenDir, 0700); err != nil {
+		return
+	}
+	os.WriteFile(routeTokenPath(name), []byte(token+"\n"), 0600)
+}
+
+// forgetRouteToken removes the saved token for a deregistered route.
+func forgetRouteToken(name string) {
+	if routeTokenDir != "" {
+		os.Remove(routeTokenPath(name))
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alexcatdad/paw-proxy/internal/notification"
+	pawclient "github.com/alexcatdad/paw-proxy/pkg/client"
+)
+
+// parseStaticOptions builds static settings from the --static and
+// --autoindex flag values. A static route has no dev server, so no command
+// may follow.
+func parseStaticOptions(dir string, autoIndex bool, nargs int) (*pawclient.StaticConfig, error) {
+	if dir == "" {
+		if autoIndex {
+			return nil, fmt.Errorf("--autoindex requires --static")
+		}
+		return nil, nil
+	}
+	if nargs > 0 {
+		return nil, fmt.Errorf("--static serves files itself; don't pass a command")
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("--static: %w", err)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("--static: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("--static: %s is not a directory", root)
+	}
+	return &pawclient.StaticConfig{Root: root, AutoIndex: autoIndex}, nil
+}
+
+// runStatic registers a static route and keeps it until interrupted. It
+// returns the name the route got and the exit code.
+func runStatic(client *pawclient.Client, state *routeState, name, dir string, aliases []string, sigCh <-chan os.Signal) (string, int) {
+	// Previews keep their exact name, as in the dev server loop.
+	finalName := name
+	var err error
+	if registrationOptions.Preview != "" {
+		err = registerRoute(client, name, "", dir)
+	} else {
+		finalName, err = registerWithFallback(client, name, "", dir)
+	}
+	if err != nil {
+		fmt.Printf("Error registering route: %v\n", err)
+		return name, 1
+	}
+	if finalName != name {
+		name = finalName
+		state.SetName(name)
+	}
+	state.SetStatic(registrationOptions.Static)
+	if registrationOptions.Preview == "" {
+		state.SetAliases(registerAliases(client, aliases, "", dir))
+	}
+
+	fmt.Printf("📂 Serving %s at https://%s.test\n", registrationOptions.Static.Root, name)
+	for _, alias := range state.Aliases() {
+		fmt.Printf("   Also at: https://%s.test\n", alias)
+	}
+	notification.Notify("paw-proxy", fmt.Sprintf("Project is live at: https://%s.test", name))
+	if registrationOptions.Auth != nil {
+		fmt.Println("🔒 Route requires credentials")
+	}
+	fmt.Println("------------------------------------------------")
+
+	select {
+	case <-sigCh:
+	case <-state.Expired():
+		fmt.Printf("\n⏰ Preview %s.test expired\n", name)
+	}
+	return name, 0
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseStaticOptions(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "index.html")
+	os.WriteFile(file, nil, 0o644)
+	t.Chdir(dir)
+
+	if cfg, err := parseStaticOptions("", false, 2); cfg != nil || err != nil {
+		t.Errorf("no --static = %+v, %v; want nil, nil", cfg, err)
+	}
+	cfg, err := parseStaticOptions(".", true, 0)
+	if err != nil || cfg == nil || cfg.Root != dir || !cfg.AutoIndex {
+		t.Errorf("--static . --autoindex = %+v, %v; want root %s with auto-index", cfg, err, dir)
+	}
+	for _, tt := range []struct {
+		dir       string
+		autoIndex bool
+		nargs     int
+	}{
+		{"", true, 0},         // --autoindex alone
+		{".", false, 1},       // with a command
+		{"missing", false, 0}, // no such directory
+		{file, false, 0},      // not a directory
+	} {
+		if _, err := parseStaticOptions(tt.dir, tt.autoIndex, tt.nargs); err == nil {
+			t.Errorf("parseStaticOptions(%q, %v, %d) = nil error", tt.dir, tt.autoIndex, tt.nargs)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// warmupReadyTimeout bounds how long we wait for the dev server to start
+// accepting connections before giving up on the warm-up request.
+const warmupReadyTimeout = 2 * time.Minute
+
+// warmupRequestTimeout bounds the warm-up GET itself. JIT-compiling dev
+// servers (Next.js, Rails) can take a while to build their first page.
+const warmupRequestTimeout = 2 * time.Minute
+
+// normalizeWarmupPath ensures the warm-up path is a rooted request URI.
+func normalizeWarmupPath(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		return "/" + path
+	}
+	return path
+}
+
+// warmUp waits for the upstream to accept connections and then issues a
+// single background GET to path, so the dev server compiles its first page
+// before a human opens it. Failures are logged and otherwise ignored.
+func warmUp(ctx context.Context, upstream, host, path string) {
+	warmUpWithInterval(ctx, upstream, host, path, 250*time.Millisecond)
+}
+
+func warmUpWithInterval(ctx context.Context, upstream, host, path string, interval time.Duration) {
+	if err := waitForUpstream(ctx, upstream, interval, warmupReadyTimeout); err != nil {
+		if ctx.Err() == nil {
+			log.Printf("warning: warm-up skipped: %v", err)
+		}
+		return
+	}
+
+	status, elapsed, err := warmupRequest(ctx, upstream, host, path)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("warning: warm-up request failed: %v", err)
+		}
+		return
+	}
+	fmt.Printf("🔥 Warmed up https://%s%s (%d in %s)\n", host, path, status, elapsed.Round(time.Millisecond))
+}
+
+// waitForUpstream polls until a TCP connection to upstream succeeds.
+func waitForUpstream(ctx context.Context, upstream string, interval, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", upstream, interval)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("upstream %s not ready after %s", upstream, timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// warmupRequest sends the warm-up GET directly to the upstream, presenting
+// the same Host and forwarding headers the proxy would.
+func warmupRequest(ctx context.Context, upstream, host, path string) (int, time.Duration, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, warmupRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, "http://"+upstream+path, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Host = host
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", host)
+	req.Header.Set("User-Agent", "paw-proxy-warmup")
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the server finishes rendering the full page.
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, time.Since(start), nil
+}
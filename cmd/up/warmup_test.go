@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeWarmupPath(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"/", "/"},
+		{"/dashboard", "/dashboard"},
+		{"dashboard", "/dashboard"},
+		{"", "/"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeWarmupPath(tt.input); got != tt.want {
+			t.Errorf("normalizeWarmupPath(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestWarmUpRequestsPathWithAppHost(t *testing.T) {
+	got := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	upstream := strings.TrimPrefix(server.URL, "http://")
+	warmUpWithInterval(context.Background(), upstream, "myapp.test", "/dashboard", 10*time.Millisecond)
+
+	select {
+	case r := <-got:
+		if r.URL.Path != "/dashboard" {
+			t.Errorf("path = %q, want /dashboard", r.URL.Path)
+		}
+		if r.Host != "myapp.test" {
+			t.Errorf("host = %q, want myapp.test", r.Host)
+		}
+		if r.Header.Get("X-Forwarded-Proto") != "https" {
+			t.Errorf("X-Forwarded-Proto = %q, want https", r.Header.Get("X-Forwarded-Proto"))
+		}
+	default:
+		t.Fatal("warm-up request was not sent")
+	}
+}
+
+func TestWaitForUpstreamWaitsUntilListening(t *testing.T) {
+	// Reserve a port, release it, and start listening on it shortly after.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		late, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		defer late.Close()
+		conn, err := late.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	if err := waitForUpstream(context.Background(), addr, 10*time.Millisecond, 2*time.Second); err != nil {
+		t.Fatalf("waitForUpstream() error: %v", err)
+	}
+}
+
+func TestWaitForUpstreamStopsOnCancel(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := waitForUpstream(ctx, addr, 10*time.Millisecond, time.Minute); err == nil {
+		t.Fatal("expected error after context cancellation")
+	}
+}
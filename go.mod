@@ -2,12 +2,15 @@ module github.com/alexcatdad/paw-proxy
 
 go 1.26.1
 
-require github.com/miekg/dns v1.1.72
+require (
+	github.com/miekg/dns v1.1.72
+	golang.org/x/crypto v0.46.0
+	golang.org/x/sys v0.39.0
+)
 
 require (
 	golang.org/x/mod v0.31.0 // indirect
 	golang.org/x/net v0.48.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
-	golang.org/x/sys v0.39.0 // indirect
 	golang.org/x/tools v0.40.0 // indirect
 )
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// minAlertSample is the fewest requests an error rate is judged on, so one
+// failed request out of two doesn't count as a 50% error rate.
+const minAlertSample = 10
+
+// maxAlertRequestRate bounds the request rate threshold, in requests per
+// second.
+const maxAlertRequestRate = 100000
+
+// AlertConfig sets thresholds over the last minute of a route's traffic
+// past which the daemon warns about it, e.g. to spot a runaway polling
+// loop. Zero fields are not checked.
+type AlertConfig struct {
+	// ErrorRate is the share of 5xx responses, in percent.
+	ErrorRate float64 `json:"errorRate,omitempty"`
+	// RequestRate is in requests per second.
+	RequestRate float64 `json:"requestRate,omitempty"`
+}
+
+// ValidateAlerts checks alert thresholds from a registration request or
+// the daemon's config file.
+func ValidateAlerts(c *AlertConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.ErrorRate < 0 || c.ErrorRate > 100 {
+		return fmt.Errorf("alert errorRate must be between 0 and 100 (percent)")
+	}
+	if c.RequestRate < 0 || c.RequestRate > maxAlertRequestRate {
+		return fmt.Errorf("alert requestRate must be between 0 and %d (requests per second)", maxAlertRequestRate)
+	}
+	if c.ErrorRate == 0 && c.RequestRate == 0 {
+		return fmt.Errorf("alerts need errorRate or requestRate")
+	}
+	return nil
+}
+
+// Exceeded describes the threshold crossed by requests, of which errors
+// were 5xx, seen over window, or returns "" if none was.
+func (c *AlertConfig) Exceeded(requests, errors int64, window time.Duration) string {
+	if c == nil || requests == 0 {
+		return ""
+	}
+	if c.RequestRate > 0 {
+		if rate := float64(requests) / window.Seconds(); rate > c.RequestRate {
+			return fmt.Sprintf("%.1f requests/s over the last %s (threshold %g)", rate, window, c.RequestRate)
+		}
+	}
+	if c.ErrorRate > 0 && requests >= minAlertSample {
+		if pct := float64(errors) * 100 / float64(requests); pct > c.ErrorRate {
+			return fmt.Sprintf("%.0f%% of %d requests failed with 5xx over the last %s (threshold %g%%)", pct, requests, window, c.ErrorRate)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateAlerts(t *testing.T) {
+	tests := []struct {
+		name    string
+		alerts  *AlertConfig
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"error rate", &AlertConfig{ErrorRate: 5}, false},
+		{"request rate", &AlertConfig{RequestRate: 20}, false},
+		{"empty", &AlertConfig{}, true},
+		{"error rate over 100", &AlertConfig{ErrorRate: 150}, true},
+		{"negative request rate", &AlertConfig{RequestRate: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAlerts(tt.alerts); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAlerts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAlertConfigExceeded(t *testing.T) {
+	c := &AlertConfig{ErrorRate: 5, RequestRate: 20}
+	tests := []struct {
+		requests, errors int64
+		want             string
+	}{
+		{0, 0, ""},
+		{600, 0, ""},
+		{1260, 0, "21.0 requests/s"},
+		{100, 5, ""},
+		{100, 6, "6% of 100 requests"},
+		// Too few requests to judge an error rate on.
+		{2, 1, ""},
+	}
+	for _, tt := range tests {
+		got := c.Exceeded(tt.requests, tt.errors, time.Minute)
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("Exceeded(%d, %d) = %q, want %q", tt.requests, tt.errors, got, tt.want)
+		}
+	}
+	if got := (*AlertConfig)(nil).Exceeded(10000, 10000, time.Minute); got != "" {
+		t.Errorf("nil config Exceeded = %q", got)
+	}
+}
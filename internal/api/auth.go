@@ -0,0 +1,97 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Auth modes reported in route listings. Secrets are never serialized.
+const (
+	AuthModeBasic  = "basic"
+	AuthModeBearer = "bearer"
+)
+
+// maxAuthFieldLen bounds credential lengths accepted over the API.
+const maxAuthFieldLen = 256
+
+// RouteAuth describes credentials the daemon requires before proxying a
+// request to a route. Either Username/Password (basic auth) or Token
+// (bearer token) is set, never both.
+type RouteAuth struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// Mode returns AuthModeBasic or AuthModeBearer.
+func (a *RouteAuth) Mode() string {
+	if a.Token != "" {
+		return AuthModeBearer
+	}
+	return AuthModeBasic
+}
+
+// Authorize reports whether r carries credentials matching a.
+// SECURITY: Comparisons are constant-time to avoid leaking secrets via timing.
+func (a *RouteAuth) Authorize(r *http.Request) bool {
+	if a.Token != "" {
+		header := r.Header.Get("Authorization")
+		const prefix = "Bearer "
+		if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+			return false
+		}
+		return subtle.ConstantTimeCompare([]byte(header[len(prefix):]), []byte(a.Token)) == 1
+	}
+
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(a.Username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(a.Password)) == 1
+	return userOK && passOK
+}
+
+// Challenge returns the WWW-Authenticate header value for an unauthorized
+// request to host.
+func (a *RouteAuth) Challenge(host string) string {
+	realm := strings.ReplaceAll(host, `"`, "")
+	if a.Token != "" {
+		return fmt.Sprintf(`Bearer realm="%s"`, realm)
+	}
+	return fmt.Sprintf(`Basic realm="%s", charset="UTF-8"`, realm)
+}
+
+// validateAuth ensures exactly one credential style is configured and that
+// values are bounded and free of control characters.
+func validateAuth(auth *RouteAuth) error {
+	if auth == nil {
+		return nil
+	}
+	hasBasic := auth.Username != "" || auth.Password != ""
+	hasToken := auth.Token != ""
+	if hasBasic == hasToken {
+		return fmt.Errorf("auth: set either username and password, or token")
+	}
+	if hasBasic {
+		if auth.Username == "" || auth.Password == "" {
+			return fmt.Errorf("auth: username and password are both required")
+		}
+		if strings.Contains(auth.Username, ":") {
+			return fmt.Errorf("auth: username must not contain ':'")
+		}
+	}
+	for _, v := range []string{auth.Username, auth.Password, auth.Token} {
+		if len(v) > maxAuthFieldLen {
+			return fmt.Errorf("auth: credentials must be at most %d characters", maxAuthFieldLen)
+		}
+		for _, c := range v {
+			if c < 0x20 || c == 0x7f {
+				return fmt.Errorf("auth: credentials must not contain control characters")
+			}
+		}
+	}
+	return nil
+}
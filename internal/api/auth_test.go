@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidateAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    *RouteAuth
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"basic", &RouteAuth{Username: "dev", Password: "pw with space"}, false},
+		{"token", &RouteAuth{Token: "abc123"}, false},
+		{"empty", &RouteAuth{}, true},
+		{"both", &RouteAuth{Username: "dev", Password: "pw", Token: "t"}, true},
+		{"missing password", &RouteAuth{Username: "dev"}, true},
+		{"colon in username", &RouteAuth{Username: "a:b", Password: "pw"}, true},
+		{"control char", &RouteAuth{Token: "abc\n"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAuth(tt.auth)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRouteAuth_AuthorizeBasic(t *testing.T) {
+	auth := &RouteAuth{Username: "dev", Password: "s3cret"}
+
+	req := httptest.NewRequest("GET", "https://myapp.test/", nil)
+	if auth.Authorize(req) {
+		t.Error("expected request without credentials to be rejected")
+	}
+
+	req.SetBasicAuth("dev", "wrong")
+	if auth.Authorize(req) {
+		t.Error("expected wrong password to be rejected")
+	}
+
+	req.SetBasicAuth("dev", "s3cret")
+	if !auth.Authorize(req) {
+		t.Error("expected correct credentials to be accepted")
+	}
+}
+
+func TestRouteAuth_AuthorizeBearer(t *testing.T) {
+	auth := &RouteAuth{Token: "tok"}
+
+	req := httptest.NewRequest("GET", "https://myapp.test/", nil)
+	req.Header.Set("Authorization", "Bearer nope")
+	if auth.Authorize(req) {
+		t.Error("expected wrong token to be rejected")
+	}
+
+	req.Header.Set("Authorization", "bearer tok")
+	if !auth.Authorize(req) {
+		t.Error("expected correct token to be accepted (scheme is case-insensitive)")
+	}
+
+	req.SetBasicAuth("tok", "tok")
+	if auth.Authorize(req) {
+		t.Error("expected basic credentials to be rejected for bearer route")
+	}
+}
+
+func TestRouteRegistry_SetAuth(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	if err := r.Register("myapp", "localhost:3000", "/tmp"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if err := r.SetAuth("myapp", &RouteAuth{Token: "tok"}); err != nil {
+		t.Fatalf("SetAuth: %v", err)
+	}
+	route, _ := r.Lookup("myapp")
+	if route.AuthMode != AuthModeBearer || route.Auth == nil || route.Auth.Token != "tok" {
+		t.Fatalf("unexpected route auth: mode=%q auth=%+v", route.AuthMode, route.Auth)
+	}
+
+	if err := r.SetAuth("myapp", nil); err != nil {
+		t.Fatalf("SetAuth(nil): %v", err)
+	}
+	route, _ = r.Lookup("myapp")
+	if route.AuthMode != "" || route.Auth != nil {
+		t.Fatalf("expected auth cleared, got mode=%q auth=%+v", route.AuthMode, route.Auth)
+	}
+
+	if err := r.SetAuth("missing", nil); err == nil {
+		t.Error("expected error for unknown route")
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import "fmt"
+
+// maxBalanceUpstreams bounds the extra upstreams a route may balance over.
+const maxBalanceUpstreams = 8
+
+// Balance modes.
+const (
+	// BalanceRoundRobin sends each request to the next upstream in turn.
+	BalanceRoundRobin = "round-robin"
+	// BalanceFallback sends every request to the first upstream that is
+	// up, in order.
+	BalanceFallback = "fallback"
+)
+
+// BalanceConfig spreads a route's requests over several instances of its
+// dev server, e.g. to test sticky sessions or load-balancing behavior.
+type BalanceConfig struct {
+	// Upstreams are loopback host:ports tried after the route's Upstream.
+	Upstreams []string `json:"upstreams"`
+	// Mode is round-robin (the default) or fallback.
+	Mode string `json:"mode,omitempty"`
+}
+
+// Order returns the route's upstreams in the order to try them: the
+// route's own Upstream first, then the balance upstreams.
+func (c *BalanceConfig) Order(primary string) []string {
+	if c == nil {
+		return []string{primary}
+	}
+	return append([]string{primary}, c.Upstreams...)
+}
+
+// RoundRobin reports whether requests rotate over the upstreams.
+func (c *BalanceConfig) RoundRobin() bool {
+	return c != nil && c.Mode != BalanceFallback
+}
+
+// validateBalance checks balance settings from a registration request.
+func validateBalance(c *BalanceConfig) error {
+	if c == nil {
+		return nil
+	}
+	if len(c.Upstreams) == 0 || len(c.Upstreams) > maxBalanceUpstreams {
+		return fmt.Errorf("balance needs 1 to %d upstreams", maxBalanceUpstreams)
+	}
+	for _, u := range c.Upstreams {
+		if err := validateUpstream(u); err != nil {
+			return fmt.Errorf("balance: %w", err)
+		}
+	}
+	if c.Mode != "" && c.Mode != BalanceRoundRobin && c.Mode != BalanceFallback {
+		return fmt.Errorf("balance mode must be %s or %s", BalanceRoundRobin, BalanceFallback)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidateBalance(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance *BalanceConfig
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"round-robin", &BalanceConfig{Upstreams: []string{"localhost:3001", "127.0.0.1:3002"}}, false},
+		{"fallback", &BalanceConfig{Upstreams: []string{"localhost:3001"}, Mode: BalanceFallback}, false},
+		{"no upstreams", &BalanceConfig{}, true},
+		{"too many upstreams", &BalanceConfig{Upstreams: slices.Repeat([]string{"localhost:3001"}, maxBalanceUpstreams+1)}, true},
+		{"remote upstream", &BalanceConfig{Upstreams: []string{"example.com:80"}}, true},
+		{"unknown mode", &BalanceConfig{Upstreams: []string{"localhost:3001"}, Mode: "random"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateBalance(tt.balance); (err != nil) != tt.wantErr {
+				t.Errorf("validateBalance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	req := RegisterRequest{Name: "app", Upstream: "localhost:3000", Dir: "/tmp/app", Passthrough: true,
+		Balance: &BalanceConfig{Upstreams: []string{"localhost:3001"}}}
+	if _, err := RouteFromRequest(req); err == nil {
+		t.Error("passthrough route with balance accepted")
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Trailing slash modes for CanonicalConfig.
+const (
+	// TrailingSlashAdd redirects /about to /about/.
+	TrailingSlashAdd = "add"
+	// TrailingSlashStrip redirects /about/ to /about.
+	TrailingSlashStrip = "strip"
+)
+
+// CanonicalConfig redirects requests to a route's canonical URL at the
+// proxy, so redirect rules meant for production (SEO rules, mostly) can be
+// tried locally without the app implementing them.
+type CanonicalConfig struct {
+	// TrailingSlash is TrailingSlashAdd or TrailingSlashStrip. Paths whose
+	// last segment looks like a file (app.js) and the root are left alone.
+	TrailingSlash string `json:"trailingSlash,omitempty"`
+	// LowercaseHost redirects hosts with uppercase letters to lowercase.
+	LowercaseHost bool `json:"lowercaseHost,omitempty"`
+	// StripWWW redirects www.myapp.test to myapp.test. The route answers
+	// for the www name even without Subdomains.
+	StripWWW bool `json:"stripWww,omitempty"`
+	// Status is the redirect status, http.StatusMovedPermanently if zero.
+	Status int `json:"status,omitempty"`
+}
+
+// ValidateCanonical checks canonical redirect rules from a registration
+// request.
+func ValidateCanonical(c *CanonicalConfig) error {
+	if c == nil {
+		return nil
+	}
+	switch c.TrailingSlash {
+	case "", TrailingSlashAdd, TrailingSlashStrip:
+	default:
+		return fmt.Errorf("invalid canonical trailingSlash %q: must be %s or %s", c.TrailingSlash, TrailingSlashAdd, TrailingSlashStrip)
+	}
+	switch c.Status {
+	case 0, http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return fmt.Errorf("invalid canonical status %d: must be 301, 302, 307, or 308", c.Status)
+	}
+	return nil
+}
+
+// validateCanonicalName checks a route's canonical rules against its
+// name, which RouteFromRequest doesn't see. A route named with capitals
+// would redirect lowercased hosts to a name it doesn't answer for.
+func validateCanonicalName(route Route) error {
+	if route.Canonical != nil && route.Canonical.LowercaseHost && route.Name != strings.ToLower(route.Name) {
+		return fmt.Errorf("canonical lowercaseHost needs a lowercase route name, not %q", route.Name)
+	}
+	return nil
+}
+
+// RedirectStatus returns the status canonical redirects are sent with.
+func (c *CanonicalConfig) RedirectStatus() int {
+	if c.Status == 0 {
+		return http.StatusMovedPermanently
+	}
+	return c.Status
+}
+
+// LookupCanonical returns the route a host reaches only through its
+// canonical redirects: myapp for www.myapp.test with StripWWW, or for
+// MyApp.test with LowercaseHost. Use it once LookupSubdomain has found
+// nothing, so registered names always win.
+func (r *RouteRegistry) LookupCanonical(host string, tlds []string) (Route, bool) {
+	name := ExtractNameFor(host, tlds)
+	lower := ExtractNameFor(strings.ToLower(host), tlds)
+	bare, _ := cutWWW(name)
+	lowerBare, _ := cutWWW(lower)
+	for _, candidate := range []string{lower, bare, lowerBare} {
+		route, ok := r.Lookup(candidate)
+		if !ok || route.Canonical == nil {
+			continue
+		}
+		if route.Canonical.answers(name, lower, route.Name) {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// answers reports whether a route named routeName redirects a host whose
+// route name is name (lower when lowercased) to itself.
+func (c *CanonicalConfig) answers(name, lower, routeName string) bool {
+	if c.LowercaseHost {
+		name = lower
+	}
+	if c.StripWWW {
+		name, _ = cutWWW(name)
+	}
+	return name == routeName
+}
+
+// Redirect returns the canonical URL for r, served over scheme, and
+// whether it differs from the URL r asked for.
+func (c *CanonicalConfig) Redirect(r *http.Request, scheme string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	host := r.Host
+	if c.LowercaseHost {
+		host = strings.ToLower(host)
+	}
+	if c.StripWWW {
+		if bare, ok := cutWWW(host); ok {
+			host = bare
+		}
+	}
+
+	u := *r.URL
+	switch p := u.Path; {
+	case p == "/" || p == "" || strings.Contains(path.Base(p), "."):
+	case c.TrailingSlash == TrailingSlashAdd && !strings.HasSuffix(p, "/"):
+		u.Path = p + "/"
+		if u.RawPath != "" {
+			u.RawPath += "/"
+		}
+	case c.TrailingSlash == TrailingSlashStrip && strings.HasSuffix(p, "/"):
+		u.Path = strings.TrimRight(p, "/")
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+		if u.Path == "" {
+			u.Path, u.RawPath = "/", ""
+		}
+	}
+
+	if host == r.Host && u.Path == r.URL.Path {
+		return "", false
+	}
+	u.Scheme, u.Host, u.Fragment = scheme, host, ""
+	return u.String(), true
+}
+
+// cutWWW removes a leading "www." label from host, in any case. A bare
+// "www" host (or www with only a port) is not stripped.
+func cutWWW(host string) (string, bool) {
+	if len(host) < 4 || !strings.EqualFold(host[:4], "www.") {
+		return host, false
+	}
+	rest := host[4:]
+	name := rest
+	if h, _, err := net.SplitHostPort(rest); err == nil {
+		name = h
+	}
+	if name == "" {
+		return host, false
+	}
+	return rest, true
+}
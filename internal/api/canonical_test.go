@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidateCanonical(t *testing.T) {
+	valid := []*CanonicalConfig{
+		nil,
+		{},
+		{TrailingSlash: TrailingSlashAdd, LowercaseHost: true, StripWWW: true},
+		{TrailingSlash: TrailingSlashStrip, Status: 308},
+	}
+	for _, c := range valid {
+		if err := ValidateCanonical(c); err != nil {
+			t.Errorf("ValidateCanonical(%+v) = %v, want nil", c, err)
+		}
+	}
+	invalid := []*CanonicalConfig{
+		{TrailingSlash: "always"},
+		{Status: 200},
+		{Status: 303},
+	}
+	for _, c := range invalid {
+		if err := ValidateCanonical(c); err == nil {
+			t.Errorf("ValidateCanonical(%+v) = nil, want error", c)
+		}
+	}
+
+	lower := &CanonicalConfig{LowercaseHost: true}
+	if err := validateCanonicalName(Route{Name: "MyApp", Canonical: lower}); err == nil {
+		t.Error("lowercaseHost on a capitalized route name should be rejected")
+	}
+	if err := validateCanonicalName(Route{Name: "MyApp", Canonical: &CanonicalConfig{StripWWW: true}}); err != nil {
+		t.Errorf("stripWww on a capitalized route name: %v", err)
+	}
+}
+
+func TestCanonicalConfig_Redirect(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *CanonicalConfig
+		url    string
+		want   string
+	}{
+		{"nil", nil, "https://myapp.test/about/", ""},
+		{"add slash", &CanonicalConfig{TrailingSlash: TrailingSlashAdd}, "https://myapp.test/about?x=1", "https://myapp.test/about/?x=1"},
+		{"add slash keeps files", &CanonicalConfig{TrailingSlash: TrailingSlashAdd}, "https://myapp.test/app.js", ""},
+		{"add slash already canonical", &CanonicalConfig{TrailingSlash: TrailingSlashAdd}, "https://myapp.test/about/", ""},
+		{"strip slash", &CanonicalConfig{TrailingSlash: TrailingSlashStrip}, "https://myapp.test/about//", "https://myapp.test/about"},
+		{"strip slash keeps root", &CanonicalConfig{TrailingSlash: TrailingSlashStrip}, "https://myapp.test/", ""},
+		{"lowercase host", &CanonicalConfig{LowercaseHost: true}, "https://MyApp.Test/About", "https://myapp.test/About"},
+		{"strip www", &CanonicalConfig{StripWWW: true}, "https://www.myapp.test:8443/a", "https://myapp.test:8443/a"},
+		{"strip www any case", &CanonicalConfig{StripWWW: true}, "https://WWW.myapp.test/", "https://myapp.test/"},
+		{"all at once", &CanonicalConfig{TrailingSlash: TrailingSlashStrip, LowercaseHost: true, StripWWW: true}, "https://WWW.MyApp.test/a/", "https://myapp.test/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.url, nil)
+			got, ok := tt.config.Redirect(r, "https")
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("Redirect(%s) = %q, %v, want %q", tt.url, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalConfig_RedirectStatus(t *testing.T) {
+	if got := (&CanonicalConfig{}).RedirectStatus(); got != 301 {
+		t.Errorf("default status = %d, want 301", got)
+	}
+	if got := (&CanonicalConfig{Status: 307}).RedirectStatus(); got != 307 {
+		t.Errorf("status = %d, want 307", got)
+	}
+}
+
+func TestLookupCanonical(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	routes := []Route{
+		{Name: "shop", Upstream: "localhost:3000", Dir: "/tmp/shop", Canonical: &CanonicalConfig{StripWWW: true, LowercaseHost: true}},
+		{Name: "blog", Upstream: "localhost:3001", Dir: "/tmp/blog", Canonical: &CanonicalConfig{StripWWW: true}},
+		{Name: "docs", Upstream: "localhost:3002", Dir: "/tmp/docs"},
+	}
+	for _, route := range routes {
+		if err := registry.RegisterRoute(route); err != nil {
+			t.Fatalf("RegisterRoute(%s): %v", route.Name, err)
+		}
+	}
+	tests := []struct {
+		host, want string
+	}{
+		{"www.shop.test", "shop"},
+		{"WWW.Shop.TEST:443", "shop"},
+		{"Shop.test", "shop"},
+		{"www.blog.test", "blog"},
+		// blog only strips www., so capitals don't reach it.
+		{"Blog.test", ""},
+		{"www.Blog.test", ""},
+		// Routes without canonical rules keep exact matching.
+		{"www.docs.test", ""},
+		{"Docs.test", ""},
+		{"www.www.shop.test", ""},
+	}
+	for _, tt := range tests {
+		route, ok := registry.LookupCanonical(tt.host, []string{"test"})
+		if ok != (tt.want != "") || route.Name != tt.want {
+			t.Errorf("LookupCanonical(%q) = %q, %v, want %q", tt.host, route.Name, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MaxCaptureBody is the largest body limit a capture subscriber may ask
+// for, per request and per response.
+const MaxCaptureBody = 1 << 20
+
+// captureBuffer is how many captures a subscriber may fall behind by
+// before further ones are dropped for it.
+const captureBuffer = 256
+
+// Capture is one request proxied for a route, as streamed by
+// GET /routes/{name}/capture. Bodies are only included when the
+// subscriber asked for them, up to its limit; JSON encodes them as base64.
+type Capture struct {
+	Time                  time.Time   `json:"time"`
+	Route                 string      `json:"route"`
+	Method                string      `json:"method"`
+	Host                  string      `json:"host"`
+	URL                   string      `json:"url"`
+	Status                int         `json:"status"`
+	DurationMs            float64     `json:"durationMs"`
+	RequestHeaders        http.Header `json:"requestHeaders"`
+	ResponseHeaders       http.Header `json:"responseHeaders"`
+	RequestSize           int64       `json:"requestSize"`
+	ResponseSize          int64       `json:"responseSize"`
+	RequestBody           []byte      `json:"requestBody,omitempty"`
+	RequestBodyTruncated  bool        `json:"requestBodyTruncated,omitempty"`
+	ResponseBody          []byte      `json:"responseBody,omitempty"`
+	ResponseBodyTruncated bool        `json:"responseBodyTruncated,omitempty"`
+}
+
+// CaptureHub fans captured requests out to subscribers by route. Its
+// methods are safe on a nil hub, which captures nothing.
+type CaptureHub struct {
+	mu   sync.Mutex
+	subs map[chan Capture]captureSub
+}
+
+type captureSub struct {
+	route     string
+	bodyLimit int
+}
+
+// NewCaptureHub returns a hub with no subscribers.
+func NewCaptureHub() *CaptureHub {
+	return &CaptureHub{subs: make(map[chan Capture]captureSub)}
+}
+
+// Subscribe returns a channel that receives captures for route until it
+// is passed to Unsubscribe. bodyLimit is how many bytes of each body to
+// include; zero leaves bodies out.
+func (h *CaptureHub) Subscribe(route string, bodyLimit int) chan Capture {
+	ch := make(chan Capture, captureBuffer)
+	h.mu.Lock()
+	h.subs[ch] = captureSub{route: route, bodyLimit: bodyLimit}
+	h.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe stops delivering captures to ch.
+func (h *CaptureHub) Unsubscribe(ch chan Capture) {
+	h.mu.Lock()
+	delete(h.subs, ch)
+	h.mu.Unlock()
+}
+
+// Watching reports whether anyone is capturing route, and the largest
+// body limit among them, so requests nobody watches cost nothing.
+func (h *CaptureHub) Watching(route string) (bodyLimit int, ok bool) {
+	if h == nil {
+		return 0, false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, sub := range h.subs {
+		if sub.route == route {
+			ok = true
+			bodyLimit = max(bodyLimit, sub.bodyLimit)
+		}
+	}
+	return bodyLimit, ok
+}
+
+// Publish sends c to the route's subscribers, each with bodies cut to its
+// own limit. Subscribers that fall behind miss captures rather than
+// blocking the request.
+func (h *CaptureHub) Publish(c Capture) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch, sub := range h.subs {
+		if sub.route != c.Route {
+			continue
+		}
+		out := c
+		out.RequestBody, out.RequestBodyTruncated = cutBody(c.RequestBody, c.RequestBodyTruncated, sub.bodyLimit)
+		out.ResponseBody, out.ResponseBodyTruncated = cutBody(c.ResponseBody, c.ResponseBodyTruncated, sub.bodyLimit)
+		select {
+		case ch <- out:
+		default:
+		}
+	}
+}
+
+// cutBody shortens body to limit bytes, reporting whether anything is
+// missing from the result.
+func cutBody(body []byte, truncated bool, limit int) ([]byte, bool) {
+	if limit == 0 {
+		return nil, false
+	}
+	if len(body) > limit {
+		return body[:limit], true
+	}
+	return body, truncated
+}
+
+// SetCaptureHub sets the hub GET /routes/{name}/capture streams from.
+func (s *Server) SetCaptureHub(h *CaptureHub) {
+	s.captures = h
+}
+
+// handleCapture streams captures for the route in the path as ndjson until
+// the client disconnects or the server shuts down. The route must exist
+// when the stream starts; if it is later re-registered (an `up` restart),
+// its requests keep streaming. ?bodies=N includes up to N bytes of each
+// request and response body.
+func (s *Server) handleCapture(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if s.captures == nil {
+		jsonError(w, "capture not supported", http.StatusNotImplemented)
+		return
+	}
+	if _, ok := s.registry.Lookup(name); !ok {
+		jsonError(w, "route not found", http.StatusNotFound)
+		return
+	}
+	bodyLimit := 0
+	if v := r.URL.Query().Get("bodies"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > MaxCaptureBody {
+			jsonError(w, fmt.Sprintf("invalid bodies: must be 0-%d", MaxCaptureBody), http.StatusBadRequest)
+			return
+		}
+		bodyLimit = n
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		jsonError(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := s.captures.Subscribe(name, bodyLimit)
+	defer s.captures.Unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.shutdown:
+			return
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, "\n")
+		case c := <-ch:
+			data, merr := json.Marshal(c)
+			if merr != nil {
+				continue
+			}
+			_, err = fmt.Fprintf(w, "%s\n", data)
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCaptureHub(t *testing.T) {
+	var nilHub *CaptureHub
+	if _, ok := nilHub.Watching("shop"); ok {
+		t.Error("nil hub reports a subscriber")
+	}
+	nilHub.Publish(Capture{Route: "shop"}) // must not panic
+
+	h := NewCaptureHub()
+	meta := h.Subscribe("shop", 0)
+	bodies := h.Subscribe("shop", 4)
+	other := h.Subscribe("blog", 1024)
+	defer h.Unsubscribe(meta)
+	defer h.Unsubscribe(bodies)
+	defer h.Unsubscribe(other)
+
+	if limit, ok := h.Watching("shop"); !ok || limit != 4 {
+		t.Errorf("Watching(shop) = %d, %v; want 4, true", limit, ok)
+	}
+	if _, ok := h.Watching("api"); ok {
+		t.Error("Watching(api) = true with no subscriber")
+	}
+
+	h.Publish(Capture{Route: "shop", RequestBody: []byte("hello"), ResponseBody: []byte("ok")})
+
+	if c := <-meta; c.RequestBody != nil || c.ResponseBody != nil {
+		t.Errorf("metadata-only subscriber got bodies: %+v", c)
+	}
+	c := <-bodies
+	if string(c.RequestBody) != "hell" || !c.RequestBodyTruncated {
+		t.Errorf("request body = %q truncated=%v, want %q truncated", c.RequestBody, c.RequestBodyTruncated, "hell")
+	}
+	if string(c.ResponseBody) != "ok" || c.ResponseBodyTruncated {
+		t.Errorf("response body = %q truncated=%v, want %q", c.ResponseBody, c.ResponseBodyTruncated, "ok")
+	}
+	select {
+	case c := <-other:
+		t.Errorf("blog subscriber got a shop capture: %+v", c)
+	default:
+	}
+}
+
+func TestHandleCapture(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	registry.Register("shop", "localhost:3000", "/tmp/shop")
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+	hub := NewCaptureHub()
+	srv.SetCaptureHub(hub)
+	ts := httptest.NewServer(srv.server.Handler)
+	defer ts.Close()
+
+	for path, want := range map[string]int{
+		"/v1/routes/missing/capture":              http.StatusNotFound,
+		"/v1/routes/shop/capture?bodies=-1":       http.StatusBadRequest,
+		"/v1/routes/shop/capture?bodies=99999999": http.StatusBadRequest,
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+
+	resp, err := http.Get(ts.URL + "/v1/routes/shop/capture?bodies=16")
+	if err != nil {
+		t.Fatalf("GET capture: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	for {
+		if _, ok := hub.Watching("shop"); ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	hub.Publish(Capture{Route: "shop", Method: "POST", URL: "/cart", Status: 201, RequestBody: []byte(`{"id":1}`)})
+
+	line, err := bufio.NewReader(resp.Body).ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("reading capture: %v", err)
+	}
+	var c Capture
+	if err := json.Unmarshal(line, &c); err != nil {
+		t.Fatalf("decoding %s: %v", line, err)
+	}
+	if c.Method != "POST" || c.URL != "/cart" || c.Status != 201 || string(c.RequestBody) != `{"id":1}` {
+		t.Errorf("capture = %+v", c)
+	}
+}
@@ -0,0 +1,101 @@
+// internal/api/churn.go
+package api
+
+import (
+	"slices"
+	"time"
+)
+
+// churnWindow is how far back registrations and missed heartbeats are
+// counted.
+const churnWindow = time.Hour
+
+// A route is flapping once it has been registered flapRegistrations times,
+// or missed flapMissedHeartbeats heartbeat deadlines, within churnWindow.
+// That usually means a dev server crash-looping under `up --restart`.
+const (
+	flapRegistrations    = 5
+	flapMissedHeartbeats = 3
+)
+
+// RouteChurn counts how often a route came and went in the last hour.
+type RouteChurn struct {
+	// Registrations counts registrations, including updates in place.
+	Registrations int `json:"registrations"`
+	// MissedHeartbeats counts removals for a missed heartbeat deadline.
+	MissedHeartbeats int `json:"missedHeartbeats"`
+	// Flapping is set once either count crosses its threshold.
+	Flapping bool `json:"flapping,omitempty"`
+}
+
+// churnHistory is the recent registrations and heartbeat expiries of a
+// route name. It outlives the route, so a route that keeps expiring and
+// coming back is still counted.
+type churnHistory struct {
+	registrations []time.Time
+	missed        []time.Time
+}
+
+// prune drops events older than churnWindow and reports whether any are
+// left.
+func (h *churnHistory) prune(now time.Time) bool {
+	cutoff := now.Add(-churnWindow)
+	old := func(t time.Time) bool { return t.Before(cutoff) }
+	h.registrations = slices.DeleteFunc(h.registrations, old)
+	h.missed = slices.DeleteFunc(h.missed, old)
+	return len(h.registrations) > 0 || len(h.missed) > 0
+}
+
+// churn counts the events within churnWindow of now.
+func (h *churnHistory) churn(now time.Time) RouteChurn {
+	cutoff := now.Add(-churnWindow)
+	c := RouteChurn{}
+	for _, t := range h.registrations {
+		if !t.Before(cutoff) {
+			c.Registrations++
+		}
+	}
+	for _, t := range h.missed {
+		if !t.Before(cutoff) {
+			c.MissedHeartbeats++
+		}
+	}
+	c.Flapping = c.Registrations >= flapRegistrations || c.MissedHeartbeats >= flapMissedHeartbeats
+	return c
+}
+
+// recordChurn notes a registration of name, or a missed heartbeat when
+// missed is set, and warns when that makes the route start flapping.
+// Callers hold r.mu.
+func (r *RouteRegistry) recordChurn(name string, missed bool, now time.Time) {
+	h := r.churn[name]
+	if h == nil {
+		h = &churnHistory{}
+		r.churn[name] = h
+	}
+	h.prune(now)
+	was := h.churn(now).Flapping
+	if missed {
+		h.missed = append(h.missed, now)
+	} else {
+		h.registrations = append(h.registrations, now)
+	}
+	if c := h.churn(now); c.Flapping && !was && r.logger != nil {
+		r.logger.Warn("route is flapping; its dev server may be crash-looping",
+			"route", name, "registrations", c.Registrations, "missed_heartbeats", c.MissedHeartbeats, "window", churnWindow)
+	}
+}
+
+// churnOf returns the recent churn of name, or nil if it only registered
+// once and never missed a heartbeat. Callers hold r.mu.
+func (r *RouteRegistry) churnOf(name string, now time.Time) *RouteChurn {
+	h := r.churn[name]
+	if h == nil {
+		return nil
+	}
+	c := h.churn(now)
+	if c.Registrations <= 1 && c.MissedHeartbeats == 0 {
+		return nil
+	}
+	return &c
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteRegistry_FlappingRoute(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRouteRegistry(30 * time.Second)
+	r.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	r.Register("steady", "localhost:3001", "/tmp/steady")
+	for range flapRegistrations + 2 {
+		r.UpsertRoute(Route{Name: "crashy", Upstream: "localhost:3000", Dir: "/tmp/crashy"}, Caller{})
+	}
+
+	churn := map[string]*RouteChurn{}
+	for _, route := range r.List() {
+		churn[route.Name] = route.Churn
+	}
+	if churn["steady"] != nil {
+		t.Errorf("steady route has churn %+v", churn["steady"])
+	}
+	if c := churn["crashy"]; c == nil || !c.Flapping || c.Registrations != flapRegistrations+2 {
+		t.Errorf("crashy churn = %+v, want %d registrations and flapping", c, flapRegistrations+2)
+	}
+	if n := strings.Count(buf.String(), "route is flapping"); n != 1 {
+		t.Errorf("logged %d flapping warnings, want 1:\n%s", n, buf.String())
+	}
+}
+
+func TestRouteRegistry_ChurnCountsMissedHeartbeats(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	r.Register("myapp", "localhost:3000", "/tmp/myapp")
+	r.mu.Lock()
+	r.routes["myapp"].LastHeartbeat = time.Now().Add(-time.Minute)
+	r.mu.Unlock()
+	r.Sweep()
+
+	// The history outlives the route, so it shows once it comes back.
+	r.Register("myapp", "localhost:3000", "/tmp/myapp")
+	route := r.List()[0]
+	if c := route.Churn; c == nil || c.MissedHeartbeats != 1 || c.Registrations != 2 || c.Flapping {
+		t.Errorf("churn = %+v, want 2 registrations and 1 missed heartbeat", c)
+	}
+
+	// Events older than the window are forgotten.
+	r.mu.Lock()
+	h := r.churn["myapp"]
+	for i := range h.registrations {
+		h.registrations[i] = h.registrations[i].Add(-2 * churnWindow)
+	}
+	for i := range h.missed {
+		h.missed[i] = h.missed[i].Add(-2 * churnWindow)
+	}
+	r.mu.Unlock()
+	r.Sweep()
+	if _, ok := r.churn["myapp"]; ok {
+		t.Error("expected stale churn history to be pruned")
+	}
+}
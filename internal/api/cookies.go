@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// CookieConfig adjusts the Set-Cookie headers of a route's responses, so
+// auth flows written against localhost keep working under the route's
+// name. Secure and SameSite only change over HTTPS, since browsers drop
+// Secure cookies set over plain HTTP.
+type CookieConfig struct {
+	// Secure adds the Secure attribute to every cookie.
+	Secure bool `json:"secure,omitempty"`
+	// Domain sets each cookie's Domain to the route's host, replacing
+	// whatever the upstream sent, so subdomains such as api.myapp.test
+	// share them.
+	Domain bool `json:"domain,omitempty"`
+	// SameSiteNone sets SameSite=None, for cookies sent on cross-site
+	// requests such as OAuth callbacks. It implies Secure, which browsers
+	// require for it.
+	SameSiteNone bool `json:"sameSiteNone,omitempty"`
+}
+
+// Apply rewrites the Set-Cookie headers in h for a response served at
+// scheme://host.
+func (c *CookieConfig) Apply(h http.Header, scheme, host string) {
+	if c == nil {
+		return
+	}
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	https := scheme == "https"
+	cookies := h["Set-Cookie"]
+	for i, cookie := range cookies {
+		attrs := strings.Split(cookie, ";")
+		kept := []string{attrs[0]}
+		secure := false
+		for _, attr := range attrs[1:] {
+			name, _, _ := strings.Cut(strings.TrimSpace(attr), "=")
+			switch {
+			case c.Domain && strings.EqualFold(name, "Domain"),
+				https && c.SameSiteNone && strings.EqualFold(name, "SameSite"):
+				continue
+			case strings.EqualFold(name, "Secure"):
+				secure = true
+			}
+			kept = append(kept, attr)
+		}
+		if c.Domain {
+			kept = append(kept, " Domain="+host)
+		}
+		if https && (c.Secure || c.SameSiteNone) && !secure {
+			kept = append(kept, " Secure")
+		}
+		if https && c.SameSiteNone {
+			kept = append(kept, " SameSite=None")
+		}
+		cookies[i] = strings.Join(kept, ";")
+	}
+}
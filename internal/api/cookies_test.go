@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCookieConfigApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		c      *CookieConfig
+		scheme string
+		in     string
+		want   string
+	}{
+		{"nil", nil, "https", "sid=1; Path=/", "sid=1; Path=/"},
+		{"secure", &CookieConfig{Secure: true}, "https", "sid=1; Path=/", "sid=1; Path=/; Secure"},
+		{"already secure", &CookieConfig{Secure: true}, "https", "sid=1; secure", "sid=1; secure"},
+		{"secure over http", &CookieConfig{Secure: true}, "http", "sid=1", "sid=1"},
+		{"domain", &CookieConfig{Domain: true}, "https", "sid=1; Domain=localhost; HttpOnly", "sid=1; HttpOnly; Domain=myapp.test"},
+		{"domain over http", &CookieConfig{Domain: true}, "http", "sid=1", "sid=1; Domain=myapp.test"},
+		{"samesite none", &CookieConfig{SameSiteNone: true}, "https", "sid=1; SameSite=Lax", "sid=1; Secure; SameSite=None"},
+		{"samesite over http", &CookieConfig{SameSiteNone: true}, "http", "sid=1; SameSite=Lax", "sid=1; SameSite=Lax"},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Add("Set-Cookie", tt.in)
+		tt.c.Apply(h, tt.scheme, "myapp.test:443")
+		if got := h.Get("Set-Cookie"); got != tt.want {
+			t.Errorf("%s: Set-Cookie = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
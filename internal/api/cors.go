@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// maxCORSEntries bounds each CORS list accepted over the API.
+const maxCORSEntries = 20
+
+// corsMaxAge is how long (seconds) browsers may cache a preflight answer.
+const corsMaxAge = "600"
+
+// defaultCORSMethods is returned to preflights when Methods is empty.
+var defaultCORSMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// CORSConfig makes the daemon answer CORS preflights and decorate responses
+// for a route, so cross-origin frontends work without backend changes.
+// An empty config is fully permissive: any origin is reflected and
+// credentials are allowed.
+type CORSConfig struct {
+	// Origins restricts allowed origins (e.g. "https://app.test"). Empty allows any.
+	Origins []string `json:"origins,omitempty"`
+	// Methods overrides the allowed methods returned to preflights.
+	Methods []string `json:"methods,omitempty"`
+	// Headers overrides the allowed request headers. Empty reflects the
+	// preflight's Access-Control-Request-Headers.
+	Headers []string `json:"headers,omitempty"`
+}
+
+// IsPreflight reports whether r is a CORS preflight request.
+func IsPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// AllowsOrigin reports whether origin may access the route.
+func (c *CORSConfig) AllowsOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if len(c.Origins) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(c.Origins, func(o string) bool {
+		return strings.EqualFold(o, origin)
+	})
+}
+
+// ApplyPreflight writes the headers answering a preflight from r into h.
+// Nothing is written when the origin is not allowed.
+func (c *CORSConfig) ApplyPreflight(h http.Header, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if !c.AllowsOrigin(origin) {
+		return
+	}
+	c.applyOrigin(h, origin)
+
+	methods := c.Methods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+
+	if len(c.Headers) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(c.Headers, ", "))
+	} else if reqHeaders := r.Header.Get("Access-Control-Request-Headers"); reqHeaders != "" {
+		h.Set("Access-Control-Allow-Headers", reqHeaders)
+		h.Add("Vary", "Access-Control-Request-Headers")
+	}
+	h.Set("Access-Control-Max-Age", corsMaxAge)
+}
+
+// ApplyResponse decorates a proxied response for origin, replacing any
+// CORS headers the upstream set itself.
+func (c *CORSConfig) ApplyResponse(h http.Header, origin string) {
+	if !c.AllowsOrigin(origin) {
+		return
+	}
+	h.Del("Access-Control-Allow-Origin")
+	h.Del("Access-Control-Allow-Credentials")
+	c.applyOrigin(h, origin)
+	h.Set("Access-Control-Expose-Headers", "*")
+}
+
+func (c *CORSConfig) applyOrigin(h http.Header, origin string) {
+	// Reflect the origin rather than "*" so credentialed requests work.
+	h.Set("Access-Control-Allow-Origin", origin)
+	h.Set("Access-Control-Allow-Credentials", "true")
+	h.Add("Vary", "Origin")
+}
+
+// validateCORS ensures CORS lists are bounded and well-formed.
+func validateCORS(c *CORSConfig) error {
+	if c == nil {
+		return nil
+	}
+	if len(c.Origins) > maxCORSEntries || len(c.Methods) > maxCORSEntries || len(c.Headers) > maxCORSEntries {
+		return fmt.Errorf("cors: at most %d entries per list", maxCORSEntries)
+	}
+	for _, o := range c.Origins {
+		u, err := url.Parse(o)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			return fmt.Errorf("cors: invalid origin %q (expected scheme://host[:port])", o)
+		}
+	}
+	for _, m := range c.Methods {
+		if !headerNamePattern.MatchString(m) {
+			return fmt.Errorf("cors: invalid method %q", m)
+		}
+	}
+	for _, h := range c.Headers {
+		if !headerNamePattern.MatchString(h) {
+			return fmt.Errorf("cors: invalid header %q", h)
+		}
+	}
+	return nil
+}
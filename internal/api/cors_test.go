@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsPreflight(t *testing.T) {
+	req := httptest.NewRequest("OPTIONS", "https://api.test/items", nil)
+	if IsPreflight(req) {
+		t.Error("plain OPTIONS should not be a preflight")
+	}
+	req.Header.Set("Origin", "https://app.test")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	if !IsPreflight(req) {
+		t.Error("expected preflight")
+	}
+}
+
+func TestCORSConfig_ApplyPreflightPermissive(t *testing.T) {
+	c := &CORSConfig{}
+	req := httptest.NewRequest("OPTIONS", "https://api.test/items", nil)
+	req.Header.Set("Origin", "https://app.test")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type, x-csrf")
+
+	h := http.Header{}
+	c.ApplyPreflight(h, req)
+
+	if got := h.Get("Access-Control-Allow-Origin"); got != "https://app.test" {
+		t.Errorf("Allow-Origin = %q, want reflected origin", got)
+	}
+	if got := h.Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Allow-Credentials = %q, want true", got)
+	}
+	if got := h.Get("Access-Control-Allow-Headers"); got != "content-type, x-csrf" {
+		t.Errorf("Allow-Headers = %q, want reflected request headers", got)
+	}
+	if h.Get("Access-Control-Allow-Methods") == "" {
+		t.Error("expected Allow-Methods")
+	}
+}
+
+func TestCORSConfig_RestrictedOrigins(t *testing.T) {
+	c := &CORSConfig{Origins: []string{"https://app.test"}}
+
+	h := http.Header{}
+	c.ApplyResponse(h, "https://evil.example")
+	if h.Get("Access-Control-Allow-Origin") != "" {
+		t.Error("expected no CORS headers for disallowed origin")
+	}
+
+	h = http.Header{"Access-Control-Allow-Origin": {"*"}}
+	c.ApplyResponse(h, "https://app.test")
+	if got := h.Values("Access-Control-Allow-Origin"); len(got) != 1 || got[0] != "https://app.test" {
+		t.Errorf("Allow-Origin = %v, want upstream value replaced", got)
+	}
+}
+
+func TestValidateCORS(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   *CORSConfig
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"permissive", &CORSConfig{}, false},
+		{"origin", &CORSConfig{Origins: []string{"https://app.test:8443"}}, false},
+		{"origin with path", &CORSConfig{Origins: []string{"https://app.test/x"}}, true},
+		{"origin without scheme", &CORSConfig{Origins: []string{"app.test"}}, true},
+		{"bad header", &CORSConfig{Headers: []string{"bad header"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateCORS(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("validateCORS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// internal/api/events.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Route event types.
+const (
+	// EventAdded is sent when a route is registered.
+	EventAdded = "added"
+	// EventUpdated is sent when a route is re-registered in place with
+	// PUT /routes/{name}.
+	EventUpdated = "updated"
+	// EventRemoved is sent when a route is deregistered.
+	EventRemoved = "removed"
+	// EventExpired is sent when a route missed its heartbeat deadline or
+	// the keep-alive connection holding it closed.
+	EventExpired = "expired"
+	// EventIdleExpired is sent when a route with an idle timeout served no
+	// requests for that long.
+	EventIdleExpired = "idle-expired"
+)
+
+// eventKeepAlive is how often GET /events writes a comment to idle
+// streams, so clients and the server notice dead connections.
+const eventKeepAlive = 30 * time.Second
+
+// eventBuffer is how many events a subscriber may fall behind by before
+// further events are dropped for it.
+const eventBuffer = 64
+
+// RouteEvent describes a change to the route registry.
+type RouteEvent struct {
+	Type     string    `json:"type"`
+	Route    string    `json:"route"`
+	Upstream string    `json:"upstream"`
+	Dir      string    `json:"dir"`
+	Preview  string    `json:"preview,omitempty"`
+	Project  string    `json:"project,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Subscribe returns a channel that receives registry changes until it is
+// passed to Unsubscribe. Events are dropped for a subscriber that falls
+// behind rather than blocking the registry.
+func (r *RouteRegistry) Subscribe() chan RouteEvent {
+	ch := make(chan RouteEvent, eventBuffer)
+	r.subsMu.Lock()
+	r.subs[ch] = struct{}{}
+	r.subsMu.Unlock()
+	return ch
+}
+
+// Unsubscribe stops delivering events to ch.
+func (r *RouteRegistry) Unsubscribe(ch chan RouteEvent) {
+	r.subsMu.Lock()
+	delete(r.subs, ch)
+	r.subsMu.Unlock()
+}
+
+// publish sends an event for route to every subscriber.
+func (r *RouteRegistry) publish(typ string, route *Route) {
+	ev := RouteEvent{
+		Type:     typ,
+		Route:    route.Name,
+		Upstream: route.Upstream,
+		Dir:      route.Dir,
+		Preview:  route.Preview,
+		Project:  route.Project,
+		Time:     time.Now(),
+	}
+	r.subsMu.Lock()
+	for ch := range r.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+	r.subsMu.Unlock()
+}
+
+// wantsNDJSON reports whether a GET /events client asked for
+// newline-delimited JSON instead of server-sent events.
+func wantsNDJSON(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "ndjson" {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/x-ndjson")
+}
+
+// handleEvents streams registry changes until the client disconnects or
+// the server shuts down.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		jsonError(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	ndjson := wantsNDJSON(r)
+
+	ch := s.registry.Subscribe()
+	defer s.registry.Unsubscribe(ch)
+
+	if ndjson {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "text/event-stream")
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.shutdown:
+			return
+		case <-keepAlive.C:
+			if ndjson {
+				_, err = fmt.Fprint(w, "\n")
+			} else {
+				_, err = fmt.Fprint(w, ": keep-alive\n\n")
+			}
+		case ev := <-ch:
+			data, merr := json.Marshal(ev)
+			if merr != nil {
+				continue
+			}
+			if ndjson {
+				_, err = fmt.Fprintf(w, "%s\n", data)
+			} else {
+				_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+			}
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// nextEvent returns the next event on ch or fails after a second.
+func nextEvent(t *testing.T, ch chan RouteEvent) RouteEvent {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+		return RouteEvent{}
+	}
+}
+
+func TestRouteRegistry_Events(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	ch := r.Subscribe()
+	defer r.Unsubscribe(ch)
+
+	r.Register("myapp", "localhost:3000", "/tmp/myapp")
+	ev := nextEvent(t, ch)
+	if ev.Type != EventAdded || ev.Route != "myapp" || ev.Upstream != "localhost:3000" || ev.Dir != "/tmp/myapp" {
+		t.Errorf("register event = %+v", ev)
+	}
+
+	r.Deregister("myapp")
+	if ev := nextEvent(t, ch); ev.Type != EventRemoved || ev.Route != "myapp" {
+		t.Errorf("deregister event = %+v", ev)
+	}
+
+	r.Deregister("missing")
+	r.Register("stale", "localhost:3000", "/tmp/stale")
+	nextEvent(t, ch)
+	r.RegisterRoute(Route{Name: "pr-1.myapp", Upstream: "localhost:3002", Dir: "/tmp/myapp", Preview: "pr-1", IdleTimeout: time.Minute})
+	nextEvent(t, ch)
+
+	r.mu.Lock()
+	r.routes["stale"].LastHeartbeat = time.Now().Add(-time.Minute)
+	r.routes["pr-1.myapp"].Registered = time.Now().Add(-2 * time.Minute)
+	r.mu.Unlock()
+	r.Sweep()
+
+	got := map[string]RouteEvent{}
+	for range 2 {
+		ev := nextEvent(t, ch)
+		got[ev.Route] = ev
+	}
+	if got["stale"].Type != EventExpired {
+		t.Errorf("stale event = %+v, want %s", got["stale"], EventExpired)
+	}
+	if got["pr-1.myapp"].Type != EventIdleExpired || got["pr-1.myapp"].Preview != "pr-1" {
+		t.Errorf("preview event = %+v, want %s", got["pr-1.myapp"], EventIdleExpired)
+	}
+
+	select {
+	case ev := <-ch:
+		t.Errorf("unexpected event %+v", ev)
+	default:
+	}
+}
+
+func TestRouteRegistry_SlowSubscriberDoesNotBlock(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	ch := r.Subscribe()
+	defer r.Unsubscribe(ch)
+
+	for i := range eventBuffer + 10 {
+		r.Register(fmt.Sprintf("app%d", i), "localhost:3000", "/tmp")
+	}
+	if len(ch) != eventBuffer {
+		t.Errorf("buffered %d events, want %d", len(ch), eventBuffer)
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		ctype  string
+	}{
+		{name: "sse", path: "/v1/events", ctype: "text/event-stream"},
+		{name: "ndjson query", path: "/v1/events?format=ndjson", ctype: "application/x-ndjson"},
+		{name: "ndjson accept", path: "/events", accept: "application/x-ndjson", ctype: "application/x-ndjson"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRouteRegistry(30 * time.Second)
+			srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+			ts := httptest.NewServer(srv.server.Handler)
+			defer ts.Close()
+
+			req, _ := http.NewRequest("GET", ts.URL+tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if ct := resp.Header.Get("Content-Type"); ct != tt.ctype {
+				t.Fatalf("Content-Type = %q, want %q", ct, tt.ctype)
+			}
+
+			registry.Register("myapp", "localhost:3000", "/tmp/myapp")
+
+			sc := bufio.NewScanner(resp.Body)
+			var data string
+			for sc.Scan() {
+				line := sc.Text()
+				if tt.ctype == "application/x-ndjson" {
+					data = line
+					break
+				}
+				if line == "event: added" {
+					continue
+				}
+				if d, ok := strings.CutPrefix(line, "data: "); ok {
+					data = d
+					break
+				}
+				t.Fatalf("unexpected SSE line %q", line)
+			}
+			var ev RouteEvent
+			if err := json.Unmarshal([]byte(data), &ev); err != nil {
+				t.Fatalf("decoding %q: %v", data, err)
+			}
+			if ev.Type != EventAdded || ev.Route != "myapp" {
+				t.Errorf("event = %+v", ev)
+			}
+		})
+	}
+}
+
+func TestHandleEvents_EndsOnStop(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+	ts := httptest.NewServer(srv.server.Handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/v1/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	srv.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		bufio.NewReader(resp.Body).ReadString('\x00')
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("event stream still open after Stop")
+	}
+}
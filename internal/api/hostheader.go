@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Host header modes decide the Host header a route's upstream receives.
+const (
+	// HostHeaderPreserve sends the client's Host (myapp.test), the default.
+	HostHeaderPreserve = "preserve"
+	// HostHeaderUpstream sends the upstream's own address (localhost:9000),
+	// for virtual-hosted dev servers and S3 emulators that sign requests
+	// with it.
+	HostHeaderUpstream = "upstream"
+	// hostHeaderCustom prefixes a mode that sends a fixed value, as in
+	// "custom:api.local".
+	hostHeaderCustom = "custom:"
+)
+
+// ValidateHostHeader checks mode is empty, preserve, upstream, or
+// custom:<host>.
+func ValidateHostHeader(mode string) error {
+	switch mode {
+	case "", HostHeaderPreserve, HostHeaderUpstream:
+		return nil
+	}
+	if host, ok := strings.CutPrefix(mode, hostHeaderCustom); ok {
+		if !ValidHost(host) {
+			return fmt.Errorf("invalid hostHeader %q: custom value must be a host name", mode)
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid hostHeader %q: must be %s, %s, or %s<host>", mode, HostHeaderPreserve, HostHeaderUpstream, hostHeaderCustom)
+}
+
+// UpstreamHost returns the Host header to send to upstream for mode, or
+// "" to keep the client's.
+func UpstreamHost(mode, upstream string) string {
+	if mode == HostHeaderUpstream {
+		return upstream
+	}
+	if host, ok := strings.CutPrefix(mode, hostHeaderCustom); ok {
+		return host
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package api
+
+import "testing"
+
+func TestValidateHostHeader(t *testing.T) {
+	for _, mode := range []string{"", "preserve", "upstream", "custom:api.local", "custom:localhost:9000"} {
+		if err := ValidateHostHeader(mode); err != nil {
+			t.Errorf("ValidateHostHeader(%q) = %v", mode, err)
+		}
+	}
+	for _, mode := range []string{"client", "custom:", "custom:bad host", "custom:a\r\nX: y"} {
+		if err := ValidateHostHeader(mode); err == nil {
+			t.Errorf("ValidateHostHeader(%q) should fail", mode)
+		}
+	}
+}
+
+func TestUpstreamHost(t *testing.T) {
+	tests := []struct {
+		mode, want string
+	}{
+		{"", ""},
+		{HostHeaderPreserve, ""},
+		{HostHeaderUpstream, "localhost:9000"},
+		{"custom:api.local", "api.local"},
+	}
+	for _, tt := range tests {
+		if got := UpstreamHost(tt.mode, "localhost:9000"); got != tt.want {
+			t.Errorf("UpstreamHost(%q) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HSTS modes control what happens to Strict-Transport-Security headers
+// sent by upstreams. An empty mode on a route defers to the daemon's
+// "hsts" setting, which itself defaults to HSTSKeep.
+const (
+	// HSTSKeep passes the header through unchanged.
+	HSTSKeep = "keep"
+	// HSTSStrip removes the header, so browsers never pin the name.
+	HSTSStrip = "strip"
+	// HSTSRewrite drops the includeSubDomains and preload directives, so
+	// one app can't pin its sibling routes (api.shop.test under shop.test)
+	// or ask to be preloaded for a name that only exists locally.
+	HSTSRewrite = "rewrite"
+)
+
+// HSTSHeader is the response header HSTS modes act on.
+const HSTSHeader = "Strict-Transport-Security"
+
+// ValidateHSTS checks mode is empty or one of the HSTS modes.
+func ValidateHSTS(mode string) error {
+	switch mode {
+	case "", HSTSKeep, HSTSStrip, HSTSRewrite:
+		return nil
+	}
+	return fmt.Errorf("invalid hsts mode %q: must be %s, %s, or %s", mode, HSTSKeep, HSTSStrip, HSTSRewrite)
+}
+
+// ApplyHSTS rewrites the Strict-Transport-Security header in h for mode.
+func ApplyHSTS(h http.Header, mode string) {
+	switch mode {
+	case HSTSStrip:
+		h.Del(HSTSHeader)
+	case HSTSRewrite:
+		value := h.Get(HSTSHeader)
+		if value == "" {
+			return
+		}
+		var kept []string
+		for _, directive := range strings.Split(value, ";") {
+			directive = strings.TrimSpace(directive)
+			name, _, _ := strings.Cut(directive, "=")
+			if directive == "" || strings.EqualFold(name, "includeSubDomains") || strings.EqualFold(name, "preload") {
+				continue
+			}
+			kept = append(kept, directive)
+		}
+		if len(kept) == 0 {
+			h.Del(HSTSHeader)
+			return
+		}
+		h.Set(HSTSHeader, strings.Join(kept, "; "))
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestApplyHSTS(t *testing.T) {
+	tests := []struct {
+		mode, in, want string
+	}{
+		{HSTSKeep, "max-age=31536000; includeSubDomains; preload", "max-age=31536000; includeSubDomains; preload"},
+		{"", "max-age=31536000; includeSubDomains", "max-age=31536000; includeSubDomains"},
+		{HSTSStrip, "max-age=31536000", ""},
+		{HSTSRewrite, "max-age=31536000; includeSubDomains; preload", "max-age=31536000"},
+		{HSTSRewrite, "max-age=600;INCLUDESUBDOMAINS", "max-age=600"},
+		{HSTSRewrite, "includeSubDomains", ""},
+		{HSTSRewrite, "", ""},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.in != "" {
+			h.Set(HSTSHeader, tt.in)
+		}
+		ApplyHSTS(h, tt.mode)
+		if got := h.Get(HSTSHeader); got != tt.want {
+			t.Errorf("ApplyHSTS(%q, %q) = %q, want %q", tt.in, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestValidateHSTS(t *testing.T) {
+	for _, mode := range []string{"", HSTSKeep, HSTSStrip, HSTSRewrite} {
+		if err := ValidateHSTS(mode); err != nil {
+			t.Errorf("ValidateHSTS(%q) = %v", mode, err)
+		}
+	}
+	if err := ValidateHSTS("off"); err == nil {
+		t.Error("ValidateHSTS(off) should fail")
+	}
+}
@@ -0,0 +1,145 @@
+// internal/api/keepalive.go
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// maxKeepAliveLine bounds one line of a POST /heartbeat stream: maxRoutes
+// names and tokens fit comfortably.
+const maxKeepAliveLine = 64 * 1024
+
+// KeepAliveRoute is a route held by a keep-alive connection, with the
+// token it was registered with.
+type KeepAliveRoute struct {
+	Name  string `json:"name"`
+	Token string `json:"token,omitempty"`
+}
+
+// KeepAliveRequest is one line a client sends on POST /heartbeat: every
+// route it currently holds. Each line counts as a heartbeat for them.
+type KeepAliveRequest struct {
+	Routes []KeepAliveRoute `json:"routes"`
+}
+
+// KeepAliveStatus is the daemon's answer to each KeepAliveRequest, naming
+// the routes that were not refreshed.
+type KeepAliveStatus struct {
+	// Missing routes are not registered, e.g. after a daemon restart.
+	Missing []string `json:"missing,omitempty"`
+	// Expired routes were removed for inactivity and should not be
+	// registered again.
+	Expired []string `json:"expired,omitempty"`
+	// Denied routes are owned by another client or user.
+	Denied []string `json:"denied,omitempty"`
+}
+
+// KeepAlive heartbeats routes on behalf of peer and returns the ones that
+// were refreshed along with the status of the others.
+func (r *RouteRegistry) KeepAlive(routes []KeepAliveRoute, peer *PeerCred) ([]KeepAliveRoute, KeepAliveStatus) {
+	var held []KeepAliveRoute
+	var status KeepAliveStatus
+	for _, kr := range routes {
+		err := r.heartbeatAs(kr.Name, &Caller{Token: kr.Token, Peer: peer})
+		switch {
+		case err == nil:
+			held = append(held, kr)
+		case errors.Is(err, ErrIdleExpired):
+			status.Expired = append(status.Expired, kr.Name)
+		case errors.Is(err, ErrNotOwner), errors.Is(err, ErrOtherUser), errors.Is(err, ErrPermanent):
+			status.Denied = append(status.Denied, kr.Name)
+		default:
+			status.Missing = append(status.Missing, kr.Name)
+		}
+	}
+	return held, status
+}
+
+// Release removes routes held by a keep-alive connection that closed,
+// skipping any since re-registered by another client. It returns how many
+// were removed.
+func (r *RouteRegistry) Release(routes []KeepAliveRoute, peer *PeerCred) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, kr := range routes {
+		route, ok := r.routes[kr.Name]
+		if !ok || route.allows(Caller{Token: kr.Token, Peer: peer}) != nil {
+			continue
+		}
+		delete(r.routes, kr.Name)
+		n++
+		r.debug("route expired: keep-alive connection closed", "route", kr.Name)
+		r.publish(EventExpired, route)
+	}
+	return n
+}
+
+// handleKeepAlive holds routes alive for as long as the client keeps the
+// request open. The client streams KeepAliveRequest lines and gets one
+// KeepAliveStatus line back for each. When the connection drops, e.g.
+// because `up` was killed, the routes it held are removed at once instead
+// of waiting out the heartbeat timeout.
+func (s *Server) handleKeepAlive(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	if err := rc.EnableFullDuplex(); err != nil {
+		jsonError(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	peer := peerCredOf(r)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	lines := make(chan KeepAliveRequest)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(r.Body)
+		sc.Buffer(make([]byte, 4096), maxKeepAliveLine)
+		for sc.Scan() {
+			var req KeepAliveRequest
+			if err := json.Unmarshal(sc.Bytes(), &req); err != nil || len(req.Routes) > maxRoutes {
+				return
+			}
+			select {
+			case lines <- req:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var held []KeepAliveRoute
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-s.shutdown:
+			// The registry goes away with the daemon; nothing to release.
+			return
+		case req, ok := <-lines:
+			if !ok {
+				s.registry.Release(held, peer)
+				return
+			}
+			var status KeepAliveStatus
+			held, status = s.registry.KeepAlive(req.Routes, peer)
+			if err := enc.Encode(status); err != nil {
+				s.registry.Release(held, peer)
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				s.registry.Release(held, peer)
+				return
+			}
+		}
+	}
+}
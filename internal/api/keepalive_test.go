@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHandleKeepAlive(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+	ts := httptest.NewServer(srv.server.Handler)
+	defer ts.Close()
+
+	for _, r := range []Route{
+		{Name: "web", Upstream: "localhost:3000", Dir: "/src/shop", Token: "web-token"},
+		{Name: "api", Upstream: "localhost:4000", Dir: "/src/shop", Token: "api-token"},
+		{Name: "other", Upstream: "localhost:5000", Dir: "/src/other", Token: "other-token"},
+	} {
+		if err := registry.RegisterRoute(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "POST /v1/heartbeat HTTP/1.1\r\nHost: paw\r\nTransfer-Encoding: chunked\r\n\r\n")
+	send := func(req KeepAliveRequest) {
+		data, _ := json.Marshal(req)
+		data = append(data, '\n')
+		fmt.Fprintf(conn, "%x\r\n%s\r\n", len(data), data)
+	}
+
+	send(KeepAliveRequest{Routes: []KeepAliveRoute{
+		{Name: "web", Token: "web-token"},
+		{Name: "api", Token: "api-token"},
+		{Name: "other", Token: "wrong"},
+		{Name: "ghost"},
+	}})
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	dec := json.NewDecoder(resp.Body)
+	var status KeepAliveStatus
+	if err := dec.Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(status.Missing, []string{"ghost"}) || !slices.Equal(status.Denied, []string{"other"}) || len(status.Expired) != 0 {
+		t.Errorf("status = %+v", status)
+	}
+
+	// The client stops holding api, e.g. because it deregistered it.
+	send(KeepAliveRequest{Routes: []KeepAliveRoute{{Name: "web", Token: "web-token"}}})
+	if err := dec.Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dropping the connection, as when the client is killed, removes the
+	// routes it held at once.
+	conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := registry.Lookup("web"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("route held by a closed connection was not removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, name := range []string{"api", "other"} {
+		if _, ok := registry.Lookup(name); !ok {
+			t.Errorf("route %s not held by the connection was removed", name)
+		}
+	}
+}
+
+func TestRelease_SkipsReRegisteredRoutes(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	registry.RegisterRoute(Route{Name: "web", Upstream: "localhost:3000", Dir: "/src/shop", Token: "new-token"})
+
+	if n := registry.Release([]KeepAliveRoute{{Name: "web", Token: "old-token"}}, nil); n != 0 {
+		t.Errorf("Release removed %d routes, want 0", n)
+	}
+	if _, ok := registry.Lookup("web"); !ok {
+		t.Error("route re-registered by another client was removed")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !linux
+
+package api
+
+// processAlive is unsupported on this platform, where routes get no owner
+// anyway; every process counts as alive.
+func processAlive(pid int) bool {
+	return true
+}
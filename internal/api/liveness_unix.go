@@ -0,0 +1,15 @@
+//go:build darwin || linux
+
+package api
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// processAlive reports whether pid is a running process. A process owned
+// by another user still counts: signal 0 fails with EPERM, not ESRCH.
+func processAlive(pid int) bool {
+	return !errors.Is(unix.Kill(pid, 0), unix.ESRCH)
+}
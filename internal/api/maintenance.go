@@ -0,0 +1,95 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/schedule"
+)
+
+const (
+	minMaintenanceDuration = time.Minute
+	maxMaintenanceDuration = 7 * 24 * time.Hour
+)
+
+// MaintenanceConfig takes a route down for maintenance on a schedule:
+// during each window, requests get a 503 maintenance page instead of
+// reaching the upstream, and the route resumes by itself afterwards.
+type MaintenanceConfig struct {
+	// Schedule is a cron expression for when windows start, in the
+	// daemon's local time, e.g. "0 12 * * mon-fri" for noon on weekdays.
+	Schedule string `json:"schedule"`
+	// Duration is how long each window lasts, as a Go duration like "30m".
+	Duration string `json:"duration"`
+}
+
+// ValidateMaintenance checks a maintenance schedule.
+func ValidateMaintenance(c *MaintenanceConfig) error {
+	if c == nil {
+		return nil
+	}
+	_, _, err := c.Window()
+	return err
+}
+
+// Window parses the schedule windows start on and how long they last.
+func (c *MaintenanceConfig) Window() (*schedule.Cron, time.Duration, error) {
+	cron, err := schedule.ParseCron(c.Schedule)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid maintenance schedule: %w", err)
+	}
+	d, err := time.ParseDuration(c.Duration)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid maintenance duration: %w", err)
+	}
+	if d < minMaintenanceDuration || d > maxMaintenanceDuration {
+		return nil, 0, fmt.Errorf("maintenance duration must be between %s and %s", minMaintenanceDuration, maxMaintenanceDuration)
+	}
+	if cron.Next(time.Now()).IsZero() {
+		return nil, 0, fmt.Errorf("maintenance schedule %q never starts a window", c.Schedule)
+	}
+	return cron, d, nil
+}
+
+// ActiveUntil returns the end of the window now falls in, or the zero
+// time outside windows.
+func (c *MaintenanceConfig) ActiveUntil(now time.Time) time.Time {
+	cron, d, err := c.Window()
+	if err != nil {
+		return time.Time{}
+	}
+	// The first start after now-d is the window now would be in.
+	if start := cron.Next(now.Add(-d)); !start.IsZero() && !start.After(now) {
+		return start.Add(d)
+	}
+	return time.Time{}
+}
+
+// InMaintenance reports whether the route is in a maintenance window at
+// now.
+func (route *Route) InMaintenance(now time.Time) bool {
+	return now.Before(route.MaintenanceUntil)
+}
+
+// SetMaintenance starts a maintenance window on a route that lasts until
+// until, or ends one when until is zero.
+func (r *RouteRegistry) SetMaintenance(name string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route, ok := r.routes[name]
+	if !ok {
+		return fmt.Errorf("route %q not found", name)
+	}
+	if route.MaintenanceUntil.Equal(until) {
+		return nil
+	}
+	route.MaintenanceUntil = until
+	if until.IsZero() {
+		r.debug("route maintenance ended", "route", name)
+	} else {
+		r.debug("route maintenance started", "route", name, "until", until)
+	}
+	r.publish(EventUpdated, route)
+	return nil
+}
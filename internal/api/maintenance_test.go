@@ -0,0 +1,83 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateMaintenance(t *testing.T) {
+	valid := []*MaintenanceConfig{
+		nil,
+		{Schedule: "0 12 * * mon-fri", Duration: "30m"},
+		{Schedule: "@daily", Duration: "1m"},
+	}
+	for _, c := range valid {
+		if err := ValidateMaintenance(c); err != nil {
+			t.Errorf("ValidateMaintenance(%+v) = %v, want nil", c, err)
+		}
+	}
+	invalid := []*MaintenanceConfig{
+		{},
+		{Schedule: "0 12 * * *"},
+		{Schedule: "noon", Duration: "1h"},
+		{Schedule: "0 12 * * *", Duration: "30s"},
+		{Schedule: "0 12 * * *", Duration: "30d"},
+		{Schedule: "0 12 * * *", Duration: "200h"},
+		{Schedule: "0 0 30 2 *", Duration: "1h"},
+	}
+	for _, c := range invalid {
+		if err := ValidateMaintenance(c); err == nil {
+			t.Errorf("ValidateMaintenance(%+v) = nil, want error", c)
+		}
+	}
+}
+
+func TestMaintenanceConfig_ActiveUntil(t *testing.T) {
+	c := &MaintenanceConfig{Schedule: "0 12 * * *", Duration: "30m"}
+	day := func(hour, min int) time.Time { return time.Date(2026, 3, 11, hour, min, 0, 0, time.Local) }
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{day(11, 59), time.Time{}},
+		{day(12, 0), day(12, 30)},
+		{day(12, 29), day(12, 30)},
+		{day(12, 30), time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := c.ActiveUntil(tt.now); !got.Equal(tt.want) {
+			t.Errorf("ActiveUntil(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestSetMaintenance(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	if err := registry.RegisterRoute(Route{Name: "shop", Upstream: "localhost:3000", Dir: "/tmp/shop"}); err != nil {
+		t.Fatal(err)
+	}
+	events := registry.Subscribe()
+	defer registry.Unsubscribe(events)
+
+	until := time.Now().Add(time.Hour)
+	if err := registry.SetMaintenance("shop", until); err != nil {
+		t.Fatal(err)
+	}
+	route, _ := registry.Lookup("shop")
+	if !route.InMaintenance(time.Now()) || route.InMaintenance(until) {
+		t.Errorf("MaintenanceUntil = %v, want in maintenance until %v", route.MaintenanceUntil, until)
+	}
+	if ev := <-events; ev.Type != EventUpdated || ev.Route != "shop" {
+		t.Errorf("event = %+v, want updated shop", ev)
+	}
+
+	if err := registry.SetMaintenance("shop", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if route, _ := registry.Lookup("shop"); route.InMaintenance(time.Now()) {
+		t.Error("route still in maintenance after it ended")
+	}
+	if err := registry.SetMaintenance("missing", until); err == nil {
+		t.Error("SetMaintenance on a missing route succeeded")
+	}
+}
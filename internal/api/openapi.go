@@ -0,0 +1,23 @@
+package api
+
+import (
+	_ "embed"
+	"log"
+	"net/http"
+)
+
+// APIPrefix is the path prefix of the current control API version.
+const APIPrefix = "/v1"
+
+// openAPISpec documents the control API. Keep it in step with the routes
+// registered in NewServer.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(openAPISpec); err != nil {
+		log.Printf("api: failed to write openapi spec: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenAPISpecServed(t *testing.T) {
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), NewRouteRegistry(30*time.Second))
+
+	w := httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") || spec.Info.Version != strings.TrimPrefix(APIPrefix, "/v") {
+		t.Errorf("unexpected spec header: %+v", spec)
+	}
+}
+
+// TestOpenAPISpecMatchesRoutes checks every documented operation is served
+// under /v1 and at its legacy unversioned path.
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), NewRouteRegistry(30*time.Second))
+	mux := srv.server.Handler.(*http.ServeMux)
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("parsing spec: %v", err)
+	}
+	if len(spec.Paths) == 0 {
+		t.Fatal("spec has no paths")
+	}
+
+	for path, ops := range spec.Paths {
+		concrete := strings.ReplaceAll(path, "{name}", "myapp")
+		for method := range ops {
+			method = strings.ToUpper(method)
+			prefixes := []string{APIPrefix, ""}
+			if path == "/openapi.json" {
+				prefixes = []string{APIPrefix}
+			}
+			for _, prefix := range prefixes {
+				req := httptest.NewRequest(method, prefix+concrete, nil)
+				if _, pattern := mux.Handler(req); pattern == "" {
+					t.Errorf("%s %s%s documented but not served", method, prefix, path)
+				}
+			}
+		}
+	}
+}
+
+func TestVersionedAndLegacyPaths(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+	h := srv.server.Handler
+
+	body := `{"name":"myapp","upstream":"localhost:3000","dir":"/path/to/project"}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/v1/routes", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /v1/routes: expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	var reg RegisterResponse
+	json.NewDecoder(w.Body).Decode(&reg)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/routes", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"name":"myapp"`) {
+		t.Errorf("GET /routes: got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("DELETE", "/v1/routes/myapp", nil)
+	req.Header.Set(RouteTokenHeader, reg.Token)
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("DELETE /v1/routes/myapp: expected 200, got %d", w.Code)
+	}
+}
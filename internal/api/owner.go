@@ -0,0 +1,67 @@
+// internal/api/owner.go
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+)
+
+// RouteTokenHeader carries the route token returned at registration on
+// requests that change or remove the route.
+const RouteTokenHeader = "X-Paw-Route-Token"
+
+// ErrNotOwner is returned when a request to change or remove a route does
+// not present the token it was registered with.
+var ErrNotOwner = errors.New("route is owned by another client (missing or wrong route token)")
+
+// ErrOtherUser is returned when a local user tries to change or remove a
+// route registered by a different user.
+var ErrOtherUser = errors.New("route is owned by another user")
+
+// ErrPermanent is returned when a client tries to change or remove a
+// route defined in the daemon's config file.
+var ErrPermanent = errors.New("route is defined in the daemon config file")
+
+// Caller identifies who is asking to change or remove a route.
+type Caller struct {
+	// Token is the route token the caller presented.
+	Token string
+	// Peer is the caller's process, for requests over the unix socket.
+	Peer *PeerCred
+}
+
+// newRouteToken returns a random route token.
+func newRouteToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// owns reports whether token may change the route. Routes registered
+// without a token, from inside the daemon, have no owner.
+func (route *Route) owns(token string) bool {
+	if route.Token == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(route.Token), []byte(token)) == 1
+}
+
+// allows returns nil if caller may change the route: it must present the
+// route's token and, when both sides are known, run as the same user that
+// registered it. Routes from the config file can't be changed by anyone.
+func (route *Route) allows(caller Caller) error {
+	if route.Permanent {
+		return ErrPermanent
+	}
+	if route.Owner != nil && caller.Peer != nil && caller.Peer.UID != route.Owner.UID {
+		return ErrOtherUser
+	}
+	if !route.owns(caller.Token) {
+		return ErrNotOwner
+	}
+	return nil
+}
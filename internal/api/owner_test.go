@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteRegistry_OwnedOperations(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	r.RegisterRoute(Route{Name: "myapp", Upstream: "localhost:3000", Dir: "/tmp/myapp", Token: "secret"})
+
+	if err := r.HeartbeatOwned("myapp", Caller{Token: "wrong"}); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("HeartbeatOwned(wrong) = %v, want ErrNotOwner", err)
+	}
+	if err := r.HeartbeatOwned("myapp", Caller{Token: "secret"}); err != nil {
+		t.Errorf("HeartbeatOwned(secret) = %v", err)
+	}
+	if err := r.SetAuthOwned("myapp", Caller{}, &RouteAuth{Token: "t"}); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("SetAuthOwned(empty) = %v, want ErrNotOwner", err)
+	}
+	if _, err := r.DeregisterOwned("myapp", Caller{Token: "wrong"}); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("DeregisterOwned(wrong) = %v, want ErrNotOwner", err)
+	}
+	if _, ok := r.Lookup("myapp"); !ok {
+		t.Fatal("route removed without its token")
+	}
+
+	// The daemon's own callers don't need the token.
+	if err := r.Heartbeat("myapp"); err != nil {
+		t.Errorf("Heartbeat() = %v", err)
+	}
+	if removed, err := r.DeregisterOwned("myapp", Caller{Token: "secret"}); !removed || err != nil {
+		t.Errorf("DeregisterOwned(secret) = %v, %v", removed, err)
+	}
+
+	// Routes registered without a token have no owner.
+	r.Register("open", "localhost:3001", "/tmp/open")
+	if removed, err := r.DeregisterOwned("open", Caller{Token: ""}); !removed || err != nil {
+		t.Errorf("DeregisterOwned(unowned) = %v, %v", removed, err)
+	}
+}
+
+func TestRouteRegistry_OtherUserRejected(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	owner := &PeerCred{UID: 501, PID: 100}
+	r.RegisterRoute(Route{Name: "myapp", Upstream: "localhost:3000", Dir: "/tmp/myapp", Token: "secret", Owner: owner})
+
+	// The token alone isn't enough for another local user.
+	other := Caller{Token: "secret", Peer: &PeerCred{UID: 502, PID: 200}}
+	if err := r.HeartbeatOwned("myapp", other); !errors.Is(err, ErrOtherUser) {
+		t.Errorf("HeartbeatOwned(other user) = %v, want ErrOtherUser", err)
+	}
+	if _, err := r.UpsertRoute(Route{Name: "myapp", Upstream: "localhost:4000", Dir: "/tmp/myapp"}, other); !errors.Is(err, ErrOtherUser) {
+		t.Errorf("UpsertRoute(other user) = %v, want ErrOtherUser", err)
+	}
+	if _, err := r.DeregisterOwned("myapp", other); !errors.Is(err, ErrOtherUser) {
+		t.Errorf("DeregisterOwned(other user) = %v, want ErrOtherUser", err)
+	}
+
+	// Same user from a new process, e.g. up run again after a crash.
+	again := Caller{Token: "secret", Peer: &PeerCred{UID: 501, PID: 300}}
+	if _, err := r.UpsertRoute(Route{Name: "myapp", Upstream: "localhost:4000", Dir: "/tmp/myapp", Owner: again.Peer}, again); err != nil {
+		t.Fatalf("UpsertRoute(same user) = %v", err)
+	}
+	if route, _ := r.Lookup("myapp"); route.Owner == nil || route.Owner.PID != 300 {
+		t.Errorf("owner after update = %+v, want pid 300", route.Owner)
+	}
+
+	// Callers over TCP have no peer credentials; the token decides.
+	if err := r.HeartbeatOwned("myapp", Caller{Token: "secret"}); err != nil {
+		t.Errorf("HeartbeatOwned(no peer) = %v", err)
+	}
+}
+
+func TestSweep_RemovesRoutesOfExitedProcess(t *testing.T) {
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("can't run a short-lived process: %v", err)
+	}
+	exited := &PeerCred{PID: cmd.Process.Pid}
+	alive := &PeerCred{PID: os.Getpid()}
+
+	r := NewRouteRegistry(30 * time.Second)
+	r.RegisterRoute(Route{Name: "orphan", Upstream: "localhost:3000", Dir: "/tmp/a", Owner: exited, BindProcess: true})
+	r.RegisterRoute(Route{Name: "unbound", Upstream: "localhost:3001", Dir: "/tmp/b", Owner: exited})
+	r.RegisterRoute(Route{Name: "running", Upstream: "localhost:3002", Dir: "/tmp/c", Owner: alive, BindProcess: true})
+
+	if got := r.Sweep(); got != (SweepResult{Orphaned: 1}) {
+		t.Errorf("Sweep() = %+v, want one orphaned route", got)
+	}
+	if _, ok := r.Lookup("orphan"); ok {
+		t.Error("route bound to an exited process was kept")
+	}
+	for _, name := range []string{"unbound", "running"} {
+		if _, ok := r.Lookup(name); !ok {
+			t.Errorf("%s was removed", name)
+		}
+	}
+}
+
+func TestRouteTokenRequiredOverAPI(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	h := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry).server.Handler
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set(RouteTokenHeader, token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("POST", "/v1/routes", "", `{"name":"myapp","upstream":"localhost:3000","dir":"/path/to/project"}`)
+	var reg RegisterResponse
+	if err := json.NewDecoder(w.Body).Decode(&reg); err != nil || len(reg.Token) != 64 {
+		t.Fatalf("POST /v1/routes: expected a token, got %d %q", w.Code, reg.Token)
+	}
+
+	for _, tt := range []struct{ method, path, body string }{
+		{"POST", "/v1/routes/myapp/heartbeat", ""},
+		{"PUT", "/v1/routes/myapp/auth", `{"token":"abc"}`},
+		{"DELETE", "/v1/routes/myapp", ""},
+	} {
+		if w := do(tt.method, tt.path, "", tt.body); w.Code != http.StatusForbidden {
+			t.Errorf("%s %s without token: expected 403, got %d", tt.method, tt.path, w.Code)
+		}
+		if w := do(tt.method, tt.path, "wrong", tt.body); w.Code != http.StatusForbidden {
+			t.Errorf("%s %s with wrong token: expected 403, got %d", tt.method, tt.path, w.Code)
+		}
+	}
+
+	if w := do("GET", "/v1/routes", "", ""); strings.Contains(w.Body.String(), reg.Token) {
+		t.Error("GET /v1/routes leaked the route token")
+	}
+
+	for _, tt := range []struct{ method, path, body string }{
+		{"POST", "/v1/routes/myapp/heartbeat", ""},
+		{"PUT", "/v1/routes/myapp/auth", `{"token":"abc"}`},
+		{"DELETE", "/v1/routes/myapp", ""},
+	} {
+		if w := do(tt.method, tt.path, reg.Token, tt.body); w.Code != http.StatusOK {
+			t.Errorf("%s %s with token: expected 200, got %d %s", tt.method, tt.path, w.Code, w.Body.String())
+		}
+	}
+}
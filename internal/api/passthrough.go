@@ -0,0 +1,56 @@
+package api
+
+import "fmt"
+
+// validatePassthrough rejects HTTP options on a passthrough route. The
+// daemon never decrypts its traffic, so it can't see requests to apply
+// them to.
+func validatePassthrough(req RegisterRequest) error {
+	if !req.Passthrough {
+		return nil
+	}
+	if opt := httpOption(req); opt != "" {
+		return fmt.Errorf("passthrough routes can't use %s", opt)
+	}
+	return nil
+}
+
+// httpOption returns the name of the first option in req that only
+// applies to proxied HTTP requests, or "" if none is set.
+func httpOption(req RegisterRequest) string {
+	switch {
+	case req.Auth != nil:
+		return "auth"
+	case len(req.Headers) > 0:
+		return "headers"
+	case req.CORS != nil:
+		return "cors"
+	case req.HSTS != "":
+		return "hsts"
+	case req.HostHeader != "":
+		return "hostHeader"
+	case req.PlainHTTP:
+		return "plainHttp"
+	case req.TrustForwarded:
+		return "trustForwarded"
+	case req.Pool != nil:
+		return "pool"
+	case req.Mirror != "":
+		return "mirror"
+	case req.Rewrite != nil:
+		return "rewrite"
+	case req.Cookies != nil:
+		return "cookies"
+	case req.Balance != nil:
+		return "balance"
+	case req.Alerts != nil:
+		return "alerts"
+	case req.SPA != nil:
+		return "spa"
+	case req.Canonical != nil:
+		return "canonical"
+	case req.Maintenance != nil:
+		return "maintenance"
+	}
+	return ""
+}
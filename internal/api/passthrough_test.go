@@ -0,0 +1,30 @@
+package api
+
+import "testing"
+
+func TestValidatePassthrough(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     RegisterRequest
+		wantErr bool
+	}{
+		{"not passthrough", RegisterRequest{Headers: map[string]string{"X-Env": "dev"}}, false},
+		{"passthrough", RegisterRequest{Passthrough: true}, false},
+		{"with proxy protocol", RegisterRequest{Passthrough: true, ProxyProtocol: true}, false},
+		{"with auth", RegisterRequest{Passthrough: true, Auth: &RouteAuth{Token: "t"}}, true},
+		{"with headers", RegisterRequest{Passthrough: true, Headers: map[string]string{"X-Env": "dev"}}, true},
+		{"with hsts", RegisterRequest{Passthrough: true, HSTS: HSTSStrip}, true},
+		{"with plain http", RegisterRequest{Passthrough: true, PlainHTTP: true}, true},
+		{"with mirror", RegisterRequest{Passthrough: true, Mirror: "localhost:3001"}, true},
+		{"with hostHeader", RegisterRequest{Passthrough: true, HostHeader: "upstream"}, true},
+		{"with cookies", RegisterRequest{Passthrough: true, Cookies: &CookieConfig{Secure: true}}, true},
+		{"with rewrite", RegisterRequest{Passthrough: true, Rewrite: &RewriteConfig{StripPrefix: "/api"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePassthrough(tt.req); (err != nil) != tt.wantErr {
+				t.Errorf("validatePassthrough() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// internal/api/peercred.go
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// PeerCred identifies the local process on the other end of a unix socket
+// connection.
+type PeerCred struct {
+	UID int `json:"uid"`
+	PID int `json:"pid"`
+	// Process is the command name of PID, if it could be read.
+	Process string `json:"process,omitempty"`
+}
+
+type peerCredKey struct{}
+
+// withPeerCred is the unix socket server's ConnContext. It records the
+// connecting process so handlers can read it with peerCredOf. Connections
+// whose credentials can't be read carry none.
+func withPeerCred(ctx context.Context, c net.Conn) context.Context {
+	uc, ok := c.(*net.UnixConn)
+	if !ok {
+		return ctx
+	}
+	cred, err := readPeerCred(uc)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, peerCredKey{}, cred)
+}
+
+// peerCredOf returns the credentials of the process that sent r, or nil
+// for requests that didn't come over the unix socket.
+func peerCredOf(r *http.Request) *PeerCred {
+	cred, _ := r.Context().Value(peerCredKey{}).(*PeerCred)
+	return cred
+}
+
+// callerOf returns the Caller for a request to change or remove a route.
+func callerOf(r *http.Request) Caller {
+	return Caller{Token: r.Header.Get(RouteTokenHeader), Peer: peerCredOf(r)}
+}
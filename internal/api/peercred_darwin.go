@@ -0,0 +1,37 @@
+//go:build darwin
+
+package api
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// readPeerCred reads the peer's credentials with LOCAL_PEERCRED and
+// LOCAL_PEERPID.
+func readPeerCred(c *net.UnixConn) (*PeerCred, error) {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var xucred *unix.Xucred
+	var pid int
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		xucred, credErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+		if credErr == nil {
+			pid, credErr = unix.GetsockoptInt(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERPID)
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if credErr != nil {
+		return nil, credErr
+	}
+	cred := &PeerCred{UID: int(xucred.Uid), PID: pid}
+	if kp, err := unix.SysctlKinfoProc("kern.proc.pid", pid); err == nil {
+		cred.Process = unix.ByteSliceToString(kp.Proc.P_comm[:])
+	}
+	return cred, nil
+}
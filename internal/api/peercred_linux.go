@@ -0,0 +1,35 @@
+//go:build linux
+
+package api
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// readPeerCred reads the peer's credentials with SO_PEERCRED.
+func readPeerCred(c *net.UnixConn) (*PeerCred, error) {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var ucred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		ucred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return nil, err
+	}
+	if credErr != nil {
+		return nil, credErr
+	}
+	cred := &PeerCred{UID: int(ucred.Uid), PID: int(ucred.Pid)}
+	if comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", ucred.Pid)); err == nil {
+		cred.Process = strings.TrimSpace(string(comm))
+	}
+	return cred, nil
+}
@@ -0,0 +1,13 @@
+//go:build !darwin && !linux
+
+package api
+
+import (
+	"errors"
+	"net"
+)
+
+// readPeerCred is unsupported on this platform; routes get no owner.
+func readPeerCred(c *net.UnixConn) (*PeerCred, error) {
+	return nil, errors.New("peer credentials not supported on this platform")
+}
@@ -0,0 +1,52 @@
+//go:build darwin || linux
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAPIServer_RecordsRouteOwner(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	registry := NewRouteRegistry(30 * time.Second)
+	srv := NewServer(socketPath, registry)
+
+	go srv.Start()
+	defer srv.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+	body := []byte(`{"name":"myapp","upstream":"localhost:3000","dir":"/path/to/project","owner":{"uid":12345}}`)
+	resp, err := client.Post("http://unix/v1/routes", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /v1/routes failed: %v", err)
+	}
+	resp.Body.Close()
+
+	route, ok := registry.Lookup("myapp")
+	if !ok {
+		t.Fatal("route not registered")
+	}
+	if route.Owner == nil {
+		t.Fatal("route has no owner")
+	}
+	if route.Owner.UID != os.Getuid() || route.Owner.PID != os.Getpid() {
+		t.Errorf("owner = %+v, want uid %d pid %d", *route.Owner, os.Getuid(), os.Getpid())
+	}
+	if route.Owner.Process == "" {
+		t.Error("owner process name not recorded")
+	}
+}
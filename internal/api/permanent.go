@@ -0,0 +1,100 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// PermanentRoute validates a route defined in the daemon's config file
+// and builds it. Such routes live as long as the file names them, so the
+// options that expire a route don't apply, and the directory is optional
+// since they aren't started from a project.
+func PermanentRoute(req RegisterRequest) (Route, error) {
+	if err := validateRouteName(req.Name); err != nil {
+		return Route{}, err
+	}
+	if IsReservedName(req.Name) {
+		return Route{}, &ReservedError{Name: req.Name}
+	}
+	switch {
+	case req.HeartbeatTimeout != "":
+		return Route{}, fmt.Errorf("heartbeatTimeout doesn't apply to routes in the config file")
+	case req.IdleTimeout != "":
+		return Route{}, fmt.Errorf("idleTimeout doesn't apply to routes in the config file")
+	case req.Preview != "":
+		return Route{}, fmt.Errorf("preview doesn't apply to routes in the config file")
+	case req.BindProcess:
+		return Route{}, fmt.Errorf("bindProcess doesn't apply to routes in the config file")
+	}
+
+	dir := req.Dir
+	if dir == "" {
+		req.Dir = "/"
+	}
+	route, err := RouteFromRequest(req)
+	if err != nil {
+		return Route{}, err
+	}
+	route.Dir = dir
+	route.Permanent = true
+	if err := validateCanonicalName(route); err != nil {
+		return Route{}, err
+	}
+	return route, nil
+}
+
+// SetPermanent makes the routes defined in the daemon's config file match
+// routes: new ones are registered, changed ones replaced, and ones no
+// longer defined removed. A route registered through the API under the
+// same name is replaced, since the file is the user's explicit choice.
+// Unchanged routes are left alone, so reloading the file publishes no
+// events for them.
+func (r *RouteRegistry) SetPermanent(routes []Route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	want := make(map[string]Route, len(routes))
+	for _, route := range routes {
+		route.Permanent = true
+		route.Token = ""
+		route.Owner = nil
+		want[route.Name] = route
+	}
+
+	for name := range r.permanent {
+		if _, ok := want[name]; ok {
+			continue
+		}
+		delete(r.permanent, name)
+		if route, ok := r.routes[name]; ok && route.Permanent {
+			delete(r.routes, name)
+			r.debug("config route removed", "route", name)
+			r.publish(EventRemoved, route)
+		}
+	}
+
+	for name, route := range want {
+		existing, ok := r.routes[name]
+		if ok && existing.Permanent && reflect.DeepEqual(r.permanent[name], route) {
+			continue
+		}
+		r.permanent[name] = route
+		registered := time.Now()
+		if ok && existing.Permanent {
+			registered = existing.Registered
+		}
+		r.store(route, registered)
+		switch {
+		case !ok:
+			r.debug("config route registered", "route", name, "upstream", route.Upstream)
+			r.publish(EventAdded, r.routes[name])
+		case !existing.Permanent:
+			r.debug("config route replaced a registered route", "route", name, "upstream", route.Upstream, "dir", existing.Dir)
+			r.publish(EventUpdated, r.routes[name])
+		default:
+			r.debug("config route updated", "route", name, "upstream", route.Upstream)
+			r.publish(EventUpdated, r.routes[name])
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRouteRegistry_SetPermanent(t *testing.T) {
+	r := NewRouteRegistry(time.Millisecond)
+	r.RegisterRoute(Route{Name: "pgadmin", Upstream: "localhost:9999", Dir: "/tmp/pg", Token: "tok"})
+
+	ch := r.Subscribe()
+	defer r.Unsubscribe(ch)
+
+	r.SetPermanent([]Route{
+		{Name: "pgadmin", Upstream: "localhost:5050"},
+		{Name: "grafana", Upstream: "localhost:3000"},
+	})
+	route, ok := r.Lookup("pgadmin")
+	if !ok || !route.Permanent || route.Upstream != "localhost:5050" || route.Token != "" {
+		t.Fatalf("pgadmin = %+v, %v; want the config route in place of the registered one", route, ok)
+	}
+	for range 2 {
+		<-ch
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if res := r.Sweep(); res.Expired != 0 {
+		t.Errorf("Sweep expired %d config routes", res.Expired)
+	}
+
+	caller := Caller{Token: "tok"}
+	if _, err := r.UpsertRoute(Route{Name: "grafana", Upstream: "localhost:3001"}, caller); !errors.Is(err, ErrPermanent) {
+		t.Errorf("UpsertRoute over a config route = %v, want ErrPermanent", err)
+	}
+	if _, err := r.DeregisterOwned("grafana", caller); !errors.Is(err, ErrPermanent) {
+		t.Errorf("DeregisterOwned of a config route = %v, want ErrPermanent", err)
+	}
+
+	// Reloading an unchanged config publishes nothing; dropping a route
+	// removes it.
+	registered := route.Registered
+	r.SetPermanent([]Route{{Name: "pgadmin", Upstream: "localhost:5050"}})
+	if ev := <-ch; ev.Type != EventRemoved || ev.Route != "grafana" {
+		t.Errorf("event = %+v, want grafana removed", ev)
+	}
+	select {
+	case ev := <-ch:
+		t.Errorf("unexpected event %+v", ev)
+	default:
+	}
+	if route, _ := r.Lookup("pgadmin"); !route.Registered.Equal(registered) {
+		t.Error("unchanged config route was registered again")
+	}
+
+	r.SetPermanent(nil)
+	if _, ok := r.Lookup("pgadmin"); ok {
+		t.Error("config route kept after it was removed from the config")
+	}
+}
+
+func TestSnapshot_SkipsPermanent(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	r.SetPermanent([]Route{{Name: "pgadmin", Upstream: "localhost:5050"}})
+	r.RegisterRoute(Route{Name: "web", Upstream: "localhost:3000", Dir: "/tmp/web"})
+	data, err := r.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewRouteRegistry(30 * time.Second)
+	restored.SetPermanent([]Route{{Name: "pgadmin", Upstream: "localhost:5051"}})
+	if _, err := restored.Restore(data); err != nil {
+		t.Fatal(err)
+	}
+	if route, _ := restored.Lookup("pgadmin"); route.Upstream != "localhost:5051" {
+		t.Errorf("pgadmin upstream = %q, want the new config's", route.Upstream)
+	}
+	if _, ok := restored.Lookup("web"); !ok {
+		t.Error("registered route not restored")
+	}
+}
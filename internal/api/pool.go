@@ -0,0 +1,52 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// maxPoolIdle bounds the idle connections a route may keep pooled.
+	maxPoolIdle        = 100
+	minPoolIdleTimeout = time.Second
+	maxPoolIdleTimeout = time.Hour
+)
+
+// PoolConfig tunes the keep-alive pool the daemon keeps to a route's
+// upstream. Dev servers that restart often leave pooled connections dead;
+// a short idle timeout, or no keep-alives at all, avoids reusing them.
+type PoolConfig struct {
+	// MaxIdle caps idle connections kept to the upstream. -1 disables
+	// keep-alives; 0 uses the default.
+	MaxIdle int `json:"maxIdle,omitempty"`
+	// IdleTimeout is a Go duration (e.g. "5s") after which an idle pooled
+	// connection is closed.
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+}
+
+// Timeout returns the parsed IdleTimeout, or zero for the default.
+func (c *PoolConfig) Timeout() time.Duration {
+	d, _ := time.ParseDuration(c.IdleTimeout)
+	return d
+}
+
+// validatePool checks pool settings from a registration request.
+func validatePool(c *PoolConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.MaxIdle < -1 || c.MaxIdle > maxPoolIdle {
+		return fmt.Errorf("pool maxIdle must be between -1 and %d", maxPoolIdle)
+	}
+	if c.IdleTimeout == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(c.IdleTimeout)
+	if err != nil {
+		return fmt.Errorf("invalid pool idleTimeout: %w", err)
+	}
+	if d < minPoolIdleTimeout || d > maxPoolIdleTimeout {
+		return fmt.Errorf("pool idleTimeout must be between %s and %s", minPoolIdleTimeout, maxPoolIdleTimeout)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidatePool(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    *PoolConfig
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"max idle", &PoolConfig{MaxIdle: 4}, false},
+		{"keep-alives off", &PoolConfig{MaxIdle: -1}, false},
+		{"idle timeout", &PoolConfig{IdleTimeout: "5s"}, false},
+		{"max idle too low", &PoolConfig{MaxIdle: -2}, true},
+		{"max idle too high", &PoolConfig{MaxIdle: 1000}, true},
+		{"bad duration", &PoolConfig{IdleTimeout: "soon"}, true},
+		{"timeout too short", &PoolConfig{IdleTimeout: "10ms"}, true},
+		{"timeout too long", &PoolConfig{IdleTimeout: "2h"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePool(tt.pool); (err != nil) != tt.wantErr {
+				t.Errorf("validatePool() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPoolConfigTimeout(t *testing.T) {
+	if got := (&PoolConfig{IdleTimeout: "5s"}).Timeout(); got != 5*time.Second {
+		t.Errorf("Timeout() = %v, want 5s", got)
+	}
+	if got := (&PoolConfig{}).Timeout(); got != 0 {
+		t.Errorf("Timeout() = %v, want 0", got)
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Idle timeout bounds for preview routes.
+const (
+	minIdleTimeout = time.Minute
+	maxIdleTimeout = 7 * 24 * time.Hour
+)
+
+// expiredRetention is how long the registry remembers idle-expired routes,
+// so their `up` process learns on its next heartbeat that the route was
+// retired rather than lost in a daemon restart.
+const expiredRetention = 5 * time.Minute
+
+// ErrIdleExpired is returned by Heartbeat for a route that was removed
+// because it received no requests within its idle timeout.
+var ErrIdleExpired = errors.New("route expired after idle timeout")
+
+// previewLabelPattern matches a single DNS label such as "pr-123".
+var previewLabelPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// validatePreview checks a preview label and parses its idle timeout.
+// An empty timeout disables idle expiry.
+func validatePreview(label, idleTimeout string) (time.Duration, error) {
+	if label != "" && !previewLabelPattern.MatchString(label) {
+		return 0, fmt.Errorf("invalid preview label %q: use lowercase letters, digits, and dashes", label)
+	}
+	if idleTimeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(idleTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid idleTimeout: %w", err)
+	}
+	if timeout < minIdleTimeout || timeout > maxIdleTimeout {
+		return 0, fmt.Errorf("idleTimeout must be between %s and %s", minIdleTimeout, maxIdleTimeout)
+	}
+	return timeout, nil
+}
+
+// idleSince returns when the route was last used: its last proxied
+// request, or its registration if it has never served one.
+func (route *Route) idleSince() time.Time {
+	if route.LastRequest.After(route.Registered) {
+		return route.LastRequest
+	}
+	return route.Registered
+}
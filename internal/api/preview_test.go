@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidatePreview(t *testing.T) {
+	tests := []struct {
+		name        string
+		label       string
+		idleTimeout string
+		want        time.Duration
+		wantErr     bool
+	}{
+		{"no preview", "", "", 0, false},
+		{"label only", "pr-123", "", 0, false},
+		{"label and timeout", "pr-123", "2h", 2 * time.Hour, false},
+		{"uppercase label", "PR-123", "", 0, true},
+		{"dotted label", "pr.123", "", 0, true},
+		{"bad duration", "pr-1", "soon", 0, true},
+		{"timeout too short", "pr-1", "10s", 0, true},
+		{"timeout too long", "pr-1", "720h", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validatePreview(tt.label, tt.idleTimeout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validatePreview() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("validatePreview() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteRegistry_IdleExpiry(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	if err := r.RegisterRoute(Route{
+		Name:        "pr-1.myapp",
+		Upstream:    "localhost:3000",
+		Dir:         "/tmp/myapp",
+		Preview:     "pr-1",
+		IdleTimeout: time.Minute,
+	}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+	r.Register("myapp", "localhost:3001", "/tmp/myapp")
+
+	// A recent request keeps the preview alive.
+	r.mu.Lock()
+	r.routes["pr-1.myapp"].Registered = time.Now().Add(-2 * time.Minute)
+	r.mu.Unlock()
+	route, _ := r.Lookup("pr-1.myapp")
+	r.Touch(route)
+	r.Cleanup()
+	if _, ok := r.Lookup("pr-1.myapp"); !ok {
+		t.Fatal("expected recently used preview to survive cleanup")
+	}
+
+	// Once idle past its timeout it is removed, while heartbeats continue.
+	r.mu.Lock()
+	r.routes["pr-1.myapp"].LastRequest = time.Now().Add(-2 * time.Minute)
+	r.mu.Unlock()
+	r.Cleanup()
+	if _, ok := r.Lookup("pr-1.myapp"); ok {
+		t.Fatal("expected idle preview to be removed")
+	}
+	if _, ok := r.Lookup("myapp"); !ok {
+		t.Error("expected route without idle timeout to be kept")
+	}
+	if err := r.Heartbeat("pr-1.myapp"); !errors.Is(err, ErrIdleExpired) {
+		t.Errorf("expected ErrIdleExpired from heartbeat, got %v", err)
+	}
+
+	// Re-registering clears the expiry record.
+	r.Register("pr-1.myapp", "localhost:3000", "/tmp/myapp")
+	if err := r.Heartbeat("pr-1.myapp"); err != nil {
+		t.Errorf("expected heartbeat to succeed after re-registration, got %v", err)
+	}
+}
+
+func TestHandleHeartbeat_GoneAfterIdleExpiry(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+	registry.mu.Lock()
+	registry.expired["pr-1.myapp"] = time.Now()
+	registry.mu.Unlock()
+
+	req := httptest.NewRequest("POST", "/routes/pr-1.myapp/heartbeat", nil)
+	req.SetPathValue("name", "pr-1.myapp")
+	w := httptest.NewRecorder()
+	srv.handleHeartbeat(w, req)
+
+	if w.Code != http.StatusGone {
+		t.Errorf("expected 410 for idle-expired route, got %d", w.Code)
+	}
+}
+
+func TestRouteRegistry_SweepCounts(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	r.Register("stale", "localhost:3000", "/tmp/stale")
+	r.Register("live", "localhost:3001", "/tmp/live")
+	r.RegisterRoute(Route{Name: "pr-1.myapp", Upstream: "localhost:3002", Dir: "/tmp/myapp", Preview: "pr-1", IdleTimeout: time.Minute})
+
+	r.mu.Lock()
+	r.routes["stale"].LastHeartbeat = time.Now().Add(-time.Minute)
+	r.routes["pr-1.myapp"].Registered = time.Now().Add(-2 * time.Minute)
+	r.expired["pr-old.myapp"] = time.Now().Add(-expiredRetention - time.Minute)
+	r.mu.Unlock()
+
+	got := r.Sweep()
+	want := SweepResult{Expired: 1, IdleExpired: 1, Tombstones: 1}
+	if got != want {
+		t.Errorf("Sweep() = %+v, want %+v", got, want)
+	}
+	if _, ok := r.Lookup("live"); !ok {
+		t.Error("expected live route to be kept")
+	}
+
+	// The fresh tombstone left by the idle preview is kept.
+	if got := r.Sweep(); got != (SweepResult{}) {
+		t.Errorf("second Sweep() = %+v, want nothing removed", got)
+	}
+}
+
+func TestHandleGC(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	registry.Register("stale", "localhost:3000", "/tmp/stale")
+	registry.mu.Lock()
+	registry.routes["stale"].LastHeartbeat = time.Now().Add(-time.Minute)
+	registry.mu.Unlock()
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+
+	w := httptest.NewRecorder()
+	srv.handleGC(w, httptest.NewRequest("POST", "/gc", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var got SweepResult
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Expired != 1 {
+		t.Errorf("expected 1 expired route, got %+v", got)
+	}
+}
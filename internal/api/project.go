@@ -0,0 +1,84 @@
+// internal/api/project.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+)
+
+// DeregisterProjectResponse is the body of a successful
+// DELETE /routes?project=name.
+type DeregisterProjectResponse struct {
+	// Removed lists the routes that were deregistered, sorted by name.
+	Removed []string `json:"removed"`
+}
+
+// validateProject checks an optional project name. Projects follow the
+// same rules as route names.
+func validateProject(project string) error {
+	if project != "" && !routeNamePattern.MatchString(project) {
+		return fmt.Errorf("invalid project: must start with a letter or digit and contain only letters, numbers, dashes, underscores, or dots (max 63 chars)")
+	}
+	return nil
+}
+
+// DeregisterProject removes every route registered with project and
+// returns their names. Routes are removed by project rather than by
+// token, so a local peer may only remove the group if it registered every
+// route in it as the same user (ErrOtherUser otherwise, removing nothing).
+// A nil peer skips that check. Routes from the config file are kept.
+func (r *RouteRegistry) DeregisterProject(project string, peer *PeerCred) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var group []*Route
+	for _, route := range r.routes {
+		if route.Project != project || route.Permanent {
+			continue
+		}
+		if peer != nil && route.Owner != nil && route.Owner.UID != peer.UID {
+			return nil, ErrOtherUser
+		}
+		group = append(group, route)
+	}
+
+	removed := make([]string, 0, len(group))
+	for _, route := range group {
+		delete(r.routes, route.Name)
+		removed = append(removed, route.Name)
+		r.publish(EventRemoved, route)
+	}
+	slices.Sort(removed)
+	if len(removed) > 0 {
+		r.debug("project deregistered", "project", project, "routes", removed)
+	}
+	return removed, nil
+}
+
+// handleDeregisterProject removes every route in the project named by the
+// ?project= query parameter.
+func (s *Server) handleDeregisterProject(w http.ResponseWriter, r *http.Request) {
+	project := r.URL.Query().Get("project")
+	if project == "" {
+		jsonError(w, "project query parameter required", http.StatusBadRequest)
+		return
+	}
+	if err := validateProject(project); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	removed, err := s.registry.DeregisterProject(project, peerCredOf(r))
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(DeregisterProjectResponse{Removed: removed}); err != nil {
+		log.Printf("api: failed to encode deregister response: %v", err)
+	}
+}
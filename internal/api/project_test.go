@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteRegistry_DeregisterProject(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	r.RegisterRoute(Route{Name: "web.shop", Upstream: "localhost:3000", Dir: "/tmp/shop", Project: "shop"})
+	r.RegisterRoute(Route{Name: "api.shop", Upstream: "localhost:3001", Dir: "/tmp/shop", Project: "shop"})
+	r.RegisterRoute(Route{Name: "blog", Upstream: "localhost:4000", Dir: "/tmp/blog", Project: "blog"})
+
+	ch := r.Subscribe()
+	defer r.Unsubscribe(ch)
+
+	removed, err := r.DeregisterProject("shop", nil)
+	if err != nil {
+		t.Fatalf("DeregisterProject() = %v", err)
+	}
+	if !slices.Equal(removed, []string{"api.shop", "web.shop"}) {
+		t.Errorf("removed = %v, want [api.shop web.shop]", removed)
+	}
+	if _, ok := r.Lookup("blog"); !ok {
+		t.Error("route in another project was removed")
+	}
+	for range 2 {
+		if ev := <-ch; ev.Type != EventRemoved || ev.Project != "shop" {
+			t.Errorf("event = %+v, want removed in shop", ev)
+		}
+	}
+
+	if removed, err := r.DeregisterProject("missing", nil); err != nil || len(removed) != 0 {
+		t.Errorf("DeregisterProject(missing) = %v, %v; want nothing", removed, err)
+	}
+}
+
+func TestRouteRegistry_DeregisterProjectOtherUser(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	r.RegisterRoute(Route{Name: "web.shop", Upstream: "localhost:3000", Dir: "/tmp/shop", Project: "shop", Owner: &PeerCred{UID: 501}})
+	r.RegisterRoute(Route{Name: "api.shop", Upstream: "localhost:3001", Dir: "/tmp/shop", Project: "shop", Owner: &PeerCred{UID: 502}})
+
+	if _, err := r.DeregisterProject("shop", &PeerCred{UID: 501}); !errors.Is(err, ErrOtherUser) {
+		t.Fatalf("DeregisterProject() = %v, want ErrOtherUser", err)
+	}
+	if len(r.List()) != 2 {
+		t.Error("routes removed despite ErrOtherUser")
+	}
+}
+
+func TestHandleDeregisterProject(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	h := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry).server.Handler
+
+	for _, body := range []string{
+		`{"name":"web.shop","upstream":"localhost:3000","dir":"/tmp/shop","project":"shop"}`,
+		`{"name":"api.shop","upstream":"localhost:3001","dir":"/tmp/shop","project":"shop"}`,
+		`{"name":"blog","upstream":"localhost:4000","dir":"/tmp/blog"}`,
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/v1/routes", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("POST /v1/routes: %d %s", w.Code, w.Body.String())
+		}
+	}
+	if route, _ := registry.Lookup("web.shop"); route.Project != "shop" {
+		t.Errorf("project = %q, want shop", route.Project)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/v1/routes", strings.NewReader(`{"name":"x","upstream":"localhost:5000","dir":"/tmp/x","project":"bad project"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid project: expected 400, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("DELETE", "/v1/routes", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("DELETE without project: expected 400, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("DELETE", "/v1/routes?project=shop", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("DELETE ?project=shop: expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	var resp DeregisterProjectResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !slices.Equal(resp.Removed, []string{"api.shop", "web.shop"}) {
+		t.Errorf("removed = %v, want [api.shop web.shop]", resp.Removed)
+	}
+	if names := len(registry.List()); names != 1 {
+		t.Errorf("expected 1 route left, got %d", names)
+	}
+}
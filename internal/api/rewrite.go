@@ -0,0 +1,200 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	// maxRewriteLocations bounds the origins a route rewrites redirects from.
+	maxRewriteLocations = 10
+	maxStripPrefixLen   = 256
+)
+
+// RewriteConfig rewrites request paths on the way to a route's upstream
+// and redirects on the way back, for apps that don't know the URL they're
+// served at.
+type RewriteConfig struct {
+	// StripPrefix is removed from request paths before forwarding, so
+	// "/api" sends /api/users to the upstream as /users. Other paths are
+	// forwarded unchanged. Redirects to root-relative paths get the
+	// prefix back.
+	StripPrefix string `json:"stripPrefix,omitempty"`
+	// Locations are upstream origins, e.g. "localhost:3000", whose
+	// absolute URLs in Location headers are pointed back at the route.
+	// The upstream's own address never needs listing.
+	Locations []string `json:"locations,omitempty"`
+	// KeepUpstreamURLs turns off the automatic rewriting of the upstream's
+	// own URLs: Location headers pointing at it and Set-Cookie Domain
+	// attributes naming it.
+	KeepUpstreamURLs bool `json:"keepUpstreamUrls,omitempty"`
+}
+
+// validateRewrite checks rewrite rules from a registration request.
+func validateRewrite(c *RewriteConfig) error {
+	if c == nil {
+		return nil
+	}
+	if p := c.StripPrefix; p != "" {
+		if len(p) > maxStripPrefixLen || !strings.HasPrefix(p, "/") || p == "/" ||
+			strings.HasSuffix(p, "/") || strings.ContainsAny(p, "?#%\\") || path.Clean(p) != p {
+			return fmt.Errorf("invalid rewrite stripPrefix %q: must be a clean path like /api", p)
+		}
+	}
+	if len(c.Locations) > maxRewriteLocations {
+		return fmt.Errorf("too many rewrite locations (max %d)", maxRewriteLocations)
+	}
+	for _, loc := range c.Locations {
+		if _, _, err := net.SplitHostPort(locationHost(loc)); err != nil {
+			return fmt.Errorf("invalid rewrite location %q: must be host:port", loc)
+		}
+	}
+	return nil
+}
+
+// locationHost returns the lowercased host:port of a Locations entry,
+// which may also be written as an http:// or https:// origin.
+func locationHost(loc string) string {
+	loc = strings.TrimPrefix(strings.TrimPrefix(loc, "http://"), "https://")
+	return strings.ToLower(strings.TrimSuffix(loc, "/"))
+}
+
+// Request returns r with StripPrefix removed from its path, or r itself
+// when the path is outside the prefix. Like http.StripPrefix, the
+// returned request is a shallow copy, so r keeps its original URL for
+// logging.
+func (c *RewriteConfig) Request(r *http.Request) *http.Request {
+	p := c.StripPrefix
+	if p == "" || !hasPathPrefix(r.URL.Path, p) {
+		return r
+	}
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, p), "/")
+	if hasPathPrefix(r.URL.RawPath, p) {
+		r2.URL.RawPath = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.RawPath, p), "/")
+	} else {
+		r2.URL.RawPath = ""
+	}
+	return r2
+}
+
+// hasPathPrefix reports whether path is prefix or lies under it.
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// ApplyResponse rewrites the Location and Set-Cookie headers in h on
+// their way from upstream to the client at scheme://host, the route's
+// public origin. A nil config still gets the automatic rewriting of the
+// upstream's own URLs.
+func (c *RewriteConfig) ApplyResponse(h http.Header, upstream, scheme, host string) {
+	var rules RewriteConfig
+	if c != nil {
+		rules = *c
+	}
+	rules.applyLocation(h, upstream, scheme, host)
+	if !rules.KeepUpstreamURLs {
+		stripCookieDomains(h, upstream)
+	}
+}
+
+// applyLocation rewrites the Location header in h. Absolute URLs on the
+// upstream or one of Locations move to the public origin, and
+// root-relative paths gain StripPrefix.
+func (c *RewriteConfig) applyLocation(h http.Header, upstream, scheme, host string) {
+	loc := h.Get("Location")
+	if loc == "" {
+		return
+	}
+	u, err := url.Parse(loc)
+	if err != nil {
+		return
+	}
+	switch {
+	case u.Host != "":
+		if !c.rewritesFrom(u.Host) && (c.KeepUpstreamURLs || !isUpstreamHost(u.Host, upstream)) {
+			return
+		}
+		u.Scheme, u.Host = scheme, host
+	case u.Scheme == "" && strings.HasPrefix(u.Path, "/"):
+		if c.StripPrefix == "" {
+			return
+		}
+	default:
+		return
+	}
+	if c.StripPrefix != "" {
+		u.Path = c.StripPrefix + u.Path
+		if u.RawPath != "" {
+			u.RawPath = c.StripPrefix + u.RawPath
+		}
+	}
+	h.Set("Location", u.String())
+}
+
+// rewritesFrom reports whether host is one of Locations.
+func (c *RewriteConfig) rewritesFrom(host string) bool {
+	host = strings.ToLower(host)
+	for _, loc := range c.Locations {
+		if locationHost(loc) == host {
+			return true
+		}
+	}
+	return false
+}
+
+// isUpstreamHost reports whether hostport names the upstream: its own
+// address, or any loopback or unspecified address on the same port, which
+// is how dev servers usually spell themselves (localhost:3000,
+// 127.0.0.1:3000, 0.0.0.0:3000).
+func isUpstreamHost(hostport, upstream string) bool {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return false
+	}
+	upHost, upPort, err := net.SplitHostPort(upstream)
+	if err != nil || port != upPort {
+		return false
+	}
+	return strings.EqualFold(host, upHost) || isLocalName(host)
+}
+
+// isLocalName reports whether host is localhost or a loopback or
+// unspecified IP address.
+func isLocalName(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// stripCookieDomains removes Domain attributes naming the upstream's host
+// (e.g. Domain=localhost) from Set-Cookie headers in h, so browsers store
+// the cookie for the route's own name instead of rejecting it.
+func stripCookieDomains(h http.Header, upstream string) {
+	upHost, _, _ := net.SplitHostPort(upstream)
+	cookies := h["Set-Cookie"]
+	for i, cookie := range cookies {
+		attrs := strings.Split(cookie, ";")
+		kept := []string{attrs[0]}
+		for _, attr := range attrs[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(attr), "=")
+			value = strings.TrimPrefix(strings.TrimSpace(value), ".")
+			if strings.EqualFold(name, "Domain") && (isLocalName(value) || strings.EqualFold(value, upHost)) {
+				continue
+			}
+			kept = append(kept, attr)
+		}
+		if len(kept) != len(attrs) {
+			cookies[i] = strings.Join(kept, ";")
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateRewrite(t *testing.T) {
+	valid := []*RewriteConfig{
+		nil,
+		{},
+		{StripPrefix: "/api"},
+		{StripPrefix: "/api/v1", Locations: []string{"localhost:3000", "http://127.0.0.1:3000/"}},
+	}
+	for _, c := range valid {
+		if err := validateRewrite(c); err != nil {
+			t.Errorf("validateRewrite(%+v) = %v", c, err)
+		}
+	}
+	invalid := []*RewriteConfig{
+		{StripPrefix: "api"},
+		{StripPrefix: "/"},
+		{StripPrefix: "/api/"},
+		{StripPrefix: "/api/../admin"},
+		{StripPrefix: "/api?x=1"},
+		{Locations: []string{"localhost"}},
+		{Locations: make([]string, maxRewriteLocations+1)},
+	}
+	for _, c := range invalid {
+		if err := validateRewrite(c); err == nil {
+			t.Errorf("validateRewrite(%+v) should fail", c)
+		}
+	}
+}
+
+func TestRewriteRequest(t *testing.T) {
+	c := &RewriteConfig{StripPrefix: "/api"}
+	tests := []struct {
+		in, want string
+	}{
+		{"/api/users?page=2", "/users?page=2"},
+		{"/api", "/"},
+		{"/api/", "/"},
+		{"/apiary", "/apiary"},
+		{"/", "/"},
+		{"/api/a%2Fb", "/a%2Fb"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "https://myapp.test"+tt.in, nil)
+		got := c.Request(r)
+		if got.URL.RequestURI() != tt.want {
+			t.Errorf("Request(%s) = %s, want %s", tt.in, got.URL.RequestURI(), tt.want)
+		}
+		if r.URL.RequestURI() != tt.in {
+			t.Errorf("Request(%s) changed the original request to %s", tt.in, r.URL.RequestURI())
+		}
+	}
+}
+
+func TestApplyResponseLocation(t *testing.T) {
+	tests := []struct {
+		name string
+		c    *RewriteConfig
+		in   string
+		want string
+	}{
+		{"listed origin", &RewriteConfig{Locations: []string{"localhost:4000"}}, "http://localhost:4000/login?next=%2F", "https://myapp.test/login?next=%2F"},
+		{"origin written with scheme", &RewriteConfig{Locations: []string{"http://LOCALHOST:4000"}}, "http://localhost:4000/", "https://myapp.test/"},
+		{"other origin", nil, "https://github.com/login", "https://github.com/login"},
+		{"upstream", nil, "http://localhost:3000/login", "https://myapp.test/login"},
+		{"upstream by IP", nil, "http://127.0.0.1:3000/", "https://myapp.test/"},
+		{"upstream unspecified", nil, "http://0.0.0.0:3000/", "https://myapp.test/"},
+		{"upstream IPv6", nil, "http://[::1]:3000/", "https://myapp.test/"},
+		{"other port", nil, "http://localhost:3001/", "http://localhost:3001/"},
+		{"upstream kept", &RewriteConfig{KeepUpstreamURLs: true}, "http://localhost:3000/login", "http://localhost:3000/login"},
+		{"relative without prefix", nil, "/login", "/login"},
+		{"relative with prefix", &RewriteConfig{StripPrefix: "/api"}, "/login", "/api/login"},
+		{"upstream with prefix", &RewriteConfig{StripPrefix: "/api"}, "http://localhost:3000/login", "https://myapp.test/api/login"},
+		{"path-relative", &RewriteConfig{StripPrefix: "/api"}, "login", "login"},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Set("Location", tt.in)
+		tt.c.ApplyResponse(h, "localhost:3000", "https", "myapp.test")
+		if got := h.Get("Location"); got != tt.want {
+			t.Errorf("%s: Location = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyResponseCookies(t *testing.T) {
+	h := http.Header{}
+	h.Add("Set-Cookie", "sid=1; Path=/; Domain=localhost; HttpOnly")
+	h.Add("Set-Cookie", "a=2; domain=.127.0.0.1")
+	h.Add("Set-Cookie", "b=3; Domain=example.com")
+	h.Add("Set-Cookie", "c=4")
+	(*RewriteConfig)(nil).ApplyResponse(h, "localhost:3000", "https", "myapp.test")
+	want := []string{"sid=1; Path=/; HttpOnly", "a=2", "b=3; Domain=example.com", "c=4"}
+	got := h.Values("Set-Cookie")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Set-Cookie = %q, want %q", got, want)
+	}
+
+	h = http.Header{}
+	h.Add("Set-Cookie", "sid=1; Domain=localhost")
+	(&RewriteConfig{KeepUpstreamURLs: true}).ApplyResponse(h, "localhost:3000", "https", "myapp.test")
+	if got := h.Get("Set-Cookie"); got != "sid=1; Domain=localhost" {
+		t.Errorf("kept: Set-Cookie = %q", got)
+	}
+}
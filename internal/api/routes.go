@@ -3,6 +3,8 @@ package api
 
 import (
 	"fmt"
+	"log/slog"
+	"maps"
 	"net"
 	"strings"
 	"sync"
@@ -11,12 +13,148 @@ import (
 
 const maxRoutes = 100
 
+// Bounds for a route's own heartbeat timeout. The floor keeps a live `up`
+// session, which heartbeats every 10s, from being reaped; the ceiling lets
+// a route without a heartbeating client live for a week at most.
+const (
+	minRouteHeartbeatTimeout = 15 * time.Second
+	maxRouteHeartbeatTimeout = 7 * 24 * time.Hour
+)
+
 type Route struct {
 	Name          string    `json:"name"`
 	Upstream      string    `json:"upstream"`
 	Dir           string    `json:"dir"`
 	Registered    time.Time `json:"registered"`
 	LastHeartbeat time.Time `json:"lastHeartbeat"`
+	// AuthMode is "basic" or "bearer" when the route requires credentials.
+	AuthMode string `json:"auth,omitempty"`
+	// Auth holds the route's credentials. Never serialized.
+	Auth *RouteAuth `json:"-"`
+	// Token is the owner's secret for changing or removing the route.
+	// Routes registered without one can be changed by anyone. Never
+	// serialized.
+	Token string `json:"-"`
+	// Owner is the local process that registered the route over the unix
+	// socket, when its credentials could be read.
+	Owner *PeerCred `json:"owner,omitempty"`
+	// Headers are set on every request proxied to the upstream.
+	Headers map[string]string `json:"headers,omitempty"`
+	// CORS, when set, makes the daemon answer preflights and add CORS
+	// headers to responses.
+	CORS *CORSConfig `json:"cors,omitempty"`
+	// Preview labels the route as a preview environment (e.g. "pr-123").
+	Preview string `json:"preview,omitempty"`
+	// Project groups related routes, e.g. the services of a compose project.
+	Project string `json:"project,omitempty"`
+	// ProxyProtocol sends a PROXY protocol v2 header on each upstream
+	// connection, for upstreams that read the client address from it.
+	ProxyProtocol bool `json:"proxyProtocol,omitempty"`
+	// TrustForwarded keeps X-Forwarded-* and Forwarded headers sent by a
+	// local client and appends to them instead of replacing them.
+	TrustForwarded bool `json:"trustForwarded,omitempty"`
+	// PlainHTTP serves the route on port 80 as well, instead of
+	// redirecting to HTTPS, for clients that can't speak TLS.
+	PlainHTTP bool `json:"plainHttp,omitempty"`
+	// Passthrough forwards the raw TLS connection to the upstream, picked
+	// by SNI, instead of terminating TLS in the daemon. The upstream serves
+	// its own certificate, and HTTP options don't apply.
+	Passthrough bool `json:"passthrough,omitempty"`
+	// UDP makes Upstream a UDP address. The daemon forwards datagrams to
+	// it from UDPPort on loopback and publishes that port in DNS SRV
+	// records; HTTP requests for the route are refused.
+	UDP bool `json:"udp,omitempty"`
+	// UDPPort is the loopback port UDP datagrams are forwarded from. Zero
+	// until the daemon has picked one.
+	UDPPort int `json:"udpPort,omitempty"`
+	// Mirror is a second loopback upstream that gets a copy of every
+	// request. Its responses are discarded.
+	Mirror string `json:"mirror,omitempty"`
+	// Rewrite rewrites request paths and redirects between the route's
+	// public URL and the upstream.
+	Rewrite *RewriteConfig `json:"rewrite,omitempty"`
+	// Cookies adjusts the attributes of cookies the upstream sets.
+	Cookies *CookieConfig `json:"cookies,omitempty"`
+	// Pool tunes the keep-alive connections kept to the upstream.
+	Pool *PoolConfig `json:"pool,omitempty"`
+	// Balance spreads requests over Upstream and more instances of the
+	// dev server.
+	Balance *BalanceConfig `json:"balance,omitempty"`
+	// Alerts overrides the daemon's request and error rate alert
+	// thresholds for this route.
+	Alerts *AlertConfig `json:"alerts,omitempty"`
+	// HSTS overrides the daemon's handling of the upstream's
+	// Strict-Transport-Security header. Empty uses the daemon setting.
+	HSTS string `json:"hsts,omitempty"`
+	// HostHeader is the Host header mode: preserve (the default),
+	// upstream, or custom:<host>.
+	HostHeader string `json:"hostHeader,omitempty"`
+	// Subdomains makes the route also answer for any name under it
+	// (tenant1.myapp.test) that isn't registered itself.
+	Subdomains bool `json:"subdomains,omitempty"`
+	// Throttle slows the route's traffic down to simulate a slow network.
+	Throttle *ThrottleConfig `json:"throttle,omitempty"`
+	// Static routes are served from a directory by the daemon, and have
+	// no Upstream.
+	Static *StaticConfig `json:"static,omitempty"`
+	// SPA serves the app's index page for deep links the upstream
+	// answers with 404 (or other configured statuses).
+	SPA *SPAConfig `json:"spa,omitempty"`
+	// Canonical redirects requests to the route's canonical URL before
+	// they reach the upstream.
+	Canonical *CanonicalConfig `json:"canonical,omitempty"`
+	// Maintenance takes the route down on a schedule.
+	Maintenance *MaintenanceConfig `json:"maintenance,omitempty"`
+	// MaintenanceUntil is when the current maintenance window ends. Zero
+	// outside windows.
+	MaintenanceUntil time.Time `json:"maintenanceUntil,omitzero"`
+	// Paused routes get the daemon's waiting page instead of reaching the
+	// upstream, e.g. while `up` stops its dev server before removing the
+	// route. Registering the route again resumes it.
+	Paused bool `json:"paused,omitempty"`
+	// BindProcess removes the route as soon as its Owner process exits,
+	// instead of waiting out the heartbeat timeout.
+	BindProcess bool `json:"bindProcess,omitempty"`
+	// Permanent routes are defined in the daemon's config file. They
+	// never expire, and only editing the file changes or removes them.
+	Permanent bool `json:"permanent,omitempty"`
+	// HeartbeatTimeout overrides the daemon's heartbeat timeout for this
+	// route. Zero uses the daemon's.
+	HeartbeatTimeout time.Duration `json:"-"`
+	// HeartbeatTimeoutSeconds mirrors HeartbeatTimeout for API clients.
+	HeartbeatTimeoutSeconds int64 `json:"heartbeatTimeoutSeconds,omitempty"`
+	// IdleTimeout removes the route once it has served no requests for
+	// this long. Zero disables idle expiry.
+	IdleTimeout time.Duration `json:"-"`
+	// IdleTimeoutSeconds mirrors IdleTimeout for API clients.
+	IdleTimeoutSeconds int64 `json:"idleTimeoutSeconds,omitempty"`
+	// LastRequest is when a request was last proxied to the route. Only
+	// tracked for routes with an IdleTimeout.
+	LastRequest time.Time `json:"lastRequest,omitzero"`
+	// Churn counts recent registrations and missed heartbeats. Only set by
+	// List, and only for routes that registered more than once or missed a
+	// heartbeat in the last hour.
+	Churn *RouteChurn `json:"churn,omitempty"`
+}
+
+// DashboardName is the route name reserved for the daemon's dashboard,
+// served at _paw.<tld>.
+const DashboardName = "_paw"
+
+// IsReservedName reports whether name belongs to the daemon and can't be
+// registered as a route. Host names are case-insensitive, so neither is
+// the reservation.
+func IsReservedName(name string) bool {
+	return strings.EqualFold(name, DashboardName)
+}
+
+// ReservedError is returned when registering a reserved route name.
+type ReservedError struct {
+	Name string
+}
+
+func (e *ReservedError) Error() string {
+	return fmt.Sprintf("route name %q is reserved", e.Name)
 }
 
 type ConflictError struct {
@@ -38,24 +176,56 @@ func (e *LimitError) Error() string {
 
 type RouteRegistry struct {
 	routes  map[string]*Route
-	timeout time.Duration
-	mu      sync.RWMutex
+	expired map[string]time.Time // idle-expired route names -> expiry time
+	// permanent holds the routes last set from the config file, as defined.
+	permanent map[string]Route
+	churn     map[string]*churnHistory
+	timeout   time.Duration
+	mu        sync.RWMutex
+	logger    *slog.Logger
+	subsMu    sync.Mutex
+	subs      map[chan RouteEvent]struct{}
 }
 
 func NewRouteRegistry(timeout time.Duration) *RouteRegistry {
 	return &RouteRegistry{
-		routes:  make(map[string]*Route),
-		timeout: timeout,
+		routes:    make(map[string]*Route),
+		expired:   make(map[string]time.Time),
+		permanent: make(map[string]Route),
+		churn:     make(map[string]*churnHistory),
+		timeout:   timeout,
+		subs:      make(map[chan RouteEvent]struct{}),
+	}
+}
+
+// SetLogger enables debug logging of route changes.
+func (r *RouteRegistry) SetLogger(logger *slog.Logger) {
+	r.logger = logger
+}
+
+func (r *RouteRegistry) debug(msg string, args ...any) {
+	if r.logger != nil {
+		r.logger.Debug(msg, args...)
 	}
 }
 
 func (r *RouteRegistry) Register(name, upstream, dir string) error {
+	return r.RegisterRoute(Route{Name: name, Upstream: upstream, Dir: dir})
+}
+
+// RegisterRoute adds a route built from the caller-supplied fields (name,
+// upstream, dir, and optional settings). Timestamps are set by the registry.
+func (r *RouteRegistry) RegisterRoute(route Route) error {
+	if IsReservedName(route.Name) {
+		return &ReservedError{Name: route.Name}
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if existing, ok := r.routes[name]; ok {
+	if existing, ok := r.routes[route.Name]; ok {
 		return &ConflictError{
-			Name:        name,
+			Name:        route.Name,
 			ExistingDir: existing.Dir,
 		}
 	}
@@ -63,30 +233,224 @@ func (r *RouteRegistry) Register(name, upstream, dir string) error {
 		return &LimitError{Limit: maxRoutes}
 	}
 
-	now := time.Now()
-	r.routes[name] = &Route{
-		Name:          name,
-		Upstream:      upstream,
-		Dir:           dir,
-		Registered:    now,
-		LastHeartbeat: now,
+	r.store(route, time.Now())
+	r.debug("route registered", "route", route.Name, "upstream", route.Upstream, "dir", route.Dir, "auth", route.AuthMode)
+	r.publish(EventAdded, r.routes[route.Name])
+	return nil
+}
+
+// UpsertRoute registers route, or replaces the registered route of the
+// same name if it was registered from the same directory, keeping its
+// registration time and token. It reports whether the route was newly
+// created. A route registered from another directory is a *ConflictError,
+// and updating an owned route requires its token (ErrNotOwner) and, when
+// both are known, the same user as its owner (ErrOtherUser).
+func (r *RouteRegistry) UpsertRoute(route Route, caller Caller) (created bool, err error) {
+	if IsReservedName(route.Name) {
+		return false, &ReservedError{Name: route.Name}
 	}
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.routes[route.Name]
+	if !ok {
+		if len(r.routes) >= maxRoutes {
+			return false, &LimitError{Limit: maxRoutes}
+		}
+		r.store(route, time.Now())
+		r.debug("route registered", "route", route.Name, "upstream", route.Upstream, "dir", route.Dir, "auth", route.AuthMode)
+		r.publish(EventAdded, r.routes[route.Name])
+		return true, nil
+	}
+	if existing.Dir != route.Dir {
+		return false, &ConflictError{Name: route.Name, ExistingDir: existing.Dir}
+	}
+	if err := existing.allows(caller); err != nil {
+		return false, err
+	}
+
+	route.Token = existing.Token
+	if route.Owner == nil {
+		route.Owner = existing.Owner
+	}
+	r.store(route, existing.Registered)
+	if route.IdleTimeout > 0 {
+		// Re-registering counts as activity, or a preview whose client
+		// restarts could idle-expire at once.
+		r.routes[route.Name].LastRequest = time.Now()
+	}
+	r.debug("route updated", "route", route.Name, "upstream", route.Upstream, "dir", route.Dir, "auth", route.AuthMode)
+	r.publish(EventUpdated, r.routes[route.Name])
+	return false, nil
+}
+
+// store saves a private copy of route under its name, with fresh
+// heartbeat and idle timers. Callers hold r.mu.
+func (r *RouteRegistry) store(route Route, registered time.Time) {
+	route.Registered = registered
+	route.LastHeartbeat = time.Now()
+	route.LastRequest = time.Time{}
+	route.setAuth(route.Auth)
+	route.Headers = maps.Clone(route.Headers)
+	route.IdleTimeoutSeconds = int64(route.IdleTimeout / time.Second)
+	route.HeartbeatTimeoutSeconds = int64(route.HeartbeatTimeout / time.Second)
+	route.Churn = nil
+	r.routes[route.Name] = &route
+	delete(r.expired, route.Name)
+	r.recordChurn(route.Name, false, route.LastHeartbeat)
+}
+
+// SetAuth replaces the credentials required for a route. A nil auth makes
+// the route public again.
+func (r *RouteRegistry) SetAuth(name string, auth *RouteAuth) error {
+	return r.setAuthAs(name, nil, auth)
+}
+
+// SetAuthOwned is SetAuth for a caller that must own the route.
+func (r *RouteRegistry) SetAuthOwned(name string, caller Caller, auth *RouteAuth) error {
+	return r.setAuthAs(name, &caller, auth)
+}
+
+// setAuthAs implements SetAuth. A nil caller skips the ownership check,
+// for callers inside the daemon.
+func (r *RouteRegistry) setAuthAs(name string, caller *Caller, auth *RouteAuth) error {
+	if err := validateAuth(auth); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route, ok := r.routes[name]
+	if !ok {
+		return fmt.Errorf("route %q not found", name)
+	}
+	if caller != nil {
+		if err := route.allows(*caller); err != nil {
+			return err
+		}
+	}
+	route.setAuth(auth)
+	r.debug("route auth changed", "route", name, "auth", route.AuthMode)
+	return nil
+}
+
+// SetThrottle replaces a route's throttle. A nil throttle removes it.
+func (r *RouteRegistry) SetThrottle(name string, throttle *ThrottleConfig) error {
+	return r.setThrottleAs(name, nil, throttle)
+}
+
+// SetThrottleOwned is SetThrottle for a caller that must run as the
+// route owner's user. Unlike other changes it needs no token: throttling
+// is a testing knob, set from a shell or the dashboard rather than by the
+// `up` holding the token.
+func (r *RouteRegistry) SetThrottleOwned(name string, caller Caller, throttle *ThrottleConfig) error {
+	return r.setThrottleAs(name, &caller, throttle)
+}
+
+// setThrottleAs implements SetThrottle. A nil caller skips the ownership
+// check.
+func (r *RouteRegistry) setThrottleAs(name string, caller *Caller, throttle *ThrottleConfig) error {
+	throttle, err := normalizeThrottle(throttle)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route, ok := r.routes[name]
+	if !ok {
+		return fmt.Errorf("route %q not found", name)
+	}
+	if caller != nil && route.Owner != nil && caller.Peer != nil && caller.Peer.UID != route.Owner.UID {
+		return ErrOtherUser
+	}
+	route.Throttle = throttle
+	r.debug("route throttle changed", "route", name, "throttle", throttle)
+	r.publish(EventUpdated, route)
+	return nil
+}
+
+// Pause stops proxying to a route's upstream until it is registered again.
+func (r *RouteRegistry) Pause(name string) error {
+	return r.pauseAs(name, nil)
+}
+
+// PauseOwned is Pause for a caller that must own the route.
+func (r *RouteRegistry) PauseOwned(name string, caller Caller) error {
+	return r.pauseAs(name, &caller)
+}
+
+// pauseAs implements Pause. A nil caller skips the ownership check.
+func (r *RouteRegistry) pauseAs(name string, caller *Caller) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route, ok := r.routes[name]
+	if !ok {
+		return fmt.Errorf("route %q not found", name)
+	}
+	if caller != nil {
+		if err := route.allows(*caller); err != nil {
+			return err
+		}
+	}
+	if route.Paused {
+		return nil
+	}
+	route.Paused = true
+	r.debug("route paused", "route", name)
+	r.publish(EventUpdated, route)
 	return nil
 }
 
+// setAuth stores a private copy of auth and keeps AuthMode in sync.
+func (route *Route) setAuth(auth *RouteAuth) {
+	if auth == nil {
+		route.Auth = nil
+		route.AuthMode = ""
+		return
+	}
+	copied := *auth
+	route.Auth = &copied
+	route.AuthMode = copied.Mode()
+}
+
 func (r *RouteRegistry) Deregister(name string) bool {
+	ok, _ := r.deregisterAs(name, nil)
+	return ok
+}
+
+// DeregisterOwned is Deregister for a caller that must own the route.
+func (r *RouteRegistry) DeregisterOwned(name string, caller Caller) (bool, error) {
+	return r.deregisterAs(name, &caller)
+}
+
+// deregisterAs implements Deregister. A nil caller skips the ownership
+// check.
+func (r *RouteRegistry) deregisterAs(name string, caller *Caller) (bool, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, ok := r.routes[name]; ok {
-		delete(r.routes, name)
-		return true
+	route, ok := r.routes[name]
+	if !ok {
+		return false, nil
+	}
+	if caller != nil {
+		if err := route.allows(*caller); err != nil {
+			return false, err
+		}
 	}
-	return false
+	delete(r.routes, name)
+	r.debug("route deregistered", "route", name)
+	r.publish(EventRemoved, route)
+	return true, nil
 }
 
 // Lookup returns a copy of the route with the given name.
+// The Auth pointer is shared but never mutated in place; SetAuth swaps it.
 // Returning a copy prevents callers from mutating registry-owned data.
 func (r *RouteRegistry) Lookup(name string) (Route, bool) {
 	r.mu.RLock()
@@ -99,70 +463,287 @@ func (r *RouteRegistry) Lookup(name string) (Route, bool) {
 	return *route, true
 }
 
+// SubdomainHeader carries the part of the host in front of the route's
+// name when a request matched a Subdomains route (tenant1 for
+// tenant1.myapp.test).
+const SubdomainHeader = "X-Paw-Subdomain"
+
+// LookupSubdomain returns the route for name, falling back to the nearest
+// parent route registered with Subdomains. subdomain is the part of name
+// in front of the parent's name, empty on an exact match. Registered
+// names always win, so web.shop still reaches web.shop when shop takes
+// subdomains.
+func (r *RouteRegistry) LookupSubdomain(name string) (route Route, subdomain string, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if rt, ok := r.routes[name]; ok {
+		return *rt, "", true
+	}
+	for rest := name; ; {
+		_, parent, found := strings.Cut(rest, ".")
+		if !found {
+			return Route{}, "", false
+		}
+		if rt, ok := r.routes[parent]; ok && rt.Subdomains {
+			return *rt, strings.TrimSuffix(name, "."+parent), true
+		}
+		rest = parent
+	}
+}
+
+// MaxHostLen is the longest Host header value accepted: a 253-character
+// DNS name plus ":" and a 5-digit port.
+const MaxHostLen = 253 + 6
+
+// ValidHost reports whether host (optionally with a port) is a plausible
+// DNS name: bounded in length, with labels of 1-63 letters, digits,
+// hyphens, or underscores. It keeps oversized or malformed values from the
+// network out of route lookup, cert generation, and error pages.
+func ValidHost(host string) bool {
+	if host == "" || len(host) > MaxHostLen {
+		return false
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for label := range strings.SplitSeq(host, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // ExtractName extracts the route name from a host string like
 // "myapp.test", "frontend.myapp.test:443", etc. Strips port and .test suffix.
 func ExtractName(host string) string {
+	return ExtractNameFor(host, []string{"test"})
+}
+
+// ExtractNameFor is like ExtractName but strips whichever of tlds the host
+// ends with, for daemons configured to answer more than one TLD.
+func ExtractNameFor(host string, tlds []string) string {
+	// SECURITY: Don't do work proportional to attacker-controlled input;
+	// no valid route name can come from an oversized host.
+	if len(host) > MaxHostLen {
+		return ""
+	}
 	if h, _, err := net.SplitHostPort(host); err == nil {
 		host = h
 	}
-	return strings.TrimSuffix(host, ".test")
+	for _, tld := range tlds {
+		if name, ok := strings.CutSuffix(host, "."+tld); ok {
+			return name
+		}
+	}
+	return host
 }
 
-// LookupByHost extracts the route name from a host string and looks it up.
+// LookupByHost extracts the route name from a host string and looks it
+// up, matching Subdomains routes by suffix.
 func (r *RouteRegistry) LookupByHost(host string) (Route, bool) {
-	return r.Lookup(ExtractName(host))
+	route, _, ok := r.LookupSubdomain(ExtractName(host))
+	return route, ok
 }
 
 func (r *RouteRegistry) Heartbeat(name string) error {
+	return r.heartbeatAs(name, nil)
+}
+
+// HeartbeatOwned is Heartbeat for a caller that must own the route.
+func (r *RouteRegistry) HeartbeatOwned(name string, caller Caller) error {
+	return r.heartbeatAs(name, &caller)
+}
+
+// heartbeatAs implements Heartbeat. A nil caller skips the ownership
+// check.
+func (r *RouteRegistry) heartbeatAs(name string, caller *Caller) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	route, ok := r.routes[name]
 	if !ok {
+		if _, gone := r.expired[name]; gone {
+			return ErrIdleExpired
+		}
 		return fmt.Errorf("route %q not found", name)
 	}
+	if caller != nil {
+		if err := route.allows(*caller); err != nil {
+			return err
+		}
+	}
 
 	route.LastHeartbeat = time.Now()
 	return nil
 }
 
-// Cleanup removes routes whose heartbeat has expired. It uses a read-lock
-// to scan for expired routes, then upgrades to a write-lock only if
-// deletions are needed, reducing contention on the hot path.
+// Touch records that a request was proxied to the route, resetting its
+// idle timer. Only routes with an IdleTimeout are updated, so routes
+// without one never take the write lock on the request path.
+func (r *RouteRegistry) Touch(route Route) {
+	if route.IdleTimeout == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.routes[route.Name]; ok {
+		existing.LastRequest = time.Now()
+	}
+}
+
+// SetTimeout changes how long a route may go without a heartbeat before
+// Cleanup removes it.
+func (r *RouteRegistry) SetTimeout(timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timeout = timeout
+}
+
+// SweepResult counts what a Sweep removed.
+type SweepResult struct {
+	// Expired routes missed their heartbeat deadline.
+	Expired int `json:"expired"`
+	// IdleExpired routes (previews) served no requests for their idle timeout.
+	IdleExpired int `json:"idleExpired"`
+	// Tombstones are idle-expiry records kept past their retention.
+	Tombstones int `json:"tombstones"`
+	// Orphaned routes were bound to a process that has exited.
+	Orphaned int `json:"orphaned"`
+}
+
+// Cleanup removes routes whose heartbeat has expired or whose bound
+// process has exited.
 func (r *RouteRegistry) Cleanup() {
+	r.Sweep()
+}
+
+// Sweep removes expired and orphaned routes and stale tombstones,
+// reporting what was removed. It uses a read-lock to scan for expired
+// routes, then upgrades to a write-lock only if deletions are needed,
+// reducing contention on the hot path.
+func (r *RouteRegistry) Sweep() SweepResult {
+	var result SweepResult
+	now := time.Now()
 	r.mu.RLock()
-	cutoff := time.Now().Add(-r.timeout)
 	var expired []string
 	for name, route := range r.routes {
-		if route.LastHeartbeat.Before(cutoff) {
+		if route.heartbeatExpired(now, r.timeout) || route.idleExpired(now) || route.ownerExited() {
 			expired = append(expired, name)
 		}
 	}
+	prune := len(r.expired) > 0 || len(r.churn) > 0
 	r.mu.RUnlock()
 
-	if len(expired) == 0 {
-		return
+	if len(expired) == 0 && !prune {
+		return result
 	}
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	for name, at := range r.expired {
+		if now.Sub(at) > expiredRetention {
+			delete(r.expired, name)
+			result.Tombstones++
+		}
+	}
+	for name, h := range r.churn {
+		if !h.prune(now) {
+			delete(r.churn, name)
+		}
+	}
 	for _, name := range expired {
-		// Re-check under write lock in case a heartbeat arrived between
-		// releasing the read lock and acquiring the write lock.
-		if route, ok := r.routes[name]; ok && route.LastHeartbeat.Before(cutoff) {
+		// Re-check under write lock in case a heartbeat or request arrived
+		// between releasing the read lock and acquiring the write lock.
+		route, ok := r.routes[name]
+		if !ok {
+			continue
+		}
+		switch {
+		case route.heartbeatExpired(now, r.timeout):
+			delete(r.routes, name)
+			result.Expired++
+			r.debug("route expired", "route", name, "last_heartbeat", route.LastHeartbeat)
+			r.recordChurn(name, true, now)
+			r.publish(EventExpired, route)
+		case route.idleExpired(now):
 			delete(r.routes, name)
+			r.expired[name] = now
+			result.IdleExpired++
+			r.debug("route idle-expired", "route", name, "idle_since", route.idleSince())
+			r.publish(EventIdleExpired, route)
+		case route.ownerExited():
+			delete(r.routes, name)
+			result.Orphaned++
+			r.debug("route expired: owner process exited", "route", name, "pid", route.Owner.PID)
+			r.publish(EventExpired, route)
 		}
 	}
+	return result
+}
+
+// heartbeatExpired reports whether the route missed its heartbeat deadline:
+// its own timeout if it has one, or the registry's. Permanent routes have
+// no deadline.
+func (route *Route) heartbeatExpired(now time.Time, timeout time.Duration) bool {
+	if route.Permanent {
+		return false
+	}
+	if route.HeartbeatTimeout > 0 {
+		timeout = route.HeartbeatTimeout
+	}
+	return now.Sub(route.LastHeartbeat) > timeout
 }
 
-// List returns copies of all registered routes.
+// validateHeartbeatTimeout parses a route's heartbeat timeout. An empty
+// timeout uses the daemon's.
+func validateHeartbeatTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid heartbeatTimeout: %w", err)
+	}
+	if timeout < minRouteHeartbeatTimeout || timeout > maxRouteHeartbeatTimeout {
+		return 0, fmt.Errorf("heartbeatTimeout must be between %s and %s", minRouteHeartbeatTimeout, maxRouteHeartbeatTimeout)
+	}
+	return timeout, nil
+}
+
+// ownerExited reports whether the route is bound to its owner process and
+// that process is gone.
+func (route *Route) ownerExited() bool {
+	return route.BindProcess && route.Owner != nil && route.Owner.PID > 0 && !processAlive(route.Owner.PID)
+}
+
+// idleExpired reports whether the route has an idle timeout that elapsed
+// before now.
+func (route *Route) idleExpired(now time.Time) bool {
+	return route.IdleTimeout > 0 && now.Sub(route.idleSince()) > route.IdleTimeout
+}
+
+// List returns copies of all registered routes, with their recent churn.
 func (r *RouteRegistry) List() []Route {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	now := time.Now()
 	routes := make([]Route, 0, len(r.routes))
 	for _, route := range r.routes {
-		routes = append(routes, *route)
+		copied := *route
+		copied.Churn = r.churnOf(route.Name, now)
+		routes = append(routes, copied)
 	}
 	return routes
 }
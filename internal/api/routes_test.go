@@ -2,7 +2,11 @@
 package api
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -44,6 +48,20 @@ func TestRouteRegistry_ConflictFromSameDir(t *testing.T) {
 	}
 }
 
+func TestRouteRegistry_RejectsReservedName(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+
+	for _, name := range []string{"_paw", "_PAW"} {
+		err := r.RegisterRoute(Route{Name: name, Upstream: "localhost:3000", Dir: "/tmp"})
+		if _, ok := err.(*ReservedError); !ok {
+			t.Errorf("%s: expected ReservedError, got %v", name, err)
+		}
+	}
+	if err := r.Register("paw", "localhost:3000", "/tmp"); err != nil {
+		t.Errorf("expected paw to be registrable, got %v", err)
+	}
+}
+
 func TestRouteRegistry_ConflictFromDifferentDir(t *testing.T) {
 	r := NewRouteRegistry(30 * time.Second)
 
@@ -116,6 +134,34 @@ func TestRouteRegistry_LookupReturnsCopy(t *testing.T) {
 	}
 }
 
+func TestRouteRegistry_LookupSubdomain(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	if err := r.RegisterRoute(Route{Name: "shop", Upstream: "localhost:3000", Dir: "/tmp/shop", Subdomains: true}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+	r.Register("web.shop", "localhost:3001", "/tmp/web")
+	r.Register("blog", "localhost:3002", "/tmp/blog")
+
+	tests := []struct {
+		name, route, subdomain string
+		ok                     bool
+	}{
+		{"shop", "shop", "", true},
+		{"tenant1.shop", "shop", "tenant1", true},
+		{"a.b.shop", "shop", "a.b", true},
+		{"web.shop", "web.shop", "", true},
+		{"x.web.shop", "shop", "x.web", true},
+		{"tenant1.blog", "", "", false},
+		{"shopx", "", "", false},
+	}
+	for _, tt := range tests {
+		route, subdomain, ok := r.LookupSubdomain(tt.name)
+		if ok != tt.ok || route.Name != tt.route || subdomain != tt.subdomain {
+			t.Errorf("LookupSubdomain(%q) = %q, %q, %v; want %q, %q, %v", tt.name, route.Name, subdomain, ok, tt.route, tt.subdomain, tt.ok)
+		}
+	}
+}
+
 func TestRouteRegistry_ListReturnsCopies(t *testing.T) {
 	r := NewRouteRegistry(30 * time.Second)
 
@@ -228,6 +274,38 @@ func TestRouteRegistry_ConcurrentAccess(t *testing.T) {
 
 // TestCleanupDuringHeartbeat registers a route, starts cleanup, and simultaneously
 // sends heartbeats. The route should survive if heartbeats keep it recent.
+func TestRouteRegistry_PauseUntilRegisteredAgain(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	if err := r.Register("myapp", "localhost:3000", "/tmp"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if err := r.Pause("myapp"); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if route, _ := r.Lookup("myapp"); !route.Paused {
+		t.Fatal("route not paused")
+	}
+	// Heartbeats keep a paused route around; they don't resume it.
+	if err := r.Heartbeat("myapp"); err != nil {
+		t.Fatalf("Heartbeat: %v", err)
+	}
+	if route, _ := r.Lookup("myapp"); !route.Paused {
+		t.Fatal("heartbeat resumed the route")
+	}
+
+	if _, err := r.UpsertRoute(Route{Name: "myapp", Upstream: "localhost:3001", Dir: "/tmp"}, Caller{}); err != nil {
+		t.Fatalf("UpsertRoute: %v", err)
+	}
+	if route, _ := r.Lookup("myapp"); route.Paused {
+		t.Error("registering again didn't resume the route")
+	}
+
+	if err := r.Pause("missing"); err == nil {
+		t.Error("expected error for unknown route")
+	}
+}
+
 func TestCleanupDuringHeartbeat(t *testing.T) {
 	// Use a short timeout so cleanup would expire routes quickly
 	r := NewRouteRegistry(200 * time.Millisecond)
@@ -308,3 +386,114 @@ func TestRouteRegistry_MaxRoutesLimit(t *testing.T) {
 		t.Fatalf("expected limit %d, got %d", maxRoutes, limitErr.Limit)
 	}
 }
+
+func TestRouteRegistry_DebugLogsChanges(t *testing.T) {
+	var buf bytes.Buffer
+	registry := NewRouteRegistry(30 * time.Second)
+	registry.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	registry.Register("myapp", "localhost:3000", "/tmp/myapp")
+	registry.Deregister("myapp")
+
+	logs := buf.String()
+	for _, msg := range []string{"route registered", "route deregistered"} {
+		if !strings.Contains(logs, msg) {
+			t.Errorf("expected %q in debug logs, got %s", msg, logs)
+		}
+	}
+}
+
+func TestRouteRegistry_UpsertRoute(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	ch := r.Subscribe()
+	defer r.Unsubscribe(ch)
+
+	created, err := r.UpsertRoute(Route{Name: "myapp", Upstream: "localhost:3000", Dir: "/tmp/myapp"}, Caller{})
+	if err != nil || !created {
+		t.Fatalf("first UpsertRoute() = %v, %v; want created", created, err)
+	}
+	if ev := nextEvent(t, ch); ev.Type != EventAdded {
+		t.Errorf("first event = %+v, want %s", ev, EventAdded)
+	}
+	first, _ := r.Lookup("myapp")
+
+	created, err = r.UpsertRoute(Route{Name: "myapp", Upstream: "localhost:4000", Dir: "/tmp/myapp", Headers: map[string]string{"X-Env": "dev"}}, Caller{})
+	if err != nil || created {
+		t.Fatalf("same-dir UpsertRoute() = %v, %v; want updated", created, err)
+	}
+	if ev := nextEvent(t, ch); ev.Type != EventUpdated || ev.Upstream != "localhost:4000" {
+		t.Errorf("update event = %+v, want %s", ev, EventUpdated)
+	}
+	got, _ := r.Lookup("myapp")
+	if got.Upstream != "localhost:4000" || got.Headers["X-Env"] != "dev" {
+		t.Errorf("route not updated: %+v", got)
+	}
+	if !got.Registered.Equal(first.Registered) {
+		t.Errorf("Registered changed from %v to %v", first.Registered, got.Registered)
+	}
+
+	_, err = r.UpsertRoute(Route{Name: "myapp", Upstream: "localhost:5000", Dir: "/tmp/other"}, Caller{})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.ExistingDir != "/tmp/myapp" {
+		t.Fatalf("other-dir UpsertRoute() error = %v, want conflict", err)
+	}
+	if got, _ := r.Lookup("myapp"); got.Upstream != "localhost:4000" {
+		t.Errorf("conflicting upsert changed the route: %+v", got)
+	}
+
+	if _, err := r.UpsertRoute(Route{Name: "_PAW", Upstream: "localhost:3000", Dir: "/tmp"}, Caller{}); err == nil {
+		t.Error("expected reserved name to be rejected")
+	}
+}
+
+func TestRouteRegistry_UpsertRestartsIdleTimer(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	route := Route{Name: "pr-1.myapp", Upstream: "localhost:3000", Dir: "/tmp/myapp", Preview: "pr-1", IdleTimeout: time.Minute}
+	r.RegisterRoute(route)
+
+	r.mu.Lock()
+	r.routes[route.Name].Registered = time.Now().Add(-2 * time.Minute)
+	r.mu.Unlock()
+
+	if _, err := r.UpsertRoute(route, Caller{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Sweep(); got.IdleExpired != 0 {
+		t.Errorf("Sweep() = %+v, want the re-registered preview kept", got)
+	}
+}
+
+func TestSweep_RouteHeartbeatTimeout(t *testing.T) {
+	r := NewRouteRegistry(time.Minute)
+	r.Register("default", "localhost:3000", "/tmp/default")
+	r.RegisterRoute(Route{Name: "long", Upstream: "localhost:3001", Dir: "/tmp/long", HeartbeatTimeout: time.Hour})
+	r.RegisterRoute(Route{Name: "short", Upstream: "localhost:3002", Dir: "/tmp/short", HeartbeatTimeout: 15 * time.Second})
+
+	r.mu.Lock()
+	r.routes["default"].LastHeartbeat = time.Now().Add(-2 * time.Minute)
+	r.routes["long"].LastHeartbeat = time.Now().Add(-2 * time.Minute)
+	r.routes["short"].LastHeartbeat = time.Now().Add(-30 * time.Second)
+	r.mu.Unlock()
+
+	if got := r.Sweep(); got.Expired != 2 {
+		t.Errorf("Sweep() expired %d routes, want 2", got.Expired)
+	}
+	if route, ok := r.Lookup("long"); !ok {
+		t.Error("route with a long heartbeat timeout was removed")
+	} else if route.HeartbeatTimeoutSeconds != 3600 {
+		t.Errorf("HeartbeatTimeoutSeconds = %d, want 3600", route.HeartbeatTimeoutSeconds)
+	}
+}
+
+func TestValidateHeartbeatTimeout(t *testing.T) {
+	for _, s := range []string{"", "15s", "24h", "168h"} {
+		if _, err := validateHeartbeatTimeout(s); err != nil {
+			t.Errorf("validateHeartbeatTimeout(%q) = %v", s, err)
+		}
+	}
+	for _, s := range []string{"soon", "5s", "169h"} {
+		if _, err := validateHeartbeatTimeout(s); err == nil {
+			t.Errorf("validateHeartbeatTimeout(%q) accepted", s)
+		}
+	}
+}
@@ -4,6 +4,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -12,7 +13,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
-	"syscall"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -29,8 +32,27 @@ type Server struct {
 	socketPath string
 	registry   *RouteRegistry
 	server     *http.Server
+	listenerMu sync.Mutex
 	listener   net.Listener
 	startTime  time.Time
+	reload     func() error
+	restart    func() error
+	invalidate func() (int, error)
+	problems   func() []string
+	stats      func() map[string]RouteStats
+	pair       func() (string, error)
+	captures   *CaptureHub
+	// readOnly refuses requests that change routes, see SetReadOnly.
+	readOnly atomic.Bool
+	// shutdown is closed when Stop begins, ending GET /events streams
+	// that would otherwise hold shutdown open.
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+
+	// tcpServer and tcpListener serve the optional token-protected
+	// loopback TCP API (see ListenTCP).
+	tcpServer   *http.Server
+	tcpListener net.Listener
 }
 
 func NewServer(socketPath string, registry *RouteRegistry) *Server {
@@ -38,6 +60,7 @@ func NewServer(socketPath string, registry *RouteRegistry) *Server {
 		socketPath: socketPath,
 		registry:   registry,
 		startTime:  time.Now(),
+		shutdown:   make(chan struct{}),
 	}
 
 	// SECURITY: Per-endpoint rate limiters prevent runaway scripts from causing
@@ -47,47 +70,232 @@ func NewServer(socketPath string, registry *RouteRegistry) *Server {
 	routeDeleteLimiter := newRateLimiter(10)
 	routeListLimiter := newRateLimiter(50)
 	healthLimiter := newRateLimiter(100)
+	reloadLimiter := newRateLimiter(5)
+	gcLimiter := newRateLimiter(5)
+	invalidateLimiter := newRateLimiter(5)
+	pairLimiter := newRateLimiter(5)
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("POST /routes", rateLimit(routeRegLimiter, s.handleRegister))
-	mux.HandleFunc("DELETE /routes/{name}", rateLimit(routeDeleteLimiter, s.handleDeregister))
-	mux.HandleFunc("POST /routes/{name}/heartbeat", rateLimit(heartbeatLimiter, s.handleHeartbeat))
-	mux.HandleFunc("GET /routes", rateLimit(routeListLimiter, s.handleList))
-	mux.HandleFunc("GET /health", rateLimit(healthLimiter, s.handleHealth))
-
-	s.server = &http.Server{Handler: mux}
+	// Every endpoint is served under APIPrefix and, for clients written
+	// before versioning, at its unversioned path. Both share a limiter.
+	handle := func(method, path string, h http.HandlerFunc) {
+		mux.HandleFunc(method+" "+APIPrefix+path, h)
+		mux.HandleFunc(method+" "+path, h)
+	}
+	handle("POST", "/routes", rateLimit(routeRegLimiter, s.writable(s.handleRegister)))
+	handle("PUT", "/routes/{name}", rateLimit(routeRegLimiter, s.writable(s.handleUpsert)))
+	handle("DELETE", "/routes/{name}", rateLimit(routeDeleteLimiter, s.writable(s.handleDeregister)))
+	handle("DELETE", "/routes", rateLimit(routeDeleteLimiter, s.writable(s.handleDeregisterProject)))
+	handle("POST", "/routes/{name}/heartbeat", rateLimit(heartbeatLimiter, s.handleHeartbeat))
+	handle("POST", "/heartbeat", rateLimit(heartbeatLimiter, s.handleKeepAlive))
+	handle("POST", "/routes/{name}/pause", rateLimit(routeRegLimiter, s.writable(s.handlePause)))
+	handle("PUT", "/routes/{name}/auth", rateLimit(routeRegLimiter, s.writable(s.handleSetAuth)))
+	handle("PUT", "/routes/{name}/throttle", rateLimit(routeRegLimiter, s.writable(s.handleSetThrottle)))
+	handle("GET", "/routes", rateLimit(routeListLimiter, s.handleList))
+	handle("GET", "/events", rateLimit(routeListLimiter, s.handleEvents))
+	handle("GET", "/routes/{name}/capture", rateLimit(routeListLimiter, s.handleCapture))
+	handle("GET", "/health", rateLimit(healthLimiter, s.handleHealth))
+	handle("GET", "/stats", rateLimit(routeListLimiter, s.handleStats))
+	handle("POST", "/reload", rateLimit(reloadLimiter, s.handleReload))
+	handle("POST", "/restart", rateLimit(reloadLimiter, s.handleRestart))
+	handle("POST", "/gc", rateLimit(gcLimiter, s.handleGC))
+	handle("POST", "/certs/invalidate", rateLimit(invalidateLimiter, s.handleInvalidateCerts))
+	handle("POST", "/dashboard/pair", rateLimit(pairLimiter, s.handlePair))
+	mux.HandleFunc("GET "+APIPrefix+"/openapi.json", rateLimit(healthLimiter, handleOpenAPI))
+
+	s.server = &http.Server{Handler: mux, ConnContext: withPeerCred}
 
 	return s
 }
 
+// SetReloadFunc sets the function run by POST /reload to re-read the
+// daemon configuration.
+func (s *Server) SetReloadFunc(fn func() error) {
+	s.reload = fn
+}
+
+// SetRestartFunc sets the function run by POST /restart to restart the
+// daemon in place. It should only start the restart, since the response
+// is written after it returns.
+func (s *Server) SetRestartFunc(fn func() error) {
+	s.restart = fn
+}
+
+// SetListener makes Start serve l, e.g. the socket inherited from the
+// previous daemon process, instead of creating the socket file.
+func (s *Server) SetListener(l net.Listener) {
+	s.listenerMu.Lock()
+	defer s.listenerMu.Unlock()
+	s.listener = l
+}
+
+// Listener returns the unix socket listener, or nil before Start.
+func (s *Server) Listener() net.Listener {
+	s.listenerMu.Lock()
+	defer s.listenerMu.Unlock()
+	return s.listener
+}
+
+// SetInvalidateFunc sets the function run by POST /certs/invalidate to drop
+// cached certificates, returning how many were dropped.
+func (s *Server) SetInvalidateFunc(fn func() (int, error)) {
+	s.invalidate = fn
+}
+
+// SetHealthFunc sets the function GET /health calls to list conditions
+// that leave the daemon running but degraded.
+func (s *Server) SetHealthFunc(fn func() []string) {
+	s.problems = fn
+}
+
+// SetPairFunc sets the function POST /dashboard/pair calls to create a
+// one-time dashboard sign-in URL.
+func (s *Server) SetPairFunc(fn func() (string, error)) {
+	s.pair = fn
+}
+
+// SetReadOnly makes the API refuse requests that add, remove, or change
+// routes, e.g. for a demo machine or a route set managed from the config
+// file. Listing, health, events, and heartbeats for existing routes keep
+// working.
+func (s *Server) SetReadOnly(on bool) {
+	s.readOnly.Store(on)
+}
+
+// writable wraps a handler that changes routes so it answers 403 while
+// the API is read-only.
+func (s *Server) writable(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.readOnly.Load() {
+			jsonError(w, "the control API is read-only", http.StatusForbidden)
+			return
+		}
+		h(w, r)
+	}
+}
+
 func (s *Server) Start() error {
-	// Remove existing socket
-	os.Remove(s.socketPath)
-
-	// SECURITY: Set umask before creating socket so it is born with 0600
-	// permissions. This avoids the TOCTOU race between Listen and Chmod
-	// where another process could connect during the gap.
-	oldMask := syscall.Umask(0077)
-	var err error
-	s.listener, err = net.Listen("unix", s.socketPath)
-	syscall.Umask(oldMask)
-	if err != nil {
-		return err
+	s.listenerMu.Lock()
+	l := s.listener
+	if l == nil {
+		// Remove existing socket
+		os.Remove(s.socketPath)
+
+		var err error
+		l, err = listenSocket(s.socketPath)
+		if err != nil {
+			s.listenerMu.Unlock()
+			return err
+		}
+		s.listener = l
 	}
+	s.listenerMu.Unlock()
 
-	return s.server.Serve(s.listener)
+	return s.server.Serve(l)
 }
 
 func (s *Server) Stop() error {
+	// Shutdown waits for active requests, so end event streams first.
+	s.shutdownOnce.Do(func() { close(s.shutdown) })
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	return s.server.Shutdown(ctx)
+	var tcpErr error
+	if s.tcpServer != nil {
+		tcpErr = s.tcpServer.Shutdown(ctx)
+	}
+	return errors.Join(s.server.Shutdown(ctx), tcpErr)
 }
 
 type RegisterRequest struct {
-	Name     string `json:"name"`
-	Upstream string `json:"upstream"`
-	Dir      string `json:"dir"`
+	Name     string     `json:"name"`
+	Upstream string     `json:"upstream"`
+	Dir      string     `json:"dir"`
+	Auth     *RouteAuth `json:"auth,omitempty"`
+	// Headers are injected into every request proxied to the upstream.
+	Headers map[string]string `json:"headers,omitempty"`
+	// CORS enables CORS helper mode for the route.
+	CORS *CORSConfig `json:"cors,omitempty"`
+	// Preview labels the route as a preview environment (e.g. "pr-123").
+	Preview string `json:"preview,omitempty"`
+	// Project groups related routes, such as the services of one compose
+	// project, so they can be listed and removed together.
+	Project string `json:"project,omitempty"`
+	// IdleTimeout is a Go duration (e.g. "2h") after which a route that has
+	// served no requests is removed.
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+	// ProxyProtocol sends a PROXY protocol v2 header to the upstream.
+	ProxyProtocol bool `json:"proxyProtocol,omitempty"`
+	// TrustForwarded appends to incoming forwarding headers instead of
+	// replacing them.
+	TrustForwarded bool `json:"trustForwarded,omitempty"`
+	// PlainHTTP proxies plain HTTP requests for the route instead of
+	// redirecting them to HTTPS. HTTPS keeps working.
+	PlainHTTP bool `json:"plainHttp,omitempty"`
+	// Passthrough routes TLS connections by SNI straight to the upstream,
+	// which terminates TLS itself (e.g. Postgres with TLS).
+	Passthrough bool `json:"passthrough,omitempty"`
+	// UDP forwards datagrams to Upstream, a UDP address, instead of
+	// proxying HTTP. Clients find the port through DNS SRV records.
+	UDP bool `json:"udp,omitempty"`
+	// UDPPort fixes the loopback port UDP is forwarded from. Zero picks a
+	// free one.
+	UDPPort int `json:"udpPort,omitempty"`
+	// Mirror is a loopback host:port that also receives each request,
+	// fire-and-forget, e.g. a rewrite running next to the original.
+	Mirror string `json:"mirror,omitempty"`
+	// Rewrite strips a path prefix before forwarding and points redirects
+	// to the upstream's own origin back at the route.
+	Rewrite *RewriteConfig `json:"rewrite,omitempty"`
+	// Cookies forces Secure, the route's Domain, or SameSite=None on
+	// cookies the upstream sets.
+	Cookies *CookieConfig `json:"cookies,omitempty"`
+	// Pool tunes the keep-alive connections kept to the upstream.
+	Pool *PoolConfig `json:"pool,omitempty"`
+	// Balance spreads requests over more instances of the dev server.
+	Balance *BalanceConfig `json:"balance,omitempty"`
+	// Alerts warn when the route's request or error rate crosses a
+	// threshold, overriding the daemon's.
+	Alerts *AlertConfig `json:"alerts,omitempty"`
+	// Throttle slows the route's traffic down to simulate a slow network.
+	Throttle *ThrottleConfig `json:"throttle,omitempty"`
+	// Static serves a directory from the daemon instead of proxying.
+	// Upstream must be empty.
+	Static *StaticConfig `json:"static,omitempty"`
+	// SPA serves a single-page app's index page for page navigations the
+	// upstream answers with 404, so client-side routes load on refresh.
+	SPA *SPAConfig `json:"spa,omitempty"`
+	// Canonical redirects to the route's canonical URL: trailing slash
+	// added or stripped, host lowercased, www. removed.
+	Canonical *CanonicalConfig `json:"canonical,omitempty"`
+	// Maintenance takes the route down for maintenance windows on a
+	// cron schedule.
+	Maintenance *MaintenanceConfig `json:"maintenance,omitempty"`
+	// HSTS overrides the daemon's handling of the upstream's
+	// Strict-Transport-Security header: keep, strip, or rewrite.
+	HSTS string `json:"hsts,omitempty"`
+	// HostHeader picks the Host header sent upstream: preserve keeps the
+	// client's, upstream sends the upstream address, and custom:<host>
+	// sends a fixed one.
+	HostHeader string `json:"hostHeader,omitempty"`
+	// Subdomains also routes unregistered names under this one
+	// (tenant1.myapp.test) to it, with the subdomain in X-Paw-Subdomain.
+	Subdomains bool `json:"subdomains,omitempty"`
+	// HeartbeatTimeout is a Go duration (e.g. "24h") that overrides the
+	// daemon's heartbeat timeout for this route, for clients that
+	// heartbeat rarely or not at all.
+	HeartbeatTimeout string `json:"heartbeatTimeout,omitempty"`
+	// BindProcess ties the route to the registering process: the daemon
+	// removes it once that process has exited. Only applies over the unix
+	// socket, where the daemon can tell which process registered it.
+	BindProcess bool `json:"bindProcess,omitempty"`
+}
+
+// RegisterResponse is the body of a successful registration.
+type RegisterResponse struct {
+	// Token must be sent in the X-Paw-Route-Token header to heartbeat,
+	// update, or deregister the route.
+	Token string `json:"token"`
 }
 
 // validateRouteName ensures route names are safe for DNS, filesystem, and shell use
@@ -122,6 +330,46 @@ func validateUpstream(upstream string) error {
 	return nil
 }
 
+// maxRouteHeaders bounds the number of injected headers per route.
+const maxRouteHeaders = 32
+
+// headerNamePattern matches RFC 7230 header field names (token characters).
+var headerNamePattern = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]{1,128}$")
+
+// reservedHeaders may not be injected: they are owned by the proxy or the
+// transport and overriding them would break forwarding or framing.
+var reservedHeaders = map[string]bool{
+	"Host":              true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"X-Forwarded-For":   true,
+	"X-Forwarded-Host":  true,
+	"X-Forwarded-Proto": true,
+	SubdomainHeader:     true,
+}
+
+// ValidateHeaders ensures injected request headers are well-formed and do
+// not override proxy-controlled headers.
+func ValidateHeaders(headers map[string]string) error {
+	if len(headers) > maxRouteHeaders {
+		return fmt.Errorf("too many headers (max %d)", maxRouteHeaders)
+	}
+	for name, value := range headers {
+		if !headerNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if reservedHeaders[http.CanonicalHeaderKey(name)] {
+			return fmt.Errorf("header %q is managed by paw-proxy and cannot be set", name)
+		}
+		if len(value) > 1024 || strings.ContainsAny(value, "\r\n\x00") {
+			return fmt.Errorf("invalid value for header %q", name)
+		}
+	}
+	return nil
+}
+
 // validateDir ensures directory paths are absolute and don't contain traversal
 func validateDir(dir string) error {
 	if dir == "" {
@@ -152,54 +400,303 @@ func jsonError(w http.ResponseWriter, msg string, code int) {
 }
 
 func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	route, ok := decodeRoute(w, r)
+	if !ok {
+		return
+	}
+	if err := validateRouteName(route.Name); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateCanonicalName(route); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := newRouteToken()
+	if err != nil {
+		jsonError(w, "registration failed", http.StatusInternalServerError)
+		return
+	}
+	route.Token = token
+	route.Owner = peerCredOf(r)
+	if err := s.registry.RegisterRoute(route); err != nil {
+		writeRegisterError(w, err)
+		return
+	}
+
+	writeRegistered(w, http.StatusOK, token)
+}
+
+// handleUpsert registers the route named in the path, or updates it in
+// place when it is already registered from the same directory. This lets a
+// client that restarts (or crashed and is run again) take its route back
+// without deregistering it first.
+func (s *Server) handleUpsert(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := validateRouteName(name); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	route, ok := decodeRoute(w, r)
+	if !ok {
+		return
+	}
+	if route.Name != "" && route.Name != name {
+		jsonError(w, "name in body does not match path", http.StatusBadRequest)
+		return
+	}
+	route.Name = name
+	if err := validateCanonicalName(route); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The fresh token is only used if the route is created; an update
+	// keeps the token the caller presented. The caller's process becomes
+	// the route's owner either way.
+	caller := callerOf(r)
+	token, err := newRouteToken()
+	if err != nil {
+		jsonError(w, "registration failed", http.StatusInternalServerError)
+		return
+	}
+	route.Token = token
+	route.Owner = caller.Peer
+	created, err := s.registry.UpsertRoute(route, caller)
+	if err != nil {
+		writeRegisterError(w, err)
+		return
+	}
+
+	if created {
+		writeRegistered(w, http.StatusCreated, token)
+	} else {
+		writeRegistered(w, http.StatusOK, caller.Token)
+	}
+}
+
+// writeRegistered answers a successful registration with the route token.
+func writeRegistered(w http.ResponseWriter, code int, token string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(RegisterResponse{Token: token}); err != nil {
+		log.Printf("api: failed to encode register response: %v", err)
+	}
+}
+
+// decodeRoute reads and validates a RegisterRequest body, writing a 400
+// and reporting false if it is invalid. The name is left to the caller,
+// since PUT takes it from the path.
+func decodeRoute(w http.ResponseWriter, r *http.Request) (Route, bool) {
 	// Limit request body size
 	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
 
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		jsonError(w, "invalid request body", http.StatusBadRequest)
-		return
+		return Route{}, false
 	}
-
-	// Validate all inputs
-	if err := validateRouteName(req.Name); err != nil {
+	route, err := RouteFromRequest(req)
+	if err != nil {
 		jsonError(w, err.Error(), http.StatusBadRequest)
+		return Route{}, false
+	}
+	if err := checkStaticOwner(req.Static, peerCredOf(r)); err != nil {
+		jsonError(w, err.Error(), http.StatusForbidden)
+		return Route{}, false
+	}
+	return route, true
+}
+
+// RouteFromRequest validates req and builds the route it asks for. The
+// name is not checked, since PUT takes it from the path.
+func RouteFromRequest(req RegisterRequest) (Route, error) {
+	if req.Static == nil {
+		if err := validateUpstream(req.Upstream); err != nil {
+			return Route{}, err
+		}
+	}
+	if err := validateStatic(req); err != nil {
+		return Route{}, err
+	}
+	if err := validateDir(req.Dir); err != nil {
+		return Route{}, err
+	}
+	if err := validateAuth(req.Auth); err != nil {
+		return Route{}, err
+	}
+	if err := ValidateHeaders(req.Headers); err != nil {
+		return Route{}, err
+	}
+	if err := validateCORS(req.CORS); err != nil {
+		return Route{}, err
+	}
+	if err := validateRewrite(req.Rewrite); err != nil {
+		return Route{}, err
+	}
+	if err := validatePool(req.Pool); err != nil {
+		return Route{}, err
+	}
+	if err := validateBalance(req.Balance); err != nil {
+		return Route{}, err
+	}
+	if err := ValidateAlerts(req.Alerts); err != nil {
+		return Route{}, err
+	}
+	if err := ValidateSPA(req.SPA); err != nil {
+		return Route{}, err
+	}
+	if err := ValidateCanonical(req.Canonical); err != nil {
+		return Route{}, err
+	}
+	if err := ValidateMaintenance(req.Maintenance); err != nil {
+		return Route{}, err
+	}
+	throttle, err := normalizeThrottle(req.Throttle)
+	if err != nil {
+		return Route{}, err
+	}
+	if err := validateProject(req.Project); err != nil {
+		return Route{}, err
+	}
+	if err := ValidateHSTS(req.HSTS); err != nil {
+		return Route{}, err
+	}
+	if err := ValidateHostHeader(req.HostHeader); err != nil {
+		return Route{}, err
+	}
+	if req.Mirror != "" {
+		if err := validateUpstream(req.Mirror); err != nil {
+			return Route{}, fmt.Errorf("mirror: %w", err)
+		}
+	}
+	if err := validatePassthrough(req); err != nil {
+		return Route{}, err
+	}
+	if err := validateUDP(req); err != nil {
+		return Route{}, err
+	}
+	idleTimeout, err := validatePreview(req.Preview, req.IdleTimeout)
+	if err != nil {
+		return Route{}, err
+	}
+	heartbeatTimeout, err := validateHeartbeatTimeout(req.HeartbeatTimeout)
+	if err != nil {
+		return Route{}, err
+	}
+
+	return Route{
+		Name:             req.Name,
+		Upstream:         req.Upstream,
+		Dir:              req.Dir,
+		Auth:             req.Auth,
+		Headers:          req.Headers,
+		CORS:             req.CORS,
+		Preview:          req.Preview,
+		Project:          req.Project,
+		IdleTimeout:      idleTimeout,
+		HeartbeatTimeout: heartbeatTimeout,
+		ProxyProtocol:    req.ProxyProtocol,
+		TrustForwarded:   req.TrustForwarded,
+		PlainHTTP:        req.PlainHTTP,
+		Passthrough:      req.Passthrough,
+		UDP:              req.UDP,
+		UDPPort:          req.UDPPort,
+		Mirror:           req.Mirror,
+		Rewrite:          req.Rewrite,
+		Cookies:          req.Cookies,
+		Pool:             req.Pool,
+		Balance:          req.Balance,
+		Alerts:           req.Alerts,
+		Throttle:         throttle,
+		Static:           req.Static,
+		SPA:              req.SPA,
+		Canonical:        req.Canonical,
+		Maintenance:      req.Maintenance,
+		HSTS:             req.HSTS,
+		HostHeader:       req.HostHeader,
+		Subdomains:       req.Subdomains,
+		BindProcess:      req.BindProcess,
+	}, nil
+}
+
+// writeRegisterError maps a registry error from registering a route to
+// its response.
+func writeRegisterError(w http.ResponseWriter, err error) {
+	if conflict, ok := err.(*ConflictError); ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		if encErr := json.NewEncoder(w).Encode(map[string]string{
+			"error":       "conflict",
+			"existingDir": conflict.ExistingDir,
+		}); encErr != nil {
+			log.Printf("api: failed to encode conflict response: %v", encErr)
+		}
+		return
+	}
+	if reserved, ok := err.(*ReservedError); ok {
+		jsonError(w, reserved.Error(), http.StatusBadRequest)
 		return
 	}
-	if err := validateUpstream(req.Upstream); err != nil {
+	if errors.Is(err, ErrNotOwner) || errors.Is(err, ErrOtherUser) || errors.Is(err, ErrPermanent) {
+		jsonError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if limit, ok := err.(*LimitError); ok {
+		jsonError(w, fmt.Sprintf("route limit reached (%d)", limit.Limit), http.StatusTooManyRequests)
+		return
+	}
+	jsonError(w, "registration failed", http.StatusInternalServerError)
+}
+
+func (s *Server) handleDeregister(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	if err := validateRouteName(name); err != nil {
 		jsonError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if err := validateDir(req.Dir); err != nil {
+
+	removed, err := s.registry.DeregisterOwned(name, callerOf(r))
+	switch {
+	case err != nil:
+		jsonError(w, err.Error(), http.StatusForbidden)
+	case removed:
+		w.WriteHeader(http.StatusOK)
+	default:
+		jsonError(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	if err := validateRouteName(name); err != nil {
 		jsonError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	err := s.registry.Register(req.Name, req.Upstream, req.Dir)
-	if err != nil {
-		if conflict, ok := err.(*ConflictError); ok {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusConflict)
-			if encErr := json.NewEncoder(w).Encode(map[string]string{
-				"error":       "conflict",
-				"existingDir": conflict.ExistingDir,
-			}); encErr != nil {
-				log.Printf("api: failed to encode conflict response: %v", encErr)
-			}
+	if err := s.registry.HeartbeatOwned(name, callerOf(r)); err != nil {
+		if errors.Is(err, ErrIdleExpired) {
+			jsonError(w, err.Error(), http.StatusGone)
 			return
 		}
-		if limit, ok := err.(*LimitError); ok {
-			jsonError(w, fmt.Sprintf("route limit reached (%d)", limit.Limit), http.StatusTooManyRequests)
+		if errors.Is(err, ErrNotOwner) || errors.Is(err, ErrOtherUser) || errors.Is(err, ErrPermanent) {
+			jsonError(w, err.Error(), http.StatusForbidden)
 			return
 		}
-		jsonError(w, "registration failed", http.StatusInternalServerError)
+		jsonError(w, "not found", http.StatusNotFound)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
-func (s *Server) handleDeregister(w http.ResponseWriter, r *http.Request) {
+// handlePause serves the waiting page for a route until it registers
+// again, so a dev server being stopped doesn't show connection errors.
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
 	name := r.PathValue("name")
 
 	if err := validateRouteName(name); err != nil {
@@ -207,14 +704,57 @@ func (s *Server) handleDeregister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if s.registry.Deregister(name) {
-		w.WriteHeader(http.StatusOK)
-	} else {
+	if err := s.registry.PauseOwned(name, callerOf(r)); err != nil {
+		if errors.Is(err, ErrNotOwner) || errors.Is(err, ErrOtherUser) || errors.Is(err, ErrPermanent) {
+			jsonError(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		jsonError(w, "not found", http.StatusNotFound)
+		return
 	}
+
+	w.WriteHeader(http.StatusOK)
 }
 
-func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+// handleSetAuth replaces a route's credentials. An empty JSON object or
+// null body clears them.
+func (s *Server) handleSetAuth(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	if err := validateRouteName(name); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+	var auth *RouteAuth
+	if err := json.NewDecoder(r.Body).Decode(&auth); err != nil {
+		jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if auth != nil && *auth == (RouteAuth{}) {
+		auth = nil
+	}
+	if err := validateAuth(auth); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.registry.SetAuthOwned(name, callerOf(r), auth); err != nil {
+		if errors.Is(err, ErrNotOwner) || errors.Is(err, ErrOtherUser) || errors.Is(err, ErrPermanent) {
+			jsonError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		jsonError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleSetThrottle sets or, given null or an empty object, removes a
+// route's throttle.
+func (s *Server) handleSetThrottle(w http.ResponseWriter, r *http.Request) {
 	name := r.PathValue("name")
 
 	if err := validateRouteName(name); err != nil {
@@ -222,7 +762,22 @@ func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.registry.Heartbeat(name); err != nil {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+	var throttle *ThrottleConfig
+	if err := json.NewDecoder(r.Body).Decode(&throttle); err != nil {
+		jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if _, err := normalizeThrottle(throttle); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.registry.SetThrottleOwned(name, callerOf(r), throttle); err != nil {
+		if errors.Is(err, ErrNotOwner) || errors.Is(err, ErrOtherUser) || errors.Is(err, ErrPermanent) {
+			jsonError(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		jsonError(w, "not found", http.StatusNotFound)
 		return
 	}
@@ -240,12 +795,101 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	uptime := time.Since(s.startTime)
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+	resp := map[string]interface{}{
 		"status":  "ok",
 		"version": Version,
 		"uptime":  uptime.String(),
-	}); err != nil {
+	}
+	if s.readOnly.Load() {
+		resp["readOnly"] = true
+	}
+	if s.problems != nil {
+		if problems := s.problems(); len(problems) > 0 {
+			resp["status"] = "degraded"
+			resp["problems"] = problems
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		log.Printf("api: failed to encode health response: %v", err)
 	}
 }
+
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if s.reload == nil {
+		jsonError(w, "reload not supported", http.StatusNotImplemented)
+		return
+	}
+	if err := s.reload(); err != nil {
+		jsonError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "reloaded"}); err != nil {
+		log.Printf("api: failed to encode reload response: %v", err)
+	}
+}
+
+// handleRestart starts an in-place restart: the daemon hands its sockets
+// and routes to a fresh copy of its binary, e.g. after an upgrade.
+func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request) {
+	if s.restart == nil {
+		jsonError(w, "restart not supported", http.StatusNotImplemented)
+		return
+	}
+	if err := s.restart(); err != nil {
+		jsonError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "restarting"}); err != nil {
+		log.Printf("api: failed to encode restart response: %v", err)
+	}
+}
+
+// handleInvalidateCerts drops the daemon's cached certificates so they are
+// issued again, e.g. after the CA was rotated.
+func (s *Server) handleInvalidateCerts(w http.ResponseWriter, r *http.Request) {
+	if s.invalidate == nil {
+		jsonError(w, "certificate invalidation not supported", http.StatusNotImplemented)
+		return
+	}
+	n, err := s.invalidate()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]int{"invalidated": n}); err != nil {
+		log.Printf("api: failed to encode invalidate response: %v", err)
+	}
+}
+
+// handlePair returns a one-time URL that signs a browser in to the
+// dashboard. Only callers of the control API can obtain one.
+func (s *Server) handlePair(w http.ResponseWriter, r *http.Request) {
+	if s.pair == nil {
+		jsonError(w, "dashboard pairing not supported", http.StatusNotImplemented)
+		return
+	}
+	pairURL, err := s.pair()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"url": pairURL}); err != nil {
+		log.Printf("api: failed to encode pair response: %v", err)
+	}
+}
+
+// handleGC sweeps expired routes and stale tombstones immediately instead
+// of waiting for the daemon's cleanup tick.
+func (s *Server) handleGC(w http.ResponseWriter, r *http.Request) {
+	result := s.registry.Sweep()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("api: failed to encode gc response: %v", err)
+	}
+}
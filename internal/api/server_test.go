@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -218,6 +219,32 @@ func TestValidateDir(t *testing.T) {
 	}
 }
 
+func TestValidateHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   map[string]string
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"custom", map[string]string{"X-Team": "core"}, false},
+		{"empty value", map[string]string{"X-Flag": ""}, false},
+
+		{"reserved host", map[string]string{"host": "evil.test"}, true},
+		{"reserved forwarded", map[string]string{"X-Forwarded-For": "1.2.3.4"}, true},
+		{"space in name", map[string]string{"X Team": "core"}, true},
+		{"crlf in value", map[string]string{"X-Team": "a\r\nX-Injected: 1"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateHeaders(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateHeaders(%v) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestAPIServer_ValidationRejection(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -482,3 +509,280 @@ func TestAPIServer_RequestBodyLimit(t *testing.T) {
 		t.Errorf("expected 400 for oversized body, got %d", resp.StatusCode)
 	}
 }
+
+func TestExtractNameFor(t *testing.T) {
+	tlds := []string{"test", "localhost"}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"myapp.test", "myapp"},
+		{"myapp.localhost:443", "myapp"},
+		{"api.shop.localhost", "api.shop"},
+		{"myapp.example", "myapp.example"},
+	}
+	for _, tt := range tests {
+		if got := ExtractNameFor(tt.input, tlds); got != tt.want {
+			t.Errorf("ExtractNameFor(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestHandleReload(t *testing.T) {
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), NewRouteRegistry(30*time.Second))
+
+	w := httptest.NewRecorder()
+	srv.handleReload(w, httptest.NewRequest("POST", "/reload", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("expected 501 without reload func, got %d", w.Code)
+	}
+
+	srv.SetReloadFunc(func() error { return fmt.Errorf("bad log_level") })
+	w = httptest.NewRecorder()
+	srv.handleReload(w, httptest.NewRequest("POST", "/reload", nil))
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "bad log_level") {
+		t.Errorf("expected 422 with error message, got %d %s", w.Code, w.Body.String())
+	}
+
+	srv.SetReloadFunc(func() error { return nil })
+	w = httptest.NewRecorder()
+	srv.handleReload(w, httptest.NewRequest("POST", "/reload", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestHandleInvalidateCerts(t *testing.T) {
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), NewRouteRegistry(30*time.Second))
+
+	w := httptest.NewRecorder()
+	srv.handleInvalidateCerts(w, httptest.NewRequest("POST", "/certs/invalidate", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("expected 501 without invalidate func, got %d", w.Code)
+	}
+
+	srv.SetInvalidateFunc(func() (int, error) { return 0, fmt.Errorf("loading CA: no such file") })
+	w = httptest.NewRecorder()
+	srv.handleInvalidateCerts(w, httptest.NewRequest("POST", "/certs/invalidate", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "loading CA") {
+		t.Errorf("expected 500 with error message, got %d %s", w.Code, w.Body.String())
+	}
+
+	srv.SetInvalidateFunc(func() (int, error) { return 3, nil })
+	w = httptest.NewRecorder()
+	srv.handleInvalidateCerts(w, httptest.NewRequest("POST", "/certs/invalidate", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"invalidated":3`) {
+		t.Errorf("expected 200 with count, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleRegister_ProxyProtocol(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+
+	body := `{"name":"myapp","upstream":"localhost:3000","dir":"/path/to/project","proxyProtocol":true}`
+	w := httptest.NewRecorder()
+	srv.handleRegister(w, httptest.NewRequest("POST", "/routes", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	route, ok := registry.Lookup("myapp")
+	if !ok || !route.ProxyProtocol {
+		t.Errorf("expected route with ProxyProtocol, got %+v", route)
+	}
+}
+
+func TestHandleRegister_TrustForwarded(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+
+	body := `{"name":"myapp","upstream":"localhost:3000","dir":"/path/to/project","trustForwarded":true}`
+	w := httptest.NewRecorder()
+	srv.handleRegister(w, httptest.NewRequest("POST", "/routes", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	route, ok := registry.Lookup("myapp")
+	if !ok || !route.TrustForwarded {
+		t.Errorf("expected route with TrustForwarded, got %+v", route)
+	}
+}
+
+func TestHandleUpsert(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+
+	var token string
+	put := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("PUT", path, strings.NewReader(body))
+		req.Header.Set(RouteTokenHeader, token)
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := put("/v1/routes/myapp", `{"upstream":"localhost:3000","dir":"/path/to/project"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d %s", w.Code, w.Body.String())
+	}
+	var reg RegisterResponse
+	if err := json.NewDecoder(w.Body).Decode(&reg); err != nil || reg.Token == "" {
+		t.Fatalf("create: expected a route token, got %q (%v)", reg.Token, err)
+	}
+
+	if w := put("/v1/routes/myapp", `{"upstream":"localhost:4000","dir":"/path/to/project"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("update without token: expected 403, got %d %s", w.Code, w.Body.String())
+	}
+	token = reg.Token
+	if w := put("/v1/routes/myapp", `{"name":"myapp","upstream":"localhost:4000","dir":"/path/to/project"}`); w.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	if route, _ := registry.Lookup("myapp"); route.Upstream != "localhost:4000" {
+		t.Errorf("expected upstream updated, got %+v", route)
+	}
+
+	w = put("/routes/myapp", `{"upstream":"localhost:5000","dir":"/other/project"}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"existingDir":"/path/to/project"`) {
+		t.Errorf("other dir: expected 409 with existingDir, got %d %s", w.Code, w.Body.String())
+	}
+	if w := put("/v1/routes/myapp", `{"name":"other","upstream":"localhost:3000","dir":"/path/to/project"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("mismatched name: expected 400, got %d", w.Code)
+	}
+	if w := put("/v1/routes/myapp", `{"upstream":"example.com:80","dir":"/path/to/project"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("remote upstream: expected 400, got %d", w.Code)
+	}
+	if w := put("/v1/routes/myapp", `{"upstream":"localhost:4000","dir":"/path/to/project","mirror":"example.com:80"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("remote mirror: expected 400, got %d", w.Code)
+	}
+	if w := put("/v1/routes/myapp", `{"upstream":"localhost:4000","dir":"/path/to/project","rewrite":{"stripPrefix":"api"}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("relative stripPrefix: expected 400, got %d", w.Code)
+	}
+}
+
+func TestHandlePair(t *testing.T) {
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), NewRouteRegistry(30*time.Second))
+
+	w := httptest.NewRecorder()
+	srv.handlePair(w, httptest.NewRequest("POST", "/dashboard/pair", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("expected 501 without a pair func, got %d", w.Code)
+	}
+
+	srv.SetPairFunc(func() (string, error) { return "https://_paw.test/pair?code=abc", nil })
+	w = httptest.NewRecorder()
+	srv.handlePair(w, httptest.NewRequest("POST", "/dashboard/pair", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"url":"https://_paw.test/pair?code=abc"`) {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestHandleHealthDegraded(t *testing.T) {
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), NewRouteRegistry(30*time.Second))
+
+	var problems []string
+	srv.SetHealthFunc(func() []string { return problems })
+
+	w := httptest.NewRecorder()
+	srv.handleHealth(w, httptest.NewRequest("GET", "/health", nil))
+	if !strings.Contains(w.Body.String(), `"status":"ok"`) || strings.Contains(w.Body.String(), "problems") {
+		t.Errorf("expected ok status without problems, got %s", w.Body.String())
+	}
+
+	problems = []string{"port 443 held by nginx (pid 123)"}
+	w = httptest.NewRecorder()
+	srv.handleHealth(w, httptest.NewRequest("GET", "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"status":"degraded"`) || !strings.Contains(w.Body.String(), "held by nginx") {
+		t.Errorf("expected degraded status with problem, got %s", w.Body.String())
+	}
+}
+
+func TestValidHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"myapp.test", true},
+		{"myapp.test:443", true},
+		{"_paw.test", true},
+		{"frontend.myapp.test.", true},
+		{"", false},
+		{strings.Repeat("a", 10*1024), false},
+		{strings.Repeat("a", 64) + ".test", false},
+		{strings.Repeat("a.", 127) + "test", false}, // 257 chars
+		{"my app.test", false},
+		{"myapp..test", false},
+		{"<script>.test", false},
+		{"myäpp.test", false},
+	}
+	for _, tt := range tests {
+		if got := ValidHost(tt.host); got != tt.want {
+			t.Errorf("ValidHost(%.40q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestExtractNameRejectsOversizedHost(t *testing.T) {
+	host := strings.Repeat("a", 10*1024) + ".test"
+	if got := ExtractName(host); got != "" {
+		t.Errorf("expected empty name for oversized host, got %d bytes", len(got))
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+	var token string
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(RouteTokenHeader, token)
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+	w := do("POST", "/v1/routes", `{"name":"myapp","upstream":"localhost:3000","dir":"/path/to/project"}`)
+	var reg RegisterResponse
+	if err := json.NewDecoder(w.Body).Decode(&reg); err != nil || reg.Token == "" {
+		t.Fatalf("register: got %d %v", w.Code, err)
+	}
+	token = reg.Token
+	srv.SetReadOnly(true)
+
+	for _, tt := range []struct{ method, path, body string }{
+		{"POST", "/v1/routes", `{"name":"other","upstream":"localhost:4000","dir":"/path/to/project"}`},
+		{"PUT", "/v1/routes/myapp", `{"upstream":"localhost:4000","dir":"/path/to/project"}`},
+		{"DELETE", "/v1/routes/myapp", ""},
+		{"DELETE", "/routes?project=shop", ""},
+		{"POST", "/v1/routes/myapp/pause", `{"paused":true}`},
+		{"PUT", "/v1/routes/myapp/auth", `{"token":"secret"}`},
+		{"PUT", "/v1/routes/myapp/throttle", `{"preset":"3g"}`},
+	} {
+		w := do(tt.method, tt.path, tt.body)
+		if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "read-only") {
+			t.Errorf("%s %s: expected 403 read-only, got %d %s", tt.method, tt.path, w.Code, w.Body.String())
+		}
+	}
+	if route, ok := registry.Lookup("myapp"); !ok || route.Upstream != "localhost:3000" {
+		t.Errorf("route changed while read-only: %+v", route)
+	}
+
+	if w := do("GET", "/v1/routes", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "myapp") {
+		t.Errorf("list: expected 200 with the route, got %d %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/v1/routes/myapp/heartbeat", ""); w.Code != http.StatusOK {
+		t.Errorf("heartbeat: expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	if w := do("GET", "/v1/health", ""); !strings.Contains(w.Body.String(), `"readOnly":true`) {
+		t.Errorf("health: expected readOnly, got %s", w.Body.String())
+	}
+
+	srv.SetReadOnly(false)
+	if w := do("DELETE", "/v1/routes/myapp", ""); w.Code != http.StatusOK && w.Code != http.StatusNoContent {
+		t.Errorf("delete after read-only: got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,81 @@
+// internal/api/snapshot.go
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// savedRoute is a Route along with the fields the API never returns, so a
+// restarted daemon gets back exactly what was registered.
+type savedRoute struct {
+	Route
+	AuthSecret       *RouteAuth    `json:"authSecret,omitempty"`
+	OwnerToken       string        `json:"ownerToken,omitempty"`
+	HeartbeatTimeout time.Duration `json:"heartbeatTimeoutNs,omitempty"`
+	IdleTimeout      time.Duration `json:"idleTimeoutNs,omitempty"`
+}
+
+// registrySnapshot is the registry state handed to a daemon restarting in
+// place.
+type registrySnapshot struct {
+	Routes []savedRoute `json:"routes"`
+	// Expired keeps idle-expired names answering 410, so their `up`
+	// still stops instead of registering again.
+	Expired map[string]time.Time `json:"expired,omitempty"`
+}
+
+// Snapshot serializes the registered routes, credentials and tokens
+// included. It is only for handing the registry to a new daemon process,
+// never for the API.
+func (r *RouteRegistry) Snapshot() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snap := registrySnapshot{Expired: r.expired}
+	for _, route := range r.routes {
+		if route.Permanent {
+			// The new process registers them from its config file.
+			continue
+		}
+		saved := savedRoute{
+			Route:            *route,
+			AuthSecret:       route.Auth,
+			OwnerToken:       route.Token,
+			HeartbeatTimeout: route.HeartbeatTimeout,
+			IdleTimeout:      route.IdleTimeout,
+		}
+		saved.Churn = nil
+		snap.Routes = append(snap.Routes, saved)
+	}
+	return json.Marshal(snap)
+}
+
+// Restore adds the routes from a Snapshot, keeping their registration
+// and heartbeat times, and returns how many there were. No events are
+// published: to subscribers the routes were never gone. Routes already
+// registered from the config file win over restored ones.
+func (r *RouteRegistry) Restore(data []byte) (int, error) {
+	var snap registrySnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, saved := range snap.Routes {
+		if existing, ok := r.routes[saved.Name]; ok && existing.Permanent {
+			continue
+		}
+		route := saved.Route
+		route.setAuth(saved.AuthSecret)
+		route.Token = saved.OwnerToken
+		route.HeartbeatTimeout = saved.HeartbeatTimeout
+		route.IdleTimeout = saved.IdleTimeout
+		r.routes[route.Name] = &route
+	}
+	for name, at := range snap.Expired {
+		r.expired[name] = at
+	}
+	return len(snap.Routes), nil
+}
@@ -0,0 +1,66 @@
+// internal/api/snapshot_test.go
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRouteRegistry_SnapshotRestore(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	route := Route{
+		Name:             "shop",
+		Upstream:         "localhost:3000",
+		Dir:              "/src/shop",
+		Auth:             &RouteAuth{Username: "dev", Password: "secret"},
+		Token:            "owner-token",
+		Headers:          map[string]string{"X-Env": "dev"},
+		HeartbeatTimeout: time.Minute,
+		IdleTimeout:      2 * time.Hour,
+	}
+	if err := r.RegisterRoute(route); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+	r.mu.Lock()
+	r.expired["old-preview"] = time.Now()
+	r.mu.Unlock()
+	before, _ := r.Lookup("shop")
+
+	data, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	restored := NewRouteRegistry(30 * time.Second)
+	events := restored.Subscribe()
+	defer restored.Unsubscribe(events)
+	n, err := restored.Restore(data)
+	if err != nil || n != 1 {
+		t.Fatalf("Restore = %d, %v; want 1 route", n, err)
+	}
+
+	got, ok := restored.Lookup("shop")
+	if !ok {
+		t.Fatal("route not restored")
+	}
+	if got.Auth == nil || got.Auth.Password != "secret" || got.AuthMode != AuthModeBasic {
+		t.Errorf("auth = %+v (mode %q), want the basic credentials", got.Auth, got.AuthMode)
+	}
+	if got.Token != "owner-token" {
+		t.Errorf("token = %q, want owner-token", got.Token)
+	}
+	if got.HeartbeatTimeout != time.Minute || got.IdleTimeout != 2*time.Hour {
+		t.Errorf("timeouts = %s, %s; want 1m, 2h", got.HeartbeatTimeout, got.IdleTimeout)
+	}
+	if !got.Registered.Equal(before.Registered) || got.Headers["X-Env"] != "dev" {
+		t.Errorf("restored route = %+v, want %+v", got, before)
+	}
+	if err := restored.Heartbeat("old-preview"); err != ErrIdleExpired {
+		t.Errorf("heartbeat for idle-expired name = %v, want ErrIdleExpired", err)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("Restore published %+v", ev)
+	default:
+	}
+}
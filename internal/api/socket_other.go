@@ -0,0 +1,12 @@
+//go:build !darwin && !linux
+
+package api
+
+import "net"
+
+// listenSocket listens on the unix socket at path. There is no umask on
+// this platform; the socket inherits the ACL of its directory, which
+// lives under the user's profile.
+func listenSocket(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
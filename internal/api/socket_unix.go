@@ -0,0 +1,18 @@
+//go:build darwin || linux
+
+package api
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenSocket listens on the unix socket at path.
+func listenSocket(path string) (net.Listener, error) {
+	// SECURITY: Set umask before creating socket so it is born with 0600
+	// permissions. This avoids the TOCTOU race between Listen and Chmod
+	// where another process could connect during the gap.
+	oldMask := syscall.Umask(0077)
+	defer syscall.Umask(oldMask)
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultSPAIndex is the page served for client-side routes by default.
+const DefaultSPAIndex = "/index.html"
+
+// maxSPAStatuses bounds the statuses a route's SPA fallback replaces.
+const maxSPAStatuses = 8
+
+// SPAConfig makes a route serve a single-page app's index page for deep
+// links the upstream doesn't know, such as /settings/profile, so the
+// app's client-side router handles them. Dev servers usually do this
+// themselves, but not all do, and some answer 404 while they restart.
+type SPAConfig struct {
+	// Index is the upstream path served instead, DefaultSPAIndex if empty.
+	Index string `json:"index,omitempty"`
+	// Statuses are the upstream statuses that get the index page instead.
+	// Others pass through unchanged. Empty means 404 only.
+	Statuses []int `json:"statuses,omitempty"`
+	// KeepStatus serves the index page with the upstream's status rather
+	// than 200, for tests that check a missing page still reports 404.
+	KeepStatus bool `json:"keepStatus,omitempty"`
+}
+
+// IndexPath returns the path to serve instead of a missing page.
+func (c *SPAConfig) IndexPath() string {
+	if c.Index == "" {
+		return DefaultSPAIndex
+	}
+	return c.Index
+}
+
+// Replaces reports whether an upstream response with status gets the
+// index page instead.
+func (c *SPAConfig) Replaces(status int) bool {
+	if len(c.Statuses) == 0 {
+		return status == http.StatusNotFound
+	}
+	return slices.Contains(c.Statuses, status)
+}
+
+// Applies reports whether r may get the index page: a browser navigating
+// (GET or HEAD accepting HTML) to a path that doesn't look like a file.
+// Missing scripts, images, and API calls keep their errors.
+func (c *SPAConfig) Applies(r *http.Request) bool {
+	if c == nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		return false
+	}
+	p := r.URL.Path
+	return p != c.IndexPath() && !strings.Contains(path.Base(p), ".")
+}
+
+// ValidateSPA checks SPA fallback settings.
+func ValidateSPA(c *SPAConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.Index != "" && (!strings.HasPrefix(c.Index, "/") || strings.ContainsAny(c.Index, "?#") || path.Clean(c.Index) != c.Index) {
+		return fmt.Errorf("spa index must be a clean absolute path, e.g. %s", DefaultSPAIndex)
+	}
+	if len(c.Statuses) > maxSPAStatuses {
+		return fmt.Errorf("spa statuses: at most %d", maxSPAStatuses)
+	}
+	for _, s := range c.Statuses {
+		if s < 400 || s > 599 {
+			return fmt.Errorf("spa status %d: must be an error status (400-599)", s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateSPA(t *testing.T) {
+	valid := []*SPAConfig{
+		nil,
+		{},
+		{Index: "/app/index.html"},
+		{Statuses: []int{404, 410, 503}, KeepStatus: true},
+	}
+	for _, c := range valid {
+		if err := ValidateSPA(c); err != nil {
+			t.Errorf("ValidateSPA(%+v) = %v, want nil", c, err)
+		}
+	}
+	invalid := []*SPAConfig{
+		{Index: "index.html"},
+		{Index: "/index.html?x=1"},
+		{Index: "/a/../index.html"},
+		{Statuses: []int{200}},
+		{Statuses: []int{302}},
+		{Statuses: []int{404, 404, 404, 404, 404, 404, 404, 404, 404}},
+	}
+	for _, c := range invalid {
+		if err := ValidateSPA(c); err == nil {
+			t.Errorf("ValidateSPA(%+v) = nil, want error", c)
+		}
+	}
+}
+
+func TestSPAConfig_Applies(t *testing.T) {
+	spa := &SPAConfig{}
+	tests := []struct {
+		method, path, accept string
+		want                 bool
+	}{
+		{"GET", "/settings/profile", "text/html,*/*", true},
+		{"HEAD", "/about", "text/html", true},
+		{"GET", "/assets/app.js", "*/*", false},
+		{"GET", "/assets/app.js", "text/html", false},
+		{"GET", "/api/users", "application/json", false},
+		{"POST", "/settings", "text/html", false},
+		{"GET", "/index.html", "text/html", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "https://shop.test"+tt.path, nil)
+		r.Header.Set("Accept", tt.accept)
+		if got := spa.Applies(r); got != tt.want {
+			t.Errorf("Applies(%s %s, %q) = %v, want %v", tt.method, tt.path, tt.accept, got, tt.want)
+		}
+	}
+	var none *SPAConfig
+	if none.Applies(httptest.NewRequest("GET", "/x", nil)) {
+		t.Error("nil config should never apply")
+	}
+}
+
+func TestSPAConfig_Replaces(t *testing.T) {
+	if !(&SPAConfig{}).Replaces(404) || (&SPAConfig{}).Replaces(410) {
+		t.Error("default should replace only 404")
+	}
+	c := &SPAConfig{Statuses: []int{410, 503}}
+	if c.Replaces(404) || !c.Replaces(503) {
+		t.Error("listed statuses should replace 404's default")
+	}
+}
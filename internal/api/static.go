@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// StaticConfig makes the daemon serve a directory itself instead of
+// proxying to an upstream, e.g. a built site or generated docs.
+type StaticConfig struct {
+	// Root is the absolute path of the directory to serve.
+	Root string `json:"root"`
+	// AutoIndex lists the files of a directory that has no index.html,
+	// instead of answering 404.
+	AutoIndex bool `json:"autoIndex,omitempty"`
+}
+
+// errStaticOtherUser rejects a static route registered by another local
+// user: the daemon reads the files with its own user's permissions.
+var errStaticOtherUser = errors.New("static routes can only be registered by the user running the daemon")
+
+// errStaticNoPeer rejects a static route from a caller that can't be
+// identified, such as a client of the TCP API, which may be a process in
+// a container holding nothing but the token.
+var errStaticNoPeer = errors.New("static routes can only be registered over the unix socket")
+
+// validateStatic checks the static options of a registration. A static
+// route has no upstream, so options about reaching one don't apply.
+func validateStatic(req RegisterRequest) error {
+	if req.Static == nil {
+		return nil
+	}
+	if req.Upstream != "" {
+		return fmt.Errorf("static routes can't have an upstream")
+	}
+	root := req.Static.Root
+	if !filepath.IsAbs(root) || filepath.Clean(root) != root {
+		return fmt.Errorf("static root must be a clean absolute path")
+	}
+	info, err := os.Stat(root)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("static root %s is not a directory", root)
+	}
+	switch {
+	case req.Passthrough:
+		return fmt.Errorf("static routes can't use passthrough")
+	case req.UDP:
+		return fmt.Errorf("static routes can't use udp")
+	case req.ProxyProtocol:
+		return fmt.Errorf("static routes can't use proxyProtocol")
+	case req.Pool != nil:
+		return fmt.Errorf("static routes can't use pool")
+	case req.Balance != nil:
+		return fmt.Errorf("static routes can't use balance")
+	case req.Mirror != "":
+		return fmt.Errorf("static routes can't use mirror")
+	case req.HostHeader != "":
+		return fmt.Errorf("static routes can't use hostHeader")
+	}
+	return nil
+}
+
+// checkStaticOwner refuses a static route from a local process running as
+// a different user than the daemon, or from one that can't be identified,
+// which could otherwise read files through it that it has no access to.
+func checkStaticOwner(static *StaticConfig, peer *PeerCred) error {
+	switch {
+	case static == nil:
+		return nil
+	case peer == nil:
+		return errStaticNoPeer
+	case peer.UID != os.Getuid():
+		return errStaticOtherUser
+	}
+	return nil
+}
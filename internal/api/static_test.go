@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateStatic(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "file.txt")
+	os.WriteFile(file, nil, 0o644)
+
+	tests := []struct {
+		name    string
+		req     RegisterRequest
+		wantErr bool
+	}{
+		{"not static", RegisterRequest{Upstream: "localhost:3000"}, false},
+		{"static", RegisterRequest{Static: &StaticConfig{Root: root, AutoIndex: true}}, false},
+		{"with auth and headers", RegisterRequest{Static: &StaticConfig{Root: root}, Auth: &RouteAuth{Token: "t"}, Headers: map[string]string{"X-Env": "dev"}}, false},
+		{"with upstream", RegisterRequest{Upstream: "localhost:3000", Static: &StaticConfig{Root: root}}, true},
+		{"relative root", RegisterRequest{Static: &StaticConfig{Root: "dist"}}, true},
+		{"unclean root", RegisterRequest{Static: &StaticConfig{Root: root + "/../" + filepath.Base(root)}}, true},
+		{"missing root", RegisterRequest{Static: &StaticConfig{Root: filepath.Join(root, "missing")}}, true},
+		{"file root", RegisterRequest{Static: &StaticConfig{Root: file}}, true},
+		{"with passthrough", RegisterRequest{Static: &StaticConfig{Root: root}, Passthrough: true}, true},
+		{"with mirror", RegisterRequest{Static: &StaticConfig{Root: root}, Mirror: "localhost:3001"}, true},
+		{"with pool", RegisterRequest{Static: &StaticConfig{Root: root}, Pool: &PoolConfig{MaxIdle: 4}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateStatic(tt.req); (err != nil) != tt.wantErr {
+				t.Errorf("validateStatic() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckStaticOwner(t *testing.T) {
+	static := &StaticConfig{Root: "/srv"}
+	if err := checkStaticOwner(static, &PeerCred{UID: os.Getuid()}); err != nil {
+		t.Errorf("daemon's own user rejected: %v", err)
+	}
+	if err := checkStaticOwner(static, &PeerCred{UID: os.Getuid() + 1}); !errors.Is(err, errStaticOtherUser) {
+		t.Errorf("other user = %v, want errStaticOtherUser", err)
+	}
+	if err := checkStaticOwner(static, nil); !errors.Is(err, errStaticNoPeer) {
+		t.Errorf("unidentified caller = %v, want errStaticNoPeer", err)
+	}
+	if err := checkStaticOwner(nil, &PeerCred{UID: os.Getuid() + 1}); err != nil {
+		t.Errorf("proxied route rejected: %v", err)
+	}
+}
+
+func TestHandleRegister_Static(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+	root := t.TempDir()
+
+	body := fmt.Sprintf(`{"name":"docs","dir":"/path/to/project","static":{"root":%q,"autoIndex":true}}`, root)
+	w := httptest.NewRecorder()
+	srv.handleRegister(w, asDaemonUser(httptest.NewRequest("POST", "/routes", strings.NewReader(body))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	route, ok := registry.Lookup("docs")
+	if !ok || route.Static == nil || route.Static.Root != root || !route.Static.AutoIndex || route.Upstream != "" {
+		t.Errorf("expected static route for %s, got %+v", root, route)
+	}
+
+	// Without static, the upstream is still required.
+	w = httptest.NewRecorder()
+	srv.handleRegister(w, httptest.NewRequest("POST", "/routes", strings.NewReader(`{"name":"bare","dir":"/path/to/project"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("route without upstream or static: got %d, want 400", w.Code)
+	}
+}
+
+func TestHandleUpsert_StaticOverTCP(t *testing.T) {
+	registry := NewRouteRegistry(30 * time.Second)
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), registry)
+	handler := requireToken("secret", srv.server.Handler)
+
+	// A TCP API client holding the token has no peer credentials.
+	body := `{"dir":"/path/to/project","static":{"root":"` + t.TempDir() + `"}}`
+	req := httptest.NewRequest("PUT", "/v1/routes/docs", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("static route over TCP: got %d %s, want 403", w.Code, w.Body.String())
+	}
+	if _, ok := registry.Lookup("docs"); ok {
+		t.Error("static route registered over TCP")
+	}
+}
+
+// asDaemonUser marks r as sent over the unix socket by the daemon's user.
+func asDaemonUser(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), peerCredKey{}, &PeerCred{UID: os.Getuid(), PID: os.Getpid()}))
+}
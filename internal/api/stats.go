@@ -0,0 +1,36 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// RouteStats counts the requests a route has served since the daemon
+// started.
+type RouteStats struct {
+	Requests int64 `json:"requests"`
+	// Errors counts responses with a 5xx status.
+	Errors int64 `json:"errors"`
+	// AvgMs is the mean latency in milliseconds.
+	AvgMs int64 `json:"avgMs"`
+}
+
+// SetStatsFunc sets the function GET /stats calls for per-route request
+// counters, keyed by route name.
+func (s *Server) SetStatsFunc(fn func() map[string]RouteStats) {
+	s.stats = fn
+}
+
+// handleStats returns request counters for the routes that have served
+// any requests.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if s.stats == nil {
+		jsonError(w, "request stats not supported", http.StatusNotImplemented)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.stats()); err != nil {
+		log.Printf("api: failed to encode stats response: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleStats(t *testing.T) {
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), NewRouteRegistry(30*time.Second))
+
+	w := httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/stats", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("without a stats source: expected 501, got %d", w.Code)
+	}
+
+	srv.SetStatsFunc(func() map[string]RouteStats {
+		return map[string]RouteStats{"myapp": {Requests: 10, Errors: 2, AvgMs: 15}}
+	})
+	w = httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := strings.TrimSpace(w.Body.String()); got != `{"myapp":{"requests":10,"errors":2,"avgMs":15}}` {
+		t.Errorf("body = %s", got)
+	}
+}
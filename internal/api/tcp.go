@@ -0,0 +1,116 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// TokenFile is the name of the control API token in the support directory.
+const TokenFile = "api-token"
+
+// LoadOrCreateToken reads the control API token at path, generating a
+// random one on first use.
+func LoadOrCreateToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("reading API token: %w", err)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating API token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	// SECURITY: Owner-only; the token grants full control of routes.
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("writing API token: %w", err)
+	}
+	return token, nil
+}
+
+// ValidateLoopbackAddr checks that addr is host:port with a loopback IP.
+// SECURITY: The TCP control API must never be reachable from the network.
+func ValidateLoopbackAddr(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid API address %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("invalid API address %q: host must be a loopback IP", addr)
+	}
+	if port == "" || port == "0" {
+		return fmt.Errorf("invalid API address %q: port required", addr)
+	}
+	return nil
+}
+
+// requireToken rejects requests without "Authorization: Bearer <token>".
+func requireToken(token string, next http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// SECURITY: Constant-time compare so the token can't be guessed
+		// byte by byte from response timing.
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="paw-proxy"`)
+			jsonError(w, "invalid or missing API token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ListenTCP binds the control API to a loopback TCP address for clients
+// that can't reach the unix socket, such as processes in containers.
+// Every request must carry the token. Call ServeTCP to accept requests.
+// A listener given to SetTCPListener is served instead of binding addr.
+func (s *Server) ListenTCP(addr, token string) error {
+	if err := ValidateLoopbackAddr(addr); err != nil {
+		return err
+	}
+	if token == "" {
+		return errors.New("API token required for TCP listener")
+	}
+	if s.tcpListener == nil {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		s.tcpListener = listener
+	}
+	s.tcpServer = &http.Server{
+		Handler:           requireToken(token, s.server.Handler),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return nil
+}
+
+// SetTCPListener makes ListenTCP use l, e.g. the socket inherited from the
+// previous daemon process, instead of binding its address.
+func (s *Server) SetTCPListener(l net.Listener) {
+	s.tcpListener = l
+}
+
+// TCPListener returns the TCP API listener, or nil before ListenTCP.
+func (s *Server) TCPListener() net.Listener {
+	return s.tcpListener
+}
+
+// ServeTCP serves the listener bound by ListenTCP. It blocks like Start.
+func (s *Server) ServeTCP() error {
+	if s.tcpServer == nil {
+		return errors.New("TCP listener not configured")
+	}
+	return s.tcpServer.Serve(s.tcpListener)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadOrCreateToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), TokenFile)
+
+	token, err := LoadOrCreateToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 64 {
+		t.Errorf("expected 64 hex chars, got %q", token)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("token file mode = %o, want 600", perm)
+	}
+
+	again, err := LoadOrCreateToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != token {
+		t.Errorf("token changed between loads: %q != %q", again, token)
+	}
+}
+
+func TestValidateLoopbackAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		ok   bool
+	}{
+		{"127.0.0.1:2019", true},
+		{"[::1]:2019", true},
+		{"0.0.0.0:2019", false},
+		{"192.168.1.10:2019", false},
+		{"localhost:2019", false},
+		{"127.0.0.1:0", false},
+		{"127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		err := ValidateLoopbackAddr(tt.addr)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateLoopbackAddr(%q) err = %v, want ok=%v", tt.addr, err, tt.ok)
+		}
+	}
+}
+
+func TestListenTCP_RequiresToken(t *testing.T) {
+	srv := NewServer(filepath.Join(t.TempDir(), "test.sock"), NewRouteRegistry(30*time.Second))
+	if err := srv.ListenTCP("127.0.0.1:0", "secret"); err == nil {
+		t.Fatal("expected port 0 to be rejected")
+	}
+	if err := srv.ListenTCP("127.0.0.1:2019", ""); err == nil {
+		t.Fatal("expected empty token to be rejected")
+	}
+
+	handler := requireToken("secret", srv.server.Handler)
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"secret", http.StatusUnauthorized},
+		{"Bearer secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/health", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("Authorization %q: got %d, want %d", tt.header, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	minThrottleKbps    = 8
+	maxThrottleKbps    = 1_000_000
+	maxThrottleLatency = 10_000 // milliseconds
+)
+
+// ThrottleConfig slows a route's traffic down to simulate a slow network,
+// for HTTP and WebSocket traffic alike and for every client, unlike
+// browser devtools throttling.
+type ThrottleConfig struct {
+	// Profile is a preset from ThrottleProfiles. Fields set alongside it
+	// override the preset's.
+	Profile string `json:"profile,omitempty"`
+	// DownKbps caps the rate of responses to the client, in kilobits per
+	// second. Zero is unlimited.
+	DownKbps int `json:"downKbps,omitempty"`
+	// UpKbps caps the rate of request bodies and WebSocket messages from
+	// the client. Zero is unlimited.
+	UpKbps int `json:"upKbps,omitempty"`
+	// LatencyMs is the round-trip time added to each request, in
+	// milliseconds. WebSocket messages are delayed by half of it each way.
+	LatencyMs int `json:"latencyMs,omitempty"`
+}
+
+// ThrottleProfiles are the named presets, after WebPageTest's connection
+// profiles.
+var ThrottleProfiles = map[string]ThrottleConfig{
+	"slow-3g": {DownKbps: 400, UpKbps: 400, LatencyMs: 400},
+	"3g":      {DownKbps: 1600, UpKbps: 768, LatencyMs: 300},
+	"dsl":     {DownKbps: 1500, UpKbps: 384, LatencyMs: 50},
+}
+
+// Latency returns LatencyMs as a duration.
+func (c *ThrottleConfig) Latency() time.Duration {
+	return time.Duration(c.LatencyMs) * time.Millisecond
+}
+
+// String describes the throttle, e.g. "3g (1600/768 kbps, 300ms)".
+func (c *ThrottleConfig) String() string {
+	rate := func(kbps int) string {
+		if kbps == 0 {
+			return "∞"
+		}
+		return strconv.Itoa(kbps)
+	}
+	s := fmt.Sprintf("%s/%s kbps, %dms", rate(c.DownKbps), rate(c.UpKbps), c.LatencyMs)
+	if c.Profile != "" {
+		s = c.Profile + " (" + s + ")"
+	}
+	return s
+}
+
+// normalizeThrottle validates c and fills in the rates and latency of its
+// profile, so the route shows the values in effect. A throttle that
+// limits nothing is nil.
+func normalizeThrottle(c *ThrottleConfig) (*ThrottleConfig, error) {
+	if c == nil || *c == (ThrottleConfig{}) {
+		return nil, nil
+	}
+	out := *c
+	if out.Profile != "" {
+		p, ok := ThrottleProfiles[out.Profile]
+		if !ok {
+			return nil, fmt.Errorf("unknown throttle profile %q (want %s)", out.Profile, strings.Join(throttleProfileNames(), ", "))
+		}
+		if out.DownKbps == 0 {
+			out.DownKbps = p.DownKbps
+		}
+		if out.UpKbps == 0 {
+			out.UpKbps = p.UpKbps
+		}
+		if out.LatencyMs == 0 {
+			out.LatencyMs = p.LatencyMs
+		}
+	}
+	for _, kbps := range []int{out.DownKbps, out.UpKbps} {
+		if kbps != 0 && (kbps < minThrottleKbps || kbps > maxThrottleKbps) {
+			return nil, fmt.Errorf("throttle rates must be between %d and %d kbps", minThrottleKbps, maxThrottleKbps)
+		}
+	}
+	if out.LatencyMs < 0 || out.LatencyMs > maxThrottleLatency {
+		return nil, fmt.Errorf("throttle latency must be between 0 and %dms", maxThrottleLatency)
+	}
+	if out.DownKbps == 0 && out.UpKbps == 0 && out.LatencyMs == 0 {
+		return nil, nil
+	}
+	return &out, nil
+}
+
+func throttleProfileNames() []string {
+	names := make([]string, 0, len(ThrottleProfiles))
+	for name := range ThrottleProfiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ParseThrottle parses a throttle from the command line: a profile name,
+// "off", or comma-separated settings such as "down=500,up=250,latency=200ms",
+// optionally after a profile ("3g,latency=1s"). It returns nil for "off".
+func ParseThrottle(s string) (*ThrottleConfig, error) {
+	if s == "off" {
+		return nil, nil
+	}
+	var c ThrottleConfig
+	for i, part := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			if i > 0 {
+				return nil, fmt.Errorf("invalid throttle setting %q", part)
+			}
+			c.Profile = part
+			continue
+		}
+		switch key {
+		case "down", "up":
+			kbps, err := strconv.Atoi(strings.TrimSuffix(value, "kbps"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid throttle %s rate %q (want kbps)", key, value)
+			}
+			if key == "down" {
+				c.DownKbps = kbps
+			} else {
+				c.UpKbps = kbps
+			}
+		case "latency":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid throttle latency %q: %w", value, err)
+			}
+			c.LatencyMs = int(d / time.Millisecond)
+		default:
+			return nil, fmt.Errorf("unknown throttle setting %q (want down, up, or latency)", key)
+		}
+	}
+	out, err := normalizeThrottle(&c)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, fmt.Errorf("throttle %q limits nothing; use off to remove one", s)
+	}
+	return out, nil
+}
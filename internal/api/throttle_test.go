@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseThrottle(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *ThrottleConfig
+		wantErr bool
+	}{
+		{in: "off", want: nil},
+		{in: "3g", want: &ThrottleConfig{Profile: "3g", DownKbps: 1600, UpKbps: 768, LatencyMs: 300}},
+		{in: "3g,latency=1s", want: &ThrottleConfig{Profile: "3g", DownKbps: 1600, UpKbps: 768, LatencyMs: 1000}},
+		{in: "down=500kbps,up=250", want: &ThrottleConfig{DownKbps: 500, UpKbps: 250}},
+		{in: "latency=200ms", want: &ThrottleConfig{LatencyMs: 200}},
+		{in: "5g", wantErr: true},
+		{in: "down=fast", wantErr: true},
+		{in: "down=4", wantErr: true},
+		{in: "latency=11s", wantErr: true},
+		{in: "down=500,3g", wantErr: true},
+		{in: "jitter=5ms", wantErr: true},
+		{in: "latency=0s", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseThrottle(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseThrottle(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("ParseThrottle(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRouteRegistry_SetThrottle(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	owner := &PeerCred{UID: 501, PID: 100}
+	r.RegisterRoute(Route{Name: "myapp", Upstream: "localhost:3000", Dir: "/tmp/myapp", Token: "secret", Owner: owner})
+
+	// Throttling is a debugging knob: the owner's user needs no token.
+	self := Caller{Peer: &PeerCred{UID: 501, PID: 300}}
+	if err := r.SetThrottleOwned("myapp", self, &ThrottleConfig{Profile: "slow-3g", LatencyMs: 100}); err != nil {
+		t.Fatalf("SetThrottleOwned: %v", err)
+	}
+	route, _ := r.Lookup("myapp")
+	want := ThrottleConfig{Profile: "slow-3g", DownKbps: 400, UpKbps: 400, LatencyMs: 100}
+	if route.Throttle == nil || *route.Throttle != want {
+		t.Errorf("Throttle = %+v, want %+v", route.Throttle, want)
+	}
+
+	other := Caller{Token: "secret", Peer: &PeerCred{UID: 502, PID: 200}}
+	if err := r.SetThrottleOwned("myapp", other, nil); !errors.Is(err, ErrOtherUser) {
+		t.Errorf("SetThrottleOwned(other user) = %v, want ErrOtherUser", err)
+	}
+	if err := r.SetThrottle("myapp", &ThrottleConfig{DownKbps: 1}); err == nil {
+		t.Error("SetThrottle accepted a 1 kbps rate")
+	}
+	if err := r.SetThrottle("myapp", &ThrottleConfig{}); err != nil {
+		t.Fatalf("SetThrottle(empty): %v", err)
+	}
+	if route, _ := r.Lookup("myapp"); route.Throttle != nil {
+		t.Errorf("empty throttle left %+v", route.Throttle)
+	}
+	if err := r.SetThrottle("missing", nil); err == nil {
+		t.Error("SetThrottle(missing) = nil, want error")
+	}
+}
@@ -0,0 +1,38 @@
+package api
+
+import "fmt"
+
+// validateUDP checks the UDP options of a registration. UDP routes carry
+// datagrams, not HTTP or TLS, so no request-level option applies.
+func validateUDP(req RegisterRequest) error {
+	if !req.UDP {
+		if req.UDPPort != 0 {
+			return fmt.Errorf("udpPort requires udp")
+		}
+		return nil
+	}
+	if req.UDPPort != 0 && (req.UDPPort < 1024 || req.UDPPort > 65535) {
+		return fmt.Errorf("invalid udpPort: must be between 1024 and 65535")
+	}
+	if req.Passthrough {
+		return fmt.Errorf("udp routes can't use passthrough")
+	}
+	if req.ProxyProtocol {
+		return fmt.Errorf("udp routes can't use proxyProtocol")
+	}
+	if opt := httpOption(req); opt != "" {
+		return fmt.Errorf("udp routes can't use %s", opt)
+	}
+	return nil
+}
+
+// SetUDPPort records the port the daemon forwards a UDP route's datagrams
+// from. It is not a registry change clients need to hear about, so no
+// event is sent.
+func (r *RouteRegistry) SetUDPPort(name string, port int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if route, ok := r.routes[name]; ok && route.UDP {
+		route.UDPPort = port
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateUDP(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     RegisterRequest
+		wantErr bool
+	}{
+		{"not udp", RegisterRequest{}, false},
+		{"udp", RegisterRequest{UDP: true}, false},
+		{"fixed port", RegisterRequest{UDP: true, UDPPort: 27015}, false},
+		{"port without udp", RegisterRequest{UDPPort: 27015}, true},
+		{"privileged port", RegisterRequest{UDP: true, UDPPort: 53}, true},
+		{"port too high", RegisterRequest{UDP: true, UDPPort: 70000}, true},
+		{"with passthrough", RegisterRequest{UDP: true, Passthrough: true}, true},
+		{"with proxy protocol", RegisterRequest{UDP: true, ProxyProtocol: true}, true},
+		{"with headers", RegisterRequest{UDP: true, Headers: map[string]string{"X-Env": "dev"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateUDP(tt.req); (err != nil) != tt.wantErr {
+				t.Errorf("validateUDP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetUDPPort(t *testing.T) {
+	r := NewRouteRegistry(30 * time.Second)
+	r.Register("shop", "localhost:3000", "/tmp/shop")
+	if err := r.RegisterRoute(Route{Name: "game", Upstream: "127.0.0.1:9000", Dir: "/tmp/game", UDP: true}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+	events := r.Subscribe()
+	defer r.Unsubscribe(events)
+
+	r.SetUDPPort("game", 40000)
+	r.SetUDPPort("shop", 40001)
+
+	if route, _ := r.Lookup("game"); route.UDPPort != 40000 {
+		t.Errorf("game UDPPort = %d, want 40000", route.UDPPort)
+	}
+	if route, _ := r.Lookup("shop"); route.UDPPort != 0 {
+		t.Errorf("shop UDPPort = %d, want 0 for an HTTP route", route.UDPPort)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected event %+v", ev)
+	default:
+	}
+}
@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/dashboard"
+)
+
+// alertCheckInterval is how often routes' request and error rates are
+// compared with their alert thresholds.
+const alertCheckInterval = 10 * time.Second
+
+// routeAlerts holds the routes currently over an alert threshold, by name,
+// with what they crossed.
+type routeAlerts struct {
+	mu     sync.Mutex
+	active map[string]string
+}
+
+// list returns a copy of the active alerts, for the dashboard.
+func (a *routeAlerts) list() map[string]string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return maps.Clone(a.active)
+}
+
+// watchAlerts checks alert thresholds every alertCheckInterval until ctx
+// is done.
+func (d *Daemon) watchAlerts(ctx context.Context) {
+	ticker := time.NewTicker(alertCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.checkAlerts(now)
+		}
+	}
+}
+
+// checkAlerts compares each route's traffic over the last minute with its
+// own alert thresholds, or the daemon's. A route crossing one is logged
+// and notified once, and highlighted in the dashboard until it drops back
+// under.
+func (d *Daemon) checkAlerts(now time.Time) {
+	rs := d.settings.Load()
+	rates := d.metrics.Rates(now)
+	active := make(map[string]string)
+	for _, route := range d.registry.List() {
+		cfg := route.Alerts
+		if cfg == nil && rs != nil {
+			cfg = rs.alerts
+		}
+		r := rates[route.Name]
+		if reason := cfg.Exceeded(r.Requests, r.Errors, dashboard.RateWindow); reason != "" {
+			active[route.Name] = reason
+		}
+	}
+
+	d.alerts.mu.Lock()
+	previous := d.alerts.active
+	d.alerts.active = active
+	d.alerts.mu.Unlock()
+
+	for name, reason := range active {
+		if _, ok := previous[name]; ok {
+			continue
+		}
+		d.logger.Warn("route alert", "route", name, "reason", reason)
+		d.notifier.notify("alert:"+name, name+"."+d.config.TLD+": "+reason)
+	}
+	for name := range previous {
+		if _, ok := active[name]; !ok {
+			d.logger.Info("route alert cleared", "route", name)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package daemon
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/dashboard"
+)
+
+func TestCheckAlerts(t *testing.T) {
+	n, sent := recordingNotifier()
+	d := &Daemon{
+		config:   &Config{TLD: "test"},
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		notifier: n,
+		metrics:  dashboard.NewMetrics(100),
+		registry: api.NewRouteRegistry(time.Minute),
+	}
+	d.settings.Store(&runtimeSettings{alerts: &api.AlertConfig{RequestRate: 1}})
+	d.registry.Register("poller", "localhost:3000", "/tmp/poller")
+	d.registry.RegisterRoute(api.Route{Name: "flaky", Upstream: "localhost:3001", Dir: "/tmp/flaky",
+		Alerts: &api.AlertConfig{ErrorRate: 10}})
+	d.registry.Register("quiet", "localhost:3002", "/tmp/quiet")
+
+	now := time.Now()
+	for i := range 120 {
+		d.metrics.Record(dashboard.RequestEntry{Timestamp: now, Route: "poller", StatusCode: 200})
+		status := 200
+		if i%2 == 0 {
+			status = 500
+		}
+		// Over the daemon's request rate, but flaky has its own thresholds.
+		d.metrics.Record(dashboard.RequestEntry{Timestamp: now, Route: "flaky", StatusCode: status})
+	}
+	d.metrics.Record(dashboard.RequestEntry{Timestamp: now, Route: "quiet", StatusCode: 200})
+
+	d.checkAlerts(now)
+	active := d.alerts.list()
+	if len(active) != 2 || !strings.Contains(active["poller"], "requests/s") || !strings.Contains(active["flaky"], "5xx") {
+		t.Fatalf("active alerts = %v, want poller over the request rate and flaky over the error rate", active)
+	}
+	for range 2 {
+		select {
+		case msg := <-sent:
+			if !strings.HasPrefix(msg, "poller.test: ") && !strings.HasPrefix(msg, "flaky.test: ") {
+				t.Errorf("notification = %q", msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("alert not notified")
+		}
+	}
+
+	// Still over: no second notification. Once the minute has passed, the
+	// alerts clear.
+	d.checkAlerts(now)
+	select {
+	case msg := <-sent:
+		t.Errorf("notified again: %q", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+	d.checkAlerts(now.Add(2 * dashboard.RateWindow))
+	if active := d.alerts.list(); len(active) != 0 {
+		t.Errorf("active alerts after traffic stopped = %v", active)
+	}
+}
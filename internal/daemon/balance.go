@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+// upstreamDownFor is how long balanced routes try an upstream that
+// couldn't be reached last, before giving it requests again.
+const upstreamDownFor = 10 * time.Second
+
+// maxBalanceCounters bounds the round-robin positions kept; past it they
+// all start over, which only shifts which upstream is next.
+const maxBalanceCounters = 1024
+
+// balancer picks the upstream order for routes that balance over several
+// instances of their dev server.
+type balancer struct {
+	mu   sync.Mutex
+	next map[string]uint64    // route name -> requests sent round-robin
+	down map[string]time.Time // upstream -> when it last couldn't be reached
+}
+
+func newBalancer() *balancer {
+	return &balancer{
+		next: make(map[string]uint64),
+		down: make(map[string]time.Time),
+	}
+}
+
+// order returns the upstreams to try for a request to route: the first to
+// send it to, then the ones to fail over to. Round-robin routes start one
+// further along for each request, fallback routes always start at the
+// primary, and upstreams that recently couldn't be reached go last.
+func (b *balancer) order(route api.Route, now time.Time) []string {
+	all := route.Balance.Order(route.Upstream)
+	if len(all) == 1 {
+		return all
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if route.Balance.RoundRobin() {
+		if len(b.next) >= maxBalanceCounters {
+			clear(b.next)
+		}
+		i := int(b.next[route.Name] % uint64(len(all)))
+		b.next[route.Name]++
+		all = slices.Concat(all[i:], all[:i])
+	}
+	up := make([]string, 0, len(all))
+	var down []string
+	for _, u := range all {
+		if at, ok := b.down[u]; ok && now.Sub(at) < upstreamDownFor {
+			down = append(down, u)
+		} else {
+			up = append(up, u)
+		}
+	}
+	return append(up, down...)
+}
+
+// failed records that upstream couldn't be reached.
+func (b *balancer) failed(upstream string, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for u, at := range b.down {
+		if now.Sub(at) >= upstreamDownFor {
+			delete(b.down, u)
+		}
+	}
+	b.down[upstream] = now
+}
@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+func TestBalancer_Order(t *testing.T) {
+	b := newBalancer()
+	now := time.Now()
+	route := api.Route{Name: "app", Upstream: "localhost:3000", Balance: &api.BalanceConfig{
+		Upstreams: []string{"localhost:3001", "localhost:3002"},
+	}}
+
+	var firsts []string
+	for range 4 {
+		firsts = append(firsts, b.order(route, now)[0])
+	}
+	if want := []string{"localhost:3000", "localhost:3001", "localhost:3002", "localhost:3000"}; !slices.Equal(firsts, want) {
+		t.Errorf("round-robin picked %v, want %v", firsts, want)
+	}
+
+	// An upstream that couldn't be reached goes last until it has had
+	// time to come back.
+	b.failed("localhost:3001", now)
+	if got := b.order(route, now); !slices.Equal(got, []string{"localhost:3002", "localhost:3000", "localhost:3001"}) {
+		t.Errorf("order with 3001 down = %v", got)
+	}
+
+	route.Balance.Mode = api.BalanceFallback
+	b.failed("localhost:3000", now)
+	if got := b.order(route, now); !slices.Equal(got, []string{"localhost:3002", "localhost:3000", "localhost:3001"}) {
+		t.Errorf("fallback order with 3000 and 3001 down = %v", got)
+	}
+	if got := b.order(route, now.Add(upstreamDownFor)); got[0] != "localhost:3000" {
+		t.Errorf("fallback order after recovery = %v, want the primary first", got)
+	}
+
+	if got := b.order(api.Route{Upstream: "localhost:4000"}, now); !slices.Equal(got, []string{"localhost:4000"}) {
+		t.Errorf("unbalanced route order = %v", got)
+	}
+}
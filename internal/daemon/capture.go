@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+// captureRecorder collects what `paw-proxy tap` sees of one request:
+// metadata, sizes, and up to bodyLimit bytes of each body.
+type captureRecorder struct {
+	c         api.Capture
+	bodyLimit int
+	reqBody   bytes.Buffer
+	respBody  bytes.Buffer
+}
+
+// startCapture begins recording r and the response written through rw.
+// It must run after the daemon's own request headers are set, so the
+// capture shows what the upstream received.
+func startCapture(r *http.Request, rw *statusCapture, route string, bodyLimit int) *captureRecorder {
+	rec := &captureRecorder{
+		c: api.Capture{
+			Time:           time.Now(),
+			Route:          route,
+			Method:         r.Method,
+			Host:           r.Host,
+			URL:            r.URL.RequestURI(),
+			RequestHeaders: r.Header.Clone(),
+		},
+		bodyLimit: bodyLimit,
+	}
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = readCloser{io.TeeReader(r.Body, writerFunc(rec.recordRequest)), r.Body}
+	}
+	rw.onWrite = rec.recordResponse
+	return rec
+}
+
+func (rec *captureRecorder) recordRequest(b []byte) (int, error) {
+	rec.c.RequestSize += int64(len(b))
+	keep(&rec.reqBody, b, rec.bodyLimit)
+	return len(b), nil
+}
+
+func (rec *captureRecorder) recordResponse(b []byte) {
+	rec.c.ResponseSize += int64(len(b))
+	keep(&rec.respBody, b, rec.bodyLimit)
+}
+
+// finish completes the capture once the response has been written.
+func (rec *captureRecorder) finish(status int, header http.Header) api.Capture {
+	c := rec.c
+	c.Status = status
+	c.DurationMs = fractionalMs(time.Since(c.Time))
+	c.ResponseHeaders = header.Clone()
+	if rec.bodyLimit > 0 {
+		c.RequestBody = rec.reqBody.Bytes()
+		c.RequestBodyTruncated = c.RequestSize > int64(len(c.RequestBody))
+		c.ResponseBody = rec.respBody.Bytes()
+		c.ResponseBodyTruncated = c.ResponseSize > int64(len(c.ResponseBody))
+	}
+	return c
+}
+
+// keep appends as much of b to buf as fits in limit bytes.
+func keep(buf *bytes.Buffer, b []byte, limit int) {
+	if room := limit - buf.Len(); room > 0 {
+		buf.Write(b[:min(room, len(b))])
+	}
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(b []byte) (int, error) { return f(b) }
+
+// readCloser reads from one reader and closes another.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
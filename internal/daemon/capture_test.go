@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/dashboard"
+	"github.com/alexcatdad/paw-proxy/internal/proxy"
+)
+
+func TestHandleRequest_Capture(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Upstream", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created " + string(body)))
+	}))
+	defer upstream.Close()
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	registry.Register("shop", strings.TrimPrefix(upstream.URL, "http://"), "/tmp/shop")
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+		captures: api.NewCaptureHub(),
+	}
+	ch := d.captures.Subscribe("shop", 8)
+	defer d.captures.Unsubscribe(ch)
+
+	w := httptest.NewRecorder()
+	d.handleRequest(w, httptest.NewRequest("POST", "https://shop.test/orders?x=1", strings.NewReader("order-42")))
+	if got := w.Body.String(); got != "created order-42" {
+		t.Fatalf("response body = %q; capture must not change it", got)
+	}
+
+	var c api.Capture
+	select {
+	case c = <-ch:
+	default:
+		t.Fatal("no capture published")
+	}
+	if c.Route != "shop" || c.Method != "POST" || c.URL != "/orders?x=1" || c.Status != http.StatusCreated {
+		t.Errorf("capture = %+v", c)
+	}
+	if c.ResponseHeaders.Get("X-Upstream") != "yes" {
+		t.Errorf("response headers = %v", c.ResponseHeaders)
+	}
+	if c.RequestSize != 8 || string(c.RequestBody) != "order-42" || c.RequestBodyTruncated {
+		t.Errorf("request body = %q (%d bytes, truncated=%v)", c.RequestBody, c.RequestSize, c.RequestBodyTruncated)
+	}
+	if c.ResponseSize != 16 || string(c.ResponseBody) != "created " || !c.ResponseBodyTruncated {
+		t.Errorf("response body = %q (%d bytes, truncated=%v)", c.ResponseBody, c.ResponseSize, c.ResponseBodyTruncated)
+	}
+}
@@ -3,17 +3,23 @@ package daemon
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"log/slog"
+	"math"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -21,10 +27,14 @@ import (
 	"github.com/alexcatdad/paw-proxy/internal/dashboard"
 	"github.com/alexcatdad/paw-proxy/internal/dns"
 	"github.com/alexcatdad/paw-proxy/internal/errorpage"
+	"github.com/alexcatdad/paw-proxy/internal/hooks"
 	"github.com/alexcatdad/paw-proxy/internal/launchd"
 	"github.com/alexcatdad/paw-proxy/internal/paths"
 	"github.com/alexcatdad/paw-proxy/internal/proxy"
+	"github.com/alexcatdad/paw-proxy/internal/schedule"
 	"github.com/alexcatdad/paw-proxy/internal/ssl"
+	"github.com/alexcatdad/paw-proxy/internal/static"
+	"github.com/alexcatdad/paw-proxy/internal/systemd"
 )
 
 type Config struct {
@@ -35,6 +45,33 @@ type Config struct {
 	SupportDir string
 	SocketPath string
 	LogPath    string
+	ConfigPath string
+	// LogLevel overrides log_level from the config file when set, e.g. by
+	// `paw-proxy run --log-level` or PAW_PROXY_LOG_LEVEL.
+	LogLevel string
+	// APIAddr, when set, also serves the control API on this loopback TCP
+	// address, protected by the token in the support directory.
+	APIAddr string
+	// ListenAddr, when set, is a non-loopback IP the HTTP and HTTPS
+	// listeners also bind, e.g. a Tailscale address. Only clients in
+	// AllowFrom (CIDRs or IPs) may connect on it.
+	ListenAddr string
+	AllowFrom  []string
+	// ReadOnly makes the control API refuse to change routes whatever
+	// the config file says, e.g. by `paw-proxy run --read-only` or
+	// PAW_PROXY_READ_ONLY=1.
+	ReadOnly bool
+}
+
+// IssueLogPath is where the daemon records the certificates it issues.
+func IssueLogPath(supportDir string) string {
+	return filepath.Join(supportDir, "issued-certs.ndjson")
+}
+
+// readOnlyEnv reports whether PAW_PROXY_READ_ONLY is set to a true value.
+func readOnlyEnv() bool {
+	on, _ := strconv.ParseBool(os.Getenv("PAW_PROXY_READ_ONLY"))
+	return on
 }
 
 func DefaultConfig() (*Config, error) {
@@ -51,6 +88,9 @@ func DefaultConfig() (*Config, error) {
 		SupportDir: p.SupportDir,
 		SocketPath: p.SocketPath,
 		LogPath:    p.LogPath,
+		ConfigPath: filepath.Join(p.SupportDir, "config.json"),
+		LogLevel:   os.Getenv("PAW_PROXY_LOG_LEVEL"),
+		ReadOnly:   readOnlyEnv(),
 	}, nil
 }
 
@@ -60,11 +100,34 @@ type Daemon struct {
 	registry  *api.RouteRegistry
 	apiServer *api.Server
 	certCache *ssl.CertCache
+	issued    *ssl.IssueLog
 	proxy     *proxy.Proxy
 	logger    *slog.Logger
 	logFile   *os.File
 	metrics   *dashboard.Metrics
 	dash      *dashboard.Dashboard
+	hooks     *hooks.Runner
+	notifier  *notifier
+	caExpiry  time.Time
+	logLevel  *slog.LevelVar
+	settings  atomic.Pointer[runtimeSettings]
+	udp       *udpForwarders
+	balancer  *balancer
+	alerts    routeAlerts
+	captures  *api.CaptureHub
+	problems  problems
+	acme      atomic.Pointer[ssl.ACMEManager]
+	crashes   crashReports
+	handoff   *handoff
+	restartCh chan struct{}
+	conns     *connLimit
+	hostLimit *hostLimit
+	// scheduler runs timed jobs: the daily CA expiry check and route
+	// maintenance windows.
+	scheduler *schedule.Scheduler
+	// maintenance holds the maintenance schedules with a scheduler job,
+	// by route. Owned by watchMaintenance.
+	maintenance map[string]api.MaintenanceConfig
 }
 
 func New(config *Config) (*Daemon, error) {
@@ -96,7 +159,19 @@ func New(config *Config) (*Daemon, error) {
 	if err != nil {
 		return nil, fmt.Errorf("opening log file: %w", err)
 	}
-	logger := slog.New(slog.NewJSONHandler(logFile, nil))
+	logLevel := new(slog.LevelVar)
+	// The dashboard's log pane tails the same lines.
+	logs := dashboard.NewLogBuffer(1000)
+	logger := slog.New(slog.NewJSONHandler(io.MultiWriter(logFile, logs), &slog.HandlerOptions{Level: logLevel}))
+
+	// Reloadable settings: a broken config file is fatal at startup, but
+	// only logged (keeping the old settings) on reload.
+	settings, err := config.loadSettings()
+	if err != nil {
+		logFile.Close()
+		return nil, err
+	}
+	logLevel.Set(settings.logLevel)
 
 	// Warn if CA certificate is near expiry
 	if ca.Leaf != nil {
@@ -113,15 +188,18 @@ func New(config *Config) (*Daemon, error) {
 		logFile.Close()
 		return nil, fmt.Errorf("creating DNS server: %w", err)
 	}
+	dnsServer.SetLogger(logger)
 
-	// Create route registry with 30s heartbeat timeout
-	registry := api.NewRouteRegistry(30 * time.Second)
+	registry := api.NewRouteRegistry(settings.heartbeatTimeout)
+	registry.SetLogger(logger)
 
 	// Create API server
 	apiServer := api.NewServer(config.SocketPath, registry)
 
 	certCache := ssl.NewCertCache(ca, config.TLD)
 	certCache.SetLogger(logger)
+	issued := ssl.NewIssueLog(IssueLogPath(config.SupportDir))
+	certCache.SetIssueLog(issued)
 
 	metrics := dashboard.NewMetrics(1000)
 	dash, err := dashboard.New(metrics, registry, api.Version, time.Now())
@@ -130,23 +208,64 @@ func New(config *Config) (*Daemon, error) {
 		return nil, fmt.Errorf("creating dashboard: %w", err)
 	}
 
-	return &Daemon{
+	// A daemon restarting in place inherits its sockets and routes.
+	ho, err := inheritHandoff()
+	if err != nil {
+		logger.Warn("ignoring inherited sockets", "error", err)
+	}
+
+	d := &Daemon{
 		config:    config,
 		dnsServer: dnsServer,
 		registry:  registry,
 		apiServer: apiServer,
 		certCache: certCache,
+		issued:    issued,
 		proxy:     proxy.New(),
 		logger:    logger,
 		logFile:   logFile,
 		metrics:   metrics,
 		dash:      dash,
-	}, nil
+		hooks:     hooks.NewRunner(config.TLD, logger),
+		notifier:  newNotifier(),
+		udp:       newUDPForwarders(),
+		balancer:  newBalancer(),
+		captures:  api.NewCaptureHub(),
+		logLevel:  logLevel,
+		handoff:   ho,
+		restartCh: make(chan struct{}, 1),
+		scheduler: schedule.New(),
+	}
+	if ca.Leaf != nil {
+		d.caExpiry = ca.Leaf.NotAfter
+	}
+	d.apply(settings)
+	d.proxy.SetUpstreamErrorFunc(d.upstreamFailed)
+	apiServer.SetReloadFunc(d.Reload)
+	apiServer.SetInvalidateFunc(d.invalidateCerts)
+	apiServer.SetHealthFunc(d.problems.list)
+	apiServer.SetStatsFunc(d.routeStats)
+	dash.SetPoolFunc(d.proxy.PoolStats)
+	dash.SetAlertFunc(d.alerts.list)
+	dash.SetCertCache(certCache, d.invalidateCerts)
+	dash.SetLogBuffer(logs)
+	dash.SetRouteEvents(registry)
+	apiServer.SetPairFunc(d.dashboardPairURL)
+	dnsServer.SetUDPLookup(d.udpPortFor)
+	dnsServer.SetPanicHandler(func(v any, stack []byte, query string) {
+		d.reportPanic("dns", v, stack, query)
+	})
+	apiServer.SetCaptureHub(d.captures)
+	d.conns = newConnLimit(d.maxConnections)
+	d.hostLimit = newHostLimit()
+	apiServer.SetRestartFunc(d.requestRestart)
+	d.adoptHandoff()
+	return d, nil
 }
 
 func (d *Daemon) Run() error {
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	signal.Notify(sigCh, append([]os.Signal{syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP}, restartSignals...)...)
 
 	errCh := make(chan error, 4)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -177,6 +296,88 @@ func (d *Daemon) Run() error {
 		}
 	}()
 
+	// Start the optional TCP control API for clients in containers
+	if d.config.APIAddr != "" {
+		token, err := api.LoadOrCreateToken(filepath.Join(d.config.SupportDir, api.TokenFile))
+		if err == nil {
+			err = d.apiServer.ListenTCP(d.config.APIAddr, token)
+		}
+		if err != nil {
+			cancel()
+			d.dnsServer.Stop()
+			d.apiServer.Stop()
+			return fmt.Errorf("creating TCP API listener: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.logger.Info("server started", "component", "api-tcp", "addr", d.config.APIAddr)
+			if err := d.apiServer.ServeTCP(); err != nil && err != http.ErrServerClosed {
+				errCh <- fmt.Errorf("TCP API server: %w", err)
+			}
+		}()
+	}
+
+	// Run lifecycle hooks for route changes
+	hookEvents := d.registry.Subscribe()
+	defer d.registry.Unsubscribe(hookEvents)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.hooks.Run(ctx, hookEvents)
+	}()
+
+	// Desktop notifications for expired routes and CA expiry
+	notifyEvents := d.registry.Subscribe()
+	defer d.registry.Unsubscribe(notifyEvents)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.watchNotifications(ctx, notifyEvents)
+	}()
+
+	// Watch for the OS resolver dropping the TLD
+	if resolverWatchdogEnabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.watchResolver(ctx, newResolverWatchdog())
+		}()
+	}
+
+	// Warn about routes whose request or error rate crosses a threshold
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.watchAlerts(ctx)
+	}()
+
+	// UDP forwarders follow the registry's UDP routes
+	udpEvents := d.registry.Subscribe()
+	defer d.registry.Unsubscribe(udpEvents)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.watchUDP(ctx, udpEvents)
+	}()
+
+	// Route maintenance windows follow the registry's schedules
+	maintenanceEvents := d.registry.Subscribe()
+	defer d.registry.Unsubscribe(maintenanceEvents)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.watchMaintenance(ctx, maintenanceEvents)
+	}()
+
+	// Run timed jobs
+	d.scheduler.Set("ca-expiry", schedule.Every(24*time.Hour), d.checkCAExpiry)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.scheduler.Run(ctx)
+	}()
+
 	// Start cleanup routine
 	wg.Add(1)
 	go func() {
@@ -184,48 +385,68 @@ func (d *Daemon) Run() error {
 		d.cleanupRoutine(ctx)
 	}()
 
-	// Start HTTP redirect server
-	httpServer, httpListener, err := d.createHTTPServer()
+	// Start HTTP redirect and HTTPS servers. A port held by another
+	// process degrades the daemon instead of stopping it.
+	httpServer, err := d.startServer(ctx, &wg, errCh, "http", d.config.HTTPPort, d.createHTTPServer,
+		func(srv *http.Server, l net.Listener) error { return srv.Serve(l) })
 	if err != nil {
 		cancel()
 		d.dnsServer.Stop()
 		d.apiServer.Stop()
 		return fmt.Errorf("creating HTTP server: %w", err)
 	}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		d.logger.Info("server started", "component", "http", "addr", httpListener.Addr().String())
-		if err := httpServer.Serve(httpListener); err != nil && err != http.ErrServerClosed {
-			errCh <- fmt.Errorf("HTTP server: %w", err)
-		}
-	}()
 
-	// Start HTTPS server
-	httpsServer, httpsListener, err := d.createHTTPSServer()
+	httpsServer, err := d.startServer(ctx, &wg, errCh, "https", d.config.HTTPSPort, d.createHTTPSServer,
+		func(srv *http.Server, l net.Listener) error {
+			// ServeTLS wraps the plain listener with TLS and auto-configures HTTP/2
+			return srv.ServeTLS(l, "", "")
+		})
 	if err != nil {
 		cancel()
-		httpListener.Close()
+		httpServer.Shutdown(context.Background())
 		d.dnsServer.Stop()
 		d.apiServer.Stop()
 		return fmt.Errorf("creating HTTPS server: %w", err)
 	}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		d.logger.Info("server started", "component", "https", "addr", httpsListener.Addr().String())
-		// ServeTLS wraps the plain listener with TLS and auto-configures HTTP/2
-		if err := httpsServer.ServeTLS(httpsListener, "", ""); err != nil && err != http.ErrServerClosed {
-			errCh <- fmt.Errorf("HTTPS server: %w", err)
-		}
-	}()
 
-	// Wait for signal or component failure
-	select {
-	case sig := <-sigCh:
-		d.logger.Info("shutdown signal received", "signal", sig.String())
-	case err := <-errCh:
-		d.logger.Error("component failure", "error", err)
+	// Anything the previous process passed that is no longer used.
+	d.handoff.closeUnclaimed()
+
+	if d.markRunning() {
+		d.logger.Warn("previous run ended without a clean shutdown")
+		d.notifier.notify("restart", "paw-proxy restarted after an unexpected exit")
+	}
+	d.checkCAExpiry(time.Now())
+
+	// Wait for signal or component failure. SIGHUP reloads the config
+	// file in place; listeners and open connections are unaffected.
+	// SIGUSR2 or POST /restart restarts in place, handing the sockets to
+	// a fresh copy of the binary.
+	var handoffFiles map[string][]*os.File
+wait:
+	for {
+		select {
+		case sig := <-sigCh:
+			if sig == syscall.SIGHUP {
+				d.Reload() // errors are logged; old settings stay active
+				continue
+			}
+			if slices.Contains(restartSignals, sig) {
+				if handoffFiles = d.prepareHandoff(); handoffFiles != nil {
+					break wait
+				}
+				continue
+			}
+			d.logger.Info("shutdown signal received", "signal", sig.String())
+			break wait
+		case <-d.restartCh:
+			if handoffFiles = d.prepareHandoff(); handoffFiles != nil {
+				break wait
+			}
+		case err := <-errCh:
+			d.logger.Error("component failure", "error", err)
+			break wait
+		}
 	}
 
 	// Begin graceful shutdown
@@ -270,15 +491,28 @@ func (d *Daemon) Run() error {
 	}()
 
 	shutdownWg.Wait()
+	d.setACME(nil)
 
-	// Clean up socket file
-	if err := os.Remove(d.config.SocketPath); err != nil && !os.IsNotExist(err) {
-		d.logger.Warn("socket cleanup failed", "error", err)
+	// Clean up socket file, unless the next process is taking it over
+	if handoffFiles == nil {
+		if err := os.Remove(d.config.SocketPath); err != nil && !os.IsNotExist(err) {
+			d.logger.Warn("socket cleanup failed", "error", err)
+		}
 	}
 
 	// Wait for all goroutines to finish
 	wg.Wait()
 
+	d.markStopped()
+	if handoffFiles != nil {
+		err := d.execHandoff(handoffFiles)
+		d.logger.Error("restart in place failed", "error", err)
+		closeFiles(handoffFiles)
+		if d.logFile != nil {
+			d.logFile.Close()
+		}
+		return fmt.Errorf("restarting in place: %w", err)
+	}
 	d.logger.Info("shutdown complete")
 
 	// Close log file after all logging is done
@@ -289,21 +523,24 @@ func (d *Daemon) Run() error {
 	return nil
 }
 
+// cleanupRoutine sweeps expired routes every cleanup interval. The
+// interval is re-read after each sweep, so a reload takes effect from the
+// next one.
 func (d *Daemon) cleanupRoutine(ctx context.Context) {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
 	for {
+		timer := time.NewTimer(d.settings.Load().cleanupInterval)
 		select {
 		case <-ctx.Done():
+			timer.Stop()
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			d.registry.Cleanup()
 		}
 	}
 }
 
-func redirectTarget(rawHost, requestURI, tld string) (string, bool) {
-	if rawHost == "" {
+func redirectTarget(rawHost, requestURI string, tlds ...string) (string, bool) {
+	if !api.ValidHost(rawHost) {
 		return "", false
 	}
 
@@ -315,51 +552,96 @@ func redirectTarget(rawHost, requestURI, tld string) (string, bool) {
 	}
 
 	host = strings.TrimSuffix(strings.ToLower(host), ".")
-	tld = strings.Trim(strings.ToLower(tld), ".")
-	if host == "" || tld == "" {
+	if host == "" {
 		return "", false
 	}
-	if host != tld && !strings.HasSuffix(host, "."+tld) {
-		return "", false
+	for _, tld := range tlds {
+		tld = strings.Trim(strings.ToLower(tld), ".")
+		if tld != "" && (host == tld || strings.HasSuffix(host, "."+tld)) {
+			return "https://" + host + requestURI, true
+		}
 	}
+	return "", false
+}
 
-	return "https://" + host + requestURI, true
+// activateSocket returns the sockets passed under name (IPv4 and IPv6
+// loopback) as one listener, and what passed them: the previous process
+// of a daemon restarting in place ("handoff"), or the service manager
+// ("launchd" or "systemd"). An empty string means the caller should bind
+// the port itself.
+func (d *Daemon) activateSocket(name string) (net.Listener, string) {
+	for _, m := range []struct {
+		via      string
+		activate func(string) ([]net.Listener, bool, error)
+	}{
+		{"handoff", d.handoff.listeners},
+		{"launchd", launchd.ActivateSocket},
+		{"systemd", systemd.ActivateSocket},
+	} {
+		listeners, activated, err := m.activate(name)
+		if err != nil {
+			d.logger.Warn("socket activation failed, falling back to direct binding",
+				"socket", name, "via", m.via, "error", err)
+			continue
+		}
+		if activated {
+			sockets := make([]any, len(listeners))
+			for i, l := range listeners {
+				sockets[i] = l
+			}
+			d.handoff.keep(name, sockets...)
+			return mergeListeners(listeners...), m.via
+		}
+	}
+	return nil, ""
+}
+
+// handleHTTP redirects a plain HTTP request to HTTPS, or proxies it when
+// its route was registered with PlainHTTP.
+func (d *Daemon) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	target, ok := redirectTarget(r.Host, r.URL.RequestURI(), d.tlds()...)
+	if !ok {
+		http.Error(w, "invalid host", http.StatusBadRequest)
+		return
+	}
+	if route, _, found := d.registry.LookupSubdomain(api.ExtractNameFor(r.Host, d.tlds())); found && route.PlainHTTP {
+		d.handleRequest(w, r.WithContext(proxy.WithPlainHTTP(r.Context())))
+		return
+	}
+	http.Redirect(w, r, target, http.StatusPermanentRedirect)
 }
 
 // createHTTPServer creates the HTTP redirect server and its listener.
 // The caller owns the lifecycle of the returned server.
 func (d *Daemon) createHTTPServer() (*http.Server, net.Listener, error) {
-	// Try launchd socket activation first (macOS only; no-op on Linux)
-	listener, activated, err := launchd.ActivateSocket("http")
-	if err != nil {
-		d.logger.Warn("socket activation failed, falling back to direct binding",
-			"socket", "http", "error", err)
-	}
+	// Try socket activation first (launchd on macOS, systemd on Linux)
+	listener, activatedBy := d.activateSocket("http")
 
-	if !activated {
-		// SECURITY: Bind to loopback only to prevent external access
-		addr := fmt.Sprintf("127.0.0.1:%d", d.config.HTTPPort)
-		listener, err = net.Listen("tcp", addr)
+	if activatedBy == "" {
+		var err error
+		listener, err = d.listenLoopback("http", d.config.HTTPPort)
 		if err != nil {
-			return nil, nil, fmt.Errorf("listening on %s: %w", addr, err)
+			return nil, nil, err
 		}
-		d.logger.Info("using direct binding", "component", "http", "addr", addr)
 	} else {
-		d.logger.Info("using launchd socket activation", "component", "http")
+		d.logger.Info("using socket activation", "component", "http", "via", activatedBy)
+	}
+	extra, err := d.listenConfigured("http", d.config.HTTPPort)
+	if err != nil {
+		listener.Close()
+		return nil, nil, err
+	}
+	if extra != nil {
+		listener = mergeListeners(listener, extra)
 	}
+	listener = d.conns.listener(listener)
 
 	server := &http.Server{
-		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			target, ok := redirectTarget(r.Host, r.URL.RequestURI(), d.config.TLD)
-			if !ok {
-				http.Error(w, "invalid host", http.StatusBadRequest)
-				return
-			}
-			http.Redirect(w, r, target, http.StatusPermanentRedirect)
-		}),
+		Handler:           d.recoverHTTP("http", d.handleHTTP),
+		ConnState:         d.conns.trackState,
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       30 * time.Second,
-		WriteTimeout:      30 * time.Second,
+		WriteTimeout:      2 * time.Minute, // as HTTPS, since PlainHTTP routes are proxied here
 		IdleTimeout:       120 * time.Second,
 		MaxHeaderBytes:    1 << 20, // 1MB — explicit limit to prevent header-based DoS
 	}
@@ -374,7 +656,7 @@ func (d *Daemon) createHTTPServer() (*http.Server, net.Listener, error) {
 func (d *Daemon) createHTTPSServer() (*http.Server, net.Listener, error) {
 	// SECURITY: TLS hardening - minimum TLS 1.2, secure cipher suites
 	tlsConfig := &tls.Config{
-		GetCertificate: d.certCache.GetCertificate,
+		GetCertificate: d.getCertificate,
 		MinVersion:     tls.VersionTLS12,
 		CipherSuites: []uint16{
 			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
@@ -386,30 +668,36 @@ func (d *Daemon) createHTTPSServer() (*http.Server, net.Listener, error) {
 		},
 	}
 
-	// Try launchd socket activation first (macOS only; no-op on Linux).
-	// Launchd passes raw TCP sockets — ServeTLS in the caller wraps with TLS.
-	listener, activated, err := launchd.ActivateSocket("https")
-	if err != nil {
-		d.logger.Warn("socket activation failed, falling back to direct binding",
-			"socket", "https", "error", err)
-	}
+	// Try socket activation first (launchd on macOS, systemd on Linux).
+	// Both pass raw TCP sockets — ServeTLS in the caller wraps with TLS.
+	listener, activatedBy := d.activateSocket("https")
 
-	if !activated {
-		// SECURITY: Bind to loopback only to prevent external access
-		addr := fmt.Sprintf("127.0.0.1:%d", d.config.HTTPSPort)
-		// Use plain TCP listener — ServeTLS wraps it with TLS and enables HTTP/2
-		listener, err = net.Listen("tcp", addr)
+	if activatedBy == "" {
+		// Use plain TCP listeners — ServeTLS wraps them with TLS and enables HTTP/2
+		var err error
+		listener, err = d.listenLoopback("https", d.config.HTTPSPort)
 		if err != nil {
-			return nil, nil, fmt.Errorf("listening on %s: %w", addr, err)
+			return nil, nil, err
 		}
-		d.logger.Info("using direct binding", "component", "https", "addr", addr)
 	} else {
-		d.logger.Info("using launchd socket activation", "component", "https")
+		d.logger.Info("using socket activation", "component", "https", "via", activatedBy)
+	}
+	extra, err := d.listenConfigured("https", d.config.HTTPSPort)
+	if err != nil {
+		listener.Close()
+		return nil, nil, err
+	}
+	if extra != nil {
+		listener = mergeListeners(listener, extra)
 	}
+	listener = d.conns.listener(listener)
+	// Passthrough routes are picked off by SNI before TLS is terminated.
+	listener = d.newPassthroughListener(listener)
 
 	server := &http.Server{
-		Handler:           http.HandlerFunc(d.handleRequest),
+		Handler:           d.recoverHTTP("https", d.handleRequest),
 		TLSConfig:         tlsConfig,
+		ConnState:         d.conns.trackState,
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       30 * time.Second,
 		WriteTimeout:      2 * time.Minute,
@@ -420,16 +708,89 @@ func (d *Daemon) createHTTPSServer() (*http.Server, net.Listener, error) {
 	return server, listener, nil
 }
 
+// getCertificate serves the ACME certificate for names under its domain
+// once it has been issued, and a certificate from the local CA otherwise.
+func (d *Daemon) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if m := d.acme.Load(); m != nil {
+		if cert := m.Certificate(hello.ServerName); cert != nil {
+			return cert, nil
+		}
+	}
+	return d.certCache.GetCertificate(hello)
+}
+
+// invalidateCerts drops the local CA's cached certificates so each name is
+// issued a new one on its next handshake. A CA rotated on disk since the
+// daemon started is loaded and signs from then on.
+func (d *Daemon) invalidateCerts() (int, error) {
+	ca, err := ssl.LoadCA(filepath.Join(d.config.SupportDir, "ca.crt"), filepath.Join(d.config.SupportDir, "ca.key"))
+	if err != nil {
+		return 0, fmt.Errorf("loading CA: %w", err)
+	}
+	if bytes.Equal(ca.Leaf.Raw, d.certCache.CA().Raw) {
+		ca = nil
+	}
+	n := d.certCache.Invalidate(ca)
+	if ca != nil {
+		d.logger.Info("CA reloaded", "subject", ca.Leaf.Subject.CommonName, "expires", ca.Leaf.NotAfter)
+		if rs := d.settings.Load(); rs != nil {
+			d.checkCADomains(rs)
+		}
+	}
+	d.logger.Info("certificate cache invalidated", "dropped", n, "ca_reloaded", ca != nil)
+	return n, nil
+}
+
+// routeStats reports the request counters the dashboard keeps, for the
+// control API.
+func (d *Daemon) routeStats() map[string]api.RouteStats {
+	stats := make(map[string]api.RouteStats)
+	for name, rm := range d.metrics.RouteStats() {
+		s := api.RouteStats{Requests: rm.Requests, Errors: rm.Errors}
+		if rm.Requests > 0 {
+			s.AvgMs = rm.TotalMs / rm.Requests
+		}
+		stats[name] = s
+	}
+	return stats
+}
+
+// dashboardPairURL returns a one-time link that signs a browser in to the
+// dashboard.
+func (d *Daemon) dashboardPairURL() (string, error) {
+	code, err := d.dash.NewPairingCode()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s.%s/pair?code=%s", api.DashboardName, d.tlds()[0], code), nil
+}
+
 func (d *Daemon) handleRequest(w http.ResponseWriter, r *http.Request) {
+	// SECURITY: Reject malformed or oversized Host headers before they reach
+	// route lookup, metrics, or error pages. Only the length is logged.
+	if !api.ValidHost(r.Host) {
+		http.Error(w, "invalid host", http.StatusBadRequest)
+		d.logger.Warn("invalid host rejected", "host_len", len(r.Host), "method", r.Method)
+		return
+	}
+
 	// Dashboard intercept — not recorded in metrics to avoid feedback loop
-	if api.ExtractName(r.Host) == "_paw" {
+	name := api.ExtractNameFor(r.Host, d.tlds())
+	if api.IsReservedName(name) {
 		d.dash.ServeHTTP(w, r)
 		return
 	}
 
 	start := time.Now()
 
-	route, ok := d.registry.LookupByHost(r.Host)
+	route, subdomain, ok := d.registry.LookupSubdomain(name)
+	if !ok {
+		route, ok = d.registry.LookupCanonical(r.Host, d.tlds())
+	}
+	if !ok && isEcho(name) {
+		d.serveEcho(w, r, start)
+		return
+	}
 	if !ok {
 		d.serveNotFound(w, r)
 		elapsed := time.Since(start).Milliseconds()
@@ -451,8 +812,183 @@ func (d *Daemon) handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A passthrough route's upstream expects TLS, which the daemon has
+	// already terminated here (a client that sent no SNI, or a reused
+	// connection), so don't forward the request in the clear. A UDP
+	// route's upstream doesn't speak HTTP at all.
+	if route.Passthrough || route.UDP {
+		msg := "route is TLS passthrough; connect with SNI " + r.Host
+		if route.UDP {
+			msg = "route forwards UDP, not HTTP"
+		}
+		http.Error(w, msg, http.StatusMisdirectedRequest)
+		d.logRequest(start, r, route, http.StatusMisdirectedRequest, nil)
+		return
+	}
+
+	scheme := "https"
+	if r.TLS == nil {
+		scheme = "http"
+	}
+
+	// Canonical redirects go first, so the app only ever sees canonical
+	// URLs, even behind auth.
+	if target, redirect := route.Canonical.Redirect(r, scheme); redirect {
+		status := route.Canonical.RedirectStatus()
+		http.Redirect(w, r, target, status)
+		d.logRequest(start, r, route, status, nil)
+		return
+	}
+
+	d.registry.Touch(route)
+
+	// CORS helper mode: answer preflights here, before auth, since browsers
+	// never send credentials on them. Other responses are decorated below.
+	origin := r.Header.Get("Origin")
+	if route.CORS != nil {
+		if api.IsPreflight(r) {
+			route.CORS.ApplyPreflight(w.Header(), r)
+			w.WriteHeader(http.StatusNoContent)
+			d.logRequest(start, r, route, http.StatusNoContent, nil)
+			return
+		}
+	}
+
+	// Per-route access control is enforced before anything reaches upstream.
+	if route.Auth != nil {
+		if !route.Auth.Authorize(r) {
+			if route.CORS != nil {
+				// Let cross-origin frontends read the 401.
+				route.CORS.ApplyResponse(w.Header(), origin)
+			}
+			w.Header().Set("WWW-Authenticate", route.Auth.Challenge(r.Host))
+			errorpage.Unauthorized(w, errorpage.Language(r), r.Host)
+			d.logRequest(start, r, route, http.StatusUnauthorized, nil)
+			return
+		}
+		// The credentials were meant for paw-proxy, not the upstream app.
+		r.Header.Del("Authorization")
+	}
+
+	// A route in a maintenance window is down on purpose.
+	if route.InMaintenance(time.Now()) {
+		errorpage.Maintenance(w, errorpage.Language(r), r.Host, route.MaintenanceUntil)
+		d.logRequest(start, r, route, http.StatusServiceUnavailable, nil)
+		return
+	}
+
+	// A paused route's dev server is on its way down; show the waiting
+	// page rather than whatever errors it gives while it stops.
+	if route.Paused {
+		errorpage.UpstreamDown(w, errorpage.Language(r), r.Host, route.Upstream)
+		d.logRequest(start, r, route, http.StatusBadGateway, nil)
+		return
+	}
+
+	// Cap the requests in flight to one host; past that, wait for one to
+	// finish rather than open yet another upstream connection.
+	done, ok := d.admitRequest(r)
+	if !ok {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many concurrent requests for "+r.Host, http.StatusServiceUnavailable)
+		d.logRequest(start, r, route, http.StatusServiceUnavailable, nil)
+		return
+	}
+	defer done()
+
+	// Global headers from the config file first, so route headers win.
+	hsts := route.HSTS
+	if rs := d.settings.Load(); rs != nil {
+		for name, value := range rs.headers {
+			r.Header.Set(name, value)
+		}
+		if hsts == "" {
+			hsts = rs.hsts
+		}
+	}
+	for name, value := range route.Headers {
+		r.Header.Set(name, value)
+	}
+	// Only the daemon sets the subdomain header, so apps can trust it.
+	r.Header.Del(api.SubdomainHeader)
+	if subdomain != "" {
+		r.Header.Set(api.SubdomainHeader, subdomain)
+	}
+
+	// Response headers are rewritten after the upstream's are copied, so
+	// ours win. Redirects and cookies naming the upstream itself
+	// (localhost:3000) always point back at the route.
+	timing := &proxy.Timing{}
 	rw := &statusCapture{ResponseWriter: w}
-	d.proxy.ServeHTTP(rw, r, route.Upstream)
+	rw.onHeader = func(h http.Header) {
+		// A stream the request didn't announce (fetch rather than
+		// EventSource) gives its slot back once it starts.
+		if isEventStream(h) {
+			done()
+		}
+		if route.CORS != nil {
+			route.CORS.ApplyResponse(h, origin)
+		}
+		api.ApplyHSTS(h, hsts)
+		route.Rewrite.ApplyResponse(h, timing.AddrOr(route.Upstream), scheme, r.Host)
+		route.Cookies.Apply(h, scheme, r.Host)
+	}
+	ctx := proxy.WithTiming(r.Context(), timing)
+	if route.ProxyProtocol {
+		ctx = proxy.WithProxyProtocol(ctx)
+	}
+	if route.TrustForwarded {
+		ctx = proxy.WithTrustForwarded(ctx)
+	}
+	if host := api.UpstreamHost(route.HostHeader, route.Upstream); host != "" {
+		ctx = proxy.WithUpstreamHost(ctx, host)
+	}
+	if route.Throttle != nil {
+		ctx = proxy.WithThrottle(ctx, proxy.Throttle{
+			DownKbps: route.Throttle.DownKbps,
+			UpKbps:   route.Throttle.UpKbps,
+			Latency:  route.Throttle.Latency(),
+		})
+	}
+	if route.Pool != nil {
+		ctx = proxy.WithPool(ctx, proxy.PoolConfig{
+			MaxIdlePerHost: route.Pool.MaxIdle,
+			IdleTimeout:    route.Pool.Timeout(),
+		})
+	}
+	r = r.WithContext(ctx)
+	var capture *captureRecorder
+	if bodyLimit, ok := d.captures.Watching(route.Name); ok {
+		capture = startCapture(r, rw, route.Name, bodyLimit)
+	}
+	// The mirror and upstream see the rewritten path; logs and captures
+	// keep the one the client asked for.
+	out := r
+	if route.Rewrite != nil {
+		out = route.Rewrite.Request(r)
+	}
+	serve := func(w http.ResponseWriter, out *http.Request) {
+		// Static routes are answered from disk by the daemon itself.
+		if route.Static != nil {
+			static.Handler{Root: route.Static.Root, AutoIndex: route.Static.AutoIndex}.ServeHTTP(w, out)
+			return
+		}
+		upstream := route.Upstream
+		if route.Balance != nil {
+			order := d.balancer.order(route, time.Now())
+			upstream = order[0]
+			out = out.WithContext(proxy.WithFallbacks(out.Context(), order[1:]))
+		}
+		d.proxy.ServeHTTP(w, out, upstream)
+	}
+	if route.Mirror != "" {
+		d.proxy.Mirror(out, route.Mirror)
+	}
+	if route.SPA.Applies(out) {
+		serveSPA(rw, out, route.SPA, serve)
+	} else {
+		serve(rw, out)
+	}
 
 	status := rw.status
 	if status == 0 {
@@ -462,16 +998,37 @@ func (d *Daemon) handleRequest(w http.ResponseWriter, r *http.Request) {
 			status = 200
 		}
 	}
+	if capture != nil {
+		d.captures.Publish(capture.finish(status, rw.Header()))
+	}
 
-	elapsed := time.Since(start).Milliseconds()
+	d.logRequest(start, r, route, status, timing)
+}
+
+// logRequest writes the access log line and records metrics for a request
+// that matched route. timing is nil when the daemon answered without
+// contacting the upstream, in which case all time is proxy overhead.
+func (d *Daemon) logRequest(start time.Time, r *http.Request, route api.Route, status int, timing *proxy.Timing) {
+	total := time.Since(start)
+	elapsed := total.Milliseconds()
+	var dial, upstream time.Duration
+	addr := route.Upstream
+	if timing != nil {
+		dial, upstream = timing.Dial, timing.Upstream
+		addr = timing.AddrOr(addr)
+	}
+	overhead := max(total-dial-upstream, 0)
 	d.logger.Info("request",
 		"host", r.Host,
 		"method", r.Method,
 		"path", r.URL.Path,
 		"route", route.Name,
-		"upstream", route.Upstream,
+		"upstream", addr,
 		"status", status,
 		"duration_ms", elapsed,
+		"dial_ms", fractionalMs(dial),
+		"upstream_ms", fractionalMs(upstream),
+		"proxy_overhead_ms", fractionalMs(overhead),
 	)
 	d.metrics.Record(dashboard.RequestEntry{
 		Timestamp:  start,
@@ -481,43 +1038,59 @@ func (d *Daemon) handleRequest(w http.ResponseWriter, r *http.Request) {
 		StatusCode: status,
 		LatencyMs:  elapsed,
 		Route:      route.Name,
-		Upstream:   route.Upstream,
+		Upstream:   addr,
 	})
 }
 
+// fractionalMs converts d to milliseconds rounded to two decimals, so
+// sub-millisecond proxy overhead is still visible in logs.
+func fractionalMs(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*100) / 100
+}
+
 func (d *Daemon) serveNotFound(w http.ResponseWriter, r *http.Request) {
-	appName := api.ExtractName(r.Host)
+	appName := api.ExtractNameFor(r.Host, d.tlds())
 	routes := d.registry.List()
 	var names []string
 	for _, route := range routes {
 		names = append(names, route.Name)
 	}
-	errorpage.NotFound(w, r.Host, appName, names)
+	errorpage.NotFound(w, errorpage.Language(r), r.Host, appName, names)
 }
 
 // statusCapture wraps an http.ResponseWriter to capture the status code.
 // It forwards Hijack and Flush to the underlying writer so WebSocket
-// and SSE proxying continue to work.
+// and SSE proxying continue to work. If onHeader is set, it is called once
+// with the response headers just before they are sent.
 type statusCapture struct {
 	http.ResponseWriter
-	status  int
-	written bool
+	status   int
+	written  bool
+	onHeader func(http.Header)
+	// onWrite, if set, sees each chunk of the response body.
+	onWrite func([]byte)
 }
 
 func (s *statusCapture) WriteHeader(code int) {
 	if !s.written {
 		s.status = code
 		s.written = true
+		if s.onHeader != nil {
+			s.onHeader(s.Header())
+		}
 	}
 	s.ResponseWriter.WriteHeader(code)
 }
 
 func (s *statusCapture) Write(b []byte) (int, error) {
 	if !s.written {
-		s.status = 200
-		s.written = true
+		s.WriteHeader(http.StatusOK)
+	}
+	n, err := s.ResponseWriter.Write(b)
+	if s.onWrite != nil {
+		s.onWrite(b[:n])
 	}
-	return s.ResponseWriter.Write(b)
+	return n, err
 }
 
 func (s *statusCapture) Hijack() (net.Conn, *bufio.ReadWriter, error) {
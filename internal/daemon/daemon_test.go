@@ -1,21 +1,29 @@
 package daemon
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
+	"io"
 	"log/slog"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/dashboard"
+	"github.com/alexcatdad/paw-proxy/internal/echo"
+	"github.com/alexcatdad/paw-proxy/internal/proxy"
 	"github.com/alexcatdad/paw-proxy/internal/ssl"
 )
 
@@ -66,6 +74,13 @@ func TestRedirectTarget(t *testing.T) {
 			tld:        "test",
 			wantOK:     false,
 		},
+		{
+			name:       "reject oversized host",
+			host:       strings.Repeat("a", 10*1024) + ".test",
+			requestURI: "/",
+			tld:        "test",
+			wantOK:     false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -209,3 +224,603 @@ func TestLogFilePermissions(t *testing.T) {
 		t.Errorf("expected log file permissions 0600, got %04o", perm)
 	}
 }
+
+func TestHandleRequest_EnforcesRouteAuth(t *testing.T) {
+	var gotAuth string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	if err := registry.RegisterRoute(api.Route{
+		Name:     "myapp",
+		Upstream: strings.TrimPrefix(upstream.URL, "http://"),
+		Dir:      "/tmp/myapp",
+		Auth:     &api.RouteAuth{Username: "dev", Password: "s3cret"},
+	}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+
+	req := httptest.NewRequest("GET", "https://myapp.test/", nil)
+	w := httptest.NewRecorder()
+	d.handleRequest(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without credentials, got %d", w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic ") {
+		t.Errorf("expected Basic challenge, got %q", w.Header().Get("WWW-Authenticate"))
+	}
+
+	req = httptest.NewRequest("GET", "https://myapp.test/", nil)
+	req.SetBasicAuth("dev", "s3cret")
+	w = httptest.NewRecorder()
+	d.handleRequest(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with credentials, got %d", w.Code)
+	}
+	if gotAuth != "" {
+		t.Errorf("expected proxy credentials stripped before upstream, got %q", gotAuth)
+	}
+}
+
+func TestHandleRequest_InjectsRouteHeaders(t *testing.T) {
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer upstream.Close()
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	if err := registry.RegisterRoute(api.Route{
+		Name:     "myapp",
+		Upstream: strings.TrimPrefix(upstream.URL, "http://"),
+		Dir:      "/tmp/myapp",
+		Headers:  map[string]string{"X-Team": "core"},
+	}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+
+	req := httptest.NewRequest("GET", "https://myapp.test/", nil)
+	req.Header.Set("X-Team", "spoofed")
+	d.handleRequest(httptest.NewRecorder(), req)
+
+	if got.Get("X-Team") != "core" {
+		t.Errorf("X-Team = %q, want core", got.Get("X-Team"))
+	}
+}
+
+func TestHandleRequest_CORSHelperMode(t *testing.T) {
+	var upstreamHits int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHits++
+		w.Header().Set("Access-Control-Allow-Origin", "https://other.test")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	if err := registry.RegisterRoute(api.Route{
+		Name:     "api",
+		Upstream: strings.TrimPrefix(upstream.URL, "http://"),
+		Dir:      "/tmp/api",
+		CORS:     &api.CORSConfig{},
+	}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+
+	// Preflight is answered by the daemon
+	req := httptest.NewRequest("OPTIONS", "https://api.test/items", nil)
+	req.Header.Set("Origin", "https://app.test")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	d.handleRequest(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("preflight status = %d, want 204", w.Code)
+	}
+	if upstreamHits != 0 {
+		t.Errorf("preflight reached upstream")
+	}
+
+	// Actual request gets CORS headers that override the upstream's
+	req = httptest.NewRequest("GET", "https://api.test/items", nil)
+	req.Header.Set("Origin", "https://app.test")
+	w = httptest.NewRecorder()
+	d.handleRequest(w, req)
+
+	if got := w.Header().Values("Access-Control-Allow-Origin"); len(got) != 1 || got[0] != "https://app.test" {
+		t.Errorf("Allow-Origin = %v, want [https://app.test]", got)
+	}
+}
+
+func TestHandleRequest_HSTS(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains; preload")
+	}))
+	defer upstream.Close()
+	addr := strings.TrimPrefix(upstream.URL, "http://")
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	registry.Register("shop", addr, "/tmp/shop")
+	if err := registry.RegisterRoute(api.Route{Name: "legacy", Upstream: addr, Dir: "/tmp/legacy", HSTS: api.HSTSKeep}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+	get := func(host string) string {
+		w := httptest.NewRecorder()
+		d.handleRequest(w, httptest.NewRequest("GET", "https://"+host+"/", nil))
+		return w.Header().Get("Strict-Transport-Security")
+	}
+
+	// Without config the header passes through.
+	if got := get("shop.test"); got != "max-age=31536000; includeSubDomains; preload" {
+		t.Errorf("default: HSTS = %q", got)
+	}
+
+	d.settings.Store(&runtimeSettings{tlds: []string{"test"}, hsts: api.HSTSRewrite})
+	if got := get("shop.test"); got != "max-age=31536000" {
+		t.Errorf("rewrite: HSTS = %q", got)
+	}
+	// The route's own mode wins over the daemon's.
+	if got := get("legacy.test"); got != "max-age=31536000; includeSubDomains; preload" {
+		t.Errorf("route keep: HSTS = %q", got)
+	}
+
+	d.settings.Store(&runtimeSettings{tlds: []string{"test"}, hsts: api.HSTSStrip})
+	if got := get("shop.test"); got != "" {
+		t.Errorf("strip: HSTS = %q", got)
+	}
+}
+
+func TestHandleRequest_Rewrite(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "http://"+r.Header.Get("X-Test-Upstream")+"/new", http.StatusFound)
+			return
+		}
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer upstream.Close()
+	addr := strings.TrimPrefix(upstream.URL, "http://")
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	if err := registry.RegisterRoute(api.Route{
+		Name:     "shop",
+		Upstream: addr,
+		Dir:      "/tmp/shop",
+		Headers:  map[string]string{"X-Test-Upstream": addr},
+		Rewrite:  &api.RewriteConfig{StripPrefix: "/api", Locations: []string{addr}},
+	}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+
+	w := httptest.NewRecorder()
+	d.handleRequest(w, httptest.NewRequest("GET", "https://shop.test/api/users", nil))
+	if got := w.Body.String(); got != "/users" {
+		t.Errorf("upstream path = %q, want /users", got)
+	}
+
+	w = httptest.NewRecorder()
+	d.handleRequest(w, httptest.NewRequest("GET", "https://shop.test/api/old", nil))
+	if got := w.Header().Get("Location"); got != "https://shop.test/api/new" {
+		t.Errorf("Location = %q, want https://shop.test/api/new", got)
+	}
+
+	// Redirects to the upstream itself are rewritten without any rules.
+	registry.Register("blog", addr, "/tmp/blog")
+	req := httptest.NewRequest("GET", "https://blog.test/old", nil)
+	req.Header.Set("X-Test-Upstream", addr)
+	w = httptest.NewRecorder()
+	d.handleRequest(w, req)
+	if got := w.Header().Get("Location"); got != "https://blog.test/new" {
+		t.Errorf("default Location = %q, want https://blog.test/new", got)
+	}
+}
+
+func TestHandleRequest_SPA(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.html":
+			w.Header().Set("X-Page", "index")
+			w.Write([]byte("app shell"))
+		case "/gone":
+			http.Error(w, "gone", http.StatusGone)
+		default:
+			w.Header().Set("X-Page", "missing")
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+	addr := strings.TrimPrefix(upstream.URL, "http://")
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	if err := registry.RegisterRoute(api.Route{Name: "shop", Upstream: addr, Dir: "/tmp/shop", SPA: &api.SPAConfig{}}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+	if err := registry.RegisterRoute(api.Route{Name: "blog", Upstream: addr, Dir: "/tmp/blog", SPA: &api.SPAConfig{Statuses: []int{404, 410}, KeepStatus: true}}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+	get := func(url, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		d.handleRequest(w, req)
+		return w
+	}
+	const html = "text/html,application/xhtml+xml,*/*;q=0.8"
+
+	w := get("https://shop.test/settings/profile", html)
+	if w.Code != http.StatusOK || w.Body.String() != "app shell" || w.Header().Get("X-Page") != "index" {
+		t.Errorf("deep link: %d %q X-Page=%q, want the index page", w.Code, w.Body.String(), w.Header().Get("X-Page"))
+	}
+	// Missing files and non-navigations keep their 404.
+	if w := get("https://shop.test/assets/app.js", "*/*"); w.Code != http.StatusNotFound {
+		t.Errorf("missing asset: %d, want 404", w.Code)
+	}
+	if w := get("https://shop.test/api/users", "application/json"); w.Code != http.StatusNotFound {
+		t.Errorf("API call: %d, want 404", w.Code)
+	}
+	// Statuses the route doesn't list pass through.
+	if w := get("https://shop.test/gone", html); w.Code != http.StatusGone {
+		t.Errorf("410 on default statuses: %d, want 410", w.Code)
+	}
+
+	// KeepStatus serves the index with the upstream's status.
+	w = get("https://blog.test/gone", html)
+	if w.Code != http.StatusGone || w.Body.String() != "app shell" {
+		t.Errorf("keepStatus: %d %q, want 410 with the index page", w.Code, w.Body.String())
+	}
+	entries, _ := d.metrics.Since(0)
+	if last := entries[len(entries)-1]; last.StatusCode != http.StatusGone || last.Path != "/gone" {
+		t.Errorf("logged %d %s, want 410 /gone", last.StatusCode, last.Path)
+	}
+}
+
+func TestHandleRequest_Canonical(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + r.URL.Path))
+	}))
+	defer upstream.Close()
+	addr := strings.TrimPrefix(upstream.URL, "http://")
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	canonical := &api.CanonicalConfig{TrailingSlash: api.TrailingSlashAdd, LowercaseHost: true, StripWWW: true}
+	if err := registry.RegisterRoute(api.Route{Name: "shop", Upstream: addr, Dir: "/tmp/shop", Canonical: canonical}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		d.handleRequest(w, httptest.NewRequest("GET", url, nil))
+		return w
+	}
+
+	for url, want := range map[string]string{
+		"https://www.shop.test/cart/": "https://shop.test/cart/",
+		"https://Shop.test/cart/":     "https://shop.test/cart/",
+		"https://shop.test/cart?id=1": "https://shop.test/cart/?id=1",
+	} {
+		w := get(url)
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != want {
+			t.Errorf("GET %s: %d Location=%q, want 301 to %s", url, w.Code, w.Header().Get("Location"), want)
+		}
+	}
+	if w := get("https://shop.test/cart/"); w.Code != http.StatusOK || w.Body.String() != "shop.test/cart/" {
+		t.Errorf("canonical URL: %d %q, want it proxied", w.Code, w.Body.String())
+	}
+	// Only the route's own www name is redirected.
+	if w := get("https://www.other.test/"); w.Header().Get("Location") != "" {
+		t.Errorf("unknown www host redirected to %s", w.Header().Get("Location"))
+	}
+	entries, _ := d.metrics.Since(0)
+	if first := entries[0]; first.StatusCode != http.StatusMovedPermanently {
+		t.Errorf("logged %d for a redirect, want 301", first.StatusCode)
+	}
+}
+
+func TestHandleRequest_Subdomains(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Paw-Subdomain")))
+	}))
+	defer upstream.Close()
+	addr := strings.TrimPrefix(upstream.URL, "http://")
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	if err := registry.RegisterRoute(api.Route{Name: "shop", Upstream: addr, Dir: "/tmp/shop", Subdomains: true}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+	get := func(host string) (int, string) {
+		req := httptest.NewRequest("GET", "https://"+host+"/", nil)
+		req.Header.Set("X-Paw-Subdomain", "spoofed")
+		w := httptest.NewRecorder()
+		d.handleRequest(w, req)
+		return w.Code, w.Body.String()
+	}
+
+	if code, body := get("tenant1.shop.test"); code != http.StatusOK || body != "tenant1" {
+		t.Errorf("tenant1.shop.test = %d %q, want 200 tenant1", code, body)
+	}
+	if code, body := get("shop.test"); code != http.StatusOK || body != "" {
+		t.Errorf("shop.test = %d %q, want 200 with no subdomain", code, body)
+	}
+}
+
+func TestHandleHTTP_PlainHTTPRoute(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Forwarded-Proto")))
+	}))
+	defer upstream.Close()
+	addr := strings.TrimPrefix(upstream.URL, "http://")
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	registry.Register("shop", addr, "/tmp/shop")
+	if err := registry.RegisterRoute(api.Route{Name: "printer", Upstream: addr, Dir: "/tmp/printer", PlainHTTP: true}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+
+	w := httptest.NewRecorder()
+	d.handleHTTP(w, httptest.NewRequest("GET", "http://shop.test/cart", nil))
+	if w.Code != http.StatusPermanentRedirect || w.Header().Get("Location") != "https://shop.test/cart" {
+		t.Errorf("shop: got %d to %q, want redirect to HTTPS", w.Code, w.Header().Get("Location"))
+	}
+
+	w = httptest.NewRecorder()
+	d.handleHTTP(w, httptest.NewRequest("GET", "http://printer.test/status", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "http" {
+		t.Errorf("printer over HTTP: got %d %q, want 200 proxied with proto http", w.Code, w.Body.String())
+	}
+
+	// HTTPS still serves the plain HTTP route.
+	w = httptest.NewRecorder()
+	d.handleRequest(w, httptest.NewRequest("GET", "https://printer.test/status", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "https" {
+		t.Errorf("printer over HTTPS: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleRequest_LogsTimingBreakdown(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer upstream.Close()
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	registry.Register("myapp", strings.TrimPrefix(upstream.URL, "http://"), "/tmp/myapp")
+
+	var logs bytes.Buffer
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(&logs, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+
+	d.handleRequest(httptest.NewRecorder(), httptest.NewRequest("GET", "https://myapp.test/", nil))
+
+	var entry struct {
+		Duration int64    `json:"duration_ms"`
+		Dial     *float64 `json:"dial_ms"`
+		Upstream *float64 `json:"upstream_ms"`
+		Overhead *float64 `json:"proxy_overhead_ms"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("decoding log line %q: %v", logs.String(), err)
+	}
+	if entry.Dial == nil || entry.Upstream == nil || entry.Overhead == nil {
+		t.Fatalf("missing timing fields in log line: %s", logs.String())
+	}
+	if *entry.Upstream < 10 {
+		t.Errorf("expected upstream_ms >= 10, got %v", *entry.Upstream)
+	}
+	if *entry.Overhead < 0 {
+		t.Errorf("expected non-negative proxy_overhead_ms, got %v", *entry.Overhead)
+	}
+	if sum := *entry.Dial + *entry.Upstream + *entry.Overhead; sum+1 < float64(entry.Duration) {
+		t.Errorf("breakdown %v does not account for duration_ms %d", sum, entry.Duration)
+	}
+}
+
+func TestHandleRequest_RejectsInvalidHost(t *testing.T) {
+	d := &Daemon{
+		registry: api.NewRouteRegistry(30 * time.Second),
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+
+	hosts := []string{
+		strings.Repeat("a", 10*1024) + ".test",
+		strings.Repeat("a", 64) + ".test",
+		"my app.test",
+		"<script>.test",
+		"..test",
+	}
+	for _, host := range hosts {
+		req := httptest.NewRequest("GET", "https://myapp.test/", nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		d.handleRequest(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("host %.20q: expected 400, got %d", host, w.Code)
+		}
+		if w.Body.Len() > 64 {
+			t.Errorf("host %.20q: response echoed %d bytes", host, w.Body.Len())
+		}
+	}
+	if got := len(d.metrics.Recent(10)); got != 0 {
+		t.Errorf("expected invalid hosts not to be recorded in metrics, got %d", got)
+	}
+}
+
+func TestHandleRequest_ServesDashboard(t *testing.T) {
+	metrics := dashboard.NewMetrics(10)
+	registry := api.NewRouteRegistry(30 * time.Second)
+	dash, err := dashboard.New(metrics, registry, "test", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  metrics,
+		dash:     dash,
+	}
+
+	for _, host := range []string{"_paw.test", "_PAW.test", "_paw.test:443"} {
+		req := httptest.NewRequest("GET", "https://_paw.test/", nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		d.handleRequest(w, req)
+
+		// The dashboard answers (and asks for sign-in) instead of the
+		// route-not-found page.
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected dashboard 401, got %d", host, w.Code)
+		}
+		if w.Header().Get("Content-Security-Policy") == "" {
+			t.Errorf("%s: expected dashboard CSP header", host)
+		}
+	}
+	if got := len(metrics.Recent(10)); got != 0 {
+		t.Errorf("dashboard requests should not be recorded, got %d", got)
+	}
+}
+
+func TestHandleRequest_PausedRouteServesWaitingPage(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("paused route reached the upstream")
+	}))
+	defer upstream.Close()
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	if err := registry.Register("shop", strings.TrimPrefix(upstream.URL, "http://"), "/tmp/shop"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := registry.Pause("shop"); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+	w := httptest.NewRecorder()
+	d.handleRequest(w, httptest.NewRequest("GET", "https://shop.test/", nil))
+	if w.Code != http.StatusBadGateway || !strings.Contains(w.Body.String(), "Waiting") {
+		t.Errorf("status = %d, want the waiting page", w.Code)
+	}
+}
+
+func TestHandleRequest_Echo(t *testing.T) {
+	registry := api.NewRouteRegistry(30 * time.Second)
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+
+	req := httptest.NewRequest("POST", "https://echo.test/hook?x=1", strings.NewReader(`{"event":"push"}`))
+	req.RemoteAddr = "127.0.0.1:5000"
+	req.Header.Set("Connection", "keep-alive, X-Hop")
+	req.Header.Set("X-Hop", "1")
+	req.Header.Set(api.SubdomainHeader, "spoofed")
+	w := httptest.NewRecorder()
+	d.handleRequest(w, req)
+
+	var got echo.Request
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding echo: %v (%s)", err, w.Body.String())
+	}
+	if got.Method != "POST" || got.URL != "/hook?x=1" || got.Body != `{"event":"push"}` {
+		t.Errorf("echo = %s %s %q", got.Method, got.URL, got.Body)
+	}
+	// The headers are the ones an upstream would get.
+	if got.Headers.Get("X-Forwarded-Host") != "echo.test" || got.Headers.Get("X-Forwarded-Proto") != "https" {
+		t.Errorf("forwarding headers missing: %v", got.Headers)
+	}
+	for _, name := range []string{"Connection", "X-Hop", api.SubdomainHeader} {
+		if got.Headers.Get(name) != "" {
+			t.Errorf("echo shows %s, which the proxy doesn't forward", name)
+		}
+	}
+	if got.TLS == nil || got.TLS.ServerName != "echo.test" {
+		t.Errorf("TLS = %+v, want the client connection", got.TLS)
+	}
+
+	// A route registered as echo takes the name over.
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "app")
+	}))
+	defer upstream.Close()
+	registry.RegisterRoute(api.Route{Name: "echo", Upstream: strings.TrimPrefix(upstream.URL, "http://"), Dir: "/tmp/echo"})
+	w = httptest.NewRecorder()
+	d.handleRequest(w, httptest.NewRequest("GET", "https://echo.test/", nil))
+	if w.Body.String() != "app" {
+		t.Errorf("registered echo route got %q, want the app", w.Body.String())
+	}
+}
@@ -0,0 +1,36 @@
+package daemon
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/echo"
+	"github.com/alexcatdad/paw-proxy/internal/proxy"
+)
+
+// echoName is the name the daemon answers itself, with a reflection of
+// each request, while no route is registered under it.
+const echoName = "echo"
+
+// isEcho reports whether an unrouted name is the built-in echo route.
+func isEcho(name string) bool {
+	return strings.EqualFold(name, echoName)
+}
+
+// serveEcho answers r with the request an app behind the proxy would get,
+// global headers from the config file and forwarding headers included,
+// plus the client's TLS connection, which apps never see.
+func (d *Daemon) serveEcho(w http.ResponseWriter, r *http.Request, start time.Time) {
+	out := r.Clone(r.Context())
+	if rs := d.settings.Load(); rs != nil {
+		for name, value := range rs.headers {
+			out.Header.Set(name, value)
+		}
+	}
+	out.Header.Del(api.SubdomainHeader)
+	out.Header = proxy.OutboundHeader(out)
+	echo.Handler{}.ServeHTTP(w, out)
+	d.logRequest(start, r, api.Route{Name: echoName}, http.StatusOK, nil)
+}
@@ -0,0 +1,335 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// handoffEnv passes a daemon restarting in place the descriptors it
+// inherited from its previous binary: "name=fd,fd;name=fd". The names are
+// the sockets ("http", "https", "dns", "api", "api-tcp", and "http-listen"
+// and "https-listen" for --listen-addr) plus "state", a file holding the
+// route registry.
+const handoffEnv = "PAW_PROXY_HANDOFF"
+
+// handoff tracks the sockets that survive an in-place restart: the ones
+// inherited from the previous process until they are claimed, and the
+// listeners in use that the next process should get.
+type handoff struct {
+	mu        sync.Mutex
+	inherited map[string][]*os.File
+	live      map[string][]filer
+}
+
+// filer is a listener or packet connection whose socket can be
+// duplicated, like *net.TCPListener, *net.UnixListener and *net.UDPConn.
+type filer interface {
+	File() (*os.File, error)
+}
+
+// inheritHandoff picks up the descriptors named in handoffEnv and clears
+// it, so hooks and other children don't see it.
+func inheritHandoff() (*handoff, error) {
+	h := &handoff{inherited: make(map[string][]*os.File), live: make(map[string][]filer)}
+	value, ok := os.LookupEnv(handoffEnv)
+	if !ok {
+		return h, nil
+	}
+	os.Unsetenv(handoffEnv)
+	fds, err := parseHandoff(value)
+	if err != nil {
+		return h, err
+	}
+	for name, list := range fds {
+		for _, fd := range list {
+			h.inherited[name] = append(h.inherited[name], os.NewFile(uintptr(fd), "handoff-"+name))
+		}
+	}
+	return h, nil
+}
+
+// parseHandoff parses a handoffEnv value.
+func parseHandoff(value string) (map[string][]int, error) {
+	fds := make(map[string][]int)
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		name, list, ok := strings.Cut(part, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid %s entry %q", handoffEnv, part)
+		}
+		for _, s := range strings.Split(list, ",") {
+			fd, err := strconv.Atoi(s)
+			if err != nil || fd < 3 {
+				return nil, fmt.Errorf("invalid %s descriptor %q for %s", handoffEnv, s, name)
+			}
+			fds[name] = append(fds[name], fd)
+		}
+	}
+	return fds, nil
+}
+
+// formatHandoff is the inverse of parseHandoff.
+func formatHandoff(fds map[string][]int) string {
+	names := make([]string, 0, len(fds))
+	for name := range fds {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		list := make([]string, len(fds[name]))
+		for i, fd := range fds[name] {
+			list[i] = strconv.Itoa(fd)
+		}
+		parts = append(parts, name+"="+strings.Join(list, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// claim removes and returns the inherited files for name.
+func (h *handoff) claim(name string) []*os.File {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	files := h.inherited[name]
+	delete(h.inherited, name)
+	return files
+}
+
+// listeners returns the listeners inherited under name, in the shape of
+// the launchd and systemd ActivateSocket functions.
+func (h *handoff) listeners(name string) ([]net.Listener, bool, error) {
+	files := h.claim(name)
+	if len(files) == 0 {
+		return nil, false, nil
+	}
+	var ls []net.Listener
+	for _, f := range files {
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range ls {
+				l.Close()
+			}
+			return nil, false, fmt.Errorf("inherited %s socket: %w", name, err)
+		}
+		ls = append(ls, l)
+	}
+	return ls, true, nil
+}
+
+// packetConn returns the UDP socket inherited under name, or nil.
+func (h *handoff) packetConn(name string) (net.PacketConn, error) {
+	files := h.claim(name)
+	if len(files) == 0 {
+		return nil, nil
+	}
+	for _, f := range files[1:] {
+		f.Close()
+	}
+	defer files[0].Close()
+	pc, err := net.FilePacketConn(files[0])
+	if err != nil {
+		return nil, fmt.Errorf("inherited %s socket: %w", name, err)
+	}
+	return pc, nil
+}
+
+// state returns the saved route registry, if one was inherited.
+func (h *handoff) state() ([]byte, error) {
+	files := h.claim("state")
+	if len(files) == 0 {
+		return nil, nil
+	}
+	for _, f := range files[1:] {
+		f.Close()
+	}
+	defer files[0].Close()
+	return io.ReadAll(files[0])
+}
+
+// closeUnclaimed closes inherited descriptors nothing asked for, e.g. a
+// socket for a port no longer configured.
+func (h *handoff) closeUnclaimed() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for name, files := range h.inherited {
+		for _, f := range files {
+			f.Close()
+		}
+		delete(h.inherited, name)
+	}
+}
+
+// keep records the sockets serving name, replacing any recorded before.
+// Values that can't be duplicated are skipped.
+func (h *handoff) keep(name string, sockets ...any) {
+	if h == nil {
+		return
+	}
+	var fs []filer
+	for _, s := range sockets {
+		if f, ok := s.(filer); ok {
+			fs = append(fs, f)
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.live[name] = fs
+}
+
+// dup duplicates every recorded socket, so they stay open after the
+// servers using them shut down.
+func (h *handoff) dup() (map[string][]*os.File, error) {
+	if h == nil {
+		return nil, errors.New("no sockets to hand off")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	files := make(map[string][]*os.File)
+	for name, fs := range h.live {
+		for _, s := range fs {
+			f, err := s.File()
+			if err != nil {
+				closeFiles(files)
+				return nil, fmt.Errorf("duplicating %s socket: %w", name, err)
+			}
+			files[name] = append(files[name], f)
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no sockets to hand off")
+	}
+	return files, nil
+}
+
+func closeFiles(files map[string][]*os.File) {
+	for _, list := range files {
+		for _, f := range list {
+			f.Close()
+		}
+	}
+}
+
+// requestRestart asks Run to restart the daemon in place.
+func (d *Daemon) requestRestart() error {
+	if !restartSupported {
+		return errors.New("restarting in place is not supported on this platform")
+	}
+	select {
+	case d.restartCh <- struct{}{}:
+	default: // one is already pending
+	}
+	return nil
+}
+
+// prepareHandoff duplicates the sockets the next process will serve, so
+// they outlive the shutdown of this one. Connections that arrive in the
+// meantime wait in the kernel's backlog. It returns nil, and the daemon
+// keeps running, if the sockets can't be passed on.
+func (d *Daemon) prepareHandoff() map[string][]*os.File {
+	if l := d.apiServer.Listener(); l != nil {
+		if ul, ok := l.(*net.UnixListener); ok {
+			// The next process serves the same socket file.
+			ul.SetUnlinkOnClose(false)
+		}
+		d.handoff.keep("api", l)
+	}
+	if l := d.apiServer.TCPListener(); l != nil {
+		d.handoff.keep("api-tcp", l)
+	}
+	if pc := d.dnsServer.PacketConn(); pc != nil {
+		d.handoff.keep("dns", pc)
+	}
+	files, err := d.handoff.dup()
+	if err != nil {
+		d.logger.Error("restart in place failed; still running", "error", err)
+		return nil
+	}
+	d.logger.Info("restarting in place: draining connections")
+	return files
+}
+
+// adoptHandoff takes over the routes and the DNS and API sockets passed by
+// the previous process of a daemon restarting in place. The HTTP and HTTPS
+// sockets are picked up by activateSocket. A TCP API socket is only taken
+// over while it is still on the configured address; otherwise it is
+// closed with the other unclaimed descriptors.
+func (d *Daemon) adoptHandoff() {
+	if data, err := d.handoff.state(); err != nil {
+		d.logger.Warn("reading inherited routes failed", "error", err)
+	} else if data != nil {
+		n, err := d.registry.Restore(data)
+		if err != nil {
+			d.logger.Warn("restoring inherited routes failed", "error", err)
+		} else {
+			d.logger.Info("restarted in place", "routes", n)
+		}
+	}
+	if pc, err := d.handoff.packetConn("dns"); err != nil {
+		d.logger.Warn("inherited DNS socket unusable", "error", err)
+	} else if pc != nil {
+		d.dnsServer.SetPacketConn(pc)
+	}
+	if ls, ok, err := d.handoff.listeners("api"); err != nil {
+		d.logger.Warn("inherited API socket unusable", "error", err)
+	} else if ok {
+		for _, extra := range ls[1:] {
+			extra.Close()
+		}
+		d.apiServer.SetListener(ls[0])
+	}
+	if d.config.APIAddr == "" {
+		return
+	}
+	if l := d.inheritedListener("api-tcp", d.config.APIAddr); l != nil {
+		d.apiServer.SetTCPListener(l)
+	}
+}
+
+// inheritedListener returns the listener passed under name if it is still
+// on addr, or nil. One on another address is closed, so it can be bound
+// again.
+func (d *Daemon) inheritedListener(name, addr string) net.Listener {
+	ls, ok, err := d.handoff.listeners(name)
+	if err != nil {
+		d.logger.Warn("inherited socket unusable", "socket", name, "error", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	for _, extra := range ls[1:] {
+		extra.Close()
+	}
+	if !sameAddr(ls[0].Addr().String(), addr) {
+		ls[0].Close()
+		return nil
+	}
+	return ls[0]
+}
+
+// sameAddr reports whether the IP:port addresses a and b are equal.
+func sameAddr(a, b string) bool {
+	ap, err := netip.ParseAddrPort(a)
+	if err != nil {
+		return false
+	}
+	bp, err := netip.ParseAddrPort(b)
+	return err == nil && ap == bp
+}
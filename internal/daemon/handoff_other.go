@@ -0,0 +1,17 @@
+//go:build !darwin && !linux
+
+package daemon
+
+import (
+	"errors"
+	"os"
+)
+
+// restartSignals is empty: restarting in place needs exec.
+var restartSignals []os.Signal
+
+const restartSupported = false
+
+func (d *Daemon) execHandoff(files map[string][]*os.File) error {
+	return errors.New("restarting in place is not supported on this platform")
+}
@@ -0,0 +1,146 @@
+package daemon
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+func TestParseFormatHandoff(t *testing.T) {
+	fds := map[string][]int{"http": {3, 4}, "https": {5}, "state": {9}}
+	value := formatHandoff(fds)
+	if value != "http=3,4;https=5;state=9" {
+		t.Errorf("formatHandoff = %q", value)
+	}
+	got, err := parseHandoff(value)
+	if err != nil {
+		t.Fatalf("parseHandoff: %v", err)
+	}
+	if !reflect.DeepEqual(got, fds) {
+		t.Errorf("parseHandoff = %v, want %v", got, fds)
+	}
+
+	for _, bad := range []string{"http", "=3", "http=x", "http=1"} {
+		if _, err := parseHandoff(bad); err == nil {
+			t.Errorf("parseHandoff(%q) succeeded", bad)
+		}
+	}
+}
+
+// A connection made while the sockets change hands waits in the backlog
+// and is accepted by the next process instead of being refused.
+func TestHandoffKeepsListenerOpen(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+
+	old := &handoff{live: make(map[string][]filer)}
+	old.keep("https", l)
+	files, err := old.dup()
+	if err != nil {
+		t.Fatalf("dup: %v", err)
+	}
+	l.Close() // the old server shuts down
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial during handoff: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("hello"))
+
+	next := &handoff{inherited: files}
+	ls, ok, err := next.listeners("https")
+	if err != nil || !ok || len(ls) != 1 {
+		t.Fatalf("listeners = %v, %v, %v", ls, ok, err)
+	}
+	defer ls[0].Close()
+	c, err := ls[0].Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	defer c.Close()
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(c, buf); err != nil || string(buf) != "hello" {
+		t.Errorf("read %q, %v", buf, err)
+	}
+
+	if _, ok, _ := next.listeners("https"); ok {
+		t.Error("listeners claimed twice")
+	}
+}
+
+func TestHandoffState(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"routes":[]}`)
+	f.Seek(0, 0)
+
+	h := &handoff{inherited: map[string][]*os.File{"state": {f}}}
+	data, err := h.state()
+	if err != nil || string(data) != `{"routes":[]}` {
+		t.Errorf("state = %q, %v", data, err)
+	}
+
+	var none *handoff
+	if data, err := none.state(); data != nil || err != nil {
+		t.Errorf("nil handoff state = %q, %v", data, err)
+	}
+}
+
+func TestAdoptHandoffTCPAPI(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	old := &handoff{live: make(map[string][]filer)}
+	old.keep("api-tcp", l)
+	handoffFiles := func() map[string][]*os.File {
+		files, err := old.dup()
+		if err != nil {
+			t.Fatalf("dup: %v", err)
+		}
+		return files
+	}
+	newDaemon := func(apiAddr string) *Daemon {
+		registry := api.NewRouteRegistry(30 * time.Second)
+		return &Daemon{
+			config:    &Config{APIAddr: apiAddr},
+			registry:  registry,
+			apiServer: api.NewServer(filepath.Join(t.TempDir(), "api.sock"), registry),
+			logger:    slog.New(slog.NewJSONHandler(io.Discard, nil)),
+			handoff:   &handoff{inherited: handoffFiles()},
+		}
+	}
+
+	// The old process still holds the port, so binding it would fail.
+	d := newDaemon(addr)
+	d.adoptHandoff()
+	if err := d.apiServer.ListenTCP(addr, "secret"); err != nil {
+		t.Fatalf("ListenTCP with the inherited socket: %v", err)
+	}
+	if got := d.apiServer.TCPListener(); got == nil || got.Addr().String() != addr {
+		t.Errorf("TCP API listener = %v, want the inherited one on %s", got, addr)
+	}
+	d.apiServer.TCPListener().Close()
+
+	// A socket for an address no longer configured isn't used.
+	d = newDaemon("127.0.0.1:1")
+	d.adoptHandoff()
+	if got := d.apiServer.TCPListener(); got != nil {
+		t.Errorf("adopted a TCP API socket on %s for another address", got.Addr())
+	}
+	l.Close()
+}
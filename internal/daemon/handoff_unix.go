@@ -0,0 +1,74 @@
+//go:build darwin || linux
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// restartSignals restart the daemon in place, like POST /restart.
+var restartSignals = []os.Signal{syscall.SIGUSR2}
+
+// restartSupported reports whether the daemon can restart in place.
+const restartSupported = true
+
+// execHandoff replaces the daemon with the binary now at its path, passing
+// it files and the route registry. The process ID stays the same, so
+// launchd and systemd don't notice. It only returns on failure.
+func (d *Daemon) execHandoff(files map[string][]*os.File) error {
+	state, err := d.registry.Snapshot()
+	if err != nil {
+		return fmt.Errorf("saving routes: %w", err)
+	}
+	// An unlinked file: the routes (tokens and credentials included) never
+	// stay on disk.
+	f, err := os.CreateTemp(d.config.SupportDir, ".handoff-*")
+	if err != nil {
+		return fmt.Errorf("saving routes: %w", err)
+	}
+	os.Remove(f.Name())
+	if _, err := f.Write(state); err != nil {
+		f.Close()
+		return fmt.Errorf("saving routes: %w", err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		f.Close()
+		return fmt.Errorf("saving routes: %w", err)
+	}
+	files["state"] = []*os.File{f}
+
+	fds := make(map[string][]int)
+	for name, list := range files {
+		for _, f := range list {
+			fd := int(f.Fd())
+			// Let the descriptor survive exec.
+			if _, err := unix.FcntlInt(uintptr(fd), unix.F_SETFD, 0); err != nil {
+				return fmt.Errorf("passing %s socket: %w", name, err)
+			}
+			fds[name] = append(fds[name], fd)
+		}
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	env := make([]string, 0, len(os.Environ())+1)
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, handoffEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+	env = append(env, handoffEnv+"="+formatHandoff(fds))
+
+	d.logger.Info("restarting in place", "binary", exe)
+	if d.logFile != nil {
+		d.logFile.Sync()
+	}
+	return syscall.Exec(exe, os.Args, env)
+}
@@ -0,0 +1,257 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMaxConnections caps the client connections open on the HTTP and
+// HTTPS listeners together when the config file does not override it.
+// Each proxied request holds an upstream connection too, so the daemon's
+// descriptors stay well under the usual 10240 limit.
+const defaultMaxConnections = 1024
+
+// defaultMaxRequestsPerHost caps the requests in flight to one host when
+// the config file does not override it. It matches the streams a browser
+// may open on one HTTP/2 connection.
+const defaultMaxRequestsPerHost = 256
+
+// hostQueueTimeout is how long a request over the per-host limit waits
+// for a slot before it gets a 503.
+const hostQueueTimeout = 10 * time.Second
+
+// connLimit caps the connections open across the listeners it wraps.
+// At the cap, Accept waits and new connections queue in the kernel's
+// backlog; idle keep-alive connections are closed to make room, so
+// clients that are done can't starve new ones.
+type connLimit struct {
+	max func() int
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	open int
+	idle map[net.Conn]struct{}
+}
+
+func newConnLimit(max func() int) *connLimit {
+	c := &connLimit{max: max, idle: make(map[net.Conn]struct{})}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// listener wraps l so its connections count against the limit. A nil
+// connLimit leaves l as it is.
+func (c *connLimit) listener(l net.Listener) net.Listener {
+	if c == nil {
+		return l
+	}
+	return &limitedListener{Listener: l, limit: c}
+}
+
+// trackState is an http.Server ConnState hook that remembers which
+// connections are idle between requests.
+func (c *connLimit) trackState(conn net.Conn, state http.ConnState) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if state == http.StateIdle {
+		c.idle[conn] = struct{}{}
+	} else {
+		delete(c.idle, conn)
+	}
+}
+
+// wake re-checks waiting Accepts, e.g. after a reload raised the limit.
+func (c *connLimit) wake() {
+	if c == nil {
+		return
+	}
+	c.cond.Broadcast()
+}
+
+// acquire waits for a free connection slot, closing an idle connection
+// if that makes one. It returns false once l is closed.
+func (c *connLimit) acquire(l *limitedListener) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.open >= c.max() {
+		if l.closed {
+			return false
+		}
+		for conn := range c.idle {
+			delete(c.idle, conn)
+			// Its slot is freed when the close reaches limitedConn.
+			go conn.Close()
+			break
+		}
+		c.cond.Wait()
+	}
+	if l.closed {
+		return false
+	}
+	c.open++
+	return true
+}
+
+func (c *connLimit) release() {
+	c.mu.Lock()
+	c.open--
+	c.mu.Unlock()
+	c.cond.Broadcast()
+}
+
+type limitedListener struct {
+	net.Listener
+	limit  *connLimit
+	closed bool // guarded by limit.mu
+}
+
+func (l *limitedListener) Accept() (net.Conn, error) {
+	if !l.limit.acquire(l) {
+		return nil, net.ErrClosed
+	}
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		l.limit.release()
+		return nil, err
+	}
+	return &limitedConn{Conn: conn, release: l.limit.release}, nil
+}
+
+func (l *limitedListener) Close() error {
+	l.limit.mu.Lock()
+	l.closed = true
+	l.limit.mu.Unlock()
+	l.limit.cond.Broadcast()
+	return l.Listener.Close()
+}
+
+// limitedConn gives its slot back when closed, once.
+type limitedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// hostLimit caps the requests in flight to each host, so one page
+// issuing thousands of requests can't use up the daemon's descriptors
+// or bury its dev server.
+type hostLimit struct {
+	mu    sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+type hostSlots struct {
+	inFlight int
+	freed    chan struct{} // closed and replaced whenever a slot frees up
+}
+
+func newHostLimit() *hostLimit {
+	return &hostLimit{hosts: make(map[string]*hostSlots)}
+}
+
+// acquire waits until host has fewer than max requests in flight and
+// counts one more. It returns false if ctx is done first. Each true
+// result must be paired with a release.
+func (h *hostLimit) acquire(ctx context.Context, host string, max int) bool {
+	if h == nil {
+		return true
+	}
+	for {
+		h.mu.Lock()
+		s := h.hosts[host]
+		if s == nil {
+			s = &hostSlots{freed: make(chan struct{})}
+			h.hosts[host] = s
+		}
+		if s.inFlight < max {
+			s.inFlight++
+			h.mu.Unlock()
+			return true
+		}
+		freed := s.freed
+		h.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (h *hostLimit) release(host string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.hosts[host]
+	s.inFlight--
+	close(s.freed)
+	s.freed = make(chan struct{})
+	if s.inFlight == 0 {
+		delete(h.hosts, host)
+	}
+}
+
+// maxConnections returns the connection cap from the config file, or
+// the default.
+func (d *Daemon) maxConnections() int {
+	if rs := d.settings.Load(); rs != nil && rs.maxConnections > 0 {
+		return rs.maxConnections
+	}
+	return defaultMaxConnections
+}
+
+// maxRequestsPerHost returns the per-host request cap from the config
+// file, or the default.
+func (d *Daemon) maxRequestsPerHost() int {
+	if rs := d.settings.Load(); rs != nil && rs.maxRequestsPerHost > 0 {
+		return rs.maxRequestsPerHost
+	}
+	return defaultMaxRequestsPerHost
+}
+
+// admitRequest waits for r's host to have a free request slot, for up to
+// hostQueueTimeout. It returns the function that frees the slot, which
+// may be called more than once, or false if none freed up in time or the
+// client went away. Long-lived requests don't take a slot.
+func (d *Daemon) admitRequest(r *http.Request) (done func(), ok bool) {
+	if longLived(r) {
+		return func() {}, true
+	}
+	host := strings.ToLower(r.Host)
+	ctx, cancel := context.WithTimeout(r.Context(), hostQueueTimeout)
+	defer cancel()
+	if !d.hostLimit.acquire(ctx, host, d.maxRequestsPerHost()) {
+		return nil, false
+	}
+	return sync.OnceFunc(func() { d.hostLimit.release(host) }), true
+}
+
+// longLived reports whether r asks for a connection that stays open as
+// long as the page does: a protocol upgrade such as WebSocket, or a
+// server-sent events stream. Counting those would let a page's few
+// streams use up the slots meant for its ordinary requests.
+func longLived(r *http.Request) bool {
+	return strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// isEventStream reports whether h starts a server-sent events stream.
+func isEventStream(h http.Header) bool {
+	return strings.HasPrefix(h.Get("Content-Type"), "text/event-stream")
+}
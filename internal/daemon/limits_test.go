@@ -0,0 +1,187 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConnLimit_WaitsForSlot(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit := newConnLimit(func() int { return 1 })
+	l := limit.listener(inner)
+	defer l.Close()
+
+	for range 2 {
+		c, err := net.Dial("tcp", inner.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+	}
+
+	first, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := l.Accept()
+		if err == nil {
+			accepted <- c
+		}
+	}()
+	select {
+	case <-accepted:
+		t.Fatal("second connection accepted past the limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	first.Close()
+	select {
+	case c := <-accepted:
+		c.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatal("second connection not accepted after the first closed")
+	}
+}
+
+func TestConnLimit_ClosesIdleConnection(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit := newConnLimit(func() int { return 1 })
+	l := limit.listener(inner)
+	defer l.Close()
+
+	for range 2 {
+		c, err := net.Dial("tcp", inner.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+	}
+
+	idle, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit.trackState(idle, http.StateIdle)
+
+	done := make(chan error, 1)
+	go func() {
+		c, err := l.Accept()
+		if err == nil {
+			c.Close()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Accept: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle connection not closed to make room")
+	}
+}
+
+func TestConnLimit_CloseUnblocksAccept(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit := newConnLimit(func() int { return 0 })
+	l := limit.listener(inner)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.Accept()
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	l.Close()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Accept succeeded on a closed listener")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Accept still waiting after Close")
+	}
+}
+
+func TestHostLimit(t *testing.T) {
+	h := newHostLimit()
+	if !h.acquire(context.Background(), "app.test", 1) {
+		t.Fatal("first request not admitted")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if h.acquire(ctx, "app.test", 1) {
+		t.Fatal("second request admitted past the limit")
+	}
+	if !h.acquire(context.Background(), "other.test", 1) {
+		t.Fatal("other host not admitted")
+	}
+
+	admitted := make(chan bool, 1)
+	go func() { admitted <- h.acquire(context.Background(), "app.test", 1) }()
+	time.Sleep(20 * time.Millisecond)
+	h.release("app.test")
+	select {
+	case ok := <-admitted:
+		if !ok {
+			t.Fatal("waiting request not admitted")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("waiting request not admitted after release")
+	}
+	h.release("app.test")
+	h.release("other.test")
+	if len(h.hosts) != 0 {
+		t.Errorf("hosts not cleaned up: %v", h.hosts)
+	}
+}
+
+func TestAdmitRequest_LongLived(t *testing.T) {
+	d := &Daemon{hostLimit: newHostLimit()}
+	for range defaultMaxRequestsPerHost {
+		d.hostLimit.acquire(context.Background(), "app.test", defaultMaxRequestsPerHost)
+	}
+
+	ws := httptest.NewRequest("GET", "https://app.test/socket", nil)
+	ws.Header.Set("Connection", "Upgrade")
+	ws.Header.Set("Upgrade", "websocket")
+	sse := httptest.NewRequest("GET", "https://app.test/events", nil)
+	sse.Header.Set("Accept", "text/event-stream")
+	for _, r := range []*http.Request{ws, sse} {
+		if _, ok := d.admitRequest(r); !ok {
+			t.Errorf("%s not admitted to a full host", r.URL.Path)
+		}
+	}
+	if n := d.hostLimit.hosts["app.test"].inFlight; n != defaultMaxRequestsPerHost {
+		t.Errorf("in flight = %d, want streams not counted", n)
+	}
+
+	// Freeing a slot twice, as a stream's response and the handler's
+	// return both do, gives back only one.
+	d.hostLimit.release("app.test")
+	done, ok := d.admitRequest(httptest.NewRequest("GET", "https://app.test/", nil))
+	if !ok {
+		t.Fatal("request not admitted to a free slot")
+	}
+	done()
+	done()
+	if n := d.hostLimit.hosts["app.test"].inFlight; n != defaultMaxRequestsPerHost-1 {
+		t.Errorf("in flight = %d after done twice, want %d", n, defaultMaxRequestsPerHost-1)
+	}
+}
@@ -0,0 +1,222 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+)
+
+// maxAllowFrom bounds the client networks accepted with --allow.
+const maxAllowFrom = 32
+
+// listenLoopback listens on port on both 127.0.0.1 and [::1]. The DNS
+// server answers AAAA queries with ::1, so clients that try IPv6 first
+// would otherwise be refused and fall back slowly. IPv4 is required; a
+// missing IPv6 loopback (IPv6 disabled) only costs the second listener.
+// Port 0 picks a free port on IPv4 and reuses it for IPv6.
+func (d *Daemon) listenLoopback(component string, port int) (net.Listener, error) {
+	// SECURITY: Bind to loopback only to prevent external access
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	v4, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	port = v4.Addr().(*net.TCPAddr).Port
+	addr6 := net.JoinHostPort("::1", strconv.Itoa(port))
+	v6, err := net.Listen("tcp", addr6)
+	if err != nil {
+		d.logger.Warn("IPv6 loopback unavailable, listening on IPv4 only", "component", component, "addr", addr6, "error", err)
+		d.logger.Info("using direct binding", "component", component, "addr", addr)
+		d.handoff.keep(component, v4)
+		return v4, nil
+	}
+	d.handoff.keep(component, v4, v6)
+	d.logger.Info("using direct binding", "component", component, "addr", addr, "addr6", addr6)
+	return mergeListeners(v4, v6), nil
+}
+
+// ParseListen validates a --listen-addr IP and the --allow networks that
+// must come with it. addr must be one specific non-loopback address, such
+// as a Tailscale IP; each allow entry is a CIDR or a single IP.
+func ParseListen(addr string, allow []string) (netip.Addr, []netip.Prefix, error) {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil || ip.Zone() != "" {
+		return netip.Addr{}, nil, fmt.Errorf("invalid listen address %q: must be an IP address", addr)
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsMulticast() {
+		return netip.Addr{}, nil, fmt.Errorf("invalid listen address %q: must be a specific non-loopback interface address", addr)
+	}
+	// SECURITY: Never listen beyond loopback without an explicit allowlist.
+	if len(allow) == 0 {
+		return netip.Addr{}, nil, errors.New("listen address requires at least one allowed client network")
+	}
+	if len(allow) > maxAllowFrom {
+		return netip.Addr{}, nil, fmt.Errorf("too many allowed client networks (max %d)", maxAllowFrom)
+	}
+	prefixes := make([]netip.Prefix, 0, len(allow))
+	for _, s := range allow {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			a, aerr := netip.ParseAddr(s)
+			if aerr != nil || a.Zone() != "" {
+				return netip.Addr{}, nil, fmt.Errorf("invalid allowed network %q: must be a CIDR or IP", s)
+			}
+			p = netip.PrefixFrom(a, a.BitLen())
+		}
+		p = p.Masked()
+		if p.Bits() == 0 {
+			return netip.Addr{}, nil, fmt.Errorf("invalid allowed network %q: would allow every address", s)
+		}
+		prefixes = append(prefixes, p)
+	}
+	return ip, prefixes, nil
+}
+
+// listenConfigured listens on port at the configured --listen-addr, or
+// returns nil when there is none. Connections from outside the allowlist
+// are closed as soon as they are accepted. A daemon restarting in place
+// takes over the previous process's socket while the address is the same.
+func (d *Daemon) listenConfigured(component string, port int) (net.Listener, error) {
+	if d.config.ListenAddr == "" {
+		return nil, nil
+	}
+	ip, allow, err := ParseListen(d.config.ListenAddr, d.config.AllowFrom)
+	if err != nil {
+		return nil, err
+	}
+	addr := net.JoinHostPort(ip.String(), strconv.Itoa(port))
+	name := component + "-listen"
+	l := d.inheritedListener(name, addr)
+	if l == nil {
+		if l, err = net.Listen("tcp", addr); err != nil {
+			return nil, fmt.Errorf("listening on %s: %w", addr, err)
+		}
+	}
+	d.handoff.keep(name, l)
+	d.logger.Info("listening beyond loopback", "component", component, "addr", addr, "allow", d.config.AllowFrom)
+	return &allowListener{Listener: l, allow: allow, component: component, logger: d.logger}, nil
+}
+
+// allowListener drops connections from peers outside allow.
+type allowListener struct {
+	net.Listener
+	allow     []netip.Prefix
+	component string
+	logger    *slog.Logger
+}
+
+func (l *allowListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if l.allowed(c.RemoteAddr()) {
+			return c, nil
+		}
+		l.logger.Warn("connection rejected: client not allowed", "component", l.component, "remote", c.RemoteAddr().String())
+		c.Close()
+	}
+}
+
+// allowed reports whether a peer at addr may connect.
+func (l *allowListener) allowed(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	ip, ok := netip.AddrFromSlice(tcp.IP)
+	if !ok {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, p := range l.allow {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeListeners returns a listener accepting from all of ls, or ls[0]
+// when there is only one.
+func mergeListeners(ls ...net.Listener) net.Listener {
+	if len(ls) == 1 {
+		return ls[0]
+	}
+	m := &multiListener{
+		ls:    ls,
+		conns: make(chan net.Conn),
+		errs:  make(chan error),
+		done:  make(chan struct{}),
+	}
+	for _, l := range ls {
+		go m.acceptLoop(l)
+	}
+	return m
+}
+
+// multiListener accepts connections from several listeners, such as the
+// IPv4 and IPv6 loopback sockets for one port. Its Addr is the first
+// listener's.
+type multiListener struct {
+	ls    []net.Listener
+	conns chan net.Conn
+	errs  chan error
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (m *multiListener) acceptLoop(l net.Listener) {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			select {
+			case m.errs <- err:
+				continue
+			case <-m.done:
+				return
+			}
+		}
+		select {
+		case m.conns <- c:
+		case <-m.done:
+			c.Close()
+			return
+		}
+	}
+}
+
+// Accept returns the next connection from any of the listeners.
+func (m *multiListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-m.conns:
+		return c, nil
+	case err := <-m.errs:
+		return nil, err
+	case <-m.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close closes every listener.
+func (m *multiListener) Close() error {
+	var errs []error
+	m.once.Do(func() {
+		close(m.done)
+		for _, l := range m.ls {
+			errs = append(errs, l.Close())
+		}
+	})
+	return errors.Join(errs...)
+}
+
+func (m *multiListener) Addr() net.Addr { return m.ls[0].Addr() }
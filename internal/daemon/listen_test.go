@@ -0,0 +1,171 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestListenLoopback(t *testing.T) {
+	if l, err := net.Listen("tcp", "[::1]:0"); err != nil {
+		t.Skip("no IPv6 loopback")
+	} else {
+		l.Close()
+	}
+
+	d := &Daemon{logger: slog.New(slog.NewJSONHandler(io.Discard, nil))}
+	l, err := d.listenLoopback("test", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	for _, host := range []string{"127.0.0.1", "::1"} {
+		addr := net.JoinHostPort(host, fmt.Sprint(port))
+		c, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("dial %s: %v", addr, err)
+		}
+		ac, err := l.Accept()
+		if err != nil {
+			t.Fatalf("accept from %s: %v", addr, err)
+		}
+		if got := ac.LocalAddr().(*net.TCPAddr).IP; !got.Equal(net.ParseIP(host)) {
+			t.Errorf("connection to %s accepted on %s", addr, got)
+		}
+		ac.Close()
+		c.Close()
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Accept after Close = %v, want net.ErrClosed", err)
+	}
+}
+
+func TestListenConfiguredHandoff(t *testing.T) {
+	ip := interfaceIP(t)
+	probe, err := net.Listen("tcp", net.JoinHostPort(ip, "0"))
+	if err != nil {
+		t.Skipf("can't listen on %s: %v", ip, err)
+	}
+	port := probe.Addr().(*net.TCPAddr).Port
+	probe.Close()
+
+	newDaemon := func(h *handoff) *Daemon {
+		return &Daemon{
+			config:  &Config{ListenAddr: ip, AllowFrom: []string{"10.0.0.0/8"}},
+			logger:  slog.New(slog.NewJSONHandler(io.Discard, nil)),
+			handoff: h,
+		}
+	}
+	old := newDaemon(&handoff{live: make(map[string][]filer)})
+	l, err := old.listenConfigured("https", port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	files, err := old.handoff.dup()
+	if err != nil {
+		t.Fatalf("--listen-addr socket not kept for the handoff: %v", err)
+	}
+
+	// The old socket is still open, so only the inherited one can serve.
+	next := newDaemon(&handoff{inherited: files, live: make(map[string][]filer)})
+	nl, err := next.listenConfigured("https", port)
+	if err != nil {
+		t.Fatalf("listenConfigured with the inherited socket: %v", err)
+	}
+	nl.Close()
+}
+
+// interfaceIP returns a non-loopback IPv4 address of this machine, or
+// skips the test.
+func interfaceIP(t *testing.T) string {
+	t.Helper()
+	addrs, _ := net.InterfaceAddrs()
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok && !n.IP.IsLoopback() && n.IP.To4() != nil {
+			return n.IP.String()
+		}
+	}
+	t.Skip("no non-loopback IPv4 address")
+	return ""
+}
+
+func TestParseListen(t *testing.T) {
+	tests := []struct {
+		addr    string
+		allow   []string
+		wantErr bool
+	}{
+		{"100.64.0.5", []string{"100.64.0.0/10"}, false},
+		{"100.64.0.5", []string{"100.64.0.7", "fd7a:115c:a1e0::/48"}, false},
+		{"fd7a:115c:a1e0::5", []string{"fd7a:115c:a1e0::/48"}, false},
+		{"100.64.0.5", nil, true},
+		{"", []string{"100.64.0.0/10"}, true},
+		{"127.0.0.1", []string{"100.64.0.0/10"}, true},
+		{"::1", []string{"100.64.0.0/10"}, true},
+		{"0.0.0.0", []string{"100.64.0.0/10"}, true},
+		{"::", []string{"100.64.0.0/10"}, true},
+		{"myhost", []string{"100.64.0.0/10"}, true},
+		{"100.64.0.5:80", []string{"100.64.0.0/10"}, true},
+		{"100.64.0.5", []string{"0.0.0.0/0"}, true},
+		{"100.64.0.5", []string{"::/0"}, true},
+		{"100.64.0.5", []string{"tailnet"}, true},
+	}
+	for _, tt := range tests {
+		_, _, err := ParseListen(tt.addr, tt.allow)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseListen(%q, %q) error = %v, wantErr %v", tt.addr, tt.allow, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAllowListener(t *testing.T) {
+	_, allow, err := ParseListen("100.64.0.5", []string{"127.0.0.2", "10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &allowListener{Listener: inner, allow: allow, component: "test",
+		logger: slog.New(slog.NewJSONHandler(io.Discard, nil))}
+	defer l.Close()
+
+	// Rejected: 127.0.0.1 is not in the allowlist. The listener closes it
+	// and keeps waiting, so the next (allowed) connection is accepted.
+	rejected, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rejected.Close()
+	d := net.Dialer{LocalAddr: &net.TCPAddr{IP: net.ParseIP("127.0.0.2")}}
+	allowed, err := d.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Skipf("can't dial from 127.0.0.2: %v", err)
+	}
+	defer allowed.Close()
+
+	c, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if got := c.RemoteAddr().(*net.TCPAddr).IP.String(); got != "127.0.0.2" {
+		t.Errorf("accepted connection from %s, want 127.0.0.2", got)
+	}
+	rejected.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := rejected.Read(make([]byte, 1)); err == nil {
+		t.Error("connection from outside the allowlist was not closed")
+	}
+}
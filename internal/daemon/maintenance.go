@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"context"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/schedule"
+)
+
+// watchMaintenance keeps a scheduler job starting each route's
+// maintenance windows, until ctx is done. Like watchUDP, every event
+// triggers a full sync.
+func (d *Daemon) watchMaintenance(ctx context.Context, events <-chan api.RouteEvent) {
+	d.maintenance = make(map[string]api.MaintenanceConfig)
+	d.syncMaintenance(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+			d.syncMaintenance(time.Now())
+		}
+	}
+}
+
+// syncMaintenance makes the scheduled maintenance windows match the
+// registry's routes. A route registered mid-window, or restored after the
+// daemon restarted, enters the window at once, and one whose schedule
+// was dropped leaves it. Only watchMaintenance's goroutine calls it, so
+// d.maintenance needs no lock.
+func (d *Daemon) syncMaintenance(now time.Time) {
+	want := make(map[string]api.MaintenanceConfig)
+	for _, route := range d.registry.List() {
+		if route.Maintenance == nil {
+			if !route.MaintenanceUntil.IsZero() {
+				d.endMaintenance(route.Name, route.MaintenanceUntil)
+			}
+			continue
+		}
+		want[route.Name] = *route.Maintenance
+		if route.MaintenanceUntil.IsZero() {
+			if until := route.Maintenance.ActiveUntil(now); !until.IsZero() {
+				d.startMaintenance(route.Name, until)
+			}
+		}
+	}
+
+	for name, c := range d.maintenance {
+		if want[name] != c {
+			d.scheduler.Remove(maintenanceJob(name))
+			delete(d.maintenance, name)
+		}
+	}
+	for name, c := range want {
+		if _, ok := d.maintenance[name]; ok {
+			continue
+		}
+		cron, length, err := c.Window()
+		if err != nil {
+			d.logger.Warn("invalid maintenance schedule", "route", name, "error", err)
+			continue
+		}
+		d.scheduler.Set(maintenanceJob(name), cron, func(start time.Time) {
+			d.startMaintenance(name, start.Add(length))
+		})
+		d.maintenance[name] = c
+	}
+}
+
+// maintenanceJob names the scheduler job starting a route's windows.
+func maintenanceJob(route string) string {
+	return "maintenance:" + route
+}
+
+// startMaintenance puts a route in a maintenance window until until, and
+// schedules its end.
+func (d *Daemon) startMaintenance(name string, until time.Time) {
+	if err := d.registry.SetMaintenance(name, until); err != nil {
+		return
+	}
+	d.logger.Info("route maintenance started", "route", name, "until", until)
+	d.scheduler.Set("maintenance-end:"+name, schedule.At(until), func(time.Time) {
+		d.endMaintenance(name, until)
+	})
+}
+
+// endMaintenance ends a route's maintenance window, unless a later window
+// has replaced the one ending at until.
+func (d *Daemon) endMaintenance(name string, until time.Time) {
+	route, ok := d.registry.Lookup(name)
+	if !ok || !route.MaintenanceUntil.Equal(until) {
+		return
+	}
+	if err := d.registry.SetMaintenance(name, time.Time{}); err == nil {
+		d.logger.Info("route maintenance ended", "route", name)
+	}
+}
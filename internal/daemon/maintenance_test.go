@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/dashboard"
+	"github.com/alexcatdad/paw-proxy/internal/proxy"
+	"github.com/alexcatdad/paw-proxy/internal/schedule"
+)
+
+func TestMaintenanceWindow(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("app"))
+	}))
+	defer upstream.Close()
+	addr := strings.TrimPrefix(upstream.URL, "http://")
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	// Every minute for an hour: always inside a window.
+	always := &api.MaintenanceConfig{Schedule: "* * * * *", Duration: "1h"}
+	if err := registry.RegisterRoute(api.Route{Name: "shop", Upstream: addr, Dir: "/tmp/shop", Maintenance: always}); err != nil {
+		t.Fatal(err)
+	}
+	d := &Daemon{
+		registry:    registry,
+		proxy:       proxy.New(),
+		logger:      slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:     dashboard.NewMetrics(10),
+		scheduler:   schedule.New(),
+		maintenance: make(map[string]api.MaintenanceConfig),
+	}
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		d.handleRequest(w, httptest.NewRequest("GET", "https://shop.test/", nil))
+		return w
+	}
+
+	d.syncMaintenance(time.Now())
+	if _, ok := d.scheduler.Next(maintenanceJob("shop")); !ok {
+		t.Error("no job scheduled for the route's windows")
+	}
+	route, _ := registry.Lookup("shop")
+	if !route.InMaintenance(time.Now()) {
+		t.Fatal("route registered mid-window is not in maintenance")
+	}
+	if _, ok := d.scheduler.Next("maintenance-end:shop"); !ok {
+		t.Error("window end not scheduled")
+	}
+	w := get()
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("in window: %d Retry-After=%q, want 503 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Dropping the schedule ends the window and its job.
+	if _, err := registry.UpsertRoute(api.Route{Name: "shop", Upstream: addr, Dir: "/tmp/shop", MaintenanceUntil: route.MaintenanceUntil}, api.Caller{}); err != nil {
+		t.Fatal(err)
+	}
+	d.syncMaintenance(time.Now())
+	if _, ok := d.scheduler.Next(maintenanceJob("shop")); ok {
+		t.Error("job kept after the schedule was dropped")
+	}
+	if w := get(); w.Code != http.StatusOK || w.Body.String() != "app" {
+		t.Errorf("after the window: %d %q, want the app", w.Code, w.Body.String())
+	}
+}
+
+func TestEndMaintenanceKeepsLaterWindow(t *testing.T) {
+	registry := api.NewRouteRegistry(30 * time.Second)
+	if err := registry.RegisterRoute(api.Route{Name: "shop", Upstream: "localhost:3000", Dir: "/tmp/shop"}); err != nil {
+		t.Fatal(err)
+	}
+	d := &Daemon{registry: registry, logger: slog.New(slog.NewJSONHandler(io.Discard, nil)), scheduler: schedule.New()}
+
+	first := time.Now().Add(time.Minute)
+	second := time.Now().Add(time.Hour)
+	d.startMaintenance("shop", first)
+	d.startMaintenance("shop", second)
+	d.endMaintenance("shop", first)
+	if route, _ := registry.Lookup("shop"); !route.MaintenanceUntil.Equal(second) {
+		t.Errorf("MaintenanceUntil = %v, want the later window's end %v", route.MaintenanceUntil, second)
+	}
+	d.endMaintenance("shop", second)
+	if route, _ := registry.Lookup("shop"); !route.MaintenanceUntil.IsZero() {
+		t.Errorf("MaintenanceUntil = %v after the window ended", route.MaintenanceUntil)
+	}
+}
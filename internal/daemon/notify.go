@@ -0,0 +1,123 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/notification"
+)
+
+// notifyInterval is the minimum time between two notifications about the
+// same thing, e.g. an upstream that stays down while its error page keeps
+// reloading.
+const notifyInterval = 10 * time.Minute
+
+// caExpiryWarning is how close to expiry the CA must be for a notification.
+const caExpiryWarning = 30 * 24 * time.Hour
+
+// runningMarker is created in the support directory while the daemon runs
+// and removed on a clean shutdown, so the next start can tell it follows
+// a crash.
+const runningMarker = "daemon.running"
+
+// notifier sends desktop notifications for daemon events, when enabled
+// with "notifications": true in config.json.
+type notifier struct {
+	enabled atomic.Bool
+	send    func(title, message string) error
+	mu      sync.Mutex
+	last    map[string]time.Time
+}
+
+func newNotifier() *notifier {
+	return &notifier{send: notification.Notify, last: make(map[string]time.Time)}
+}
+
+// notify shows message unless notifications are off or one with the same
+// key was shown within notifyInterval. It reports whether it was shown.
+func (n *notifier) notify(key, message string) bool {
+	if n == nil || !n.enabled.Load() {
+		return false
+	}
+	n.mu.Lock()
+	now := time.Now()
+	if last, ok := n.last[key]; ok && now.Sub(last) < notifyInterval {
+		n.mu.Unlock()
+		return false
+	}
+	n.last[key] = now
+	n.mu.Unlock()
+
+	go n.send("paw-proxy", message) //nolint:errcheck // notifications are best-effort
+	return true
+}
+
+// watchNotifications notifies about routes removed for missing heartbeats
+// until ctx is done. The scheduler runs the daily CA expiry check.
+func (d *Daemon) watchNotifications(ctx context.Context, events <-chan api.RouteEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-events:
+			if ev.Type == api.EventExpired {
+				d.notifier.notify("expired:"+ev.Route, fmt.Sprintf("%s.%s was removed: its up process stopped sending heartbeats", ev.Route, d.config.TLD))
+			}
+		}
+	}
+}
+
+// upstreamFailed notifies that a route's dev server isn't answering, and
+// has balanced routes skip it for a while.
+func (d *Daemon) upstreamFailed(host, upstream string, err error) {
+	d.balancer.failed(upstream, time.Now())
+	d.notifier.notify("upstream:"+upstream, fmt.Sprintf("%s is not responding (%s)", host, upstream))
+}
+
+// checkCAExpiry notifies if the CA certificate expires within
+// caExpiryWarning.
+func (d *Daemon) checkCAExpiry(now time.Time) {
+	if d.caExpiry.IsZero() {
+		return
+	}
+	left := d.caExpiry.Sub(now)
+	if left > caExpiryWarning {
+		return
+	}
+	msg := fmt.Sprintf("The paw-proxy CA expires in %d days. Run: sudo paw-proxy setup", int(left.Hours()/24))
+	if left <= 0 {
+		msg = "The paw-proxy CA has expired. Run: sudo paw-proxy setup"
+	}
+	d.notifier.notify("ca-expiry", msg)
+}
+
+// markRunning records that the daemon is running and reports whether the
+// previous run ended without a clean shutdown.
+func (d *Daemon) markRunning() (crashed bool) {
+	path := d.runningMarkerPath()
+	if _, err := os.Stat(path); err == nil {
+		crashed = true
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0600); err != nil {
+		d.logger.Warn("could not write running marker", "path", path, "error", err)
+	}
+	return crashed
+}
+
+// markStopped removes the running marker after a clean shutdown.
+func (d *Daemon) markStopped() {
+	if err := os.Remove(d.runningMarkerPath()); err != nil && !os.IsNotExist(err) {
+		d.logger.Warn("could not remove running marker", "error", err)
+	}
+}
+
+func (d *Daemon) runningMarkerPath() string {
+	return filepath.Join(d.config.SupportDir, runningMarker)
+}
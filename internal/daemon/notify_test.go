@@ -0,0 +1,123 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+// recordingNotifier returns an enabled notifier whose messages arrive on
+// the returned channel.
+func recordingNotifier() (*notifier, chan string) {
+	sent := make(chan string, 10)
+	n := newNotifier()
+	n.send = func(title, message string) error {
+		sent <- message
+		return nil
+	}
+	n.enabled.Store(true)
+	return n, sent
+}
+
+func TestNotifierRateLimitsAndOptIn(t *testing.T) {
+	n, sent := recordingNotifier()
+
+	if !n.notify("upstream:localhost:3000", "down") {
+		t.Fatal("first notification not shown")
+	}
+	if n.notify("upstream:localhost:3000", "down again") {
+		t.Error("repeat notification within notifyInterval was shown")
+	}
+	if !n.notify("upstream:localhost:4000", "other") {
+		t.Error("notification with another key was suppressed")
+	}
+	for range 2 {
+		<-sent
+	}
+
+	n.enabled.Store(false)
+	if n.notify("ca-expiry", "soon") {
+		t.Error("notification shown while disabled")
+	}
+	var nilNotifier *notifier
+	if nilNotifier.notify("x", "y") {
+		t.Error("nil notifier showed a notification")
+	}
+}
+
+func TestMarkRunningDetectsCrash(t *testing.T) {
+	d := &Daemon{
+		config: &Config{SupportDir: t.TempDir()},
+		logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	if d.markRunning() {
+		t.Error("first start reported a crash")
+	}
+	// No markStopped: the process died.
+	if !d.markRunning() {
+		t.Error("start after an unclean exit not detected")
+	}
+	d.markStopped()
+	if d.markRunning() {
+		t.Error("start after a clean shutdown reported a crash")
+	}
+}
+
+func TestCheckCAExpiry(t *testing.T) {
+	now := time.Now()
+	n, sent := recordingNotifier()
+	d := &Daemon{notifier: n, caExpiry: now.Add(90 * 24 * time.Hour)}
+
+	d.checkCAExpiry(now)
+	d.caExpiry = now.Add(10*24*time.Hour + time.Hour)
+	d.checkCAExpiry(now)
+
+	select {
+	case msg := <-sent:
+		if !strings.Contains(msg, "expires in 10 days") {
+			t.Errorf("message = %q", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no notification for a CA expiring in 10 days")
+	}
+	select {
+	case msg := <-sent:
+		t.Errorf("unexpected extra notification %q", msg)
+	default:
+	}
+}
+
+func TestWatchNotificationsExpiredRoute(t *testing.T) {
+	n, sent := recordingNotifier()
+	d := &Daemon{config: &Config{TLD: "test"}, notifier: n}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan api.RouteEvent, 2)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.watchNotifications(ctx, events)
+	}()
+
+	events <- api.RouteEvent{Type: api.EventRemoved, Route: "quiet"}
+	events <- api.RouteEvent{Type: api.EventExpired, Route: "myapp"}
+
+	select {
+	case msg := <-sent:
+		if !strings.HasPrefix(msg, "myapp.test was removed") {
+			t.Errorf("message = %q", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification for an expired route")
+	}
+	cancel()
+	wg.Wait()
+}
@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+// crashReportInterval limits crash report files to one per component in
+// this window, so a panic on every request doesn't fill the disk. Each
+// panic is still logged.
+const crashReportInterval = time.Minute
+
+// crashReports remembers when each component last wrote a crash report.
+type crashReports struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// due reports whether component may write a report at now, and if so
+// records it.
+func (c *crashReports) due(component string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if last, ok := c.last[component]; ok && now.Sub(last) < crashReportInterval {
+		return false
+	}
+	if c.last == nil {
+		c.last = make(map[string]time.Time)
+	}
+	c.last[component] = now
+	return true
+}
+
+// recoverHTTP keeps a panic in h from taking down the server: the request
+// gets a 500, and the panic is logged and written to a crash report.
+// http.ErrAbortHandler is re-raised, since it is how handlers abort a
+// response on purpose.
+func (d *Daemon) recoverHTTP(component string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+			d.reportPanic(component, v, debug.Stack(), fmt.Sprintf("%s %s%s", r.Method, r.Host, r.URL.Path))
+			// Harmless if the handler already started the response.
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}()
+		h(w, r)
+	}
+}
+
+// reportPanic logs a recovered panic with its stack and writes a crash
+// report to <support dir>/crash. detail says what was being handled.
+func (d *Daemon) reportPanic(component string, v any, stack []byte, detail string) {
+	d.logger.Error("recovered from panic", "component", component, "panic", fmt.Sprint(v), "request", detail, "stack", string(stack))
+	if !d.crashes.due(component, time.Now()) {
+		return
+	}
+	path, err := writeCrashReport(d.config.SupportDir, component, time.Now(), v, stack, detail)
+	if err != nil {
+		d.logger.Warn("writing crash report failed", "error", err)
+		return
+	}
+	d.logger.Info("crash report written", "path", path)
+}
+
+// writeCrashReport saves a panic as crash/daemon-<component>-<time>.log
+// under supportDir and returns its path.
+func writeCrashReport(supportDir, component string, now time.Time, v any, stack []byte, detail string) (string, error) {
+	dir := filepath.Join(supportDir, "crash")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	report := fmt.Sprintf("paw-proxy %s recovered from a panic in %s at %s\nHandling: %s\nPanic: %v\n\n%s",
+		api.Version, component, now.Format(time.RFC3339), detail, v, stack)
+	path := filepath.Join(dir, fmt.Sprintf("daemon-%s-%s.log", component, now.Format("20060102-150405")))
+	if err := os.WriteFile(path, []byte(report), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,58 @@
+package daemon
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecoverHTTP(t *testing.T) {
+	d := &Daemon{
+		config: &Config{SupportDir: t.TempDir()},
+		logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+	h := d.recoverHTTP("https", func(w http.ResponseWriter, r *http.Request) {
+		panic("handler broke")
+	})
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", "https://shop.test/cart", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("status = %d, want 500", w.Code)
+		}
+	}
+
+	// A second panic within crashReportInterval is only logged.
+	reports, _ := filepath.Glob(filepath.Join(d.config.SupportDir, "crash", "daemon-https-*.log"))
+	if len(reports) != 1 {
+		t.Fatalf("got %d crash reports, want 1", len(reports))
+	}
+	data, _ := os.ReadFile(reports[0])
+	for _, want := range []string{"handler broke", "GET shop.test/cart", "panic_test.go"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("crash report missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestRecoverHTTP_ReraisesErrAbortHandler(t *testing.T) {
+	d := &Daemon{
+		config: &Config{SupportDir: t.TempDir()},
+		logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+	h := d.recoverHTTP("https", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", v)
+		}
+	}()
+	h(httptest.NewRecorder(), httptest.NewRequest("GET", "https://shop.test/", nil))
+}
@@ -0,0 +1,239 @@
+package daemon
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/proxy"
+)
+
+// helloTimeout bounds how long a client may take to send its ClientHello
+// before the connection is dropped.
+const helloTimeout = 10 * time.Second
+
+// passthroughDialTimeout bounds dialing a passthrough upstream.
+const passthroughDialTimeout = 5 * time.Second
+
+// errHelloRead stops the handshake once the ClientHello has been read.
+var errHelloRead = errors.New("client hello read")
+
+// passthroughListener wraps the HTTPS listener. It reads the ClientHello
+// of each connection and pipes connections whose SNI names a passthrough
+// route to that route's upstream, still encrypted. Every other connection
+// is replayed from its first byte to the HTTPS server through Accept.
+type passthroughListener struct {
+	net.Listener
+	d *Daemon
+
+	conns chan net.Conn
+	errs  chan error
+	done  chan struct{}
+	once  sync.Once
+
+	mu     sync.Mutex
+	active map[net.Conn]struct{}
+}
+
+func (d *Daemon) newPassthroughListener(inner net.Listener) *passthroughListener {
+	l := &passthroughListener{
+		Listener: inner,
+		d:        d,
+		conns:    make(chan net.Conn),
+		errs:     make(chan error),
+		done:     make(chan struct{}),
+		active:   make(map[net.Conn]struct{}),
+	}
+	go l.acceptLoop()
+	return l
+}
+
+func (l *passthroughListener) acceptLoop() {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			select {
+			case l.errs <- err:
+			case <-l.done:
+				return
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		// Peek off the accept loop so a slow client can't stall others.
+		go l.dispatch(c)
+	}
+}
+
+// Accept returns the next connection meant for the HTTPS server.
+func (l *passthroughListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case err := <-l.errs:
+		return nil, err
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close stops accepting and closes open passthrough connections.
+func (l *passthroughListener) Close() error {
+	err := l.Listener.Close()
+	l.once.Do(func() {
+		close(l.done)
+		l.mu.Lock()
+		for c := range l.active {
+			c.Close()
+		}
+		l.mu.Unlock()
+	})
+	return err
+}
+
+func (l *passthroughListener) dispatch(c net.Conn) {
+	c.SetReadDeadline(time.Now().Add(helloTimeout))
+	serverName, peeked := peekServerName(c)
+	c.SetReadDeadline(time.Time{})
+	replay := &replayConn{Conn: c, r: io.MultiReader(bytes.NewReader(peeked), c)}
+
+	if serverName != "" {
+		name := api.ExtractNameFor(serverName, l.d.tlds())
+		if route, _, ok := l.d.registry.LookupSubdomain(name); ok && route.Passthrough {
+			l.pipe(replay, route, serverName)
+			return
+		}
+	}
+
+	select {
+	case l.conns <- replay:
+	case <-l.done:
+		c.Close()
+	}
+}
+
+// pipe copies c to and from the route's upstream until either side
+// closes.
+func (l *passthroughListener) pipe(c net.Conn, route api.Route, serverName string) {
+	start := time.Now()
+	if !l.track(c) {
+		c.Close()
+		return
+	}
+	defer l.untrack(c)
+	defer c.Close()
+
+	upstream, err := net.DialTimeout("tcp", route.Upstream, passthroughDialTimeout)
+	if err != nil {
+		l.d.logger.Warn("passthrough dial failed", "host", serverName, "upstream", route.Upstream, "error", err)
+		return
+	}
+	defer upstream.Close()
+	if route.ProxyProtocol {
+		if _, err := upstream.Write(proxy.ConnHeader(c)); err != nil {
+			l.d.logger.Warn("passthrough proxy header failed", "host", serverName, "error", err)
+			return
+		}
+	}
+	l.d.registry.Touch(route)
+
+	var in, out int64
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		in, _ = io.Copy(upstream, c)
+		closeWrite(upstream)
+	}()
+	out, _ = io.Copy(c, upstream)
+	closeWrite(c)
+	wg.Wait()
+
+	l.d.logger.Info("passthrough",
+		"host", serverName,
+		"route", route.Name,
+		"upstream", route.Upstream,
+		"bytes_in", in,
+		"bytes_out", out,
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+}
+
+// track records c as open, reporting false if the listener is closed.
+func (l *passthroughListener) track(c net.Conn) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-l.done:
+		return false
+	default:
+	}
+	l.active[c] = struct{}{}
+	return true
+}
+
+func (l *passthroughListener) untrack(c net.Conn) {
+	l.mu.Lock()
+	delete(l.active, c)
+	l.mu.Unlock()
+}
+
+// closeWrite half-closes c when it supports it, so the peer sees EOF
+// while replies can still arrive.
+func closeWrite(c net.Conn) {
+	if rc, ok := c.(*replayConn); ok {
+		c = rc.Conn
+	}
+	if cw, ok := c.(interface{ CloseWrite() error }); ok {
+		cw.CloseWrite()
+		return
+	}
+	c.Close()
+}
+
+// peekServerName reads the ClientHello from c and returns its SNI along
+// with every byte read, which must be replayed to whoever handles c. The
+// name is empty if the client sent none or didn't speak TLS.
+func peekServerName(c io.Reader) (string, []byte) {
+	var peeked bytes.Buffer
+	var serverName string
+	tls.Server(helloConn{r: io.TeeReader(c, &peeked)}, &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = hello.ServerName
+			return nil, errHelloRead
+		},
+	}).Handshake()
+	return serverName, peeked.Bytes()
+}
+
+// helloConn is a read-only net.Conn for peekServerName; the handshake
+// stops before anything is written.
+type helloConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c helloConn) Read(p []byte) (int, error)       { return c.r.Read(p) }
+func (c helloConn) Write(p []byte) (int, error)      { return 0, io.ErrClosedPipe }
+func (c helloConn) Close() error                     { return nil }
+func (c helloConn) LocalAddr() net.Addr              { return nil }
+func (c helloConn) RemoteAddr() net.Addr             { return nil }
+func (c helloConn) SetDeadline(time.Time) error      { return nil }
+func (c helloConn) SetReadDeadline(time.Time) error  { return nil }
+func (c helloConn) SetWriteDeadline(time.Time) error { return nil }
+
+// replayConn reads the bytes peekServerName consumed before reading
+// from the connection again.
+type replayConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *replayConn) Read(p []byte) (int, error) { return c.r.Read(p) }
@@ -0,0 +1,129 @@
+package daemon
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/dashboard"
+	"github.com/alexcatdad/paw-proxy/internal/proxy"
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+)
+
+func TestPassthroughRoutesBySNI(t *testing.T) {
+	// The passthrough upstream terminates TLS itself.
+	tlsUpstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "upstream tls")
+	}))
+	defer tlsUpstream.Close()
+	plainUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "proxied")
+	}))
+	defer plainUpstream.Close()
+
+	registry := api.NewRouteRegistry(30 * time.Second)
+	registry.Register("shop", strings.TrimPrefix(plainUpstream.URL, "http://"), "/tmp/shop")
+	if err := registry.RegisterRoute(api.Route{
+		Name:        "db",
+		Upstream:    strings.TrimPrefix(tlsUpstream.URL, "https://"),
+		Dir:         "/tmp/db",
+		Passthrough: true,
+	}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+
+	d := &Daemon{
+		config:    &Config{HTTPSPort: 0, TLD: "test"},
+		certCache: ssl.NewCertCache(testCA(t), "test"),
+		registry:  registry,
+		proxy:     proxy.New(),
+		logger:    slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:   dashboard.NewMetrics(10),
+	}
+	srv, ln, err := d.createHTTPSServer()
+	if err != nil {
+		t.Fatalf("createHTTPSServer: %v", err)
+	}
+	go srv.ServeTLS(ln, "", "")
+	defer srv.Close()
+
+	get := func(serverName string) (string, *tls.ConnectionState) {
+		t.Helper()
+		var state *tls.ConnectionState
+		client := &http.Client{Transport: &http.Transport{
+			DialTLSContext: func(_ context.Context, network, _ string) (net.Conn, error) {
+				c, err := tls.Dial(network, ln.Addr().String(), &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+				if err == nil {
+					cs := c.ConnectionState()
+					state = &cs
+				}
+				return c, err
+			},
+		}}
+		resp, err := client.Get("https://" + serverName + "/")
+		if err != nil {
+			t.Fatalf("GET %s: %v", serverName, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body), state
+	}
+
+	body, state := get("db.test")
+	if body != "upstream tls" {
+		t.Errorf("db.test body = %q, want the upstream's", body)
+	}
+	if leaf := state.PeerCertificates[0]; !leaf.Equal(tlsUpstream.Certificate()) {
+		t.Errorf("db.test served %v, want the upstream's own certificate", leaf.Subject)
+	}
+
+	if body, _ := get("shop.test"); body != "proxied" {
+		t.Errorf("shop.test body = %q, want it proxied by the daemon", body)
+	}
+}
+
+func TestHandleRequest_PassthroughRouteMisdirected(t *testing.T) {
+	registry := api.NewRouteRegistry(30 * time.Second)
+	if err := registry.RegisterRoute(api.Route{Name: "db", Upstream: "127.0.0.1:5432", Dir: "/tmp/db", Passthrough: true}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+	d := &Daemon{
+		registry: registry,
+		proxy:    proxy.New(),
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		metrics:  dashboard.NewMetrics(10),
+	}
+	w := httptest.NewRecorder()
+	d.handleRequest(w, httptest.NewRequest("GET", "https://db.test/", nil))
+	if w.Code != http.StatusMisdirectedRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusMisdirectedRequest)
+	}
+}
+
+func TestPeekServerName(t *testing.T) {
+	hello := make(chan []byte, 1)
+	client, server := net.Pipe()
+	go func() {
+		// Record what the client sends; the handshake itself never finishes.
+		tls.Client(client, &tls.Config{ServerName: "db.test", InsecureSkipVerify: true}).Handshake()
+	}()
+	go func() {
+		name, peeked := peekServerName(server)
+		if name != "db.test" {
+			t.Errorf("server name = %q, want db.test", name)
+		}
+		hello <- peeked
+		server.Close()
+	}()
+	if peeked := <-hello; len(peeked) == 0 || peeked[0] != 0x16 {
+		t.Errorf("peeked bytes don't start with a TLS handshake record")
+	}
+}
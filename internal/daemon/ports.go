@@ -0,0 +1,215 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// portRetryInterval is how often a listener blocked by another process
+// retries its port.
+const portRetryInterval = 5 * time.Second
+
+// PortHolder identifies the process listening on a port. Zero PID means
+// the process could not be identified.
+type PortHolder struct {
+	Port    int
+	Command string
+	PID     int
+}
+
+func (h PortHolder) String() string {
+	if h.PID == 0 {
+		return fmt.Sprintf("port %d in use by another process", h.Port)
+	}
+	return fmt.Sprintf("port %d held by %s (pid %d)", h.Port, h.Command, h.PID)
+}
+
+// FindPortHolder asks lsof (macOS) or ss (Linux) which process listens on
+// port. Processes owned by other users may only be visible to root.
+func FindPortHolder(port int) PortHolder {
+	holder := PortHolder{Port: port}
+	var out []byte
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		out, err = exec.Command("lsof", "-nP", fmt.Sprintf("-iTCP:%d", port), "-sTCP:LISTEN", "-Fpc").Output()
+		if err == nil {
+			holder.Command, holder.PID = parseLsof(string(out))
+		}
+	case "linux":
+		out, err = exec.Command("ss", "-Hltnp", fmt.Sprintf("sport = :%d", port)).Output()
+		if err == nil {
+			holder.Command, holder.PID = parseSS(string(out))
+		}
+	}
+	return holder
+}
+
+// parseLsof reads the first process from `lsof -Fpc` output, where each
+// field is on its own line prefixed with its type (p = pid, c = command).
+func parseLsof(out string) (command string, pid int) {
+	for line := range strings.SplitSeq(out, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			if pid != 0 {
+				return command, pid
+			}
+			pid, _ = strconv.Atoi(line[1:])
+		case 'c':
+			if command == "" {
+				command = line[1:]
+			}
+		}
+	}
+	return command, pid
+}
+
+var ssUsersPattern = regexp.MustCompile(`users:\(\("([^"]+)",pid=(\d+)`)
+
+// parseSS reads the first process from `ss -p` output.
+func parseSS(out string) (command string, pid int) {
+	m := ssUsersPattern.FindStringSubmatch(out)
+	if m == nil {
+		return "", 0
+	}
+	pid, _ = strconv.Atoi(m[2])
+	return m[1], pid
+}
+
+func isAddrInUse(err error) bool {
+	return errors.Is(err, syscall.EADDRINUSE)
+}
+
+// problems records conditions that leave the daemon running but degraded,
+// keyed by component.
+type problems struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func (p *problems) set(component, msg string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.m == nil {
+		p.m = make(map[string]string)
+	}
+	p.m[component] = msg
+}
+
+func (p *problems) clear(component string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.m, component)
+}
+
+// list returns the current problems in a stable order.
+func (p *problems) list() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]string, 0, len(p.m))
+	for _, msg := range p.m {
+		out = append(out, msg)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// managedServer is a listener-backed server that may start after Run,
+// once its port frees up.
+type managedServer struct {
+	mu  sync.Mutex
+	srv *http.Server
+}
+
+func (m *managedServer) set(srv *http.Server) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.srv = srv
+}
+
+// Shutdown stops the server if it was started.
+func (m *managedServer) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	srv := m.srv
+	m.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
+}
+
+// startServer creates and serves a listener-backed server. If its port is
+// held by another process, the daemon stays up in a degraded state,
+// reports the conflict through /health, and retries until the port frees
+// up or ctx is cancelled. Other listen errors are returned.
+func (d *Daemon) startServer(ctx context.Context, wg *sync.WaitGroup, errCh chan<- error, component string, port int,
+	create func() (*http.Server, net.Listener, error), serve func(*http.Server, net.Listener) error) (*managedServer, error) {
+	m := &managedServer{}
+	run := func(srv *http.Server, listener net.Listener) {
+		defer wg.Done()
+		d.logger.Info("server started", "component", component, "addr", listener.Addr().String())
+		if err := serve(srv, listener); err != nil && err != http.ErrServerClosed {
+			errCh <- fmt.Errorf("%s server: %w", strings.ToUpper(component), err)
+		}
+	}
+
+	srv, listener, err := create()
+	if err == nil {
+		m.set(srv)
+		wg.Add(1)
+		go run(srv, listener)
+		return m, nil
+	}
+	if !isAddrInUse(err) {
+		return nil, err
+	}
+
+	holder := FindPortHolder(port)
+	d.problems.set(component, holder.String())
+	d.logger.Error("port conflict", "component", component, "port", port,
+		"holder", holder.Command, "holder_pid", holder.PID, "error", err)
+
+	wg.Add(1)
+	go func() {
+		ticker := time.NewTicker(portRetryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				wg.Done()
+				return
+			case <-ticker.C:
+			}
+			srv, listener, err := create()
+			if err != nil {
+				if isAddrInUse(err) {
+					continue
+				}
+				errCh <- fmt.Errorf("creating %s server: %w", strings.ToUpper(component), err)
+				wg.Done()
+				return
+			}
+			m.set(srv)
+			d.problems.clear(component)
+			d.logger.Info("port conflict resolved", "component", component, "port", port)
+			run(srv, listener)
+			return
+		}
+	}()
+	return m, nil
+}
@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestParseLsof(t *testing.T) {
+	out := "p123\nccom.docker.backend\nf7\np456\ncnginx\n"
+	command, pid := parseLsof(out)
+	if command != "com.docker.backend" || pid != 123 {
+		t.Errorf("parseLsof = %q, %d; want com.docker.backend, 123", command, pid)
+	}
+
+	if command, pid := parseLsof(""); command != "" || pid != 0 {
+		t.Errorf("parseLsof(empty) = %q, %d", command, pid)
+	}
+}
+
+func TestParseSS(t *testing.T) {
+	out := `LISTEN 0      511      127.0.0.1:443      0.0.0.0:*    users:(("nginx",pid=4242,fd=6),("nginx",pid=4243,fd=6))` + "\n"
+	command, pid := parseSS(out)
+	if command != "nginx" || pid != 4242 {
+		t.Errorf("parseSS = %q, %d; want nginx, 4242", command, pid)
+	}
+
+	// Without privileges ss omits the users column.
+	if command, pid := parseSS("LISTEN 0 511 127.0.0.1:443 0.0.0.0:*\n"); command != "" || pid != 0 {
+		t.Errorf("parseSS(no users) = %q, %d", command, pid)
+	}
+}
+
+func TestPortHolderString(t *testing.T) {
+	h := PortHolder{Port: 443, Command: "com.docker.backend", PID: 123}
+	if got := h.String(); got != "port 443 held by com.docker.backend (pid 123)" {
+		t.Errorf("String() = %q", got)
+	}
+	h = PortHolder{Port: 80}
+	if got := h.String(); got != "port 80 in use by another process" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestStartServer_PortConflictDegrades(t *testing.T) {
+	blocker, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blocker.Close()
+	addr := blocker.Addr().String()
+	port := blocker.Addr().(*net.TCPAddr).Port
+
+	d := &Daemon{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+
+	create := func() (*http.Server, net.Listener, error) {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &http.Server{}, l, nil
+	}
+	m, err := d.startServer(ctx, &wg, errCh, "https", port, create,
+		func(srv *http.Server, l net.Listener) error { return srv.Serve(l) })
+	if err != nil {
+		t.Fatalf("startServer should degrade on conflict, got %v", err)
+	}
+
+	problems := d.problems.list()
+	if len(problems) != 1 {
+		t.Fatalf("expected one problem, got %v", problems)
+	}
+
+	cancel()
+	wg.Wait()
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown of unstarted server: %v", err)
+	}
+}
@@ -0,0 +1,403 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/hooks"
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+)
+
+// defaultHeartbeatTimeout is how long a route survives without a heartbeat
+// when the config file does not override it.
+const defaultHeartbeatTimeout = 30 * time.Second
+
+// defaultCleanupInterval is how often expired routes are swept when the
+// config file does not override it.
+const defaultCleanupInterval = 10 * time.Second
+
+// maxExtraTLDs bounds the additional TLDs accepted from the config file.
+const maxExtraTLDs = 10
+
+// maxHooks bounds the route lifecycle hooks accepted from the config file.
+const maxHooks = 20
+
+// maxConfigRoutes bounds the permanent routes accepted from the config
+// file, leaving room under the registry's limit for routes from up.
+const maxConfigRoutes = 50
+
+var tldPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// FileConfig is the part of the daemon configuration read from config.json
+// in the support directory. Every field can be changed at runtime with
+// SIGHUP or `paw-proxy reload`; listeners and ports are never touched.
+type FileConfig struct {
+	// LogLevel is one of debug, info, warn, or error.
+	LogLevel string `json:"log_level,omitempty"`
+	// TLDs are answered in addition to the primary TLD. The OS resolver
+	// must also be pointed at the daemon for each of them.
+	TLDs []string `json:"tlds,omitempty"`
+	// HeartbeatTimeout is a Go duration such as "30s" or "2m".
+	HeartbeatTimeout string `json:"heartbeat_timeout,omitempty"`
+	// CleanupInterval is how often expired routes are swept, a Go
+	// duration such as "5s".
+	CleanupInterval string `json:"cleanup_interval,omitempty"`
+	// Headers are injected into requests for every route. Route-level
+	// headers with the same name take precedence.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Hooks run commands or call webhooks when routes change.
+	Hooks []hooks.Hook `json:"hooks,omitempty"`
+	// Notifications shows desktop notifications for routes that stop
+	// heartbeating, unreachable dev servers, CA expiry, and restarts
+	// after a crash.
+	Notifications bool `json:"notifications,omitempty"`
+	// HSTS is what happens to Strict-Transport-Security headers from
+	// upstreams: "keep" (the default), "strip", or "rewrite" to drop
+	// includeSubDomains and preload. Routes can override it.
+	HSTS string `json:"hsts,omitempty"`
+	// ACME serves routes under a real domain with a publicly trusted
+	// wildcard certificate instead of one from the local CA. The domain is
+	// answered like an extra TLD.
+	ACME *ssl.ACMEConfig `json:"acme,omitempty"`
+	// MaxConnections caps the client connections open on the HTTP and
+	// HTTPS ports together. Past it, idle keep-alive connections are
+	// closed and new ones wait to be accepted.
+	MaxConnections int `json:"max_connections,omitempty"`
+	// MaxRequestsPerHost caps the requests in flight to one host. Past
+	// it, requests wait up to 10s for a slot, then get a 503.
+	MaxRequestsPerHost int `json:"max_requests_per_host,omitempty"`
+	// ResolverRepair lets the resolver watchdog rewrite the resolver file
+	// and flush the DNS cache when *.<tld> stops resolving, after asking
+	// for an administrator password. macOS only.
+	ResolverRepair bool `json:"resolver_repair,omitempty"`
+	// ReadOnlyAPI makes the control API and dashboard refuse to add,
+	// remove, or change routes, while listing and health stay readable.
+	ReadOnlyAPI bool `json:"read_only_api,omitempty"`
+	// Routes are registered at startup and never expire, for long-running
+	// services that aren't started through up. Each takes the same fields
+	// as a registration through the API.
+	Routes []api.RegisterRequest `json:"routes,omitempty"`
+	// Alerts warn when a route's request or error rate over the last
+	// minute crosses a threshold. Routes can override them.
+	Alerts *api.AlertConfig `json:"alerts,omitempty"`
+}
+
+// runtimeSettings is the validated form of FileConfig used on the request
+// path. It is replaced wholesale on reload, never mutated.
+type runtimeSettings struct {
+	logLevel         slog.Level
+	tlds             []string // primary TLD first
+	heartbeatTimeout time.Duration
+	cleanupInterval  time.Duration
+	headers          map[string]string
+	hooks            []hooks.Hook
+	notifications    bool
+	hsts             string
+	acme             *ssl.ACMEConfig
+
+	maxConnections     int
+	maxRequestsPerHost int
+	resolverRepair     bool
+	readOnlyAPI        bool
+	routes             []api.Route
+	alerts             *api.AlertConfig
+}
+
+// LoadFileConfig reads the config file at path. A missing file yields an
+// empty config so the daemon runs with defaults.
+func LoadFileConfig(path string) (FileConfig, error) {
+	var fc FileConfig
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fc, nil
+	}
+	if err != nil {
+		return fc, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return fc, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return fc, nil
+}
+
+// resolve validates fc and combines it with the static primary TLD.
+func (fc FileConfig) resolve(primaryTLD string) (*runtimeSettings, error) {
+	rs := &runtimeSettings{
+		logLevel:         slog.LevelInfo,
+		tlds:             []string{primaryTLD},
+		heartbeatTimeout: defaultHeartbeatTimeout,
+		cleanupInterval:  defaultCleanupInterval,
+		headers:          maps.Clone(fc.Headers),
+		hooks:            fc.Hooks,
+		notifications:    fc.Notifications,
+		hsts:             fc.HSTS,
+		acme:             fc.ACME,
+
+		maxConnections:     defaultMaxConnections,
+		maxRequestsPerHost: defaultMaxRequestsPerHost,
+		resolverRepair:     fc.ResolverRepair,
+		readOnlyAPI:        fc.ReadOnlyAPI,
+		alerts:             fc.Alerts,
+	}
+
+	if fc.LogLevel != "" {
+		if err := rs.logLevel.UnmarshalText([]byte(fc.LogLevel)); err != nil {
+			return nil, fmt.Errorf("log_level: %w", err)
+		}
+	}
+
+	if len(fc.TLDs) > maxExtraTLDs {
+		return nil, fmt.Errorf("tlds: at most %d entries", maxExtraTLDs)
+	}
+	for _, tld := range fc.TLDs {
+		if !tldPattern.MatchString(tld) {
+			return nil, fmt.Errorf("tlds: invalid TLD %q", tld)
+		}
+		if !slices.Contains(rs.tlds, tld) {
+			rs.tlds = append(rs.tlds, tld)
+		}
+	}
+
+	if fc.HeartbeatTimeout != "" {
+		timeout, err := time.ParseDuration(fc.HeartbeatTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("heartbeat_timeout: %w", err)
+		}
+		// SECURITY: Keep a floor so routes from live `up` sessions (which
+		// heartbeat every 10s) are never reaped, and a ceiling so dead
+		// routes do not linger indefinitely.
+		if timeout < 15*time.Second || timeout > time.Hour {
+			return nil, fmt.Errorf("heartbeat_timeout: must be between 15s and 1h")
+		}
+		rs.heartbeatTimeout = timeout
+	}
+
+	if fc.CleanupInterval != "" {
+		interval, err := time.ParseDuration(fc.CleanupInterval)
+		if err != nil {
+			return nil, fmt.Errorf("cleanup_interval: %w", err)
+		}
+		if interval < time.Second || interval > time.Minute {
+			return nil, fmt.Errorf("cleanup_interval: must be between 1s and 1m")
+		}
+		rs.cleanupInterval = interval
+	}
+
+	if fc.MaxConnections != 0 {
+		if fc.MaxConnections < 64 || fc.MaxConnections > 65536 {
+			return nil, fmt.Errorf("max_connections: must be between 64 and 65536")
+		}
+		rs.maxConnections = fc.MaxConnections
+	}
+
+	if fc.MaxRequestsPerHost != 0 {
+		if fc.MaxRequestsPerHost < 16 || fc.MaxRequestsPerHost > 65536 {
+			return nil, fmt.Errorf("max_requests_per_host: must be between 16 and 65536")
+		}
+		rs.maxRequestsPerHost = fc.MaxRequestsPerHost
+	}
+
+	if err := api.ValidateHeaders(fc.Headers); err != nil {
+		return nil, fmt.Errorf("headers: %w", err)
+	}
+
+	if err := api.ValidateHSTS(fc.HSTS); err != nil {
+		return nil, fmt.Errorf("hsts: %w", err)
+	}
+
+	if err := api.ValidateAlerts(fc.Alerts); err != nil {
+		return nil, fmt.Errorf("alerts: %w", err)
+	}
+
+	if fc.ACME != nil {
+		if err := fc.ACME.Validate(); err != nil {
+			return nil, fmt.Errorf("acme: %w", err)
+		}
+		if !slices.Contains(rs.tlds, fc.ACME.Domain) {
+			rs.tlds = append(rs.tlds, fc.ACME.Domain)
+		}
+	}
+
+	if len(fc.Hooks) > maxHooks {
+		return nil, fmt.Errorf("hooks: at most %d entries", maxHooks)
+	}
+	for i, h := range fc.Hooks {
+		if err := h.Validate(); err != nil {
+			return nil, fmt.Errorf("hooks[%d]: %w", i, err)
+		}
+	}
+
+	if len(fc.Routes) > maxConfigRoutes {
+		return nil, fmt.Errorf("routes: at most %d entries", maxConfigRoutes)
+	}
+	for i, req := range fc.Routes {
+		route, err := api.PermanentRoute(req)
+		if err != nil {
+			return nil, fmt.Errorf("routes[%d]: %w", i, err)
+		}
+		if slices.ContainsFunc(rs.routes, func(r api.Route) bool { return r.Name == route.Name }) {
+			return nil, fmt.Errorf("routes[%d]: duplicate name %q", i, route.Name)
+		}
+		rs.routes = append(rs.routes, route)
+	}
+	return rs, nil
+}
+
+// Validate checks fc the way the daemon does when it loads the config
+// file, with primaryTLD as the TLD from the command line.
+func (fc FileConfig) Validate(primaryTLD string) error {
+	_, err := fc.resolve(primaryTLD)
+	return err
+}
+
+// loadSettings reads and validates the config file, then applies the
+// LogLevel override from the command line or environment, which always
+// wins over the file.
+func (c *Config) loadSettings() (*runtimeSettings, error) {
+	fc, err := LoadFileConfig(c.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := fc.resolve(c.TLD)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.ConfigPath, err)
+	}
+	if c.LogLevel != "" {
+		if err := rs.logLevel.UnmarshalText([]byte(c.LogLevel)); err != nil {
+			return nil, fmt.Errorf("log level: %w", err)
+		}
+	}
+	return rs, nil
+}
+
+// CADomains returns the TLDs the local CA issues for: the primary TLD and
+// the extra TLDs from the config file. The ACME domain is left out, since
+// its certificates come from the ACME CA.
+func (c *Config) CADomains() ([]string, error) {
+	rs, err := c.loadSettings()
+	if err != nil {
+		return nil, err
+	}
+	return rs.caDomains(), nil
+}
+
+func (rs *runtimeSettings) caDomains() []string {
+	if rs.acme == nil {
+		return rs.tlds
+	}
+	return slices.DeleteFunc(slices.Clone(rs.tlds), func(tld string) bool { return tld == rs.acme.Domain })
+}
+
+// Reload re-reads the config file and applies it. On error the running
+// settings are left untouched.
+func (d *Daemon) Reload() error {
+	rs, err := d.config.loadSettings()
+	if err != nil {
+		d.logger.Error("config reload failed", "path", d.config.ConfigPath, "error", err)
+		return err
+	}
+	d.apply(rs)
+	d.logger.Info("config reloaded",
+		"path", d.config.ConfigPath,
+		"log_level", rs.logLevel.String(),
+		"tlds", rs.tlds,
+		"heartbeat_timeout", rs.heartbeatTimeout.String(),
+		"cleanup_interval", rs.cleanupInterval.String(),
+		"headers", len(rs.headers),
+		"hooks", len(rs.hooks),
+		"notifications", rs.notifications,
+		"hsts", rs.hsts,
+		"acme", rs.acme != nil,
+		"max_connections", rs.maxConnections,
+		"max_requests_per_host", rs.maxRequestsPerHost,
+		"resolver_repair", rs.resolverRepair,
+		"read_only_api", rs.readOnlyAPI || d.config.ReadOnly,
+		"routes", len(rs.routes),
+		"alerts", rs.alerts != nil,
+	)
+	return nil
+}
+
+func (d *Daemon) apply(rs *runtimeSettings) {
+	d.logLevel.Set(rs.logLevel)
+	d.dnsServer.SetTLDs(rs.tlds)
+	d.registry.SetTimeout(rs.heartbeatTimeout)
+	d.registry.SetPermanent(rs.routes)
+	if d.hooks != nil {
+		d.hooks.SetHooks(rs.hooks)
+	}
+	if d.notifier != nil {
+		d.notifier.enabled.Store(rs.notifications)
+	}
+	d.setACME(rs.acme)
+	d.checkCADomains(rs)
+	readOnly := rs.readOnlyAPI || d.config.ReadOnly
+	if d.apiServer != nil {
+		d.apiServer.SetReadOnly(readOnly)
+	}
+	if d.dash != nil {
+		d.dash.SetReadOnly(readOnly)
+	}
+	d.settings.Store(rs)
+	if d.conns != nil {
+		// Accepts waiting on the old connection cap.
+		d.conns.wake()
+	}
+}
+
+// checkCADomains reports TLDs the CA's name constraints don't cover:
+// browsers reject certificates for them until the CA is rotated.
+func (d *Daemon) checkCADomains(rs *runtimeSettings) {
+	if d.certCache == nil {
+		return
+	}
+	missing := ssl.Unpermitted(d.certCache.CA(), rs.caDomains())
+	if len(missing) == 0 {
+		d.problems.clear("ca")
+		return
+	}
+	d.logger.Warn("CA name constraints do not cover configured TLDs; run: sudo paw-proxy ca rotate", "tlds", missing)
+	d.problems.set("ca", fmt.Sprintf("CA is not permitted to issue for %s; run: sudo paw-proxy ca rotate", strings.Join(missing, ", ")))
+}
+
+// setACME starts serving certificates for config, replacing the running
+// ACME manager when the config changed. A nil config stops it.
+func (d *Daemon) setACME(config *ssl.ACMEConfig) {
+	old := d.acme.Load()
+	if old == nil && config == nil {
+		return
+	}
+	if old != nil {
+		if cur := old.Config(); cur.Equal(config) {
+			return
+		}
+	}
+	var m *ssl.ACMEManager
+	if config != nil {
+		m = ssl.NewACMEManager(*config, filepath.Join(d.config.SupportDir, "acme"), d.logger)
+		m.SetIssueLog(d.issued)
+		m.Start()
+	}
+	d.acme.Store(m)
+	if old != nil {
+		old.Stop()
+	}
+}
+
+// tlds returns the TLDs currently served, primary first. Daemons built
+// without New (as in tests) fall back to the default TLD.
+func (d *Daemon) tlds() []string {
+	if rs := d.settings.Load(); rs != nil {
+		return rs.tlds
+	}
+	return []string{"test"}
+}
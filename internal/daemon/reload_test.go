@@ -0,0 +1,195 @@
+package daemon
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/dns"
+	"github.com/alexcatdad/paw-proxy/internal/hooks"
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+)
+
+func TestFileConfigResolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		fc      FileConfig
+		wantErr bool
+	}{
+		{"empty uses defaults", FileConfig{}, false},
+		{"full config", FileConfig{
+			LogLevel:         "debug",
+			TLDs:             []string{"localhost", "dev-box"},
+			HeartbeatTimeout: "2m",
+			Headers:          map[string]string{"X-Env": "dev"},
+		}, false},
+		{"bad log level", FileConfig{LogLevel: "loud"}, true},
+		{"bad tld", FileConfig{TLDs: []string{"Not.Valid"}}, true},
+		{"unparseable timeout", FileConfig{HeartbeatTimeout: "soon"}, true},
+		{"timeout too short", FileConfig{HeartbeatTimeout: "5s"}, true},
+		{"cleanup interval", FileConfig{CleanupInterval: "2s"}, false},
+		{"cleanup interval too long", FileConfig{CleanupInterval: "5m"}, true},
+		{"reserved header", FileConfig{Headers: map[string]string{"Host": "x"}}, true},
+		{"hooks", FileConfig{Hooks: []hooks.Hook{
+			{On: []string{"added"}, Command: "echo up"},
+			{Route: "*.shop", URL: "http://127.0.0.1:9000/paw"},
+		}}, false},
+		{"hook without action", FileConfig{Hooks: []hooks.Hook{{On: []string{"added"}}}}, true},
+		{"hook with unknown event", FileConfig{Hooks: []hooks.Hook{{On: []string{"registered"}, Command: "true"}}}, true},
+		{"hsts rewrite", FileConfig{HSTS: "rewrite"}, false},
+		{"bad hsts", FileConfig{HSTS: "off"}, true},
+		{"connection limits", FileConfig{MaxConnections: 256, MaxRequestsPerHost: 64}, false},
+		{"max connections too low", FileConfig{MaxConnections: 8}, true},
+		{"max requests per host too high", FileConfig{MaxRequestsPerHost: 100000}, true},
+		{"acme", FileConfig{ACME: &ssl.ACMEConfig{Domain: "dev.example.com", DNSHook: []string{"/usr/local/bin/dns-hook"}}}, false},
+		{"acme without hook", FileConfig{ACME: &ssl.ACMEConfig{Domain: "dev.example.com"}}, true},
+		{"acme bad domain", FileConfig{ACME: &ssl.ACMEConfig{Domain: "*.dev.example.com", DNSHook: []string{"/usr/local/bin/dns-hook"}}}, true},
+		{"routes", FileConfig{Routes: []api.RegisterRequest{{Name: "pgadmin", Upstream: "localhost:5050"}}}, false},
+		{"route without upstream", FileConfig{Routes: []api.RegisterRequest{{Name: "pgadmin"}}}, true},
+		{"route with bad name", FileConfig{Routes: []api.RegisterRequest{{Name: "-pg", Upstream: "localhost:5050"}}}, true},
+		{"reserved route name", FileConfig{Routes: []api.RegisterRequest{{Name: "_paw", Upstream: "localhost:5050"}}}, true},
+		{"route with idle timeout", FileConfig{Routes: []api.RegisterRequest{{Name: "pgadmin", Upstream: "localhost:5050", IdleTimeout: "1h"}}}, true},
+		{"duplicate routes", FileConfig{Routes: []api.RegisterRequest{
+			{Name: "pgadmin", Upstream: "localhost:5050"},
+			{Name: "pgadmin", Upstream: "localhost:5051"},
+		}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, err := tt.fc.resolve("test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && rs.tlds[0] != "test" {
+				t.Errorf("expected primary TLD first, got %v", rs.tlds)
+			}
+			if err == nil && tt.fc.ACME != nil && !slices.Contains(rs.tlds, tt.fc.ACME.Domain) {
+				t.Errorf("expected ACME domain in tlds, got %v", rs.tlds)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+
+	dnsServer, err := dns.NewServer("127.0.0.1:0", "test")
+	if err != nil {
+		t.Fatalf("dns.NewServer: %v", err)
+	}
+	d := &Daemon{
+		config:    &Config{TLD: "test", ConfigPath: configPath},
+		dnsServer: dnsServer,
+		registry:  api.NewRouteRegistry(30 * time.Second),
+		logger:    slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		logLevel:  new(slog.LevelVar),
+	}
+
+	// Missing file: defaults
+	if err := d.Reload(); err != nil {
+		t.Fatalf("Reload with no file: %v", err)
+	}
+
+	os.WriteFile(configPath, []byte(`{"log_level": "debug", "tlds": ["localhost"]}`), 0600)
+	if err := d.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if d.logLevel.Level() != slog.LevelDebug {
+		t.Errorf("expected debug level, got %v", d.logLevel.Level())
+	}
+	if !slices.Equal(d.tlds(), []string{"test", "localhost"}) {
+		t.Errorf("unexpected tlds %v", d.tlds())
+	}
+
+	// An invalid file is rejected and the previous settings stay active.
+	os.WriteFile(configPath, []byte(`{"log_level": "loud"}`), 0600)
+	if err := d.Reload(); err == nil {
+		t.Fatal("expected error for invalid config")
+	}
+	if d.logLevel.Level() != slog.LevelDebug {
+		t.Errorf("expected previous level to be kept, got %v", d.logLevel.Level())
+	}
+	if !slices.Equal(d.tlds(), []string{"test", "localhost"}) {
+		t.Errorf("expected previous tlds to be kept, got %v", d.tlds())
+	}
+}
+
+func TestReload_CADomains(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+
+	dnsServer, err := dns.NewServer("127.0.0.1:0", "test")
+	if err != nil {
+		t.Fatalf("dns.NewServer: %v", err)
+	}
+	d := &Daemon{
+		config:    &Config{TLD: "test", ConfigPath: configPath},
+		dnsServer: dnsServer,
+		registry:  api.NewRouteRegistry(30 * time.Second),
+		logger:    slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		logLevel:  new(slog.LevelVar),
+		certCache: ssl.NewCertCache(&tls.Certificate{Leaf: &x509.Certificate{PermittedDNSDomains: []string{"test"}}}, "test"),
+	}
+
+	if err := d.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := d.problems.list(); len(got) != 0 {
+		t.Errorf("unexpected problems %v", got)
+	}
+
+	// A TLD outside the CA's constraints is reported until it is removed.
+	os.WriteFile(configPath, []byte(`{"tlds": ["localhost"]}`), 0600)
+	if err := d.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := d.problems.list(); len(got) != 1 || !strings.Contains(got[0], "localhost") {
+		t.Errorf("expected a problem naming localhost, got %v", got)
+	}
+
+	os.WriteFile(configPath, []byte(`{}`), 0600)
+	if err := d.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := d.problems.list(); len(got) != 0 {
+		t.Errorf("problem not cleared: %v", got)
+	}
+}
+
+func TestLoadSettings_LogLevelOverride(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(configPath, []byte(`{"log_level": "warn"}`), 0600)
+
+	cfg := &Config{TLD: "test", ConfigPath: configPath}
+	rs, err := cfg.loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings: %v", err)
+	}
+	if rs.logLevel != slog.LevelWarn {
+		t.Errorf("expected file level warn, got %v", rs.logLevel)
+	}
+
+	cfg.LogLevel = "debug"
+	rs, err = cfg.loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings: %v", err)
+	}
+	if rs.logLevel != slog.LevelDebug {
+		t.Errorf("expected override level debug, got %v", rs.logLevel)
+	}
+
+	cfg.LogLevel = "chatty"
+	if _, err := cfg.loadSettings(); err == nil {
+		t.Error("expected error for invalid override level")
+	}
+}
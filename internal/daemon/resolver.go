@@ -0,0 +1,112 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// resolverCheckInterval is how often the resolver watchdog looks up its
+// canary name.
+const resolverCheckInterval = time.Minute
+
+// resolverLookupTimeout bounds one canary lookup.
+const resolverLookupTimeout = 5 * time.Second
+
+// resolverCanary is the label looked up under the primary TLD. The DNS
+// server answers every name in the zone, so any label would do; this one
+// reads well in query logs.
+const resolverCanary = "paw-proxy-canary"
+
+// resolverWatchdog tracks whether the system resolver still sends the
+// primary TLD to the daemon. VPN clients on macOS are known to reset
+// resolver state, after which every route fails to resolve while the
+// daemon itself looks healthy.
+type resolverWatchdog struct {
+	lookup func(ctx context.Context, host string) ([]string, error)
+	repair func(tld string, dnsPort int) error
+	// failing is set while the canary doesn't resolve.
+	failing bool
+	// repaired is set once a repair was attempted for the current
+	// failure, so the password prompt isn't repeated every minute.
+	repaired bool
+}
+
+func newResolverWatchdog() *resolverWatchdog {
+	return &resolverWatchdog{lookup: net.DefaultResolver.LookupHost, repair: repairResolver}
+}
+
+// watchResolver checks the system resolver every resolverCheckInterval
+// until ctx is done.
+func (d *Daemon) watchResolver(ctx context.Context, w *resolverWatchdog) {
+	ticker := time.NewTicker(resolverCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.checkResolver(ctx, w)
+		}
+	}
+}
+
+// checkResolver looks up the canary through the system resolver. When it
+// stops resolving, the failure is logged, reported in the health check,
+// and notified; with resolver_repair set, the resolver file is rewritten
+// once per failure.
+func (d *Daemon) checkResolver(ctx context.Context, w *resolverWatchdog) {
+	tld := d.config.TLD
+	host := resolverCanary + "." + tld
+	err := w.resolve(ctx, host)
+	if err == nil {
+		if w.failing {
+			d.logger.Info("system resolver recovered", "component", "resolver", "tld", tld)
+			d.problems.clear("resolver")
+		}
+		w.failing, w.repaired = false, false
+		return
+	}
+
+	if !w.failing {
+		d.logger.Warn("system resolver check failed", "component", "resolver", "host", host, "error", err)
+		msg := fmt.Sprintf("*.%s no longer resolves to paw-proxy, e.g. after a VPN reset DNS; run: sudo paw-proxy doctor --fix", tld)
+		d.problems.set("resolver", msg)
+		d.notifier.notify("resolver", msg)
+		w.failing = true
+	}
+	if rs := d.settings.Load(); w.repaired || rs == nil || !rs.resolverRepair {
+		return
+	}
+	w.repaired = true
+	if err := w.repair(tld, d.config.DNSPort); err != nil {
+		d.logger.Warn("resolver repair failed", "component", "resolver", "tld", tld, "error", err)
+		return
+	}
+	if err := w.resolve(ctx, host); err != nil {
+		d.logger.Warn("system resolver still failing after repair", "component", "resolver", "host", host, "error", err)
+		return
+	}
+	d.logger.Info("system resolver repaired", "component", "resolver", "tld", tld)
+	d.problems.clear("resolver")
+	w.failing, w.repaired = false, false
+}
+
+// resolve looks up host and checks that it points at loopback, where the
+// daemon's DNS server sends every name it answers.
+func (w *resolverWatchdog) resolve(ctx context.Context, host string) error {
+	ctx, cancel := context.WithTimeout(ctx, resolverLookupTimeout)
+	defer cancel()
+	addrs, err := w.lookup(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil && ip.IsLoopback() {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s resolved to %s instead of loopback", host, strings.Join(addrs, ", "))
+}
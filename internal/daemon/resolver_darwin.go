@@ -0,0 +1,34 @@
+//go:build darwin
+
+package daemon
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/alexcatdad/paw-proxy/internal/setup"
+)
+
+// resolverWatchdogEnabled is true on macOS, whose /etc/resolver entries
+// VPN clients tend to override.
+const resolverWatchdogEnabled = true
+
+// repairResolver rewrites /etc/resolver/<tld> and flushes the DNS cache.
+// The daemon runs as the user, so macOS asks for an administrator
+// password first.
+func repairResolver(tld string, dnsPort int) error {
+	path := "/etc/resolver/" + tld
+	script := fmt.Sprintf("mkdir -p /etc/resolver && printf %%s '%s' > %s && dscacheutil -flushcache && killall -HUP mDNSResponder",
+		setup.ResolverContent(dnsPort), path)
+	// Embed the shell script in an AppleScript string literal.
+	script = strings.ReplaceAll(script, `\`, `\\`)
+	script = strings.ReplaceAll(script, `"`, `\"`)
+	script = strings.ReplaceAll(script, "\n", `\n`)
+	out, err := exec.Command("osascript", "-e",
+		fmt.Sprintf(`do shell script "%s" with prompt "paw-proxy wants to repair DNS for .%s" with administrator privileges`, script, tld)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !darwin
+
+package daemon
+
+import "fmt"
+
+// resolverWatchdogEnabled is false off macOS: systemd-resolved keeps its
+// stub zone config, and doctor checks it.
+const resolverWatchdogEnabled = false
+
+func repairResolver(tld string, dnsPort int) error {
+	return fmt.Errorf("not supported on this platform")
+}
@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCheckResolver(t *testing.T) {
+	n, sent := recordingNotifier()
+	d := &Daemon{
+		config:   &Config{TLD: "test", DNSPort: 9353},
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		notifier: n,
+	}
+	d.settings.Store(&runtimeSettings{})
+
+	addrs := []string{"127.0.0.1"}
+	var lookedUp string
+	repairs := 0
+	w := &resolverWatchdog{
+		lookup: func(ctx context.Context, host string) ([]string, error) {
+			lookedUp = host
+			if addrs == nil {
+				return nil, errors.New("no such host")
+			}
+			return addrs, nil
+		},
+		repair: func(tld string, port int) error {
+			if tld != "test" || port != 9353 {
+				t.Errorf("repair(%q, %d), want (test, 9353)", tld, port)
+			}
+			repairs++
+			addrs = []string{"::1"}
+			return nil
+		},
+	}
+
+	d.checkResolver(context.Background(), w)
+	if lookedUp != "paw-proxy-canary.test" {
+		t.Errorf("looked up %q, want paw-proxy-canary.test", lookedUp)
+	}
+	if w.failing || len(d.problems.list()) != 0 {
+		t.Fatal("healthy resolver reported as failing")
+	}
+
+	// A VPN took over DNS: the canary resolves somewhere else.
+	addrs = []string{"10.0.0.5"}
+	d.checkResolver(context.Background(), w)
+	if !w.failing {
+		t.Fatal("wrong answer not detected")
+	}
+	if p := d.problems.list(); len(p) != 1 || !strings.Contains(p[0], "*.test") {
+		t.Errorf("problems = %q", p)
+	}
+	if msg := <-sent; !strings.Contains(msg, "*.test") {
+		t.Errorf("notification %q", msg)
+	}
+	if repairs != 0 {
+		t.Error("repaired without resolver_repair")
+	}
+
+	// With repair enabled, one repair is attempted and fixes it.
+	d.settings.Store(&runtimeSettings{resolverRepair: true})
+	addrs = nil
+	d.checkResolver(context.Background(), w)
+	if repairs != 1 {
+		t.Fatalf("repairs = %d, want 1", repairs)
+	}
+	if w.failing || len(d.problems.list()) != 0 {
+		t.Error("problem not cleared after a successful repair")
+	}
+}
+
+func TestCheckResolver_RepairsOncePerFailure(t *testing.T) {
+	d := &Daemon{
+		config: &Config{TLD: "test", DNSPort: 9353},
+		logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+	d.settings.Store(&runtimeSettings{resolverRepair: true})
+
+	healthy := false
+	repairs := 0
+	w := &resolverWatchdog{
+		lookup: func(ctx context.Context, host string) ([]string, error) {
+			if healthy {
+				return []string{"127.0.0.1"}, nil
+			}
+			return nil, errors.New("no such host")
+		},
+		repair: func(tld string, port int) error {
+			repairs++
+			return errors.New("user canceled")
+		},
+	}
+
+	for range 3 {
+		d.checkResolver(context.Background(), w)
+	}
+	if repairs != 1 {
+		t.Errorf("repairs = %d during one failure, want 1", repairs)
+	}
+
+	healthy = true
+	d.checkResolver(context.Background(), w)
+	if w.failing || len(d.problems.list()) != 0 {
+		t.Error("recovery not detected")
+	}
+
+	// A new failure gets a new attempt.
+	healthy = false
+	d.checkResolver(context.Background(), w)
+	if repairs != 2 {
+		t.Errorf("repairs = %d after a second failure, want 2", repairs)
+	}
+}
@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+// serveSPA serves r through serve, and if the response has a status the
+// route's SPA fallback replaces, serves the route's index page instead.
+// The first response is held back until its status is known, so nothing
+// of it reaches the client.
+func serveSPA(w http.ResponseWriter, r *http.Request, spa *api.SPAConfig, serve func(http.ResponseWriter, *http.Request)) {
+	held := &spaWriter{ResponseWriter: w, spa: spa, header: make(http.Header)}
+	serve(held, r)
+	if held.missed == 0 {
+		return
+	}
+
+	index := r.Clone(r.Context())
+	index.URL.Path = spa.IndexPath()
+	index.URL.RawPath = ""
+	if spa.KeepStatus {
+		w = &statusOverride{ResponseWriter: w, status: held.missed}
+	}
+	serve(w, index)
+}
+
+// spaWriter passes a response through unless its status is one the SPA
+// fallback replaces, in which case it records the status and discards
+// the response. Headers are buffered until the status is known.
+type spaWriter struct {
+	http.ResponseWriter
+	spa     *api.SPAConfig
+	header  http.Header
+	decided bool
+	// missed is the status of the discarded response, or 0.
+	missed int
+}
+
+func (w *spaWriter) Header() http.Header {
+	if w.decided && w.missed == 0 {
+		return w.ResponseWriter.Header()
+	}
+	return w.header
+}
+
+func (w *spaWriter) WriteHeader(code int) {
+	if w.decided {
+		if w.missed == 0 {
+			w.ResponseWriter.WriteHeader(code)
+		}
+		return
+	}
+	// Informational responses (103 Early Hints) may precede either kind;
+	// drop them rather than commit to one.
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		return
+	}
+	w.decided = true
+	if w.spa.Replaces(code) {
+		w.missed = code
+		return
+	}
+	maps.Copy(w.ResponseWriter.Header(), w.header)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *spaWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.missed != 0 {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *spaWriter) Flush() {
+	if !w.decided || w.missed != 0 {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *spaWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("hijack not supported")
+}
+
+func (w *spaWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// statusOverride sends status instead of 200 OK, for an index page served
+// with the status of the response it replaced.
+type statusOverride struct {
+	http.ResponseWriter
+	status  int
+	written bool
+}
+
+func (w *statusOverride) WriteHeader(code int) {
+	if code == http.StatusOK && !w.written {
+		code = w.status
+	}
+	w.written = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusOverride) Write(b []byte) (int, error) {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusOverride) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusOverride) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
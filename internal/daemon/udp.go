@@ -0,0 +1,229 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+// udpSessionIdle is how long a client's upstream socket is kept without
+// traffic in either direction.
+const udpSessionIdle = 2 * time.Minute
+
+// maxUDPSessions caps the clients a UDP route tracks at once.
+const maxUDPSessions = 1024
+
+// maxDatagram fits any UDP payload.
+const maxDatagram = 64 * 1024
+
+// udpForwarders holds one forwarder per UDP route, keyed by route name.
+type udpForwarders struct {
+	mu sync.Mutex
+	m  map[string]*udpForwarder
+}
+
+func newUDPForwarders() *udpForwarders {
+	return &udpForwarders{m: make(map[string]*udpForwarder)}
+}
+
+// watchUDP starts and stops UDP forwarders as routes change, until ctx is
+// done. Every event triggers a full sync, so dropped events only delay it.
+func (d *Daemon) watchUDP(ctx context.Context, events <-chan api.RouteEvent) {
+	defer d.closeUDP()
+	// Routes restored after an in-place restart come without events.
+	d.syncUDP()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+			d.syncUDP()
+		}
+	}
+}
+
+// syncUDP makes the running forwarders match the registry's UDP routes.
+// A forwarder survives re-registration as long as its upstream and
+// requested port are unchanged, so clients keep the port across restarts
+// of the dev server.
+func (d *Daemon) syncUDP() {
+	want := make(map[string]api.Route)
+	for _, route := range d.registry.List() {
+		if route.UDP {
+			want[route.Name] = route
+		}
+	}
+
+	d.udp.mu.Lock()
+	defer d.udp.mu.Unlock()
+	for name, f := range d.udp.m {
+		route, ok := want[name]
+		if ok && route.Upstream == f.route && (route.UDPPort == 0 || route.UDPPort == f.port) {
+			continue
+		}
+		f.close()
+		delete(d.udp.m, name)
+		d.logger.Info("udp forwarding stopped", "route", name, "port", f.port)
+	}
+	for name, route := range want {
+		if f, ok := d.udp.m[name]; ok {
+			d.registry.SetUDPPort(name, f.port)
+			continue
+		}
+		f, err := newUDPForwarder(route)
+		if err != nil {
+			d.logger.Warn("udp forwarding failed", "route", name, "upstream", route.Upstream, "error", err)
+			continue
+		}
+		d.udp.m[name] = f
+		d.registry.SetUDPPort(name, f.port)
+		d.logger.Info("udp forwarding", "route", name, "port", f.port, "upstream", route.Upstream)
+	}
+}
+
+// closeUDP stops every forwarder.
+func (d *Daemon) closeUDP() {
+	d.udp.mu.Lock()
+	defer d.udp.mu.Unlock()
+	for name, f := range d.udp.m {
+		f.close()
+		delete(d.udp.m, name)
+	}
+}
+
+// udpPortFor returns the port UDP is forwarded from for host, for DNS SRV
+// answers.
+func (d *Daemon) udpPortFor(host string) (int, bool) {
+	name := api.ExtractNameFor(host, d.tlds())
+	d.udp.mu.Lock()
+	defer d.udp.mu.Unlock()
+	if f, ok := d.udp.m[name]; ok {
+		return f.port, true
+	}
+	return 0, false
+}
+
+// udpForwarder relays datagrams between clients on its loopback port and
+// a route's upstream. Each client gets its own upstream socket, so
+// replies find their way back to the right client.
+type udpForwarder struct {
+	conn     *net.UDPConn
+	port     int
+	route    string // the route's Upstream, as registered
+	upstream *net.UDPAddr
+
+	mu       sync.Mutex
+	sessions map[string]*udpSession
+}
+
+type udpSession struct {
+	client   *net.UDPAddr
+	conn     *net.UDPConn
+	lastSeen atomic.Int64 // unix nanoseconds
+}
+
+func newUDPForwarder(route api.Route) (*udpForwarder, error) {
+	upstream, err := net.ResolveUDPAddr("udp", route.Upstream)
+	if err != nil {
+		return nil, err
+	}
+	// SECURITY: Loopback only, like the HTTP and HTTPS listeners.
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: route.UDPPort})
+	if err != nil {
+		return nil, err
+	}
+	f := &udpForwarder{
+		conn:     conn,
+		port:     conn.LocalAddr().(*net.UDPAddr).Port,
+		route:    route.Upstream,
+		upstream: upstream,
+		sessions: make(map[string]*udpSession),
+	}
+	go f.serve()
+	return f, nil
+}
+
+func (f *udpForwarder) serve() {
+	buf := make([]byte, maxDatagram)
+	for {
+		n, client, err := f.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		s := f.session(client)
+		if s == nil {
+			continue // too many clients; the datagram is dropped
+		}
+		s.lastSeen.Store(time.Now().UnixNano())
+		s.conn.Write(buf[:n])
+	}
+}
+
+// session returns the upstream socket for client, opening one on its
+// first datagram.
+func (f *udpForwarder) session(client *net.UDPAddr) *udpSession {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.sessions[client.String()]; ok {
+		return s
+	}
+	if len(f.sessions) >= maxUDPSessions {
+		return nil
+	}
+	conn, err := net.DialUDP("udp", nil, f.upstream)
+	if err != nil {
+		return nil
+	}
+	s := &udpSession{client: client, conn: conn}
+	f.sessions[client.String()] = s
+	go f.reply(s)
+	return s
+}
+
+// reply copies the upstream's datagrams back to the session's client
+// until the session has been idle for udpSessionIdle.
+func (f *udpForwarder) reply(s *udpSession) {
+	defer func() {
+		f.mu.Lock()
+		delete(f.sessions, s.client.String())
+		f.mu.Unlock()
+		s.conn.Close()
+	}()
+	buf := make([]byte, maxDatagram)
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(udpSessionIdle))
+		n, err := s.conn.Read(buf)
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			continue // the upstream isn't listening yet, or is restarting
+		}
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() &&
+				time.Since(time.Unix(0, s.lastSeen.Load())) < udpSessionIdle {
+				continue // the client is still sending
+			}
+			return
+		}
+		s.lastSeen.Store(time.Now().UnixNano())
+		f.conn.WriteToUDP(buf[:n], s.client)
+	}
+}
+
+// close stops the forwarder and its sessions.
+func (f *udpForwarder) close() {
+	f.conn.Close()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.sessions {
+		s.conn.Close()
+	}
+}
@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+// udpEcho starts a UDP server that replies with each datagram it gets.
+func udpEcho(t *testing.T) *net.UDPConn {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteToUDP(append([]byte("echo "), buf[:n]...), from)
+		}
+	}()
+	return conn
+}
+
+func TestSyncUDP(t *testing.T) {
+	echo := udpEcho(t)
+	registry := api.NewRouteRegistry(30 * time.Second)
+	if err := registry.RegisterRoute(api.Route{Name: "game", Upstream: echo.LocalAddr().String(), Dir: "/tmp/game", UDP: true}); err != nil {
+		t.Fatalf("RegisterRoute: %v", err)
+	}
+	d := &Daemon{
+		registry: registry,
+		logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		udp:      newUDPForwarders(),
+	}
+	defer d.closeUDP()
+
+	d.syncUDP()
+	port, ok := d.udpPortFor("game.test")
+	if !ok {
+		t.Fatal("no UDP port for game.test")
+	}
+	if route, _ := registry.Lookup("game"); route.UDPPort != port {
+		t.Errorf("route UDPPort = %d, want %d", route.UDPPort, port)
+	}
+
+	client, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+	if err != nil {
+		t.Fatalf("DialUDP: %v", err)
+	}
+	defer client.Close()
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := client.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	buf := make([]byte, 64)
+	n, err := client.Read(buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got := string(buf[:n]); got != "echo ping" {
+		t.Errorf("reply = %q, want %q", got, "echo ping")
+	}
+
+	// Re-syncing keeps the port; removing the route stops forwarding.
+	d.syncUDP()
+	if again, _ := d.udpPortFor("game.test"); again != port {
+		t.Errorf("port changed on resync: %d -> %d", port, again)
+	}
+	registry.Deregister("game")
+	d.syncUDP()
+	if _, ok := d.udpPortFor("game.test"); ok {
+		t.Error("UDP still forwarded after the route was removed")
+	}
+}
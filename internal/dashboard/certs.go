@@ -0,0 +1,76 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+)
+
+// caStatus is the CA part of GET /api/certs.
+type caStatus struct {
+	Subject  string    `json:"subject"`
+	NotAfter time.Time `json:"notAfter"`
+	// Domains are the names the CA may sign for; empty when it is
+	// unconstrained.
+	Domains []string `json:"domains,omitempty"`
+}
+
+type certsStatus struct {
+	CA    caStatus         `json:"ca"`
+	Certs []ssl.CachedCert `json:"certs"`
+}
+
+// SetCertCache sets the cache whose CA and certificates the certificates
+// panel shows, and the function its flush button runs. flush drops the
+// cached certificates, loading a CA rotated on disk, and returns how many
+// it dropped.
+func (d *Dashboard) SetCertCache(cache *ssl.CertCache, flush func() (int, error)) {
+	d.certs = cache
+	d.flushCerts = flush
+}
+
+func (d *Dashboard) handleAPICerts(w http.ResponseWriter, r *http.Request) {
+	if d.certs == nil {
+		http.Error(w, "certificates not available", http.StatusNotImplemented)
+		return
+	}
+	status := certsStatus{Certs: d.certs.Cached()}
+	if ca := d.certs.CA(); ca != nil {
+		status.CA = caStatus{
+			Subject:  ca.Subject.CommonName,
+			NotAfter: ca.NotAfter,
+			Domains:  ca.PermittedDNSDomains,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Printf("dashboard: failed to encode certs: %v", err)
+	}
+}
+
+// handleAPIFlushCerts drops the cached certificates, like `paw-proxy certs
+// flush`. It is protected like handleAPISetAuth.
+func (d *Dashboard) handleAPIFlushCerts(w http.ResponseWriter, r *http.Request) {
+	if d.flushCerts == nil {
+		http.Error(w, "certificates not available", http.StatusNotImplemented)
+		return
+	}
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+		http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	n, err := d.flushCerts()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]int{"flushed": n}); err != nil {
+		log.Printf("dashboard: failed to encode flush response: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package dashboard
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+)
+
+func TestDashboard_Certs(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := ssl.GenerateCA(certPath, keyPath); err != nil {
+		t.Fatal(err)
+	}
+	ca, err := ssl.LoadCA(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := ssl.NewCertCache(ca, "test")
+	if _, err := cache.GetCertificate(&tls.ClientHelloInfo{ServerName: "myapp.test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	d := newTestDashboard(t, NewMetrics(10), &mockRouteProvider{}, "1.0.0", time.Now())
+	d.SetCertCache(cache, func() (int, error) { return cache.Invalidate(nil), nil })
+
+	req := httptest.NewRequest("GET", "https://_paw.test/api/certs", nil)
+	signIn(t, d, req)
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var status certsStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.CA.Subject != ca.Leaf.Subject.CommonName || !status.CA.NotAfter.Equal(ca.Leaf.NotAfter) {
+		t.Errorf("CA = %+v", status.CA)
+	}
+	if len(status.Certs) != 1 || status.Certs[0].Names[0] != "myapp.test" {
+		t.Errorf("certs = %+v, want myapp.test", status.Certs)
+	}
+
+	// A form post, which a cross-site page could send, is refused.
+	req = httptest.NewRequest("POST", "https://_paw.test/api/certs/flush", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	signIn(t, d, req)
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, req)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("form post: expected 415, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "https://_paw.test/api/certs/flush", nil)
+	req.Header.Set("Content-Type", "application/json")
+	signIn(t, d, req)
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "{\"flushed\":1}\n" {
+		t.Errorf("flush: got %d %q", w.Code, w.Body.String())
+	}
+	if got := cache.Cached(); len(got) != 0 {
+		t.Errorf("certificates still cached after flush: %+v", got)
+	}
+}
@@ -6,10 +6,17 @@ import (
 	"fmt"
 	"io/fs"
 	"log"
+	"mime"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/proxy"
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
 )
 
 //go:embed static
@@ -18,6 +25,14 @@ var staticFS embed.FS
 // RouteProvider abstracts access to the route registry.
 type RouteProvider interface {
 	List() []api.Route
+	SetAuth(name string, auth *api.RouteAuth) error
+	SetThrottle(name string, throttle *api.ThrottleConfig) error
+}
+
+// RouteEventSource publishes route registry changes.
+type RouteEventSource interface {
+	Subscribe() chan api.RouteEvent
+	Unsubscribe(ch chan api.RouteEvent)
 }
 
 // cspDashboard is the Content-Security-Policy for the dashboard.
@@ -25,6 +40,13 @@ type RouteProvider interface {
 // EventSource (SSE) via connect-src. No inline scripts or styles are used.
 const cspDashboard = "default-src 'self'; script-src 'self'; style-src 'self'; img-src 'self' data:; font-src 'self'; connect-src 'self'"
 
+const (
+	// sseKeepAlive is how often /events writes a comment to an idle stream.
+	sseKeepAlive = 15 * time.Second
+	// sseRetry is the reconnect delay /events asks the browser to use.
+	sseRetry = 3 * time.Second
+)
+
 // Dashboard serves the web dashboard UI and its API endpoints.
 type Dashboard struct {
 	metrics   *Metrics
@@ -32,6 +54,25 @@ type Dashboard struct {
 	version   string
 	startTime time.Time
 	mux       *http.ServeMux
+	// pool reports upstream connection pool stats keyed by upstream.
+	pool func() map[string]proxy.PoolStats
+	// alertsFn reports the routes over an alert threshold.
+	alertsFn func() map[string]string
+	sessions *sessions
+	// certs and flushCerts back the certificates panel.
+	certs      *ssl.CertCache
+	flushCerts func() (int, error)
+	// logs backs the log pane.
+	logs *LogBuffer
+	// routeEvents, when set, lets the UI refresh routes as they change.
+	routeEvents RouteEventSource
+	// readOnly refuses changes to routes, as for the control API.
+	readOnly atomic.Bool
+}
+
+// SetReadOnly makes the dashboard refuse to change route settings.
+func (d *Dashboard) SetReadOnly(on bool) {
+	d.readOnly.Store(on)
 }
 
 // New creates a Dashboard instance.
@@ -41,6 +82,7 @@ func New(metrics *Metrics, routes RouteProvider, version string, startTime time.
 		routes:    routes,
 		version:   version,
 		startTime: startTime,
+		sessions:  newSessions(),
 	}
 
 	staticSub, err := fs.Sub(staticFS, "static")
@@ -49,33 +91,83 @@ func New(metrics *Metrics, routes RouteProvider, version string, startTime time.
 	}
 
 	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pair", d.handlePair)
 	mux.HandleFunc("GET /events", d.handleEvents)
 	mux.HandleFunc("GET /api/routes", d.handleAPIRoutes)
 	mux.HandleFunc("GET /api/stats", d.handleAPIStats)
+	mux.HandleFunc("PUT /api/routes/{name}/auth", d.handleAPISetAuth)
+	mux.HandleFunc("PUT /api/routes/{name}/throttle", d.handleAPISetThrottle)
+	mux.HandleFunc("GET /api/certs", d.handleAPICerts)
+	mux.HandleFunc("GET /api/logs", d.handleAPILogs)
+	mux.HandleFunc("POST /api/certs/flush", d.handleAPIFlushCerts)
+	mux.HandleFunc("GET /widget", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, staticSub, "widget.html")
+	})
+	mux.HandleFunc("GET /widget/routes", d.handleWidgetRoutes)
 	mux.Handle("GET /", http.FileServerFS(staticSub))
 
 	d.mux = mux
 	return d, nil
 }
 
+// SetRouteEvents makes the /events stream include route registry changes
+// as "route" events.
+func (d *Dashboard) SetRouteEvents(src RouteEventSource) {
+	d.routeEvents = src
+}
+
+// SetAlertFunc sets the source of the routes over an alert threshold, with
+// what they crossed.
+func (d *Dashboard) SetAlertFunc(fn func() map[string]string) {
+	d.alertsFn = fn
+}
+
+// SetPoolFunc sets the source of the connection pool stats shown per route.
+func (d *Dashboard) SetPoolFunc(fn func() map[string]proxy.PoolStats) {
+	d.pool = fn
+}
+
 func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Security-Policy", cspDashboard)
+	if !d.authorize(w, r) {
+		return
+	}
 	d.mux.ServeHTTP(w, r)
 }
 
 type routeWithMetrics struct {
-	Name       string    `json:"name"`
-	Upstream   string    `json:"upstream"`
-	Dir        string    `json:"dir"`
-	Registered time.Time `json:"registered"`
-	Requests   int64     `json:"requests"`
-	AvgMs      int64     `json:"avgMs"`
-	Errors     int64     `json:"errors"`
+	Name       string              `json:"name"`
+	Upstream   string              `json:"upstream"`
+	Dir        string              `json:"dir"`
+	Registered time.Time           `json:"registered"`
+	Requests   int64               `json:"requests"`
+	AvgMs      int64               `json:"avgMs"`
+	Errors     int64               `json:"errors"`
+	Auth       string              `json:"auth,omitempty"`
+	Preview    string              `json:"preview,omitempty"`
+	Project    string              `json:"project,omitempty"`
+	Owner      *api.PeerCred       `json:"owner,omitempty"`
+	IdleSecs   int64               `json:"idleTimeoutSeconds,omitempty"`
+	Pool       *proxy.PoolStats    `json:"pool,omitempty"`
+	Churn      *api.RouteChurn     `json:"churn,omitempty"`
+	Throttle   *api.ThrottleConfig `json:"throttle,omitempty"`
+	Static     *api.StaticConfig   `json:"static,omitempty"`
+	Permanent  bool                `json:"permanent,omitempty"`
+	Balance    *api.BalanceConfig  `json:"balance,omitempty"`
+	Alert      string              `json:"alert,omitempty"`
 }
 
 func (d *Dashboard) handleAPIRoutes(w http.ResponseWriter, r *http.Request) {
 	routes := d.routes.List()
 	stats := d.metrics.RouteStats()
+	var pools map[string]proxy.PoolStats
+	if d.pool != nil {
+		pools = d.pool()
+	}
+	var alerts map[string]string
+	if d.alertsFn != nil {
+		alerts = d.alertsFn()
+	}
 
 	result := make([]routeWithMetrics, 0, len(routes))
 	for _, route := range routes {
@@ -84,6 +176,17 @@ func (d *Dashboard) handleAPIRoutes(w http.ResponseWriter, r *http.Request) {
 			Upstream:   route.Upstream,
 			Dir:        route.Dir,
 			Registered: route.Registered,
+			Auth:       route.AuthMode,
+			Preview:    route.Preview,
+			Project:    route.Project,
+			Owner:      route.Owner,
+			IdleSecs:   route.IdleTimeoutSeconds,
+			Churn:      route.Churn,
+			Throttle:   route.Throttle,
+			Static:     route.Static,
+			Permanent:  route.Permanent,
+			Balance:    route.Balance,
+			Alert:      alerts[route.Name],
 		}
 		if s, ok := stats[route.Name]; ok {
 			rm.Requests = s.Requests
@@ -92,6 +195,9 @@ func (d *Dashboard) handleAPIRoutes(w http.ResponseWriter, r *http.Request) {
 				rm.AvgMs = s.TotalMs / s.Requests
 			}
 		}
+		if ps, ok := pools[route.Upstream]; ok {
+			rm.Pool = &ps
+		}
 		result = append(result, rm)
 	}
 
@@ -101,6 +207,112 @@ func (d *Dashboard) handleAPIRoutes(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// maxAuthBodySize bounds the JSON body accepted by the auth endpoint.
+const maxAuthBodySize = 4096
+
+// handleAPISetAuth sets or clears credentials on a route. An empty JSON
+// object clears them.
+//
+// SECURITY: Requiring a JSON content type forces a CORS preflight for
+// cross-origin callers, which the dashboard never approves. Requests that
+// do carry an Origin must match the dashboard host (see authorize).
+func (d *Dashboard) handleAPISetAuth(w http.ResponseWriter, r *http.Request) {
+	if d.readOnly.Load() {
+		http.Error(w, "routes are read-only", http.StatusForbidden)
+		return
+	}
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+		http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAuthBodySize)
+	var auth api.RouteAuth
+	if err := json.NewDecoder(r.Body).Decode(&auth); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var authPtr *api.RouteAuth
+	if auth != (api.RouteAuth{}) {
+		authPtr = &auth
+	}
+	if err := d.routes.SetAuth(r.PathValue("name"), authPtr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAPISetThrottle sets or, given an empty JSON object, removes a
+// route's throttle. It is protected like handleAPISetAuth.
+func (d *Dashboard) handleAPISetThrottle(w http.ResponseWriter, r *http.Request) {
+	if d.readOnly.Load() {
+		http.Error(w, "routes are read-only", http.StatusForbidden)
+		return
+	}
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+		http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAuthBodySize)
+	var throttle api.ThrottleConfig
+	if err := json.NewDecoder(r.Body).Decode(&throttle); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var throttlePtr *api.ThrottleConfig
+	if throttle != (api.ThrottleConfig{}) {
+		throttlePtr = &throttle
+	}
+	if err := d.routes.SetThrottle(r.PathValue("name"), throttlePtr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// widgetRoute is what the widget shows of a route. It is served without a
+// session, so it leaves out upstreams, directories, and owners.
+type widgetRoute struct {
+	Name string `json:"name"`
+	// Status is up, paused, maintenance, alert (over an alert threshold),
+	// or flapping.
+	Status string `json:"status"`
+}
+
+func (d *Dashboard) handleWidgetRoutes(w http.ResponseWriter, r *http.Request) {
+	var alerts map[string]string
+	if d.alertsFn != nil {
+		alerts = d.alertsFn()
+	}
+
+	routes := d.routes.List()
+	result := make([]widgetRoute, 0, len(routes))
+	for _, route := range routes {
+		status := "up"
+		switch {
+		case route.Paused:
+			status = "paused"
+		case route.InMaintenance(time.Now()):
+			status = "maintenance"
+		case alerts[route.Name] != "":
+			status = "alert"
+		case route.Churn != nil && route.Churn.Flapping:
+			status = "flapping"
+		}
+		result = append(result, widgetRoute{Name: route.Name, Status: status})
+	}
+	slices.SortFunc(result, func(a, b widgetRoute) int { return strings.Compare(a.Name, b.Name) })
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("dashboard: failed to encode widget routes: %v", err)
+	}
+}
+
 func (d *Dashboard) handleAPIStats(w http.ResponseWriter, r *http.Request) {
 	uptime := time.Since(d.startTime)
 	uptimeStr := formatDuration(uptime)
@@ -139,27 +351,74 @@ func (d *Dashboard) handleEvents(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.WriteHeader(http.StatusOK)
-	flusher.Flush()
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds()); err != nil {
+		return
+	}
 
+	// Subscribe before replaying, so no entry falls between the two;
+	// entries already replayed are skipped when they come through ch.
 	ch := d.metrics.Subscribe()
 	defer d.metrics.Unsubscribe(ch)
 
+	// routeCh stays nil, and never ready, without an event source.
+	var routeCh chan api.RouteEvent
+	if d.routeEvents != nil {
+		routeCh = d.routeEvents.Subscribe()
+		defer d.routeEvents.Unsubscribe(routeCh)
+	}
+
+	var lastSent uint64
+	writeEntry := func(entry RequestEntry) error {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return nil
+		}
+		lastSent = entry.ID
+		_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", entry.ID, data)
+		return err
+	}
+
+	// A reconnecting EventSource sends the ID of the last entry it got;
+	// replay what it missed while that is still in the buffer.
+	if id, err := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64); err == nil {
+		missed, _ := d.metrics.Since(id)
+		for _, entry := range missed {
+			if err := writeEntry(entry); err != nil {
+				return
+			}
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
 	for {
+		var err error
 		select {
 		case <-r.Context().Done():
 			return
+		case <-keepAlive.C:
+			// Proxies and browsers drop streams that stay silent.
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		case ev := <-routeCh:
+			data, merr := json.Marshal(ev)
+			if merr != nil {
+				continue
+			}
+			_, err = fmt.Fprintf(w, "event: route\ndata: %s\n\n", data)
 		case entry, ok := <-ch:
 			if !ok {
 				return
 			}
-			data, err := json.Marshal(entry)
-			if err != nil {
+			if entry.ID <= lastSent {
 				continue
 			}
-			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
-				return
-			}
-			flusher.Flush()
+			err = writeEntry(entry)
+		}
+		if err != nil {
+			return
 		}
+		flusher.Flush()
 	}
 }
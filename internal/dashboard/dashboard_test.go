@@ -1,25 +1,46 @@
 package dashboard
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/alexcatdad/paw-proxy/internal/api"
+	"github.com/alexcatdad/paw-proxy/internal/proxy"
 )
 
 type mockRouteProvider struct {
-	routes []api.Route
+	routes   []api.Route
+	auth     map[string]*api.RouteAuth
+	throttle map[string]*api.ThrottleConfig
 }
 
 func (m *mockRouteProvider) List() []api.Route {
 	return m.routes
 }
 
+func (m *mockRouteProvider) SetAuth(name string, auth *api.RouteAuth) error {
+	if m.auth == nil {
+		m.auth = make(map[string]*api.RouteAuth)
+	}
+	m.auth[name] = auth
+	return nil
+}
+
+func (m *mockRouteProvider) SetThrottle(name string, throttle *api.ThrottleConfig) error {
+	if m.throttle == nil {
+		m.throttle = make(map[string]*api.ThrottleConfig)
+	}
+	m.throttle[name] = throttle
+	return nil
+}
+
 func newTestDashboard(t *testing.T, metrics *Metrics, routes RouteProvider, version string, startTime time.Time) *Dashboard {
 	t.Helper()
 	d, err := New(metrics, routes, version, startTime)
@@ -29,10 +50,25 @@ func newTestDashboard(t *testing.T, metrics *Metrics, routes RouteProvider, vers
 	return d
 }
 
+// signIn pairs a new session and adds its cookie to req.
+func signIn(t *testing.T, d *Dashboard, req *http.Request) {
+	t.Helper()
+	code, err := d.NewPairingCode()
+	if err != nil {
+		t.Fatalf("NewPairingCode: %v", err)
+	}
+	token, ok := d.sessions.redeem(code)
+	if !ok {
+		t.Fatal("redeem failed for a fresh code")
+	}
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: token})
+}
+
 func TestDashboard_ServesHTML(t *testing.T) {
 	d := newTestDashboard(t, NewMetrics(10), &mockRouteProvider{}, "1.0.0", time.Now())
 
 	req := httptest.NewRequest("GET", "https://_paw.test/", nil)
+	signIn(t, d, req)
 	w := httptest.NewRecorder()
 	d.ServeHTTP(w, req)
 
@@ -52,6 +88,7 @@ func TestDashboard_ServesCSS(t *testing.T) {
 	d := newTestDashboard(t, NewMetrics(10), &mockRouteProvider{}, "1.0.0", time.Now())
 
 	req := httptest.NewRequest("GET", "https://_paw.test/style.css", nil)
+	signIn(t, d, req)
 	w := httptest.NewRecorder()
 	d.ServeHTTP(w, req)
 
@@ -68,6 +105,7 @@ func TestDashboard_ServesJS(t *testing.T) {
 	d := newTestDashboard(t, NewMetrics(10), &mockRouteProvider{}, "1.0.0", time.Now())
 
 	req := httptest.NewRequest("GET", "https://_paw.test/app.js", nil)
+	signIn(t, d, req)
 	w := httptest.NewRecorder()
 	d.ServeHTTP(w, req)
 
@@ -94,6 +132,7 @@ func TestDashboard_APIRoutes(t *testing.T) {
 	d := newTestDashboard(t, m, routes, "1.0.0", now)
 
 	req := httptest.NewRequest("GET", "https://_paw.test/api/routes", nil)
+	signIn(t, d, req)
 	w := httptest.NewRecorder()
 	d.ServeHTTP(w, req)
 
@@ -126,11 +165,76 @@ func TestDashboard_APIRoutes(t *testing.T) {
 	}
 }
 
+func TestDashboard_APIRoutesPoolStats(t *testing.T) {
+	routes := &mockRouteProvider{
+		routes: []api.Route{
+			{Name: "myapp", Upstream: "localhost:3000"},
+			{Name: "other", Upstream: "localhost:4000"},
+		},
+	}
+	d := newTestDashboard(t, NewMetrics(10), routes, "1.0.0", time.Now())
+	d.SetPoolFunc(func() map[string]proxy.PoolStats {
+		return map[string]proxy.PoolStats{"localhost:3000": {Open: 2, Idle: 1, Dials: 3, Reused: 7}}
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "https://_paw.test/api/routes", nil)
+	signIn(t, d, req)
+	d.ServeHTTP(w, req)
+
+	var result []routeWithMetrics
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode JSON: %v", err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 routes, got %d", len(result))
+	}
+	if result[0].Pool == nil || result[0].Pool.Reused != 7 || result[0].Pool.Idle != 1 {
+		t.Errorf("expected pool stats for myapp, got %+v", result[0].Pool)
+	}
+	if result[1].Pool != nil {
+		t.Errorf("expected no pool stats for unused upstream, got %+v", result[1].Pool)
+	}
+}
+
+func TestDashboard_APIRoutesOwnerAndProject(t *testing.T) {
+	routes := &mockRouteProvider{
+		routes: []api.Route{
+			{Name: "myapp", Upstream: "localhost:3000", Project: "shop", Owner: &api.PeerCred{UID: 501, PID: 4242, Process: "up"}},
+			{Name: "other", Upstream: "localhost:4000"},
+		},
+	}
+	d := newTestDashboard(t, NewMetrics(10), routes, "1.0.0", time.Now())
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "https://_paw.test/api/routes", nil)
+	signIn(t, d, req)
+	d.ServeHTTP(w, req)
+
+	var result []routeWithMetrics
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode JSON: %v", err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 routes, got %d", len(result))
+	}
+	if o := result[0].Owner; o == nil || o.PID != 4242 || o.Process != "up" {
+		t.Errorf("expected owner for myapp, got %+v", o)
+	}
+	if result[0].Project != "shop" {
+		t.Errorf("expected project shop for myapp, got %q", result[0].Project)
+	}
+	if result[1].Owner != nil {
+		t.Errorf("expected no owner for other, got %+v", result[1].Owner)
+	}
+}
+
 func TestDashboard_APIStats(t *testing.T) {
 	startTime := time.Now().Add(-5 * time.Minute)
 	d := newTestDashboard(t, NewMetrics(10), &mockRouteProvider{}, "1.2.3", startTime)
 
 	req := httptest.NewRequest("GET", "https://_paw.test/api/stats", nil)
+	signIn(t, d, req)
 	w := httptest.NewRecorder()
 	d.ServeHTTP(w, req)
 
@@ -167,6 +271,7 @@ func TestDashboard_CSPHeader(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest("GET", "https://_paw.test"+tt.path, nil)
+			signIn(t, d, req)
 			w := httptest.NewRecorder()
 			d.ServeHTTP(w, req)
 
@@ -198,6 +303,7 @@ func TestDashboard_SSEHeaders(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create request: %v", err)
 	}
+	signIn(t, d, req)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -215,3 +321,232 @@ func TestDashboard_SSEHeaders(t *testing.T) {
 		t.Errorf("expected no-cache, got %s", cc)
 	}
 }
+
+func TestDashboard_SSERouteEvents(t *testing.T) {
+	registry := api.NewRouteRegistry(time.Minute)
+	d := newTestDashboard(t, NewMetrics(10), registry, "1.0.0", time.Now())
+	d.SetRouteEvents(registry)
+
+	srv := httptest.NewServer(d)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signIn(t, d, req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	registry.Register("myapp", "localhost:3000", "/tmp/myapp")
+
+	sc := bufio.NewScanner(resp.Body)
+	if !sc.Scan() || sc.Text() != "retry: 3000" {
+		t.Fatalf("first line = %q, want the retry hint", sc.Text())
+	}
+	sc.Scan()
+	if !sc.Scan() || sc.Text() != "event: route" {
+		t.Fatalf("first line = %q, want event: route", sc.Text())
+	}
+	if !sc.Scan() || !strings.Contains(sc.Text(), `"route":"myapp"`) {
+		t.Errorf("data line = %q", sc.Text())
+	}
+}
+
+func TestDashboard_SSEResumesFromLastEventID(t *testing.T) {
+	m := NewMetrics(10)
+	for _, path := range []string{"/a", "/b", "/c"} {
+		e := makeEntry("myapp", 200, 1)
+		e.Path = path
+		m.Record(e)
+	}
+	d := newTestDashboard(t, m, &mockRouteProvider{}, "1.0.0", time.Now())
+	srv := httptest.NewServer(d)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signIn(t, d, req)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	e := makeEntry("myapp", 200, 1)
+	e.Path = "/d"
+	m.Record(e)
+
+	// The entries after 1 are replayed, then live ones follow, once each.
+	sc := bufio.NewScanner(resp.Body)
+	var ids, paths []string
+	for len(paths) < 3 && sc.Scan() {
+		line := sc.Text()
+		if id, ok := strings.CutPrefix(line, "id: "); ok {
+			ids = append(ids, id)
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var entry RequestEntry
+			if err := json.Unmarshal([]byte(data), &entry); err != nil {
+				t.Fatal(err)
+			}
+			paths = append(paths, entry.Path)
+		}
+	}
+	if got := strings.Join(ids, ","); got != "2,3,4" {
+		t.Errorf("ids = %s, want 2,3,4", got)
+	}
+	if got := strings.Join(paths, ","); got != "/b,/c,/d" {
+		t.Errorf("paths = %s, want /b,/c,/d", got)
+	}
+}
+
+func TestDashboard_Widget(t *testing.T) {
+	routes := &mockRouteProvider{routes: []api.Route{
+		{Name: "web", Upstream: "localhost:3000", Dir: "/home/dev/web"},
+		{Name: "api", Upstream: "localhost:3001", Dir: "/home/dev/api", Paused: true},
+		{Name: "worker", Upstream: "localhost:3002", Dir: "/home/dev/worker"},
+	}}
+	d := newTestDashboard(t, NewMetrics(10), routes, "1.0.0", time.Now())
+	d.SetAlertFunc(func() map[string]string { return map[string]string{"worker": "6% of 100 requests got a 5xx"} })
+
+	// No session: the widget is embedded where the cookie isn't sent.
+	for _, path := range []string{"/widget", "/widget.js", "/theme.js", "/style.css"} {
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, httptest.NewRequest("GET", "https://_paw.test"+path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200 without a session, got %d", path, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "https://_paw.test/widget/routes", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if body := w.Body.String(); strings.Contains(body, "localhost") || strings.Contains(body, "/home/dev") {
+		t.Errorf("widget exposes upstreams or directories: %s", body)
+	}
+	var got []widgetRoute
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []widgetRoute{{"api", "paused"}, {"web", "up"}, {"worker", "alert"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("widget routes = %v, want %v", got, want)
+	}
+}
+
+func TestDashboard_SetAuth(t *testing.T) {
+	routes := &mockRouteProvider{}
+	d := newTestDashboard(t, NewMetrics(10), routes, "1.0.0", time.Now())
+
+	body := strings.NewReader(`{"username":"dev","password":"s3cret"}`)
+	req := httptest.NewRequest("PUT", "https://_paw.test/api/routes/myapp/auth", body)
+	signIn(t, d, req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "https://_paw.test")
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	got := routes.auth["myapp"]
+	if got == nil || got.Username != "dev" || got.Password != "s3cret" {
+		t.Fatalf("unexpected auth stored: %+v", got)
+	}
+
+	// Empty object clears credentials
+	req = httptest.NewRequest("PUT", "https://_paw.test/api/routes/myapp/auth", strings.NewReader(`{}`))
+	signIn(t, d, req)
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if routes.auth["myapp"] != nil {
+		t.Errorf("expected auth cleared, got %+v", routes.auth["myapp"])
+	}
+}
+
+func TestDashboard_SetThrottle(t *testing.T) {
+	routes := &mockRouteProvider{}
+	d := newTestDashboard(t, NewMetrics(10), routes, "1.0.0", time.Now())
+
+	put := func(body string) int {
+		req := httptest.NewRequest("PUT", "https://_paw.test/api/routes/myapp/throttle", strings.NewReader(body))
+		signIn(t, d, req)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "https://_paw.test")
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := put(`{"profile":"3g","latencyMs":500}`); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", code)
+	}
+	got := routes.throttle["myapp"]
+	if got == nil || got.Profile != "3g" || got.LatencyMs != 500 {
+		t.Fatalf("unexpected throttle stored: %+v", got)
+	}
+
+	// Empty object removes the throttle
+	if code := put(`{}`); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", code)
+	}
+	if routes.throttle["myapp"] != nil {
+		t.Errorf("expected throttle removed, got %+v", routes.throttle["myapp"])
+	}
+
+	d.SetReadOnly(true)
+	if code := put(`{"profile":"3g"}`); code != http.StatusForbidden {
+		t.Errorf("read-only: expected 403, got %d", code)
+	}
+	if routes.throttle["myapp"] != nil {
+		t.Errorf("throttle set while read-only: %+v", routes.throttle["myapp"])
+	}
+}
+
+func TestDashboard_SetAuthRejectsCrossOrigin(t *testing.T) {
+	d := newTestDashboard(t, NewMetrics(10), &mockRouteProvider{}, "1.0.0", time.Now())
+
+	tests := []struct {
+		name        string
+		contentType string
+		origin      string
+		want        int
+	}{
+		{"form post", "application/x-www-form-urlencoded", "", http.StatusUnsupportedMediaType},
+		{"foreign origin", "application/json", "https://evil.example", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "https://_paw.test/api/routes/myapp/auth", strings.NewReader(`{"token":"x"}`))
+			signIn(t, d, req)
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			d.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
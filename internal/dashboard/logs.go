@@ -0,0 +1,133 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"log"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogEntry is one line of the daemon's structured log.
+type LogEntry struct {
+	// ID numbers lines in the order they were written, from 1, so the
+	// dashboard can ask for the ones after the last it has.
+	ID    uint64         `json:"id"`
+	Time  time.Time      `json:"time"`
+	Level string         `json:"level"`
+	Msg   string         `json:"msg"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+
+	level slog.Level
+}
+
+// LogBuffer keeps the most recent lines of the daemon's log for the
+// dashboard's log pane. It is an io.Writer for a slog.JSONHandler, which
+// writes each record in one call.
+type LogBuffer struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	pos     int
+	count   int
+	lastID  uint64
+}
+
+// NewLogBuffer returns a buffer holding the last size lines.
+func NewLogBuffer(size int) *LogBuffer {
+	return &LogBuffer{entries: make([]LogEntry, size)}
+}
+
+// Write records one log line. Lines that aren't slog JSON are kept as
+// info messages. It never fails, so it can sit behind an io.MultiWriter.
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	entry := parseLogEntry(strings.TrimSpace(string(p)))
+
+	b.mu.Lock()
+	b.lastID++
+	entry.ID = b.lastID
+	b.entries[b.pos] = entry
+	b.pos = (b.pos + 1) % len(b.entries)
+	if b.count < len(b.entries) {
+		b.count++
+	}
+	b.mu.Unlock()
+	return len(p), nil
+}
+
+func parseLogEntry(line string) LogEntry {
+	entry := LogEntry{Time: time.Now(), Level: slog.LevelInfo.String(), Msg: line}
+	var attrs map[string]any
+	if json.Unmarshal([]byte(line), &attrs) != nil {
+		return entry
+	}
+	if s, ok := attrs[slog.TimeKey].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			entry.Time = t
+		}
+	}
+	if s, ok := attrs[slog.LevelKey].(string); ok && entry.level.UnmarshalText([]byte(s)) == nil {
+		entry.Level = s
+	}
+	if s, ok := attrs[slog.MessageKey].(string); ok {
+		entry.Msg = s
+	}
+	delete(attrs, slog.TimeKey)
+	delete(attrs, slog.LevelKey)
+	delete(attrs, slog.MessageKey)
+	if len(attrs) > 0 {
+		entry.Attrs = attrs
+	}
+	return entry
+}
+
+// Since returns the buffered lines after the one with ID id at minLevel or
+// above, oldest first.
+func (b *LogBuffer) Since(id uint64, minLevel slog.Level) []LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	result := []LogEntry{}
+	for i := b.count; i > 0; i-- {
+		entry := b.entries[(b.pos-i+len(b.entries))%len(b.entries)]
+		if entry.ID > id && entry.level >= minLevel {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// SetLogBuffer sets the source of the dashboard's log pane.
+func (d *Dashboard) SetLogBuffer(logs *LogBuffer) {
+	d.logs = logs
+}
+
+// handleAPILogs returns the log lines after ?since=<id>, optionally only
+// those at ?level= or above.
+func (d *Dashboard) handleAPILogs(w http.ResponseWriter, r *http.Request) {
+	if d.logs == nil {
+		http.Error(w, "logs not available", http.StatusNotImplemented)
+		return
+	}
+	var since uint64
+	if s := r.URL.Query().Get("since"); s != "" {
+		var err error
+		if since, err = strconv.ParseUint(s, 10, 64); err != nil {
+			http.Error(w, "since must be a log line ID", http.StatusBadRequest)
+			return
+		}
+	}
+	minLevel := slog.LevelDebug
+	if s := r.URL.Query().Get("level"); s != "" {
+		if err := minLevel.UnmarshalText([]byte(s)); err != nil {
+			http.Error(w, "level must be debug, info, warn, or error", http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(d.logs.Since(since, minLevel)); err != nil {
+		log.Printf("dashboard: failed to encode logs: %v", err)
+	}
+}
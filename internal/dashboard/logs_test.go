@@ -0,0 +1,87 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLogBuffer(t *testing.T) {
+	logs := NewLogBuffer(3)
+	logger := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	logger.Debug("dns query", "name", "myapp.test")
+	logger.Info("route registered", "route", "myapp", "port", 3000)
+	logger.Warn("upstream down", "upstream", "localhost:3000")
+	logs.Write([]byte("2026/10/18 12:00:00 dashboard: plain line\n"))
+
+	// The buffer holds the last 3 lines.
+	got := logs.Since(0, slog.LevelDebug)
+	if len(got) != 3 || got[0].ID != 2 || got[2].ID != 4 {
+		t.Fatalf("Since(0) = %+v, want lines 2 to 4", got)
+	}
+	if got[0].Msg != "route registered" || got[0].Level != "INFO" || got[0].Attrs["route"] != "myapp" || got[0].Attrs["port"] != float64(3000) {
+		t.Errorf("parsed entry = %+v", got[0])
+	}
+	if got[2].Msg != "2026/10/18 12:00:00 dashboard: plain line" || got[2].Level != "INFO" {
+		t.Errorf("plain line = %+v", got[2])
+	}
+
+	if got := logs.Since(2, slog.LevelWarn); len(got) != 1 || got[0].Msg != "upstream down" {
+		t.Errorf("Since(2, warn) = %+v, want only the warning", got)
+	}
+	if got := logs.Since(4, slog.LevelDebug); len(got) != 0 {
+		t.Errorf("Since(4) = %+v, want nothing", got)
+	}
+}
+
+func TestDashboard_Logs(t *testing.T) {
+	logs := NewLogBuffer(10)
+	logger := slog.New(slog.NewJSONHandler(logs, nil))
+	logger.Info("route registered", "route", "myapp")
+	logger.Error("hook failed", "route", "myapp")
+
+	d := newTestDashboard(t, NewMetrics(10), &mockRouteProvider{}, "1.0.0", time.Now())
+	d.SetLogBuffer(logs)
+
+	tests := []struct {
+		query string
+		code  int
+		want  []string
+	}{
+		{"", http.StatusOK, []string{"route registered", "hook failed"}},
+		{"?since=1", http.StatusOK, []string{"hook failed"}},
+		{"?level=error", http.StatusOK, []string{"hook failed"}},
+		{"?since=2", http.StatusOK, []string{}},
+		{"?since=-1", http.StatusBadRequest, nil},
+		{"?level=loud", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "https://_paw.test/api/logs"+tt.query, nil)
+		signIn(t, d, req)
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d", tt.query, tt.code, w.Code)
+			continue
+		}
+		if tt.want == nil {
+			continue
+		}
+		var entries []LogEntry
+		if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if len(entries) != len(tt.want) {
+			t.Errorf("%s: got %d entries, want %v", tt.query, len(entries), tt.want)
+			continue
+		}
+		for i, e := range entries {
+			if e.Msg != tt.want[i] {
+				t.Errorf("%s: entry %d = %q, want %q", tt.query, i, e.Msg, tt.want[i])
+			}
+		}
+	}
+}
@@ -6,6 +6,9 @@ import (
 )
 
 type RequestEntry struct {
+	// ID numbers entries in the order they were recorded, from 1, for
+	// resuming the event stream.
+	ID         uint64    `json:"id"`
 	Timestamp  time.Time `json:"timestamp"`
 	Host       string    `json:"host"`
 	Method     string    `json:"method"`
@@ -23,12 +26,58 @@ type RouteMetrics struct {
 	LastSeen time.Time `json:"lastSeen"`
 }
 
+// RateWindow is how far back Rates looks.
+const RateWindow = time.Minute
+
+// RouteRate counts a route's requests over the last RateWindow.
+type RouteRate struct {
+	Requests int64
+	Errors   int64
+}
+
+// rateWindow counts requests and 5xx errors in one-second slots over the
+// last RateWindow.
+type rateWindow struct {
+	secs     [int(RateWindow / time.Second)]int64 // unix second each slot counts
+	requests [int(RateWindow / time.Second)]int64
+	errors   [int(RateWindow / time.Second)]int64
+}
+
+func (w *rateWindow) add(at time.Time, failed bool) {
+	sec := at.Unix()
+	i := int(sec % int64(len(w.secs)))
+	if sec < w.secs[i] {
+		return // older than the window
+	}
+	if w.secs[i] != sec {
+		w.secs[i], w.requests[i], w.errors[i] = sec, 0, 0
+	}
+	w.requests[i]++
+	if failed {
+		w.errors[i]++
+	}
+}
+
+func (w *rateWindow) sum(now time.Time) RouteRate {
+	var r RouteRate
+	oldest := now.Unix() - int64(len(w.secs))
+	for i, sec := range w.secs {
+		if sec > oldest {
+			r.Requests += w.requests[i]
+			r.Errors += w.errors[i]
+		}
+	}
+	return r
+}
+
 type Metrics struct {
 	mu      sync.RWMutex
 	entries []RequestEntry
 	pos     int
 	count   int
+	lastID  uint64
 	routes  map[string]*RouteMetrics
+	rates   map[string]*rateWindow
 	subsMu  sync.Mutex
 	subs    map[chan RequestEntry]struct{}
 }
@@ -37,12 +86,15 @@ func NewMetrics(bufferSize int) *Metrics {
 	return &Metrics{
 		entries: make([]RequestEntry, bufferSize),
 		routes:  make(map[string]*RouteMetrics),
+		rates:   make(map[string]*rateWindow),
 		subs:    make(map[chan RequestEntry]struct{}),
 	}
 }
 
 func (m *Metrics) Record(entry RequestEntry) {
 	m.mu.Lock()
+	m.lastID++
+	entry.ID = m.lastID
 	m.entries[m.pos] = entry
 	m.pos = (m.pos + 1) % len(m.entries)
 	if m.count < len(m.entries) {
@@ -60,6 +112,12 @@ func (m *Metrics) Record(entry RequestEntry) {
 			rm.Errors++
 		}
 		rm.LastSeen = entry.Timestamp
+		rw, ok := m.rates[entry.Route]
+		if !ok {
+			rw = &rateWindow{}
+			m.rates[entry.Route] = rw
+		}
+		rw.add(entry.Timestamp, entry.StatusCode >= 500)
 	}
 	m.mu.Unlock()
 
@@ -87,6 +145,25 @@ func (m *Metrics) Recent(n int) []RequestEntry {
 	return result
 }
 
+// Since returns the entries recorded after the one with ID id, oldest
+// first. complete is false when some of them have already left the buffer.
+func (m *Metrics) Since(id uint64) (entries []RequestEntry, complete bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if id >= m.lastID {
+		return nil, id == m.lastID
+	}
+	n := m.lastID - id
+	complete = n <= uint64(m.count)
+	n = min(n, uint64(m.count))
+	entries = make([]RequestEntry, n)
+	for i := range entries {
+		idx := (m.pos - len(entries) + i + len(m.entries)) % len(m.entries)
+		entries[i] = m.entries[idx]
+	}
+	return entries, complete
+}
+
 func (m *Metrics) RouteStats() map[string]RouteMetrics {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -97,6 +174,20 @@ func (m *Metrics) RouteStats() map[string]RouteMetrics {
 	return result
 }
 
+// Rates returns each route's requests and errors over the RateWindow
+// before now, for routes that had any.
+func (m *Metrics) Rates(now time.Time) map[string]RouteRate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make(map[string]RouteRate)
+	for name, rw := range m.rates {
+		if r := rw.sum(now); r.Requests > 0 {
+			result[name] = r
+		}
+	}
+	return result
+}
+
 func (m *Metrics) Subscribe() chan RequestEntry {
 	ch := make(chan RequestEntry, 64)
 	m.subsMu.Lock()
@@ -188,3 +188,48 @@ func TestMetrics_ConcurrentAccess(t *testing.T) {
 	close(done)
 	wg.Wait()
 }
+
+func TestMetrics_Rates(t *testing.T) {
+	m := NewMetrics(10)
+	now := time.Now()
+	for i := range 30 {
+		e := makeEntry("app", 200, 1)
+		if i%3 == 0 {
+			e.StatusCode = 502
+		}
+		e.Timestamp = now.Add(-time.Duration(i) * time.Second)
+		m.Record(e)
+	}
+	old := makeEntry("app", 200, 1)
+	old.Timestamp = now.Add(-2 * RateWindow)
+	m.Record(old)
+
+	// The rates see past the ring buffer, but not past the window.
+	rates := m.Rates(now)
+	if got := rates["app"]; got.Requests != 30 || got.Errors != 10 {
+		t.Errorf("app rate = %+v, want 30 requests, 10 errors", got)
+	}
+	if _, ok := m.Rates(now.Add(2 * RateWindow))["app"]; ok {
+		t.Error("rate reported for a route idle for longer than the window")
+	}
+}
+
+func TestMetrics_Since(t *testing.T) {
+	m := NewMetrics(3)
+	for range 5 {
+		m.Record(makeEntry("app", 200, 1))
+	}
+
+	got, complete := m.Since(3)
+	if len(got) != 2 || got[0].ID != 4 || got[1].ID != 5 || !complete {
+		t.Errorf("Since(3) = %v, %v; want entries 4 and 5", got, complete)
+	}
+	// Entries 2 and earlier have left the buffer.
+	got, complete = m.Since(0)
+	if len(got) != 3 || got[0].ID != 3 || complete {
+		t.Errorf("Since(0) = %v, %v; want entries 3 to 5, incomplete", got, complete)
+	}
+	if got, complete := m.Since(5); len(got) != 0 || !complete {
+		t.Errorf("Since(5) = %v, %v; want nothing", got, complete)
+	}
+}
@@ -0,0 +1,167 @@
+package dashboard
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// sessionCookie holds the dashboard session token.
+	sessionCookie = "paw_session"
+	// pairingTTL is how long a pairing link from `paw-proxy dashboard open`
+	// stays valid.
+	pairingTTL = 2 * time.Minute
+	// sessionTTL is how long a paired browser stays signed in. Sessions
+	// are kept in memory, so a daemon restart also ends them.
+	sessionTTL = 7 * 24 * time.Hour
+	// maxPendingPairings bounds unused pairing codes kept at once.
+	maxPendingPairings = 16
+)
+
+// sessions tracks one-time pairing codes and the browser sessions they
+// were exchanged for.
+type sessions struct {
+	mu     sync.Mutex
+	codes  map[string]time.Time
+	tokens map[string]time.Time
+	now    func() time.Time
+}
+
+func newSessions() *sessions {
+	return &sessions{
+		codes:  make(map[string]time.Time),
+		tokens: make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("dashboard: generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newCode creates a pairing code that can be redeemed once.
+func (s *sessions) newCode() (string, error) {
+	code, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	expire(s.codes, now)
+	if len(s.codes) >= maxPendingPairings {
+		return "", fmt.Errorf("dashboard: too many pending pairing links")
+	}
+	s.codes[code] = now.Add(pairingTTL)
+	return code, nil
+}
+
+// redeem consumes a pairing code and returns a new session token.
+func (s *sessions) redeem(code string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	expiry, ok := s.codes[code]
+	delete(s.codes, code)
+	if !ok || now.After(expiry) {
+		return "", false
+	}
+	token, err := randomToken()
+	if err != nil {
+		return "", false
+	}
+	expire(s.tokens, now)
+	s.tokens[token] = now.Add(sessionTTL)
+	return token, true
+}
+
+// valid reports whether token belongs to an unexpired session.
+func (s *sessions) valid(token string) bool {
+	if token == "" {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiry, ok := s.tokens[token]
+	return ok && s.now().Before(expiry)
+}
+
+// expire deletes entries whose expiry has passed.
+func expire(m map[string]time.Time, now time.Time) {
+	for k, expiry := range m {
+		if now.After(expiry) {
+			delete(m, k)
+		}
+	}
+}
+
+// NewPairingCode returns a one-time code that signs a browser in to the
+// dashboard when it visits /pair?code=<code>.
+func (d *Dashboard) NewPairingCode() (string, error) {
+	return d.sessions.newCode()
+}
+
+// handlePair exchanges a pairing code for a session cookie.
+func (d *Dashboard) handlePair(w http.ResponseWriter, r *http.Request) {
+	token, ok := d.sessions.redeem(r.URL.Query().Get("code"))
+	if !ok {
+		http.Error(w, "This pairing link has expired or was already used. Run `paw-proxy dashboard open` for a new one.", http.StatusForbidden)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(sessionTTL / time.Second),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// publicPaths are served without a session: the widget and the files it
+// loads.
+var publicPaths = map[string]bool{
+	"/widget":        true,
+	"/widget/routes": true,
+	"/widget.js":     true,
+	"/theme.js":      true,
+	"/style.css":     true,
+}
+
+// authorize rejects cross-origin requests and requests without a paired
+// session. It writes the error response and returns false when the request
+// must not be served.
+//
+// SECURITY: Any local process, or a web page using DNS rebinding, can reach
+// the dashboard host. The session cookie is SameSite=Strict and only
+// obtainable through a pairing code from the daemon's control socket, so
+// neither can use the dashboard's API. The widget is the exception: it is
+// embedded where the cookie isn't sent, so it only shows route names and
+// whether they are healthy.
+func (d *Dashboard) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+			return false
+		}
+	}
+	if r.URL.Path == "/pair" || publicPaths[r.URL.Path] {
+		return true
+	}
+	if c, err := r.Cookie(sessionCookie); err == nil && d.sessions.valid(c.Value) {
+		return true
+	}
+	http.Error(w, "Not signed in. Run `paw-proxy dashboard open` to open the dashboard.", http.StatusUnauthorized)
+	return false
+}
@@ -0,0 +1,115 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDashboard_RequiresSession(t *testing.T) {
+	d := newTestDashboard(t, NewMetrics(10), &mockRouteProvider{}, "1.0.0", time.Now())
+
+	for _, path := range []string{"/", "/api/routes", "/events"} {
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, httptest.NewRequest("GET", "https://_paw.test"+path, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401 without a session, got %d", path, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "https://_paw.test/api/routes", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: "forged"})
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for unknown session, got %d", w.Code)
+	}
+}
+
+func TestDashboard_PairingFlow(t *testing.T) {
+	d := newTestDashboard(t, NewMetrics(10), &mockRouteProvider{}, "1.0.0", time.Now())
+	code, err := d.NewPairingCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "https://_paw.test/pair?code="+code, nil))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" {
+		t.Fatalf("expected redirect to /, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected one cookie, got %d", len(cookies))
+	}
+	c := cookies[0]
+	if c.Name != sessionCookie || !c.HttpOnly || !c.Secure || c.SameSite != http.SameSiteStrictMode {
+		t.Errorf("unexpected cookie attributes: %+v", c)
+	}
+
+	req := httptest.NewRequest("GET", "https://_paw.test/api/stats", nil)
+	req.AddCookie(c)
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 with session, got %d", w.Code)
+	}
+
+	// Codes are one-time
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "https://_paw.test/pair?code="+code, nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 reusing a code, got %d", w.Code)
+	}
+}
+
+func TestDashboard_RejectsCrossOriginReads(t *testing.T) {
+	d := newTestDashboard(t, NewMetrics(10), &mockRouteProvider{}, "1.0.0", time.Now())
+
+	req := httptest.NewRequest("GET", "https://_paw.test/api/routes", nil)
+	signIn(t, d, req)
+	req.Header.Set("Origin", "http://rebind.example")
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestSessions_Expiry(t *testing.T) {
+	now := time.Now()
+	s := newSessions()
+	s.now = func() time.Time { return now }
+
+	code, err := s.newCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(pairingTTL + time.Second)
+	if _, ok := s.redeem(code); ok {
+		t.Error("expected expired code to be rejected")
+	}
+
+	code, _ = s.newCode()
+	token, ok := s.redeem(code)
+	if !ok || !s.valid(token) {
+		t.Fatal("expected fresh code to give a valid session")
+	}
+	now = now.Add(sessionTTL + time.Second)
+	if s.valid(token) {
+		t.Error("expected session to expire")
+	}
+}
+
+func TestSessions_PendingLimit(t *testing.T) {
+	s := newSessions()
+	for i := 0; i < maxPendingPairings; i++ {
+		if _, err := s.newCode(); err != nil {
+			t.Fatalf("code %d: %v", i, err)
+		}
+	}
+	if _, err := s.newCode(); err == nil {
+		t.Error("expected error past the pending limit")
+	}
+}
@@ -12,10 +12,25 @@
   var filterNameEl = document.getElementById("filter-name");
   var clearFilter = document.getElementById("clear-filter");
   var sseDot = document.getElementById("sse-dot");
+  var sseLabel = document.getElementById("sse-label");
   var versionEl = document.getElementById("version");
   var uptimeEl = document.getElementById("uptime");
   var routesBody = document.getElementById("routes-body");
   var noRoutes = document.getElementById("no-routes");
+  var themeBtn = document.getElementById("theme-btn");
+  var caStatusEl = document.getElementById("ca-status");
+  var certsBody = document.getElementById("certs-body");
+  var noCerts = document.getElementById("no-certs");
+  var flushCertsBtn = document.getElementById("flush-certs-btn");
+  var announceEl = document.getElementById("announce");
+
+  var logList = document.getElementById("log-list");
+  var logLevel = document.getElementById("log-level");
+  var MAX_LOG = 500;
+  var lastLogID = 0;
+
+  // CA_WARN_DAYS matches when the daemon starts notifying about CA expiry.
+  var CA_WARN_DAYS = 30;
 
   function fetchStats() {
     fetch("/api/stats")
@@ -37,21 +52,62 @@
           return;
         }
         noRoutes.hidden = true;
+        // Routes are grouped by project; previews follow regular routes
+        // within a group.
+        routes.sort(function(a, b) {
+          var ga = groupKey(a), gb = groupKey(b);
+          if (ga !== gb) return ga < gb ? -1 : 1;
+          var pa = a.preview ? 1 : 0, pb = b.preview ? 1 : 0;
+          if (pa !== pb) return pa - pb;
+          return a.name < b.name ? -1 : a.name > b.name ? 1 : 0;
+        });
+        var sizes = {};
         routes.forEach(function(route) {
+          if (route.project) sizes[route.project] = (sizes[route.project] || 0) + 1;
+        });
+        var lastGroup = null;
+        routes.forEach(function(route) {
+          var titled = route.project && (sizes[route.project] > 1 || route.name !== route.project);
+          if (titled && route.project !== lastGroup) {
+            routesBody.appendChild(createGroupRow(route.project));
+          }
+          lastGroup = titled ? route.project : null;
+
           var tr = document.createElement("tr");
           tr.className = "clickable";
+          tr.tabIndex = 0;
+          tr.title = "Show only " + route.name + ".test in the request feed";
           tr.addEventListener("click", function() { setFilter(route.name); });
+          tr.addEventListener("keydown", function(e) {
+            // Enter and Space on a link or button in the row act on that instead.
+            if (e.target !== tr || (e.key !== "Enter" && e.key !== " ")) return;
+            e.preventDefault();
+            setFilter(route.name);
+          });
 
           var avgMs = route.requests > 0 ? Math.round(route.avgMs) : 0;
 
+          var nameCell = createLinkCell(route.name + ".test", "https://" + route.name + ".test");
+          if (route.preview) nameCell.appendChild(createPreviewBadge(route));
+          if (route.permanent) nameCell.appendChild(createConfigBadge());
+          if (route.churn && route.churn.flapping) nameCell.appendChild(createFlappingBadge(route.churn));
+          if (route.alert) {
+            tr.className += " alerting";
+            nameCell.appendChild(createAlertBadge(route.alert));
+          }
+
           var cells = [
-            createLinkCell(route.name + ".test", "https://" + route.name + ".test"),
-            createTextCell(route.upstream),
+            nameCell,
+            createUpstreamCell(route),
             createTextCell(shortenDir(route.dir)),
+            createOwnerCell(route.owner),
             createTextCell(formatUptime(route.registered)),
             createTextCell(String(route.requests)),
             createTextCell(avgMs + "ms"),
-            createErrorCell(route.errors)
+            createErrorCell(route.errors),
+            createPoolCell(route.pool),
+            createThrottleCell(route),
+            createAuthCell(route)
           ];
           cells.forEach(function(td) { tr.appendChild(td); });
           routesBody.appendChild(tr);
@@ -60,6 +116,20 @@
       .catch(function() {});
   }
 
+  function groupKey(route) {
+    return route.project || route.name;
+  }
+
+  function createGroupRow(project) {
+    var tr = document.createElement("tr");
+    tr.className = "group-row";
+    var td = document.createElement("td");
+    td.colSpan = 11;
+    td.textContent = project;
+    tr.appendChild(td);
+    return tr;
+  }
+
   function createTextCell(text) {
     var td = document.createElement("td");
     td.textContent = text;
@@ -76,6 +146,62 @@
     return td;
   }
 
+  function createUpstreamCell(route) {
+    if (route.static) return createTextCell(route.static.root);
+    if (!route.balance) return createTextCell(route.upstream);
+    var td = createTextCell(route.upstream + " +" + route.balance.upstreams.length);
+    td.title = (route.balance.mode || "round-robin") + ": " + [route.upstream].concat(route.balance.upstreams).join(", ");
+    return td;
+  }
+
+  function createOwnerCell(owner) {
+    if (!owner) return createTextCell("-");
+    var td = createTextCell((owner.process || "pid") + " " + owner.pid);
+    td.title = "pid " + owner.pid + ", uid " + owner.uid;
+    return td;
+  }
+
+  function createPreviewBadge(route) {
+    var span = document.createElement("span");
+    span.className = "badge preview-badge";
+    span.textContent = "preview " + route.preview;
+    if (route.idleTimeoutSeconds) {
+      span.title = "Removed after " + formatDuration(route.idleTimeoutSeconds) + " without requests";
+    }
+    return span;
+  }
+
+  function createConfigBadge() {
+    var span = document.createElement("span");
+    span.className = "badge preview-badge";
+    span.textContent = "config";
+    span.title = "Defined in the daemon config file; never expires";
+    return span;
+  }
+
+  function createFlappingBadge(churn) {
+    var span = document.createElement("span");
+    span.className = "badge flapping-badge";
+    span.textContent = "flapping";
+    span.title = churn.registrations + " registrations and " + churn.missedHeartbeats +
+      " missed heartbeats in the last hour. The dev server may be crash-looping.";
+    return span;
+  }
+
+  function createAlertBadge(reason) {
+    var span = document.createElement("span");
+    span.className = "badge alert-badge";
+    span.textContent = "alert";
+    span.title = reason;
+    return span;
+  }
+
+  function formatDuration(seconds) {
+    if (seconds >= 86400 && seconds % 86400 === 0) return (seconds / 86400) + "d";
+    if (seconds >= 3600 && seconds % 3600 === 0) return (seconds / 3600) + "h";
+    return Math.round(seconds / 60) + "m";
+  }
+
   function createErrorCell(errors) {
     var td = document.createElement("td");
     td.textContent = String(errors);
@@ -83,6 +209,140 @@
     return td;
   }
 
+  function createPoolCell(pool) {
+    var td = document.createElement("td");
+    if (!pool) {
+      td.textContent = "-";
+      return td;
+    }
+    var total = pool.dials + pool.reused;
+    var reusedPct = total > 0 ? Math.round(pool.reused * 100 / total) : 0;
+    td.textContent = pool.idle + "/" + pool.open + " idle \u00B7 " + reusedPct + "% reused";
+    td.title = pool.dials + " dials, " + pool.reused + " reused, " +
+      pool.dialsPerSec.toFixed(1) + " dials/sec";
+    return td;
+  }
+
+  function createThrottleCell(route) {
+    var td = document.createElement("td");
+    var btn = document.createElement("button");
+    btn.className = "btn-small";
+    var t = route.throttle;
+    if (t) {
+      btn.textContent = "\uD83D\uDC22 " + (t.profile || formatKbps(t.downKbps) + " \u00B7 " + (t.latencyMs || 0) + "ms");
+      btn.title = "Down " + formatKbps(t.downKbps) + ", up " + formatKbps(t.upKbps) + ", " + (t.latencyMs || 0) + "ms latency";
+    } else {
+      btn.textContent = "full speed";
+      btn.title = "Simulate a slow network for HTTP and WebSocket traffic";
+    }
+    btn.addEventListener("click", function(e) {
+      e.stopPropagation();
+      editThrottle(route.name);
+    });
+    td.appendChild(btn);
+    return td;
+  }
+
+  function formatKbps(kbps) {
+    if (!kbps) return "unlimited";
+    return kbps >= 1000 ? (kbps / 1000) + " Mbps" : kbps + " kbps";
+  }
+
+  // parseThrottle reads the same syntax as `paw-proxy throttle`: a
+  // profile and/or down=KBPS,up=KBPS,latency=200ms.
+  function parseThrottle(input) {
+    var body = {};
+    var parts = input.split(",");
+    for (var i = 0; i < parts.length; i++) {
+      var part = parts[i].trim();
+      var sep = part.indexOf("=");
+      if (sep < 0) {
+        if (i > 0) return null;
+        body.profile = part;
+        continue;
+      }
+      var key = part.substring(0, sep);
+      var value = part.substring(sep + 1);
+      if (key === "down" || key === "up") {
+        var kbps = parseInt(value, 10);
+        if (isNaN(kbps)) return null;
+        body[key + "Kbps"] = kbps;
+      } else if (key === "latency") {
+        var m = /^(\d+)(ms|s)?$/.exec(value);
+        if (!m) return null;
+        body.latencyMs = m[2] === "s" ? parseInt(m[1], 10) * 1000 : parseInt(m[1], 10);
+      } else {
+        return null;
+      }
+    }
+    return body;
+  }
+
+  function editThrottle(name) {
+    var input = window.prompt("Throttle " + name + ".test\n\nslow-3g, 3g, dsl, or down=KBPS,up=KBPS,latency=200ms; empty for full speed", "3g");
+    if (input === null) return;
+
+    var body = input === "" ? {} : parseThrottle(input);
+    if (body === null) {
+      window.alert("Expected a profile (slow-3g, 3g, dsl) or down=KBPS,up=KBPS,latency=200ms");
+      return;
+    }
+
+    fetch("/api/routes/" + encodeURIComponent(name) + "/throttle", {
+      method: "PUT",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(body)
+    })
+      .then(function(r) {
+        if (!r.ok) return r.text().then(function(t) { window.alert(t); });
+        fetchRoutes();
+      })
+      .catch(function() {});
+  }
+
+  function createAuthCell(route) {
+    var td = document.createElement("td");
+    var btn = document.createElement("button");
+    btn.className = "btn-small";
+    btn.textContent = route.auth ? "\uD83D\uDD12 " + route.auth : "open";
+    btn.title = "Set basic auth (user:pass), bearer token (token:VALUE), or leave empty to clear";
+    btn.addEventListener("click", function(e) {
+      e.stopPropagation();
+      editAuth(route.name);
+    });
+    td.appendChild(btn);
+    return td;
+  }
+
+  function editAuth(name) {
+    var input = window.prompt("Protect " + name + ".test\n\nuser:password for basic auth, token:VALUE for a bearer token, empty to clear", "");
+    if (input === null) return;
+
+    var body = {};
+    if (input.indexOf("token:") === 0) {
+      body.token = input.substring("token:".length);
+    } else if (input !== "") {
+      var sep = input.indexOf(":");
+      if (sep <= 0) {
+        window.alert("Expected user:password or token:VALUE");
+        return;
+      }
+      body.username = input.substring(0, sep);
+      body.password = input.substring(sep + 1);
+    }
+
+    fetch("/api/routes/" + encodeURIComponent(name) + "/auth", {
+      method: "PUT",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(body)
+    })
+      .then(function(r) {
+        if (!r.ok) return r.text().then(function(t) { window.alert(t); });
+        fetchRoutes();
+      })
+      .catch(function() {});
+  }
+
   function shortenDir(dir) {
     var home = "/Users/";
     var idx = dir.indexOf(home);
@@ -113,6 +373,128 @@
     return "status-2xx";
   }
 
+  function fetchLogs() {
+    fetch("/api/logs?since=" + lastLogID + "&level=" + encodeURIComponent(logLevel.value))
+      .then(function(r) { return r.ok ? r.json() : []; })
+      .then(function(entries) {
+        if (entries.length === 0) return;
+        var atBottom = logList.scrollTop + logList.clientHeight >= logList.scrollHeight - 4;
+        entries.forEach(function(entry) {
+          lastLogID = entry.id;
+          logList.appendChild(createLogEntry(entry));
+        });
+        while (logList.children.length > MAX_LOG) {
+          logList.removeChild(logList.firstChild);
+        }
+        // Follow new lines unless the user scrolled up to read older ones.
+        if (atBottom) logList.scrollTop = logList.scrollHeight;
+      })
+      .catch(function() {});
+  }
+
+  function createLogEntry(entry) {
+    var div = document.createElement("div");
+    div.className = "log-entry";
+    var attrs = Object.keys(entry.attrs || {}).map(function(k) {
+      var v = entry.attrs[k];
+      return k + "=" + (typeof v === "string" ? v : JSON.stringify(v));
+    }).join(" ");
+    var parts = [
+      { cls: "feed-time", text: formatTime(entry.time) },
+      { cls: "log-level log-" + entry.level, text: entry.level },
+      { cls: "log-msg", text: entry.msg },
+      { cls: "log-attrs", text: attrs }
+    ];
+    parts.forEach(function(p) {
+      var span = document.createElement("span");
+      span.className = p.cls;
+      span.textContent = p.text;
+      div.appendChild(span);
+    });
+    return div;
+  }
+
+  logLevel.addEventListener("change", function() {
+    logList.textContent = "";
+    lastLogID = 0;
+    fetchLogs();
+  });
+
+  function daysUntil(ts) {
+    return Math.floor((new Date(ts).getTime() - Date.now()) / 86400000);
+  }
+
+  function fetchCerts() {
+    fetch("/api/certs")
+      .then(function(r) { return r.ok ? r.json() : null; })
+      .then(function(data) {
+        if (!data) return;
+        renderCA(data.ca);
+        certsBody.textContent = "";
+        var certs = data.certs || [];
+        noCerts.hidden = certs.length > 0;
+        certs.forEach(function(cert) {
+          var tr = document.createElement("tr");
+          var names = document.createElement("td");
+          names.textContent = cert.names.join(", ");
+          tr.appendChild(names);
+          var expires = document.createElement("td");
+          expires.textContent = new Date(cert.notAfter).toLocaleDateString() + " (" + daysUntil(cert.notAfter) + "d)";
+          tr.appendChild(expires);
+          certsBody.appendChild(tr);
+        });
+      })
+      .catch(function() {});
+  }
+
+  function renderCA(ca) {
+    caStatusEl.textContent = "";
+    var days = daysUntil(ca.notAfter);
+    var expiry = document.createElement("span");
+    expiry.textContent = "CA " + ca.subject + ", expires " + new Date(ca.notAfter).toLocaleDateString() + " (" + days + " days)";
+    if (days < CA_WARN_DAYS) expiry.className = "warn";
+    caStatusEl.appendChild(expiry);
+
+    var scope = document.createElement("span");
+    scope.className = ca.domains ? "" : "warn";
+    scope.textContent = ca.domains
+      ? " \u2014 limited to ." + ca.domains.join(", .")
+      : " \u2014 unconstrained: its key can sign for any domain";
+    caStatusEl.appendChild(scope);
+
+    // Rotating replaces the CA in the system trust store, which needs root,
+    // so it is done from a terminal rather than from here.
+    var hint = document.createElement("span");
+    hint.className = "muted";
+    hint.textContent = " \u2014 rotate with ";
+    var code = document.createElement("code");
+    code.textContent = "sudo paw-proxy ca rotate";
+    hint.appendChild(code);
+    caStatusEl.appendChild(hint);
+  }
+
+  flushCertsBtn.addEventListener("click", function() {
+    fetch("/api/certs/flush", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: "{}"
+    })
+      .then(function(r) {
+        if (!r.ok) return r.text().then(function(t) { window.alert(t); });
+        return r.json().then(function(data) {
+          announce("Flushed " + data.flushed + " cached certificates");
+          fetchCerts();
+        });
+      })
+      .catch(function() {});
+  });
+
+  // announce tells screen readers about the outcome of an action that
+  // has no visible focus change.
+  function announce(text) {
+    announceEl.textContent = text;
+  }
+
   function formatTime(ts) {
     var d = new Date(ts);
     return d.toLocaleTimeString("en-US", { hour12: false });
@@ -152,7 +534,7 @@
 
     es.onopen = function() {
       sseDot.className = "dot dot-on";
-      sseDot.title = "SSE connected";
+      sseLabel.textContent = "live";
     };
 
     es.onmessage = function(event) {
@@ -165,12 +547,28 @@
       addFeedEntry(entry);
     };
 
+    // Route changes refresh the table now instead of at the next poll.
+    es.addEventListener("route", fetchRoutes);
+
     es.onerror = function() {
       sseDot.className = "dot dot-off";
-      sseDot.title = "SSE disconnected — reconnecting...";
+      sseLabel.textContent = "reconnecting";
     };
   }
 
+  function labelThemeButton() {
+    var other = window.pawTheme.current() === "light" ? "dark" : "light";
+    themeBtn.textContent = other;
+    themeBtn.setAttribute("aria-label", "Switch to " + other + " theme");
+  }
+
+  themeBtn.addEventListener("click", function() {
+    window.pawTheme.toggle();
+    labelThemeButton();
+  });
+  window.matchMedia("(prefers-color-scheme: light)").addEventListener("change", labelThemeButton);
+  labelThemeButton();
+
   pauseBtn.addEventListener("click", function() {
     paused = !paused;
     pauseBtn.textContent = paused ? "Resume" : "Pause";
@@ -185,12 +583,16 @@
     filterNameEl.textContent = route + ".test";
     filterLabel.hidden = false;
     feedList.textContent = "";
+    announce("Request feed shows only " + route + ".test");
   }
 
   clearFilter.addEventListener("click", function() {
     filterRoute = null;
     filterLabel.hidden = true;
     feedList.textContent = "";
+    // The button just hid itself; keep keyboard focus in the feed controls.
+    pauseBtn.focus();
+    announce("Request feed shows all routes");
   });
 
   fetchStats();
@@ -198,4 +600,8 @@
   connectSSE();
   setInterval(fetchRoutes, 5000);
   setInterval(fetchStats, 10000);
+  fetchCerts();
+  setInterval(fetchCerts, 30000);
+  fetchLogs();
+  setInterval(fetchLogs, 2000);
 })();
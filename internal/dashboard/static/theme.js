@@ -0,0 +1,46 @@
+// Applies the theme before the page renders: ?theme=light or ?theme=dark
+// (for an embedded widget), then the one picked with the toggle, then the
+// system's.
+(function() {
+  "use strict";
+
+  var KEY = "paw-theme";
+  var media = window.matchMedia("(prefers-color-scheme: light)");
+
+  function valid(theme) {
+    return theme === "light" || theme === "dark";
+  }
+
+  function chosen() {
+    var param = new URLSearchParams(location.search).get("theme");
+    if (valid(param)) return param;
+    try {
+      var saved = localStorage.getItem(KEY);
+      if (valid(saved)) return saved;
+    } catch (e) {
+      // Storage is blocked in some embedded frames.
+    }
+    return null;
+  }
+
+  function apply() {
+    document.documentElement.dataset.theme = chosen() || (media.matches ? "light" : "dark");
+  }
+
+  apply();
+  media.addEventListener("change", apply);
+
+  window.pawTheme = {
+    current: function() {
+      return document.documentElement.dataset.theme;
+    },
+    toggle: function() {
+      var next = this.current() === "light" ? "dark" : "light";
+      try {
+        localStorage.setItem(KEY, next);
+      } catch (e) {}
+      document.documentElement.dataset.theme = next;
+      return next;
+    }
+  };
+})();
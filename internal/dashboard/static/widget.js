@@ -0,0 +1,51 @@
+(function() {
+  "use strict";
+
+  var list = document.getElementById("widget-routes");
+  var empty = document.getElementById("widget-empty");
+
+  var LABELS = { up: "up", paused: "paused", maintenance: "maintenance", alert: "alert", flapping: "flapping" };
+  var last = null;
+
+  function render(routes) {
+    list.textContent = "";
+    empty.hidden = routes.length > 0;
+    routes.forEach(function(route) {
+      var li = document.createElement("li");
+      li.className = "widget-route";
+
+      var dot = document.createElement("span");
+      dot.className = "dot " + (route.status === "up" ? "dot-on" : "dot-" + route.status);
+      dot.setAttribute("aria-hidden", "true");
+      li.appendChild(dot);
+
+      var name = document.createElement("span");
+      name.className = "widget-name";
+      name.textContent = route.name;
+      li.appendChild(name);
+
+      var status = document.createElement("span");
+      status.className = "widget-status";
+      status.textContent = LABELS[route.status] || route.status;
+      li.appendChild(status);
+
+      list.appendChild(li);
+    });
+  }
+
+  function refresh() {
+    fetch("/widget/routes")
+      .then(function(r) { return r.text(); })
+      .then(function(body) {
+        // The list is a live region; re-render only on change so screen
+        // readers announce changes rather than every poll.
+        if (body === last) return;
+        last = body;
+        render(JSON.parse(body));
+      })
+      .catch(function() {});
+  }
+
+  refresh();
+  setInterval(refresh, 5000);
+})();
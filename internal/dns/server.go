@@ -3,41 +3,232 @@ package dns
 
 import (
 	"log"
+	"log/slog"
 	"net"
+	"runtime/debug"
 	"strings"
+	"sync"
 
 	"github.com/miekg/dns"
 )
 
 type Server struct {
 	addr   string
-	tld    string
+	tlds   []string
+	mu     sync.RWMutex
 	server *dns.Server
+	logger *slog.Logger
+	// udpPort returns the UDP port forwarded for a host, for SRV answers.
+	udpPort func(host string) (int, bool)
+	// onPanic is told about panics recovered while answering a query.
+	onPanic func(v any, stack []byte, query string)
+	// conn is the UDP socket, set by Start or SetPacketConn.
+	conn net.PacketConn
 }
 
 func NewServer(addr, tld string) (*Server, error) {
 	s := &Server{
 		addr: addr,
-		tld:  tld,
+		tlds: []string{tld},
 	}
 
 	s.server = &dns.Server{
 		Addr:    addr,
 		Net:     "udp",
-		Handler: dns.HandlerFunc(s.handleRequest),
+		Handler: dns.HandlerFunc(s.serveDNS),
 	}
 
 	return s, nil
 }
 
 func (s *Server) Start() error {
-	return s.server.ListenAndServe()
+	s.mu.Lock()
+	conn := s.conn
+	if conn == nil {
+		var err error
+		if conn, err = net.ListenPacket("udp", s.addr); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		s.conn = conn
+	}
+	s.mu.Unlock()
+	s.server.PacketConn = conn
+	return s.server.ActivateAndServe()
+}
+
+// SetPacketConn makes Start serve conn, e.g. the socket inherited from
+// the previous daemon process, instead of binding the address.
+func (s *Server) SetPacketConn(conn net.PacketConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn = conn
+}
+
+// PacketConn returns the UDP socket, or nil before Start.
+func (s *Server) PacketConn() net.PacketConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn
 }
 
 func (s *Server) Stop() error {
 	return s.server.Shutdown()
 }
 
+// SetLogger enables debug logging of DNS queries.
+func (s *Server) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// SetTLDs replaces the TLDs the server answers for. Safe to call while
+// the server is running.
+func (s *Server) SetTLDs(tlds []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tlds = append([]string(nil), tlds...)
+}
+
+// SetUDPLookup makes the server answer SRV queries such as
+// _game._udp.myapp.test with the port lookup returns for myapp.test.
+// Safe to call while the server is running.
+func (s *Server) SetUDPLookup(lookup func(host string) (int, bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.udpPort = lookup
+}
+
+// SetPanicHandler sets a function told about panics recovered while
+// answering a query, with the stack and the question.
+func (s *Server) SetPanicHandler(h func(v any, stack []byte, query string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onPanic = h
+}
+
+// serveDNS answers a query, turning a panic into SERVFAIL: the DNS library
+// doesn't recover them, so one bad query would otherwise stop the daemon.
+func (s *Server) serveDNS(w dns.ResponseWriter, r *dns.Msg) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		stack := debug.Stack()
+		query := "(none)"
+		if len(r.Question) > 0 {
+			query = r.Question[0].String()
+		}
+		s.mu.RLock()
+		onPanic := s.onPanic
+		s.mu.RUnlock()
+		if onPanic != nil {
+			onPanic(v, stack, query)
+		} else {
+			log.Printf("dns: recovered from panic answering %s: %v\n%s", query, v, stack)
+		}
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeServerFailure)
+		w.WriteMsg(m)
+	}()
+	s.handleRequest(w, r)
+}
+
+// udpSRV returns the SRV record answering a _service._udp query for name,
+// if the host has UDP forwarded.
+func (s *Server) udpSRV(qname, name string) (*dns.SRV, bool) {
+	labels := strings.SplitN(name, ".", 3)
+	if len(labels) < 3 || !strings.HasPrefix(labels[0], "_") || labels[1] != "_udp" {
+		return nil, false
+	}
+	host := labels[2]
+	s.mu.RLock()
+	lookup := s.udpPort
+	s.mu.RUnlock()
+	if lookup == nil {
+		return nil, false
+	}
+	port, ok := lookup(strings.TrimSuffix(host, "."))
+	if !ok {
+		return nil, false
+	}
+	return &dns.SRV{
+		Hdr: dns.RR_Header{
+			Name:   qname,
+			Rrtype: dns.TypeSRV,
+			Class:  dns.ClassINET,
+			Ttl:    answerTTL,
+		},
+		Port:   uint16(port),
+		Target: host,
+	}, true
+}
+
+func (s *Server) answers(name string) bool {
+	_, ok := s.zone(name)
+	return ok
+}
+
+// zone returns the apex of the zone name belongs to, e.g. "test." for
+// "myapp.test." and for "test." itself.
+func (s *Server) zone(name string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, tld := range s.tlds {
+		apex := tld + "."
+		if name == apex || strings.HasSuffix(name, "."+apex) {
+			return apex, true
+		}
+	}
+	return "", false
+}
+
+// Record TTLs. Names always resolve to loopback, so positive answers can
+// be cached for a while; SRV records come and go with UDP routes, so "no
+// such record" is only cached briefly.
+const (
+	answerTTL   = 60
+	negativeTTL = 5
+)
+
+// ednsUDPSize is the UDP payload size advertised to EDNS clients, the
+// size recommended to avoid fragmentation.
+const ednsUDPSize = 1232
+
+// nameserver is the name of the zone's only nameserver, the daemon itself.
+func nameserver(apex string) string {
+	return "ns." + apex
+}
+
+// soa returns the zone's SOA record. Its minimum field, which resolvers
+// use as the negative caching TTL (RFC 2308), is negativeTTL.
+func soa(apex string, ttl uint32) *dns.SOA {
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: apex, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: ttl},
+		Ns:      nameserver(apex),
+		Mbox:    "hostmaster." + apex,
+		Serial:  1,
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  86400,
+		Minttl:  negativeTTL,
+	}
+}
+
+func loopbackA(name string) *dns.A {
+	return &dns.A{
+		Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: answerTTL},
+		A:   net.ParseIP("127.0.0.1"),
+	}
+}
+
+func loopbackAAAA(name string) *dns.AAAA {
+	return &dns.AAAA{
+		Hdr:  dns.RR_Header{Name: name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: answerTTL},
+		AAAA: net.ParseIP("::1"),
+	}
+}
+
 // maxLabelLen is the maximum length of a single DNS label per RFC 1035 section 2.3.4.
 const maxLabelLen = 63
 
@@ -62,8 +253,25 @@ func validDNSName(name string) bool {
 }
 
 func (s *Server) handleRequest(w dns.ResponseWriter, r *dns.Msg) {
+	m := s.reply(r)
+	// Reply with EDNS to EDNS queries, or resolvers such as
+	// systemd-resolved assume the server is broken and downgrade.
+	if r.IsEdns0() != nil {
+		m.SetEdns0(ednsUDPSize, false)
+	}
+	if err := w.WriteMsg(m); err != nil {
+		log.Printf("dns: write response error: %v", err)
+	}
+}
+
+// reply builds the response to query r.
+func (s *Server) reply(r *dns.Msg) *dns.Msg {
 	m := new(dns.Msg)
 	m.SetReply(r)
+	if r.Opcode != dns.OpcodeQuery {
+		m.Rcode = dns.RcodeNotImplemented
+		return m
+	}
 	m.Authoritative = true
 
 	for _, q := range r.Question {
@@ -75,38 +283,78 @@ func (s *Server) handleRequest(w dns.ResponseWriter, r *dns.Msg) {
 			break
 		}
 
-		if !strings.HasSuffix(name, "."+s.tld+".") {
+		apex, ok := s.zone(name)
+		if !ok {
+			if s.logger != nil {
+				s.logger.Debug("dns query ignored", "name", name, "type", dns.TypeToString[q.Qtype])
+			}
+			// Not ours: refuse rather than claim the name has no records.
+			m.Authoritative = false
+			m.Rcode = dns.RcodeRefused
+			continue
+		}
+		if s.logger != nil {
+			s.logger.Debug("dns query", "name", name, "type", dns.TypeToString[q.Qtype])
+		}
+		if q.Qclass != dns.ClassINET && q.Qclass != dns.ClassANY {
+			m.Rcode = dns.RcodeRefused
 			continue
 		}
 
-		switch q.Qtype {
-		case dns.TypeA:
-			rr := &dns.A{
-				Hdr: dns.RR_Header{
-					Name:   q.Name,
-					Rrtype: dns.TypeA,
-					Class:  dns.ClassINET,
-					Ttl:    60,
-				},
-				A: net.ParseIP("127.0.0.1"),
-			}
-			m.Answer = append(m.Answer, rr)
-
-		case dns.TypeAAAA:
-			rr := &dns.AAAA{
-				Hdr: dns.RR_Header{
-					Name:   q.Name,
-					Rrtype: dns.TypeAAAA,
-					Class:  dns.ClassINET,
-					Ttl:    60,
-				},
-				AAAA: net.ParseIP("::1"),
-			}
-			m.Answer = append(m.Answer, rr)
+		answered := len(m.Answer)
+		s.answer(m, q, name, apex)
+		// An empty answer carries the SOA, so resolvers cache the
+		// absence instead of retrying and stalling.
+		if len(m.Answer) == answered {
+			m.Ns = append(m.Ns, soa(apex, negativeTTL))
 		}
 	}
+	return m
+}
 
-	if err := w.WriteMsg(m); err != nil {
-		log.Printf("dns: write response error: %v", err)
+// answer adds the records for question q, about name in the zone at
+// apex, to m. Every name resolves to loopback; the apex also has the SOA
+// and NS records resolvers probe for.
+func (s *Server) answer(m *dns.Msg, q dns.Question, name, apex string) {
+	isApex := name == apex
+	switch q.Qtype {
+	case dns.TypeA:
+		if !isApex {
+			m.Answer = append(m.Answer, loopbackA(q.Name))
+		}
+
+	case dns.TypeAAAA:
+		if !isApex {
+			m.Answer = append(m.Answer, loopbackAAAA(q.Name))
+		}
+
+	case dns.TypeANY:
+		// RFC 8482 allows answering ANY with a subset.
+		if isApex {
+			m.Answer = append(m.Answer, soa(apex, answerTTL))
+		} else {
+			m.Answer = append(m.Answer, loopbackA(q.Name), loopbackAAAA(q.Name))
+		}
+
+	case dns.TypeSOA:
+		if isApex {
+			m.Answer = append(m.Answer, soa(apex, answerTTL))
+		}
+
+	case dns.TypeNS:
+		if isApex {
+			ns := nameserver(apex)
+			m.Answer = append(m.Answer, &dns.NS{
+				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: answerTTL},
+				Ns:  ns,
+			})
+			m.Extra = append(m.Extra, loopbackA(ns), loopbackAAAA(ns))
+		}
+
+	case dns.TypeSRV:
+		if rr, ok := s.udpSRV(q.Name, name); ok {
+			m.Answer = append(m.Answer, rr)
+			m.Extra = append(m.Extra, loopbackA(rr.Target))
+		}
 	}
 }
@@ -231,3 +231,216 @@ func TestDNSServer_RejectsLongTotalName(t *testing.T) {
 		t.Errorf("expected no answers for invalid query, got %d", len(r.Answer))
 	}
 }
+
+func TestSetTLDs(t *testing.T) {
+	srv, err := NewServer("127.0.0.1:0", "test")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	if !srv.answers("myapp.test.") || srv.answers("myapp.localhost.") {
+		t.Fatal("expected only .test to be answered initially")
+	}
+
+	srv.SetTLDs([]string{"test", "localhost"})
+	if !srv.answers("myapp.localhost.") {
+		t.Error("expected .localhost to be answered after SetTLDs")
+	}
+
+	srv.SetTLDs([]string{"localhost"})
+	if srv.answers("myapp.test.") {
+		t.Error("expected .test to stop being answered after SetTLDs")
+	}
+}
+
+func TestSRVQuery(t *testing.T) {
+	srv, err := NewServer("127.0.0.1:19358", "test")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Stop()
+	srv.SetUDPLookup(func(host string) (int, bool) {
+		return 40000, host == "game.test"
+	})
+
+	go srv.Start()
+
+	// Wait for server to start
+	time.Sleep(50 * time.Millisecond)
+
+	c := new(dns.Client)
+	query := func(name string) *dns.Msg {
+		t.Helper()
+		m := new(dns.Msg)
+		m.SetQuestion(name, dns.TypeSRV)
+		r, _, err := c.Exchange(m, "127.0.0.1:19358")
+		if err != nil {
+			t.Fatalf("DNS query failed: %v", err)
+		}
+		return r
+	}
+
+	r := query("_quake._udp.game.test.")
+	if len(r.Answer) != 1 {
+		t.Fatalf("expected one SRV answer, got %d", len(r.Answer))
+	}
+	srvRR, ok := r.Answer[0].(*dns.SRV)
+	if !ok {
+		t.Fatalf("expected SRV record, got %T", r.Answer[0])
+	}
+	if srvRR.Port != 40000 || srvRR.Target != "game.test." {
+		t.Errorf("SRV = %s:%d, want game.test.:40000", srvRR.Target, srvRR.Port)
+	}
+	if len(r.Extra) != 1 {
+		t.Errorf("expected the target's A record in the additional section")
+	}
+
+	for _, name := range []string{"_quake._tcp.game.test.", "_quake._udp.shop.test.", "game.test."} {
+		if r := query(name); len(r.Answer) != 0 {
+			t.Errorf("%s: expected no answer, got %v", name, r.Answer)
+		}
+	}
+}
+
+func TestDNSServer_RecoversFromPanic(t *testing.T) {
+	srv, err := NewServer("127.0.0.1:19359", "test")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Stop()
+	srv.SetUDPLookup(func(host string) (int, bool) {
+		panic("lookup broke")
+	})
+	panics := make(chan string, 1)
+	srv.SetPanicHandler(func(v any, stack []byte, query string) {
+		panics <- query
+	})
+
+	go srv.Start()
+
+	// Wait for server to start
+	time.Sleep(50 * time.Millisecond)
+
+	c := new(dns.Client)
+	m := new(dns.Msg)
+	m.SetQuestion("_quake._udp.game.test.", dns.TypeSRV)
+	r, _, err := c.Exchange(m, "127.0.0.1:19359")
+	if err != nil {
+		t.Fatalf("DNS query failed: %v", err)
+	}
+	if r.Rcode != dns.RcodeServerFailure {
+		t.Errorf("rcode = %s, want SERVFAIL", dns.RcodeToString[r.Rcode])
+	}
+	if query := <-panics; !strings.Contains(query, "_quake._udp.game.test.") {
+		t.Errorf("panic handler got query %q", query)
+	}
+
+	// The server keeps answering.
+	m.SetQuestion("myapp.test.", dns.TypeA)
+	if r, _, err := c.Exchange(m, "127.0.0.1:19359"); err != nil || len(r.Answer) == 0 {
+		t.Errorf("query after panic: answer %v, err %v", r, err)
+	}
+}
+
+func TestZoneRecords(t *testing.T) {
+	srv, err := NewServer("127.0.0.1:0", "test")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	query := func(name string, qtype uint16) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion(name, qtype)
+		return srv.reply(m)
+	}
+
+	r := query("test.", dns.TypeSOA)
+	if len(r.Answer) != 1 || !r.Authoritative {
+		t.Fatalf("apex SOA: answer %v, aa=%v", r.Answer, r.Authoritative)
+	}
+	if soa, ok := r.Answer[0].(*dns.SOA); !ok || soa.Ns != "ns.test." || soa.Minttl != negativeTTL {
+		t.Errorf("apex SOA = %v", r.Answer[0])
+	}
+
+	r = query("TEST.", dns.TypeNS)
+	if len(r.Answer) != 1 || r.Answer[0].(*dns.NS).Ns != "ns.test." {
+		t.Fatalf("apex NS = %v", r.Answer)
+	}
+	if len(r.Extra) != 2 {
+		t.Errorf("apex NS: expected A and AAAA glue, got %v", r.Extra)
+	}
+
+	// Empty answers carry the SOA, with the negative caching TTL.
+	for _, q := range []struct {
+		name  string
+		qtype uint16
+	}{
+		{"myapp.test.", dns.TypeMX},
+		{"myapp.test.", dns.TypeHTTPS},
+		{"myapp.test.", dns.TypeNS},
+		{"test.", dns.TypeA},
+		{"test.", dns.TypeDS},
+		{"_quake._udp.game.test.", dns.TypeSRV},
+	} {
+		r := query(q.name, q.qtype)
+		if r.Rcode != dns.RcodeSuccess || len(r.Answer) != 0 {
+			t.Errorf("%s %s: rcode %s, answer %v; want empty NOERROR", q.name, dns.TypeToString[q.qtype], dns.RcodeToString[r.Rcode], r.Answer)
+			continue
+		}
+		if len(r.Ns) != 1 || r.Ns[0].Header().Rrtype != dns.TypeSOA || r.Ns[0].Header().Ttl != negativeTTL {
+			t.Errorf("%s %s: authority = %v, want the SOA", q.name, dns.TypeToString[q.qtype], r.Ns)
+		}
+	}
+
+	r = query("myapp.test.", dns.TypeANY)
+	if len(r.Answer) != 2 {
+		t.Errorf("ANY = %v, want A and AAAA", r.Answer)
+	}
+
+	r = query("example.com.", dns.TypeA)
+	if r.Rcode != dns.RcodeRefused || r.Authoritative || len(r.Answer) != 0 {
+		t.Errorf("outside the zone: rcode %s, aa=%v", dns.RcodeToString[r.Rcode], r.Authoritative)
+	}
+	r = query("nottest.", dns.TypeA)
+	if r.Rcode != dns.RcodeRefused {
+		t.Errorf("nottest.: rcode %s, want REFUSED", dns.RcodeToString[r.Rcode])
+	}
+
+	m := new(dns.Msg)
+	m.SetNotify("test.")
+	if r := srv.reply(m); r.Rcode != dns.RcodeNotImplemented {
+		t.Errorf("NOTIFY: rcode %s, want NOTIMP", dns.RcodeToString[r.Rcode])
+	}
+}
+
+func TestDNSServer_EDNS(t *testing.T) {
+	srv, err := NewServer("127.0.0.1:19360", "test")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Stop()
+
+	go srv.Start()
+
+	// Wait for server to start
+	time.Sleep(50 * time.Millisecond)
+
+	c := new(dns.Client)
+	m := new(dns.Msg)
+	m.SetQuestion("myapp.test.", dns.TypeA)
+	r, _, err := c.Exchange(m, "127.0.0.1:19360")
+	if err != nil {
+		t.Fatalf("DNS query failed: %v", err)
+	}
+	if r.IsEdns0() != nil {
+		t.Error("plain query got an EDNS reply")
+	}
+
+	m.SetEdns0(4096, false)
+	r, _, err = c.Exchange(m, "127.0.0.1:19360")
+	if err != nil {
+		t.Fatalf("EDNS query failed: %v", err)
+	}
+	if opt := r.IsEdns0(); opt == nil || opt.UDPSize() != ednsUDPSize {
+		t.Errorf("EDNS reply OPT = %v, want UDP size %d", opt, ednsUDPSize)
+	}
+}
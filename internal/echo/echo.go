@@ -0,0 +1,98 @@
+// internal/echo/echo.go
+package echo
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"unicode/utf8"
+)
+
+// maxBody caps how much of a request body is echoed back.
+const maxBody = 1 << 20
+
+// Request is the JSON reflection of a request.
+type Request struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Proto      string      `json:"proto"`
+	Host       string      `json:"host"`
+	RemoteAddr string      `json:"remoteAddr"`
+	Headers    http.Header `json:"headers"`
+	// Body is the request body as text, or base64 when it isn't UTF-8.
+	Body          string `json:"body,omitempty"`
+	BodyBase64    bool   `json:"bodyBase64,omitempty"`
+	BodySize      int64  `json:"bodySize"`
+	BodyTruncated bool   `json:"bodyTruncated,omitempty"`
+	// TLS describes the client's connection; nil for plain HTTP.
+	TLS *TLSInfo `json:"tls,omitempty"`
+}
+
+// TLSInfo describes a TLS connection.
+type TLSInfo struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipherSuite"`
+	ServerName  string `json:"serverName,omitempty"`
+	ALPN        string `json:"alpn,omitempty"`
+	Resumed     bool   `json:"resumed"`
+	// ClientCertificates is the subject of each certificate the client
+	// presented, if any.
+	ClientCertificates []string `json:"clientCertificates,omitempty"`
+}
+
+// Handler answers every request with its own method, URL, headers, body,
+// and TLS details as JSON, e.g. to check the headers a proxy adds or to
+// catch a webhook without writing a server.
+type Handler struct{}
+
+func (Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	out := Request{
+		Method:     r.Method,
+		URL:        r.URL.RequestURI(),
+		Proto:      r.Proto,
+		Host:       r.Host,
+		RemoteAddr: r.RemoteAddr,
+		Headers:    r.Header,
+		TLS:        tlsInfo(r.TLS),
+	}
+	if r.Body != nil {
+		body, _ := io.ReadAll(io.LimitReader(r.Body, maxBody))
+		rest, _ := io.Copy(io.Discard, r.Body)
+		out.BodySize = int64(len(body)) + rest
+		out.BodyTruncated = rest > 0
+		if utf8.Valid(body) {
+			out.Body = string(body)
+		} else {
+			out.Body = base64.StdEncoding.EncodeToString(body)
+			out.BodyBase64 = true
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		log.Printf("echo: failed to encode request: %v", err)
+	}
+}
+
+func tlsInfo(cs *tls.ConnectionState) *TLSInfo {
+	if cs == nil {
+		return nil
+	}
+	info := &TLSInfo{
+		Version:     tls.VersionName(cs.Version),
+		CipherSuite: tls.CipherSuiteName(cs.CipherSuite),
+		ServerName:  cs.ServerName,
+		ALPN:        cs.NegotiatedProtocol,
+		Resumed:     cs.DidResume,
+	}
+	for _, cert := range cs.PeerCertificates {
+		info.ClientCertificates = append(info.ClientCertificates, cert.Subject.String())
+	}
+	return info
+}
@@ -0,0 +1,57 @@
+package echo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func echoOf(t *testing.T, method, target string, body []byte) Request {
+	t.Helper()
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	req.Header.Set("X-Test", "1")
+	w := httptest.NewRecorder()
+	Handler{}.ServeHTTP(w, req)
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var got Request
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	return got
+}
+
+func TestHandler(t *testing.T) {
+	got := echoOf(t, "PUT", "http://app.test/a?b=c", []byte("hello"))
+	if got.Method != "PUT" || got.URL != "/a?b=c" || got.Host != "app.test" {
+		t.Errorf("request line = %s %s %s", got.Method, got.Host, got.URL)
+	}
+	if got.Body != "hello" || got.BodyBase64 || got.BodySize != 5 || got.BodyTruncated {
+		t.Errorf("body = %+v", got)
+	}
+	if got.Headers.Get("X-Test") != "1" {
+		t.Errorf("headers = %v", got.Headers)
+	}
+	if got.TLS != nil {
+		t.Errorf("plain HTTP request has TLS %+v", got.TLS)
+	}
+
+	binary := []byte{0xff, 0xfe, 0x00}
+	got = echoOf(t, "POST", "https://app.test/", binary)
+	if !got.BodyBase64 || got.Body != base64.StdEncoding.EncodeToString(binary) {
+		t.Errorf("binary body = %q base64=%v", got.Body, got.BodyBase64)
+	}
+	if got.TLS == nil || got.TLS.Version != "TLS 1.2" || got.TLS.ServerName != "app.test" {
+		t.Errorf("TLS = %+v", got.TLS)
+	}
+
+	big := strings.Repeat("x", maxBody+10)
+	got = echoOf(t, "POST", "http://app.test/", []byte(big))
+	if !got.BodyTruncated || got.BodySize != int64(len(big)) || len(got.Body) != maxBody {
+		t.Errorf("big body: size %d truncated %v, echoed %d bytes", got.BodySize, got.BodyTruncated, len(got.Body))
+	}
+}
@@ -2,19 +2,76 @@
 package errorpage
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"html"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/i18n"
 )
 
 // cspErrorPage is the Content-Security-Policy for error pages.
 // Error pages use only inline styles and no scripts.
 const cspErrorPage = "default-src 'none'; style-src 'unsafe-inline'"
 
+// baseStyle is shared by the error pages. Colors meet WCAG AA contrast on
+// the white background, and focused links get a visible outline.
+const baseStyle = `body { font-family: -apple-system, system-ui, sans-serif; max-width: 600px; margin: 80px auto; padding: 0 20px; color: #333; }
+a { color: #1f6fb2; }
+a:focus-visible { outline: 2px solid #1f6fb2; outline-offset: 2px; border-radius: 2px; }
+`
+
+// waitScript polls a route whose dev server is down and reloads the page
+// once the route stops answering with this page's 502. Unlike a meta
+// refresh, the page isn't reloaded every two seconds, so screen readers
+// and keyboard focus aren't reset while waiting; the status line
+// announces the reload. Browsers without scripts use the meta refresh in
+// <noscript>.
+const waitScript = `(function () {
+  var status = document.getElementById("status");
+  setInterval(function () {
+    fetch(location.href, { cache: "no-store" }).then(function (res) {
+      if (res.status === 502) return;
+      status.textContent = status.dataset.ready;
+      location.reload();
+    }).catch(function () {});
+  }, 2000);
+})();`
+
+// cspWaitPage allows only waitScript, by hash, and its polling.
+var cspWaitPage = func() string {
+	sum := sha256.Sum256([]byte(waitScript))
+	return cspErrorPage + "; script-src 'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'; connect-src 'self'"
+}()
+
+// maxDisplayLen caps how much of a request-supplied value is echoed into a
+// page, so oversized Host headers can't inflate responses.
+const maxDisplayLen = 255
+
+// clip truncates s to maxDisplayLen bytes (on a rune boundary) and marks
+// the cut with an ellipsis.
+func clip(s string) string {
+	if len(s) <= maxDisplayLen {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxDisplayLen], "") + "…"
+}
+
+// Language returns the language to render error pages in for r, from its
+// Accept-Language header. The daemon's own locale isn't used, since the
+// browser asking may not be the daemon's user.
+func Language(r *http.Request) string {
+	return i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+}
+
 // NotFound renders an HTML page when no route is registered for the host.
-// SECURITY: All dynamic content is HTML-escaped to prevent XSS.
-func NotFound(w http.ResponseWriter, host string, appName string, activeRoutes []string) {
+// SECURITY: All dynamic content is HTML-escaped to prevent XSS. Translated
+// messages come from the i18n catalog and are trusted.
+func NotFound(w http.ResponseWriter, lang, host string, appName string, activeRoutes []string) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Header().Set("Content-Security-Policy", cspErrorPage)
 	w.WriteHeader(http.StatusBadGateway)
@@ -28,60 +85,140 @@ func NotFound(w http.ResponseWriter, host string, appName string, activeRoutes [
 				html.EscapeString(r), html.EscapeString(r),
 			))
 		}
-		routeList = "<h2>Active Routes</h2><ul>" + strings.Join(items, "") + "</ul>"
+		routeList = "<h2>" + i18n.In(lang, "Active Routes") + "</h2><ul>" + strings.Join(items, "") + "</ul>"
 	}
 
 	fmt.Fprintf(w, `<!DOCTYPE html>
-<html><head>
+<html lang="%s"><head>
 <meta charset="utf-8">
-<title>Not Found - %s</title>
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
 <style>
-body { font-family: -apple-system, system-ui, sans-serif; max-width: 600px; margin: 80px auto; padding: 0 20px; color: #333; }
-h1 { color: #e74c3c; }
+`+baseStyle+`h1 { color: #c0392b; }
 pre { background: #f4f4f4; padding: 12px; border-radius: 6px; overflow-x: auto; }
-a { color: #3498db; }
 ul { list-style: none; padding: 0; }
 li { padding: 4px 0; }
 </style>
 </head><body>
-<h1>No app at %s</h1>
-<p>Start your dev server with:</p>
-<pre>up -n %s &lt;your-dev-command&gt;</pre>
+<main>
+<h1>%s</h1>
+<p>%s</p>
+<pre>up -n %s &lt;%s&gt;</pre>
 %s
+</main>
 </body></html>`,
-		html.EscapeString(host),
-		html.EscapeString(host),
-		html.EscapeString(appName),
+		lang,
+		i18n.In(lang, "Not Found - %s", html.EscapeString(clip(host))),
+		i18n.In(lang, "No app at %s", html.EscapeString(clip(host))),
+		i18n.In(lang, "Start your dev server with:"),
+		html.EscapeString(clip(appName)),
+		i18n.In(lang, "your-dev-command"),
 		routeList,
 	)
 }
 
 // UpstreamDown renders an HTML page when the upstream server is not responding.
-// Includes auto-refresh so the page reloads when the dev server starts.
+// It polls the route and reloads when the dev server starts (see waitScript).
 // SECURITY: All dynamic content is HTML-escaped to prevent XSS.
-func UpstreamDown(w http.ResponseWriter, host string, upstream string) {
+func UpstreamDown(w http.ResponseWriter, lang, host string, upstream string) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Header().Set("Content-Security-Policy", cspErrorPage)
+	w.Header().Set("Content-Security-Policy", cspWaitPage)
 	w.WriteHeader(http.StatusBadGateway)
 
 	fmt.Fprintf(w, `<!DOCTYPE html>
-<html><head>
+<html lang="%s"><head>
 <meta charset="utf-8">
-<meta http-equiv="refresh" content="2">
-<title>Waiting - %s</title>
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<noscript><meta http-equiv="refresh" content="2"></noscript>
+<title>%s</title>
 <style>
-body { font-family: -apple-system, system-ui, sans-serif; max-width: 600px; margin: 80px auto; padding: 0 20px; color: #333; }
-h1 { color: #e67e22; }
+`+baseStyle+`h1 { color: #b35c00; }
 .spinner { display: inline-block; animation: spin 1s linear infinite; }
 @keyframes spin { to { transform: rotate(360deg); } }
+@media (prefers-reduced-motion: reduce) { .spinner { animation: none; } }
+</style>
+</head><body>
+<main>
+<h1><span class="spinner" aria-hidden="true">&#x21bb;</span> %s</h1>
+<p>%s</p>
+<p id="status" role="status" data-ready="%s">%s <small>%s</small></p>
+</main>
+<script>`+waitScript+`</script>
+</body></html>`,
+		lang,
+		i18n.In(lang, "Waiting - %s", html.EscapeString(clip(host))),
+		i18n.In(lang, "%s is not responding", html.EscapeString(clip(host))),
+		i18n.In(lang, "The dev server at <code>%s</code> isn't running.", html.EscapeString(clip(upstream))),
+		i18n.In(lang, "The dev server is up. Reloading..."),
+		i18n.In(lang, "Waiting for it to start..."),
+		i18n.In(lang, "(auto-refreshing every 2s)"),
+	)
+}
+
+// maxMaintenanceRefresh caps how long the maintenance page waits before
+// reloading, so a long window still picks up an early end.
+const maxMaintenanceRefresh = 5 * time.Minute
+
+// Maintenance renders a 503 page for a route in a maintenance window
+// that ends at until. The page reloads when the window ends, and
+// Retry-After tells other clients the same.
+// SECURITY: All dynamic content is HTML-escaped to prevent XSS.
+func Maintenance(w http.ResponseWriter, lang, host string, until time.Time) {
+	left := time.Until(until).Round(time.Second)
+	refresh := min(max(left, time.Second), maxMaintenanceRefresh)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", cspErrorPage)
+	w.Header().Set("Retry-After", strconv.Itoa(int(max(left, time.Second).Seconds())))
+	w.WriteHeader(http.StatusServiceUnavailable)
+
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html lang="%s"><head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta http-equiv="refresh" content="%d">
+<title>%s</title>
+<style>
+`+baseStyle+`h1 { color: #b35c00; }
+</style>
+</head><body>
+<main>
+<h1>%s</h1>
+<p>%s</p>
+</main>
+</body></html>`,
+		lang,
+		int(refresh.Seconds()),
+		i18n.In(lang, "Maintenance - %s", html.EscapeString(clip(host))),
+		i18n.In(lang, "%s is down for maintenance", html.EscapeString(clip(host))),
+		i18n.In(lang, "Scheduled maintenance ends at %s. This page reloads then.", until.Local().Format("15:04")),
+	)
+}
+
+// Unauthorized renders an HTML page when a protected route is requested
+// without valid credentials. The caller sets WWW-Authenticate.
+// SECURITY: All dynamic content is HTML-escaped to prevent XSS.
+func Unauthorized(w http.ResponseWriter, lang, host string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", cspErrorPage)
+	w.WriteHeader(http.StatusUnauthorized)
+
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html lang="%s"><head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<style>
+`+baseStyle+`h1 { color: #c0392b; }
 </style>
 </head><body>
-<h1><span class="spinner">&#x21bb;</span> %s is not responding</h1>
-<p>The dev server at <code>%s</code> isn't running.</p>
-<p>Waiting for it to start... <small>(auto-refreshing every 2s)</small></p>
+<main>
+<h1>%s</h1>
+<p>%s</p>
+</main>
 </body></html>`,
-		html.EscapeString(host),
-		html.EscapeString(host),
-		html.EscapeString(upstream),
+		lang,
+		i18n.In(lang, "Unauthorized - %s", html.EscapeString(clip(host))),
+		i18n.In(lang, "%s requires credentials", html.EscapeString(clip(host))),
+		i18n.In(lang, "This route is protected by paw-proxy. Ask the owner for access."),
 	)
 }
@@ -1,15 +1,18 @@
 package errorpage
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNotFoundRendersHTML(t *testing.T) {
 	w := httptest.NewRecorder()
-	NotFound(w, "myapp.test", "myapp", []string{"dashboard", "api"})
+	NotFound(w, "en", "myapp.test", "myapp", []string{"dashboard", "api"})
 
 	if w.Code != http.StatusBadGateway {
 		t.Errorf("expected 502, got %d", w.Code)
@@ -31,7 +34,7 @@ func TestNotFoundRendersHTML(t *testing.T) {
 
 func TestNotFoundNoRoutes(t *testing.T) {
 	w := httptest.NewRecorder()
-	NotFound(w, "myapp.test", "myapp", nil)
+	NotFound(w, "en", "myapp.test", "myapp", nil)
 
 	body := w.Body.String()
 	if strings.Contains(body, "Active Routes") {
@@ -41,7 +44,7 @@ func TestNotFoundNoRoutes(t *testing.T) {
 
 func TestUpstreamDownRendersHTML(t *testing.T) {
 	w := httptest.NewRecorder()
-	UpstreamDown(w, "myapp.test", "localhost:3000")
+	UpstreamDown(w, "en", "myapp.test", "localhost:3000")
 
 	if w.Code != http.StatusBadGateway {
 		t.Errorf("expected 502, got %d", w.Code)
@@ -58,9 +61,39 @@ func TestUpstreamDownRendersHTML(t *testing.T) {
 	}
 }
 
+func TestMaintenanceRendersHTML(t *testing.T) {
+	w := httptest.NewRecorder()
+	until := time.Now().Add(90 * time.Second)
+	Maintenance(w, "en", "<b>myapp.test", until)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "90" {
+		t.Errorf("Retry-After = %q, want 90", got)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "&lt;b&gt;myapp.test is down for maintenance") {
+		t.Error("expected escaped host in the heading")
+	}
+	if !strings.Contains(body, until.Local().Format("15:04")) {
+		t.Error("expected the window's end time")
+	}
+	if !strings.Contains(body, `content="90"`) {
+		t.Error("expected a refresh when the window ends")
+	}
+
+	// Long windows still reload every few minutes.
+	w = httptest.NewRecorder()
+	Maintenance(w, "en", "myapp.test", time.Now().Add(2*time.Hour))
+	if !strings.Contains(w.Body.String(), `content="300"`) {
+		t.Error("expected the refresh capped at 5 minutes")
+	}
+}
+
 func TestNotFoundEscapesHTML(t *testing.T) {
 	w := httptest.NewRecorder()
-	NotFound(w, "<script>alert(1)</script>.test", "xss", []string{"<img onerror=alert(1)>"})
+	NotFound(w, "en", "<script>alert(1)</script>.test", "xss", []string{"<img onerror=alert(1)>"})
 
 	body := w.Body.String()
 	if strings.Contains(body, "<script>") {
@@ -73,10 +106,11 @@ func TestNotFoundEscapesHTML(t *testing.T) {
 
 func TestUpstreamDownEscapesHTML(t *testing.T) {
 	w := httptest.NewRecorder()
-	UpstreamDown(w, "<script>alert(1)</script>.test", "<img onerror=alert(1)>")
+	UpstreamDown(w, "en", "<script>alert(1)</script>.test", "<img onerror=alert(1)>")
 
+	// The page has its own polling script, so look for the injected one.
 	body := w.Body.String()
-	if strings.Contains(body, "<script>") {
+	if strings.Contains(body, "<script>alert") {
 		t.Error("XSS: unescaped script tag in host")
 	}
 	if strings.Contains(body, "<img onerror") {
@@ -86,7 +120,7 @@ func TestUpstreamDownEscapesHTML(t *testing.T) {
 
 func TestNotFoundSetsCSPHeader(t *testing.T) {
 	w := httptest.NewRecorder()
-	NotFound(w, "myapp.test", "myapp", nil)
+	NotFound(w, "en", "myapp.test", "myapp", nil)
 
 	csp := w.Header().Get("Content-Security-Policy")
 	if csp == "" {
@@ -102,7 +136,7 @@ func TestNotFoundSetsCSPHeader(t *testing.T) {
 
 func TestUpstreamDownSetsCSPHeader(t *testing.T) {
 	w := httptest.NewRecorder()
-	UpstreamDown(w, "myapp.test", "localhost:3000")
+	UpstreamDown(w, "en", "myapp.test", "localhost:3000")
 
 	csp := w.Header().Get("Content-Security-Policy")
 	if csp == "" {
@@ -115,3 +149,72 @@ func TestUpstreamDownSetsCSPHeader(t *testing.T) {
 		t.Errorf("CSP should contain style-src 'unsafe-inline', got: %s", csp)
 	}
 }
+
+func TestErrorPagesClipLongHost(t *testing.T) {
+	host := strings.Repeat("a", 10*1024) + ".test"
+
+	pages := map[string]func(w *httptest.ResponseRecorder){
+		"NotFound":     func(w *httptest.ResponseRecorder) { NotFound(w, "en", host, host, nil) },
+		"UpstreamDown": func(w *httptest.ResponseRecorder) { UpstreamDown(w, "en", host, host) },
+		"Unauthorized": func(w *httptest.ResponseRecorder) { Unauthorized(w, "en", host) },
+	}
+	for name, render := range pages {
+		w := httptest.NewRecorder()
+		render(w)
+		if w.Body.Len() > 4096 {
+			t.Errorf("%s: expected clipped output, got %d bytes", name, w.Body.Len())
+		}
+	}
+}
+
+func TestErrorPagesTranslated(t *testing.T) {
+	r := httptest.NewRequest("GET", "https://myapp.test/", nil)
+	r.Header.Set("Accept-Language", "ja,en;q=0.8")
+	lang := Language(r)
+	if lang != "ja" {
+		t.Fatalf("Language = %q, want ja", lang)
+	}
+
+	w := httptest.NewRecorder()
+	NotFound(w, lang, "myapp.test", "myapp", nil)
+	body := w.Body.String()
+	if !strings.Contains(body, `<html lang="ja">`) {
+		t.Error("expected lang attribute")
+	}
+	if !strings.Contains(body, "myapp.test にアプリはありません") {
+		t.Errorf("expected Japanese heading, got:\n%s", body)
+	}
+	if !strings.Contains(body, "up -n myapp") {
+		t.Error("expected up command in body")
+	}
+}
+
+func TestUpstreamDownAccessibility(t *testing.T) {
+	w := httptest.NewRecorder()
+	UpstreamDown(w, "en", "myapp.test", "localhost:3000")
+	body := w.Body.String()
+
+	for _, want := range []string{
+		"<main>",
+		`role="status"`,
+		`class="spinner" aria-hidden="true"`,
+		"prefers-reduced-motion: reduce",
+		`<noscript><meta http-equiv="refresh" content="2"></noscript>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in body", want)
+		}
+	}
+
+	// The polling script must be allowed by its hash, and nothing else.
+	_, rest, ok := strings.Cut(body, "<script>")
+	script, _, ok2 := strings.Cut(rest, "</script>")
+	if !ok || !ok2 {
+		t.Fatal("expected an inline script")
+	}
+	sum := sha256.Sum256([]byte(script))
+	want := "script-src 'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+	if csp := w.Header().Get("Content-Security-Policy"); !strings.Contains(csp, want) {
+		t.Errorf("CSP %q doesn't allow the script (want %s)", csp, want)
+	}
+}
@@ -9,49 +9,225 @@ var PawProxyCommand = Command{
 		{
 			Name:         "setup",
 			Summary:      "Configure DNS, CA, and install daemon (requires sudo)",
-			Usage:        "sudo paw-proxy setup",
+			Usage:        "sudo paw-proxy setup [--dry-run] [--socket-activation] [--http-port N] [--https-port N] [--api-addr addr] [--listen-addr IP --allow CIDR] [--ca-cert file --ca-key file | --ca-url URL --ca-sha256 hex]",
 			RequiresRoot: true,
+			Flags: []Flag{
+				{Short: "-n", Long: "--dry-run", Desc: "Print the files, commands, and keychain entries setup would touch, without sudo or changes"},
+				{Long: "--socket-activation", Desc: "Linux: let systemd bind ports 80/443 instead of using setcap"},
+				{Long: "--http-port", Arg: "port", Desc: "Listen on this port instead of 80 and forward 80 to it (pf/nftables)"},
+				{Long: "--https-port", Arg: "port", Desc: "Listen on this port instead of 443 and forward 443 to it (pf/nftables)"},
+				{Long: "--api-addr", Arg: "addr", Desc: "Also serve the control API on this loopback address (e.g. 127.0.0.1:2019) for containers"},
+				{Long: "--listen-addr", Arg: "IP", Desc: "Also serve apps on this interface address (e.g. a Tailscale IP); requires --allow"},
+				{Long: "--allow", Arg: "CIDR", Desc: "Client networks allowed on --listen-addr (comma-separated or repeated)"},
+				{Long: "--ca-cert", Arg: "file", Desc: "Install this team-shared CA certificate instead of generating one; requires --ca-key"},
+				{Long: "--ca-key", Arg: "file", Desc: "Private key of the --ca-cert CA"},
+				{Long: "--ca-url", Arg: "URL", Desc: "Download a shared CA (certificate and key in one PEM file) over HTTPS; requires --ca-sha256"},
+				{Long: "--ca-sha256", Arg: "hex", Desc: "SHA-256 the --ca-url download must match"},
+			},
 		},
 		{
 			Name:         "uninstall",
 			Summary:      "Remove all paw-proxy components (requires sudo)",
-			Usage:        "sudo paw-proxy uninstall [--brew]",
+			Usage:        "sudo paw-proxy uninstall [--dry-run] [--brew]",
 			RequiresRoot: true,
 			Flags: []Flag{
+				{Short: "-n", Long: "--dry-run", Desc: "Print the files, commands, and keychain entries uninstall would touch, without changes"},
 				{Long: "--brew", Desc: "Skip CA removal prompt (used by Homebrew uninstall hook)"},
 			},
 		},
 		{
 			Name:    "status",
 			Summary: "Show daemon status and registered routes",
+			Usage:   "paw-proxy status [--json | --watch]",
+			Flags: []Flag{
+				{Long: "--json", Desc: "Print the status as one JSON object: running, version, problems, routes, caExpires"},
+				{Short: "-w", Long: "--watch", Desc: "Redraw the status and a table of routes with request counts every second and on each route change"},
+			},
+		},
+		{
+			Name:    "routes",
+			Summary: "List routes grouped by project, or remove a project's routes",
+			Usage:   "paw-proxy routes [--project name] | paw-proxy routes rm --project name",
+			Flags: []Flag{
+				{Short: "-p", Long: "--project", Arg: "name", Desc: "Only list, or remove, the routes in this project"},
+			},
 		},
 		{
 			Name:    "run",
 			Summary: "Run daemon in foreground (used by service manager)",
+			Usage:   "paw-proxy run [--log-level level] [--verbose] [--http-port port] [--https-port port] [--api-addr addr] [--listen-addr IP --allow CIDR] [--read-only]",
+			Flags: []Flag{
+				{Long: "--log-level", Arg: "level", Desc: "debug, info, warn, or error (overrides PAW_PROXY_LOG_LEVEL and config.json)"},
+				{Short: "-v", Long: "--verbose", Desc: "Same as --log-level debug"},
+				{Long: "--http-port", Arg: "port", Desc: "HTTP listener port (default 80)"},
+				{Long: "--https-port", Arg: "port", Desc: "HTTPS listener port (default 443)"},
+				{Long: "--api-addr", Arg: "addr", Desc: "Loopback TCP address for the token-protected control API"},
+				{Long: "--listen-addr", Arg: "IP", Desc: "Non-loopback address the HTTP and HTTPS listeners also bind"},
+				{Long: "--allow", Arg: "CIDR", Desc: "Client networks allowed to connect on --listen-addr"},
+				{Long: "--read-only", Desc: "Refuse API requests that add, remove, or change routes (also PAW_PROXY_READ_ONLY=1)"},
+			},
 		},
 		{
 			Name:    "logs",
 			Summary: "Show daemon logs",
-			Usage:   "paw-proxy logs [--tail|-f] [--clear]",
+			Usage:   "paw-proxy logs [--tail|-f] [--clear] [--json] [--since 10m] [--route name] [--level warn]",
 			Flags: []Flag{
 				{Short: "-f", Long: "--tail", Desc: "Follow log output in real time"},
 				{Long: "--clear", Desc: "Truncate the log file"},
+				{Long: "--json", Desc: "Print raw JSON lines instead of the colorized view"},
+				{Long: "--since", Desc: "Only show entries newer than this duration (e.g. 10m)"},
+				{Long: "--route", Desc: "Only show entries for this route"},
+				{Long: "--level", Desc: "Only show entries at or above this level"},
 			},
 		},
 		{
 			Name:    "doctor",
 			Summary: "Run diagnostics to check system health",
+			Usage:   "paw-proxy doctor [--fix [--yes] | --json]",
+			Flags: []Flag{
+				{Long: "--fix", Desc: "Repair failed checks one by one and report each outcome"},
+				{Short: "-y", Long: "--yes", Desc: "Regenerate an expired CA without prompting"},
+				{Long: "--json", Desc: "Print the results as one JSON object: ok, failed, and each check's id, pass, and message"},
+			},
+		},
+		{
+			Name:    "reload",
+			Summary: "Re-read the daemon config file without restarting (same as SIGHUP)",
+		},
+		{
+			Name:    "dashboard",
+			Summary: "Sign a browser in to the https://_paw.test dashboard with a one-time link",
+			Usage:   "paw-proxy dashboard open [--no-browser]",
+			Flags: []Flag{
+				{Long: "--no-browser", Desc: "Only print the link instead of opening it"},
+			},
+		},
+		{
+			Name:    "tray",
+			Summary: "Print a menu bar/tray menu of daemon health and routes (xbar, SwiftBar, Argos)",
+			Usage:   "paw-proxy tray [--stream]",
+			Flags: []Flag{
+				{Long: "--stream", Desc: "Keep running and redraw on every route change (SwiftBar streamable plugin)"},
+			},
+		},
+		{
+			Name:    "gc",
+			Summary: "Remove expired routes, stale preview records, and old rotated logs",
+			Usage:   "paw-proxy gc [--dry-run]",
+			Flags: []Flag{
+				{Short: "-n", Long: "--dry-run", Desc: "Show what would be removed without removing it"},
+			},
+		},
+		{
+			Name:    "k8s-sync",
+			Summary: "Register .test routes for a local cluster's Ingresses and annotated Services",
+			Usage:   "paw-proxy k8s-sync [--context name] [--namespace ns] [--interval 5s]",
+			Flags: []Flag{
+				{Long: "--context", Arg: "name", Desc: "kubectl context to sync (default: current context)"},
+				{Short: "-n", Long: "--namespace", Arg: "ns", Desc: "Only sync this namespace (default: all)"},
+				{Long: "--interval", Arg: "duration", Desc: "How often to poll the cluster (default 5s)"},
+			},
+		},
+		{
+			Name:    "certs",
+			Summary: "List the certificates the daemon has issued, or drop its cached ones so they are issued again",
+			Usage:   "paw-proxy certs list [--name pattern] [--json] | paw-proxy certs flush",
+			Flags: []Flag{
+				{Long: "--name", Arg: "pattern", Desc: "Only certificates with a name matching this glob (e.g. '*.shop.test')"},
+				{Long: "--json", Desc: "Print one JSON object per line"},
+			},
+		},
+		{
+			Name:    "ca",
+			Summary: "Show the CA's name constraints, or replace it with one limited to the configured TLDs",
+			Usage:   "paw-proxy ca rotate|status",
+		},
+		{
+			Name:    "ca-key",
+			Summary: "Encrypt the CA private key with a key bound to this machine, or decrypt it",
+			Usage:   "paw-proxy ca-key protect|unprotect|status",
+		},
+		{
+			Name:    "export",
+			Summary: "Write the CA and daemon config, with its permanent routes, to stdout as a tar archive, to move them to another machine; routes from up aren't exported",
+			Usage:   "paw-proxy export > paw-backup.tar",
+		},
+		{
+			Name:    "import",
+			Summary: "Replace the CA and daemon config with an export's, and trust the imported CA",
+			Usage:   "sudo paw-proxy import [file]",
+		},
+		{
+			Name:    "tailscale",
+			Summary: "Share routes with your tailnet over HTTPS via tailscale serve",
+			Usage:   "paw-proxy tailscale enable <name>... [--port N] | paw-proxy tailscale disable <name>... | paw-proxy tailscale status",
+			Flags: []Flag{
+				{Long: "--port", Arg: "port", Desc: "HTTPS port on the machine's MagicDNS name (default: first free from 8443)"},
+			},
+		},
+		{
+			Name:    "service",
+			Summary: "Manage the launchd/systemd service: install, restart, or status",
+			Usage:   "paw-proxy service install|restart|status",
+		},
+		{
+			Name:    "trust",
+			Summary: "Make Java or Python trust the paw-proxy CA (they ignore the system store)",
+			Usage:   "paw-proxy trust java [--print] [--remove] | paw-proxy trust python [--remove]",
+			Flags: []Flag{
+				{Long: "--print", Desc: "java: write a truststore copy instead of changing the JDK, and print the -D flags for it"},
+				{Long: "--remove", Desc: "Remove the CA again"},
+			},
+		},
+		{
+			Name:    "tap",
+			Summary: "Record a route's requests and responses as ndjson for offline analysis",
+			Usage:   "paw-proxy tap <name> [--out file] [--bodies bytes]",
+			Flags: []Flag{
+				{Long: "--out", Short: "-o", Arg: "file", Desc: "Write captures to file (default: stdout)"},
+				{Long: "--bodies", Arg: "bytes", Desc: "Include up to this many bytes of each body, base64 in the JSON (max 1048576)"},
+			},
+		},
+		{
+			Name:    "throttle",
+			Summary: "Slow a route's HTTP and WebSocket traffic down to simulate a slow network",
+			Usage:   "paw-proxy throttle <name> [slow-3g|3g|dsl|down=KBPS,up=KBPS,latency=DURATION|off]",
+		},
+		{
+			Name:    "update",
+			Summary: "Install the latest release, restore port binding capability, and restart the daemon in place",
+			Usage:   "paw-proxy update [--check] [--force]",
+			Flags: []Flag{
+				{Long: "--check", Desc: "Only report whether a newer release exists"},
+				{Long: "--force", Desc: "Install the latest release even if it is not newer"},
+			},
 		},
 		{
 			Name:    "version",
 			Summary: "Show version",
 		},
 	},
+	EnvVars: []EnvVar{
+		{Name: "PAW_PROXY_LOG_LEVEL", Desc: "Daemon log level: debug, info, warn, or error"},
+		{Name: "PAW_PROXY_READ_ONLY", Desc: "Set to 1 to make the daemon's control API read-only, like run --read-only"},
+	},
 	Examples: []Example{
 		{Command: "sudo paw-proxy setup", Desc: "Initial setup (creates CA, configures DNS, installs daemon)"},
+		{Command: "paw-proxy setup --dry-run", Desc: "See every file, command, and keychain change before granting sudo"},
 		{Command: "paw-proxy status", Desc: "Check if daemon is running and see active routes"},
+		{Command: "paw-proxy routes rm --project shop", Desc: "Remove every route a compose project registered"},
 		{Command: "paw-proxy logs -f", Desc: "Follow daemon logs in real time"},
+		{Command: "paw-proxy logs --route myapp --level warn --since 1h", Desc: "Show recent warnings and errors for one route"},
 		{Command: "paw-proxy doctor", Desc: "Diagnose common issues"},
+		{Command: "sudo paw-proxy doctor --fix", Desc: "Repair what doctor finds without re-running full setup"},
+		{Command: "paw-proxy service install", Desc: "Regenerate the daemon service after the binary moved"},
+		{Command: "paw-proxy dashboard open", Desc: "Open the dashboard (each link signs in one browser, once)"},
+		{Command: "paw-proxy trust python", Desc: "Let requests/httpx in up's dev servers trust .test routes"},
+		{Command: "paw-proxy tap myapp --out tap.ndjson --bodies 65536", Desc: "Record myapp's traffic, with bodies, until Ctrl-C"},
+		{Command: "paw-proxy throttle myapp 3g", Desc: "Load myapp over a simulated 3G connection; off to undo"},
+		{Command: "paw-proxy reload", Desc: "Apply config.json changes to the running daemon"},
+		{Command: "paw-proxy k8s-sync --context kind-dev", Desc: "Give a kind cluster's Ingress hosts trusted .test names"},
+		{Command: "paw-proxy tailscale enable myapp", Desc: "Let teammates on your tailnet open myapp with a real certificate"},
 	},
 	// Files is populated per-platform by init() in pawproxy_files_*.go
 	SeeAlso: []string{"up(1)"},
@@ -6,6 +6,7 @@ func init() {
 	PawProxyCommand.Files = []FilePath{
 		{Path: "~/Library/Application Support/paw-proxy/", Desc: "Support directory (CA, socket)"},
 		{Path: "~/Library/Logs/paw-proxy.log", Desc: "Daemon log file"},
+		{Path: "~/Library/Application Support/paw-proxy/config.json", Desc: "Daemon config (reloadable)"},
 		{Path: "/etc/resolver/test", Desc: "macOS DNS resolver for .test TLD"},
 		{Path: "~/Library/LaunchAgents/dev.paw-proxy.plist", Desc: "LaunchAgent for auto-start"},
 	}
@@ -4,10 +4,58 @@ package help
 var UpCommand = Command{
 	Name:    "up",
 	Summary: "Dev server wrapper — register routes with paw-proxy and run commands",
-	Usage:   "up [-n name] [--restart] <command> [args...]",
+	Usage:   "up [-n name] [--restart] [--warmup path] <command> [args...]\n  up [-n name] --static dir [--autoindex]",
 	Flags: []Flag{
 		{Short: "-n", Arg: "name", Desc: "Custom domain name (default: package.json name or directory)"},
-		{Long: "--restart", Desc: "Auto-restart on crash (non-zero exit)"},
+		{Long: "--restart", Desc: "Auto-restart on crash (non-zero exit), backing off from 1s up to 30s"},
+		{Long: "--max-restarts", Arg: "n", Desc: "With --restart, give up after n restarts (default: no limit)"},
+		{Long: "--port-env", Arg: "name", Desc: "Also pass the port as this variable (repeatable); Nuxt and Vite projects get theirs automatically"},
+		{Long: "--env-file", Arg: "path", Desc: "Load variables for the dev server from a dotenv file (repeatable; may use $APP_URL)"},
+		{Long: "--crash-log", Desc: "Also save each crash report to crash/<name>-<time>.log in the support directory"},
+		{Long: "--pty", Desc: "Run the dev server on a pseudo-terminal, keeping colors and interactive prompts (macOS and Linux)"},
+		{Long: "--warmup", Arg: "path", Desc: "Request path once the dev server is ready, so the first page is pre-compiled"},
+		{Long: "--auth", Arg: "user:password", Desc: "Require HTTP basic auth before proxying to the route"},
+		{Long: "--auth-token", Arg: "token", Desc: "Require an Authorization: Bearer token before proxying to the route"},
+		{Long: "--cors", Desc: "Answer CORS preflights and add CORS headers to responses"},
+		{Long: "--cors-origins", Arg: "origins", Desc: "Comma-separated origins allowed by --cors (default: any)"},
+		{Long: "--preview", Arg: "label", Desc: "Register as a preview at <label>.<name>.test (e.g. pr-123)"},
+		{Long: "--preview-idle", Arg: "duration", Desc: "Remove a preview after this long without requests (default 2h)"},
+		{Long: "--project", Arg: "name", Desc: "Group the routes under this project (default: the app name, or the compose project)"},
+		{Long: "--no-trust-env", Desc: "Don't set DENO_CERT, SSL_CERT_FILE, REQUESTS_CA_BUNDLE, CURL_CA_BUNDLE, or GIT_SSL_CAINFO"},
+		{Long: "--proxy-protocol", Desc: "Send a PROXY protocol v2 header on each upstream connection (real client address)"},
+		{Long: "--hsts", Arg: "mode", Desc: "Strict-Transport-Security from the dev server: keep, strip, or rewrite (drop includeSubDomains/preload)"},
+		{Long: "--host-header", Arg: "mode", Desc: "Host header for the dev server: preserve, upstream (localhost:PORT), or custom:<host>"},
+		{Long: "--pool-max-idle", Arg: "n", Desc: "Idle keep-alive connections kept to the dev server (-1 disables keep-alives)"},
+		{Long: "--pool-idle-timeout", Arg: "duration", Desc: "Close pooled connections after this long unused (e.g. 5s)"},
+		{Long: "--subdomains", Desc: "Route <tenant>.<name>.test to this app too; the app reads the tenant from X-Paw-Subdomain"},
+		{Long: "--plain-http", Desc: "Proxy plain HTTP on port 80 for this route instead of redirecting (HTTPS still works)"},
+		{Long: "--passthrough", Desc: "Route TLS by SNI straight to the dev server, which terminates TLS itself"},
+		{Long: "--udp", Desc: "Dev server takes UDP on PORT; forward it and publish the port as SRV _<service>._udp.<name>.test"},
+		{Long: "--balance", Arg: "ports", Desc: "Spread requests over more instances too, e.g. 3001,3002 (ports or host:ports)"},
+		{Long: "--balance-mode", Arg: "mode", Desc: "round-robin (default) or fallback: the dev server first, the others while it's down"},
+		{Long: "--alert-error-rate", Arg: "pct", Desc: "Warn (log, notification, dashboard) when over pct% of requests get a 5xx in a minute"},
+		{Long: "--alert-request-rate", Arg: "n", Desc: "Warn when the route gets over n requests/s in a minute, e.g. a runaway polling loop"},
+		{Long: "--mirror", Arg: "port", Desc: "Also send each request to this port or host:port (fire-and-forget), e.g. a rewrite"},
+		{Long: "--strip-prefix", Arg: "path", Desc: "Remove a path prefix (e.g. /api) before forwarding; redirects get it back"},
+		{Long: "--rewrite-location", Arg: "origins", Desc: "Also point redirects to these origins (e.g. localhost:8080) back at https://<name>.test"},
+		{Long: "--cookies", Arg: "opts", Desc: "Adjust cookies from the dev server: secure, domain (<name>.test), samesite-none"},
+		{Long: "--keep-upstream-urls", Desc: "Leave redirects to the dev server's own address and Domain=localhost cookies alone"},
+		{Long: "--static", Arg: "dir", Desc: "Serve a directory from the daemon (ETags, ranges) instead of running a command"},
+		{Long: "--autoindex", Desc: "With --static, list directories that have no index.html, sortable by name, size, or date"},
+		{Long: "--spa", Desc: "Serve /index.html for page loads that get a 404, so client-side routes survive a refresh"},
+		{Long: "--spa-index", Arg: "path", Desc: "Page --spa serves instead (default /index.html)"},
+		{Long: "--spa-status", Arg: "codes", Desc: "Statuses --spa replaces, e.g. 404,503 (default 404); others pass through"},
+		{Long: "--spa-keep-status", Desc: "Serve the --spa page with the dev server's status (e.g. 404) instead of 200"},
+		{Long: "--trailing-slash", Arg: "mode", Desc: "Redirect /about to /about/ (add) or /about/ to /about (strip)"},
+		{Long: "--lowercase-host", Desc: "Redirect MyApp.test to myapp.test"},
+		{Long: "--strip-www", Desc: "Redirect www.<name>.test to <name>.test"},
+		{Long: "--canonical-status", Arg: "code", Desc: "Status of the redirects above: 301, 302, 307, or 308 (default 301)"},
+		{Long: "--maintenance", Arg: "cron", Desc: "Take the route down on a schedule, e.g. \"0 12 * * mon-fri\" (local time)"},
+		{Long: "--maintenance-for", Arg: "duration", Desc: "How long each --maintenance window lasts, e.g. 30m"},
+		{Long: "--throttle", Arg: "profile", Desc: "Slow HTTP and WebSocket traffic down: slow-3g, 3g, dsl, or down=KBPS,up=KBPS,latency=200ms"},
+		{Long: "--trust-forwarded", Desc: "Keep X-Forwarded-*/Forwarded headers from a local proxy in front and append to them"},
+		{Long: "--port", Arg: "port", Desc: "Fixed PORT for the dev server instead of a free one; fails fast if it is taken"},
+		{Long: "--host-port", Arg: "port", Desc: "Host port --port is published on; registers that with the host daemon (devcontainers)"},
 	},
 	EnvVars: []EnvVar{
 		{Name: "PORT", Desc: "Allocated port for your dev server to bind to"},
@@ -15,12 +63,27 @@ var UpCommand = Command{
 		{Name: "APP_URL", Desc: "Full URL, e.g. https://myapp.test"},
 		{Name: "HTTPS", Desc: "Always \"true\""},
 		{Name: "NODE_EXTRA_CA_CERTS", Desc: "Path to CA cert (for Node.js HTTPS requests)"},
+		{Name: "DENO_CERT", Desc: "Path to CA cert (for Deno)"},
+		{Name: "SSL_CERT_FILE", Desc: "System roots plus the CA, for OpenSSL-based clients (certifi-based after 'paw-proxy trust python')"},
+		{Name: "REQUESTS_CA_BUNDLE", Desc: "Same bundle as SSL_CERT_FILE, for Python requests"},
+		{Name: "CURL_CA_BUNDLE", Desc: "System roots plus the CA, for curl"},
+		{Name: "GIT_SSL_CAINFO", Desc: "System roots plus the CA, for git over HTTPS"},
+		{Name: "PAW_PROXY_API", Desc: "Daemon control API address (host:port) to use instead of the unix socket"},
+		{Name: "PAW_PROXY_API_TOKEN", Desc: "Token for PAW_PROXY_API (default: read api-token from the support directory)"},
+		{Name: "PAW_PROXY_PORT", Desc: "Default for --port"},
+		{Name: "PAW_PROXY_HOST_PORT", Desc: "Default for --host-port"},
 	},
 	Examples: []Example{
 		{Command: "up bun dev", Desc: "Run Bun dev server with HTTPS"},
 		{Command: "up npm run dev", Desc: "Run npm dev server with HTTPS"},
 		{Command: "up -n api bun dev", Desc: "Custom domain: https://api.test"},
 		{Command: "up --restart bun dev", Desc: "Auto-restart on crash"},
+		{Command: "up --warmup / npm run dev", Desc: "Compile the home page before you open it"},
+		{Command: "up -n api --cors-origins https://app.test bun dev", Desc: "Let app.test call api.test without backend CORS code"},
+		{Command: "up --static dist --autoindex", Desc: "Serve a built site at https://myapp.test"},
+		{Command: "up --preview pr-123 npm run dev", Desc: "Preview a branch at https://pr-123.myapp.test"},
+		{Command: "PAW_PROXY_API=host.docker.internal:2019 up bun dev", Desc: "Register from inside a container"},
+		{Command: "up --port 3000 --host-port 3000 npm run dev", Desc: "Dev server in a devcontainer, published on host port 3000"},
 	},
 	SeeAlso: []string{"paw-proxy(1)"},
 }
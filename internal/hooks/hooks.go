@@ -0,0 +1,218 @@
+// Package hooks runs user-configured shell commands and webhooks when
+// routes are registered, removed, or expire.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+// Timeout bounds a single hook run. Commands still running are killed.
+const Timeout = 10 * time.Second
+
+// maxConcurrent bounds how many hooks run at once, so a burst of route
+// changes (e.g. a compose project starting) can't fork without limit.
+const maxConcurrent = 4
+
+// maxOutput is how much of a failed command's output is logged.
+const maxOutput = 1024
+
+// events are the route event types a hook can subscribe to.
+var events = []string{api.EventAdded, api.EventUpdated, api.EventRemoved, api.EventExpired, api.EventIdleExpired}
+
+// Hook runs Command or calls URL for matching route events.
+type Hook struct {
+	// On lists the event types that fire the hook: added, updated,
+	// removed, expired, or idle-expired. Empty means all of them.
+	On []string `json:"on,omitempty"`
+	// Route limits the hook to routes matching this glob (e.g. "*.shop").
+	Route string `json:"route,omitempty"`
+	// Command is run with sh -c, with the event in PAW_* variables.
+	Command string `json:"command,omitempty"`
+	// URL receives the event as a JSON POST.
+	URL string `json:"url,omitempty"`
+}
+
+// Validate checks that the hook has exactly one action and only known
+// events.
+func (h Hook) Validate() error {
+	if (h.Command == "") == (h.URL == "") {
+		return fmt.Errorf("set exactly one of command or url")
+	}
+	for _, on := range h.On {
+		if !slices.Contains(events, on) {
+			return fmt.Errorf("unknown event %q (want one of %v)", on, events)
+		}
+	}
+	if _, err := path.Match(h.Route, ""); err != nil {
+		return fmt.Errorf("route: %w", err)
+	}
+	if h.URL != "" {
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url: must be an http or https URL")
+		}
+	}
+	return nil
+}
+
+// matches reports whether ev fires the hook.
+func (h Hook) matches(ev api.RouteEvent) bool {
+	if len(h.On) > 0 && !slices.Contains(h.On, ev.Type) {
+		return false
+	}
+	if h.Route != "" {
+		if ok, _ := path.Match(h.Route, ev.Route); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Runner fires the configured hooks for route events.
+type Runner struct {
+	tld    string
+	logger *slog.Logger
+	client *http.Client
+	hooks  atomic.Pointer[[]Hook]
+	sem    chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewRunner returns a Runner with no hooks. tld builds the PAW_URL given
+// to commands.
+func NewRunner(tld string, logger *slog.Logger) *Runner {
+	return &Runner{
+		tld:    tld,
+		logger: logger,
+		client: &http.Client{Timeout: Timeout},
+		sem:    make(chan struct{}, maxConcurrent),
+	}
+}
+
+// SetHooks replaces the configured hooks. Hooks already running finish.
+func (r *Runner) SetHooks(hooks []Hook) {
+	hooks = slices.Clone(hooks)
+	r.hooks.Store(&hooks)
+}
+
+// Run fires hooks for events until ctx is done, then waits for running
+// hooks to finish.
+func (r *Runner) Run(ctx context.Context, events <-chan api.RouteEvent) {
+	defer r.wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-events:
+			r.Fire(ctx, ev)
+		}
+	}
+}
+
+// Fire starts every hook matching ev in the background.
+func (r *Runner) Fire(ctx context.Context, ev api.RouteEvent) {
+	hooks := r.hooks.Load()
+	if hooks == nil {
+		return
+	}
+	for _, h := range *hooks {
+		if !h.matches(ev) {
+			continue
+		}
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.sem <- struct{}{}
+			defer func() { <-r.sem }()
+			r.run(ctx, h, ev)
+		}()
+	}
+}
+
+// run executes one hook for ev and logs the outcome.
+func (r *Runner) run(ctx context.Context, h Hook, ev api.RouteEvent) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	var err error
+	var output []byte
+	target := h.URL
+	if h.Command != "" {
+		target = h.Command
+		output, err = r.runCommand(ctx, h.Command, ev)
+	} else {
+		err = r.post(ctx, h.URL, ev)
+	}
+
+	if err != nil {
+		r.logger.Warn("hook failed", "event", ev.Type, "route", ev.Route, "hook", target, "error", err, "output", string(output))
+		return
+	}
+	r.logger.Debug("hook ran", "event", ev.Type, "route", ev.Route, "hook", target)
+}
+
+// runCommand runs command with sh -c and the event in its environment. It
+// returns the start of the output on failure.
+func (r *Runner) runCommand(ctx context.Context, command string, ev api.RouteEvent) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Env = append(os.Environ(), r.env(ev)...)
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil, nil
+	}
+	if len(out) > maxOutput {
+		out = out[:maxOutput]
+	}
+	return out, err
+}
+
+// env returns the PAW_* variables describing ev.
+func (r *Runner) env(ev api.RouteEvent) []string {
+	return []string{
+		"PAW_EVENT=" + ev.Type,
+		"PAW_ROUTE=" + ev.Route,
+		"PAW_UPSTREAM=" + ev.Upstream,
+		"PAW_DIR=" + ev.Dir,
+		"PAW_PREVIEW=" + ev.Preview,
+		"PAW_PROJECT=" + ev.Project,
+		"PAW_URL=https://" + ev.Route + "." + r.tld,
+	}
+}
+
+// post sends ev as JSON to url and expects a 2xx response.
+func (r *Runner) post(ctx context.Context, url string, ev api.RouteEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "paw-proxy/"+api.Version)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+func testRunner() *Runner {
+	return NewRunner("test", slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestHookValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    Hook
+		wantErr bool
+	}{
+		{"command", Hook{Command: "true"}, false},
+		{"webhook with events", Hook{On: []string{"added", "removed"}, URL: "https://example.com/hook"}, false},
+		{"no action", Hook{On: []string{"added"}}, true},
+		{"both actions", Hook{Command: "true", URL: "http://127.0.0.1/"}, true},
+		{"unknown event", Hook{On: []string{"started"}, Command: "true"}, true},
+		{"bad url", Hook{URL: "file:///etc/passwd"}, true},
+		{"bad route glob", Hook{Route: "[", Command: "true"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.hook.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHookMatches(t *testing.T) {
+	ev := api.RouteEvent{Type: api.EventAdded, Route: "web.shop"}
+	tests := []struct {
+		hook Hook
+		want bool
+	}{
+		{Hook{}, true},
+		{Hook{On: []string{"added"}}, true},
+		{Hook{On: []string{"removed", "expired"}}, false},
+		{Hook{Route: "*.shop"}, true},
+		{Hook{Route: "blog"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.hook.matches(ev); got != tt.want {
+			t.Errorf("%+v.matches() = %v, want %v", tt.hook, got, tt.want)
+		}
+	}
+}
+
+func TestRunnerCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	r := testRunner()
+	r.SetHooks([]Hook{
+		{On: []string{"added"}, Command: `echo "$PAW_EVENT $PAW_ROUTE $PAW_UPSTREAM $PAW_DIR $PAW_URL" > ` + out},
+		{On: []string{"removed"}, Command: "echo wrong > " + out + ".removed"},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan api.RouteEvent, 1)
+	events <- api.RouteEvent{Type: api.EventAdded, Route: "myapp", Upstream: "localhost:3000", Dir: "/src/myapp"}
+
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx, events)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	var data []byte
+	for time.Now().Before(deadline) {
+		if data, _ = os.ReadFile(out); len(data) > 0 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	want := "added myapp localhost:3000 /src/myapp https://myapp.test\n"
+	if string(data) != want {
+		t.Errorf("command saw %q, want %q", data, want)
+	}
+	if _, err := os.Stat(out + ".removed"); !os.IsNotExist(err) {
+		t.Error("hook for another event ran")
+	}
+}
+
+func TestRunnerWebhook(t *testing.T) {
+	got := make(chan api.RouteEvent, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var ev api.RouteEvent
+		json.NewDecoder(r.Body).Decode(&ev)
+		got <- ev
+	}))
+	defer srv.Close()
+
+	r := testRunner()
+	r.SetHooks([]Hook{{URL: srv.URL}})
+	r.Fire(context.Background(), api.RouteEvent{Type: api.EventExpired, Route: "myapp"})
+
+	select {
+	case ev := <-got:
+		if ev.Type != api.EventExpired || ev.Route != "myapp" {
+			t.Errorf("webhook got %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not called")
+	}
+	r.wg.Wait()
+}
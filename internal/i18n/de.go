@@ -0,0 +1,105 @@
+package i18n
+
+// de is the German catalog.
+var de = map[string]string{
+	// setup
+	"Creating support directory...":                             "Support-Verzeichnis wird angelegt...",
+	"Generating CA certificate...":                              "CA-Zertifikat wird erzeugt...",
+	"Installed shared CA from %s":                               "Gemeinsame CA aus %s installiert",
+	"Shared CA already installed":                               "Gemeinsame CA ist bereits installiert",
+	"CA already exists":                                         "CA ist bereits vorhanden",
+	"Generated CA certificate%s":                                "CA-Zertifikat erzeugt%s",
+	"(limited to %s)":                                           "(beschränkt auf %s)",
+	"CA bundle with system roots at %s":                         "CA-Bundle mit System-Stammzertifikaten unter %s",
+	"Adding CA to system trust store...":                        "CA wird dem System-Zertifikatsspeicher hinzugefügt...",
+	"CA trusted in system store":                                "CA im Systemspeicher als vertrauenswürdig eingetragen",
+	"Adding CA to keychain...":                                  "CA wird dem Schlüsselbund hinzugefügt...",
+	"Note: You may be prompted for your password":               "Hinweis: Möglicherweise werden Sie nach Ihrem Passwort gefragt",
+	"CA trusted in login keychain":                              "CA im Anmeldeschlüsselbund als vertrauenswürdig eingetragen",
+	"Adding CA to Firefox and Chromium profiles...":             "CA wird den Firefox- und Chromium-Profilen hinzugefügt...",
+	"Adding CA to Firefox profiles...":                          "CA wird den Firefox-Profilen hinzugefügt...",
+	"Configuring DNS resolver (systemd-resolved)...":            "DNS-Resolver (systemd-resolved) wird eingerichtet...",
+	"Configuring DNS resolver...":                               "DNS-Resolver wird eingerichtet...",
+	"systemd-resolved configured for .%s":                       "systemd-resolved für .%s eingerichtet",
+	"/etc/resolver/%s created":                                  "/etc/resolver/%s angelegt",
+	"Skipping port binding capabilities (socket activation)...": "Port-Berechtigungen werden übersprungen (Socket-Aktivierung)...",
+	"systemd will bind ports 80 and 443":                        "systemd bindet die Ports 80 und 443",
+	"Installing systemd socket units...":                        "systemd-Socket-Units werden installiert...",
+	"paw-proxy-http.socket, paw-proxy-https.socket, and paw-proxy.service installed and started": "paw-proxy-http.socket, paw-proxy-https.socket und paw-proxy.service installiert und gestartet",
+	"Setting port binding capabilities...":                                                       "Port-Berechtigungen werden gesetzt...",
+	"cap_net_bind_service set on %s":                                                             "cap_net_bind_service für %s gesetzt",
+	"Installing systemd user service...":                                                         "systemd-Benutzerdienst wird installiert...",
+	"systemd user service installed and started":                                                 "systemd-Benutzerdienst installiert und gestartet",
+	"Installing daemon...":                                                                       "Daemon wird installiert...",
+	"LaunchAgent installed and started":                                                          "LaunchAgent installiert und gestartet",
+	"Setup complete!":                                                                            "Einrichtung abgeschlossen!",
+	"Note: Restart your browser to pick up the new CA certificate.":                              "Hinweis: Starten Sie Ihren Browser neu, damit er das neue CA-Zertifikat übernimmt.",
+	"Note: macOS may show a 'Background Items Added' notification. This is normal.":              "Hinweis: macOS zeigt eventuell die Mitteilung „Hintergrundobjekte hinzugefügt“. Das ist normal.",
+	"Note: If you upgrade the binary, run 'sudo paw-proxy doctor --fix'\n" +
+		"      to restore port binding capabilities, or re-run setup with\n" +
+		"      --socket-activation to stop needing them.": "Hinweis: Führen Sie nach einem Update der Binärdatei 'sudo paw-proxy doctor --fix'\n" +
+		"      aus, um die Port-Berechtigungen wiederherzustellen, oder richten Sie\n" +
+		"      mit --socket-activation neu ein, damit sie nicht mehr nötig sind.",
+	"Usage:":                      "Verwendung:",
+	"Start dev server with HTTPS": "Dev-Server mit HTTPS starten",
+	"Custom domain name":          "Eigener Domainname",
+	"Error: setup requires sudo":  "Fehler: Die Einrichtung benötigt sudo",
+	"Run: %s":                     "Ausführen: %s",
+	"Setup failed: %v":            "Einrichtung fehlgeschlagen: %v",
+
+	// uninstall
+	"Removing daemon...":                             "Daemon wird entfernt...",
+	"Systemd socket units removed":                   "systemd-Socket-Units entfernt",
+	"Service unit not found (already removed)":       "Service-Unit nicht gefunden (bereits entfernt)",
+	"Systemd service removed":                        "systemd-Dienst entfernt",
+	"LaunchAgent not found (already removed)":        "LaunchAgent nicht gefunden (bereits entfernt)",
+	"LaunchAgent removed":                            "LaunchAgent entfernt",
+	"Removing DNS resolver...":                       "DNS-Resolver wird entfernt...",
+	"Resolved config not found (already removed)":    "systemd-resolved-Konfiguration nicht gefunden (bereits entfernt)",
+	"systemd-resolved config removed":                "systemd-resolved-Konfiguration entfernt",
+	"/etc/resolver/%s not found (already removed)":   "/etc/resolver/%s nicht gefunden (bereits entfernt)",
+	"/etc/resolver/%s removed":                       "/etc/resolver/%s entfernt",
+	"Remove CA certificate from system trust? [y/N]": "CA-Zertifikat aus dem System-Zertifikatsspeicher entfernen? [j/N]",
+	"Remove CA certificate from keychain? [y/N]":     "CA-Zertifikat aus dem Schlüsselbund entfernen? [j/N]",
+	"Removed CA from %s":                             "CA aus %s entfernt",
+	"No CA certificates found in keychain":           "Keine CA-Zertifikate im Schlüsselbund gefunden",
+	"Removed certificate %s":                         "Zertifikat %s entfernt",
+	"Support directory removed":                      "Support-Verzeichnis entfernt",
+	"CA kept in system trust store":                  "CA bleibt im System-Zertifikatsspeicher",
+	"CA kept in keychain":                            "CA bleibt im Schlüsselbund",
+	"Uninstall completed with errors.":               "Deinstallation mit Fehlern abgeschlossen.",
+	"Uninstall complete!":                            "Deinstallation abgeschlossen!",
+	"Uninstall failed: %v":                           "Deinstallation fehlgeschlagen: %v",
+
+	// status
+	"Status: ❌ Daemon not running":                                            "Status: ❌ Daemon läuft nicht",
+	"Status: ⚠️  Degraded (v%s, up %s)":                                       "Status: ⚠️  Eingeschränkt (v%s, läuft seit %s)",
+	"Status: ✅ Running (v%s, up %s)":                                          "Status: ✅ Läuft (v%s, läuft seit %s)",
+	"Stop the conflicting process; paw-proxy retries the port automatically.": "Beenden Sie den störenden Prozess; paw-proxy versucht den Port automatisch erneut.",
+	"Routes: (none)":              "Routen: (keine)",
+	"Routes:":                     "Routen:",
+	"Previews:":                   "Vorschauen:",
+	"Expires if idle for %s more": "Läuft ab, wenn noch %s lang ungenutzt",
+	"Dir: %s":                     "Verzeichnis: %s",
+	"Owner: %s":                   "Eigentümer: %s",
+	"CA Expires: %s":              "CA läuft ab: %s",
+
+	// error pages
+	"Not Found - %s":              "Nicht gefunden - %s",
+	"No app at %s":                "Keine App unter %s",
+	"Start your dev server with:": "Starten Sie Ihren Dev-Server mit:",
+	"your-dev-command":            "ihr-dev-befehl",
+	"Active Routes":               "Aktive Routen",
+	"Waiting - %s":                "Warten - %s",
+	"%s is not responding":        "%s antwortet nicht",
+	"The dev server at <code>%s</code> isn't running.":                "Der Dev-Server unter <code>%s</code> läuft nicht.",
+	"The dev server is up. Reloading...":                              "Der Dev-Server läuft. Seite wird neu geladen...",
+	"Waiting for it to start...":                                      "Warten auf den Start...",
+	"(auto-refreshing every 2s)":                                      "(aktualisiert alle 2 s)",
+	"Maintenance - %s":                                                "Wartung - %s",
+	"%s is down for maintenance":                                      "%s ist wegen Wartung nicht erreichbar",
+	"Scheduled maintenance ends at %s. This page reloads then.":       "Die geplante Wartung endet um %s. Diese Seite wird dann neu geladen.",
+	"Unauthorized - %s":                                               "Nicht autorisiert - %s",
+	"%s requires credentials":                                         "%s erfordert Zugangsdaten",
+	"This route is protected by paw-proxy. Ask the owner for access.": "Diese Route ist durch paw-proxy geschützt. Bitten Sie den Eigentümer um Zugang.",
+}
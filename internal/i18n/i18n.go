@@ -0,0 +1,121 @@
+// Package i18n translates the user-facing messages of setup, uninstall,
+// status, and the proxy's error pages. Messages are keyed by their English
+// text, so call sites stay readable and a message missing from a catalog
+// falls back to English rather than to an opaque key.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Default is the language used when none of the supported ones is asked for.
+const Default = "en"
+
+// catalogs maps a language to its translations of the English messages.
+// English needs no catalog.
+var catalogs = map[string]map[string]string{
+	"ja": ja,
+	"de": de,
+}
+
+// Supported reports whether lang has a catalog (or is English).
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok || lang == Default
+}
+
+// envLang is the language picked from the environment, looked up once.
+var envLang = sync.OnceValue(func() string {
+	return FromEnv(os.Getenv)
+})
+
+// FromEnv picks the language from the first of LC_ALL, LC_MESSAGES, and
+// LANG that is set, the way gettext does. Values like "ja_JP.UTF-8" and
+// "de_DE@euro" select "ja" and "de"; unsupported languages and the C and
+// POSIX locales select English.
+func FromEnv(getenv func(string) string) string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := getenv(name); v != "" {
+			return normalize(v)
+		}
+	}
+	return Default
+}
+
+// FromAcceptLanguage picks the first supported language in an
+// Accept-Language header, in the order listed. Quality values are
+// ignored; browsers already list languages by preference.
+func FromAcceptLanguage(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		if lang := primary(strings.TrimSpace(tag)); Supported(lang) {
+			return lang
+		}
+	}
+	return Default
+}
+
+// normalize reduces a locale or language tag to a supported language,
+// or Default.
+func normalize(v string) string {
+	if lang := primary(v); Supported(lang) {
+		return lang
+	}
+	return Default
+}
+
+// primary returns the lowercased language part of a locale ("ja_JP.UTF-8")
+// or language tag ("de-AT").
+func primary(v string) string {
+	lang := strings.ToLower(v)
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
+
+// T translates msg into the language of the environment and formats it
+// with args, like fmt.Sprintf.
+func T(msg string, args ...any) string {
+	return In(envLang(), msg, args...)
+}
+
+// In translates msg into lang and formats it with args, like fmt.Sprintf.
+func In(lang, msg string, args ...any) string {
+	if s, ok := catalogs[lang][msg]; ok {
+		msg = s
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// yes lists the answers, besides "y" and "yes", that confirm a [y/N]
+// prompt in each language.
+var yes = map[string][]string{
+	"ja": {"はい"},
+	"de": {"j", "ja"},
+}
+
+// Yes reports whether answer confirms a [y/N] prompt. "y" and "yes" always
+// do, so answering in English works whatever the language.
+func Yes(answer string) bool {
+	return yesIn(envLang(), answer)
+}
+
+func yesIn(lang, answer string) bool {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "y" || answer == "yes" {
+		return true
+	}
+	for _, s := range yes[lang] {
+		if answer == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{nil, "en"},
+		{map[string]string{"LANG": "ja_JP.UTF-8"}, "ja"},
+		{map[string]string{"LANG": "de_DE@euro"}, "de"},
+		{map[string]string{"LANG": "de-AT"}, "de"},
+		{map[string]string{"LANG": "fr_FR.UTF-8"}, "en"},
+		{map[string]string{"LANG": "C.UTF-8"}, "en"},
+		{map[string]string{"LANG": "ja_JP.UTF-8", "LC_MESSAGES": "de_DE.UTF-8"}, "de"},
+		{map[string]string{"LANG": "ja_JP.UTF-8", "LC_MESSAGES": "de_DE.UTF-8", "LC_ALL": "C"}, "en"},
+	}
+	for _, tt := range tests {
+		got := FromEnv(func(k string) string { return tt.env[k] })
+		if got != tt.want {
+			t.Errorf("FromEnv(%v) = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"ja,en-US;q=0.9", "ja"},
+		{"fr-FR, de-DE;q=0.8, en;q=0.5", "de"},
+		{"en-US,ja;q=0.5", "en"},
+		{"*", "en"},
+	}
+	for _, tt := range tests {
+		if got := FromAcceptLanguage(tt.header); got != tt.want {
+			t.Errorf("FromAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestIn(t *testing.T) {
+	if got := In("de", "Setup failed: %v", "boom"); got != "Einrichtung fehlgeschlagen: boom" {
+		t.Errorf("In(de) = %q", got)
+	}
+	if got := In("en", "Setup failed: %v", "boom"); got != "Setup failed: boom" {
+		t.Errorf("In(en) = %q", got)
+	}
+	// Messages missing from a catalog fall back to English.
+	if got := In("ja", "not in any catalog"); got != "not in any catalog" {
+		t.Errorf("In(ja) fallback = %q", got)
+	}
+}
+
+var verb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// TestCatalogsComplete checks that every catalog translates the same
+// messages and keeps their formatting verbs, in order.
+func TestCatalogsComplete(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for other, otherCatalog := range catalogs {
+			for msg := range otherCatalog {
+				if _, ok := catalog[msg]; !ok {
+					t.Errorf("%s is missing %q (translated in %s)", lang, msg, other)
+				}
+			}
+		}
+		for msg, s := range catalog {
+			if want, got := verb.FindAllString(msg, -1), verb.FindAllString(s, -1); !slices.Equal(want, got) {
+				t.Errorf("%s %q has verbs %v, want %v", lang, s, got, want)
+			}
+		}
+	}
+}
+
+func TestYes(t *testing.T) {
+	for _, answer := range []string{"y", "Y", "yes", " yes\n"} {
+		if !yesIn("de", answer) {
+			t.Errorf("yesIn(de, %q) = false", answer)
+		}
+	}
+	if !yesIn("de", "j") || !yesIn("ja", "はい") {
+		t.Error("localized answers should confirm")
+	}
+	if yesIn("en", "j") || yesIn("de", "") || yesIn("de", "n") {
+		t.Error("only confirming answers should confirm")
+	}
+}
@@ -0,0 +1,105 @@
+package i18n
+
+// ja is the Japanese catalog.
+var ja = map[string]string{
+	// setup
+	"Creating support directory...":                             "サポートディレクトリを作成しています...",
+	"Generating CA certificate...":                              "CA 証明書を生成しています...",
+	"Installed shared CA from %s":                               "%s から共有 CA をインストールしました",
+	"Shared CA already installed":                               "共有 CA はインストール済みです",
+	"CA already exists":                                         "CA は既に存在します",
+	"Generated CA certificate%s":                                "CA 証明書を生成しました%s",
+	"(limited to %s)":                                           "（%s に限定）",
+	"CA bundle with system roots at %s":                         "システムのルート証明書を含む CA バンドル: %s",
+	"Adding CA to system trust store...":                        "CA をシステムの信頼ストアに追加しています...",
+	"CA trusted in system store":                                "CA をシステムストアで信頼済みにしました",
+	"Adding CA to keychain...":                                  "CA をキーチェーンに追加しています...",
+	"Note: You may be prompted for your password":               "注意: パスワードの入力を求められる場合があります",
+	"CA trusted in login keychain":                              "CA をログインキーチェーンで信頼済みにしました",
+	"Adding CA to Firefox and Chromium profiles...":             "CA を Firefox と Chromium のプロファイルに追加しています...",
+	"Adding CA to Firefox profiles...":                          "CA を Firefox のプロファイルに追加しています...",
+	"Configuring DNS resolver (systemd-resolved)...":            "DNS リゾルバ (systemd-resolved) を設定しています...",
+	"Configuring DNS resolver...":                               "DNS リゾルバを設定しています...",
+	"systemd-resolved configured for .%s":                       "systemd-resolved を .%s 用に設定しました",
+	"/etc/resolver/%s created":                                  "/etc/resolver/%s を作成しました",
+	"Skipping port binding capabilities (socket activation)...": "ポートバインド権限の設定をスキップします (ソケットアクティベーション)...",
+	"systemd will bind ports 80 and 443":                        "ポート 80 と 443 は systemd がバインドします",
+	"Installing systemd socket units...":                        "systemd ソケットユニットをインストールしています...",
+	"paw-proxy-http.socket, paw-proxy-https.socket, and paw-proxy.service installed and started": "paw-proxy-http.socket、paw-proxy-https.socket、paw-proxy.service をインストールして起動しました",
+	"Setting port binding capabilities...":                                                       "ポートバインド権限を設定しています...",
+	"cap_net_bind_service set on %s":                                                             "%s に cap_net_bind_service を設定しました",
+	"Installing systemd user service...":                                                         "systemd ユーザーサービスをインストールしています...",
+	"systemd user service installed and started":                                                 "systemd ユーザーサービスをインストールして起動しました",
+	"Installing daemon...":                                                                       "デーモンをインストールしています...",
+	"LaunchAgent installed and started":                                                          "LaunchAgent をインストールして起動しました",
+	"Setup complete!":                                                                            "セットアップが完了しました!",
+	"Note: Restart your browser to pick up the new CA certificate.":                              "注意: 新しい CA 証明書を読み込むためにブラウザを再起動してください。",
+	"Note: macOS may show a 'Background Items Added' notification. This is normal.":              "注意: macOS が「バックグラウンド項目が追加されました」と通知することがありますが、正常な動作です。",
+	"Note: If you upgrade the binary, run 'sudo paw-proxy doctor --fix'\n" +
+		"      to restore port binding capabilities, or re-run setup with\n" +
+		"      --socket-activation to stop needing them.": "注意: バイナリを更新した場合は 'sudo paw-proxy doctor --fix' を実行して\n" +
+		"      ポートバインド権限を復元するか、--socket-activation を付けて\n" +
+		"      セットアップを再実行すると権限が不要になります。",
+	"Usage:":                      "使い方:",
+	"Start dev server with HTTPS": "HTTPS で開発サーバーを起動",
+	"Custom domain name":          "ドメイン名を指定",
+	"Error: setup requires sudo":  "エラー: セットアップには sudo が必要です",
+	"Run: %s":                     "実行: %s",
+	"Setup failed: %v":            "セットアップに失敗しました: %v",
+
+	// uninstall
+	"Removing daemon...":                             "デーモンを削除しています...",
+	"Systemd socket units removed":                   "systemd ソケットユニットを削除しました",
+	"Service unit not found (already removed)":       "サービスユニットが見つかりません (削除済み)",
+	"Systemd service removed":                        "systemd サービスを削除しました",
+	"LaunchAgent not found (already removed)":        "LaunchAgent が見つかりません (削除済み)",
+	"LaunchAgent removed":                            "LaunchAgent を削除しました",
+	"Removing DNS resolver...":                       "DNS リゾルバを削除しています...",
+	"Resolved config not found (already removed)":    "systemd-resolved の設定が見つかりません (削除済み)",
+	"systemd-resolved config removed":                "systemd-resolved の設定を削除しました",
+	"/etc/resolver/%s not found (already removed)":   "/etc/resolver/%s が見つかりません (削除済み)",
+	"/etc/resolver/%s removed":                       "/etc/resolver/%s を削除しました",
+	"Remove CA certificate from system trust? [y/N]": "CA 証明書をシステムの信頼ストアから削除しますか? [y/N]",
+	"Remove CA certificate from keychain? [y/N]":     "CA 証明書をキーチェーンから削除しますか? [y/N]",
+	"Removed CA from %s":                             "%s から CA を削除しました",
+	"No CA certificates found in keychain":           "キーチェーンに CA 証明書が見つかりません",
+	"Removed certificate %s":                         "証明書 %s を削除しました",
+	"Support directory removed":                      "サポートディレクトリを削除しました",
+	"CA kept in system trust store":                  "CA はシステムの信頼ストアに残しました",
+	"CA kept in keychain":                            "CA はキーチェーンに残しました",
+	"Uninstall completed with errors.":               "アンインストールは完了しましたが、エラーがありました。",
+	"Uninstall complete!":                            "アンインストールが完了しました!",
+	"Uninstall failed: %v":                           "アンインストールに失敗しました: %v",
+
+	// status
+	"Status: ❌ Daemon not running":                                            "状態: ❌ デーモンは停止しています",
+	"Status: ⚠️  Degraded (v%s, up %s)":                                       "状態: ⚠️  一部に問題があります (v%s、稼働 %s)",
+	"Status: ✅ Running (v%s, up %s)":                                          "状態: ✅ 稼働中 (v%s、稼働 %s)",
+	"Stop the conflicting process; paw-proxy retries the port automatically.": "競合しているプロセスを停止してください。paw-proxy は自動的にポートを再試行します。",
+	"Routes: (none)":              "ルート: (なし)",
+	"Routes:":                     "ルート:",
+	"Previews:":                   "プレビュー:",
+	"Expires if idle for %s more": "あと %s アイドル状態が続くと期限切れになります",
+	"Dir: %s":                     "ディレクトリ: %s",
+	"Owner: %s":                   "所有者: %s",
+	"CA Expires: %s":              "CA の有効期限: %s",
+
+	// error pages
+	"Not Found - %s":              "見つかりません - %s",
+	"No app at %s":                "%s にアプリはありません",
+	"Start your dev server with:": "次のコマンドで開発サーバーを起動してください:",
+	"your-dev-command":            "開発コマンド",
+	"Active Routes":               "有効なルート",
+	"Waiting - %s":                "待機中 - %s",
+	"%s is not responding":        "%s が応答しません",
+	"The dev server at <code>%s</code> isn't running.":                "<code>%s</code> の開発サーバーは起動していません。",
+	"The dev server is up. Reloading...":                              "開発サーバーが起動しました。再読み込みしています...",
+	"Waiting for it to start...":                                      "起動を待っています...",
+	"(auto-refreshing every 2s)":                                      "(2 秒ごとに自動更新)",
+	"Maintenance - %s":                                                "メンテナンス中 - %s",
+	"%s is down for maintenance":                                      "%s はメンテナンス中です",
+	"Scheduled maintenance ends at %s. This page reloads then.":       "予定されたメンテナンスは %s に終了します。終了時にこのページを再読み込みします。",
+	"Unauthorized - %s":                                               "認証が必要です - %s",
+	"%s requires credentials":                                         "%s には認証情報が必要です",
+	"This route is protected by paw-proxy. Ask the owner for access.": "このルートは paw-proxy で保護されています。アクセス権は所有者に問い合わせてください。",
+}
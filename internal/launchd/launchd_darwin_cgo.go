@@ -24,11 +24,12 @@ const (
 	enoent = 2 // socket name not found in plist
 )
 
-// ActivateSocket asks launchd for a pre-bound socket by name.
-// Returns (listener, true, nil) on success.
+// ActivateSocket asks launchd for the pre-bound sockets under name, one
+// per address the plist declares for it.
+// Returns (listeners, true, nil) on success.
 // Returns (nil, false, nil) when not launched by launchd (ESRCH/ENOENT).
 // Returns (nil, false, err) on unexpected errors.
-func ActivateSocket(name string) (net.Listener, bool, error) {
+func ActivateSocket(name string) ([]net.Listener, bool, error) {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
@@ -53,32 +54,45 @@ func ActivateSocket(name string) (net.Listener, bool, error) {
 		return nil, false, fmt.Errorf("launch_activate_socket(%q): returned 0 fds", name)
 	}
 
-	// Use the first file descriptor (plist declares one socket per name)
 	fdSlice := unsafe.Slice((*C.int)(fds), int(cnt))
-	fd := int(fdSlice[0])
-
-	// Close any extra fds we won't use
-	for i := 1; i < int(cnt); i++ {
-		_ = os.NewFile(uintptr(fdSlice[i]), "").Close()
+	var listeners []net.Listener
+	for i, cfd := range fdSlice {
+		listener, err := fileListener(name, int(cfd))
+		if err != nil {
+			// Close what was wrapped and the fds not yet reached.
+			for _, l := range listeners {
+				l.Close()
+			}
+			for _, rest := range fdSlice[i+1:] {
+				closeFD(int(rest))
+			}
+			return nil, false, err
+		}
+		listeners = append(listeners, listener)
 	}
+	return listeners, true, nil
+}
 
+// fileListener validates one socket launchd passed as fd and wraps it.
+// fd is closed either way.
+func fileListener(name string, fd int) (net.Listener, error) {
 	// SECURITY: Validate fd is a TCP stream socket to prevent launchd from
 	// passing UDP/Unix sockets that break accept() semantics.
 	sockType, err := syscall.GetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_TYPE)
 	if err != nil {
 		closeFD(fd)
-		return nil, false, fmt.Errorf("getsockopt SO_TYPE for %q fd %d: %w", name, fd, err)
+		return nil, fmt.Errorf("getsockopt SO_TYPE for %q fd %d: %w", name, fd, err)
 	}
 	if sockType != syscall.SOCK_STREAM {
 		closeFD(fd)
-		return nil, false, fmt.Errorf("launchd socket %q: expected SOCK_STREAM (%d), got %d", name, syscall.SOCK_STREAM, sockType)
+		return nil, fmt.Errorf("launchd socket %q: expected SOCK_STREAM (%d), got %d", name, syscall.SOCK_STREAM, sockType)
 	}
 
 	// SECURITY: Ensure the socket is in a listening state even if launchd was
 	// misconfigured; on BSD, listen() on an already-listening socket is safe.
 	if err := syscall.Listen(fd, syscall.SOMAXCONN); err != nil {
 		closeFD(fd)
-		return nil, false, fmt.Errorf("listen() on launchd socket %q fd %d: %w", name, fd, err)
+		return nil, fmt.Errorf("listen() on launchd socket %q fd %d: %w", name, fd, err)
 	}
 
 	// Wrap fd as net.Listener. net.FileListener dups the fd,
@@ -87,17 +101,17 @@ func ActivateSocket(name string) (net.Listener, bool, error) {
 	listener, err := net.FileListener(f)
 	f.Close()
 	if err != nil {
-		return nil, false, fmt.Errorf("net.FileListener for %q: %w", name, err)
+		return nil, fmt.Errorf("net.FileListener for %q: %w", name, err)
 	}
 
 	// SECURITY: Verify the listener has a valid bound address to reject
 	// 0.0.0.0:0 sockets from misconfigured launchd activation.
 	if tcpAddr, ok := listener.Addr().(*net.TCPAddr); ok && tcpAddr.Port == 0 {
 		listener.Close()
-		return nil, false, fmt.Errorf("launchd socket %q has invalid address %s", name, listener.Addr())
+		return nil, fmt.Errorf("launchd socket %q has invalid address %s", name, listener.Addr())
 	}
 
-	return listener, true, nil
+	return listener, nil
 }
 
 // closeFD closes a raw file descriptor by wrapping it in os.File.
@@ -7,6 +7,6 @@ import "net"
 
 // ActivateSocket is a stub for builds without cgo (e.g., cross-compilation).
 // Always returns (nil, false, nil) to trigger fallback to direct binding.
-func ActivateSocket(_ string) (net.Listener, bool, error) {
+func ActivateSocket(_ string) ([]net.Listener, bool, error) {
 	return nil, false, nil
 }
@@ -0,0 +1,32 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// errUnreachable is returned by dials that found nothing listening on the
+// upstream port, so the request never reached it.
+var errUnreachable = errors.New("upstream unreachable")
+
+type fallbacksKey struct{}
+
+// WithFallbacks returns a context that makes ServeHTTP try upstreams in
+// order when the upstream it was given can't be reached.
+func WithFallbacks(ctx context.Context, upstreams []string) context.Context {
+	return context.WithValue(ctx, fallbacksKey{}, upstreams)
+}
+
+func fallbacksFrom(ctx context.Context) []string {
+	upstreams, _ := ctx.Value(fallbacksKey{}).([]string)
+	return upstreams
+}
+
+// canFailOver reports whether req, which failed with err, can be sent to
+// another upstream: the failed one was never reached, and there's no body
+// the transport has already consumed. Incoming HTTP/2 requests have a
+// Body even when it is empty, so the length is what tells.
+func canFailOver(req *http.Request, err error) bool {
+	return req.ContentLength == 0 && errors.Is(err, errUnreachable)
+}
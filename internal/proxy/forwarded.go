@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type trustForwardedKey struct{}
+
+// WithTrustForwarded returns a context that makes ServeHTTP keep the
+// forwarding headers a loopback client sent (e.g. a tunnel or another
+// local proxy) and append to them, instead of replacing them.
+func WithTrustForwarded(ctx context.Context) context.Context {
+	return context.WithValue(ctx, trustForwardedKey{}, true)
+}
+
+func trustForwardedFrom(ctx context.Context) bool {
+	on, _ := ctx.Value(trustForwardedKey{}).(bool)
+	return on
+}
+
+type plainHTTPKey struct{}
+
+// WithPlainHTTP returns a context that marks the request as having
+// arrived over plain HTTP, so forwarding headers say proto=http.
+func WithPlainHTTP(ctx context.Context) context.Context {
+	return context.WithValue(ctx, plainHTTPKey{}, true)
+}
+
+// forwardedProto returns the scheme the client used to reach the daemon.
+func forwardedProto(ctx context.Context) string {
+	if plain, _ := ctx.Value(plainHTTPKey{}).(bool); plain {
+		return "http"
+	}
+	return "https"
+}
+
+type upstreamHostKey struct{}
+
+// WithUpstreamHost returns a context that makes ServeHTTP send host as the
+// upstream request's Host header instead of the client's. Forwarding
+// headers still carry the client's host.
+func WithUpstreamHost(ctx context.Context, host string) context.Context {
+	return context.WithValue(ctx, upstreamHostKey{}, host)
+}
+
+func upstreamHostFrom(ctx context.Context) string {
+	host, _ := ctx.Value(upstreamHostKey{}).(string)
+	return host
+}
+
+// setForwardingHeaders sets X-Forwarded-For/Proto/Host and the RFC 7239
+// Forwarded header on h for a request that arrived as r.
+//
+// SECURITY: Incoming values are only kept when the route trusts them and
+// the peer is a loopback address. paw-proxy listens on loopback unless
+// given --listen-addr, and peers reaching it that way (or any other
+// non-loopback peer) never get an X-Forwarded-For entry.
+func setForwardingHeaders(h http.Header, r *http.Request, trust bool) {
+	clientIP := ""
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			clientIP = host
+		}
+	}
+	trust = trust && clientIP != ""
+	proto := forwardedProto(r.Context())
+
+	if !trust {
+		h.Del("X-Forwarded-For")
+		h.Del("Forwarded")
+	}
+	if clientIP != "" {
+		h.Set("X-Forwarded-For", appendList(h.Values("X-Forwarded-For"), clientIP))
+	}
+	if !trust || h.Get("X-Forwarded-Proto") == "" {
+		h.Set("X-Forwarded-Proto", proto)
+	}
+	if !trust || h.Get("X-Forwarded-Host") == "" {
+		h.Set("X-Forwarded-Host", r.Host)
+	}
+	h.Set("Forwarded", appendList(h.Values("Forwarded"), forwardedElement(clientIP, r.Host, proto)))
+}
+
+// appendList adds v to the comma-separated values of a list header.
+func appendList(prior []string, v string) string {
+	if len(prior) == 0 {
+		return v
+	}
+	return strings.Join(prior, ", ") + ", " + v
+}
+
+// forwardedElement renders one RFC 7239 forwarded-element for a client at
+// ip requesting host over proto.
+func forwardedElement(ip, host, proto string) string {
+	node := "unknown"
+	if ip != "" {
+		node = ip
+		if strings.Contains(ip, ":") {
+			node = "[" + ip + "]"
+		}
+	}
+	return "for=" + forwardedValue(node) + ";host=" + forwardedValue(host) + ";proto=" + proto
+}
+
+// forwardedValue returns v as a token, or as a quoted-string when it
+// contains characters a token can't (such as ':' or '[').
+func forwardedValue(v string) string {
+	if v != "" && strings.IndexFunc(v, func(c rune) bool { return !isTokenChar(c) }) < 0 {
+		return v
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range v {
+		if c == '"' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// isTokenChar reports whether c is an RFC 7230 tchar.
+func isTokenChar(c rune) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", c)
+}
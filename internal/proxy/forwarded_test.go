@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetForwardingHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		trust      bool
+		plain      bool
+		incoming   map[string]string
+		want       map[string]string
+	}{
+		{
+			name:       "fresh request",
+			remoteAddr: "127.0.0.1:5000",
+			want: map[string]string{
+				"X-Forwarded-For": "127.0.0.1",
+				"Forwarded":       "for=127.0.0.1;host=myapp.test;proto=https",
+			},
+		},
+		{
+			name:       "untrusted values replaced",
+			remoteAddr: "127.0.0.1:5000",
+			incoming: map[string]string{
+				"X-Forwarded-For":   "203.0.113.7",
+				"X-Forwarded-Proto": "http",
+				"Forwarded":         "for=203.0.113.7",
+			},
+			want: map[string]string{
+				"X-Forwarded-For":   "127.0.0.1",
+				"X-Forwarded-Proto": "https",
+				"Forwarded":         "for=127.0.0.1;host=myapp.test;proto=https",
+			},
+		},
+		{
+			name:       "trusted values appended",
+			remoteAddr: "127.0.0.1:5000",
+			trust:      true,
+			incoming: map[string]string{
+				"X-Forwarded-For":   "203.0.113.7",
+				"X-Forwarded-Proto": "http",
+				"X-Forwarded-Host":  "public.example.com",
+				"Forwarded":         "for=203.0.113.7;proto=http",
+			},
+			want: map[string]string{
+				"X-Forwarded-For":   "203.0.113.7, 127.0.0.1",
+				"X-Forwarded-Proto": "http",
+				"X-Forwarded-Host":  "public.example.com",
+				"Forwarded":         "for=203.0.113.7;proto=http, for=127.0.0.1;host=myapp.test;proto=https",
+			},
+		},
+		{
+			name:       "trust ignored for non-loopback client",
+			remoteAddr: "192.168.1.5:5000",
+			trust:      true,
+			incoming: map[string]string{
+				"X-Forwarded-For": "203.0.113.7",
+				"Forwarded":       "for=203.0.113.7",
+			},
+			want: map[string]string{
+				"X-Forwarded-For": "",
+				"Forwarded":       "for=unknown;host=myapp.test;proto=https",
+			},
+		},
+		{
+			name:       "IPv6 client quoted",
+			remoteAddr: "[::1]:5000",
+			want: map[string]string{
+				"X-Forwarded-For": "::1",
+				"Forwarded":       `for="[::1]";host=myapp.test;proto=https`,
+			},
+		},
+		{
+			name:       "plain HTTP route",
+			remoteAddr: "127.0.0.1:5000",
+			plain:      true,
+			want: map[string]string{
+				"X-Forwarded-Proto": "http",
+				"Forwarded":         "for=127.0.0.1;host=myapp.test;proto=http",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "https://myapp.test/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.plain {
+				r = r.WithContext(WithPlainHTTP(r.Context()))
+			}
+			h := http.Header{}
+			for k, v := range tt.incoming {
+				h.Set(k, v)
+			}
+			setForwardingHeaders(h, r, tt.trust)
+			for k, want := range tt.want {
+				if got := h.Get(k); got != want {
+					t.Errorf("%s = %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestForwardedValue(t *testing.T) {
+	tests := map[string]string{
+		"myapp.test":      "myapp.test",
+		"myapp.test:8443": `"myapp.test:8443"`,
+		`a"b`:             `"a\"b"`,
+		"":                `""`,
+	}
+	for in, want := range tests {
+		if got := forwardedValue(in); got != want {
+			t.Errorf("forwardedValue(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// mirrorMaxBody is the largest request body copied to a mirror. Requests
+// with bigger bodies are not mirrored, so a large upload isn't held in
+// memory twice.
+const mirrorMaxBody = 1 << 20
+
+// mirrorTimeout bounds each mirrored request, including reading the
+// mirror's response.
+const mirrorTimeout = 30 * time.Second
+
+// maxMirrorsInFlight caps mirrored requests outstanding at once. Beyond
+// it, requests are not mirrored rather than queued behind a slow mirror.
+const maxMirrorsInFlight = 64
+
+// Mirror sends a copy of r to the upstream at mirror in the background
+// and discards the response. It must be called before ServeHTTP, since it
+// buffers r's body so both requests can read it. WebSocket upgrades and
+// requests with bodies over mirrorMaxBody are not mirrored.
+func (p *Proxy) Mirror(r *http.Request, mirror string) {
+	if isWebSocket(r) || r.ContentLength > mirrorMaxBody {
+		return
+	}
+
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, mirrorMaxBody+1))
+		if err != nil || len(body) > mirrorMaxBody {
+			// Hand the primary request what was read plus the rest.
+			r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+			return
+		}
+		r.Body = readCloser{bytes.NewReader(body), r.Body}
+	}
+
+	select {
+	case p.mirrors <- struct{}{}:
+	default:
+		log.Printf("proxy: mirror to %s: too many requests in flight, skipped", mirror)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mirrorTimeout)
+	outReq := r.Clone(ctx)
+	outReq.URL.Scheme = "http"
+	outReq.URL.Host = mirror
+	outReq.RequestURI = ""
+	outReq.Body = http.NoBody
+	if body != nil {
+		outReq.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	for _, h := range hopByHopHeaders {
+		outReq.Header.Del(h)
+	}
+	setForwardingHeaders(outReq.Header, r, trustForwardedFrom(r.Context()))
+
+	go func() {
+		defer func() { <-p.mirrors }()
+		defer cancel()
+		resp, err := p.transport.RoundTrip(outReq)
+		if err != nil {
+			log.Printf("proxy: mirror to %s: %v", mirror, err)
+			return
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+}
+
+// readCloser reads from one reader and closes another, for replacing a
+// body that has been partly read.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,61 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMirror(t *testing.T) {
+	mirrored := make(chan string, 1)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mirrored <- r.Method + " " + r.URL.RequestURI() + " " + r.Host + " " + string(body)
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer mirror.Close()
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer primary.Close()
+
+	p := New()
+	send := func(body string) string {
+		t.Helper()
+		req := httptest.NewRequest("POST", "https://myapp.test/orders?x=1", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		p.Mirror(req, strings.TrimPrefix(mirror.URL, "http://"))
+		p.ServeHTTP(w, req, strings.TrimPrefix(primary.URL, "http://"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("primary status = %d, the mirror's response must not leak", w.Code)
+		}
+		return w.Body.String()
+	}
+
+	if got := send("hello"); got != "hello" {
+		t.Errorf("primary body = %q", got)
+	}
+	select {
+	case got := <-mirrored:
+		if want := "POST /orders?x=1 myapp.test hello"; got != want {
+			t.Errorf("mirror got %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not mirrored")
+	}
+
+	// Bodies over the cap go to the primary intact and are not mirrored.
+	big := strings.Repeat("x", mirrorMaxBody+10)
+	if got := send(big); got != big {
+		t.Errorf("primary got %d bytes of an oversized body, want %d", len(got), len(big))
+	}
+	select {
+	case <-mirrored:
+		t.Error("oversized request was mirrored")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
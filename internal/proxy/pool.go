@@ -0,0 +1,205 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// PoolConfig tunes the keep-alive pool kept to a route's upstream. The
+// zero value uses the proxy defaults.
+type PoolConfig struct {
+	// MaxIdlePerHost caps idle connections kept to one upstream. Negative
+	// disables keep-alives, so every request dials a fresh connection.
+	MaxIdlePerHost int
+	// IdleTimeout closes a pooled connection after it sits unused this long.
+	IdleTimeout time.Duration
+}
+
+const (
+	defaultMaxIdlePerHost  = http.DefaultMaxIdleConnsPerHost
+	defaultIdleConnTimeout = 90 * time.Second
+)
+
+type poolConfigKey struct{}
+
+// WithPool returns a context that makes ServeHTTP use a connection pool
+// tuned by cfg instead of the shared default pool.
+func WithPool(ctx context.Context, cfg PoolConfig) context.Context {
+	return context.WithValue(ctx, poolConfigKey{}, cfg)
+}
+
+func poolConfigFrom(ctx context.Context) PoolConfig {
+	cfg, _ := ctx.Value(poolConfigKey{}).(PoolConfig)
+	return cfg
+}
+
+// newTransport builds an upstream transport for cfg whose connections are
+// counted in stats.
+func newTransport(cfg PoolConfig, stats *poolStats) *http.Transport {
+	t := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialUpstream(addr)
+			if err != nil {
+				return nil, err
+			}
+			return stats.track(addr, conn), nil
+		},
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: defaultMaxIdlePerHost,
+		IdleConnTimeout:     defaultIdleConnTimeout,
+		DisableCompression:  true,
+	}
+	if cfg.MaxIdlePerHost < 0 {
+		t.DisableKeepAlives = true
+	} else if cfg.MaxIdlePerHost > 0 {
+		t.MaxIdleConnsPerHost = cfg.MaxIdlePerHost
+	}
+	if cfg.IdleTimeout > 0 {
+		t.IdleConnTimeout = cfg.IdleTimeout
+	}
+	return t
+}
+
+// transportFor returns the transport for cfg, creating it on first use.
+func (p *Proxy) transportFor(cfg PoolConfig) *http.Transport {
+	if cfg == (PoolConfig{}) {
+		return p.transport
+	}
+	p.poolsMu.Lock()
+	defer p.poolsMu.Unlock()
+	t, ok := p.pools[cfg]
+	if !ok {
+		t = newTransport(cfg, p.stats)
+		p.pools[cfg] = t
+	}
+	return t
+}
+
+// PoolStats describes the keep-alive connections to one upstream.
+type PoolStats struct {
+	// Open is the number of connections currently open.
+	Open int `json:"open"`
+	// Idle is the number of open connections not serving a request.
+	Idle int `json:"idle"`
+	// Dials counts new connections since the daemon started.
+	Dials int64 `json:"dials"`
+	// Reused counts requests served on an existing connection.
+	Reused int64 `json:"reused"`
+	// DialsPerSec is the dial rate over the last dialRateWindow.
+	DialsPerSec float64 `json:"dialsPerSec"`
+}
+
+// dialRateWindow is the period DialsPerSec is averaged over.
+const dialRateWindow = 10 * time.Second
+
+type upstreamStats struct {
+	open, active  int
+	dials, reused int64
+	recentDials   []time.Time
+}
+
+// poolStats tracks connection use per upstream address.
+type poolStats struct {
+	mu  sync.Mutex
+	ups map[string]*upstreamStats
+}
+
+func newPoolStats() *poolStats {
+	return &poolStats{ups: make(map[string]*upstreamStats)}
+}
+
+// get returns the stats for addr. Caller must hold s.mu.
+func (s *poolStats) get(addr string) *upstreamStats {
+	u, ok := s.ups[addr]
+	if !ok {
+		u = &upstreamStats{}
+		s.ups[addr] = u
+	}
+	return u
+}
+
+// track counts a newly dialed connection and wraps it so closing it is
+// counted too.
+func (s *poolStats) track(addr string, conn net.Conn) net.Conn {
+	now := time.Now()
+	s.mu.Lock()
+	u := s.get(addr)
+	u.open++
+	u.dials++
+	u.recentDials = append(pruneDials(u.recentDials, now), now)
+	s.mu.Unlock()
+	return &trackedConn{Conn: conn, onClose: func() {
+		s.mu.Lock()
+		s.get(addr).open--
+		s.mu.Unlock()
+	}}
+}
+
+// acquired records that a request got a connection to addr.
+func (s *poolStats) acquired(addr string, reused bool) {
+	s.mu.Lock()
+	u := s.get(addr)
+	u.active++
+	if reused {
+		u.reused++
+	}
+	s.mu.Unlock()
+}
+
+// released records that a request finished with its connection to addr.
+func (s *poolStats) released(addr string) {
+	s.mu.Lock()
+	s.get(addr).active--
+	s.mu.Unlock()
+}
+
+func (s *poolStats) snapshot() map[string]PoolStats {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]PoolStats, len(s.ups))
+	for addr, u := range s.ups {
+		u.recentDials = pruneDials(u.recentDials, now)
+		idle := u.open - u.active
+		if idle < 0 {
+			idle = 0
+		}
+		out[addr] = PoolStats{
+			Open:        u.open,
+			Idle:        idle,
+			Dials:       u.dials,
+			Reused:      u.reused,
+			DialsPerSec: float64(len(u.recentDials)) / dialRateWindow.Seconds(),
+		}
+	}
+	return out
+}
+
+// pruneDials drops dial times older than dialRateWindow.
+func pruneDials(dials []time.Time, now time.Time) []time.Time {
+	i := 0
+	for i < len(dials) && now.Sub(dials[i]) > dialRateWindow {
+		i++
+	}
+	return dials[i:]
+}
+
+// PoolStats returns connection pool statistics keyed by upstream address.
+func (p *Proxy) PoolStats() map[string]PoolStats {
+	return p.stats.snapshot()
+}
+
+// trackedConn calls onClose the first time the connection is closed.
+type trackedConn struct {
+	net.Conn
+	once    sync.Once
+	onClose func()
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(c.onClose)
+	return c.Conn.Close()
+}
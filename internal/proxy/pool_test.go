@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProxy_PoolStats(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+	addr := upstream.URL[7:]
+
+	p := New()
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "https://myapp.test/", nil)
+		req.RemoteAddr = "127.0.0.1:9999"
+		p.ServeHTTP(httptest.NewRecorder(), req, addr)
+	}
+
+	stats, ok := p.PoolStats()[addr]
+	if !ok {
+		t.Fatalf("no pool stats for %s", addr)
+	}
+	if stats.Dials != 1 || stats.Reused != 2 {
+		t.Errorf("dials=%d reused=%d, want 1 and 2", stats.Dials, stats.Reused)
+	}
+	if stats.Open != 1 || stats.Idle != 1 {
+		t.Errorf("open=%d idle=%d, want 1 and 1", stats.Open, stats.Idle)
+	}
+	if stats.DialsPerSec <= 0 {
+		t.Errorf("DialsPerSec = %v, want > 0", stats.DialsPerSec)
+	}
+}
+
+func TestProxy_PoolKeepAlivesDisabled(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+	addr := upstream.URL[7:]
+
+	p := New()
+	ctx := WithPool(context.Background(), PoolConfig{MaxIdlePerHost: -1})
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "https://myapp.test/", nil).WithContext(ctx)
+		req.RemoteAddr = "127.0.0.1:9999"
+		p.ServeHTTP(httptest.NewRecorder(), req, addr)
+	}
+
+	stats := p.PoolStats()[addr]
+	if stats.Dials != 2 || stats.Reused != 0 {
+		t.Errorf("dials=%d reused=%d, want 2 and 0", stats.Dials, stats.Reused)
+	}
+	if stats.Open != 0 {
+		t.Errorf("open = %d, want 0 with keep-alives disabled", stats.Open)
+	}
+}
+
+func TestNewTransport_PoolConfig(t *testing.T) {
+	tr := newTransport(PoolConfig{MaxIdlePerHost: 8, IdleTimeout: 5 * time.Second}, newPoolStats())
+	if tr.MaxIdleConnsPerHost != 8 || tr.IdleConnTimeout != 5*time.Second {
+		t.Errorf("got MaxIdleConnsPerHost=%d IdleConnTimeout=%v", tr.MaxIdleConnsPerHost, tr.IdleConnTimeout)
+	}
+	def := newTransport(PoolConfig{}, newPoolStats())
+	if def.MaxIdleConnsPerHost != defaultMaxIdlePerHost || def.IdleConnTimeout != defaultIdleConnTimeout {
+		t.Errorf("default transport got MaxIdleConnsPerHost=%d IdleConnTimeout=%v", def.MaxIdleConnsPerHost, def.IdleConnTimeout)
+	}
+}
+
+func TestPruneDials(t *testing.T) {
+	now := time.Now()
+	dials := []time.Time{now.Add(-time.Minute), now.Add(-dialRateWindow / 2), now}
+	if got := pruneDials(dials, now); len(got) != 2 {
+		t.Errorf("pruneDials kept %d, want 2", len(got))
+	}
+}
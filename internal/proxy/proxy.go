@@ -8,7 +8,9 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/alexcatdad/paw-proxy/internal/errorpage"
@@ -16,6 +18,17 @@ import (
 
 type Proxy struct {
 	transport *http.Transport
+	// ppTransport serves routes that send a PROXY protocol header.
+	ppTransport *http.Transport
+	// pools holds transports for routes with a tuned PoolConfig.
+	poolsMu sync.Mutex
+	pools   map[PoolConfig]*http.Transport
+	stats   *poolStats
+	// mirrors limits mirrored requests in flight.
+	mirrors chan struct{}
+	// upstreamFailed, if set, is told about requests the upstream could
+	// not answer.
+	upstreamFailed func(host, upstream string, err error)
 }
 
 func isLoopbackHost(host string) bool {
@@ -43,26 +56,35 @@ func dialLoopbackPort(port string, timeout time.Duration) (net.Conn, error) {
 	if ipv6Err == nil {
 		return conn, nil
 	}
-	return nil, fmt.Errorf("upstream unreachable: IPv4: %v, IPv6: %v", ipv4Err, ipv6Err)
+	return nil, fmt.Errorf("%w: IPv4: %v, IPv6: %v", errUnreachable, ipv4Err, ipv6Err)
+}
+
+// dialUpstream validates addr and connects to it on loopback.
+func dialUpstream(addr string) (net.Conn, error) {
+	port, err := extractAndValidateUpstreamPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	return dialLoopbackPort(port, 2*time.Second)
 }
 
 func New() *Proxy {
+	stats := newPoolStats()
 	return &Proxy{
-		transport: &http.Transport{
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				port, err := extractAndValidateUpstreamPort(addr)
-				if err != nil {
-					return nil, err
-				}
-				return dialLoopbackPort(port, 2*time.Second)
-			},
-			MaxIdleConns:       100,
-			IdleConnTimeout:    90 * time.Second,
-			DisableCompression: true,
-		},
+		transport:   newTransport(PoolConfig{}, stats),
+		ppTransport: newProxyProtocolTransport(),
+		pools:       make(map[PoolConfig]*http.Transport),
+		stats:       stats,
+		mirrors:     make(chan struct{}, maxMirrorsInFlight),
 	}
 }
 
+// SetUpstreamErrorFunc sets a function called, in the request's goroutine,
+// whenever a request fails because its upstream could not be reached.
+func (p *Proxy) SetUpstreamErrorFunc(fn func(host, upstream string, err error)) {
+	p.upstreamFailed = fn
+}
+
 // hopByHopHeaders are headers that apply to a single transport-level connection
 // and must not be forwarded by proxies (RFC 2616 Section 13.5.1).
 var hopByHopHeaders = []string{
@@ -75,6 +97,33 @@ var hopByHopHeaders = []string{
 	"Transfer-Encoding",
 }
 
+// prepareHeader turns the headers of client request r, in h, into the
+// ones sent upstream.
+func prepareHeader(h http.Header, r *http.Request) {
+	// Strip hop-by-hop headers before forwarding
+	toRemove := make([]string, len(hopByHopHeaders))
+	copy(toRemove, hopByHopHeaders)
+	if connHeader := h.Get("Connection"); connHeader != "" {
+		for _, name := range strings.Split(connHeader, ",") {
+			toRemove = append(toRemove, strings.TrimSpace(name))
+		}
+	}
+	for _, name := range toRemove {
+		h.Del(name)
+	}
+
+	// Set forwarding headers
+	setForwardingHeaders(h, r, trustForwardedFrom(r.Context()))
+}
+
+// OutboundHeader returns the headers ServeHTTP would send upstream for r,
+// for showing what an app behind the proxy receives.
+func OutboundHeader(r *http.Request) http.Header {
+	h := r.Header.Clone()
+	prepareHeader(h, r)
+	return h
+}
+
 func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request, upstream string) {
 	// Check for WebSocket upgrade
 	if isWebSocket(r) {
@@ -89,42 +138,98 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request, upstream strin
 	outReq.RequestURI = ""
 	// NOTE: We intentionally do NOT set outReq.Host = upstream.
 	// The original Host header from the client is preserved so upstream
-	// servers see the expected hostname (e.g. "myapp.test").
+	// servers see the expected hostname (e.g. "myapp.test"), unless the
+	// route asked for another one.
+	if host := upstreamHostFrom(r.Context()); host != "" {
+		outReq.Host = host
+	}
 
-	// Strip hop-by-hop headers before forwarding
-	toRemove := make([]string, len(hopByHopHeaders))
-	copy(toRemove, hopByHopHeaders)
-	if connHeader := outReq.Header.Get("Connection"); connHeader != "" {
-		for _, h := range strings.Split(connHeader, ",") {
-			toRemove = append(toRemove, strings.TrimSpace(h))
+	prepareHeader(outReq.Header, r)
+
+	// A throttled route pays its latency up front, as one round trip, and
+	// sends the request body at the upload rate. The server's read and
+	// write timeouts assume full speed, so they are lifted: the paced
+	// copies set an idle deadline per chunk instead, and the request's
+	// context still ends if the client goes away.
+	throttle, throttled := throttleFrom(r.Context())
+	var rc *http.ResponseController
+	if throttled {
+		rc = http.NewResponseController(w)
+		rc.SetReadDeadline(time.Time{})
+		rc.SetWriteDeadline(time.Time{})
+		if err := sleep(r.Context(), throttle.Latency); err != nil {
+			return // the client gave up
+		}
+		if up := newPacer(throttle.UpKbps); up != nil && outReq.Body != nil && outReq.Body != http.NoBody {
+			outReq.Body = &throttledBody{ctx: r.Context(), ReadCloser: outReq.Body, p: up, rc: rc}
 		}
-	}
-	for _, h := range toRemove {
-		outReq.Header.Del(h)
 	}
 
-	// Set forwarding headers
-	// SECURITY: Only forward X-Forwarded-For if the client IP is actually
-	// a loopback address. paw-proxy only listens on loopback, so this
-	// should always be true, but we validate as defense-in-depth.
-	// If the client IP is not loopback, strip any existing X-Forwarded-For
-	// header to prevent spoofed values from being forwarded.
-	if clientIP, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
-		if ip := net.ParseIP(clientIP); ip != nil && ip.IsLoopback() {
-			outReq.Header.Set("X-Forwarded-For", clientIP)
-		} else {
-			outReq.Header.Del("X-Forwarded-For")
+	// Trace connection acquisition for timings and pool stats
+	timing := timingFrom(r.Context())
+	var getConn, gotConn time.Time
+	var reused bool
+	outReq = outReq.WithContext(httptrace.WithClientTrace(outReq.Context(), &httptrace.ClientTrace{
+		GetConn: func(string) { getConn = time.Now() },
+		GotConn: func(info httptrace.GotConnInfo) {
+			gotConn = time.Now()
+			reused = info.Reused
+			p.stats.acquired(upstream, info.Reused)
+		},
+	}))
+	defer func() {
+		if !gotConn.IsZero() {
+			p.stats.released(upstream)
 		}
-	} else {
-		outReq.Header.Del("X-Forwarded-For")
-	}
-	outReq.Header.Set("X-Forwarded-Proto", "https")
-	outReq.Header.Set("X-Forwarded-Host", r.Host)
+	}()
 
 	// Send request
-	resp, err := p.transport.RoundTrip(outReq)
+	transport := p.transportFor(poolConfigFrom(r.Context()))
+	if proxyProtocolFrom(r.Context()) {
+		transport = p.ppTransport
+		outReq = outReq.WithContext(context.WithValue(outReq.Context(), headerBytesKey{}, clientHeader(r)))
+	}
+	resp, err := transport.RoundTrip(outReq)
+	if err != nil && canRetry(outReq, reused, err) {
+		// The pooled connection died with the dev server it belonged to.
+		// Drop the rest of the pool and try once more on a fresh one.
+		log.Printf("proxy: retrying %s %s on a fresh connection: %v", outReq.Method, r.Host, err)
+		transport.CloseIdleConnections()
+		p.stats.released(upstream)
+		gotConn, reused = time.Time{}, false
+		resp, err = transport.RoundTrip(outReq)
+	}
+	// A dev server that is restarting refuses connections for a moment:
+	// try once more after a short pause, unless a balanced route has
+	// another upstream to fail over to.
+	if err != nil && canRetryRefused(outReq, err) && len(fallbacksFrom(r.Context())) == 0 {
+		if sleep(r.Context(), restartDelay) == nil {
+			resp, err = transport.RoundTrip(outReq)
+		}
+	}
+	// A balanced route moves on to its next upstream when this one isn't
+	// listening.
+	for fallbacks := fallbacksFrom(r.Context()); err != nil && len(fallbacks) > 0 && canFailOver(outReq, err); {
+		p.reportUpstreamError(r.Host, upstream, err)
+		log.Printf("proxy: %s unreachable for %s, trying %s: %v", upstream, r.Host, fallbacks[0], err)
+		upstream, fallbacks = fallbacks[0], fallbacks[1:]
+		outReq = outReq.Clone(outReq.Context())
+		outReq.URL.Host = upstream
+		resp, err = transport.RoundTrip(outReq)
+	}
+	if timing != nil {
+		timing.Addr = upstream
+	}
 	if err != nil {
-		serveUpstreamError(w, r.Host, upstream, err)
+		if timing != nil && !getConn.IsZero() {
+			timing.Dial = time.Since(getConn)
+		}
+		// A failed upstream usually means the dev server restarted, so
+		// its other pooled connections are dead too. Drop them rather
+		// than hand them to the next requests.
+		transport.CloseIdleConnections()
+		p.reportUpstreamError(r.Host, upstream, err)
+		serveUpstreamError(w, r, upstream, err)
 		return
 	}
 	defer resp.Body.Close()
@@ -137,14 +242,29 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request, upstream strin
 	}
 
 	w.WriteHeader(resp.StatusCode)
-	if _, err := io.Copy(w, resp.Body); err != nil {
+	var body io.Writer = w
+	if down := newPacer(throttle.DownKbps); down != nil {
+		body = &throttledWriter{ctx: r.Context(), w: w, p: down, rc: rc}
+	}
+	if _, err := io.Copy(body, resp.Body); err != nil {
 		log.Printf("proxy: response copy: %v", err)
 	}
+
+	if timing != nil && !gotConn.IsZero() {
+		timing.Dial = gotConn.Sub(getConn)
+		timing.Upstream = time.Since(gotConn)
+	}
+}
+
+func (p *Proxy) reportUpstreamError(host, upstream string, err error) {
+	if p.upstreamFailed != nil {
+		p.upstreamFailed(host, upstream, err)
+	}
 }
 
-func serveUpstreamError(w http.ResponseWriter, host string, upstream string, err error) {
-	log.Printf("proxy: upstream error for %s -> %s: %v", host, upstream, err)
-	errorpage.UpstreamDown(w, host, upstream)
+func serveUpstreamError(w http.ResponseWriter, r *http.Request, upstream string, err error) {
+	log.Printf("proxy: upstream error for %s -> %s: %v", r.Host, upstream, err)
+	errorpage.UpstreamDown(w, errorpage.Language(r), r.Host, upstream)
 }
 
 func isWebSocket(r *http.Request) bool {
@@ -207,12 +327,29 @@ func (p *Proxy) handleWebSocket(w http.ResponseWriter, r *http.Request, upstream
 		clientConn.Write([]byte("HTTP/1.1 502 Bad Gateway\r\n\r\n"))
 		return
 	}
+	dialStart := time.Now()
 	upstreamConn, err := dialLoopbackPort(port, 5*time.Second)
+	timing := timingFrom(r.Context())
+	if timing != nil {
+		timing.Dial = time.Since(dialStart)
+		timing.Addr = upstream
+	}
 	if err != nil {
+		p.reportUpstreamError(r.Host, upstream, err)
 		clientConn.Write([]byte("HTTP/1.1 502 Bad Gateway\r\n\r\n"))
 		return
 	}
 	defer upstreamConn.Close()
+	if proxyProtocolFrom(r.Context()) {
+		if _, err := upstreamConn.Write(clientHeader(r)); err != nil {
+			clientConn.Write([]byte("HTTP/1.1 502 Bad Gateway\r\n\r\n"))
+			return
+		}
+	}
+	if timing != nil {
+		connected := time.Now()
+		defer func() { timing.Upstream = time.Since(connected) }()
+	}
 
 	// Wrap connections with idle timeout instead of absolute deadline.
 	// Each Read/Write resets the deadline, so the connection stays open
@@ -221,16 +358,31 @@ func (p *Proxy) handleWebSocket(w http.ResponseWriter, r *http.Request, upstream
 	upstreamIdle := &idleTimeoutConn{Conn: upstreamConn, timeout: wsIdleTimeout}
 
 	// Forward the original request
-	r.Write(upstreamConn)
+	setForwardingHeaders(r.Header, r, trustForwardedFrom(r.Context()))
+	outReq := r
+	if host := upstreamHostFrom(r.Context()); host != "" {
+		outReq = r.Clone(r.Context())
+		outReq.Host = host
+	}
+	outReq.Write(upstreamConn)
 
 	// Bidirectional copy — wait for BOTH goroutines to finish to avoid
 	// goroutine leaks. When one direction's io.Copy returns (client
 	// disconnected or upstream closed), we close the write side of the
 	// other connection to unblock the other io.Copy.
 	done := make(chan struct{}, 2)
+	copyUp, copyDown := io.Copy, io.Copy
+	if throttle, ok := throttleFrom(r.Context()); ok {
+		copyUp = func(dst io.Writer, src io.Reader) (int64, error) {
+			return throttledCopy(dst, src, throttle.UpKbps, throttle.Latency/2)
+		}
+		copyDown = func(dst io.Writer, src io.Reader) (int64, error) {
+			return throttledCopy(dst, src, throttle.DownKbps, throttle.Latency/2)
+		}
+	}
 
 	go func() {
-		if _, err := io.Copy(upstreamIdle, clientIdle); err != nil {
+		if _, err := copyUp(upstreamIdle, clientIdle); err != nil {
 			log.Printf("websocket: client->upstream copy: %v", err)
 		}
 		if tc, ok := upstreamConn.(*net.TCPConn); ok {
@@ -242,7 +394,7 @@ func (p *Proxy) handleWebSocket(w http.ResponseWriter, r *http.Request, upstream
 	}()
 
 	go func() {
-		if _, err := io.Copy(clientIdle, upstreamIdle); err != nil {
+		if _, err := copyDown(clientIdle, upstreamIdle); err != nil {
 			log.Printf("websocket: upstream->client copy: %v", err)
 		}
 		if tc, ok := clientConn.(*net.TCPConn); ok {
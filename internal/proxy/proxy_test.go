@@ -2,6 +2,7 @@
 package proxy
 
 import (
+	"context"
 	"io"
 	"net"
 	"net/http"
@@ -283,6 +284,26 @@ func TestProxyPreservesHostHeader(t *testing.T) {
 	}
 }
 
+func TestProxyUpstreamHost(t *testing.T) {
+	var receivedHost, forwardedHost string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHost = r.Host
+		forwardedHost = r.Header.Get("X-Forwarded-Host")
+	}))
+	defer upstream.Close()
+
+	req := httptest.NewRequest("GET", "https://minio.test/bucket", nil)
+	req = req.WithContext(WithUpstreamHost(req.Context(), "localhost:9000"))
+	New().ServeHTTP(httptest.NewRecorder(), req, upstream.URL[7:])
+
+	if receivedHost != "localhost:9000" {
+		t.Errorf("Host = %q, want localhost:9000", receivedHost)
+	}
+	if forwardedHost != "minio.test" {
+		t.Errorf("X-Forwarded-Host = %q, want the client's host", forwardedHost)
+	}
+}
+
 func TestProxyStripsHopByHopHeaders(t *testing.T) {
 	var receivedHeaders http.Header
 
@@ -841,6 +862,26 @@ func TestHandleWebSocket_UpstreamUnreachable(t *testing.T) {
 	}
 }
 
+func TestProxy_ReportsUpstreamError(t *testing.T) {
+	p := New()
+	var gotHost, gotUpstream string
+	p.SetUpstreamErrorFunc(func(host, upstream string, err error) {
+		gotHost, gotUpstream = host, upstream
+	})
+
+	// Nothing listens on port 1
+	req := httptest.NewRequest("GET", "https://myapp.test/", nil)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req, "127.0.0.1:1")
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", w.Code)
+	}
+	if gotHost != "myapp.test" || gotUpstream != "127.0.0.1:1" {
+		t.Errorf("reported %q -> %q, want myapp.test -> 127.0.0.1:1", gotHost, gotUpstream)
+	}
+}
+
 func TestHandleWebSocket_BidirectionalData(t *testing.T) {
 	echoAddr, cleanup := startEchoServer(t)
 	defer cleanup()
@@ -928,3 +969,82 @@ func TestExtractAndValidateUpstreamPort(t *testing.T) {
 		})
 	}
 }
+
+func TestProxy_RecordsTiming(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("slow"))
+	}))
+	defer upstream.Close()
+
+	p := New()
+	timing := &Timing{}
+	req := httptest.NewRequest("GET", "https://myapp.test/", nil)
+	req = req.WithContext(WithTiming(req.Context(), timing))
+	w := httptest.NewRecorder()
+
+	p.ServeHTTP(w, req, upstream.URL[7:])
+
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if timing.Upstream < 20*time.Millisecond {
+		t.Errorf("expected upstream time >= 20ms, got %v", timing.Upstream)
+	}
+	if timing.Dial < 0 || timing.Dial > timing.Upstream {
+		t.Errorf("unexpected dial time %v (upstream %v)", timing.Dial, timing.Upstream)
+	}
+}
+
+func TestProxy_TimingOptional(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	// Without a Timing in the context the proxy must not trace or panic.
+	w := httptest.NewRecorder()
+	New().ServeHTTP(w, httptest.NewRequest("GET", "https://myapp.test/", nil), upstream.URL[7:])
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestProxy_FailsOver(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("fallback"))
+	}))
+	defer backend.Close()
+	// A port nothing listens on.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := ln.Addr().String()
+	ln.Close()
+
+	var failed []string
+	p := New()
+	p.SetUpstreamErrorFunc(func(host, upstream string, err error) { failed = append(failed, upstream) })
+	timing := &Timing{}
+	ctx := WithFallbacks(WithTiming(context.Background(), timing), []string{strings.TrimPrefix(backend.URL, "http://")})
+
+	req := httptest.NewRequest("GET", "https://myapp.test/", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req, dead)
+	if w.Code != http.StatusOK || w.Body.String() != "fallback" {
+		t.Fatalf("response = %d %q, want the fallback's", w.Code, w.Body.String())
+	}
+	if len(failed) != 1 || failed[0] != dead {
+		t.Errorf("reported failures = %v, want [%s]", failed, dead)
+	}
+	if timing.Addr != strings.TrimPrefix(backend.URL, "http://") {
+		t.Errorf("timing.Addr = %q, want the fallback", timing.Addr)
+	}
+
+	// A request body can't be sent twice.
+	req = httptest.NewRequest("POST", "https://myapp.test/", strings.NewReader("x")).WithContext(ctx)
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req, dead)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("POST status = %d, want 502 without failing over", w.Code)
+	}
+}
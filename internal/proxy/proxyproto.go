@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+)
+
+// proxyV2Signature starts every PROXY protocol v2 header.
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// proxyHeaderV2 encodes a PROXY protocol v2 header for a TCP connection
+// from src to dst. When either address is missing it encodes a LOCAL
+// header, which tells the upstream to use the real connection endpoints.
+func proxyHeaderV2(src, dst *net.TCPAddr) []byte {
+	hdr := append([]byte{}, proxyV2Signature...)
+	if src == nil || dst == nil {
+		// Version 2, LOCAL command, unspecified family, no addresses.
+		return append(hdr, 0x20, 0x00, 0x00, 0x00)
+	}
+
+	srcIP, dstIP := src.IP.To4(), dst.IP.To4()
+	family := byte(0x11) // TCP over IPv4
+	if srcIP == nil || dstIP == nil {
+		srcIP, dstIP = src.IP.To16(), dst.IP.To16()
+		family = 0x21 // TCP over IPv6
+	}
+
+	addrLen := 2*len(srcIP) + 4
+	hdr = append(hdr, 0x21, family) // version 2, PROXY command
+	hdr = binary.BigEndian.AppendUint16(hdr, uint16(addrLen))
+	hdr = append(hdr, srcIP...)
+	hdr = append(hdr, dstIP...)
+	hdr = binary.BigEndian.AppendUint16(hdr, uint16(src.Port))
+	hdr = binary.BigEndian.AppendUint16(hdr, uint16(dst.Port))
+	return hdr
+}
+
+type proxyHeaderKey struct{}
+
+// WithProxyProtocol returns a context that makes ServeHTTP open a fresh
+// upstream connection for the request and begin it with a PROXY protocol
+// v2 header describing the client connection.
+func WithProxyProtocol(ctx context.Context) context.Context {
+	return context.WithValue(ctx, proxyHeaderKey{}, true)
+}
+
+func proxyProtocolFrom(ctx context.Context) bool {
+	on, _ := ctx.Value(proxyHeaderKey{}).(bool)
+	return on
+}
+
+// clientHeader builds the PROXY header for the connection r arrived on.
+func clientHeader(r *http.Request) []byte {
+	var src, dst *net.TCPAddr
+	if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		src = addr
+	}
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		dst, _ = addr.(*net.TCPAddr)
+	}
+	return proxyHeaderV2(src, dst)
+}
+
+// ConnHeader builds the PROXY header for a client connection c, for
+// callers that forward raw connections rather than requests.
+func ConnHeader(c net.Conn) []byte {
+	src, _ := c.RemoteAddr().(*net.TCPAddr)
+	dst, _ := c.LocalAddr().(*net.TCPAddr)
+	return proxyHeaderV2(src, dst)
+}
+
+type headerBytesKey struct{}
+
+// newProxyProtocolTransport returns a transport whose connections start
+// with the PROXY header stored in the request context. Keep-alives are off:
+// the header describes one client connection, so upstream connections
+// can't be shared between clients.
+func newProxyProtocolTransport() *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialUpstream(addr)
+			if err != nil {
+				return nil, err
+			}
+			if hdr, ok := ctx.Value(headerBytesKey{}).([]byte); ok {
+				if _, err := conn.Write(hdr); err != nil {
+					conn.Close()
+					return nil, err
+				}
+			}
+			return conn, nil
+		},
+		DisableKeepAlives:  true,
+		DisableCompression: true,
+	}
+}
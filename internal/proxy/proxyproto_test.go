@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyHeaderV2(t *testing.T) {
+	src := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 51234}
+	dst := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 443}
+	hdr := proxyHeaderV2(src, dst)
+	want := append(append([]byte{}, proxyV2Signature...),
+		0x21, 0x11, 0x00, 0x0c,
+		127, 0, 0, 1, 127, 0, 0, 1,
+		0xc8, 0x22, 0x01, 0xbb)
+	if !bytes.Equal(hdr, want) {
+		t.Errorf("IPv4 header = %x, want %x", hdr, want)
+	}
+
+	src6 := &net.TCPAddr{IP: net.ParseIP("::1"), Port: 51234}
+	dst6 := &net.TCPAddr{IP: net.ParseIP("::1"), Port: 443}
+	hdr = proxyHeaderV2(src6, dst6)
+	if len(hdr) != 16+36 || hdr[13] != 0x21 || hdr[15] != 36 {
+		t.Errorf("IPv6 header = %x", hdr)
+	}
+
+	hdr = proxyHeaderV2(nil, dst)
+	if len(hdr) != 16 || hdr[12] != 0x20 {
+		t.Errorf("LOCAL header = %x", hdr)
+	}
+}
+
+func TestProxy_SendsProxyProtocolHeader(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	got := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		hdr := make([]byte, 28)
+		if _, err := io.ReadFull(conn, hdr); err != nil {
+			return
+		}
+		got <- hdr
+		if _, err := http.ReadRequest(bufio.NewReader(conn)); err != nil {
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\nConnection: close\r\n\r\nok"))
+	}()
+
+	req := httptest.NewRequest("GET", "https://myapp.test/", nil)
+	req.RemoteAddr = "127.0.0.1:51234"
+	local := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 443}
+	ctx := context.WithValue(req.Context(), http.LocalAddrContextKey, net.Addr(local))
+	req = req.WithContext(WithProxyProtocol(ctx))
+	w := httptest.NewRecorder()
+
+	New().ServeHTTP(w, req, ln.Addr().String())
+
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+	hdr := <-got
+	src := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 51234}
+	if want := proxyHeaderV2(src, local); !bytes.Equal(hdr, want) {
+		t.Errorf("upstream got header %x, want %x", hdr, want)
+	}
+}
@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// restartDelay is how long a request whose dial was refused waits before
+// trying once more, in case the dev server was restarting. It is short so
+// a dev server that is down still shows its error page right away.
+const restartDelay = 200 * time.Millisecond
+
+// isIdempotent reports whether sending a request with this method twice
+// has the same effect as sending it once (RFC 9110 Section 9.2.2).
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// canRetry reports whether req, which failed with err on a connection
+// taken from the pool, can safely be sent again. Requests with a body are
+// never retried because the body has already been consumed. Incoming
+// HTTP/2 requests have a Body even when it is empty, so the length is
+// what tells.
+func canRetry(req *http.Request, reused bool, err error) bool {
+	if !reused || !isIdempotent(req.Method) || req.ContentLength != 0 {
+		return false
+	}
+	return isStaleConnError(err)
+}
+
+// canRetryRefused reports whether req, whose dial found nothing listening,
+// can be sent again. The request never reached the dev server.
+func canRetryRefused(req *http.Request, err error) bool {
+	return isIdempotent(req.Method) && req.ContentLength == 0 && errors.Is(err, errUnreachable)
+}
+
+// isStaleConnError reports whether err looks like the upstream closed a
+// keep-alive connection under us, as happens when a dev server restarts.
+func isStaleConnError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
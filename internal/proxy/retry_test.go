@@ -0,0 +1,175 @@
+package proxy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// resettingUpstream answers the first request on each connection, then
+// resets the connection when a second request arrives on it, like a dev
+// server that restarted while its keep-alive connection sat in the pool.
+func resettingUpstream(t *testing.T) (addr string, conns *atomic.Int32) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	conns = &atomic.Int32{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns.Add(1)
+			go func(conn net.Conn) {
+				br := bufio.NewReader(conn)
+				if _, err := http.ReadRequest(br); err != nil {
+					conn.Close()
+					return
+				}
+				fmt.Fprint(conn, "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok")
+				if _, err := http.ReadRequest(br); err == nil {
+					conn.(*net.TCPConn).SetLinger(0)
+				}
+				conn.Close()
+			}(conn)
+		}
+	}()
+	return ln.Addr().String(), conns
+}
+
+func TestProxy_RetriesIdempotentOnStaleConn(t *testing.T) {
+	addr, conns := resettingUpstream(t)
+	p := New()
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("DELETE", "https://myapp.test/item", nil)
+		req.RemoteAddr = "127.0.0.1:9999"
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req, addr)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, w.Code)
+		}
+	}
+	if got := conns.Load(); got != 2 {
+		t.Errorf("upstream saw %d connections, want 2 (retry on a fresh one)", got)
+	}
+}
+
+func TestProxy_RetriesRefusedDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	// The dev server comes back on its port a moment after the request.
+	started := make(chan struct{})
+	go func() {
+		defer close(started)
+		time.Sleep(50 * time.Millisecond)
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "back")
+		})}
+		t.Cleanup(func() { srv.Close() })
+		go srv.Serve(ln)
+	}()
+	defer func() { <-started }()
+
+	p := New()
+	req := httptest.NewRequest("GET", "https://myapp.test/", nil)
+	req.RemoteAddr = "127.0.0.1:9999"
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req, addr)
+	if w.Code != http.StatusOK || w.Body.String() != "back" {
+		t.Errorf("got %d %q, want the restarted server's answer", w.Code, w.Body.String())
+	}
+}
+
+func TestProxy_NoRetryForNonIdempotent(t *testing.T) {
+	addr, _ := resettingUpstream(t)
+	p := New()
+
+	codes := make([]int, 2)
+	for i := range codes {
+		req := httptest.NewRequest("POST", "https://myapp.test/item", nil)
+		req.RemoteAddr = "127.0.0.1:9999"
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req, addr)
+		codes[i] = w.Code
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusBadGateway {
+		t.Errorf("got codes %v, want [200 502]", codes)
+	}
+}
+
+func TestCanRetry(t *testing.T) {
+	reset := &net.OpError{Op: "read", Err: syscall.ECONNRESET}
+	tests := []struct {
+		name   string
+		method string
+		body   io.Reader
+		reused bool
+		err    error
+		want   bool
+	}{
+		{"GET on reused conn reset", "GET", nil, true, reset, true},
+		{"PUT on reused conn EOF", "PUT", nil, true, io.EOF, true},
+		{"fresh conn", "GET", nil, false, reset, false},
+		{"POST", "POST", nil, true, reset, false},
+		{"request with body", "PUT", strings.NewReader("x"), true, reset, false},
+		{"other error", "GET", nil, true, errors.New("timeout"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://upstream/", tt.body)
+			if got := canRetry(req, tt.reused, tt.err); got != tt.want {
+				t.Errorf("canRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanRetryRefused(t *testing.T) {
+	refused := fmt.Errorf("%w: connection refused", errUnreachable)
+	tests := []struct {
+		name   string
+		method string
+		body   io.Reader
+		err    error
+		want   bool
+	}{
+		{"GET refused", "GET", nil, refused, true},
+		{"DELETE refused", "DELETE", nil, refused, true},
+		{"POST refused", "POST", nil, refused, false},
+		{"request with body", "PUT", strings.NewReader("x"), refused, false},
+		{"reset", "GET", nil, &net.OpError{Op: "read", Err: syscall.ECONNRESET}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://upstream/", tt.body)
+			if got := canRetryRefused(req, tt.err); got != tt.want {
+				t.Errorf("canRetryRefused() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
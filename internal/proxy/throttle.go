@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Throttle slows a route's traffic down to simulate a slow network.
+type Throttle struct {
+	// DownKbps caps the rate toward the client in kilobits per second,
+	// and UpKbps the rate from it. Zero is unlimited.
+	DownKbps int
+	UpKbps   int
+	// Latency is the round-trip time added to each request. WebSocket
+	// traffic is delayed by half of it each way.
+	Latency time.Duration
+}
+
+// throttleIdleTimeout is how long a paced transfer may go without moving
+// a chunk before the connection is dropped.
+const throttleIdleTimeout = 30 * time.Second
+
+type throttleKey struct{}
+
+// WithThrottle returns a context that makes ServeHTTP throttle the
+// request and its response, or its WebSocket traffic, to t.
+func WithThrottle(ctx context.Context, t Throttle) context.Context {
+	return context.WithValue(ctx, throttleKey{}, t)
+}
+
+func throttleFrom(ctx context.Context) (Throttle, bool) {
+	t, ok := ctx.Value(throttleKey{}).(Throttle)
+	return t, ok
+}
+
+// pacer spaces out writes so they average a fixed rate. A nil pacer
+// doesn't limit anything.
+type pacer struct {
+	bytesPerSec float64
+	start       time.Time
+	sent        int64
+}
+
+func newPacer(kbps int) *pacer {
+	if kbps <= 0 {
+		return nil
+	}
+	return &pacer{bytesPerSec: float64(kbps) * 1000 / 8}
+}
+
+// chunk returns how much to write at once: about 50ms worth, so the
+// output flows steadily instead of in bursts.
+func (p *pacer) chunk(limit int) int {
+	if p == nil {
+		return limit
+	}
+	return min(max(int(p.bytesPerSec/20), 256), limit)
+}
+
+// pace records n bytes sent and sleeps until the rate allows more. Time
+// spent idle earns no credit, so the next burst is paced too.
+func (p *pacer) pace(ctx context.Context, n int) error {
+	if p == nil || n == 0 {
+		return nil
+	}
+	now := time.Now()
+	if now.After(p.due()) {
+		p.start, p.sent = now, 0
+	}
+	p.sent += int64(n)
+	return sleep(ctx, time.Until(p.due()))
+}
+
+func (p *pacer) due() time.Time {
+	return p.start.Add(time.Duration(float64(p.sent) / p.bytesPerSec * float64(time.Second)))
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttledWriter writes a response no faster than its pacer allows,
+// flushing each chunk so the client sees it arrive at that rate. With rc
+// set, each chunk pushes the write deadline back by throttleIdleTimeout.
+type throttledWriter struct {
+	ctx context.Context
+	w   io.Writer
+	p   *pacer
+	rc  *http.ResponseController
+}
+
+func (t *throttledWriter) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		if t.rc != nil {
+			t.rc.SetWriteDeadline(time.Now().Add(throttleIdleTimeout))
+		}
+		n, err := t.w.Write(b[:t.p.chunk(len(b))])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if f, ok := t.w.(http.Flusher); ok {
+			f.Flush()
+		}
+		if err := t.p.pace(t.ctx, n); err != nil {
+			return written, err
+		}
+		b = b[n:]
+	}
+	return written, nil
+}
+
+// throttledBody reads a request body no faster than its pacer allows.
+// With rc set, each chunk pushes the read deadline back by
+// throttleIdleTimeout.
+type throttledBody struct {
+	ctx context.Context
+	io.ReadCloser
+	p  *pacer
+	rc *http.ResponseController
+}
+
+func (t *throttledBody) Read(b []byte) (int, error) {
+	if t.rc != nil {
+		t.rc.SetReadDeadline(time.Now().Add(throttleIdleTimeout))
+	}
+	n, err := t.ReadCloser.Read(b[:t.p.chunk(len(b))])
+	if perr := t.p.pace(t.ctx, n); perr != nil && err == nil {
+		err = perr
+	}
+	return n, err
+}
+
+// throttledCopy copies src to dst at no more than kbps, delivering what it
+// reads delay later: a link with that bandwidth and one-way latency. It
+// returns nil when src ends, like io.Copy.
+func throttledCopy(dst io.Writer, src io.Reader, kbps int, delay time.Duration) (int64, error) {
+	type chunk struct {
+		data []byte
+		at   time.Time
+		err  error
+	}
+	chunks := make(chan chunk, 64)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			buf := make([]byte, 32*1024)
+			n, err := src.Read(buf)
+			select {
+			case chunks <- chunk{data: buf[:n], at: time.Now().Add(delay), err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	w := &throttledWriter{ctx: context.Background(), w: dst, p: newPacer(kbps)}
+	var written int64
+	for c := range chunks {
+		time.Sleep(time.Until(c.at))
+		n, err := w.Write(c.data)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+		if c.err == io.EOF {
+			return written, nil
+		}
+		if c.err != nil {
+			return written, c.err
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxy_ThrottleSlowsResponse(t *testing.T) {
+	body := strings.Repeat("x", 5000)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	defer upstream.Close()
+
+	p := New()
+	// 200 kbps is 25000 bytes/s, so the body takes 200ms, plus 100ms of
+	// latency.
+	ctx := WithThrottle(context.Background(), Throttle{DownKbps: 200, Latency: 100 * time.Millisecond})
+	req := httptest.NewRequest("GET", "https://myapp.test/", nil).WithContext(ctx)
+	req.RemoteAddr = "127.0.0.1:9999"
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	p.ServeHTTP(w, req, upstream.URL[7:])
+	elapsed := time.Since(start)
+
+	if w.Body.String() != body {
+		t.Fatalf("body = %d bytes, want %d", w.Body.Len(), len(body))
+	}
+	if elapsed < 250*time.Millisecond {
+		t.Errorf("throttled request took %v, want at least 300ms", elapsed)
+	}
+	if !w.Flushed {
+		t.Error("throttled response not flushed as it went")
+	}
+}
+
+func TestProxy_ThrottleOutlastsServerTimeouts(t *testing.T) {
+	body := strings.Repeat("x", 15000)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	defer upstream.Close()
+
+	p := New()
+	front := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithThrottle(r.Context(), Throttle{DownKbps: 200})
+		p.ServeHTTP(w, r.WithContext(ctx), upstream.URL[7:])
+	}))
+	// 15000 bytes at 200 kbps take 600ms, well past the server's timeouts.
+	front.Config.ReadTimeout = 200 * time.Millisecond
+	front.Config.WriteTimeout = 200 * time.Millisecond
+	front.Start()
+	defer front.Close()
+
+	resp, err := http.Get(front.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	got, err := io.ReadAll(resp.Body)
+	if err != nil || len(got) != len(body) {
+		t.Errorf("read %d of %d bytes: %v", len(got), len(body), err)
+	}
+}
+
+func TestProxy_ThrottleSlowsRequestBody(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		if n != 5000 {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer upstream.Close()
+
+	p := New()
+	ctx := WithThrottle(context.Background(), Throttle{UpKbps: 200})
+	req := httptest.NewRequest("POST", "https://myapp.test/", strings.NewReader(strings.Repeat("x", 5000))).WithContext(ctx)
+	req.RemoteAddr = "127.0.0.1:9999"
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	p.ServeHTTP(w, req, upstream.URL[7:])
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("throttled upload took %v, want about 200ms", elapsed)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d", w.Code)
+	}
+}
+
+func TestThrottledCopy(t *testing.T) {
+	var dst bytes.Buffer
+	src := strings.NewReader(strings.Repeat("y", 2500))
+
+	start := time.Now()
+	n, err := throttledCopy(&dst, src, 200, 50*time.Millisecond)
+	elapsed := time.Since(start)
+
+	if err != nil || n != 2500 || dst.Len() != 2500 {
+		t.Fatalf("throttledCopy = %d, %v; copied %d", n, err, dst.Len())
+	}
+	// 50ms of delay plus 100ms at 25000 bytes/s.
+	if elapsed < 120*time.Millisecond {
+		t.Errorf("throttledCopy took %v, want about 150ms", elapsed)
+	}
+}
+
+func TestPacerIdleEarnsNoCredit(t *testing.T) {
+	p := newPacer(800) // 100000 bytes/s
+	ctx := context.Background()
+	p.pace(ctx, 1000)
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	p.pace(ctx, 2000) // 20ms at the rate
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("burst after idle took %v, want about 20ms", elapsed)
+	}
+
+	var none *pacer
+	if none.chunk(4096) != 4096 || none.pace(ctx, 1<<20) != nil {
+		t.Error("nil pacer limited something")
+	}
+}
@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"context"
+	"time"
+)
+
+// Timing records where time went while proxying a single request, so the
+// daemon can attribute latency to the upstream or to the proxy itself.
+type Timing struct {
+	// Dial is the time spent obtaining an upstream connection. Near zero
+	// when a pooled keep-alive connection is reused.
+	Dial time.Duration
+	// Upstream is the time from acquiring the connection until the
+	// response has been fully relayed.
+	Upstream time.Duration
+	// Addr is the upstream the request was sent to, which differs from
+	// the one given to ServeHTTP when it failed over to another.
+	Addr string
+}
+
+type timingKey struct{}
+
+// WithTiming returns a context that makes ServeHTTP fill in t.
+func WithTiming(ctx context.Context, t *Timing) context.Context {
+	return context.WithValue(ctx, timingKey{}, t)
+}
+
+// AddrOr returns the upstream the request was sent to, or upstream if it
+// wasn't recorded.
+func (t *Timing) AddrOr(upstream string) string {
+	if t.Addr == "" {
+		return upstream
+	}
+	return t.Addr
+}
+
+func timingFrom(ctx context.Context) *Timing {
+	t, _ := ctx.Value(timingKey{}).(*Timing)
+	return t
+}
@@ -0,0 +1,166 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearch bounds how far ahead Cron.Next looks, so expressions that
+// never match (February 30th) give up instead of looping forever.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Cron is a parsed five-field cron expression: minute, hour, day of
+// month, month, and day of week. Fields take *, numbers, ranges (1-5),
+// steps (*/15, 0-30/10), and comma-separated lists of those; months and
+// days of the week also take three-letter names (jan, mon). Day of week
+// 0 and 7 are both Sunday. As in cron, when both day fields are
+// restricted, a day matching either one matches.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny record day fields starting with *.
+	domAny, dowAny bool
+}
+
+// macros are the cron shorthands that stand for a full expression.
+var macros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// ParseCron parses a cron expression or one of the @hourly, @daily,
+// @weekly, @monthly, and @yearly shorthands.
+func ParseCron(spec string) (*Cron, error) {
+	spec = strings.TrimSpace(spec)
+	if expanded, ok := macros[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q: want 5 fields (minute hour day month weekday), got %d", spec, len(fields))
+	}
+	c := &Cron{domAny: strings.HasPrefix(fields[2], "*"), dowAny: strings.HasPrefix(fields[4], "*")}
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59, nil, 0); err != nil {
+		return nil, fmt.Errorf("cron minute: %w", err)
+	}
+	if c.hour, err = parseField(fields[1], 0, 23, nil, 0); err != nil {
+		return nil, fmt.Errorf("cron hour: %w", err)
+	}
+	if c.dom, err = parseField(fields[2], 1, 31, nil, 0); err != nil {
+		return nil, fmt.Errorf("cron day of month: %w", err)
+	}
+	if c.month, err = parseField(fields[3], 1, 12, monthNames, 1); err != nil {
+		return nil, fmt.Errorf("cron month: %w", err)
+	}
+	if c.dow, err = parseField(fields[4], 0, 7, dayNames, 0); err != nil {
+		return nil, fmt.Errorf("cron day of week: %w", err)
+	}
+	// 7 is another name for Sunday.
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+// parseField parses one field into a bit set of the values it matches.
+// names, if set, name the values from base up.
+func parseField(field string, lo, hi int, names []string, base int) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+		first, last := lo, hi
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if first, err = parseValue(from, lo, hi, names, base); err != nil {
+				return 0, err
+			}
+			last = first
+			if isRange {
+				if last, err = parseValue(to, lo, hi, names, base); err != nil {
+					return 0, err
+				}
+				if last < first {
+					return 0, fmt.Errorf("range %q runs backwards", rng)
+				}
+			} else if hasStep {
+				// 5/15 means from 5 to the end in steps of 15.
+				last = hi
+			}
+		}
+		for v := first; v <= last; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// parseValue parses a number or name within lo and hi.
+func parseValue(s string, lo, hi int, names []string, base int) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return base + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < lo || v > hi {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, lo, hi)
+	}
+	return v, nil
+}
+
+// Next returns the first minute after t the expression matches, in t's
+// location, or the zero time if there is none within five years. Times
+// a daylight saving change skips over don't happen that day.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's rule for the two day fields.
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
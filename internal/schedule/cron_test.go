@@ -0,0 +1,89 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	valid := []string{
+		"* * * * *",
+		"*/15 9-17 * * mon-fri",
+		"0,30 12 1,15 jan-jun 7",
+		"5/10 * * * *",
+		"@daily",
+		"@Weekly",
+	}
+	for _, spec := range valid {
+		if _, err := ParseCron(spec); err != nil {
+			t.Errorf("ParseCron(%q) = %v, want nil", spec, err)
+		}
+	}
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"a * * * *",
+		"@sometimes",
+	}
+	for _, spec := range invalid {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) = nil, want error", spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// A Wednesday.
+	from := time.Date(2026, 3, 11, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 11, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 11, 10, 15, 0, 0, time.UTC)},
+		{"0 12 * * *", time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2026, 3, 12, 9, 0, 0, 0, time.UTC)},
+		{"30 8 * * sat,sun", time.Date(2026, 3, 14, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: the 13th or a Monday, whichever is first.
+		{"0 0 13 * mon", time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 20 * thu", time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.spec, err)
+		}
+		if got := c.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.spec, from, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextLocal(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+	c, err := ParseCron("30 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 02:30 doesn't exist on the day clocks go forward, so that day is
+	// skipped.
+	got := c.Next(time.Date(2026, 3, 29, 0, 0, 0, 0, loc))
+	if want := time.Date(2026, 3, 30, 2, 30, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("Next across the DST change = %v", got)
+	}
+}
@@ -0,0 +1,152 @@
+// Package schedule runs the daemon's timed jobs: periodic checks, and jobs
+// on cron-like schedules such as route maintenance windows.
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A Schedule says when a job runs next.
+type Schedule interface {
+	// Next returns the first run after t, or the zero time for none.
+	Next(t time.Time) time.Time
+}
+
+// Every runs a job at a fixed interval.
+type Every time.Duration
+
+// Next returns t plus the interval.
+func (e Every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// At runs a job once, at a fixed time.
+type At time.Time
+
+// Next returns the time if it is after t.
+func (a At) Next(t time.Time) time.Time {
+	if at := time.Time(a); at.After(t) {
+		return at
+	}
+	return time.Time{}
+}
+
+// job is a scheduled function and its next run.
+type job struct {
+	schedule Schedule
+	run      func(now time.Time)
+	next     time.Time
+}
+
+// Scheduler runs named jobs at the times their schedules give. Jobs run
+// one at a time on the goroutine calling Run, so they should be quick. A
+// run missed while the machine slept happens once on waking, not once
+// per missed time.
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+	// wake tells Run the earliest run may have changed.
+	wake chan struct{}
+	now  func() time.Time
+}
+
+// New returns a Scheduler with no jobs.
+func New() *Scheduler {
+	return &Scheduler{jobs: make(map[string]*job), wake: make(chan struct{}, 1), now: time.Now}
+}
+
+// Set schedules run under name, replacing any job with that name. A
+// schedule with no run ahead removes the job.
+func (s *Scheduler) Set(name string, schedule Schedule, run func(now time.Time)) {
+	s.mu.Lock()
+	next := schedule.Next(s.now())
+	if next.IsZero() {
+		delete(s.jobs, name)
+	} else {
+		s.jobs[name] = &job{schedule: schedule, run: run, next: next}
+	}
+	s.mu.Unlock()
+	s.poke()
+}
+
+// Remove cancels the job with name, if any.
+func (s *Scheduler) Remove(name string) {
+	s.mu.Lock()
+	delete(s.jobs, name)
+	s.mu.Unlock()
+	s.poke()
+}
+
+// Next returns when the job with name runs next.
+func (s *Scheduler) Next(name string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j, ok := s.jobs[name]; ok {
+		return j.next, true
+	}
+	return time.Time{}, false
+}
+
+func (s *Scheduler) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run runs jobs as they come due until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		for _, run := range s.due() {
+			run()
+		}
+		timer.Reset(s.untilNext())
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// due returns the jobs whose time has come, each bound to its run time,
+// and schedules their next runs.
+func (s *Scheduler) due() []func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	var runs []func()
+	for name, j := range s.jobs {
+		if j.next.After(now) {
+			continue
+		}
+		run, at := j.run, j.next
+		runs = append(runs, func() { run(at) })
+		// Schedule from now rather than the missed time, so a long sleep
+		// doesn't replay every run it skipped.
+		if j.next = j.schedule.Next(now); j.next.IsZero() {
+			delete(s.jobs, name)
+		}
+	}
+	return runs
+}
+
+// untilNext returns how long until the earliest run, capped at an hour
+// so changes to the wall clock are noticed.
+func (s *Scheduler) untilNext() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wait := time.Hour
+	now := s.now()
+	for _, j := range s.jobs {
+		if d := j.next.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	return max(wait, 0)
+}
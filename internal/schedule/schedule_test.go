@@ -0,0 +1,88 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRuns(t *testing.T) {
+	s := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	ticks := make(chan time.Time, 10)
+	s.Set("tick", Every(10*time.Millisecond), func(now time.Time) { ticks <- now })
+	for range 3 {
+		select {
+		case <-ticks:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Every job did not run repeatedly")
+		}
+	}
+	s.Remove("tick")
+	if _, ok := s.Next("tick"); ok {
+		t.Error("removed job still scheduled")
+	}
+
+	at := time.Now().Add(20 * time.Millisecond)
+	once := make(chan time.Time, 2)
+	s.Set("once", At(at), func(now time.Time) { once <- now })
+	select {
+	case got := <-once:
+		if !got.Equal(at) {
+			t.Errorf("At job ran with %v, want its scheduled time %v", got, at)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("At job did not run")
+	}
+	if _, ok := s.Next("once"); ok {
+		t.Error("At job still scheduled after running")
+	}
+}
+
+func TestSchedulerSet(t *testing.T) {
+	s := New()
+	now := time.Date(2026, 3, 11, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	s.Set("job", Every(time.Hour), func(time.Time) {})
+	if next, _ := s.Next("job"); !next.Equal(now.Add(time.Hour)) {
+		t.Errorf("next run = %v, want an hour from now", next)
+	}
+	// Setting the same name replaces the job.
+	s.Set("job", Every(time.Minute), func(time.Time) {})
+	if next, _ := s.Next("job"); !next.Equal(now.Add(time.Minute)) {
+		t.Errorf("replaced job runs at %v, want a minute from now", next)
+	}
+	// A schedule with nothing ahead isn't kept.
+	s.Set("past", At(now.Add(-time.Minute)), func(time.Time) {})
+	if _, ok := s.Next("past"); ok {
+		t.Error("job with no run ahead was scheduled")
+	}
+
+	// After a long sleep, an overdue job runs once and is rescheduled
+	// from now.
+	var runs int
+	s.Set("job", Every(time.Minute), func(time.Time) { runs++ })
+	now = now.Add(time.Hour)
+	for _, run := range s.due() {
+		run()
+	}
+	if runs != 1 {
+		t.Errorf("overdue job ran %d times, want 1", runs)
+	}
+	if next, _ := s.Next("job"); !next.Equal(now.Add(time.Minute)) {
+		t.Errorf("rescheduled for %v, want a minute after waking", next)
+	}
+}
@@ -1,9 +1,44 @@
 package setup
 
+import (
+	"strings"
+
+	"github.com/alexcatdad/paw-proxy/internal/i18n"
+)
+
 // Config holds platform-independent configuration for paw-proxy setup.
 type Config struct {
 	SupportDir string
 	BinaryPath string
 	DNSPort    int
 	TLD        string
+	// SocketActivation installs systemd socket units for ports 80/443
+	// instead of granting the binary cap_net_bind_service (Linux only).
+	SocketActivation bool
+	// HTTPPort and HTTPSPort are the daemon's listener ports; zero means
+	// 80 and 443. Other values get a firewall redirect from 80/443.
+	HTTPPort  int
+	HTTPSPort int
+	// APIAddr is an optional loopback TCP address for the control API,
+	// for clients in containers that can't reach the unix socket.
+	APIAddr string
+	// ListenAddr and AllowFrom are passed to the daemon's --listen-addr
+	// and --allow, to also serve a non-loopback interface to the listed
+	// client networks.
+	ListenAddr string
+	AllowFrom  []string
+	// CADomains are the TLDs a newly generated CA is name-constrained to.
+	// Empty means an unconstrained CA.
+	CADomains []string
+	// SharedCA, when set, is installed instead of generating a CA, and
+	// replaces an existing one.
+	SharedCA *SharedCA
+}
+
+// caScope describes the name constraints of a CA generated for c.
+func (c *Config) caScope() string {
+	if len(c.CADomains) == 0 {
+		return ""
+	}
+	return " " + i18n.T("(limited to %s)", "."+strings.Join(c.CADomains, ", ."))
 }
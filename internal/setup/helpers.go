@@ -3,6 +3,7 @@ package setup
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"os/user"
 	"strconv"
 )
@@ -57,3 +58,14 @@ func chownToRealUser(paths ...string) error {
 	}
 	return nil
 }
+
+// realUserCmd builds a command run as the real user when running under
+// sudo, so files it creates in the user's home stay theirs.
+func realUserCmd(name string, args ...string) *exec.Cmd {
+	if os.Getuid() == 0 {
+		if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+			return exec.Command("sudo", append([]string{"-u", sudoUser, name}, args...)...)
+		}
+	}
+	return exec.Command(name, args...)
+}
@@ -0,0 +1,187 @@
+//go:build darwin || linux
+
+package setup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// nssNickname is the name the CA is stored under in NSS databases.
+const nssNickname = "paw-proxy CA"
+
+// errNoCertutil is returned when NSS's certutil isn't installed, so
+// Firefox and Chromium profiles can't be updated.
+var errNoCertutil = errors.New("certutil not found")
+
+// NSSResult is the outcome of updating one NSS database.
+type NSSResult struct {
+	// Profile is the database directory, with the home directory shown
+	// as "~".
+	Profile string
+	Err     error
+}
+
+// nssDatabases returns the NSS database directories under home used by
+// Firefox and Chromium-based browsers, including Snap and Flatpak installs.
+// A directory counts when it holds cert9.db (sql) or cert8.db (legacy).
+func nssDatabases(home string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, pattern := range nssDatabaseGlobs {
+		matches, _ := filepath.Glob(filepath.Join(home, pattern))
+		for _, dir := range matches {
+			if seen[dir] || nssDBPrefix(dir) == "" {
+				continue
+			}
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// nssDBPrefix returns the certutil -d scheme for the database in dir, or
+// "" if dir holds none.
+func nssDBPrefix(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, "cert9.db")); err == nil {
+		return "sql:"
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cert8.db")); err == nil {
+		return "dbm:"
+	}
+	return ""
+}
+
+// findCertutil returns the path of NSS's certutil.
+func findCertutil() (string, error) {
+	if path, err := exec.LookPath("certutil"); err == nil {
+		return path, nil
+	}
+	for _, path := range certutilFallbacks {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", errNoCertutil
+}
+
+// certutilCmd builds a certutil command for the NSS database in dir, run
+// as the real user so the database files keep their owner.
+func certutilCmd(certutil, dir string, args ...string) *exec.Cmd {
+	args = append([]string{"-d", nssDBPrefix(dir) + dir}, args...)
+	return realUserCmd(certutil, args...)
+}
+
+// trustNSS adds the CA to every NSS database under home and checks that
+// it is listed afterwards. It returns errNoCertutil, and no results, when
+// certutil is missing.
+func trustNSS(certPath, home string) ([]NSSResult, error) {
+	certutil, err := findCertutil()
+	if err != nil {
+		return nil, err
+	}
+	var results []NSSResult
+	for _, dir := range nssDatabases(home) {
+		res := NSSResult{Profile: tildePath(dir, home)}
+		// Replace an older CA left by a previous setup.
+		certutilCmd(certutil, dir, "-D", "-n", nssNickname).Run() //nolint:errcheck // absent is fine
+		if out, err := certutilCmd(certutil, dir, "-A", "-t", "C,,", "-n", nssNickname, "-i", certPath).CombinedOutput(); err != nil {
+			res.Err = fmt.Errorf("adding CA: %v: %s", err, strings.TrimSpace(string(out)))
+		} else if err := certutilCmd(certutil, dir, "-L", "-n", nssNickname).Run(); err != nil {
+			res.Err = fmt.Errorf("CA not listed after adding it: %w", err)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// untrustNSS removes the CA from every NSS database under home. Databases
+// without it are reported as successful.
+func untrustNSS(home string) ([]NSSResult, error) {
+	certutil, err := findCertutil()
+	if err != nil {
+		return nil, err
+	}
+	var results []NSSResult
+	for _, dir := range nssDatabases(home) {
+		res := NSSResult{Profile: tildePath(dir, home)}
+		if certutilCmd(certutil, dir, "-L", "-n", nssNickname).Run() == nil {
+			if out, err := certutilCmd(certutil, dir, "-D", "-n", nssNickname).CombinedOutput(); err != nil {
+				res.Err = fmt.Errorf("removing CA: %v: %s", err, strings.TrimSpace(string(out)))
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// printNSSResults reports one line per database and returns how many
+// failed.
+func printNSSResults(results []NSSResult, verb string) int {
+	if len(results) == 0 {
+		fmt.Printf("  No Firefox or Chromium profiles found\n")
+		return 0
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "  warning: %s: %v\n", r.Profile, r.Err)
+			continue
+		}
+		fmt.Printf("  ✓ %s %s\n", verb, r.Profile)
+	}
+	return failed
+}
+
+// trustBrowsers runs the setup step that adds the CA to browser NSS
+// databases. Failures are reported per profile but never abort setup.
+func trustBrowsers(certPath, home string) {
+	results, err := trustNSS(certPath, home)
+	if err != nil {
+		fmt.Printf("  Skipped: certutil not found, so Firefox won't trust the CA\n")
+		for _, line := range nssInstallHint {
+			fmt.Printf("  %s\n", line)
+		}
+		return
+	}
+	printNSSResults(results, "trusted in")
+}
+
+// planNSS records what trustNSS or untrustNSS would do to the databases
+// under home.
+func planNSS(s *PlanStep, certPath, home string, remove bool) {
+	certutil, err := findCertutil()
+	if err != nil {
+		s.note("certutil not found; Firefox and Chromium profiles would be skipped")
+		certutil = "certutil"
+	}
+	dirs := nssDatabases(home)
+	if len(dirs) == 0 {
+		s.note("no Firefox or Chromium profiles found")
+	}
+	for _, dir := range dirs {
+		if remove {
+			s.run(certutilCmd(certutil, dir, "-D", "-n", nssNickname).Args...)
+			s.keychain(`remove "` + nssNickname + `" from ` + tildePath(dir, home))
+			continue
+		}
+		s.run(certutilCmd(certutil, dir, "-A", "-t", "C,,", "-n", nssNickname, "-i", certPath).Args...)
+		s.keychain(`add trusted "` + nssNickname + `" to ` + tildePath(dir, home))
+	}
+}
+
+// tildePath shortens path for display by writing home as "~".
+func tildePath(path, home string) string {
+	if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.Join("~", rel)
+	}
+	return path
+}
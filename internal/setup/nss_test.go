@@ -0,0 +1,106 @@
+//go:build darwin || linux
+
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// makeNSSDB creates an empty NSS database in home/rel.
+func makeNSSDB(t *testing.T, home, rel, file string) string {
+	t.Helper()
+	dir := filepath.Join(home, rel)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, file), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestNSSDatabases(t *testing.T) {
+	home := t.TempDir()
+	glob := strings.TrimSuffix(nssDatabaseGlobs[0], "*")
+	sql := makeNSSDB(t, home, glob+"abc.default", "cert9.db")
+	legacy := makeNSSDB(t, home, glob+"old.default", "cert8.db")
+	// A profile directory without a database is skipped.
+	if err := os.MkdirAll(filepath.Join(home, glob+"empty"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	got := nssDatabases(home)
+	if len(got) != 2 || got[0] != sql || got[1] != legacy {
+		t.Fatalf("nssDatabases = %v, want [%s %s]", got, sql, legacy)
+	}
+	if p := nssDBPrefix(sql); p != "sql:" {
+		t.Errorf("nssDBPrefix(cert9) = %q", p)
+	}
+	if p := nssDBPrefix(legacy); p != "dbm:" {
+		t.Errorf("nssDBPrefix(cert8) = %q", p)
+	}
+	if p := tildePath(sql, home); !strings.HasPrefix(p, "~/") {
+		t.Errorf("tildePath = %q", p)
+	}
+}
+
+// fakeCertutil puts a certutil on PATH that logs its arguments and fails
+// when they mention a database named "broken".
+func fakeCertutil(t *testing.T) string {
+	t.Helper()
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + log + "\ncase \"$*\" in *broken*) echo 'SEC_ERROR_BAD_DATABASE' >&2; exit 1;; esac\n"
+	if err := os.WriteFile(filepath.Join(bin, "certutil"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	t.Setenv("SUDO_USER", "")
+	return log
+}
+
+func TestTrustNSS(t *testing.T) {
+	log := fakeCertutil(t)
+	home := t.TempDir()
+	glob := strings.TrimSuffix(nssDatabaseGlobs[0], "*")
+	good := makeNSSDB(t, home, glob+"good", "cert9.db")
+	makeNSSDB(t, home, glob+"broken", "cert9.db")
+
+	results, err := trustNSS("/tmp/ca.crt", home)
+	if err != nil {
+		t.Fatalf("trustNSS: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	for _, r := range results {
+		broken := strings.HasSuffix(r.Profile, "broken")
+		if broken && (r.Err == nil || !strings.Contains(r.Err.Error(), "SEC_ERROR_BAD_DATABASE")) {
+			t.Errorf("broken profile: err = %v", r.Err)
+		}
+		if !broken && r.Err != nil {
+			t.Errorf("%s: %v", r.Profile, r.Err)
+		}
+	}
+
+	calls, _ := os.ReadFile(log)
+	want := "-d sql:" + good + " -A -t C,, -n paw-proxy CA -i /tmp/ca.crt"
+	if !strings.Contains(string(calls), want) {
+		t.Errorf("certutil calls missing %q:\n%s", want, calls)
+	}
+}
+
+func TestTrustNSS_NoCertutil(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if len(certutilFallbacks) > 0 {
+		if _, err := findCertutil(); err == nil {
+			t.Skip("certutil installed at a fallback path")
+		}
+	}
+	if _, err := trustNSS("/tmp/ca.crt", t.TempDir()); err != errNoCertutil {
+		t.Errorf("err = %v, want errNoCertutil", err)
+	}
+}
@@ -0,0 +1,87 @@
+package setup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Plan lists the changes setup or uninstall would make, step by step,
+// without making them. It backs --dry-run.
+type Plan struct {
+	Title string
+	Steps []*PlanStep
+}
+
+// PlanStep is one numbered step of a Plan.
+type PlanStep struct {
+	Name    string
+	Actions []PlanAction
+}
+
+// PlanAction is a single change: Verb is one of mkdir, write, chown,
+// remove, run, keychain, or note; Target is the path, command line, or
+// keychain entry it applies to.
+type PlanAction struct {
+	Verb   string
+	Target string
+}
+
+func (p *Plan) step(name string) *PlanStep {
+	s := &PlanStep{Name: name}
+	p.Steps = append(p.Steps, s)
+	return s
+}
+
+func (s *PlanStep) add(verb, target string) {
+	s.Actions = append(s.Actions, PlanAction{Verb: verb, Target: target})
+}
+
+func (s *PlanStep) mkdir(path string)  { s.add("mkdir", path) }
+func (s *PlanStep) write(path string)  { s.add("write", path) }
+func (s *PlanStep) remove(path string) { s.add("remove", path) }
+func (s *PlanStep) note(text string)   { s.add("note", text) }
+
+// keychain records a certificate added to or removed from a trust store.
+func (s *PlanStep) keychain(entry string) { s.add("keychain", entry) }
+
+// run records a command line, quoting arguments the shell would split.
+func (s *PlanStep) run(args ...string) {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if a == "" || strings.ContainsAny(a, " \t\"'$\\") {
+			a = strconv.Quote(a)
+		}
+		quoted[i] = a
+	}
+	s.add("run", strings.Join(quoted, " "))
+}
+
+// chown records the ownership fix chownToRealUser applies under sudo.
+func (s *PlanStep) chown(paths ...string) {
+	if os.Getuid() != 0 {
+		return
+	}
+	sudoUser := os.Getenv("SUDO_USER")
+	if sudoUser == "" {
+		return
+	}
+	for _, p := range paths {
+		s.add("chown", p+" to "+sudoUser)
+	}
+}
+
+// Print writes the plan in the numbered layout setup and uninstall use.
+func (p *Plan) Print(w io.Writer) {
+	fmt.Fprintf(w, "%s (dry run)\n", p.Title)
+	fmt.Fprintln(w, strings.Repeat("=", len(p.Title)+10))
+	fmt.Fprintln(w, "Nothing will be changed. Without --dry-run this would:")
+	for i, s := range p.Steps {
+		fmt.Fprintf(w, "\n[%d/%d] %s\n", i+1, len(p.Steps), s.Name)
+		for _, a := range s.Actions {
+			fmt.Fprintf(w, "  %-9s %s\n", a.Verb, a.Target)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+//go:build linux
+
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlanSetup_ChangesNothing(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "support")
+	config := &Config{SupportDir: dir, BinaryPath: "/usr/local/bin/paw-proxy", DNSPort: 9353, TLD: "test"}
+
+	plan, err := PlanSetup(config)
+	if err != nil {
+		t.Fatalf("PlanSetup: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("PlanSetup created %s", dir)
+	}
+
+	has := func(verb, target string) bool {
+		for _, s := range plan.Steps {
+			for _, a := range s.Actions {
+				if a.Verb == verb && a.Target == target {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for _, want := range []PlanAction{
+		{"mkdir", dir},
+		{"write", filepath.Join(dir, "ca.crt")},
+		{"write", "/etc/systemd/resolved.conf.d/paw-proxy.conf"},
+		{"run", "setcap cap_net_bind_service=+ep /usr/local/bin/paw-proxy"},
+	} {
+		if !has(want.Verb, want.Target) {
+			t.Errorf("plan missing %s %s", want.Verb, want.Target)
+		}
+	}
+}
+
+func TestPlanSetup_SocketActivation(t *testing.T) {
+	config := &Config{SupportDir: t.TempDir(), BinaryPath: "/usr/local/bin/paw-proxy", TLD: "test", SocketActivation: true}
+
+	plan, err := PlanSetup(config)
+	if err != nil {
+		t.Fatalf("PlanSetup: %v", err)
+	}
+	var writes []string
+	for _, s := range plan.Steps {
+		for _, a := range s.Actions {
+			if a.Verb == "write" {
+				writes = append(writes, a.Target)
+			}
+			if a.Verb == "run" && a.Target == "setcap cap_net_bind_service=+ep /usr/local/bin/paw-proxy" {
+				t.Error("socket activation plan should not set capabilities")
+			}
+		}
+	}
+	for _, want := range []string{socketUnitPath("http"), socketUnitPath("https"), systemServicePath()} {
+		found := false
+		for _, w := range writes {
+			found = found || w == want
+		}
+		if !found {
+			t.Errorf("plan does not write %s (writes %v)", want, writes)
+		}
+	}
+}
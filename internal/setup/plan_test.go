@@ -0,0 +1,39 @@
+package setup
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPlanPrint(t *testing.T) {
+	plan := &Plan{Title: "paw-proxy setup"}
+	s := plan.step("Configure DNS resolver")
+	s.write("/etc/resolver/test")
+	s = plan.step("Add CA to keychain")
+	s.run("security", "add-trusted-cert", "-k", "/Users/dev/Library/Keychains/login keychain", "/tmp/ca.crt")
+	s.keychain(`add trusted "paw-proxy CA"`)
+
+	var buf bytes.Buffer
+	plan.Print(&buf)
+	out := buf.String()
+	for _, want := range []string{
+		"paw-proxy setup (dry run)\n",
+		"[1/2] Configure DNS resolver\n  write     /etc/resolver/test\n",
+		"[2/2] Add CA to keychain\n",
+		`  run       security add-trusted-cert -k "/Users/dev/Library/Keychains/login keychain" /tmp/ca.crt` + "\n",
+		`  keychain  add trusted "paw-proxy CA"` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("plan output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPlanStepRunQuotes(t *testing.T) {
+	s := &PlanStep{}
+	s.run("sudo", "-u", "dev", "env", "", "echo", "$HOME")
+	if got, want := s.Actions[0].Target, `sudo -u dev env "" echo "$HOME"`; got != want {
+		t.Errorf("run = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package setup
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ports browsers connect to. The daemon listens on them unless setup was
+// given alternatives, in which case they are forwarded by the firewall.
+const (
+	DefaultHTTPPort  = 80
+	DefaultHTTPSPort = 443
+)
+
+// HTTPListenPort returns the port the daemon's HTTP listener binds.
+func (c *Config) HTTPListenPort() int {
+	if c.HTTPPort == 0 {
+		return DefaultHTTPPort
+	}
+	return c.HTTPPort
+}
+
+// HTTPSListenPort returns the port the daemon's HTTPS listener binds.
+func (c *Config) HTTPSListenPort() int {
+	if c.HTTPSPort == 0 {
+		return DefaultHTTPSPort
+	}
+	return c.HTTPSPort
+}
+
+// AltPorts reports whether the daemon listens somewhere other than 80/443
+// and needs the firewall to forward the standard ports to it.
+func (c *Config) AltPorts() bool {
+	return c.HTTPListenPort() != DefaultHTTPPort || c.HTTPSListenPort() != DefaultHTTPSPort
+}
+
+// RunArgs returns the daemon's command-line arguments for the service
+// definition.
+func (c *Config) RunArgs() []string {
+	args := []string{"run"}
+	if c.HTTPListenPort() != DefaultHTTPPort {
+		args = append(args, "--http-port", strconv.Itoa(c.HTTPListenPort()))
+	}
+	if c.HTTPSListenPort() != DefaultHTTPSPort {
+		args = append(args, "--https-port", strconv.Itoa(c.HTTPSListenPort()))
+	}
+	if c.APIAddr != "" {
+		args = append(args, "--api-addr", c.APIAddr)
+	}
+	if c.ListenAddr != "" {
+		args = append(args, "--listen-addr", c.ListenAddr, "--allow", strings.Join(c.AllowFrom, ","))
+	}
+	return args
+}
+
+// forwards returns the standard -> listener port pairs that need a redirect.
+func (c *Config) forwards() [][2]int {
+	var out [][2]int
+	if p := c.HTTPListenPort(); p != DefaultHTTPPort {
+		out = append(out, [2]int{DefaultHTTPPort, p})
+	}
+	if p := c.HTTPSListenPort(); p != DefaultHTTPSPort {
+		out = append(out, [2]int{DefaultHTTPSPort, p})
+	}
+	return out
+}
+
+// pfRules renders the pf anchor redirecting loopback traffic on the
+// standard ports to the daemon, over IPv4 and IPv6 (macOS).
+func pfRules(c *Config) string {
+	var b strings.Builder
+	b.WriteString("# Generated by paw-proxy\n")
+	for _, f := range c.forwards() {
+		fmt.Fprintf(&b, "rdr pass on lo0 inet proto tcp from any to 127.0.0.1 port %d -> 127.0.0.1 port %d\n", f[0], f[1])
+		fmt.Fprintf(&b, "rdr pass on lo0 inet6 proto tcp from any to ::1 port %d -> ::1 port %d\n", f[0], f[1])
+	}
+	return b.String()
+}
+
+// nftTable is the nftables table holding the redirect rules (Linux).
+const nftTable = "paw_proxy"
+
+// nftRules renders an nftables script redirecting locally generated
+// traffic on the standard ports to the daemon, with one table for IPv4
+// and one for IPv6. It replaces any previous version of the tables, so it
+// can be applied repeatedly.
+func nftRules(c *Config) string {
+	var b strings.Builder
+	b.WriteString("# Generated by paw-proxy\n")
+	for _, family := range []struct{ name, addr string }{
+		{"ip", "127.0.0.1"},
+		{"ip6", "::1"},
+	} {
+		fmt.Fprintf(&b, "table %s %s\ndelete table %s %s\n", family.name, nftTable, family.name, nftTable)
+		fmt.Fprintf(&b, "table %s %s {\n\tchain output {\n\t\ttype nat hook output priority -100; policy accept;\n", family.name, nftTable)
+		for _, f := range c.forwards() {
+			fmt.Fprintf(&b, "\t\t%s daddr %s tcp dport %d redirect to :%d\n", family.name, family.addr, f[0], f[1])
+		}
+		b.WriteString("\t}\n}\n")
+	}
+	return b.String()
+}
+
+// PortForwardState describes the installed port forward.
+type PortForwardState struct {
+	// Configured is true when setup installed forwarding rules.
+	Configured bool
+	// Loaded is true when the rules are active in the firewall. Only
+	// meaningful when Checked is true; inspecting the firewall needs root.
+	Loaded  bool
+	Checked bool
+}
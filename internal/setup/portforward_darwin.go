@@ -0,0 +1,118 @@
+//go:build darwin
+
+package setup
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// pfAnchor is evaluated by the stock /etc/pf.conf via rdr-anchor "com.apple/*".
+	pfAnchor     = "com.apple/paw-proxy"
+	pfAnchorPath = "/etc/pf.anchors/dev.paw-proxy"
+	// pfDaemonPath reloads the anchor at boot, since pf forgets it.
+	pfDaemonPath = "/Library/LaunchDaemons/dev.paw-proxy.pf.plist"
+)
+
+var pfDaemonPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+    <key>Label</key>
+    <string>dev.paw-proxy.pf</string>
+    <key>ProgramArguments</key>
+    <array>
+        <string>/sbin/pfctl</string>
+        <string>-E</string>
+        <string>-a</string>
+        <string>` + pfAnchor + `</string>
+        <string>-f</string>
+        <string>` + pfAnchorPath + `</string>
+    </array>
+    <key>RunAtLoad</key>
+    <true/>
+</dict>
+</plist>
+`
+
+// installPortForward writes a pf anchor redirecting 80/443 to the
+// daemon's ports, loads it, and installs a LaunchDaemon that reloads it at
+// boot. Requires root.
+func installPortForward(config *Config) error {
+	if err := os.WriteFile(pfAnchorPath, []byte(pfRules(config)), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", pfAnchorPath, err)
+	}
+	if err := os.WriteFile(pfDaemonPath, []byte(pfDaemonPlist), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", pfDaemonPath, err)
+	}
+	exec.Command("launchctl", "bootout", "system/dev.paw-proxy.pf").Run() //nolint:errcheck // not fatal if not loaded
+	if err := exec.Command("launchctl", "bootstrap", "system", pfDaemonPath).Run(); err != nil {
+		return fmt.Errorf("loading %s: %w", pfDaemonPath, err)
+	}
+	return RepairPortForward()
+}
+
+// RepairPortForward reloads the installed pf anchor. Requires root.
+func RepairPortForward() error {
+	out, err := exec.Command("pfctl", "-E", "-a", pfAnchor, "-f", pfAnchorPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("pfctl: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// removePortForward flushes and removes the pf anchor and its
+// LaunchDaemon. Missing files are not an error.
+func removePortForward() error {
+	if _, err := os.Stat(pfAnchorPath); os.IsNotExist(err) {
+		return nil
+	}
+	exec.Command("pfctl", "-a", pfAnchor, "-F", "all").Run()              //nolint:errcheck // best effort
+	exec.Command("launchctl", "bootout", "system/dev.paw-proxy.pf").Run() //nolint:errcheck // not fatal if not loaded
+	for _, p := range []string{pfDaemonPath, pfAnchorPath} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// planInstallPortForward records what installPortForward would do.
+func planInstallPortForward(s *PlanStep) {
+	s.write(pfAnchorPath)
+	s.write(pfDaemonPath)
+	s.run("launchctl", "bootout", "system/dev.paw-proxy.pf")
+	s.run("launchctl", "bootstrap", "system", pfDaemonPath)
+	s.run("pfctl", "-E", "-a", pfAnchor, "-f", pfAnchorPath)
+}
+
+// planRemovePortForward records what removePortForward would do.
+func planRemovePortForward(s *PlanStep) {
+	if _, err := os.Stat(pfAnchorPath); os.IsNotExist(err) {
+		return
+	}
+	s.run("pfctl", "-a", pfAnchor, "-F", "all")
+	s.run("launchctl", "bootout", "system/dev.paw-proxy.pf")
+	s.remove(pfDaemonPath)
+	s.remove(pfAnchorPath)
+}
+
+// PortForwardStatus reports whether a pf redirect is installed and, when
+// running as root, whether it is loaded.
+func PortForwardStatus() PortForwardState {
+	var state PortForwardState
+	if _, err := os.Stat(pfAnchorPath); err != nil {
+		return state
+	}
+	state.Configured = true
+	if os.Geteuid() != 0 {
+		return state
+	}
+	out, err := exec.Command("pfctl", "-a", pfAnchor, "-s", "nat").Output()
+	state.Checked = true
+	state.Loaded = err == nil && strings.Contains(string(out), "rdr")
+	return state
+}
@@ -0,0 +1,122 @@
+//go:build linux
+
+package setup
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	nftRulesPath = "/etc/paw-proxy/redirect.nft"
+	// redirectUnitPath re-applies the rules at boot.
+	redirectUnitPath = systemUnitDir + "/paw-proxy-redirect.service"
+)
+
+var redirectUnitTemplate = `# Generated by paw-proxy
+[Unit]
+Description=paw-proxy port forwarding
+After=network-pre.target
+Before=paw-proxy.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=%s -f ` + nftRulesPath + `
+ExecStop=%s delete table ip ` + nftTable + `
+ExecStop=-%s delete table ip6 ` + nftTable + `
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// installPortForward writes nftables rules redirecting 80/443 to the
+// daemon's ports and a system unit that applies them now and at boot.
+// Requires root.
+func installPortForward(config *Config) error {
+	nft, err := exec.LookPath("nft")
+	if err != nil {
+		return fmt.Errorf("nft not found; install nftables")
+	}
+	if err := os.MkdirAll(filepath.Dir(nftRulesPath), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(nftRulesPath), err)
+	}
+	if err := os.WriteFile(nftRulesPath, []byte(nftRules(config)), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", nftRulesPath, err)
+	}
+	unit := fmt.Sprintf(redirectUnitTemplate, nft, nft, nft)
+	if err := os.WriteFile(redirectUnitPath, []byte(unit), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", redirectUnitPath, err)
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return fmt.Errorf("daemon-reload: %w", err)
+	}
+	if err := systemctl("enable", "paw-proxy-redirect"); err != nil {
+		return fmt.Errorf("enabling paw-proxy-redirect: %w", err)
+	}
+	return RepairPortForward()
+}
+
+// RepairPortForward re-applies the installed nftables rules. Requires root.
+func RepairPortForward() error {
+	return systemctl("restart", "paw-proxy-redirect")
+}
+
+// removePortForward deletes the nftables table and its unit. Missing files
+// are not an error.
+func removePortForward() error {
+	if _, err := os.Stat(nftRulesPath); os.IsNotExist(err) {
+		return nil
+	}
+	systemctl("disable", "--now", "paw-proxy-redirect") //nolint:errcheck // not fatal if not loaded
+	for _, p := range []string{redirectUnitPath, nftRulesPath} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing %s: %w", p, err)
+		}
+	}
+	return systemctl("daemon-reload")
+}
+
+// planInstallPortForward records what installPortForward would do.
+func planInstallPortForward(s *PlanStep) {
+	if _, err := exec.LookPath("nft"); err != nil {
+		s.note("nft not found; setup would fail here until nftables is installed")
+	}
+	s.mkdir(filepath.Dir(nftRulesPath))
+	s.write(nftRulesPath)
+	s.write(redirectUnitPath)
+	s.run("systemctl", "daemon-reload")
+	s.run("systemctl", "enable", "paw-proxy-redirect")
+	s.run("systemctl", "restart", "paw-proxy-redirect")
+}
+
+// planRemovePortForward records what removePortForward would do.
+func planRemovePortForward(s *PlanStep) {
+	if _, err := os.Stat(nftRulesPath); os.IsNotExist(err) {
+		return
+	}
+	s.run("systemctl", "disable", "--now", "paw-proxy-redirect")
+	s.remove(redirectUnitPath)
+	s.remove(nftRulesPath)
+	s.run("systemctl", "daemon-reload")
+}
+
+// PortForwardStatus reports whether an nftables redirect is installed and,
+// when running as root, whether it is loaded.
+func PortForwardStatus() PortForwardState {
+	var state PortForwardState
+	if _, err := os.Stat(nftRulesPath); err != nil {
+		return state
+	}
+	state.Configured = true
+	if os.Geteuid() != 0 {
+		return state
+	}
+	out, err := exec.Command("nft", "list", "table", "ip", nftTable).Output()
+	state.Checked = true
+	state.Loaded = err == nil && strings.Contains(string(out), "redirect")
+	return state
+}
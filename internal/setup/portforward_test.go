@@ -0,0 +1,62 @@
+package setup
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestConfigRunArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   []string
+		alt    bool
+	}{
+		{"defaults", Config{}, []string{"run"}, false},
+		{"explicit defaults", Config{HTTPPort: 80, HTTPSPort: 443}, []string{"run"}, false},
+		{"https only", Config{HTTPSPort: 8443}, []string{"run", "--https-port", "8443"}, true},
+		{"both", Config{HTTPPort: 8080, HTTPSPort: 8443}, []string{"run", "--http-port", "8080", "--https-port", "8443"}, true},
+		{"api addr", Config{APIAddr: "127.0.0.1:2019"}, []string{"run", "--api-addr", "127.0.0.1:2019"}, false},
+		{"listen addr", Config{ListenAddr: "100.64.0.5", AllowFrom: []string{"100.64.0.0/10", "fd7a:115c:a1e0::/48"}},
+			[]string{"run", "--listen-addr", "100.64.0.5", "--allow", "100.64.0.0/10,fd7a:115c:a1e0::/48"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.RunArgs(); !slices.Equal(got, tt.want) {
+				t.Errorf("RunArgs() = %v, want %v", got, tt.want)
+			}
+			if got := tt.config.AltPorts(); got != tt.alt {
+				t.Errorf("AltPorts() = %v, want %v", got, tt.alt)
+			}
+		})
+	}
+}
+
+func TestForwardRules(t *testing.T) {
+	config := &Config{HTTPSPort: 8443}
+
+	pf := pfRules(config)
+	if !strings.Contains(pf, "rdr pass on lo0 inet proto tcp from any to 127.0.0.1 port 443 -> 127.0.0.1 port 8443\n") {
+		t.Errorf("pfRules missing 443 redirect:\n%s", pf)
+	}
+	if !strings.Contains(pf, "rdr pass on lo0 inet6 proto tcp from any to ::1 port 443 -> ::1 port 8443\n") {
+		t.Errorf("pfRules missing IPv6 443 redirect:\n%s", pf)
+	}
+	if strings.Contains(pf, "port 80 ") {
+		t.Errorf("pfRules should not redirect port 80 when it is not moved:\n%s", pf)
+	}
+
+	nft := nftRules(config)
+	for _, want := range []string{
+		"delete table ip paw_proxy\n",
+		"type nat hook output priority -100;",
+		"ip daddr 127.0.0.1 tcp dport 443 redirect to :8443\n",
+		"delete table ip6 paw_proxy\n",
+		"ip6 daddr ::1 tcp dport 443 redirect to :8443\n",
+	} {
+		if !strings.Contains(nft, want) {
+			t.Errorf("nftRules missing %q:\n%s", want, nft)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+//go:build darwin || linux
+
+package setup
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+	"github.com/alexcatdad/paw-proxy/internal/trust"
+)
+
+// RegenerateCA replaces the CA certificate and key in the support
+// directory, name-constrained to config.CADomains, and trusts the new
+// certificate in place of the old one. Certificates issued by the old CA
+// stop being trusted, so the daemon must be restarted afterwards.
+func RegenerateCA(config *Config) error {
+	certPath := filepath.Join(config.SupportDir, "ca.crt")
+	keyPath := filepath.Join(config.SupportDir, "ca.key")
+
+	// The old CA may be unconstrained; it must not stay trusted.
+	if err := untrustCA(certPath); err != nil {
+		fmt.Fprintf(os.Stderr, "  warning: could not remove the old CA from the trust store: %v\n", err)
+	}
+	protected, _ := ssl.KeyProtected(keyPath)
+	if err := ssl.GenerateCA(certPath, keyPath, config.CADomains...); err != nil {
+		return fmt.Errorf("generating CA: %w", err)
+	}
+	if protected {
+		if err := ssl.ProtectKey(keyPath); err != nil {
+			return fmt.Errorf("protecting CA key: %w", err)
+		}
+	}
+	// SECURITY: chown CA files to the real user so the daemon can read them.
+	if err := chownToRealUser(certPath, keyPath); err != nil {
+		return fmt.Errorf("fixing CA file ownership: %w", err)
+	}
+	if err := trustCA(certPath); err != nil {
+		return fmt.Errorf("trusting CA: %w", err)
+	}
+	if _, err := RefreshCABundle(config); err != nil {
+		return fmt.Errorf("rebuilding CA bundle: %w", err)
+	}
+	return nil
+}
+
+// InstallCA replaces the CA certificate and key in the support directory
+// with certPEM and keyPEM, e.g. a CA exported on another machine, and
+// trusts it in place of the old one. The key stays protected if the old
+// one was. The daemon must be restarted afterwards.
+func InstallCA(config *Config, certPEM, keyPEM []byte) error {
+	certPath := filepath.Join(config.SupportDir, "ca.crt")
+	keyPath := filepath.Join(config.SupportDir, "ca.key")
+
+	if err := os.MkdirAll(config.SupportDir, 0700); err != nil {
+		return fmt.Errorf("creating support dir: %w", err)
+	}
+	if err := replaceCA(certPath, keyPath, certPEM, keyPEM); err != nil {
+		return err
+	}
+	// SECURITY: chown CA files to the real user so the daemon can read them.
+	if err := chownToRealUser(config.SupportDir, certPath, keyPath); err != nil {
+		return fmt.Errorf("fixing CA file ownership: %w", err)
+	}
+	if err := trustCA(certPath); err != nil {
+		return fmt.Errorf("trusting CA: %w", err)
+	}
+	if _, err := RefreshCABundle(config); err != nil {
+		return fmt.Errorf("rebuilding CA bundle: %w", err)
+	}
+	return nil
+}
+
+// useSharedCA installs a shared CA over the CA files unless they already
+// hold it, and reports whether it changed them.
+func useSharedCA(ca *SharedCA, certPath, keyPath string) (bool, error) {
+	if cur, err := os.ReadFile(certPath); err == nil && bytes.Equal(cur, ca.Cert) {
+		return false, nil
+	}
+	if err := replaceCA(certPath, keyPath, ca.Cert, ca.Key); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// replaceCA writes certPEM and keyPEM over the CA files, untrusting the
+// old certificate first. The key stays protected if the old one was.
+func replaceCA(certPath, keyPath string, certPEM, keyPEM []byte) error {
+	if err := untrustCA(certPath); err != nil {
+		fmt.Fprintf(os.Stderr, "  warning: could not remove the old CA from the trust store: %v\n", err)
+	}
+	protected, _ := ssl.KeyProtected(keyPath)
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return fmt.Errorf("writing CA certificate: %w", err)
+	}
+	// SECURITY: the key is owner-only, as GenerateCA writes it.
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return fmt.Errorf("writing CA key: %w", err)
+	}
+	if err := os.Chmod(keyPath, 0600); err != nil {
+		return fmt.Errorf("writing CA key: %w", err)
+	}
+	if protected {
+		if err := ssl.ProtectKey(keyPath); err != nil {
+			return fmt.Errorf("protecting CA key: %w", err)
+		}
+	}
+	return nil
+}
+
+// InstallConfig writes data as the daemon config file at path, keeping a
+// different existing file as path.bak.
+func InstallConfig(path string, data []byte) error {
+	if old, err := os.ReadFile(path); err == nil && !bytes.Equal(old, data) {
+		if err := os.WriteFile(path+".bak", old, 0600); err != nil {
+			return fmt.Errorf("backing up %s: %w", path, err)
+		}
+		if err := chownToRealUser(path + ".bak"); err != nil {
+			return fmt.Errorf("fixing config ownership: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	// SECURITY: chown to the real user so the daemon can read it.
+	if err := chownToRealUser(path); err != nil {
+		return fmt.Errorf("fixing config ownership: %w", err)
+	}
+	return nil
+}
+
+// RefreshCABundle rebuilds the combined system roots + CA bundle in the
+// support directory if it is missing or stale, and returns its path.
+func RefreshCABundle(config *Config) (string, error) {
+	certPath := filepath.Join(config.SupportDir, "ca.crt")
+	path, err := trust.EnsureCABundle(config.SupportDir, certPath)
+	if err != nil {
+		return "", err
+	}
+	// SECURITY: chown the bundle to the real user so up can refresh it.
+	if err := chownToRealUser(path); err != nil {
+		return "", fmt.Errorf("fixing CA bundle ownership: %w", err)
+	}
+	return path, nil
+}
+
+// configurePortForward installs the 80/443 redirect when the daemon uses
+// alternative ports, and removes a previously installed one otherwise.
+func configurePortForward(config *Config) error {
+	if !config.AltPorts() {
+		if err := removePortForward(); err != nil {
+			return fmt.Errorf("removing port forwarding: %w", err)
+		}
+		return nil
+	}
+	fmt.Printf("\nConfiguring port forwarding...\n")
+	if err := installPortForward(config); err != nil {
+		return fmt.Errorf("configuring port forwarding: %w", err)
+	}
+	for _, f := range config.forwards() {
+		fmt.Printf("  ✓ 127.0.0.1:%d -> 127.0.0.1:%d\n", f[0], f[1])
+	}
+	return nil
+}
+
+// planPortForward records what configurePortForward would do, as its own
+// step when a redirect is installed.
+func planPortForward(plan *Plan, config *Config) {
+	if !config.AltPorts() {
+		s := &PlanStep{Name: "Remove port forwarding"}
+		planRemovePortForward(s)
+		if len(s.Actions) > 0 {
+			plan.Steps = append(plan.Steps, s)
+		}
+		return
+	}
+	s := plan.step("Configure port forwarding")
+	planInstallPortForward(s)
+	for _, f := range config.forwards() {
+		s.note(fmt.Sprintf("127.0.0.1:%d -> 127.0.0.1:%d", f[0], f[1]))
+	}
+}
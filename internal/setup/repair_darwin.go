@@ -0,0 +1,80 @@
+//go:build darwin
+
+package setup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RepairResolver rewrites /etc/resolver/<tld>. Requires root.
+func RepairResolver(config *Config) error {
+	return configureResolver(config.TLD, config.DNSPort)
+}
+
+// ServiceInstalled reports whether the LaunchAgent plist exists.
+func ServiceInstalled() bool {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(homeDir, "Library", "LaunchAgents", "dev.paw-proxy.plist"))
+	return err == nil
+}
+
+// Status reports whether the LaunchAgent is installed and running, and
+// which binary it starts.
+func Status() (ServiceState, error) {
+	var state ServiceState
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return state, fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	state.Path = filepath.Join(homeDir, "Library", "LaunchAgents", "dev.paw-proxy.plist")
+	readServiceFile(&state, plistBinaryPath)
+	if !state.Installed {
+		return state, nil
+	}
+
+	uid, err := resolveRealUID()
+	if err != nil {
+		return state, fmt.Errorf("resolving user UID: %w", err)
+	}
+	// launchctl print fails when the service is not loaded; that is
+	// reported as not running rather than as an error.
+	out, _ := launchctlCmd("print", fmt.Sprintf("gui/%d/dev.paw-proxy", uid)).Output()
+	state.Running, state.PID = parseLaunchctlPrint(string(out))
+	return state, nil
+}
+
+// RepairService rewrites the LaunchAgent plist and loads it.
+func RepairService(config *Config) error {
+	return installLaunchAgent(config)
+}
+
+// RestartDaemon restarts the running LaunchAgent, killing the current
+// process if there is one.
+func RestartDaemon() error {
+	uid, err := resolveRealUID()
+	if err != nil {
+		return fmt.Errorf("resolving user UID: %w", err)
+	}
+	target := fmt.Sprintf("gui/%d/dev.paw-proxy", uid)
+	if err := launchctlAsUser("kickstart", "-k", target); err != nil {
+		return fmt.Errorf("launchctl kickstart %s: %w", target, err)
+	}
+	return nil
+}
+
+// RepairCapabilities is a no-op on macOS, where launchd hands the daemon
+// its privileged sockets.
+func RepairCapabilities(binaryPath string) error {
+	return nil
+}
+
+// UsesSocketActivation is always false on macOS; launchd socket activation
+// is part of the standard LaunchAgent.
+func UsesSocketActivation() bool {
+	return false
+}
@@ -0,0 +1,91 @@
+//go:build linux
+
+package setup
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// RepairResolver rewrites the systemd-resolved stub zone config and
+// restarts systemd-resolved. Requires root.
+func RepairResolver(config *Config) error {
+	return configureResolver(config.TLD, config.DNSPort)
+}
+
+// ServiceInstalled reports whether the systemd user unit, or the system
+// units used for socket activation, exist.
+func ServiceInstalled() bool {
+	if UsesSocketActivation() {
+		return true
+	}
+	homeDir, err := realUserHomeDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(homeDir, ".config", "systemd", "user", "paw-proxy.service"))
+	return err == nil
+}
+
+// Status reports whether the systemd unit is installed and running, and
+// which binary it starts.
+func Status() (ServiceState, error) {
+	var state ServiceState
+	if UsesSocketActivation() {
+		state.Path = systemServicePath()
+		readServiceFile(&state, unitBinaryPath)
+		out, err := exec.Command("systemctl", "show", "paw-proxy", "--property=ActiveState,MainPID").Output()
+		if err != nil {
+			return state, fmt.Errorf("systemctl show: %w", err)
+		}
+		state.Running, state.PID = parseSystemctlShow(string(out))
+		return state, nil
+	}
+
+	homeDir, err := realUserHomeDir()
+	if err != nil {
+		return state, fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	state.Path = filepath.Join(homeDir, ".config", "systemd", "user", "paw-proxy.service")
+	readServiceFile(&state, unitBinaryPath)
+	if !state.Installed {
+		return state, nil
+	}
+
+	cmd, err := systemctlUserCmd("show", "paw-proxy", "--property=ActiveState,MainPID")
+	if err != nil {
+		return state, err
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return state, fmt.Errorf("systemctl show: %w", err)
+	}
+	state.Running, state.PID = parseSystemctlShow(string(out))
+	return state, nil
+}
+
+// RepairService rewrites the systemd unit(s) and enables them, keeping
+// socket activation if it is in use. Socket activation requires root.
+func RepairService(config *Config) error {
+	if config.SocketActivation || UsesSocketActivation() {
+		return installSocketActivation(config)
+	}
+	return installSystemdUnit(config)
+}
+
+// RestartDaemon restarts the systemd service. The system service used
+// for socket activation requires root.
+func RestartDaemon() error {
+	if UsesSocketActivation() {
+		return systemctl("restart", "paw-proxy")
+	}
+	return systemctlAsUser("restart", "paw-proxy")
+}
+
+// RepairCapabilities re-grants cap_net_bind_service, which is cleared
+// whenever the binary is replaced (e.g. by an upgrade). Requires root.
+func RepairCapabilities(binaryPath string) error {
+	return setCapabilities(binaryPath)
+}
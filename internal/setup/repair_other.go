@@ -0,0 +1,57 @@
+//go:build !darwin && !linux
+
+package setup
+
+import "fmt"
+
+func RepairResolver(config *Config) error {
+	return fmt.Errorf("not supported on this platform")
+}
+
+func ServiceInstalled() bool {
+	return false
+}
+
+func Status() (ServiceState, error) {
+	return ServiceState{}, fmt.Errorf("not supported on this platform")
+}
+
+func RepairService(config *Config) error {
+	return fmt.Errorf("not supported on this platform")
+}
+
+func RestartDaemon() error {
+	return fmt.Errorf("not supported on this platform")
+}
+
+func RepairCapabilities(binaryPath string) error {
+	return fmt.Errorf("not supported on this platform")
+}
+
+func RegenerateCA(config *Config) error {
+	return fmt.Errorf("not supported on this platform")
+}
+
+func RefreshCABundle(config *Config) (string, error) {
+	return "", fmt.Errorf("not supported on this platform")
+}
+
+func InstallCA(config *Config, certPEM, keyPEM []byte) error {
+	return fmt.Errorf("not supported on this platform")
+}
+
+func InstallConfig(path string, data []byte) error {
+	return fmt.Errorf("not supported on this platform")
+}
+
+func UsesSocketActivation() bool {
+	return false
+}
+
+func RepairPortForward() error {
+	return fmt.Errorf("not supported on this platform")
+}
+
+func PortForwardStatus() PortForwardState {
+	return PortForwardState{}
+}
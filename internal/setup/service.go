@@ -0,0 +1,106 @@
+package setup
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ServiceState describes the installed launchd/systemd service.
+type ServiceState struct {
+	// Path is the plist or unit file.
+	Path      string
+	Installed bool
+	// BinaryPath is the program the service runs, read from Path.
+	BinaryPath string
+	Running    bool
+	PID        int
+}
+
+// StableBinaryPath maps a binary inside a Homebrew Cellar to the prefix's
+// bin symlink, so services keep working after `brew upgrade` removes the
+// versioned directory. Other paths are returned unchanged.
+func StableBinaryPath(path string) string {
+	prefix, rest, ok := strings.Cut(path, "/Cellar/")
+	if !ok || !strings.Contains(rest, "/bin/") {
+		return path
+	}
+	link := filepath.Join(prefix, "bin", filepath.Base(path))
+	if target, err := filepath.EvalSymlinks(link); err == nil {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil && target == resolved {
+			return link
+		}
+	}
+	return path
+}
+
+var plistProgramPattern = regexp.MustCompile(`<key>ProgramArguments</key>\s*<array>\s*<string>([^<]*)</string>`)
+
+// plistBinaryPath returns the first ProgramArguments entry of a plist.
+func plistBinaryPath(data string) string {
+	if m := plistProgramPattern.FindStringSubmatch(data); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// unitBinaryPath returns the program of a systemd unit's ExecStart line.
+func unitBinaryPath(data string) string {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		if cmd, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "ExecStart="); ok {
+			if fields := strings.Fields(cmd); len(fields) > 0 {
+				return fields[0]
+			}
+		}
+	}
+	return ""
+}
+
+// parseLaunchctlPrint extracts state and pid from `launchctl print` output.
+func parseLaunchctlPrint(out string) (running bool, pid int) {
+	for line := range strings.SplitSeq(out, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), " = ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "state":
+			running = value == "running"
+		case "pid":
+			pid, _ = strconv.Atoi(value)
+		}
+	}
+	return running, pid
+}
+
+// parseSystemctlShow extracts ActiveState and MainPID from
+// `systemctl show --property=ActiveState,MainPID` output.
+func parseSystemctlShow(out string) (running bool, pid int) {
+	for line := range strings.SplitSeq(out, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "ActiveState":
+			running = value == "active"
+		case "MainPID":
+			pid, _ = strconv.Atoi(value)
+		}
+	}
+	return running, pid
+}
+
+// readServiceFile fills in Installed and BinaryPath from the file at path.
+func readServiceFile(state *ServiceState, parse func(string) string) {
+	data, err := os.ReadFile(state.Path)
+	if err != nil {
+		return
+	}
+	state.Installed = true
+	state.BinaryPath = parse(string(data))
+}
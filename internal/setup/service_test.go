@@ -0,0 +1,77 @@
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServiceFileBinaryPath(t *testing.T) {
+	plist := `<plist version="1.0">
+<dict>
+    <key>ProgramArguments</key>
+    <array>
+        <string>/opt/homebrew/bin/paw-proxy</string>
+        <string>run</string>
+    </array>
+</dict>
+</plist>`
+	if got := plistBinaryPath(plist); got != "/opt/homebrew/bin/paw-proxy" {
+		t.Errorf("plistBinaryPath = %q", got)
+	}
+
+	unit := "[Service]\nExecStart=/usr/local/bin/paw-proxy run\nRestart=always\n"
+	if got := unitBinaryPath(unit); got != "/usr/local/bin/paw-proxy" {
+		t.Errorf("unitBinaryPath = %q", got)
+	}
+
+	if plistBinaryPath("<plist/>") != "" || unitBinaryPath("[Service]\n") != "" {
+		t.Error("expected empty path for files without a program")
+	}
+}
+
+func TestParseServiceState(t *testing.T) {
+	running, pid := parseLaunchctlPrint("gui/501/dev.paw-proxy = {\n\tstate = running\n\tpid = 4242\n}\n")
+	if !running || pid != 4242 {
+		t.Errorf("parseLaunchctlPrint = %v, %d", running, pid)
+	}
+	running, _ = parseLaunchctlPrint("gui/501/dev.paw-proxy = {\n\tstate = not running\n}\n")
+	if running {
+		t.Error("expected not running")
+	}
+
+	running, pid = parseSystemctlShow("ActiveState=active\nMainPID=31337\n")
+	if !running || pid != 31337 {
+		t.Errorf("parseSystemctlShow = %v, %d", running, pid)
+	}
+	running, pid = parseSystemctlShow("ActiveState=failed\nMainPID=0\n")
+	if running || pid != 0 {
+		t.Errorf("parseSystemctlShow(failed) = %v, %d", running, pid)
+	}
+}
+
+func TestStableBinaryPath(t *testing.T) {
+	prefix := t.TempDir()
+	cellarBin := filepath.Join(prefix, "Cellar", "paw-proxy", "1.2.3", "bin", "paw-proxy")
+	os.MkdirAll(filepath.Dir(cellarBin), 0755)
+	os.WriteFile(cellarBin, []byte("bin"), 0755)
+	os.MkdirAll(filepath.Join(prefix, "bin"), 0755)
+	link := filepath.Join(prefix, "bin", "paw-proxy")
+	if err := os.Symlink(cellarBin, link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	if got := StableBinaryPath(cellarBin); got != link {
+		t.Errorf("StableBinaryPath(cellar) = %q, want %q", got, link)
+	}
+	if got := StableBinaryPath("/usr/local/bin/paw-proxy"); got != "/usr/local/bin/paw-proxy" {
+		t.Errorf("StableBinaryPath(plain) = %q", got)
+	}
+
+	// A bin link pointing at another version is not substituted.
+	os.Remove(link)
+	os.Symlink(filepath.Join(prefix, "Cellar", "paw-proxy", "9.9.9", "bin", "paw-proxy"), link)
+	if got := StableBinaryPath(cellarBin); got != cellarBin {
+		t.Errorf("StableBinaryPath(mismatched link) = %q, want %q", got, cellarBin)
+	}
+}
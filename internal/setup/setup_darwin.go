@@ -4,6 +4,8 @@
 package setup
 
 import (
+	"crypto/sha1"
+	"encoding/pem"
 	"fmt"
 	"os"
 	"os/exec"
@@ -11,7 +13,9 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/alexcatdad/paw-proxy/internal/i18n"
 	"github.com/alexcatdad/paw-proxy/internal/ssl"
+	"github.com/alexcatdad/paw-proxy/internal/trust"
 )
 
 func Run(config *Config) error {
@@ -19,7 +23,7 @@ func Run(config *Config) error {
 	fmt.Println("================")
 
 	// 1. Create support directory
-	fmt.Printf("\n[1/5] Creating support directory...\n")
+	fmt.Printf("\n[1/6] %s\n", i18n.T("Creating support directory..."))
 	if err := os.MkdirAll(config.SupportDir, 0700); err != nil {
 		return fmt.Errorf("creating support dir: %w", err)
 	}
@@ -31,62 +35,169 @@ func Run(config *Config) error {
 	fmt.Printf("  ✓ %s\n", config.SupportDir)
 
 	// 2. Generate CA
-	fmt.Printf("\n[2/5] Generating CA certificate...\n")
+	fmt.Printf("\n[2/6] %s\n", i18n.T("Generating CA certificate..."))
 	certPath := filepath.Join(config.SupportDir, "ca.crt")
 	keyPath := filepath.Join(config.SupportDir, "ca.key")
 
-	if _, err := os.Stat(certPath); err == nil {
-		fmt.Printf("  ✓ CA already exists\n")
+	if config.SharedCA != nil {
+		changed, err := useSharedCA(config.SharedCA, certPath, keyPath)
+		if err != nil {
+			return err
+		}
+		if changed {
+			fmt.Printf("  ✓ %s\n", i18n.T("Installed shared CA from %s", config.SharedCA.Source))
+		} else {
+			fmt.Printf("  ✓ %s\n", i18n.T("Shared CA already installed"))
+		}
+	} else if _, err := os.Stat(certPath); err == nil {
+		fmt.Printf("  ✓ %s\n", i18n.T("CA already exists"))
 	} else {
-		if err := ssl.GenerateCA(certPath, keyPath); err != nil {
+		if err := ssl.GenerateCA(certPath, keyPath, config.CADomains...); err != nil {
 			return fmt.Errorf("generating CA: %w", err)
 		}
-		fmt.Printf("  ✓ Generated CA certificate\n")
+		fmt.Printf("  ✓ %s\n", i18n.T("Generated CA certificate%s", config.caScope()))
 	}
 	// SECURITY: chown CA files to the real user so the daemon can read them.
 	if err := chownToRealUser(certPath, keyPath); err != nil {
 		return fmt.Errorf("fixing CA file ownership: %w", err)
 	}
+	// Tools that take a single CA file get system roots plus the CA.
+	if bundle, err := RefreshCABundle(config); err != nil {
+		fmt.Fprintf(os.Stderr, "  warning: could not build CA bundle: %v\n", err)
+	} else {
+		fmt.Printf("  ✓ %s\n", i18n.T("CA bundle with system roots at %s", bundle))
+	}
 
 	// 3. Trust CA in keychain
-	fmt.Printf("\n[3/5] Adding CA to keychain...\n")
-	fmt.Printf("  Note: You may be prompted for your password\n")
+	fmt.Printf("\n[3/6] %s\n", i18n.T("Adding CA to keychain..."))
+	fmt.Printf("  %s\n", i18n.T("Note: You may be prompted for your password"))
 	if err := trustCA(certPath); err != nil {
 		return fmt.Errorf("trusting CA: %w", err)
 	}
-	fmt.Printf("  ✓ CA trusted in login keychain\n")
+	fmt.Printf("  ✓ %s\n", i18n.T("CA trusted in login keychain"))
+
+	// 4. Trust CA in Firefox profiles, which don't use the keychain
+	fmt.Printf("\n[4/6] %s\n", i18n.T("Adding CA to Firefox profiles..."))
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	trustBrowsers(certPath, homeDir)
 
-	// 4. Create resolver file
-	fmt.Printf("\n[4/5] Configuring DNS resolver...\n")
+	// 5. Create resolver file
+	fmt.Printf("\n[5/6] %s\n", i18n.T("Configuring DNS resolver..."))
 	if err := configureResolver(config.TLD, config.DNSPort); err != nil {
 		return fmt.Errorf("configuring resolver: %w", err)
 	}
-	fmt.Printf("  ✓ /etc/resolver/%s created\n", config.TLD)
+	fmt.Printf("  ✓ %s\n", i18n.T("/etc/resolver/%s created", config.TLD))
 
-	// 5. Install LaunchAgent
-	fmt.Printf("\n[5/5] Installing daemon...\n")
+	// 6. Install LaunchAgent
+	fmt.Printf("\n[6/6] %s\n", i18n.T("Installing daemon..."))
 	if err := installLaunchAgent(config); err != nil {
 		return fmt.Errorf("installing LaunchAgent: %w", err)
 	}
-	fmt.Printf("  ✓ LaunchAgent installed and started\n")
+	fmt.Printf("  ✓ %s\n", i18n.T("LaunchAgent installed and started"))
+
+	if err := configurePortForward(config); err != nil {
+		return err
+	}
 
 	fmt.Println("\n================")
-	fmt.Println("Setup complete!")
+	fmt.Println(i18n.T("Setup complete!"))
 	fmt.Println("")
-	fmt.Println("Note: macOS may show a 'Background Items Added' notification. This is normal.")
-	fmt.Println("Note: Restart your browser to pick up the new CA certificate.")
+	fmt.Println(i18n.T("Note: macOS may show a 'Background Items Added' notification. This is normal."))
+	fmt.Println(i18n.T("Note: Restart your browser to pick up the new CA certificate."))
 	fmt.Println("")
-	fmt.Println("Firefox users: Install 'nss' for certificate trust:")
-	fmt.Println("  brew install nss")
-	fmt.Println("  paw-proxy setup  (re-run to update Firefox)")
-	fmt.Println("")
-	fmt.Println("Usage:")
-	fmt.Println("  up bun dev           # Start dev server with HTTPS")
-	fmt.Println("  up -n myapp npm start # Custom domain name")
+	fmt.Println(i18n.T("Usage:"))
+	fmt.Println("  up bun dev           # " + i18n.T("Start dev server with HTTPS"))
+	fmt.Println("  up -n myapp npm start # " + i18n.T("Custom domain name"))
 
 	return nil
 }
 
+// PlanSetup returns what Run would do with config, without doing it.
+func PlanSetup(config *Config) (*Plan, error) {
+	plan := &Plan{Title: "paw-proxy setup"}
+
+	s := plan.step("Create support directory")
+	s.mkdir(config.SupportDir)
+	s.chown(config.SupportDir)
+
+	s = plan.step("Generate CA certificate")
+	certPath := filepath.Join(config.SupportDir, "ca.crt")
+	keyPath := filepath.Join(config.SupportDir, "ca.key")
+	if config.SharedCA != nil {
+		s.write(certPath)
+		s.write(keyPath)
+		s.note("Shared CA from " + config.SharedCA.Source)
+	} else if _, err := os.Stat(certPath); err == nil {
+		s.note("CA already exists: " + certPath)
+	} else {
+		s.write(certPath)
+		s.write(keyPath)
+		if len(config.CADomains) > 0 {
+			s.note("CA" + config.caScope())
+		}
+	}
+	s.chown(certPath, keyPath)
+	s.write(trust.CABundlePath(config.SupportDir))
+
+	s = plan.step("Add CA to keychain")
+	keychain := "<login keychain>"
+	if out, err := exec.Command("security", "login-keychain").Output(); err == nil {
+		keychain = strings.TrimSpace(strings.Trim(strings.TrimSpace(string(out)), `"`))
+	}
+	s.run("security", "add-trusted-cert", "-k", keychain, certPath)
+	s.keychain(`add trusted "paw-proxy CA" to ` + keychain)
+	s.note("if that fails, trust it in /Library/Keychains/System.keychain instead:")
+	s.run("security", "add-trusted-cert", "-d", "-r", "trustRoot", "-k", "/Library/Keychains/System.keychain", certPath)
+
+	s = plan.step("Add CA to Firefox profiles")
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	planNSS(s, certPath, homeDir, false)
+
+	s = plan.step("Configure DNS resolver")
+	s.mkdir("/etc/resolver")
+	s.write(filepath.Join("/etc/resolver", config.TLD))
+
+	s = plan.step("Install daemon")
+	plistDir := filepath.Join(homeDir, "Library", "LaunchAgents")
+	plistPath := filepath.Join(plistDir, "dev.paw-proxy.plist")
+	uid, err := resolveRealUID()
+	if err != nil {
+		return nil, fmt.Errorf("resolving user UID: %w", err)
+	}
+	s.mkdir(plistDir)
+	s.run(launchctlCmd("bootout", fmt.Sprintf("gui/%d/dev.paw-proxy", uid)).Args...)
+	s.write(plistPath)
+	s.chown(plistPath)
+	s.run(launchctlCmd("bootstrap", fmt.Sprintf("gui/%d", uid), plistPath).Args...)
+
+	planPortForward(plan, config)
+	return plan, nil
+}
+
+// nssDatabaseGlobs match the NSS databases of Firefox profiles, relative
+// to the home directory. Chrome and Safari use the keychain.
+var nssDatabaseGlobs = []string{
+	"Library/Application Support/Firefox/Profiles/*",
+}
+
+// certutilFallbacks are Homebrew's nss install locations, which are
+// keg-only and not on PATH.
+var certutilFallbacks = []string{
+	"/opt/homebrew/opt/nss/bin/certutil",
+	"/usr/local/opt/nss/bin/certutil",
+}
+
+var nssInstallHint = []string{
+	"Install it with: brew install nss",
+	"Then re-run: sudo paw-proxy setup",
+}
+
 func trustCA(certPath string) error {
 	// Try login keychain first (works for normal user sessions)
 	if out, err := exec.Command("security", "login-keychain").Output(); err == nil {
@@ -107,16 +218,53 @@ func trustCA(certPath string) error {
 	return cmd.Run()
 }
 
+// untrustCA removes the certificate at certPath from the keychains trustCA
+// adds it to. Nothing is removed if the file doesn't exist.
+func untrustCA(certPath string) error {
+	data, err := os.ReadFile(certPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("%s: no PEM certificate", certPath)
+	}
+	sha := fmt.Sprintf("%X", sha1.Sum(block.Bytes))
+
+	removed := false
+	keychains := []string{"/Library/Keychains/System.keychain"}
+	if out, err := exec.Command("security", "login-keychain").Output(); err == nil {
+		keychains = append(keychains, strings.TrimSpace(strings.Trim(strings.TrimSpace(string(out)), `"`)))
+	}
+	for _, keychain := range keychains {
+		if exec.Command("security", "delete-certificate", "-Z", sha, keychain).Run() == nil {
+			removed = true
+		}
+	}
+	if !removed {
+		return fmt.Errorf("certificate %s not found in %s", sha[:8], strings.Join(keychains, ", "))
+	}
+	return nil
+}
+
 func configureResolver(tld string, port int) error {
 	resolverDir := "/etc/resolver"
 	if err := os.MkdirAll(resolverDir, 0755); err != nil {
 		return err
 	}
 
-	content := fmt.Sprintf("# Generated by paw-proxy\nnameserver 127.0.0.1\nport %d\n", port)
 	path := filepath.Join(resolverDir, tld)
 
-	return os.WriteFile(path, []byte(content), 0644)
+	return os.WriteFile(path, []byte(ResolverContent(port)), 0644)
+}
+
+// ResolverContent is the /etc/resolver file that sends a TLD's queries to
+// the daemon's DNS server on port.
+func ResolverContent(port int) string {
+	return fmt.Sprintf("# Generated by paw-proxy\nnameserver 127.0.0.1\nport %d\n", port)
 }
 
 var launchAgentTemplate = `<?xml version="1.0" encoding="UTF-8"?>
@@ -128,7 +276,9 @@ var launchAgentTemplate = `<?xml version="1.0" encoding="UTF-8"?>
     <key>ProgramArguments</key>
     <array>
         <string>{{.BinaryPath}}</string>
-        <string>run</string>
+{{- range .RunArgs}}
+        <string>{{.}}</string>
+{{- end}}
     </array>
     <key>KeepAlive</key>
     <true/>
@@ -137,27 +287,51 @@ var launchAgentTemplate = `<?xml version="1.0" encoding="UTF-8"?>
     <key>Sockets</key>
     <dict>
         <key>http</key>
-        <dict>
-            <key>SockNodeName</key>
-            <string>127.0.0.1</string>
-            <key>SockServiceName</key>
-            <string>80</string>
-            <key>SockType</key>
-            <string>stream</string>
-            <key>SockPassive</key>
-            <true/>
-        </dict>
+        <array>
+            <dict>
+                <key>SockNodeName</key>
+                <string>127.0.0.1</string>
+                <key>SockServiceName</key>
+                <string>{{.HTTPListenPort}}</string>
+                <key>SockType</key>
+                <string>stream</string>
+                <key>SockPassive</key>
+                <true/>
+            </dict>
+            <dict>
+                <key>SockNodeName</key>
+                <string>::1</string>
+                <key>SockServiceName</key>
+                <string>{{.HTTPListenPort}}</string>
+                <key>SockType</key>
+                <string>stream</string>
+                <key>SockPassive</key>
+                <true/>
+            </dict>
+        </array>
         <key>https</key>
-        <dict>
-            <key>SockNodeName</key>
-            <string>127.0.0.1</string>
-            <key>SockServiceName</key>
-            <string>443</string>
-            <key>SockType</key>
-            <string>stream</string>
-            <key>SockPassive</key>
-            <true/>
-        </dict>
+        <array>
+            <dict>
+                <key>SockNodeName</key>
+                <string>127.0.0.1</string>
+                <key>SockServiceName</key>
+                <string>{{.HTTPSListenPort}}</string>
+                <key>SockType</key>
+                <string>stream</string>
+                <key>SockPassive</key>
+                <true/>
+            </dict>
+            <dict>
+                <key>SockNodeName</key>
+                <string>::1</string>
+                <key>SockServiceName</key>
+                <string>{{.HTTPSListenPort}}</string>
+                <key>SockType</key>
+                <string>stream</string>
+                <key>SockPassive</key>
+                <true/>
+            </dict>
+        </array>
     </dict>
 </dict>
 </plist>
@@ -213,13 +387,18 @@ func installLaunchAgent(config *Config) error {
 // a user GUI domain from the root process. Dropping to the real user via
 // "sudo -u" avoids exit status 5 from launchd.
 func launchctlAsUser(args ...string) error {
+	return launchctlCmd(args...).Run()
+}
+
+// launchctlCmd builds a launchctl command run as the real user.
+func launchctlCmd(args ...string) *exec.Cmd {
 	if os.Getuid() == 0 {
 		if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
 			sudoArgs := append([]string{"-u", sudoUser, "launchctl"}, args...)
-			return exec.Command("sudo", sudoArgs...).Run()
+			return exec.Command("sudo", sudoArgs...)
 		}
 	}
-	return exec.Command("launchctl", args...).Run()
+	return exec.Command("launchctl", args...)
 }
 
 // launchctlBootout removes a launchd service and releases its socket
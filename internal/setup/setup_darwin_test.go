@@ -108,4 +108,7 @@ func TestLaunchAgentTemplate_ContainsLoopbackBinding(t *testing.T) {
 	if !strings.Contains(launchAgentTemplate, "<string>127.0.0.1</string>") {
 		t.Error("plist template must bind to 127.0.0.1 for security")
 	}
+	if !strings.Contains(launchAgentTemplate, "<string>::1</string>") {
+		t.Error("plist template must also bind to ::1 for IPv6 clients")
+	}
 }
@@ -7,10 +7,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/alexcatdad/paw-proxy/internal/i18n"
 	"github.com/alexcatdad/paw-proxy/internal/ssl"
+	"github.com/alexcatdad/paw-proxy/internal/trust"
 )
 
 func Run(config *Config) error {
@@ -18,7 +21,7 @@ func Run(config *Config) error {
 	fmt.Println("================")
 
 	// 1. Create support directory
-	fmt.Printf("\n[1/6] Creating support directory...\n")
+	fmt.Printf("\n[1/7] %s\n", i18n.T("Creating support directory..."))
 	if err := os.MkdirAll(config.SupportDir, 0700); err != nil {
 		return fmt.Errorf("creating support dir: %w", err)
 	}
@@ -30,71 +33,246 @@ func Run(config *Config) error {
 	fmt.Printf("  ✓ %s\n", config.SupportDir)
 
 	// 2. Generate CA
-	fmt.Printf("\n[2/6] Generating CA certificate...\n")
+	fmt.Printf("\n[2/7] %s\n", i18n.T("Generating CA certificate..."))
 	certPath := filepath.Join(config.SupportDir, "ca.crt")
 	keyPath := filepath.Join(config.SupportDir, "ca.key")
 
-	if _, err := os.Stat(certPath); err == nil {
-		fmt.Printf("  ✓ CA already exists\n")
+	if config.SharedCA != nil {
+		changed, err := useSharedCA(config.SharedCA, certPath, keyPath)
+		if err != nil {
+			return err
+		}
+		if changed {
+			fmt.Printf("  ✓ %s\n", i18n.T("Installed shared CA from %s", config.SharedCA.Source))
+		} else {
+			fmt.Printf("  ✓ %s\n", i18n.T("Shared CA already installed"))
+		}
+	} else if _, err := os.Stat(certPath); err == nil {
+		fmt.Printf("  ✓ %s\n", i18n.T("CA already exists"))
 	} else {
-		if err := ssl.GenerateCA(certPath, keyPath); err != nil {
+		if err := ssl.GenerateCA(certPath, keyPath, config.CADomains...); err != nil {
 			return fmt.Errorf("generating CA: %w", err)
 		}
-		fmt.Printf("  ✓ Generated CA certificate\n")
+		fmt.Printf("  ✓ %s\n", i18n.T("Generated CA certificate%s", config.caScope()))
 	}
 	// SECURITY: chown CA files to the real user so the daemon can read them.
 	if err := chownToRealUser(certPath, keyPath); err != nil {
 		return fmt.Errorf("fixing CA file ownership: %w", err)
 	}
+	// Tools that take a single CA file get system roots plus the CA.
+	if bundle, err := RefreshCABundle(config); err != nil {
+		fmt.Fprintf(os.Stderr, "  warning: could not build CA bundle: %v\n", err)
+	} else {
+		fmt.Printf("  ✓ %s\n", i18n.T("CA bundle with system roots at %s", bundle))
+	}
 
 	// 3. Trust CA in system store
-	fmt.Printf("\n[3/6] Adding CA to system trust store...\n")
+	fmt.Printf("\n[3/7] %s\n", i18n.T("Adding CA to system trust store..."))
 	if err := trustCA(certPath); err != nil {
 		return fmt.Errorf("trusting CA: %w", err)
 	}
-	fmt.Printf("  ✓ CA trusted in system store\n")
+	fmt.Printf("  ✓ %s\n", i18n.T("CA trusted in system store"))
+
+	// 4. Trust CA in Firefox and Chromium, which use their own NSS databases
+	fmt.Printf("\n[4/7] %s\n", i18n.T("Adding CA to Firefox and Chromium profiles..."))
+	homeDir, err := realUserHomeDir()
+	if err != nil {
+		return fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	trustBrowsers(certPath, homeDir)
 
-	// 4. Configure DNS resolver (systemd-resolved)
-	fmt.Printf("\n[4/6] Configuring DNS resolver (systemd-resolved)...\n")
+	// 5. Configure DNS resolver (systemd-resolved)
+	fmt.Printf("\n[5/7] %s\n", i18n.T("Configuring DNS resolver (systemd-resolved)..."))
 	if err := configureResolver(config.TLD, config.DNSPort); err != nil {
 		return fmt.Errorf("configuring resolver: %w", err)
 	}
-	fmt.Printf("  ✓ systemd-resolved configured for .%s\n", config.TLD)
+	fmt.Printf("  ✓ %s\n", i18n.T("systemd-resolved configured for .%s", config.TLD))
+
+	if config.SocketActivation {
+		// 6. Sockets are bound by systemd, so no capability is needed
+		fmt.Printf("\n[6/7] %s\n", i18n.T("Skipping port binding capabilities (socket activation)..."))
+		fmt.Printf("  ✓ %s\n", i18n.T("systemd will bind ports 80 and 443"))
 
-	// 5. Set capabilities on binary for port 80/443 binding
-	fmt.Printf("\n[5/6] Setting port binding capabilities...\n")
-	if err := setCapabilities(config.BinaryPath); err != nil {
-		return fmt.Errorf("setting capabilities: %w", err)
+		// 7. Install systemd socket units and system service
+		fmt.Printf("\n[7/7] %s\n", i18n.T("Installing systemd socket units..."))
+		if err := installSocketActivation(config); err != nil {
+			return fmt.Errorf("installing socket units: %w", err)
+		}
+		fmt.Printf("  ✓ %s\n", i18n.T("paw-proxy-http.socket, paw-proxy-https.socket, and paw-proxy.service installed and started"))
+	} else {
+		// 6. Set capabilities on binary for port 80/443 binding
+		fmt.Printf("\n[6/7] %s\n", i18n.T("Setting port binding capabilities..."))
+		if err := setCapabilities(config.BinaryPath); err != nil {
+			return fmt.Errorf("setting capabilities: %w", err)
+		}
+		fmt.Printf("  ✓ %s\n", i18n.T("cap_net_bind_service set on %s", config.BinaryPath))
+
+		// 7. Install systemd user service
+		fmt.Printf("\n[7/7] %s\n", i18n.T("Installing systemd user service..."))
+		if err := removeSocketActivation(); err != nil {
+			return fmt.Errorf("removing socket units: %w", err)
+		}
+		if err := installSystemdUnit(config); err != nil {
+			return fmt.Errorf("installing systemd unit: %w", err)
+		}
+		fmt.Printf("  ✓ %s\n", i18n.T("systemd user service installed and started"))
 	}
-	fmt.Printf("  ✓ cap_net_bind_service set on %s\n", config.BinaryPath)
 
-	// 6. Install systemd user service
-	fmt.Printf("\n[6/6] Installing systemd user service...\n")
-	if err := installSystemdUnit(config); err != nil {
-		return fmt.Errorf("installing systemd unit: %w", err)
+	if err := configurePortForward(config); err != nil {
+		return err
 	}
-	fmt.Printf("  ✓ systemd user service installed and started\n")
 
 	fmt.Println("\n================")
-	fmt.Println("Setup complete!")
-	fmt.Println("")
-	fmt.Println("Note: Restart your browser to pick up the new CA certificate.")
-	fmt.Println("")
-	fmt.Println("Firefox users: Install NSS tools for certificate trust:")
-	fmt.Println("  sudo apt install libnss3-tools   # Debian/Ubuntu")
-	fmt.Println("  sudo dnf install nss-tools        # Fedora/RHEL")
-	fmt.Println("  sudo paw-proxy setup              # Re-run to update Firefox")
+	fmt.Println(i18n.T("Setup complete!"))
 	fmt.Println("")
-	fmt.Println("Note: If you upgrade the binary, re-run 'sudo paw-proxy setup'")
-	fmt.Println("      to restore port binding capabilities.")
+	fmt.Println(i18n.T("Note: Restart your browser to pick up the new CA certificate."))
 	fmt.Println("")
-	fmt.Println("Usage:")
-	fmt.Println("  up bun dev           # Start dev server with HTTPS")
-	fmt.Println("  up -n myapp npm start # Custom domain name")
+	if !config.SocketActivation {
+		fmt.Println(i18n.T("Note: If you upgrade the binary, run 'sudo paw-proxy doctor --fix'\n" +
+			"      to restore port binding capabilities, or re-run setup with\n" +
+			"      --socket-activation to stop needing them."))
+		fmt.Println("")
+	}
+	fmt.Println(i18n.T("Usage:"))
+	fmt.Println("  up bun dev           # " + i18n.T("Start dev server with HTTPS"))
+	fmt.Println("  up -n myapp npm start # " + i18n.T("Custom domain name"))
 
 	return nil
 }
 
+// PlanSetup returns what Run would do with config, without doing it.
+func PlanSetup(config *Config) (*Plan, error) {
+	plan := &Plan{Title: "paw-proxy setup"}
+
+	s := plan.step("Create support directory")
+	s.mkdir(config.SupportDir)
+	s.chown(config.SupportDir)
+
+	s = plan.step("Generate CA certificate")
+	certPath := filepath.Join(config.SupportDir, "ca.crt")
+	keyPath := filepath.Join(config.SupportDir, "ca.key")
+	if config.SharedCA != nil {
+		s.write(certPath)
+		s.write(keyPath)
+		s.note("Shared CA from " + config.SharedCA.Source)
+	} else if _, err := os.Stat(certPath); err == nil {
+		s.note("CA already exists: " + certPath)
+	} else {
+		s.write(certPath)
+		s.write(keyPath)
+		if len(config.CADomains) > 0 {
+			s.note("CA" + config.caScope())
+		}
+	}
+	s.chown(certPath, keyPath)
+	s.write(trust.CABundlePath(config.SupportDir))
+
+	s = plan.step("Add CA to system trust store")
+	planTrustCA(s)
+
+	homeDir, err := realUserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	s = plan.step("Add CA to Firefox and Chromium profiles")
+	planNSS(s, certPath, homeDir, false)
+
+	s = plan.step("Configure DNS resolver (systemd-resolved)")
+	s.mkdir("/etc/systemd/resolved.conf.d")
+	s.write("/etc/systemd/resolved.conf.d/paw-proxy.conf")
+	s.run("systemctl", "restart", "systemd-resolved")
+
+	unitDir := filepath.Join(homeDir, ".config", "systemd", "user")
+	userUnit := filepath.Join(unitDir, "paw-proxy.service")
+
+	if config.SocketActivation {
+		s = plan.step("Skip port binding capabilities (socket activation)")
+		s.note("systemd will bind ports " + strconv.Itoa(config.HTTPListenPort()) + " and " + strconv.Itoa(config.HTTPSListenPort()))
+
+		s = plan.step("Install systemd socket units")
+		if _, err := os.Stat(userUnit); err == nil {
+			planUserSystemctl(s, "disable", "--now", "paw-proxy")
+			s.remove(userUnit)
+			planUserSystemctl(s, "daemon-reload")
+		}
+		for _, name := range socketUnitNames {
+			s.write(socketUnitPath(name))
+		}
+		s.write(systemServicePath())
+		sockets := []string{"paw-proxy-http.socket", "paw-proxy-https.socket"}
+		s.run("systemctl", "daemon-reload")
+		s.run(append([]string{"systemctl", "enable"}, sockets...)...)
+		s.run("systemctl", "enable", "paw-proxy")
+		s.run(append([]string{"systemctl", "restart"}, sockets...)...)
+		s.run("systemctl", "restart", "paw-proxy")
+	} else {
+		s = plan.step("Set port binding capabilities")
+		s.run("setcap", "cap_net_bind_service=+ep", config.BinaryPath)
+
+		s = plan.step("Install systemd user service")
+		planRemoveSocketActivation(s)
+		s.mkdir(unitDir)
+		s.write(userUnit)
+		s.chown(userUnit, unitDir, filepath.Dir(unitDir), filepath.Join(homeDir, ".config"))
+		planUserSystemctl(s, "daemon-reload")
+		planUserSystemctl(s, "enable", "--now", "paw-proxy")
+	}
+
+	planPortForward(plan, config)
+	return plan, nil
+}
+
+// planTrustCA records what trustCA would do with the trust tool found on
+// this system.
+func planTrustCA(s *PlanStep) {
+	if _, err := exec.LookPath("update-ca-certificates"); err == nil {
+		s.write("/usr/local/share/ca-certificates/paw-proxy-ca.crt")
+		s.run("update-ca-certificates")
+		return
+	}
+	if _, err := exec.LookPath("update-ca-trust"); err == nil {
+		s.write("/etc/pki/ca-trust/source/anchors/paw-proxy-ca.crt")
+		s.run("update-ca-trust")
+		return
+	}
+	s.note("no supported CA trust tool found (need update-ca-certificates or update-ca-trust)")
+}
+
+// planUserSystemctl records a systemctl --user command as the real user
+// would run it.
+func planUserSystemctl(s *PlanStep, args ...string) {
+	cmd, err := systemctlUserCmd(args...)
+	if err != nil {
+		s.run(append([]string{"systemctl", "--user"}, args...)...)
+		return
+	}
+	s.run(cmd.Args...)
+}
+
+// nssDatabaseGlobs match the NSS databases of Firefox profiles and the
+// shared Chromium/Chrome database, relative to the home directory,
+// including Snap and Flatpak installs.
+var nssDatabaseGlobs = []string{
+	".mozilla/firefox/*",
+	"snap/firefox/common/.mozilla/firefox/*",
+	".var/app/org.mozilla.firefox/.mozilla/firefox/*",
+	".pki/nssdb",
+	"snap/chromium/current/.pki/nssdb",
+	".var/app/org.chromium.Chromium/.pki/nssdb",
+	".var/app/com.google.Chrome/.pki/nssdb",
+	".var/app/com.brave.Browser/.pki/nssdb",
+}
+
+// certutilFallbacks are certutil locations off PATH. Distributions
+// install it on PATH.
+var certutilFallbacks []string
+
+var nssInstallHint = []string{
+	"Install it with: sudo apt install libnss3-tools   # Debian/Ubuntu",
+	"             or: sudo dnf install nss-tools        # Fedora/RHEL",
+	"Then re-run: sudo paw-proxy setup",
+}
+
 // trustCA installs the CA certificate into the system trust store.
 // Supports Debian/Ubuntu (update-ca-certificates) and Fedora/RHEL/Arch (update-ca-trust).
 func trustCA(certPath string) error {
@@ -125,6 +303,12 @@ func trustCA(certPath string) error {
 	return fmt.Errorf("no supported CA trust tool found (need update-ca-certificates or update-ca-trust)")
 }
 
+// untrustCA is a no-op: trustCA installs the CA under a fixed name, so the
+// new certificate replaces the old one.
+func untrustCA(certPath string) error {
+	return nil
+}
+
 // configureResolver sets up a systemd-resolved stub zone for the .test TLD.
 // Requires systemd 247+ for non-standard port syntax in DNS= directive.
 func configureResolver(tld string, port int) error {
@@ -172,7 +356,7 @@ Description=paw-proxy local HTTPS proxy
 After=network.target
 
 [Service]
-ExecStart={{.BinaryPath}} run
+ExecStart={{.BinaryPath}}{{range .RunArgs}} {{.}}{{end}}
 Restart=always
 RestartSec=1s
 
@@ -229,29 +413,34 @@ func installSystemdUnit(config *Config) error {
 }
 
 // systemctlAsUser runs a systemctl --user subcommand as the real user.
+func systemctlAsUser(args ...string) error {
+	cmd, err := systemctlUserCmd(args...)
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// systemctlUserCmd builds a systemctl --user command for the real user.
 // When running under sudo, XDG_RUNTIME_DIR and DBUS_SESSION_BUS_ADDRESS
 // must be explicitly set for the real user's session.
-func systemctlAsUser(args ...string) error {
+func systemctlUserCmd(args ...string) (*exec.Cmd, error) {
 	if os.Getuid() == 0 {
 		if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
 			uid, err := resolveRealUID()
 			if err != nil {
-				return err
+				return nil, err
 			}
 			xdgRuntime := fmt.Sprintf("XDG_RUNTIME_DIR=/run/user/%d", uid)
 			dbusAddr := fmt.Sprintf("DBUS_SESSION_BUS_ADDRESS=unix:path=/run/user/%d/bus", uid)
 			sudoArgs := []string{"-u", sudoUser, "env", xdgRuntime, dbusAddr, "systemctl", "--user"}
 			sudoArgs = append(sudoArgs, args...)
-			cmd := exec.Command("sudo", sudoArgs...)
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			return cmd.Run()
+			return exec.Command("sudo", sudoArgs...), nil
 		}
 	}
-	cmd := exec.Command("systemctl", append([]string{"--user"}, args...)...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return exec.Command("systemctl", append([]string{"--user"}, args...)...), nil
 }
 
 // realUserHomeDir returns the home directory of the real user (not root)
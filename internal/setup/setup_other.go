@@ -11,3 +11,11 @@ func Run(config *Config) error {
 func Uninstall(supportDir, tld string, fromBrew bool) error {
 	return fmt.Errorf("paw-proxy uninstall only supports macOS and Linux")
 }
+
+func PlanSetup(config *Config) (*Plan, error) {
+	return nil, fmt.Errorf("paw-proxy setup only supports macOS and Linux")
+}
+
+func PlanUninstall(supportDir, tld string, fromBrew bool) (*Plan, error) {
+	return nil, fmt.Errorf("paw-proxy uninstall only supports macOS and Linux")
+}
@@ -0,0 +1,141 @@
+package setup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxSharedCASize bounds a downloaded or read CA file.
+const maxSharedCASize = 1 << 20
+
+// sharedCAClient fetches CAs from URLs; tests replace it.
+var sharedCAClient = &http.Client{Timeout: 30 * time.Second}
+
+// SharedCA is a CA a team shares instead of each machine generating its
+// own, so browsers and devices trust every teammate's certificates, and
+// device management can pre-trust it fleet-wide.
+type SharedCA struct {
+	Cert []byte
+	Key  []byte
+	// Source describes where it came from, for setup's output.
+	Source string
+}
+
+// LoadSharedCA reads a shared CA from its certificate and key files.
+func LoadSharedCA(certPath, keyPath string) (*SharedCA, error) {
+	cert, err := readLimited(certPath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := readLimited(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	return &SharedCA{Cert: cert, Key: key, Source: certPath}, nil
+}
+
+// FetchSharedCA downloads a PEM file holding the CA certificate and its
+// key, e.g. from an internal server, and checks it against sha256Hex
+// before using it.
+func FetchSharedCA(ctx context.Context, rawURL, sha256Hex string) (*SharedCA, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("CA URL must be an https:// URL")
+	}
+	want, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(sha256Hex), "sha256:"))
+	if err != nil || len(want) != sha256.Size {
+		return nil, fmt.Errorf("CA checksum must be a hex SHA-256")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sharedCAClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading CA: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading CA: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSharedCASize+1))
+	if err != nil {
+		return nil, fmt.Errorf("downloading CA: %w", err)
+	}
+	if len(data) > maxSharedCASize {
+		return nil, fmt.Errorf("downloading CA: larger than %d bytes", maxSharedCASize)
+	}
+	// SECURITY: the file carries a key every machine will trust; only
+	// use exactly the one the team published.
+	if sum := sha256.Sum256(data); !bytes.Equal(sum[:], want) {
+		return nil, fmt.Errorf("CA checksum mismatch: got %x", sum)
+	}
+
+	ca := &SharedCA{Source: rawURL}
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		switch {
+		case block.Type == "CERTIFICATE" && ca.Cert == nil:
+			ca.Cert = pem.EncodeToMemory(block)
+		case strings.HasSuffix(block.Type, "PRIVATE KEY") && ca.Key == nil:
+			ca.Key = pem.EncodeToMemory(block)
+		}
+	}
+	if ca.Cert == nil || ca.Key == nil {
+		return nil, fmt.Errorf("%s must hold a PEM certificate and its private key", rawURL)
+	}
+	return ca, nil
+}
+
+// Validate checks that ca is a CA certificate with its own key, valid now.
+func (ca *SharedCA) Validate() (*x509.Certificate, error) {
+	pair, err := tls.X509KeyPair(ca.Cert, ca.Key)
+	if err != nil {
+		return nil, fmt.Errorf("shared CA: %w", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("shared CA: %w", err)
+	}
+	if !cert.IsCA || !cert.BasicConstraintsValid {
+		return nil, fmt.Errorf("shared CA: %s is not a CA certificate", cert.Subject.CommonName)
+	}
+	if now := time.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, fmt.Errorf("shared CA: %s is not valid now (%s to %s)", cert.Subject.CommonName,
+			cert.NotBefore.Format("2006-01-02"), cert.NotAfter.Format("2006-01-02"))
+	}
+	return cert, nil
+}
+
+func readLimited(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxSharedCASize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSharedCASize {
+		return nil, fmt.Errorf("%s: larger than %d bytes", path, maxSharedCASize)
+	}
+	return data, nil
+}
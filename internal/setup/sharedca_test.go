@@ -0,0 +1,104 @@
+package setup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexcatdad/paw-proxy/internal/ssl"
+)
+
+// writeTestCA generates a CA and returns the paths of its files.
+func writeTestCA(t *testing.T) (certPath, keyPath string) {
+	t.Helper()
+	dir := t.TempDir()
+	certPath, keyPath = filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := ssl.GenerateCA(certPath, keyPath, "test"); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func TestLoadSharedCA(t *testing.T) {
+	certPath, keyPath := writeTestCA(t)
+	ca, err := LoadSharedCA(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ca.Validate()
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(cert.PermittedDNSDomains) != 1 || cert.PermittedDNSDomains[0] != "test" {
+		t.Errorf("PermittedDNSDomains = %v", cert.PermittedDNSDomains)
+	}
+
+	// The key of another CA doesn't match.
+	_, otherKey := writeTestCA(t)
+	ca, err = LoadSharedCA(certPath, otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ca.Validate(); err == nil {
+		t.Error("mismatched key accepted")
+	}
+}
+
+func TestFetchSharedCA(t *testing.T) {
+	certPath, keyPath := writeTestCA(t)
+	cert, _ := os.ReadFile(certPath)
+	key, _ := os.ReadFile(keyPath)
+	bundle := append(append([]byte{}, cert...), key...)
+	sum := sha256.Sum256(bundle)
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ca.pem":
+			w.Write(bundle)
+		case "/cert-only.pem":
+			w.Write(cert)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	orig := sharedCAClient
+	sharedCAClient = srv.Client()
+	defer func() { sharedCAClient = orig }()
+
+	ca, err := FetchSharedCA(context.Background(), srv.URL+"/ca.pem", hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ca.Cert) != string(cert) || string(ca.Key) != string(key) {
+		t.Error("certificate or key not split out of the bundle")
+	}
+	if _, err := ca.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	certSum := sha256.Sum256(cert)
+	tests := []struct {
+		name, url, sum, want string
+	}{
+		{"checksum mismatch", srv.URL + "/ca.pem", strings.Repeat("00", 32), "checksum mismatch"},
+		{"bad checksum", srv.URL + "/ca.pem", "abc", "hex SHA-256"},
+		{"plain http", strings.Replace(srv.URL, "https:", "http:", 1) + "/ca.pem", hex.EncodeToString(sum[:]), "https://"},
+		{"not found", srv.URL + "/missing.pem", hex.EncodeToString(sum[:]), "404"},
+		{"no key", srv.URL + "/cert-only.pem", hex.EncodeToString(certSum[:]), "private key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FetchSharedCA(context.Background(), tt.url, tt.sum)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("FetchSharedCA() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+//go:build linux
+
+package setup
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+)
+
+// systemUnitDir holds the units installed for socket activation. User
+// units can't listen on ports below 1024, so socket activation needs
+// system units; the service still runs as the real user via User=.
+const systemUnitDir = "/etc/systemd/system"
+
+var socketUnitTemplate = `# Generated by paw-proxy
+[Unit]
+Description=paw-proxy {{.Name}} socket
+
+[Socket]
+ListenStream=127.0.0.1:{{.Port}}
+ListenStream=[::1]:{{.Port}}
+# Bind ::1 even before (or without) IPv6 on lo, instead of failing the unit.
+FreeBind=true
+FileDescriptorName={{.Name}}
+Service=paw-proxy.service
+
+[Install]
+WantedBy=sockets.target
+`
+
+var systemServiceTemplate = `# Generated by paw-proxy
+[Unit]
+Description=paw-proxy local HTTPS proxy
+Requires=paw-proxy-http.socket paw-proxy-https.socket
+After=network.target paw-proxy-http.socket paw-proxy-https.socket
+
+[Service]
+ExecStart={{.BinaryPath}}{{range .RunArgs}} {{.}}{{end}}
+User={{.User}}
+Restart=always
+RestartSec=1s
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// socketUnit is one listening socket passed to the daemon, named to match
+// the name it asks for in systemd.ActivateSocket.
+type socketUnit struct {
+	Name string
+	Port int
+}
+
+// socketUnitNames are the names of the socket units, without suffix.
+var socketUnitNames = []string{"http", "https"}
+
+func socketUnits(config *Config) []socketUnit {
+	return []socketUnit{
+		{"http", config.HTTPListenPort()},
+		{"https", config.HTTPSListenPort()},
+	}
+}
+
+func systemServicePath() string {
+	return filepath.Join(systemUnitDir, "paw-proxy.service")
+}
+
+func socketUnitPath(name string) string {
+	return filepath.Join(systemUnitDir, "paw-proxy-"+name+".socket")
+}
+
+// UsesSocketActivation reports whether setup installed the systemd socket
+// units, in which case the daemon needs no setcap capability.
+func UsesSocketActivation() bool {
+	_, err := os.Stat(systemServicePath())
+	return err == nil
+}
+
+// installSocketActivation installs system socket units for ports 80/443
+// and a system service that runs the daemon as the real user, replacing
+// any user-level unit. Requires root.
+func installSocketActivation(config *Config) error {
+	user := os.Getenv("SUDO_USER")
+	if os.Getuid() != 0 || user == "" || user == "root" {
+		return fmt.Errorf("socket activation must be set up with sudo from your own account")
+	}
+
+	// Stop the user-level service so it releases the ports.
+	if homeDir, err := realUserHomeDir(); err == nil {
+		userUnit := filepath.Join(homeDir, ".config", "systemd", "user", "paw-proxy.service")
+		if _, err := os.Stat(userUnit); err == nil {
+			systemctlAsUser("disable", "--now", "paw-proxy") //nolint:errcheck // not fatal if not loaded
+			if err := os.Remove(userUnit); err != nil {
+				return fmt.Errorf("removing user unit: %w", err)
+			}
+			systemctlAsUser("daemon-reload") //nolint:errcheck // best effort
+		}
+	}
+
+	socketTmpl, err := template.New("socket").Parse(socketUnitTemplate)
+	if err != nil {
+		return fmt.Errorf("parsing socket unit template: %w", err)
+	}
+	for _, unit := range socketUnits(config) {
+		if err := writeTemplate(socketUnitPath(unit.Name), socketTmpl, unit); err != nil {
+			return err
+		}
+	}
+
+	serviceTmpl, err := template.New("service").Parse(systemServiceTemplate)
+	if err != nil {
+		return fmt.Errorf("parsing service unit template: %w", err)
+	}
+	data := struct {
+		BinaryPath string
+		RunArgs    []string
+		User       string
+	}{config.BinaryPath, config.RunArgs(), user}
+	if err := writeTemplate(systemServicePath(), serviceTmpl, data); err != nil {
+		return err
+	}
+
+	if err := systemctl("daemon-reload"); err != nil {
+		return fmt.Errorf("daemon-reload: %w", err)
+	}
+	// Restart the sockets so a changed port or address is re-bound.
+	args := []string{"enable"}
+	for _, name := range socketUnitNames {
+		args = append(args, "paw-proxy-"+name+".socket")
+	}
+	if err := systemctl(args...); err != nil {
+		return fmt.Errorf("enabling sockets: %w", err)
+	}
+	if err := systemctl("enable", "paw-proxy"); err != nil {
+		return fmt.Errorf("enabling service: %w", err)
+	}
+	if err := systemctl(append([]string{"restart"}, args[1:]...)...); err != nil {
+		return fmt.Errorf("starting sockets: %w", err)
+	}
+	if err := systemctl("restart", "paw-proxy"); err != nil {
+		return fmt.Errorf("starting service: %w", err)
+	}
+	return nil
+}
+
+// removeSocketActivation stops and removes the units installed by
+// installSocketActivation. Missing units are not an error.
+func removeSocketActivation() error {
+	if !UsesSocketActivation() {
+		return nil
+	}
+	units := []string{"paw-proxy"}
+	for _, name := range socketUnitNames {
+		units = append(units, "paw-proxy-"+name+".socket")
+	}
+	systemctl(append([]string{"disable", "--now"}, units...)...) //nolint:errcheck // not fatal if not loaded
+
+	paths := []string{systemServicePath()}
+	for _, name := range socketUnitNames {
+		paths = append(paths, socketUnitPath(name))
+	}
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing %s: %w", p, err)
+		}
+	}
+	return systemctl("daemon-reload")
+}
+
+// planRemoveSocketActivation records what removeSocketActivation would do.
+func planRemoveSocketActivation(s *PlanStep) {
+	if !UsesSocketActivation() {
+		return
+	}
+	units := []string{"paw-proxy"}
+	for _, name := range socketUnitNames {
+		units = append(units, "paw-proxy-"+name+".socket")
+	}
+	s.run(append([]string{"systemctl", "disable", "--now"}, units...)...)
+	s.remove(systemServicePath())
+	for _, name := range socketUnitNames {
+		s.remove(socketUnitPath(name))
+	}
+	s.run("systemctl", "daemon-reload")
+}
+
+func writeTemplate(path string, tmpl *template.Template, data any) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	defer f.Close()
+	if err := tmpl.Execute(f, data); err != nil {
+		return fmt.Errorf("rendering %s: %w", path, err)
+	}
+	return nil
+}
+
+// systemctl runs a system-level systemctl subcommand.
+func systemctl(args ...string) error {
+	cmd := exec.Command("systemctl", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
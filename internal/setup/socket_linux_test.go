@@ -0,0 +1,48 @@
+//go:build linux
+
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestSocketUnitTemplates(t *testing.T) {
+	dir := t.TempDir()
+
+	socketTmpl := template.Must(template.New("socket").Parse(socketUnitTemplate))
+	path := filepath.Join(dir, "paw-proxy-https.socket")
+	if err := writeTemplate(path, socketTmpl, socketUnits(&Config{})[1]); err != nil {
+		t.Fatalf("writeTemplate: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"ListenStream=127.0.0.1:443", "ListenStream=[::1]:443", "FileDescriptorName=https", "Service=paw-proxy.service"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("socket unit missing %q:\n%s", want, data)
+		}
+	}
+
+	serviceTmpl := template.Must(template.New("service").Parse(systemServiceTemplate))
+	path = filepath.Join(dir, "paw-proxy.service")
+	unitData := struct {
+		BinaryPath string
+		RunArgs    []string
+		User       string
+	}{"/usr/local/bin/paw-proxy", []string{"run", "--https-port", "8443"}, "dev"}
+	if err := writeTemplate(path, serviceTmpl, unitData); err != nil {
+		t.Fatalf("writeTemplate: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if got := unitBinaryPath(string(data)); got != "/usr/local/bin/paw-proxy" {
+		t.Errorf("unitBinaryPath = %q", got)
+	}
+	if !strings.Contains(string(data), "ExecStart=/usr/local/bin/paw-proxy run --https-port 8443\n") {
+		t.Errorf("service unit missing ExecStart args:\n%s", data)
+	}
+	if !strings.Contains(string(data), "User=dev") {
+		t.Errorf("service unit missing User=:\n%s", data)
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/alexcatdad/paw-proxy/internal/i18n"
 )
 
 func Uninstall(supportDir, tld string, fromBrew bool) error {
@@ -27,42 +29,47 @@ func Uninstall(supportDir, tld string, fromBrew bool) error {
 	fmt.Println("===================")
 
 	// 1. Stop and remove LaunchAgent (bootout releases socket reservations, unlike unload)
-	fmt.Printf("\n[1/3] Removing daemon...\n")
+	fmt.Printf("\n[1/3] %s\n", i18n.T("Removing daemon..."))
 	if err := launchctlBootout(); err != nil {
 		fmt.Fprintf(os.Stderr, "  warning: could not bootout LaunchAgent: %v\n", err)
 		// Not fatal — agent may not be loaded
 	}
 	if err := os.Remove(plistPath); err != nil {
 		if os.IsNotExist(err) {
-			fmt.Printf("  LaunchAgent not found (already removed)\n")
+			fmt.Printf("  %s\n", i18n.T("LaunchAgent not found (already removed)"))
 		} else {
 			errs = append(errs, fmt.Errorf("removing LaunchAgent plist: %w", err))
 			fmt.Fprintf(os.Stderr, "  warning: could not remove plist: %v\n", err)
 		}
 	} else {
-		fmt.Printf("  LaunchAgent removed\n")
+		fmt.Printf("  %s\n", i18n.T("LaunchAgent removed"))
+	}
+
+	if err := removePortForward(); err != nil {
+		errs = append(errs, err)
+		fmt.Fprintf(os.Stderr, "  warning: could not remove port forwarding: %v\n", err)
 	}
 
 	// 2. Remove resolver
-	fmt.Printf("\n[2/3] Removing DNS resolver...\n")
+	fmt.Printf("\n[2/3] %s\n", i18n.T("Removing DNS resolver..."))
 	if err := os.Remove(resolverPath); err != nil {
 		if os.IsNotExist(err) {
-			fmt.Printf("  /etc/resolver/%s not found (already removed)\n", tld)
+			fmt.Printf("  %s\n", i18n.T("/etc/resolver/%s not found (already removed)", tld))
 		} else {
 			errs = append(errs, fmt.Errorf("removing resolver file: %w", err))
 			fmt.Fprintf(os.Stderr, "  warning: could not remove /etc/resolver/%s: %v\n", tld, err)
 		}
 	} else {
-		fmt.Printf("  /etc/resolver/%s removed\n", tld)
+		fmt.Printf("  %s\n", i18n.T("/etc/resolver/%s removed", tld))
 	}
 
 	// 3. Remove CA (prompt unless --brew)
 	removeCA := fromBrew
 	if !fromBrew {
-		fmt.Printf("\n[3/3] Remove CA certificate from keychain? [y/N] ")
+		fmt.Printf("\n[3/3] %s ", i18n.T("Remove CA certificate from keychain? [y/N]"))
 		reader := bufio.NewReader(os.Stdin)
 		answer, _ := reader.ReadString('\n')
-		removeCA = strings.ToLower(strings.TrimSpace(answer)) == "y"
+		removeCA = i18n.Yes(answer)
 	}
 
 	if removeCA {
@@ -74,7 +81,7 @@ func Uninstall(supportDir, tld string, fromBrew bool) error {
 			// Only treat this specific code as "not found"; other errors (permission
 			// denied, keychain locked/corrupted) are real failures.
 			if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 44 {
-				fmt.Println("  No CA certificates found in keychain")
+				fmt.Println("  " + i18n.T("No CA certificates found in keychain"))
 			} else {
 				errs = append(errs, fmt.Errorf("finding CA certificates: %w", err))
 				fmt.Fprintf(os.Stderr, "  warning: security find-certificate failed: %s\n", strings.TrimSpace(string(out)))
@@ -94,31 +101,92 @@ func Uninstall(supportDir, tld string, fromBrew bool) error {
 							errs = append(errs, fmt.Errorf("removing cert %s: %w", short, err))
 							fmt.Fprintf(os.Stderr, "  warning: could not remove certificate %s: %v\n", short, err)
 						} else {
-							fmt.Printf("  Removed certificate %s\n", short)
+							fmt.Printf("  %s\n", i18n.T("Removed certificate %s", short))
 						}
 					}
 				}
 			}
 		}
 
+		// Remove from Firefox/Chromium NSS databases
+		results, err := untrustNSS(homeDir)
+		if err == nil {
+			if failed := printNSSResults(results, "removed from"); failed > 0 {
+				errs = append(errs, fmt.Errorf("removing CA from %d browser profile(s)", failed))
+			}
+		} else if len(nssDatabases(homeDir)) > 0 {
+			fmt.Fprintf(os.Stderr, "  warning: certutil not found; remove %q from Firefox manually\n", nssNickname)
+		}
+
 		// Remove support directory
 		if err := os.RemoveAll(supportDir); err != nil {
 			errs = append(errs, fmt.Errorf("removing support directory: %w", err))
 			fmt.Fprintf(os.Stderr, "  warning: could not remove support directory: %v\n", err)
 		} else {
-			fmt.Printf("  Support directory removed\n")
+			fmt.Printf("  %s\n", i18n.T("Support directory removed"))
 		}
 	} else {
-		fmt.Printf("  CA kept in keychain\n")
+		fmt.Printf("  %s\n", i18n.T("CA kept in keychain"))
 	}
 
 	fmt.Println("\n===================")
 
 	if len(errs) > 0 {
-		fmt.Println("Uninstall completed with errors.")
+		fmt.Println(i18n.T("Uninstall completed with errors."))
 		return fmt.Errorf("uninstall completed with errors: %w", errors.Join(errs...))
 	}
 
-	fmt.Println("Uninstall complete!")
+	fmt.Println(i18n.T("Uninstall complete!"))
 	return nil
 }
+
+// PlanUninstall returns what Uninstall would do, without doing it. The
+// CA and support directory are listed under a step that Uninstall asks
+// about first unless fromBrew is set.
+func PlanUninstall(supportDir, tld string, fromBrew bool) (*Plan, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("getting home directory: %w", err)
+	}
+	uid, err := resolveRealUID()
+	if err != nil {
+		return nil, fmt.Errorf("resolving user UID: %w", err)
+	}
+	plan := &Plan{Title: "paw-proxy uninstall"}
+
+	s := plan.step("Remove daemon")
+	s.run(launchctlCmd("bootout", fmt.Sprintf("gui/%d/dev.paw-proxy", uid)).Args...)
+	s.remove(filepath.Join(homeDir, "Library", "LaunchAgents", "dev.paw-proxy.plist"))
+	planRemovePortForward(s)
+
+	s = plan.step("Remove DNS resolver")
+	s.remove(filepath.Join("/etc/resolver", tld))
+
+	name := "Remove CA certificate from keychain"
+	if !fromBrew {
+		name += " (asks first)"
+	}
+	s = plan.step(name)
+	keychainPath := filepath.Join(homeDir, "Library", "Keychains", "login.keychain-db")
+	s.run("security", "find-certificate", "-a", "-c", "paw-proxy CA", "-Z", keychainPath)
+	out, err := exec.Command("security", "find-certificate", "-a", "-c", "paw-proxy CA", "-Z", keychainPath).Output()
+	found := 0
+	if err == nil {
+		for _, line := range strings.Split(string(out), "\n") {
+			line = strings.TrimSpace(line)
+			if sha, ok := strings.CutPrefix(line, "SHA-1 hash:"); ok {
+				if sha = strings.TrimSpace(sha); sha != "" {
+					s.run("security", "delete-certificate", "-Z", sha, keychainPath)
+					s.keychain(`remove "paw-proxy CA" ` + sha + " from " + keychainPath)
+					found++
+				}
+			}
+		}
+	}
+	if found == 0 {
+		s.note("no paw-proxy CA certificates found in " + keychainPath)
+	}
+	planNSS(s, "", homeDir, true)
+	s.remove(supportDir + " (recursively)")
+	return plan, nil
+}
@@ -9,7 +9,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
+
+	"github.com/alexcatdad/paw-proxy/internal/i18n"
 )
 
 func Uninstall(supportDir, tld string, fromBrew bool) error {
@@ -26,7 +27,15 @@ func Uninstall(supportDir, tld string, fromBrew bool) error {
 	fmt.Println("===================")
 
 	// 1. Stop and remove systemd user service
-	fmt.Printf("\n[1/3] Removing daemon...\n")
+	fmt.Printf("\n[1/3] %s\n", i18n.T("Removing daemon..."))
+	if UsesSocketActivation() {
+		if err := removeSocketActivation(); err != nil {
+			errs = append(errs, err)
+			fmt.Fprintf(os.Stderr, "  warning: could not remove socket units: %v\n", err)
+		} else {
+			fmt.Printf("  %s\n", i18n.T("Systemd socket units removed"))
+		}
+	}
 	if err := systemctlAsUser("disable", "--now", "paw-proxy"); err != nil {
 		fmt.Fprintf(os.Stderr, "  warning: could not disable service: %v\n", err)
 		// Not fatal — service may not be loaded
@@ -36,26 +45,31 @@ func Uninstall(supportDir, tld string, fromBrew bool) error {
 	}
 	if err := os.Remove(unitPath); err != nil {
 		if os.IsNotExist(err) {
-			fmt.Printf("  Service unit not found (already removed)\n")
+			fmt.Printf("  %s\n", i18n.T("Service unit not found (already removed)"))
 		} else {
 			errs = append(errs, fmt.Errorf("removing unit file: %w", err))
 			fmt.Fprintf(os.Stderr, "  warning: could not remove unit file: %v\n", err)
 		}
 	} else {
-		fmt.Printf("  Systemd service removed\n")
+		fmt.Printf("  %s\n", i18n.T("Systemd service removed"))
+	}
+
+	if err := removePortForward(); err != nil {
+		errs = append(errs, err)
+		fmt.Fprintf(os.Stderr, "  warning: could not remove port forwarding: %v\n", err)
 	}
 
 	// 2. Remove DNS resolver config
-	fmt.Printf("\n[2/3] Removing DNS resolver...\n")
+	fmt.Printf("\n[2/3] %s\n", i18n.T("Removing DNS resolver..."))
 	if err := os.Remove(resolvedConf); err != nil {
 		if os.IsNotExist(err) {
-			fmt.Printf("  Resolved config not found (already removed)\n")
+			fmt.Printf("  %s\n", i18n.T("Resolved config not found (already removed)"))
 		} else {
 			errs = append(errs, fmt.Errorf("removing resolved config: %w", err))
 			fmt.Fprintf(os.Stderr, "  warning: could not remove %s: %v\n", resolvedConf, err)
 		}
 	} else {
-		fmt.Printf("  systemd-resolved config removed\n")
+		fmt.Printf("  %s\n", i18n.T("systemd-resolved config removed"))
 	}
 	// Restart resolved to pick up the change
 	if err := exec.Command("systemctl", "restart", "systemd-resolved").Run(); err != nil {
@@ -65,17 +79,17 @@ func Uninstall(supportDir, tld string, fromBrew bool) error {
 	// 3. Remove CA and support directory
 	removeCA := fromBrew
 	if !fromBrew {
-		fmt.Printf("\n[3/3] Remove CA certificate from system trust? [y/N] ")
+		fmt.Printf("\n[3/3] %s ", i18n.T("Remove CA certificate from system trust? [y/N]"))
 		reader := bufio.NewReader(os.Stdin)
 		answer, _ := reader.ReadString('\n')
-		removeCA = strings.ToLower(strings.TrimSpace(answer)) == "y"
+		removeCA = i18n.Yes(answer)
 	}
 
 	if removeCA {
 		// Remove from Debian/Ubuntu trust store
 		debianPath := "/usr/local/share/ca-certificates/paw-proxy-ca.crt"
 		if err := os.Remove(debianPath); err == nil {
-			fmt.Printf("  Removed CA from %s\n", debianPath)
+			fmt.Printf("  %s\n", i18n.T("Removed CA from %s", debianPath))
 			if cmd := exec.Command("update-ca-certificates"); cmd.Run() != nil {
 				fmt.Fprintf(os.Stderr, "  warning: update-ca-certificates failed\n")
 			}
@@ -86,7 +100,7 @@ func Uninstall(supportDir, tld string, fromBrew bool) error {
 		// Remove from Fedora/RHEL/Arch trust store
 		fedoraPath := "/etc/pki/ca-trust/source/anchors/paw-proxy-ca.crt"
 		if err := os.Remove(fedoraPath); err == nil {
-			fmt.Printf("  Removed CA from %s\n", fedoraPath)
+			fmt.Printf("  %s\n", i18n.T("Removed CA from %s", fedoraPath))
 			if cmd := exec.Command("update-ca-trust"); cmd.Run() != nil {
 				fmt.Fprintf(os.Stderr, "  warning: update-ca-trust failed\n")
 			}
@@ -94,24 +108,81 @@ func Uninstall(supportDir, tld string, fromBrew bool) error {
 			errs = append(errs, fmt.Errorf("removing %s: %w", fedoraPath, err))
 		}
 
+		// Remove from Firefox/Chromium NSS databases
+		results, err := untrustNSS(homeDir)
+		if err == nil {
+			if failed := printNSSResults(results, "removed from"); failed > 0 {
+				errs = append(errs, fmt.Errorf("removing CA from %d browser profile(s)", failed))
+			}
+		} else if len(nssDatabases(homeDir)) > 0 {
+			fmt.Fprintf(os.Stderr, "  warning: certutil not found; remove %q from Firefox manually\n", nssNickname)
+		}
+
 		// Remove support directory
 		if err := os.RemoveAll(supportDir); err != nil {
 			errs = append(errs, fmt.Errorf("removing support directory: %w", err))
 			fmt.Fprintf(os.Stderr, "  warning: could not remove support directory: %v\n", err)
 		} else {
-			fmt.Printf("  Support directory removed\n")
+			fmt.Printf("  %s\n", i18n.T("Support directory removed"))
 		}
 	} else {
-		fmt.Printf("  CA kept in system trust store\n")
+		fmt.Printf("  %s\n", i18n.T("CA kept in system trust store"))
 	}
 
 	fmt.Println("\n===================")
 
 	if len(errs) > 0 {
-		fmt.Println("Uninstall completed with errors.")
+		fmt.Println(i18n.T("Uninstall completed with errors."))
 		return fmt.Errorf("uninstall completed with errors: %w", errors.Join(errs...))
 	}
 
-	fmt.Println("Uninstall complete!")
+	fmt.Println(i18n.T("Uninstall complete!"))
 	return nil
 }
+
+// PlanUninstall returns what Uninstall would do, without doing it. The
+// CA and support directory are listed under a step that Uninstall asks
+// about first unless fromBrew is set.
+func PlanUninstall(supportDir, tld string, fromBrew bool) (*Plan, error) {
+	homeDir, err := realUserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("getting home directory: %w", err)
+	}
+	plan := &Plan{Title: "paw-proxy uninstall"}
+
+	s := plan.step("Remove daemon")
+	planRemoveSocketActivation(s)
+	planUserSystemctl(s, "disable", "--now", "paw-proxy")
+	planUserSystemctl(s, "daemon-reload")
+	s.remove(filepath.Join(homeDir, ".config", "systemd", "user", "paw-proxy.service"))
+	planRemovePortForward(s)
+
+	s = plan.step("Remove DNS resolver")
+	s.remove(filepath.Join("/etc/systemd/resolved.conf.d", "paw-proxy.conf"))
+	s.run("systemctl", "restart", "systemd-resolved")
+
+	name := "Remove CA certificate from system trust"
+	if !fromBrew {
+		name += " (asks first)"
+	}
+	s = plan.step(name)
+	found := false
+	for _, ca := range []struct{ path, tool string }{
+		{"/usr/local/share/ca-certificates/paw-proxy-ca.crt", "update-ca-certificates"},
+		{"/etc/pki/ca-trust/source/anchors/paw-proxy-ca.crt", "update-ca-trust"},
+	} {
+		if _, err := os.Stat(ca.path); err != nil {
+			continue
+		}
+		s.remove(ca.path)
+		s.keychain(`remove "paw-proxy CA" from the system trust store`)
+		s.run(ca.tool)
+		found = true
+	}
+	if !found {
+		s.note("no paw-proxy CA found in the system trust store")
+	}
+	planNSS(s, "", homeDir, true)
+	s.remove(supportDir + " (recursively)")
+	return plan, nil
+}
@@ -0,0 +1,389 @@
+// internal/ssl/acme.go
+package ssl
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+const (
+	// acmeRenewBefore is how long before expiry the certificate is renewed.
+	acmeRenewBefore = 30 * 24 * time.Hour
+	// acmeCheckInterval is how often the certificate's expiry is checked,
+	// and acmeRetryInterval how soon a failed issuance is retried.
+	acmeCheckInterval = 12 * time.Hour
+	acmeRetryInterval = time.Hour
+	// acmeIssueTimeout bounds one issuance, including DNS propagation.
+	acmeIssueTimeout = 10 * time.Minute
+	// acmeHookTimeout bounds each run of the DNS hook.
+	acmeHookTimeout = 2 * time.Minute
+)
+
+// ACMEConfig gets a publicly trusted wildcard certificate for a real
+// domain (e.g. *.dev.example.com, pointed at 127.0.0.1 in public DNS) over
+// ACME DNS-01, so routes under it work without trusting the local CA.
+type ACMEConfig struct {
+	// Domain is the parent of the route names: myapp is served as
+	// myapp.<Domain>.
+	Domain string `json:"domain"`
+	// Email is the ACME account contact, for expiry notices.
+	Email string `json:"email,omitempty"`
+	// DNSHook publishes the challenge TXT record. It is run with
+	// "present <fqdn> <value>" and later "cleanup <fqdn> <value>" appended
+	// (as lego's exec provider), and should return once the record is
+	// visible to public resolvers.
+	DNSHook []string `json:"dns_hook"`
+	// Directory is the ACME directory URL; Let's Encrypt by default.
+	Directory string `json:"directory,omitempty"`
+}
+
+// Validate checks an ACME config from the config file.
+func (c *ACMEConfig) Validate() error {
+	if c.Domain != strings.ToLower(c.Domain) || !validServerName(c.Domain) || !strings.Contains(c.Domain, ".") {
+		return fmt.Errorf("domain: invalid domain %q", c.Domain)
+	}
+	if len(c.DNSHook) == 0 || c.DNSHook[0] == "" {
+		return errors.New("dns_hook: a command is required")
+	}
+	if !filepath.IsAbs(c.DNSHook[0]) {
+		return fmt.Errorf("dns_hook: command must be an absolute path, got %q", c.DNSHook[0])
+	}
+	if strings.ContainsAny(c.Email, " \t\r\n") || c.Email != "" && !strings.Contains(c.Email, "@") {
+		return fmt.Errorf("email: invalid address %q", c.Email)
+	}
+	if c.Directory != "" {
+		u, err := url.Parse(c.Directory)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("directory: must be an https URL, got %q", c.Directory)
+		}
+	}
+	return nil
+}
+
+// Equal reports whether c and o describe the same certificate source.
+func (c *ACMEConfig) Equal(o *ACMEConfig) bool {
+	if c == nil || o == nil {
+		return c == o
+	}
+	return c.Domain == o.Domain && c.Email == o.Email && c.Directory == o.Directory &&
+		slices.Equal(c.DNSHook, o.DNSHook)
+}
+
+// ACMEManager keeps an ACME wildcard certificate for its domain on disk
+// and in memory, renewing it in the background.
+type ACMEManager struct {
+	config ACMEConfig
+	dir    string
+	logger *slog.Logger
+	issued *IssueLog
+	cert   atomic.Pointer[tls.Certificate]
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewACMEManager returns a manager storing its account key and
+// certificate in dir. Call Start to load or obtain the certificate.
+func NewACMEManager(config ACMEConfig, dir string, logger *slog.Logger) *ACMEManager {
+	return &ACMEManager{config: config, dir: dir, logger: logger, done: make(chan struct{})}
+}
+
+// SetIssueLog records every certificate the manager obtains in l. Call it
+// before Start.
+func (m *ACMEManager) SetIssueLog(l *IssueLog) {
+	m.issued = l
+}
+
+// Config returns the manager's configuration.
+func (m *ACMEManager) Config() ACMEConfig {
+	return m.config
+}
+
+// Start loads the stored certificate and keeps it renewed until Stop.
+// Until a certificate is available, Certificate returns nil.
+func (m *ACMEManager) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	if cert, err := m.load(); err == nil {
+		m.cert.Store(cert)
+	} else if !os.IsNotExist(err) {
+		m.logger.Warn("ACME: stored certificate unusable", "domain", m.config.Domain, "error", err)
+	}
+	go m.renewLoop(ctx)
+}
+
+// Stop ends background renewal and waits for an issuance in progress to
+// give up.
+func (m *ACMEManager) Stop() {
+	m.once.Do(func() {
+		if m.cancel != nil {
+			m.cancel()
+			<-m.done
+		}
+	})
+}
+
+// Certificate returns the wildcard certificate if it covers name, a
+// single label under the domain.
+func (m *ACMEManager) Certificate(name string) *tls.Certificate {
+	label, ok := strings.CutSuffix(strings.ToLower(name), "."+m.config.Domain)
+	if !ok || label == "" || strings.Contains(label, ".") {
+		return nil
+	}
+	return m.cert.Load()
+}
+
+func (m *ACMEManager) renewLoop(ctx context.Context) {
+	defer close(m.done)
+	for {
+		wait := acmeCheckInterval
+		if cert := m.cert.Load(); cert == nil || time.Until(cert.Leaf.NotAfter) < acmeRenewBefore {
+			if err := m.renew(ctx); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				m.logger.Error("ACME: certificate issuance failed", "domain", m.config.Domain, "error", err)
+				wait = acmeRetryInterval
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// renew obtains a new certificate, stores it, and starts serving it.
+func (m *ACMEManager) renew(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, acmeIssueTimeout)
+	defer cancel()
+
+	cert, err := m.obtain(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.save(cert); err != nil {
+		m.logger.Warn("ACME: saving certificate failed", "domain", m.config.Domain, "error", err)
+	}
+	if err := m.issued.Record(cert.Leaf, SourceACME); err != nil {
+		m.logger.Warn("ACME: recording issued certificate failed", "domain", m.config.Domain, "error", err)
+	}
+	m.cert.Store(cert)
+	m.logger.Info("ACME: certificate obtained", "domain", m.config.Domain, "expires", cert.Leaf.NotAfter)
+	return nil
+}
+
+// obtain runs an ACME order for *.<Domain>, answering its DNS-01 challenge
+// with the hook.
+func (m *ACMEManager) obtain(ctx context.Context) (*tls.Certificate, error) {
+	accountKey, err := m.accountKey()
+	if err != nil {
+		return nil, err
+	}
+	client := &acme.Client{Key: accountKey, DirectoryURL: m.config.Directory}
+	if client.DirectoryURL == "" {
+		client.DirectoryURL = acme.LetsEncryptURL
+	}
+	account := &acme.Account{}
+	if m.config.Email != "" {
+		account.Contact = []string{"mailto:" + m.config.Email}
+	}
+	if _, err := client.Register(ctx, account, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return nil, fmt.Errorf("registering account: %w", err)
+	}
+
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs("*."+m.config.Domain))
+	if err != nil {
+		return nil, fmt.Errorf("creating order: %w", err)
+	}
+	for _, authzURL := range order.AuthzURLs {
+		if err := m.authorize(ctx, client, authzURL); err != nil {
+			return nil, err
+		}
+	}
+	if order, err = client.WaitOrder(ctx, order.URI); err != nil {
+		return nil, fmt.Errorf("waiting for order: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating key: %w", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		DNSNames: []string{"*." + m.config.Domain},
+	}, key)
+	if err != nil {
+		return nil, fmt.Errorf("creating CSR: %w", err)
+	}
+	chain, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, fmt.Errorf("finalizing order: %w", err)
+	}
+	return newACMECertificate(chain, key)
+}
+
+// authorize completes the DNS-01 challenge of one authorization.
+func (m *ACMEManager) authorize(ctx context.Context, client *acme.Client, authzURL string) error {
+	authz, err := client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return fmt.Errorf("fetching authorization: %w", err)
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+	var challenge *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == "dns-01" {
+			challenge = c
+			break
+		}
+	}
+	if challenge == nil {
+		return fmt.Errorf("no dns-01 challenge offered for %s", authz.Identifier.Value)
+	}
+	value, err := client.DNS01ChallengeRecord(challenge.Token)
+	if err != nil {
+		return fmt.Errorf("computing challenge record: %w", err)
+	}
+	fqdn := "_acme-challenge." + authz.Identifier.Value + "."
+	if err := m.runHook(ctx, "present", fqdn, value); err != nil {
+		return err
+	}
+	defer func() {
+		// Clean up even when ctx is done, so records don't pile up.
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), acmeHookTimeout)
+		defer cancel()
+		if err := m.runHook(cleanupCtx, "cleanup", fqdn, value); err != nil {
+			m.logger.Warn("ACME: DNS cleanup failed", "fqdn", fqdn, "error", err)
+		}
+	}()
+
+	if _, err := client.Accept(ctx, challenge); err != nil {
+		return fmt.Errorf("accepting challenge: %w", err)
+	}
+	if _, err := client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return fmt.Errorf("validating %s: %w", fqdn, err)
+	}
+	return nil
+}
+
+// runHook runs the DNS hook with action, fqdn, and value appended.
+func (m *ACMEManager) runHook(ctx context.Context, action, fqdn, value string) error {
+	ctx, cancel := context.WithTimeout(ctx, acmeHookTimeout)
+	defer cancel()
+	args := append(slices.Clone(m.config.DNSHook[1:]), action, fqdn, value)
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, m.config.DNSHook[0], args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("dns_hook %s: %v: %s", action, err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+func (m *ACMEManager) certPath() string { return filepath.Join(m.dir, m.config.Domain+".crt") }
+func (m *ACMEManager) keyPath() string  { return filepath.Join(m.dir, m.config.Domain+".key") }
+
+// accountKey loads the ACME account key, creating it on first use. The
+// account is shared by every domain.
+func (m *ACMEManager) accountKey() (crypto.Signer, error) {
+	path := filepath.Join(m.dir, "account.key")
+	if data, err := os.ReadFile(path); err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s: no PEM data", path)
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating account key: %w", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return nil, err
+	}
+	// SECURITY: Owner-only, like the CA key.
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// load reads the stored certificate for the domain.
+func (m *ACMEManager) load() (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(m.certPath(), m.keyPath())
+	if err != nil {
+		return nil, err
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, err
+		}
+	}
+	if err := cert.Leaf.VerifyHostname("x." + m.config.Domain); err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// save writes cert and its key for load.
+func (m *ACMEManager) save(cert *tls.Certificate) error {
+	var chain bytes.Buffer
+	for _, der := range cert.Certificate {
+		pem.Encode(&chain, &pem.Block{Type: "CERTIFICATE", Bytes: der}) //nolint:errcheck // writes to a buffer
+	}
+	der, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return err
+	}
+	// SECURITY: Owner-only key file.
+	if err := os.WriteFile(m.keyPath(), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(m.certPath(), chain.Bytes(), 0644)
+}
+
+// newACMECertificate builds a serving certificate from an issued chain.
+func newACMECertificate(chain [][]byte, key *ecdsa.PrivateKey) (*tls.Certificate, error) {
+	if len(chain) == 0 {
+		return nil, errors.New("empty certificate chain")
+	}
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, fmt.Errorf("parsing certificate: %w", err)
+	}
+	return &tls.Certificate{Certificate: chain, PrivateKey: key, Leaf: leaf}, nil
+}
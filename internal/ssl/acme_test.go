@@ -0,0 +1,102 @@
+// internal/ssl/acme_test.go
+package ssl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log/slog"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestACMEConfig_Validate(t *testing.T) {
+	hook := []string{"/usr/local/bin/dns-hook"}
+	tests := []struct {
+		name    string
+		config  ACMEConfig
+		wantErr bool
+	}{
+		{"minimal", ACMEConfig{Domain: "dev.example.com", DNSHook: hook}, false},
+		{"full", ACMEConfig{Domain: "dev.example.com", Email: "me@example.com", DNSHook: []string{"/usr/bin/env", "hook.sh"},
+			Directory: "https://acme-staging-v02.api.letsencrypt.org/directory"}, false},
+		{"single label", ACMEConfig{Domain: "test", DNSHook: hook}, true},
+		{"wildcard", ACMEConfig{Domain: "*.dev.example.com", DNSHook: hook}, true},
+		{"uppercase", ACMEConfig{Domain: "Dev.Example.com", DNSHook: hook}, true},
+		{"no hook", ACMEConfig{Domain: "dev.example.com"}, true},
+		{"relative hook", ACMEConfig{Domain: "dev.example.com", DNSHook: []string{"dns-hook"}}, true},
+		{"bad email", ACMEConfig{Domain: "dev.example.com", Email: "me", DNSHook: hook}, true},
+		{"http directory", ACMEConfig{Domain: "dev.example.com", DNSHook: hook, Directory: "http://acme.example.com/dir"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestACMEManager_ServesStoredCertificate(t *testing.T) {
+	dir := t.TempDir()
+	m := NewACMEManager(ACMEConfig{Domain: "dev.example.com", DNSHook: []string{"/bin/false"}}, dir,
+		slog.New(slog.NewJSONHandler(io.Discard, nil)))
+
+	// A certificate far from expiry is served as stored, without issuance.
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "*.dev.example.com"},
+		DNSNames:     []string{"*.dev.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := newACMECertificate([][]byte{der}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.save(cert); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Start()
+	defer m.Stop()
+
+	if got := m.Certificate("myapp.dev.example.com"); got == nil || got.Leaf.SerialNumber.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("Certificate(myapp.dev.example.com) = %v, want the stored certificate", got)
+	}
+	if m.Certificate("MyApp.Dev.Example.com") == nil {
+		t.Error("Certificate should match names case-insensitively")
+	}
+	for _, name := range []string{"dev.example.com", "a.myapp.dev.example.com", "myapp.test", "myappdev.example.com"} {
+		if m.Certificate(name) != nil {
+			t.Errorf("Certificate(%q) should be nil: not covered by the wildcard", name)
+		}
+	}
+}
+
+func TestACMEConfig_Equal(t *testing.T) {
+	a := &ACMEConfig{Domain: "dev.example.com", DNSHook: []string{"/bin/hook", "cf"}}
+	b := &ACMEConfig{Domain: "dev.example.com", DNSHook: []string{"/bin/hook", "cf"}}
+	if !a.Equal(b) {
+		t.Error("identical configs should be equal")
+	}
+	b.DNSHook = []string{"/bin/hook", "route53"}
+	if a.Equal(b) {
+		t.Error("configs with different hooks should differ")
+	}
+	if a.Equal(nil) || !(*ACMEConfig)(nil).Equal(nil) {
+		t.Error("nil handling is wrong")
+	}
+}
@@ -10,11 +10,17 @@ import (
 	"encoding/pem"
 	"fmt"
 	"math/big"
+	"net"
 	"os"
+	"strings"
 	"time"
 )
 
-func GenerateCA(certPath, keyPath string) error {
+// GenerateCA writes a new CA certificate and key. When domains are given,
+// the CA carries critical name constraints: it can only issue for those
+// domains and their subdomains, and never for IP addresses, so a leaked key
+// can't be used to impersonate real sites.
+func GenerateCA(certPath, keyPath string, domains ...string) error {
 	// SECURITY: Use 4096-bit key for CA (stronger than 2048)
 	priv, err := rsa.GenerateKey(rand.Reader, 4096)
 	if err != nil {
@@ -44,6 +50,14 @@ func GenerateCA(certPath, keyPath string) error {
 		MaxPathLen:     0,
 		MaxPathLenZero: true,
 	}
+	if len(domains) > 0 {
+		template.PermittedDNSDomainsCritical = true
+		template.PermittedDNSDomains = domains
+		template.ExcludedIPRanges = []*net.IPNet{
+			{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)},
+			{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)},
+		}
+	}
 
 	derBytes, err := x509.CreateCertificate(rand.Reader, template, template, priv.Public(), priv)
 	if err != nil {
@@ -73,8 +87,22 @@ func GenerateCA(certPath, keyPath string) error {
 	return nil
 }
 
+// LoadCA loads the CA certificate and its key, decrypting the key first
+// if it was protected with ProtectKey.
 func LoadCA(certPath, keyPath string) (*tls.Certificate, error) {
-	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading key pair: %w", err)
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading key pair: %w", err)
+	}
+	keyPEM, err = decodeKeyPEM(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
 	if err != nil {
 		return nil, fmt.Errorf("loading key pair: %w", err)
 	}
@@ -86,3 +114,29 @@ func LoadCA(certPath, keyPath string) (*tls.Certificate, error) {
 
 	return &cert, nil
 }
+
+// Unpermitted returns the domains in tlds that ca's name constraints don't
+// allow it to issue for. A CA without constraints permits everything.
+func Unpermitted(ca *x509.Certificate, tlds []string) []string {
+	if len(ca.PermittedDNSDomains) == 0 {
+		return nil
+	}
+	var out []string
+	for _, tld := range tlds {
+		// Leaves are issued for <name>.<tld>; a constraint covers its own
+		// domain and everything below it.
+		name := "x." + strings.ToLower(tld)
+		permitted := false
+		for _, c := range ca.PermittedDNSDomains {
+			c = strings.ToLower(strings.TrimPrefix(c, "."))
+			if strings.HasSuffix(name, "."+c) {
+				permitted = true
+				break
+			}
+		}
+		if !permitted {
+			out = append(out, tld)
+		}
+	}
+	return out
+}
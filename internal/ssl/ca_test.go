@@ -2,11 +2,20 @@
 package ssl
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
+	"math/big"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestGenerateCA(t *testing.T) {
@@ -157,3 +166,94 @@ func TestGenerateCA_PemEncodeErrorOnReadOnlyPath(t *testing.T) {
 		t.Fatalf("parsing certificate DER: %v", err)
 	}
 }
+
+func TestGenerateCA_NameConstraints(t *testing.T) {
+	tmpDir := t.TempDir()
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	keyPath := filepath.Join(tmpDir, "ca.key")
+
+	if err := GenerateCA(certPath, keyPath, "test", "dev"); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	ca, err := LoadCA(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("LoadCA failed: %v", err)
+	}
+	if !ca.Leaf.PermittedDNSDomainsCritical {
+		t.Error("name constraints should be critical")
+	}
+	if len(ca.Leaf.ExcludedIPRanges) != 2 {
+		t.Errorf("ExcludedIPRanges = %v, want all of IPv4 and IPv6", ca.Leaf.ExcludedIPRanges)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caKey := ca.PrivateKey.(crypto.Signer)
+	for _, tt := range []struct {
+		name string
+		ok   bool
+	}{
+		{"myapp.test", true},
+		{"api.myapp.dev", true},
+		{"example.com", false},
+		{"test.com", false},
+		{"mytest", false},
+	} {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: tt.name},
+			DNSNames:     []string{tt.name},
+			NotBefore:    time.Now().Add(-time.Minute),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca.Leaf, key.Public(), caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = leaf.Verify(x509.VerifyOptions{DNSName: tt.name, Roots: roots})
+		if (err == nil) != tt.ok {
+			t.Errorf("Verify(%s) error = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+
+	// An IP address leaf is excluded even without a DNS name.
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Leaf, key.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{Roots: roots}); err == nil {
+		t.Error("a leaf for an IP address should not verify")
+	}
+}
+
+func TestUnpermitted(t *testing.T) {
+	unconstrained := &x509.Certificate{}
+	if got := Unpermitted(unconstrained, []string{"test", "dev"}); got != nil {
+		t.Errorf("Unpermitted(unconstrained) = %v, want nil", got)
+	}
+	constrained := &x509.Certificate{PermittedDNSDomains: []string{"test", ".local"}}
+	got := Unpermitted(constrained, []string{"test", "local", "dev", "Test"})
+	if want := []string{"dev"}; !slices.Equal(got, want) {
+		t.Errorf("Unpermitted = %v, want %v", got, want)
+	}
+}
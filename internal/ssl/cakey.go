@@ -0,0 +1,182 @@
+// internal/ssl/cakey.go
+package ssl
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// protectedKeyType is the PEM block type of a CA key encrypted with the
+// machine key.
+const protectedKeyType = "PAW-PROXY ENCRYPTED PRIVATE KEY"
+
+// machineKeyInfo separates the CA key's encryption key from anything else
+// derived from the machine ID.
+const machineKeyInfo = "paw-proxy ca.key v1"
+
+// machineKey derives the AES-256 key that protects the CA key on this
+// machine. It only depends on the OS machine ID, so a copy of ca.key in a
+// backup or synced folder can't be used anywhere else.
+func machineKey() ([]byte, error) {
+	id, err := machineID()
+	if err != nil {
+		return nil, fmt.Errorf("reading machine ID: %w", err)
+	}
+	if id == "" {
+		return nil, errors.New("reading machine ID: empty")
+	}
+	return hkdf.Key(sha256.New, []byte(id), nil, machineKeyInfo, 32)
+}
+
+// KeyProtected reports whether the key file at keyPath is encrypted with
+// the machine key.
+func KeyProtected(keyPath string) (bool, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return false, err
+	}
+	block, _ := pem.Decode(data)
+	return block != nil && block.Type == protectedKeyType, nil
+}
+
+// ProtectKey encrypts the plaintext key file at keyPath with the machine
+// key, in place. Protecting an already protected key is a no-op.
+func ProtectKey(keyPath string) error {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("%s: no PEM data", keyPath)
+	}
+	if block.Type == protectedKeyType {
+		return nil
+	}
+	key, err := machineKey()
+	if err != nil {
+		return err
+	}
+	sealed, err := sealKey(key, data)
+	if err != nil {
+		return err
+	}
+	return replaceKeyFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: protectedKeyType, Bytes: sealed}))
+}
+
+// UnprotectKey decrypts the key file at keyPath back to plaintext PEM, in
+// place, e.g. before moving the CA to another machine.
+func UnprotectKey(keyPath string) error {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return err
+	}
+	plain, err := decodeKeyPEM(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", keyPath, err)
+	}
+	if string(plain) == string(data) {
+		return nil
+	}
+	return replaceKeyFile(keyPath, plain)
+}
+
+// ReadKeyPEM returns the plaintext PEM of the key file at keyPath,
+// decrypting it in memory if it is protected, e.g. to export the CA.
+func ReadKeyPEM(keyPath string) ([]byte, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := decodeKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", keyPath, err)
+	}
+	return plain, nil
+}
+
+// decodeKeyPEM returns the plaintext PEM of a key file's contents,
+// decrypting it with the machine key if it is protected.
+func decodeKeyPEM(data []byte) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != protectedKeyType {
+		return data, nil
+	}
+	key, err := machineKey()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := openKey(key, block.Bytes)
+	if err != nil {
+		return nil, errors.New("decrypting CA key: it was protected on another machine or the machine ID changed")
+	}
+	return plain, nil
+}
+
+// sealKey encrypts plain with AES-256-GCM, returning nonce||ciphertext.
+func sealKey(key, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, []byte(protectedKeyType)), nil
+}
+
+// openKey reverses sealKey.
+func openKey(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, []byte(protectedKeyType))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// replaceKeyFile atomically replaces the key file at path with data,
+// keeping its owner so a sudo run doesn't lock the daemon out.
+func replaceKeyFile(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".ca.key-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	// SECURITY: CreateTemp already uses 0600; keep it owner-only.
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := chownLike(tmp.Name(), info); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,22 @@
+package ssl
+
+import (
+	"errors"
+	"os/exec"
+	"regexp"
+)
+
+var platformUUIDPattern = regexp.MustCompile(`"IOPlatformUUID" = "([0-9A-Fa-f-]+)"`)
+
+// machineID returns the hardware UUID from IOKit.
+func machineID() (string, error) {
+	out, err := exec.Command("/usr/sbin/ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+	if err != nil {
+		return "", err
+	}
+	m := platformUUIDPattern.FindSubmatch(out)
+	if m == nil {
+		return "", errors.New("IOPlatformUUID not found")
+	}
+	return string(m[1]), nil
+}
@@ -0,0 +1,18 @@
+package ssl
+
+import (
+	"os"
+	"strings"
+)
+
+// machineID returns the systemd/D-Bus machine ID.
+func machineID() (string, error) {
+	data, err := os.ReadFile("/etc/machine-id")
+	if os.IsNotExist(err) {
+		data, err = os.ReadFile("/var/lib/dbus/machine-id")
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
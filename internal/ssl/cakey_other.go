@@ -0,0 +1,18 @@
+//go:build !darwin && !linux
+
+package ssl
+
+import (
+	"errors"
+	"os"
+)
+
+// machineID is unsupported on this platform.
+func machineID() (string, error) {
+	return "", errors.New("not supported on this platform")
+}
+
+// chownLike is a no-op on this platform.
+func chownLike(path string, info os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,103 @@
+// internal/ssl/cakey_test.go
+package ssl
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProtectKey_RoundTrip(t *testing.T) {
+	if _, err := machineKey(); err != nil {
+		t.Skipf("no machine ID: %v", err)
+	}
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "ca.crt")
+	keyPath := filepath.Join(dir, "ca.key")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	os.WriteFile(keyPath, plain, 0600)
+
+	if err := ProtectKey(keyPath); err != nil {
+		t.Fatalf("ProtectKey: %v", err)
+	}
+	if protected, _ := KeyProtected(keyPath); !protected {
+		t.Fatal("key not protected after ProtectKey")
+	}
+	data, _ := os.ReadFile(keyPath)
+	if bytes.Contains(data, keyDER) || bytes.Contains(data, []byte("EC PRIVATE KEY-----")) {
+		t.Error("protected key file still contains the plaintext key")
+	}
+	if info, _ := os.Stat(keyPath); info.Mode().Perm() != 0600 {
+		t.Errorf("protected key mode = %v, want 0600", info.Mode().Perm())
+	}
+	if err := ProtectKey(keyPath); err != nil {
+		t.Errorf("ProtectKey on a protected key: %v", err)
+	}
+
+	ca, err := LoadCA(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("LoadCA with protected key: %v", err)
+	}
+	if !ca.PrivateKey.(*ecdsa.PrivateKey).Equal(key) {
+		t.Error("LoadCA returned a different key")
+	}
+	if got, err := ReadKeyPEM(keyPath); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("ReadKeyPEM of a protected key = %q, %v; want the plaintext key", got, err)
+	}
+
+	if err := UnprotectKey(keyPath); err != nil {
+		t.Fatalf("UnprotectKey: %v", err)
+	}
+	if data, _ := os.ReadFile(keyPath); !bytes.Equal(data, plain) {
+		t.Error("UnprotectKey did not restore the original key file")
+	}
+}
+
+func TestOpenKey_WrongMachine(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	other := bytes.Repeat([]byte{2}, 32)
+	sealed, err := sealKey(key, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := openKey(key, sealed); err != nil || string(got) != "secret" {
+		t.Fatalf("openKey = %q, %v", got, err)
+	}
+	if _, err := openKey(other, sealed); err == nil {
+		t.Error("openKey succeeded with another machine's key")
+	}
+	if _, err := openKey(key, sealed[:4]); err == nil {
+		t.Error("openKey succeeded on truncated data")
+	}
+}
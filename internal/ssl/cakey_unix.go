@@ -0,0 +1,17 @@
+//go:build darwin || linux
+
+package ssl
+
+import (
+	"os"
+	"syscall"
+)
+
+// chownLike gives path the owner and group in info, when run as root.
+func chownLike(path string, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || os.Geteuid() != 0 {
+		return nil
+	}
+	return os.Chown(path, int(st.Uid), int(st.Gid))
+}
@@ -26,6 +26,7 @@ type CertCache struct {
 	order  []string // Track insertion order for LRU eviction
 	mu     sync.RWMutex
 	logger *slog.Logger
+	issued *IssueLog
 }
 
 func NewCertCache(ca *tls.Certificate, tld string) *CertCache {
@@ -42,6 +43,11 @@ func (c *CertCache) SetLogger(logger *slog.Logger) {
 	c.logger = logger
 }
 
+// SetIssueLog records every certificate the cache generates in l.
+func (c *CertCache) SetIssueLog(l *IssueLog) {
+	c.issued = l
+}
+
 func (c *CertCache) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
 	name := hello.ServerName
 
@@ -53,6 +59,17 @@ func (c *CertCache) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate
 		return nil, fmt.Errorf("SNI required: connect using hostname, not IP")
 	}
 
+	// SECURITY: Reject malformed or oversized SNI before it becomes a cache
+	// key or a certificate name. Names are case-insensitive, so normalize
+	// to keep one cache entry per host.
+	if !validServerName(name) {
+		if c.logger != nil {
+			c.logger.Warn("TLS: invalid SNI rejected", "length", len(name))
+		}
+		return nil, fmt.Errorf("invalid SNI")
+	}
+	name = strings.ToLower(name)
+
 	// Fast path: read lock for cache hit (non-expired)
 	c.mu.RLock()
 	if cert, ok := c.cache[name]; ok {
@@ -76,10 +93,11 @@ func (c *CertCache) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate
 		delete(c.cache, name)
 		c.removeFromOrder(name)
 	}
+	ca := c.ca
 	c.mu.Unlock()
 
 	// Generate cert without holding lock (crypto operations are expensive)
-	cert, err := c.generateCert(name)
+	cert, err := c.generateCert(name, ca)
 	if err != nil {
 		if c.logger != nil {
 			c.logger.Error("TLS: cert generation failed", "name", name, "error", err)
@@ -100,18 +118,49 @@ func (c *CertCache) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate
 		c.removeFromOrder(name)
 	}
 
-	// SECURITY: Evict oldest entry if cache is full
-	if len(c.cache) >= maxCacheSize {
-		oldest := c.order[0]
-		delete(c.cache, oldest)
-		c.order = c.order[1:]
-	}
+	// If the CA was replaced while generating, serve this certificate but
+	// don't cache one signed by the old CA.
+	if c.ca == ca {
+		// SECURITY: Evict oldest entry if cache is full
+		if len(c.cache) >= maxCacheSize {
+			oldest := c.order[0]
+			delete(c.cache, oldest)
+			c.order = c.order[1:]
+		}
 
-	c.cache[name] = cert
-	c.order = append(c.order, name)
+		c.cache[name] = cert
+		c.order = append(c.order, name)
+	}
+	if err := c.issued.Record(cert.Leaf, SourceLocalCA); err != nil && c.logger != nil {
+		c.logger.Warn("TLS: recording issued certificate failed", "name", name, "error", err)
+	}
+	if c.logger != nil {
+		c.logger.Debug("TLS: certificate generated", "name", name, "cached", len(c.cache))
+	}
 	return cert, nil
 }
 
+// validServerName reports whether name is usable as a certificate DNS name:
+// at most 253 characters, labels of 1-63 letters, digits, hyphens, or
+// underscores.
+func validServerName(name string) bool {
+	if len(name) > 253 {
+		return false
+	}
+	for label := range strings.SplitSeq(name, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (c *CertCache) removeFromOrder(name string) {
 	for i, n := range c.order {
 		if n == name {
@@ -121,7 +170,48 @@ func (c *CertCache) removeFromOrder(name string) {
 	}
 }
 
-func (c *CertCache) generateCert(name string) (*tls.Certificate, error) {
+// Invalidate drops every cached certificate, so the next handshake for
+// each name issues a new one. A non-nil ca replaces the CA that signs them.
+// It returns how many certificates were dropped.
+func (c *CertCache) Invalidate(ca *tls.Certificate) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.cache)
+	c.cache = make(map[string]*tls.Certificate)
+	c.order = make([]string, 0, maxCacheSize)
+	if ca != nil {
+		c.ca = ca
+	}
+	return n
+}
+
+// CachedCert describes a certificate held in the cache.
+type CachedCert struct {
+	Names    []string  `json:"names"`
+	NotAfter time.Time `json:"notAfter"`
+}
+
+// Cached returns the certificates in the cache, oldest first.
+func (c *CertCache) Cached() []CachedCert {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	certs := make([]CachedCert, 0, len(c.order))
+	for _, name := range c.order {
+		if cert, ok := c.cache[name]; ok && cert.Leaf != nil {
+			certs = append(certs, CachedCert{Names: cert.Leaf.DNSNames, NotAfter: cert.Leaf.NotAfter})
+		}
+	}
+	return certs
+}
+
+// CA returns the certificate of the CA signing new certificates.
+func (c *CertCache) CA() *x509.Certificate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ca.Leaf
+}
+
+func (c *CertCache) generateCert(name string, ca *tls.Certificate) (*tls.Certificate, error) {
 	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
 		return nil, fmt.Errorf("generating key: %w", err)
@@ -154,7 +244,7 @@ func (c *CertCache) generateCert(name string) (*tls.Certificate, error) {
 		DNSNames:    dnsNames,
 	}
 
-	derBytes, err := x509.CreateCertificate(rand.Reader, template, c.ca.Leaf, priv.Public(), c.ca.PrivateKey)
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, ca.Leaf, priv.Public(), ca.PrivateKey)
 	if err != nil {
 		return nil, fmt.Errorf("creating certificate: %w", err)
 	}
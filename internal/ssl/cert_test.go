@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -51,6 +52,27 @@ func TestCertCache_GeneratesCertForDomain(t *testing.T) {
 	}
 }
 
+func TestCertCache_GeneratesCertForDashboard(t *testing.T) {
+	tmpDir := t.TempDir()
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	keyPath := filepath.Join(tmpDir, "ca.key")
+	if err := GenerateCA(certPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	ca, err := LoadCA(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("LoadCA failed: %v", err)
+	}
+
+	cert, err := NewCertCache(ca, "test").GetCertificate(&tls.ClientHelloInfo{ServerName: "_paw.test"})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if err := cert.Leaf.VerifyHostname("_paw.test"); err != nil {
+		t.Errorf("dashboard cert does not cover _paw.test: %v", err)
+	}
+}
+
 func TestCertCacheRejectsEmptySNI(t *testing.T) {
 	tmpDir := t.TempDir()
 	certPath := filepath.Join(tmpDir, "ca.crt")
@@ -277,3 +299,141 @@ func TestPerHostnameCerts(t *testing.T) {
 		t.Errorf("expected app1.test in DNSNames, got %v", cert1.Leaf.DNSNames)
 	}
 }
+
+func TestCertCacheRejectsInvalidSNI(t *testing.T) {
+	tmpDir := t.TempDir()
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	keyPath := filepath.Join(tmpDir, "ca.key")
+
+	if err := GenerateCA(certPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	ca, err := LoadCA(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("LoadCA failed: %v", err)
+	}
+	cache := NewCertCache(ca, "test")
+
+	names := []string{
+		strings.Repeat("a", 10*1024) + ".test",
+		strings.Repeat("a", 64) + ".test",
+		"my app.test",
+		"myapp..test",
+		"\x00.test",
+	}
+	for _, name := range names {
+		if _, err := cache.GetCertificate(&tls.ClientHelloInfo{ServerName: name}); err == nil {
+			t.Errorf("expected error for SNI %.20q", name)
+		}
+	}
+	if len(cache.cache) != 0 {
+		t.Errorf("expected no cache entries for invalid SNI, got %d", len(cache.cache))
+	}
+}
+
+func TestCertCacheNormalizesSNICase(t *testing.T) {
+	tmpDir := t.TempDir()
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	keyPath := filepath.Join(tmpDir, "ca.key")
+
+	if err := GenerateCA(certPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	ca, err := LoadCA(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("LoadCA failed: %v", err)
+	}
+	cache := NewCertCache(ca, "test")
+
+	lower, err := cache.GetCertificate(&tls.ClientHelloInfo{ServerName: "myapp.test"})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	upper, err := cache.GetCertificate(&tls.ClientHelloInfo{ServerName: "MyApp.TEST"})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if lower != upper {
+		t.Error("expected case variants of a name to share one cached cert")
+	}
+}
+
+func TestCertCacheInvalidate(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := GenerateCA(filepath.Join(tmpDir, "a.crt"), filepath.Join(tmpDir, "a.key")); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	if err := GenerateCA(filepath.Join(tmpDir, "b.crt"), filepath.Join(tmpDir, "b.key")); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	caA, err := LoadCA(filepath.Join(tmpDir, "a.crt"), filepath.Join(tmpDir, "a.key"))
+	if err != nil {
+		t.Fatalf("LoadCA failed: %v", err)
+	}
+	caB, err := LoadCA(filepath.Join(tmpDir, "b.crt"), filepath.Join(tmpDir, "b.key"))
+	if err != nil {
+		t.Fatalf("LoadCA failed: %v", err)
+	}
+	cache := NewCertCache(caA, "test")
+	hello := &tls.ClientHelloInfo{ServerName: "myapp.test"}
+
+	first, err := cache.GetCertificate(hello)
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if n := cache.Invalidate(nil); n != 1 {
+		t.Errorf("Invalidate dropped %d certificates, want 1", n)
+	}
+	second, err := cache.GetCertificate(hello)
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if first == second {
+		t.Error("expected a new certificate after Invalidate")
+	}
+
+	// Replacing the CA re-issues from the new one.
+	cache.Invalidate(caB)
+	if cache.CA() != caB.Leaf {
+		t.Error("CA() should return the replacement CA")
+	}
+	third, err := cache.GetCertificate(hello)
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if err := third.Leaf.CheckSignatureFrom(caB.Leaf); err != nil {
+		t.Errorf("certificate not signed by the new CA: %v", err)
+	}
+}
+
+func TestCertCache_Cached(t *testing.T) {
+	tmpDir := t.TempDir()
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	keyPath := filepath.Join(tmpDir, "ca.key")
+	if err := GenerateCA(certPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	ca, err := LoadCA(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("LoadCA failed: %v", err)
+	}
+
+	cache := NewCertCache(ca, "test")
+	for _, name := range []string{"web.test", "api.test"} {
+		if _, err := cache.GetCertificate(&tls.ClientHelloInfo{ServerName: name}); err != nil {
+			t.Fatalf("GetCertificate(%s) failed: %v", name, err)
+		}
+	}
+
+	cached := cache.Cached()
+	if len(cached) != 2 || cached[0].Names[0] != "web.test" || cached[1].Names[0] != "api.test" {
+		t.Fatalf("Cached() = %+v, want web.test then api.test", cached)
+	}
+	if cached[0].NotAfter.IsZero() {
+		t.Error("Cached() has no expiry")
+	}
+	cache.Invalidate(nil)
+	if got := cache.Cached(); len(got) != 0 {
+		t.Errorf("Cached() after Invalidate = %+v", got)
+	}
+}
@@ -0,0 +1,101 @@
+// internal/ssl/issuelog.go
+package ssl
+
+import (
+	"bufio"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sources of issued certificates.
+const (
+	SourceLocalCA = "local-ca"
+	SourceACME    = "acme"
+)
+
+// IssuedCert is one certificate the daemon minted or obtained, as
+// recorded in the issue log.
+type IssuedCert struct {
+	Time      time.Time `json:"time"`
+	Source    string    `json:"source"`
+	Names     []string  `json:"names"`
+	Serial    string    `json:"serial"`
+	Issuer    string    `json:"issuer"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+}
+
+// IssueLog is an append-only ndjson record of issued certificates, so
+// users can audit what the local CA has signed. Its methods are safe on
+// a nil log, which records nothing.
+type IssueLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewIssueLog returns a log appending to path.
+func NewIssueLog(path string) *IssueLog {
+	return &IssueLog{path: path}
+}
+
+// Record appends leaf to the log.
+func (l *IssueLog) Record(leaf *x509.Certificate, source string) error {
+	if l == nil {
+		return nil
+	}
+	data, err := json.Marshal(IssuedCert{
+		Time:      time.Now().UTC(),
+		Source:    source,
+		Names:     leaf.DNSNames,
+		Serial:    strings.ToUpper(leaf.SerialNumber.Text(16)),
+		Issuer:    leaf.Issuer.CommonName,
+		NotBefore: leaf.NotBefore.UTC(),
+		NotAfter:  leaf.NotAfter.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// SECURITY: Owner-only, like the daemon log.
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadIssueLog returns the certificates recorded at path, oldest first.
+// A missing log has no entries; lines that don't parse are skipped.
+func ReadIssueLog(path string) ([]IssuedCert, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var certs []IssuedCert
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var c IssuedCert
+		if json.Unmarshal(scanner.Bytes(), &c) == nil {
+			certs = append(certs, c)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return certs, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return certs, nil
+}
@@ -0,0 +1,81 @@
+// internal/ssl/issuelog_test.go
+package ssl
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestIssueLog_RecordsGeneratedCerts(t *testing.T) {
+	tmpDir := t.TempDir()
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	keyPath := filepath.Join(tmpDir, "ca.key")
+	if err := GenerateCA(certPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	ca, err := LoadCA(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("LoadCA failed: %v", err)
+	}
+
+	logPath := filepath.Join(tmpDir, "issued-certs.ndjson")
+	cache := NewCertCache(ca, "test")
+	cache.SetIssueLog(NewIssueLog(logPath))
+
+	first, err := cache.GetCertificate(&tls.ClientHelloInfo{ServerName: "myapp.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A cache hit is not a new certificate.
+	if _, err := cache.GetCertificate(&tls.ClientHelloInfo{ServerName: "myapp.test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetCertificate(&tls.ClientHelloInfo{ServerName: "api.test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	certs, err := ReadIssueLog(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 2 {
+		t.Fatalf("got %d log entries, want 2: %+v", len(certs), certs)
+	}
+	c := certs[0]
+	if c.Source != SourceLocalCA || !slices.Equal(c.Names, []string{"myapp.test"}) || c.Issuer != "paw-proxy CA" {
+		t.Errorf("entry = %+v", c)
+	}
+	if want := first.Leaf.SerialNumber.Text(16); !strings.EqualFold(c.Serial, want) {
+		t.Errorf("serial = %s, want %s", c.Serial, want)
+	}
+	if !c.NotAfter.Equal(first.Leaf.NotAfter) {
+		t.Errorf("notAfter = %v, want %v", c.NotAfter, first.Leaf.NotAfter)
+	}
+	if info, _ := os.Stat(logPath); info.Mode().Perm() != 0600 {
+		t.Errorf("log mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestReadIssueLog(t *testing.T) {
+	dir := t.TempDir()
+	if certs, err := ReadIssueLog(filepath.Join(dir, "missing")); err != nil || certs != nil {
+		t.Errorf("ReadIssueLog(missing) = %v, %v", certs, err)
+	}
+	path := filepath.Join(dir, "log")
+	os.WriteFile(path, []byte(`{"source":"acme","names":["*.dev.example.com"],"serial":"AB"}`+"\nnot json\n"), 0600)
+	certs, err := ReadIssueLog(path)
+	if err != nil || len(certs) != 1 || certs[0].Serial != "AB" {
+		t.Errorf("ReadIssueLog = %+v, %v; want the one valid entry", certs, err)
+	}
+}
+
+func TestIssueLog_Nil(t *testing.T) {
+	var l *IssueLog
+	if err := l.Record(nil, SourceLocalCA); err != nil {
+		t.Errorf("nil log Record = %v", err)
+	}
+}
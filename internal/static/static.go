@@ -0,0 +1,313 @@
+// internal/static/static.go
+package static
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// cspIndex is the Content-Security-Policy for directory listings, which
+// use only inline styles and no scripts.
+const cspIndex = "default-src 'none'; style-src 'unsafe-inline'"
+
+// contentTypes covers web file types missing from Go's built-in table, so
+// they don't depend on the machine's mime.types.
+var contentTypes = map[string]string{
+	".csv":         "text/csv; charset=utf-8",
+	".ico":         "image/x-icon",
+	".map":         "application/json",
+	".md":          "text/markdown; charset=utf-8",
+	".mp3":         "audio/mpeg",
+	".mp4":         "video/mp4",
+	".otf":         "font/otf",
+	".ttf":         "font/ttf",
+	".txt":         "text/plain; charset=utf-8",
+	".webm":        "video/webm",
+	".webmanifest": "application/manifest+json",
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+}
+
+// Handler serves the files under Root. It answers GET and HEAD with
+// correct content types, range requests, and ETag and If-Modified-Since
+// revalidation. Paths can't escape Root, not even through symlinks.
+type Handler struct {
+	Root string
+	// AutoIndex lists a directory that has no index.html instead of
+	// answering 404.
+	AutoIndex bool
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	root, err := os.OpenRoot(h.Root)
+	if err != nil {
+		http.Error(w, "static directory unavailable", http.StatusBadGateway)
+		return
+	}
+	defer root.Close()
+
+	urlPath := path.Clean("/" + r.URL.Path)
+	name := strings.TrimPrefix(urlPath, "/")
+	if name == "" {
+		name = "."
+	}
+	f, err := root.Open(name)
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+
+	// Directories end in a slash and files don't, so relative links in
+	// pages resolve the way they will in production.
+	if info.IsDir() && urlPath != "/" && !strings.HasSuffix(r.URL.Path, "/") {
+		redirect(w, r, urlPath+"/")
+		return
+	}
+	if !info.IsDir() && strings.HasSuffix(r.URL.Path, "/") {
+		redirect(w, r, urlPath)
+		return
+	}
+
+	if info.IsDir() {
+		index, err := root.Open(path.Join(name, "index.html"))
+		if err == nil {
+			defer index.Close()
+			if indexInfo, err := index.Stat(); err == nil && !indexInfo.IsDir() {
+				serveFile(w, r, index, indexInfo)
+				return
+			}
+		}
+		if !h.AutoIndex {
+			http.Error(w, "404 page not found", http.StatusNotFound)
+			return
+		}
+		serveIndex(w, r, root, name, urlPath)
+		return
+	}
+	serveFile(w, r, f, info)
+}
+
+// serveFile answers with the content of f. http.ServeContent handles
+// ranges and conditional requests once the ETag is set.
+func serveFile(w http.ResponseWriter, r *http.Request, f *os.File, info fs.FileInfo) {
+	h := w.Header()
+	if ct, ok := contentTypes[strings.ToLower(path.Ext(info.Name()))]; ok {
+		h.Set("Content-Type", ct)
+	} else if ct := mime.TypeByExtension(path.Ext(info.Name())); ct != "" {
+		h.Set("Content-Type", ct)
+	}
+	h.Set("ETag", etag(info))
+	// Revalidate every time: the files change as you work, and the ETag
+	// keeps an unchanged file to a 304.
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// etag identifies a version of a file by its modification time and size.
+func etag(info fs.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
+
+func serveError(w http.ResponseWriter, err error) {
+	if errors.Is(err, fs.ErrPermission) {
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+		return
+	}
+	// Missing files and paths that escape the root alike.
+	http.Error(w, "404 page not found", http.StatusNotFound)
+}
+
+func redirect(w http.ResponseWriter, r *http.Request, target string) {
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+}
+
+// entry is one row of a directory listing.
+type entry struct {
+	Name    string
+	Href    string
+	Dir     bool
+	Size    int64
+	ModTime time.Time
+}
+
+// SizeText is the entry's size for people, e.g. "4.2 KB".
+func (e entry) SizeText() string {
+	if e.Dir {
+		return "—"
+	}
+	const unit = 1000
+	if e.Size < unit {
+		return fmt.Sprintf("%d B", e.Size)
+	}
+	div, exp := int64(unit), 0
+	for n := e.Size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(e.Size)/float64(div), "KMGTPE"[exp])
+}
+
+// listing is the data for indexTemplate.
+type listing struct {
+	Path    string
+	Parent  bool
+	Entries []entry
+	Sort    string
+	Desc    bool
+}
+
+// SortHref links to the listing sorted by column, toggling the order when
+// it is already sorted by it.
+func (l listing) SortHref(column string) string {
+	order := "asc"
+	if column == l.Sort && !l.Desc {
+		order = "desc"
+	}
+	return "?sort=" + column + "&order=" + order
+}
+
+// Arrow marks the column the listing is sorted by.
+func (l listing) Arrow(column string) string {
+	switch {
+	case column != l.Sort:
+		return ""
+	case l.Desc:
+		return " ↓"
+	default:
+		return " ↑"
+	}
+}
+
+// serveIndex lists the directory name, sorted by the sort (name, size, or
+// modified) and order (asc or desc) query parameters. Dotfiles are left
+// out, so a listing reachable from other devices doesn't advertise .env
+// or .git.
+func serveIndex(w http.ResponseWriter, r *http.Request, root *os.Root, name, urlPath string) {
+	dir, err := root.Open(name)
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+	defer dir.Close()
+	dirEntries, err := dir.ReadDir(-1)
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+
+	l := listing{Path: urlPath, Parent: urlPath != "/", Sort: r.URL.Query().Get("sort"), Desc: r.URL.Query().Get("order") == "desc"}
+	if l.Sort != "size" && l.Sort != "modified" {
+		l.Sort = "name"
+	}
+	for _, de := range dirEntries {
+		if strings.HasPrefix(de.Name(), ".") {
+			continue
+		}
+		// Stat through the root so symlinks show their target, and links
+		// pointing outside the root are left out. The ./ keeps a name
+		// with a colon from reading as a URL scheme.
+		info, err := root.Stat(path.Join(name, de.Name()))
+		if err != nil {
+			continue
+		}
+		e := entry{Name: de.Name(), Href: "./" + url.PathEscape(de.Name()), Dir: info.IsDir(), Size: info.Size(), ModTime: info.ModTime()}
+		if e.Dir {
+			e.Name += "/"
+			e.Href += "/"
+		}
+		l.Entries = append(l.Entries, e)
+	}
+	sortEntries(l.Entries, l.Sort, l.Desc)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", cspIndex)
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Method == http.MethodHead {
+		return
+	}
+	indexTemplate.Execute(w, l)
+}
+
+// sortEntries orders entries by column, directories first either way.
+func sortEntries(entries []entry, column string, desc bool) {
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		if a.Dir != b.Dir {
+			if a.Dir {
+				return -1
+			}
+			return 1
+		}
+		var c int
+		switch column {
+		case "size":
+			c = cmp.Compare(a.Size, b.Size)
+		case "modified":
+			c = a.ModTime.Compare(b.ModTime)
+		}
+		if c == 0 {
+			c = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+		if desc {
+			c = -c
+		}
+		return c
+	})
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en"><head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Index of {{.Path}}</title>
+<style>
+body { font-family: -apple-system, system-ui, sans-serif; max-width: 900px; margin: 40px auto; padding: 0 20px; color: #333; }
+table { width: 100%; border-collapse: collapse; }
+th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #eee; }
+th a { color: inherit; text-decoration: none; }
+td.num, th.num { text-align: right; white-space: nowrap; }
+a { color: #3498db; }
+</style>
+</head><body>
+<main>
+<h1>Index of {{.Path}}</h1>
+<table>
+<thead><tr>
+<th scope="col"><a href="{{.SortHref "name"}}">Name{{.Arrow "name"}}</a></th>
+<th scope="col" class="num"><a href="{{.SortHref "size"}}">Size{{.Arrow "size"}}</a></th>
+<th scope="col" class="num"><a href="{{.SortHref "modified"}}">Modified{{.Arrow "modified"}}</a></th>
+</tr></thead>
+<tbody>
+{{if .Parent}}<tr><td><a href="../">../</a></td><td class="num"></td><td class="num"></td></tr>
+{{end}}{{range .Entries}}<tr><td><a href="{{.Href}}">{{.Name}}</a></td><td class="num">{{.SizeText}}</td><td class="num">{{.ModTime.Format "2006-01-02 15:04"}}</td></tr>
+{{else}}<tr><td colspan="3">Empty directory</td></tr>
+{{end}}</tbody>
+</table>
+</main>
+</body></html>
+`))
@@ -0,0 +1,163 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRoot builds a site with an index page, a font, a subdirectory with
+// no index, a dotfile, and a symlink out of the root.
+func newRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"index.html":        "<h1>home</h1>",
+		"font.woff2":        "wOF2",
+		"docs/big.txt":      strings.Repeat("x", 2500),
+		"docs/a.txt":        "a",
+		"docs/.env":         "SECRET=1",
+		"docs/sub/keep.txt": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	os.WriteFile(outside, []byte("secret"), 0o644)
+	if err := os.Symlink(outside, filepath.Join(root, "docs", "escape.txt")); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func serve(h Handler, method, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_Files(t *testing.T) {
+	h := Handler{Root: newRoot(t)}
+
+	w := serve(h, "GET", "/", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "<h1>home</h1>" {
+		t.Fatalf("GET / = %d %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("index Content-Type = %q", ct)
+	}
+
+	w = serve(h, "GET", "/font.woff2", nil)
+	if ct := w.Header().Get("Content-Type"); ct != "font/woff2" {
+		t.Errorf("font Content-Type = %q, want font/woff2", ct)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+	if w := serve(h, "GET", "/font.woff2", http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match = %d, want 304", w.Code)
+	}
+	since := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if w := serve(h, "GET", "/font.woff2", http.Header{"If-Modified-Since": {since}}); w.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since = %d, want 304", w.Code)
+	}
+
+	w = serve(h, "GET", "/docs/big.txt", http.Header{"Range": {"bytes=100-199"}})
+	if w.Code != http.StatusPartialContent || w.Body.Len() != 100 {
+		t.Errorf("Range = %d with %d bytes, want 206 with 100", w.Code, w.Body.Len())
+	}
+	if cr := w.Header().Get("Content-Range"); cr != "bytes 100-199/2500" {
+		t.Errorf("Content-Range = %q", cr)
+	}
+
+	for target, want := range map[string]int{
+		"/missing":           http.StatusNotFound,
+		"/docs/escape.txt":   http.StatusNotFound,
+		"/../../etc/passwd":  http.StatusNotFound,
+		"/docs/":             http.StatusNotFound, // no index, no auto-index
+		"/docs/.env":         http.StatusOK,
+		"/docs/a.txt?x=1":    http.StatusOK,
+		"/docs?sort=size":    http.StatusMovedPermanently,
+		"/docs/a.txt/":       http.StatusMovedPermanently,
+		"/docs/sub/keep.txt": http.StatusOK,
+	} {
+		if w := serve(h, "GET", target, nil); w.Code != want {
+			t.Errorf("GET %s = %d, want %d", target, w.Code, want)
+		}
+	}
+	if loc := serve(h, "GET", "/docs?sort=size", nil).Header().Get("Location"); loc != "/docs/?sort=size" {
+		t.Errorf("directory redirect to %q", loc)
+	}
+	if loc := serve(h, "GET", "/docs/a.txt/", nil).Header().Get("Location"); loc != "/docs/a.txt" {
+		t.Errorf("file redirect to %q", loc)
+	}
+	if w := serve(h, "POST", "/", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want 405", w.Code)
+	}
+	if w := serve(Handler{Root: "/nonexistent/dir"}, "GET", "/", nil); w.Code != http.StatusBadGateway {
+		t.Errorf("missing root = %d, want 502", w.Code)
+	}
+}
+
+func TestHandler_AutoIndex(t *testing.T) {
+	h := Handler{Root: newRoot(t), AutoIndex: true}
+
+	w := serve(h, "GET", "/docs/", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /docs/ = %d", w.Code)
+	}
+	body := w.Body.String()
+	if csp := w.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "default-src 'none'") {
+		t.Errorf("CSP = %q", csp)
+	}
+	for _, want := range []string{`href="./sub/"`, `href="./a.txt"`, "2.5 KB", `href="../"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("listing missing %s", want)
+		}
+	}
+	for _, hidden := range []string{".env", "escape.txt"} {
+		if strings.Contains(body, hidden) {
+			t.Errorf("listing shows %s", hidden)
+		}
+	}
+	// Directories first, then by name.
+	if strings.Index(body, "sub/") > strings.Index(body, "a.txt") || strings.Index(body, "a.txt") > strings.Index(body, "big.txt") {
+		t.Error("default listing not sorted directories first, then by name")
+	}
+
+	body = serve(h, "GET", "/docs/?sort=size&order=desc", nil).Body.String()
+	if strings.Index(body, "big.txt") > strings.Index(body, "a.txt") {
+		t.Error("listing by size descending puts a.txt first")
+	}
+	if !strings.Contains(body, `href="?sort=size&amp;order=asc"`) {
+		t.Error("sorted column doesn't link to the other order")
+	}
+
+	// An index.html still wins over the listing.
+	if w := serve(h, "GET", "/", nil); w.Body.String() != "<h1>home</h1>" {
+		t.Errorf("GET / = %q, want the index page", w.Body.String())
+	}
+}
+
+func TestEntrySizeText(t *testing.T) {
+	for size, want := range map[int64]string{0: "0 B", 999: "999 B", 1500: "1.5 KB", 2_500_000: "2.5 MB"} {
+		if got := (entry{Size: size}).SizeText(); got != want {
+			t.Errorf("SizeText(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
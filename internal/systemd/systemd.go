@@ -0,0 +1,125 @@
+// Package systemd implements the receiving side of systemd socket
+// activation (sd_listen_fds), the Linux counterpart of internal/launchd.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd
+// (SD_LISTEN_FDS_START).
+const listenFDsStart = 3
+
+// maxListenFDs bounds LISTEN_FDS so a hostile environment can't make the
+// daemon walk an arbitrary fd range.
+const maxListenFDs = 16
+
+var (
+	parseOnce sync.Once
+	named     map[string][]int // socket name -> fds
+	parseErr  error
+	takenMu   sync.Mutex
+	taken     = make(map[string]bool)
+)
+
+// parseListenFDs maps each socket name passed by systemd to its file
+// descriptors, one per ListenStream of the unit. It returns nil when the process was not socket-activated:
+// LISTEN_PID is unset or belongs to another process (the variables were
+// inherited from a parent).
+func parseListenFDs(getenv func(string) string, pid int) (map[string][]int, error) {
+	pidStr := getenv("LISTEN_PID")
+	if pidStr == "" {
+		return nil, nil
+	}
+	if listenPID, err := strconv.Atoi(pidStr); err != nil || listenPID != pid {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || count < 0 || count > maxListenFDs {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", getenv("LISTEN_FDS"))
+	}
+
+	var names []string
+	if v := getenv("LISTEN_FDNAMES"); v != "" {
+		names = strings.Split(v, ":")
+	}
+	fds := make(map[string][]int, count)
+	for i := range count {
+		// Unnamed sockets are called "unknown" by systemd.
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		fds[name] = append(fds[name], listenFDsStart+i)
+	}
+	return fds, nil
+}
+
+// ActivateSocket returns the listeners systemd passed under name (the
+// socket unit's FileDescriptorName), one per address the unit listens on.
+// Returns (listeners, true, nil) on success.
+// Returns (nil, false, nil) when not socket-activated or name was not passed.
+// Returns (nil, false, err) on unexpected errors.
+func ActivateSocket(name string) ([]net.Listener, bool, error) {
+	parseOnce.Do(func() {
+		named, parseErr = parseListenFDs(os.Getenv, os.Getpid())
+		// Keep child processes from believing the sockets are theirs.
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	})
+	if parseErr != nil {
+		return nil, false, parseErr
+	}
+	fds, ok := named[name]
+	if !ok {
+		return nil, false, nil
+	}
+
+	takenMu.Lock()
+	defer takenMu.Unlock()
+	if taken[name] {
+		return nil, false, fmt.Errorf("systemd socket %q already activated", name)
+	}
+	taken[name] = true
+
+	var listeners []net.Listener
+	for _, fd := range fds {
+		listener, err := fileListener(name, fd)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, false, err
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, true, nil
+}
+
+// fileListener wraps the socket systemd passed as fd.
+func fileListener(name string, fd int) (net.Listener, error) {
+	// net.FileListener dups the fd, so close the original os.File to avoid
+	// leaking it. FileListener also rejects non-stream sockets.
+	f := os.NewFile(uintptr(fd), name)
+	listener, err := net.FileListener(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("systemd socket %q (fd %d): %w", name, fd, err)
+	}
+
+	// SECURITY: Only accept TCP listeners with a real bound address, as
+	// with launchd sockets.
+	tcpAddr, ok := listener.Addr().(*net.TCPAddr)
+	if !ok || tcpAddr.Port == 0 {
+		listener.Close()
+		return nil, fmt.Errorf("systemd socket %q has invalid address %s", name, listener.Addr())
+	}
+	return listener, nil
+}
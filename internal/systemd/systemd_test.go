@@ -0,0 +1,70 @@
+package systemd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseListenFDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    map[string][]int
+		wantErr bool
+	}{
+		{
+			name: "not activated",
+			env:  map[string]string{},
+		},
+		{
+			name: "inherited from another process",
+			env:  map[string]string{"LISTEN_PID": "1", "LISTEN_FDS": "2", "LISTEN_FDNAMES": "http:https"},
+		},
+		{
+			name: "named sockets",
+			env:  map[string]string{"LISTEN_PID": "100", "LISTEN_FDS": "2", "LISTEN_FDNAMES": "http:https"},
+			want: map[string][]int{"http": {3}, "https": {4}},
+		},
+		{
+			name: "IPv4 and IPv6 sockets per name",
+			env:  map[string]string{"LISTEN_PID": "100", "LISTEN_FDS": "4", "LISTEN_FDNAMES": "http:http:https:https"},
+			want: map[string][]int{"http": {3, 4}, "https": {5, 6}},
+		},
+		{
+			name: "unnamed socket",
+			env:  map[string]string{"LISTEN_PID": "100", "LISTEN_FDS": "1"},
+			want: map[string][]int{"unknown": {3}},
+		},
+		{
+			name:    "bad count",
+			env:     map[string]string{"LISTEN_PID": "100", "LISTEN_FDS": "many"},
+			wantErr: true,
+		},
+		{
+			name:    "too many fds",
+			env:     map[string]string{"LISTEN_PID": "100", "LISTEN_FDS": "1000"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseListenFDs(func(k string) string { return tt.env[k] }, 100)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) || (len(tt.want) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("parseListenFDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActivateSocket_FallbackWhenNotActivated(t *testing.T) {
+	listeners, activated, err := ActivateSocket("http")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if activated || listeners != nil {
+		t.Fatal("expected fallback when not socket-activated")
+	}
+}
@@ -0,0 +1,108 @@
+package trust
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// JavaAlias is the alias the CA is stored under in Java keystores.
+const JavaAlias = "paw-proxy"
+
+// javaStorePass is the JDK's well-known default cacerts password.
+const javaStorePass = "changeit"
+
+// JavaHome returns the JDK to update: JAVA_HOME, else macOS's
+// java_home, else the directory above the java on PATH.
+func JavaHome() (string, error) {
+	if home := os.Getenv("JAVA_HOME"); home != "" {
+		return home, nil
+	}
+	if runtime.GOOS == "darwin" {
+		if out, err := exec.Command("/usr/libexec/java_home").Output(); err == nil {
+			return strings.TrimSpace(string(out)), nil
+		}
+	}
+	java, err := exec.LookPath("java")
+	if err != nil {
+		return "", fmt.Errorf("java not found: set JAVA_HOME")
+	}
+	java, err = filepath.EvalSymlinks(java)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(filepath.Dir(java)), nil
+}
+
+// Cacerts returns the default truststore of the JDK at home, for both the
+// JDK 9+ and JDK 8 (jre/) layouts.
+func Cacerts(home string) (string, error) {
+	for _, rel := range []string{"lib/security/cacerts", "jre/lib/security/cacerts"} {
+		path := filepath.Join(home, rel)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no cacerts found in %s", home)
+}
+
+// keytool returns the keytool of the JDK at home, falling back to PATH.
+func keytool(home string) string {
+	path := filepath.Join(home, "bin", "keytool")
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	return "keytool"
+}
+
+// ImportJava adds the CA at caPath to keystore, replacing an earlier
+// paw-proxy entry.
+func ImportJava(home, keystore, caPath string) error {
+	RemoveJava(home, keystore) //nolint:errcheck // absent is fine
+	out, err := exec.Command(keytool(home), "-importcert", "-noprompt", "-trustcacerts",
+		"-alias", JavaAlias, "-file", caPath, "-keystore", keystore, "-storepass", javaStorePass).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("keytool: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// RemoveJava deletes the paw-proxy entry from keystore.
+func RemoveJava(home, keystore string) error {
+	out, err := exec.Command(keytool(home), "-delete", "-alias", JavaAlias,
+		"-keystore", keystore, "-storepass", javaStorePass).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("keytool: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// WriteJavaTrustStore copies the JDK's cacerts to dest and adds the CA,
+// for use with JavaFlags when the JDK's own cacerts can't be changed.
+func WriteJavaTrustStore(home, caPath, dest string) error {
+	cacerts, err := Cacerts(home)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(cacerts)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", cacerts, err)
+	}
+	if err := os.WriteFile(dest, data, 0644); err != nil {
+		return err
+	}
+	return ImportJava(home, dest, caPath)
+}
+
+// JavaFlags returns the JVM options that select the truststore at path.
+// A path with spaces (macOS's Application Support) is quoted, which both
+// shells and JAVA_TOOL_OPTIONS understand.
+func JavaFlags(path string) string {
+	if strings.ContainsRune(path, ' ') {
+		path = `"` + path + `"`
+	}
+	return fmt.Sprintf("-Djavax.net.ssl.trustStore=%s -Djavax.net.ssl.trustStorePassword=%s", path, javaStorePass)
+}
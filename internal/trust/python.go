@@ -0,0 +1,74 @@
+package trust
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// systemBundles are the locations of the system CA bundle, in the order
+// tried.
+var systemBundles = []string{
+	"/etc/ssl/certs/ca-certificates.crt", // Debian/Ubuntu/Arch
+	"/etc/pki/tls/certs/ca-bundle.crt",   // Fedora/RHEL
+	"/etc/ssl/cert.pem",                  // macOS, Alpine
+}
+
+// certifiWhere returns the bundle of the certifi package, which requests
+// uses by default instead of the system store.
+var certifiWhere = func() (string, error) {
+	for _, python := range []string{"python3", "python"} {
+		out, err := exec.Command(python, "-c", "import certifi; print(certifi.where())").Output()
+		if err == nil {
+			if path := strings.TrimSpace(string(out)); path != "" {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("certifi not found")
+}
+
+// PythonBase returns the bundle the Python bundle is built from: certifi's
+// if installed, otherwise the system bundle.
+func PythonBase() (string, error) {
+	if path, err := certifiWhere(); err == nil {
+		return path, nil
+	}
+	if path, err := SystemBundle(); err == nil {
+		return path, nil
+	}
+	return "", fmt.Errorf("no CA bundle found: install certifi (pip install certifi)")
+}
+
+// SystemBundle returns the operating system's CA bundle file.
+func SystemBundle() (string, error) {
+	for _, path := range systemBundles {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no system CA bundle found")
+}
+
+// WriteBundle writes base followed by the CA at caPath to dest, so clients
+// using it trust both public sites and paw-proxy routes.
+func WriteBundle(base, caPath, dest string) error {
+	baseData, err := os.ReadFile(base)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", base, err)
+	}
+	ca, err := os.ReadFile(caPath)
+	if err != nil {
+		return fmt.Errorf("reading CA: %w", err)
+	}
+	var buf bytes.Buffer
+	buf.Write(baseData)
+	if len(baseData) > 0 && !bytes.HasSuffix(baseData, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("\n# paw-proxy CA\n")
+	buf.Write(ca)
+	return os.WriteFile(dest, buf.Bytes(), 0644)
+}
@@ -0,0 +1,104 @@
+// Package trust makes language runtimes that ignore the system trust
+// store (the JVM, Python's requests/certifi) trust the paw-proxy CA, and
+// builds the environment `up` gives dev servers.
+package trust
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// PythonBundleFile is the CA bundle `trust python` writes in the support
+// directory: the certifi (or system) bundle followed by the paw-proxy CA.
+const PythonBundleFile = "python-ca-bundle.pem"
+
+// JavaTrustStoreFile is the truststore `trust java --print` writes in the
+// support directory: a copy of the JDK's cacerts plus the paw-proxy CA.
+const JavaTrustStoreFile = "java-cacerts"
+
+// CABundleFile is the combined bundle `up` keeps in the support directory
+// for clients whose CA variable replaces their trust store rather than
+// adding to it: the system bundle followed by the paw-proxy CA.
+const CABundleFile = "ca-bundle.pem"
+
+// PythonBundlePath returns where the Python bundle is kept.
+func PythonBundlePath(supportDir string) string {
+	return filepath.Join(supportDir, PythonBundleFile)
+}
+
+// JavaTrustStorePath returns where the Java truststore copy is kept.
+func JavaTrustStorePath(supportDir string) string {
+	return filepath.Join(supportDir, JavaTrustStoreFile)
+}
+
+// ChildEnv returns trust variables for a dev server started by `up`.
+// DENO_CERT, which adds to Deno's roots, gets the CA itself. SSL_CERT_FILE,
+// REQUESTS_CA_BUNDLE, CURL_CA_BUNDLE, and GIT_SSL_CAINFO replace the
+// client's roots, so they get the combined bundle, which ChildEnv writes
+// or refreshes as needed; the Python variables prefer the certifi bundle
+// from `trust python`. Variables already set in getenv are left alone, and
+// the replacing ones are skipped if no system bundle is found.
+func ChildEnv(supportDir, caPath string, getenv func(string) string) []string {
+	vars := [][2]string{{"DENO_CERT", caPath}}
+
+	combined, err := EnsureCABundle(supportDir, caPath)
+	if err == nil {
+		python := combined
+		if _, err := os.Stat(PythonBundlePath(supportDir)); err == nil {
+			python = PythonBundlePath(supportDir)
+		}
+		vars = append(vars,
+			[2]string{"SSL_CERT_FILE", python},
+			[2]string{"REQUESTS_CA_BUNDLE", python},
+			[2]string{"CURL_CA_BUNDLE", combined},
+			[2]string{"GIT_SSL_CAINFO", combined},
+		)
+	}
+
+	var env []string
+	for _, v := range vars {
+		if getenv(v[0]) == "" {
+			env = append(env, v[0]+"="+v[1])
+		}
+	}
+	return env
+}
+
+// CABundlePath returns where the combined bundle is kept.
+func CABundlePath(supportDir string) string {
+	return filepath.Join(supportDir, CABundleFile)
+}
+
+// CABundleCurrent reports whether the combined bundle exists and is newer
+// than both the CA and the system bundle.
+func CABundleCurrent(supportDir, caPath string) bool {
+	base, err := SystemBundle()
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(CABundlePath(supportDir))
+	return err == nil && !olderThan(info, caPath) && !olderThan(info, base)
+}
+
+// EnsureCABundle returns the combined bundle, rewriting it when it is
+// missing or older than the CA or the system bundle.
+func EnsureCABundle(supportDir, caPath string) (string, error) {
+	dest := CABundlePath(supportDir)
+	if CABundleCurrent(supportDir, caPath) {
+		return dest, nil
+	}
+	base, err := SystemBundle()
+	if err != nil {
+		return "", err
+	}
+	if err := WriteBundle(base, caPath, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// olderThan reports whether info was modified before the file at path.
+func olderThan(info os.FileInfo, path string) bool {
+	src, err := os.Stat(path)
+	return err != nil || info.ModTime().Before(src.ModTime())
+}
@@ -0,0 +1,173 @@
+package trust
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSystemBundle points systemBundles at a bundle in a temp directory.
+func fakeSystemBundle(t *testing.T) string {
+	t.Helper()
+	orig := systemBundles
+	t.Cleanup(func() { systemBundles = orig })
+	path := filepath.Join(t.TempDir(), "ca-certificates.crt")
+	if err := os.WriteFile(path, []byte("system roots\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	systemBundles = []string{path}
+	return path
+}
+
+func TestChildEnv(t *testing.T) {
+	fakeSystemBundle(t)
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(ca, []byte("paw-proxy CA\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	none := func(string) string { return "" }
+
+	combined := filepath.Join(dir, CABundleFile)
+	env := ChildEnv(dir, ca, none)
+	want := []string{
+		"DENO_CERT=" + ca,
+		"SSL_CERT_FILE=" + combined,
+		"REQUESTS_CA_BUNDLE=" + combined,
+		"CURL_CA_BUNDLE=" + combined,
+		"GIT_SSL_CAINFO=" + combined,
+	}
+	if strings.Join(env, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ChildEnv = %v, want %v", env, want)
+	}
+	data, _ := os.ReadFile(combined)
+	if !strings.Contains(string(data), "system roots") || !strings.Contains(string(data), "paw-proxy CA") {
+		t.Errorf("combined bundle = %q", data)
+	}
+
+	// The certifi bundle from `trust python` is preferred for Python.
+	bundle := PythonBundlePath(dir)
+	if err := os.WriteFile(bundle, []byte("pem"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env = ChildEnv(dir, ca, none)
+	if env[1] != "SSL_CERT_FILE="+bundle || env[2] != "REQUESTS_CA_BUNDLE="+bundle || env[3] != "CURL_CA_BUNDLE="+combined {
+		t.Errorf("ChildEnv with python bundle = %v", env)
+	}
+
+	// A bundle the user chose wins.
+	userSet := func(name string) string {
+		if name == "SSL_CERT_FILE" {
+			return "/etc/mine.pem"
+		}
+		return ""
+	}
+	for _, kv := range ChildEnv(dir, ca, userSet) {
+		if strings.HasPrefix(kv, "SSL_CERT_FILE=") {
+			t.Errorf("ChildEnv overrode SSL_CERT_FILE: %v", kv)
+		}
+	}
+}
+
+func TestChildEnv_NoSystemBundle(t *testing.T) {
+	orig := systemBundles
+	defer func() { systemBundles = orig }()
+	systemBundles = nil
+
+	env := ChildEnv(t.TempDir(), "/ca.crt", func(string) string { return "" })
+	if len(env) != 1 || env[0] != "DENO_CERT=/ca.crt" {
+		t.Errorf("ChildEnv without a system bundle = %v, want only DENO_CERT", env)
+	}
+}
+
+func TestEnsureCABundle_RefreshesForNewCA(t *testing.T) {
+	fakeSystemBundle(t)
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.crt")
+	os.WriteFile(ca, []byte("old CA\n"), 0644)
+	path, err := EnsureCABundle(dir, ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Regenerating the CA makes the bundle stale.
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(path, past, past)
+	os.WriteFile(ca, []byte("new CA\n"), 0644)
+	if CABundleCurrent(dir, ca) {
+		t.Fatal("CABundleCurrent after the CA changed, want stale")
+	}
+	if _, err := EnsureCABundle(dir, ca); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "new CA") {
+		t.Errorf("bundle not refreshed: %q", data)
+	}
+	if !CABundleCurrent(dir, ca) {
+		t.Error("CABundleCurrent after refresh = false")
+	}
+}
+
+func TestWriteBundle(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "cacert.pem")
+	ca := filepath.Join(dir, "ca.crt")
+	os.WriteFile(base, []byte("-----BEGIN CERTIFICATE-----\nroot\n-----END CERTIFICATE-----"), 0644)
+	os.WriteFile(ca, []byte("-----BEGIN CERTIFICATE-----\npaw\n-----END CERTIFICATE-----\n"), 0644)
+
+	dest := filepath.Join(dir, PythonBundleFile)
+	if err := WriteBundle(base, ca, dest); err != nil {
+		t.Fatalf("WriteBundle: %v", err)
+	}
+	data, _ := os.ReadFile(dest)
+	got := string(data)
+	if !strings.HasPrefix(got, "-----BEGIN CERTIFICATE-----\nroot\n-----END CERTIFICATE-----\n") {
+		t.Errorf("bundle does not start with the base bundle:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "# paw-proxy CA\n-----BEGIN CERTIFICATE-----\npaw\n-----END CERTIFICATE-----\n") {
+		t.Errorf("bundle does not end with the CA:\n%s", got)
+	}
+}
+
+func TestPythonBase_PrefersCertifi(t *testing.T) {
+	orig := certifiWhere
+	defer func() { certifiWhere = orig }()
+
+	certifiWhere = func() (string, error) { return "/venv/certifi/cacert.pem", nil }
+	if got, err := PythonBase(); err != nil || got != "/venv/certifi/cacert.pem" {
+		t.Errorf("PythonBase = %q, %v", got, err)
+	}
+
+	certifiWhere = func() (string, error) { return "", errors.New("no certifi") }
+	got, err := PythonBase()
+	if err == nil && !strings.HasPrefix(got, "/etc/") {
+		t.Errorf("PythonBase without certifi = %q, want a system bundle", got)
+	}
+}
+
+func TestCacerts(t *testing.T) {
+	for _, rel := range []string{"lib/security/cacerts", "jre/lib/security/cacerts"} {
+		home := t.TempDir()
+		path := filepath.Join(home, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, nil, 0644)
+		if got, err := Cacerts(home); err != nil || got != path {
+			t.Errorf("Cacerts(%s) = %q, %v", rel, got, err)
+		}
+	}
+	if _, err := Cacerts(t.TempDir()); err == nil {
+		t.Error("Cacerts of an empty directory should fail")
+	}
+}
+
+func TestJavaFlags(t *testing.T) {
+	got := JavaFlags("/Users/dev/Library/Application Support/paw-proxy/java-cacerts")
+	want := `-Djavax.net.ssl.trustStore="/Users/dev/Library/Application Support/paw-proxy/java-cacerts" -Djavax.net.ssl.trustStorePassword=changeit`
+	if got != want {
+		t.Errorf("JavaFlags = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,227 @@
+// Package update downloads paw-proxy releases from GitHub and installs them
+// in place of the running binaries.
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LatestReleaseURL is the GitHub API endpoint for the newest release.
+const LatestReleaseURL = "https://api.github.com/repos/alexcatdad/paw-proxy/releases/latest"
+
+// checksumsAsset is the release asset listing SHA-256 sums of every binary.
+const checksumsAsset = "checksums.txt"
+
+// maxAssetSize bounds downloads so a bad redirect can't fill the disk.
+const maxAssetSize = 100 << 20
+
+// Release is the subset of a GitHub release used for updating.
+type Release struct {
+	TagName string  `json:"tag_name"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a downloadable file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Client fetches releases and their assets.
+type Client struct {
+	HTTP *http.Client
+	// LatestURL overrides LatestReleaseURL (for tests).
+	LatestURL string
+}
+
+// Latest returns the newest published release.
+func (c *Client) Latest(ctx context.Context) (*Release, error) {
+	url := c.LatestURL
+	if url == "" {
+		url = LatestReleaseURL
+	}
+	body, err := c.get(ctx, url, 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("fetching latest release: %w", err)
+	}
+	var rel Release
+	if err := json.Unmarshal(body, &rel); err != nil {
+		return nil, fmt.Errorf("parsing latest release: %w", err)
+	}
+	if rel.TagName == "" {
+		return nil, fmt.Errorf("parsing latest release: missing tag")
+	}
+	return &rel, nil
+}
+
+// Download fetches the named asset from rel and verifies it against the
+// release's checksums.txt. Releases are not signed, so the checksum guards
+// against corrupt or truncated downloads; TLS to GitHub provides
+// authenticity.
+func (c *Client) Download(ctx context.Context, rel *Release, name string) ([]byte, error) {
+	asset, ok := rel.asset(name)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no asset %s", rel.TagName, name)
+	}
+	sums, ok := rel.asset(checksumsAsset)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s", rel.TagName, checksumsAsset)
+	}
+
+	sumData, err := c.get(ctx, sums.URL, 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", checksumsAsset, err)
+	}
+	want, ok := parseChecksums(sumData)[name]
+	if !ok {
+		return nil, fmt.Errorf("%s has no entry for %s", checksumsAsset, name)
+	}
+
+	data, err := c.get(ctx, asset.URL, maxAssetSize)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", name, err)
+	}
+	got := sha256.Sum256(data)
+	if hex.EncodeToString(got[:]) != want {
+		return nil, fmt.Errorf("checksum mismatch for %s", name)
+	}
+	return data, nil
+}
+
+func (c *Client) get(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("response larger than %d bytes", limit)
+	}
+	return data, nil
+}
+
+func (rel *Release) asset(name string) (Asset, bool) {
+	for _, a := range rel.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// parseChecksums parses `shasum -a 256` output into name -> hex digest.
+func parseChecksums(data []byte) map[string]string {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// shasum marks binary mode with a leading '*'.
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return sums
+}
+
+// AssetName returns the release asset name of binary for a platform,
+// e.g. "paw-proxy-linux-amd64".
+func AssetName(binary, goos, goarch string) string {
+	return fmt.Sprintf("%s-%s-%s", binary, goos, goarch)
+}
+
+// Newer reports whether release version latest is newer than current.
+// Both are "vMAJOR.MINOR.PATCH"; anything unparseable (such as a "dev"
+// build) is never considered older.
+func Newer(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+func parseVersion(v string) ([3]int, bool) {
+	var out [3]int
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) != 3 {
+		return out, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return out, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
+
+// Replace atomically swaps the file at path for data. The new binary is
+// written beside the old one and renamed over it, so a running process
+// keeps its original image and a failed update leaves the old binary in
+// place.
+func Replace(path string, data []byte) error {
+	dir, base := filepath.Split(path)
+	tmp, err := os.CreateTemp(dir, "."+base+".update-*")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", tmpPath, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("syncing %s: %w", tmpPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing %s: %w", tmpPath, err)
+	}
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		return fmt.Errorf("chmod %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("replacing %s: %w", path, err)
+	}
+	return nil
+}
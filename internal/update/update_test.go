@@ -0,0 +1,134 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeReleases serves a latest release with the given binary and a
+// checksums.txt listing sum for it.
+func fakeReleases(t *testing.T, binary []byte, sum string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Release{
+			TagName: "v1.2.3",
+			Assets: []Asset{
+				{Name: "paw-proxy-linux-amd64", URL: srv.URL + "/bin"},
+				{Name: "checksums.txt", URL: srv.URL + "/sums"},
+			},
+		})
+	})
+	mux.HandleFunc("/bin", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary)
+	})
+	mux.HandleFunc("/sums", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  paw-proxy-linux-amd64\nffff  up-linux-amd64\n", sum)
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientDownload(t *testing.T) {
+	binary := []byte("new binary")
+	digest := sha256.Sum256(binary)
+	good := hex.EncodeToString(digest[:])
+
+	tests := []struct {
+		name    string
+		sum     string
+		asset   string
+		wantErr string
+	}{
+		{"verified", good, "paw-proxy-linux-amd64", ""},
+		{"checksum mismatch", strings.Repeat("0", 64), "paw-proxy-linux-amd64", "checksum mismatch"},
+		{"missing asset", good, "paw-proxy-plan9-386", "no asset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fakeReleases(t, binary, tt.sum)
+			c := &Client{HTTP: srv.Client(), LatestURL: srv.URL + "/latest"}
+			rel, err := c.Latest(context.Background())
+			if err != nil {
+				t.Fatalf("Latest: %v", err)
+			}
+			if rel.TagName != "v1.2.3" {
+				t.Errorf("TagName = %q, want v1.2.3", rel.TagName)
+			}
+
+			data, err := c.Download(context.Background(), rel, tt.asset)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Download error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Download: %v", err)
+			}
+			if string(data) != string(binary) {
+				t.Errorf("Download = %q, want %q", data, binary)
+			}
+		})
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v1.2.3", "v1.2.2", true},
+		{"v1.10.0", "v1.9.9", true},
+		{"v2.0.0", "v1.99.99", true},
+		{"v1.2.3", "v1.2.3", false},
+		{"v1.2.3", "v1.3.0", false},
+		{"v1.2.3", "dev", false},
+		{"latest", "v1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.latest, tt.current); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	sums := parseChecksums([]byte("ABCD  paw-proxy-darwin-arm64\nef01 *up-darwin-arm64\ngarbage\n"))
+	if sums["paw-proxy-darwin-arm64"] != "abcd" || sums["up-darwin-arm64"] != "ef01" || len(sums) != 2 {
+		t.Errorf("parseChecksums = %v", sums)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "paw-proxy")
+	os.WriteFile(path, []byte("old"), 0755)
+
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("contents = %q, want new", data)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("mode = %v, want 0755", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected no leftover temp files, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,557 @@
+// Package client talks to the paw-proxy daemon's control API, over its
+// unix socket or over the token-protected loopback TCP listener enabled
+// with `paw-proxy setup --api-addr`.
+//
+//	c := client.New(socketPath)
+//	err := c.Register(ctx, client.RegisterRequest{
+//		Name:     "myapp",
+//		Upstream: "localhost:3000",
+//		Dir:      dir,
+//	})
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+// DefaultTimeout bounds each request made by clients from New and NewTCP.
+const DefaultTimeout = 5 * time.Second
+
+// Types shared with the daemon.
+type (
+	// RegisterRequest describes a route to register.
+	RegisterRequest = api.RegisterRequest
+	// Route is a registered route as returned by List.
+	Route = api.Route
+	// RouteAuth holds basic auth or bearer token credentials for a route.
+	RouteAuth = api.RouteAuth
+	// CORSConfig enables the daemon's CORS helper for a route.
+	CORSConfig = api.CORSConfig
+	// PoolConfig tunes the daemon's keep-alive pool to a route's upstream.
+	PoolConfig = api.PoolConfig
+	// BalanceConfig spreads a route's requests over more instances of its
+	// dev server.
+	BalanceConfig = api.BalanceConfig
+	// AlertConfig sets the request and error rates past which the daemon
+	// warns about a route.
+	AlertConfig = api.AlertConfig
+	// ThrottleConfig slows a route's traffic down to simulate a slow
+	// network.
+	ThrottleConfig = api.ThrottleConfig
+	// StaticConfig serves a directory from the daemon instead of an
+	// upstream.
+	StaticConfig = api.StaticConfig
+	// RewriteConfig rewrites a route's request paths and redirects.
+	RewriteConfig = api.RewriteConfig
+	// CookieConfig adjusts cookies set by a route's upstream.
+	CookieConfig = api.CookieConfig
+	// SPAConfig serves a single-page app's index page for deep links.
+	SPAConfig = api.SPAConfig
+	// CanonicalConfig redirects requests to a route's canonical URL.
+	CanonicalConfig = api.CanonicalConfig
+	// MaintenanceConfig schedules maintenance windows for a route.
+	MaintenanceConfig = api.MaintenanceConfig
+	// SweepResult counts the entries removed by GC.
+	SweepResult = api.SweepResult
+	// RouteEvent is a registry change delivered by Events.
+	RouteEvent = api.RouteEvent
+	// PeerCred is the local process that registered a route (Route.Owner).
+	PeerCred = api.PeerCred
+	// RouteChurn is a route's recent registrations and missed heartbeats.
+	RouteChurn = api.RouteChurn
+	// RouteStats counts the requests a route has served.
+	RouteStats = api.RouteStats
+	// Capture is one proxied request delivered by Capture.
+	Capture = api.Capture
+	// KeepAliveStatus names the routes a KeepAlive line did not refresh.
+	KeepAliveStatus = api.KeepAliveStatus
+)
+
+// Balance modes, the values of BalanceConfig.Mode.
+const (
+	BalanceRoundRobin = api.BalanceRoundRobin
+	BalanceFallback   = api.BalanceFallback
+)
+
+// Route event types, the values of RouteEvent.Type.
+const (
+	EventAdded       = api.EventAdded
+	EventUpdated     = api.EventUpdated
+	EventRemoved     = api.EventRemoved
+	EventExpired     = api.EventExpired
+	EventIdleExpired = api.EventIdleExpired
+)
+
+// Health is the daemon's health report.
+type Health struct {
+	// Status is "ok" or "degraded".
+	Status  string `json:"status"`
+	Version string `json:"version"`
+	Uptime  string `json:"uptime"`
+	// Problems lists what degrades the daemon, e.g. a port conflict.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Degraded reports whether the daemon runs with problems.
+func (h Health) Degraded() bool {
+	return h.Status == "degraded"
+}
+
+// Client is a control API client. It is safe for concurrent use.
+//
+// The daemon returns a token for each route registered through it and
+// requires that token to heartbeat, update, or deregister the route. The
+// client remembers the tokens of routes it registered and sends them
+// automatically; RouteToken and SetRouteToken let a program keep them
+// across restarts.
+type Client struct {
+	hc *http.Client
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// New returns a client for the daemon listening on the unix socket at
+// socketPath.
+func New(socketPath string) *Client {
+	return NewWithHTTPClient(&http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+			},
+		},
+		Timeout: DefaultTimeout,
+	})
+}
+
+// NewTCP returns a client for the daemon's TCP control API at addr
+// (host:port), authenticating with token.
+func NewTCP(addr, token string) *Client {
+	return NewWithHTTPClient(&http.Client{
+		Transport: &tokenTransport{
+			token: token,
+			base: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+				},
+			},
+		},
+		Timeout: DefaultTimeout,
+	})
+}
+
+// NewWithHTTPClient returns a client that sends requests through hc. hc's
+// transport must deliver requests for http://unix/ to the daemon, whatever
+// the host; New and NewTCP build such clients.
+func NewWithHTTPClient(hc *http.Client) *Client {
+	return &Client{hc: hc, tokens: make(map[string]string)}
+}
+
+// RouteToken returns the token for the named route, or "" if the client
+// doesn't have one.
+func (c *Client) RouteToken(name string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens[name]
+}
+
+// SetRouteToken sets the token sent for the named route, e.g. one saved
+// by an earlier run. An empty token forgets it.
+func (c *Client) SetRouteToken(name, token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if token == "" {
+		delete(c.tokens, name)
+		return
+	}
+	c.tokens[name] = token
+}
+
+// tokenTransport adds the control API bearer token to each request.
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
+
+// do sends a request to path under the versioned API and decodes a JSON
+// response into out when out is non-nil; an empty body leaves out as is.
+// Non-2xx responses become errors.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	return c.doRoute(ctx, "", method, path, body, out)
+}
+
+// doRoute is do for a request about the named route, sending its token if
+// the client has one.
+func (c *Client) doRoute(ctx context.Context, route, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, "http://unix"+api.APIPrefix+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.RouteToken(route); route != "" && token != "" {
+		req.Header.Set(api.RouteTokenHeader, token)
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return &UnavailableError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return responseError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+		return fmt.Errorf("decoding %s response: %w", path, err)
+	}
+	return nil
+}
+
+// Register registers a route. A name already in use returns a
+// *ConflictError.
+func (c *Client) Register(ctx context.Context, req RegisterRequest) error {
+	var resp api.RegisterResponse
+	if err := c.do(ctx, http.MethodPost, "/routes", req, &resp); err != nil {
+		return err
+	}
+	c.SetRouteToken(req.Name, resp.Token)
+	return nil
+}
+
+// Upsert registers a route, or updates it in place if it is already
+// registered from the same directory, e.g. by an earlier run that crashed.
+// A route registered from another directory returns a *ConflictError, and
+// updating a route without its token returns ErrNotOwner.
+func (c *Client) Upsert(ctx context.Context, req RegisterRequest) error {
+	var resp api.RegisterResponse
+	if err := c.doRoute(ctx, req.Name, http.MethodPut, "/routes/"+url.PathEscape(req.Name), req, &resp); err != nil {
+		return err
+	}
+	c.SetRouteToken(req.Name, resp.Token)
+	return nil
+}
+
+// Deregister removes a route. Removing a route that doesn't exist is not
+// an error.
+func (c *Client) Deregister(ctx context.Context, name string) error {
+	err := c.doRoute(ctx, name, http.MethodDelete, "/routes/"+url.PathEscape(name), nil, nil)
+	if IsNotFound(err) {
+		err = nil
+	}
+	if err == nil {
+		c.SetRouteToken(name, "")
+	}
+	return err
+}
+
+// DeregisterProject removes every route registered with project and
+// returns their names. It needs no route tokens, but fails with
+// ErrNotOwner if another local user registered any of them.
+func (c *Client) DeregisterProject(ctx context.Context, project string) ([]string, error) {
+	var resp api.DeregisterProjectResponse
+	if err := c.do(ctx, http.MethodDelete, "/routes?project="+url.QueryEscape(project), nil, &resp); err != nil {
+		return nil, err
+	}
+	for _, name := range resp.Removed {
+		c.SetRouteToken(name, "")
+	}
+	return resp.Removed, nil
+}
+
+// Heartbeat keeps a route alive. It returns ErrNotFound when the daemon no
+// longer knows the route (e.g. after a restart), and ErrExpired when the
+// daemon removed it for inactivity and it should not be registered again.
+func (c *Client) Heartbeat(ctx context.Context, name string) error {
+	return c.doRoute(ctx, name, http.MethodPost, "/routes/"+url.PathEscape(name)+"/heartbeat", nil, nil)
+}
+
+// Pause makes the daemon serve its waiting page for a route instead of
+// proxying to the upstream, until the route is registered again. Call it
+// before stopping a dev server so browsers don't see connection errors
+// while it shuts down.
+func (c *Client) Pause(ctx context.Context, name string) error {
+	return c.doRoute(ctx, name, http.MethodPost, "/routes/"+url.PathEscape(name)+"/pause", nil, nil)
+}
+
+// SetThrottle slows a route's traffic down to simulate a slow network, or
+// with a nil throttle stops slowing it. Any process of the route owner's
+// user may call it.
+func (c *Client) SetThrottle(ctx context.Context, name string, throttle *ThrottleConfig) error {
+	return c.doRoute(ctx, name, http.MethodPut, "/routes/"+url.PathEscape(name)+"/throttle", throttle, nil)
+}
+
+// KeepAlive holds routes alive over one long-lived connection instead of
+// a Heartbeat request per route. At once and then every interval it sends
+// the names returned by routes, with their tokens, and passes the daemon's
+// answer to onStatus. If the connection drops, e.g. because this process
+// was killed, the daemon removes the routes at once instead of waiting
+// out the heartbeat timeout.
+//
+// KeepAlive returns nil when ctx is done, an *UnavailableError when the
+// daemon goes away, and ErrKeepAliveUnsupported from daemons that predate
+// it; callers then fall back to Heartbeat.
+func (c *Client) KeepAlive(ctx context.Context, interval time.Duration, routes func() []string, onStatus func(KeepAliveStatus)) error {
+	// The connection stays open indefinitely, so the per-request timeout
+	// can't apply to it. It still bounds the wait for the response
+	// headers, which the daemon sends right away.
+	hc := *c.hc
+	headerTimeout := hc.Timeout
+	if headerTimeout == 0 {
+		headerTimeout = DefaultTimeout
+	}
+	hc.Timeout = 0
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	timer := time.AfterFunc(headerTimeout, cancel)
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		enc := json.NewEncoder(pw)
+		for {
+			var line api.KeepAliveRequest
+			for _, name := range routes() {
+				line.Routes = append(line.Routes, api.KeepAliveRoute{Name: name, Token: c.RouteToken(name)})
+			}
+			if err := enc.Encode(line); err != nil {
+				return
+			}
+			select {
+			case <-reqCtx.Done():
+				pw.Close()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, "http://unix"+api.APIPrefix+"/heartbeat", pr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	// Without it, a daemon answering with an error before reading the
+	// body (e.g. one without this endpoint) waits to drain a body that
+	// never ends instead of replying.
+	req.Header.Set("Expect", "100-continue")
+	resp, err := hc.Do(req)
+	timer.Stop()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return &UnavailableError{Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return ErrKeepAliveUnsupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var status KeepAliveStatus
+		if err := dec.Decode(&status); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return &UnavailableError{Err: err}
+		}
+		onStatus(status)
+	}
+}
+
+// List returns the registered routes.
+func (c *Client) List(ctx context.Context) ([]Route, error) {
+	var routes []Route
+	if err := c.do(ctx, http.MethodGet, "/routes", nil, &routes); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// Health returns the daemon's health report.
+func (c *Client) Health(ctx context.Context) (Health, error) {
+	var h Health
+	err := c.do(ctx, http.MethodGet, "/health", nil, &h)
+	return h, err
+}
+
+// Stats returns request counters for the routes that have served any
+// requests since the daemon started, keyed by route name.
+func (c *Client) Stats(ctx context.Context) (map[string]RouteStats, error) {
+	var stats map[string]RouteStats
+	if err := c.do(ctx, http.MethodGet, "/stats", nil, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// WaitHealthy polls the daemon every interval until it answers a health
+// check or ctx is done. A degraded daemon counts as up. A rejected token
+// is returned at once, since waiting won't fix it.
+func (c *Client) WaitHealthy(ctx context.Context, interval time.Duration) (Health, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h, err := c.Health(ctx)
+		if err == nil || IsUnauthorized(err) {
+			return h, err
+		}
+		select {
+		case <-ctx.Done():
+			return h, fmt.Errorf("waiting for daemon: %w", err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reload makes the daemon re-read its config file.
+func (c *Client) Reload(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/reload", nil, nil)
+}
+
+// Restart asks the daemon to restart in place: it hands its sockets and
+// routes to a fresh copy of its binary, e.g. one just upgraded. It
+// returns once the restart has started; the new process answers Health
+// with a reset Uptime. Daemons that can't restart in place return an
+// *APIError with status 501, and ones that predate it 404.
+func (c *Client) Restart(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/restart", nil, nil)
+}
+
+// GC sweeps expired routes and stale preview records.
+func (c *Client) GC(ctx context.Context) (SweepResult, error) {
+	var result SweepResult
+	err := c.do(ctx, http.MethodPost, "/gc", nil, &result)
+	return result, err
+}
+
+// InvalidateCerts drops the daemon's cached certificates so each name is
+// issued a new one, and returns how many were dropped.
+func (c *Client) InvalidateCerts(ctx context.Context) (int, error) {
+	var body struct {
+		Invalidated int `json:"invalidated"`
+	}
+	err := c.do(ctx, http.MethodPost, "/certs/invalidate", nil, &body)
+	return body.Invalidated, err
+}
+
+// PairDashboard returns a one-time URL that signs a browser in to the
+// dashboard.
+func (c *Client) PairDashboard(ctx context.Context) (string, error) {
+	var body struct {
+		URL string `json:"url"`
+	}
+	err := c.do(ctx, http.MethodPost, "/dashboard/pair", nil, &body)
+	return body.URL, err
+}
+
+// Events calls fn for each route registry change until ctx is done, then
+// returns nil. If the daemon goes away (e.g. it restarts) Events returns
+// an *UnavailableError; callers that want a continuous feed reconnect.
+func (c *Client) Events(ctx context.Context, fn func(RouteEvent)) error {
+	return c.stream(ctx, "/events?format=ndjson", func(line []byte) error {
+		var ev RouteEvent
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return fmt.Errorf("decoding event: %w", err)
+		}
+		fn(ev)
+		return nil
+	})
+}
+
+// Capture calls fn for each request proxied for the route name, with up
+// to bodies bytes of each request and response body (0 for none), until
+// ctx is done. It fails with ErrNotFound if the route doesn't exist and
+// returns like Events when the daemon goes away.
+func (c *Client) Capture(ctx context.Context, name string, bodies int, fn func(Capture)) error {
+	path := fmt.Sprintf("/routes/%s/capture?bodies=%d", url.PathEscape(name), bodies)
+	return c.stream(ctx, path, func(line []byte) error {
+		var capture Capture
+		if err := json.Unmarshal(line, &capture); err != nil {
+			return fmt.Errorf("decoding capture: %w", err)
+		}
+		fn(capture)
+		return nil
+	})
+}
+
+// stream reads the ndjson stream at path, passing each line to fn, until
+// ctx is done (returning nil) or the stream ends.
+func (c *Client) stream(ctx context.Context, path string, fn func([]byte) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://unix"+api.APIPrefix+path, nil)
+	if err != nil {
+		return err
+	}
+	// The stream stays open indefinitely, so the per-request timeout
+	// can't apply to it.
+	hc := *c.hc
+	hc.Timeout = 0
+	resp, err := hc.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return &UnavailableError{Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	// Captures with bodies are far longer than the default line limit.
+	sc.Buffer(make([]byte, 64*1024), 4*api.MaxCaptureBody+64*1024)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue // keep-alive
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := sc.Err(); err != nil {
+		return &UnavailableError{Err: err}
+	}
+	return &UnavailableError{Err: io.EOF}
+}
@@ -0,0 +1,443 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexcatdad/paw-proxy/internal/api"
+)
+
+// testClient returns a client whose requests reach srv.
+func testClient(srv *httptest.Server) *Client {
+	addr := srv.Listener.Addr().String()
+	return NewWithHTTPClient(&http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+		},
+	}})
+}
+
+func TestRegister(t *testing.T) {
+	var got RegisterRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/routes" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"status":"registered"}`))
+	}))
+	defer srv.Close()
+
+	req := RegisterRequest{Name: "myapp", Upstream: "localhost:3000", Dir: "/src/myapp", Preview: "pr-1"}
+	if err := testClient(srv).Register(context.Background(), req); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if got.Name != "myapp" || got.Upstream != "localhost:3000" || got.Dir != "/src/myapp" || got.Preview != "pr-1" {
+		t.Errorf("daemon received %+v", got)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	var method, path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	req := RegisterRequest{Name: "pr-1.myapp", Upstream: "localhost:3000", Dir: "/src/myapp"}
+	if err := testClient(srv).Upsert(context.Background(), req); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if method != http.MethodPut || path != "/v1/routes/pr-1.myapp" {
+		t.Errorf("request = %s %s, want PUT /v1/routes/pr-1.myapp", method, path)
+	}
+}
+
+func TestRegister_Conflict(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"error":"route conflict","existingDir":"/src/other"}`))
+	}))
+	defer srv.Close()
+
+	err := testClient(srv).Register(context.Background(), RegisterRequest{Name: "myapp", Upstream: "localhost:3000"})
+	var ce *ConflictError
+	if !errors.As(err, &ce) {
+		t.Fatalf("Register() error = %v, want *ConflictError", err)
+	}
+	if ce.ExistingDir != "/src/other" {
+		t.Errorf("ExistingDir = %q, want /src/other", ce.ExistingDir)
+	}
+}
+
+func TestHeartbeat_Errors(t *testing.T) {
+	tests := []struct {
+		status       int
+		notFound     bool
+		expired      bool
+		unauthorized bool
+	}{
+		{status: http.StatusNotFound, notFound: true},
+		{status: http.StatusGone, expired: true},
+		{status: http.StatusUnauthorized, unauthorized: true},
+		{status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(`{"error":"nope"}`))
+		}))
+
+		err := testClient(srv).Heartbeat(context.Background(), "myapp")
+		srv.Close()
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status || apiErr.Message != "nope" {
+			t.Errorf("%d: error = %#v, want *APIError with message", tt.status, err)
+		}
+		if IsNotFound(err) != tt.notFound {
+			t.Errorf("%d: IsNotFound = %v", tt.status, !tt.notFound)
+		}
+		if errors.Is(err, ErrExpired) != tt.expired {
+			t.Errorf("%d: Is(ErrExpired) = %v", tt.status, !tt.expired)
+		}
+		if IsUnauthorized(err) != tt.unauthorized {
+			t.Errorf("%d: IsUnauthorized = %v", tt.status, !tt.unauthorized)
+		}
+	}
+}
+
+func TestDeregister_NotFoundIsOK(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path == "/v1/routes/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := testClient(srv)
+	if err := c.Deregister(context.Background(), "missing"); err != nil {
+		t.Errorf("Deregister(missing) error = %v, want nil", err)
+	}
+	if err := c.Deregister(context.Background(), "broken"); err == nil {
+		t.Error("Deregister(broken) error = nil, want error")
+	}
+}
+
+func TestDeregisterProject(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/v1/routes" || r.URL.Query().Get("project") != "shop" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"removed":["api.shop","web.shop"]}`))
+	}))
+	defer srv.Close()
+
+	c := testClient(srv)
+	c.SetRouteToken("web.shop", "tok")
+	removed, err := c.DeregisterProject(context.Background(), "shop")
+	if err != nil {
+		t.Fatalf("DeregisterProject() error = %v", err)
+	}
+	if len(removed) != 2 || removed[0] != "api.shop" || removed[1] != "web.shop" {
+		t.Errorf("removed = %v", removed)
+	}
+	if c.RouteToken("web.shop") != "" {
+		t.Error("token kept for a removed route")
+	}
+}
+
+func TestListAndHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/routes":
+			w.Write([]byte(`[{"name":"myapp","upstream":"localhost:3000","dir":"/src/myapp","preview":"pr-1"}]`))
+		case "/v1/health":
+			w.Write([]byte(`{"status":"degraded","version":"1.2.3","uptime":"1m","problems":["port 443 in use"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := testClient(srv)
+	routes, err := c.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(routes) != 1 || routes[0].Name != "myapp" || routes[0].Preview != "pr-1" {
+		t.Errorf("List() = %+v", routes)
+	}
+
+	h, err := c.Health(context.Background())
+	if err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if !h.Degraded() || h.Version != "1.2.3" || len(h.Problems) != 1 {
+		t.Errorf("Health() = %+v", h)
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "missing.sock"))
+	_, err := c.Health(context.Background())
+	if !IsUnavailable(err) {
+		t.Fatalf("Health() error = %v, want unavailable", err)
+	}
+}
+
+func TestWaitHealthy(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			// Simulate a daemon that is still starting up.
+			hj, _ := w.(http.Hijacker)
+			conn, _, _ := hj.Hijack()
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"status":"ok","version":"1.2.3","uptime":"1s"}`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	h, err := testClient(srv).WaitHealthy(ctx, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitHealthy() error = %v", err)
+	}
+	if h.Status != "ok" || calls.Load() < 3 {
+		t.Errorf("WaitHealthy() = %+v after %d calls", h, calls.Load())
+	}
+}
+
+func TestWaitHealthy_Unauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err := testClient(srv).WaitHealthy(ctx, 10*time.Millisecond)
+	if !IsUnauthorized(err) {
+		t.Fatalf("WaitHealthy() error = %v, want unauthorized", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("WaitHealthy() waited for the deadline despite a rejected token")
+	}
+}
+
+func TestWaitHealthy_Deadline(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "missing.sock"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.WaitHealthy(ctx, 10*time.Millisecond); !IsUnavailable(err) {
+		t.Fatalf("WaitHealthy() error = %v, want unavailable", err)
+	}
+}
+
+func TestNewTCP_SendsToken(t *testing.T) {
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	if _, err := NewTCP(srv.Listener.Addr().String(), "secret").Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want Bearer secret", gotAuth)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/events" || r.URL.Query().Get("format") != "ndjson" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"type":"added","route":"myapp","upstream":"localhost:3000"}` + "\n\n"))
+		w.Write([]byte(`{"type":"removed","route":"myapp"}` + "\n"))
+	}))
+	defer srv.Close()
+
+	var got []RouteEvent
+	err := testClient(srv).Events(context.Background(), func(ev RouteEvent) {
+		got = append(got, ev)
+	})
+	if !IsUnavailable(err) {
+		t.Errorf("Events() error = %v, want unavailable after the stream ends", err)
+	}
+	if len(got) != 2 || got[0].Type != EventAdded || got[0].Upstream != "localhost:3000" || got[1].Type != EventRemoved {
+		t.Errorf("events = %+v", got)
+	}
+}
+
+func TestEvents_CancelReturnsNil(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"type":"added","route":"myapp"}` + "\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := testClient(srv).Events(ctx, func(RouteEvent) { cancel() })
+	if err != nil {
+		t.Errorf("Events() error = %v, want nil after cancel", err)
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	lines := make(chan api.KeepAliveRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/heartbeat" {
+			http.NotFound(w, r)
+			return
+		}
+		rc := http.NewResponseController(w)
+		rc.EnableFullDuplex()
+		dec := json.NewDecoder(r.Body)
+		for {
+			var req api.KeepAliveRequest
+			if err := dec.Decode(&req); err != nil {
+				return
+			}
+			lines <- req
+			w.Write([]byte(`{"missing":["api"]}` + "\n"))
+			rc.Flush()
+		}
+	}))
+	defer srv.Close()
+
+	c := testClient(srv)
+	c.SetRouteToken("web", "web-token")
+	ctx, cancel := context.WithCancel(context.Background())
+	var statuses []KeepAliveStatus
+	err := c.KeepAlive(ctx, 10*time.Millisecond, func() []string { return []string{"web", "api"} }, func(s KeepAliveStatus) {
+		statuses = append(statuses, s)
+		if len(statuses) == 2 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Errorf("KeepAlive() error = %v, want nil after cancel", err)
+	}
+	if len(statuses) != 2 || !slices.Equal(statuses[0].Missing, []string{"api"}) {
+		t.Errorf("statuses = %+v", statuses)
+	}
+	first := <-lines
+	want := []api.KeepAliveRoute{{Name: "web", Token: "web-token"}, {Name: "api"}}
+	if !slices.Equal(first.Routes, want) {
+		t.Errorf("sent %+v, want %+v", first.Routes, want)
+	}
+}
+
+func TestKeepAlive_Unsupported(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	err := testClient(srv).KeepAlive(context.Background(), time.Second, func() []string { return nil }, func(KeepAliveStatus) {})
+	if !errors.Is(err, ErrKeepAliveUnsupported) {
+		t.Errorf("KeepAlive() error = %v, want ErrKeepAliveUnsupported", err)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/routes/missing/capture" && r.URL.Path != "/v1/routes/shop/capture" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/v1/routes/missing/capture" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"route not found"}`))
+			return
+		}
+		if r.URL.Query().Get("bodies") != "64" {
+			t.Errorf("bodies = %q, want 64", r.URL.Query().Get("bodies"))
+		}
+		w.Write([]byte(`{"route":"shop","method":"GET","url":"/","status":200,"responseBody":"aGk="}` + "\n"))
+	}))
+	defer srv.Close()
+
+	var got []Capture
+	err := testClient(srv).Capture(context.Background(), "shop", 64, func(c Capture) {
+		got = append(got, c)
+	})
+	if !IsUnavailable(err) {
+		t.Errorf("Capture() error = %v, want unavailable after the stream ends", err)
+	}
+	if len(got) != 1 || got[0].Status != 200 || string(got[0].ResponseBody) != "hi" {
+		t.Errorf("captures = %+v", got)
+	}
+
+	err = testClient(srv).Capture(context.Background(), "missing", 0, func(Capture) {})
+	if !IsNotFound(err) {
+		t.Errorf("Capture(missing) error = %v, want not found", err)
+	}
+}
+
+func TestRouteTokens(t *testing.T) {
+	var heartbeatToken, deleteToken string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			if r.URL.Path == "/v1/routes/myapp/heartbeat" {
+				heartbeatToken = r.Header.Get("X-Paw-Route-Token")
+				if heartbeatToken != "tok" {
+					w.WriteHeader(http.StatusForbidden)
+				}
+				return
+			}
+			w.Write([]byte(`{"token":"tok"}`))
+		case http.MethodDelete:
+			deleteToken = r.Header.Get("X-Paw-Route-Token")
+		}
+	}))
+	defer srv.Close()
+
+	c := testClient(srv)
+	ctx := context.Background()
+	if err := c.Register(ctx, RegisterRequest{Name: "myapp", Upstream: "localhost:3000", Dir: "/src/myapp"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.RouteToken("myapp"); got != "tok" {
+		t.Fatalf("RouteToken() = %q, want tok", got)
+	}
+	if err := c.Heartbeat(ctx, "myapp"); err != nil || heartbeatToken != "tok" {
+		t.Errorf("Heartbeat() = %v, sent token %q", err, heartbeatToken)
+	}
+	if err := c.Deregister(ctx, "myapp"); err != nil || deleteToken != "tok" {
+		t.Errorf("Deregister() = %v, sent token %q", err, deleteToken)
+	}
+	if got := c.RouteToken("myapp"); got != "" {
+		t.Errorf("RouteToken() after Deregister = %q, want empty", got)
+	}
+
+	c.SetRouteToken("myapp", "stale")
+	if err := c.Heartbeat(ctx, "myapp"); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("Heartbeat() with wrong token = %v, want ErrNotOwner", err)
+	}
+}
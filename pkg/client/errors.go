@@ -0,0 +1,109 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Sentinel errors matched by the APIError for the corresponding status.
+var (
+	// ErrNotFound means the route is not registered.
+	ErrNotFound = errors.New("route not found")
+	// ErrExpired means the daemon removed the route for inactivity.
+	ErrExpired = errors.New("route expired")
+	// ErrUnauthorized means the TCP control API rejected the token.
+	ErrUnauthorized = errors.New("control API token rejected")
+	// ErrNotOwner means the route was registered by another client: the
+	// route token was missing or wrong.
+	ErrNotOwner = errors.New("route owned by another client")
+)
+
+// ErrKeepAliveUnsupported is returned by KeepAlive when the daemon predates
+// it.
+var ErrKeepAliveUnsupported = errors.New("daemon does not support keep-alive connections")
+
+// APIError is a non-2xx response from the daemon. It matches ErrNotFound,
+// ErrExpired, ErrUnauthorized, and ErrNotOwner with errors.Is.
+type APIError struct {
+	StatusCode int
+	// Message is the daemon's error text, if it sent one.
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("paw-proxy: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("paw-proxy: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrExpired:
+		return e.StatusCode == http.StatusGone
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotOwner:
+		return e.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// ConflictError is returned by Register when the name is already
+// registered, typically by another project.
+type ConflictError struct {
+	// ExistingDir is the directory the route was registered from.
+	ExistingDir string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("route conflict: already registered from %s", e.ExistingDir)
+}
+
+// UnavailableError means the daemon could not be reached, usually because
+// it is not running.
+type UnavailableError struct {
+	Err error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("paw-proxy daemon unreachable: %v", e.Err)
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// IsNotFound reports whether err means the route is not registered.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsUnauthorized reports whether err means the control API token was
+// rejected.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsUnavailable reports whether err means the daemon could not be reached.
+func IsUnavailable(err error) bool {
+	var u *UnavailableError
+	return errors.As(err, &u)
+}
+
+// responseError turns a non-2xx response into an error.
+func responseError(resp *http.Response) error {
+	var body struct {
+		Error       string `json:"error"`
+		ExistingDir string `json:"existingDir"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body) //nolint:errcheck // best-effort detail
+	if resp.StatusCode == http.StatusConflict {
+		return &ConflictError{ExistingDir: body.ExistingDir}
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: body.Error}
+}